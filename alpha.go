@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"heictojpeg/convert"
+)
+
+var alphaPolicy = flag.String("alpha", "flatten:#ffffff", "what to do with transparency: flatten:#rrggbb flattens it onto that background, png writes the images that have it as PNG to keep it, drop ignores it")
+
+func alphaMode() string {
+	mode, _, _ := convert.ParseAlpha(*alphaPolicy)
+	return mode
+}
+
+// hasAlpha reports whether the HEIC input has transparency.
+func hasAlpha(input string) bool {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return convert.HasAlpha(f)
+}
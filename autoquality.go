@@ -0,0 +1,8 @@
+package main
+
+import "flag"
+
+var (
+	autoQuality = flag.Bool("auto-quality", false, "pick the lowest JPEG quality per image whose SSIM against the decoded HEIC stays at or above -target-ssim")
+	targetSSIM  = flag.Float64("target-ssim", 0.985, "similarity -auto-quality keeps, from 0 to 1 (1 is identical)")
+)
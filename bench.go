@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+func init() {
+	subcommands["bench"] = benchCommand
+}
+
+type benchResult struct {
+	Workers    int
+	Quality    int
+	FilesPerS  float64
+	OutputSize int64 // per file
+}
+
+// benchWorkerCounts are the worker counts tried: powers of two up to the
+// CPU count, plus the CPU count itself.
+func benchWorkerCounts(cpus int) []int {
+	var counts []int
+	for n := 1; n < cpus; n *= 2 {
+		counts = append(counts, n)
+	}
+	return append(counts, cpus)
+}
+
+// benchRun converts sample the given number of times with workerCount workers,
+// in memory, and measures the throughput.
+func benchRun(sample string, workerCount, quality, files int) (benchResult, error) {
+	result := benchResult{Workers: workerCount, Quality: quality}
+	jobs := make(chan struct{}, files)
+	for i := 0; i < files; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var firstErr error
+	var size int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				out := &countingWriter{}
+				img, exif, err := decodeHeicFile(context.Background(), sample)
+				if err == nil {
+					err = encodeJPEGQuality(out, img, exif, quality)
+				}
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				size = out.n
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return result, firstErr
+	}
+	result.FilesPerS = float64(files) / time.Since(start).Seconds()
+	result.OutputSize = size
+	return result, nil
+}
+
+func benchCommand(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	qualityList := fs.String("qualities", "60,75,90", "comma-separated JPEG qualities to try")
+	save := fs.Bool("save", false, "store the fastest worker count as the default in the config file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg bench [-qualities 60,75,90] [-save] [sample.heic]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	qualities, err := parseQualities(*qualityList)
+	if err != nil {
+		return err
+	}
+
+	sample := fs.Arg(0)
+	if sample == "" {
+		if sample, err = findSample("."); err != nil {
+			return err
+		}
+	}
+	fmt.Printf(tr("Benchmarking with %s...\n"), sample)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, tr("workers\tquality\tfiles/s\tJPEG size\t"))
+	var best benchResult
+	for _, workerCount := range benchWorkerCounts(runtime.NumCPU()) {
+		for _, q := range qualities {
+			files := 2 * workerCount
+			if files < 4 {
+				files = 4
+			}
+			r, err := benchRun(sample, workerCount, q, files)
+			if err != nil {
+				return fmt.Errorf("%s: %v", sample, err)
+			}
+			fmt.Fprintf(w, "%d\t%d\t%.2f\t%s\t\n", r.Workers, r.Quality, r.FilesPerS, humanReadableFileSize(r.OutputSize))
+			w.Flush()
+			if r.FilesPerS > best.FilesPerS {
+				best = r
+			}
+		}
+	}
+	fmt.Printf(tr("Fastest: %d workers (%.2f files/s)\n"), best.Workers, best.FilesPerS)
+
+	if *save {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		config, err := readConfig(path)
+		if err != nil {
+			return err
+		}
+		config["workers"] = best.Workers
+		if err := writeConfig(path, config); err != nil {
+			return err
+		}
+		fmt.Printf(tr("Saved workers=%d to %s\n"), best.Workers, path)
+	}
+	return nil
+}
+
+// findSample returns the first HEIC file in dir.
+func findSample(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isHEIC(e.Name()) {
+			return e.Name(), nil
+		}
+	}
+	return "", errors.New("no .heic file in the current directory; pass a sample file")
+}
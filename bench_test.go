@@ -0,0 +1,14 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBenchWorkerCounts(t *testing.T) {
+	for cpus, want := range map[int][]int{1: {1}, 4: {1, 2, 4}, 6: {1, 2, 4, 6}} {
+		if got := benchWorkerCounts(cpus); !reflect.DeepEqual(got, want) {
+			t.Errorf("benchWorkerCounts(%d) = %v, want %v", cpus, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"heictojpeg/convert"
+)
+
+var (
+	bitDepth   = flag.String("bit-depth", "8", "bits per channel of the outputs: 8 writes JPEGs, 16 writes 16-bit PNG or TIFF files (see -deep-format), keep does so only for photos of more than 8 bits, such as 10-bit HDR ones")
+	deepFormat = flag.String("deep-format", "png", "format of the 16-bit files of -bit-depth: png or tiff")
+)
+
+var (
+	bitDepths   = map[string]bool{"keep": true, "8": true, "16": true}
+	deepFormats = map[string]bool{"png": true, "tiff": true}
+)
+
+// wantsDeepOutput reports whether input converts to a 16-bit file under
+// -bit-depth. Files whose depth can't be read convert as usual, and fail
+// there if they're broken.
+func wantsDeepOutput(input string) (bool, error) {
+	switch *bitDepth {
+	case "16":
+		return true, nil
+	case "keep":
+		f, err := os.Open(longPath(input))
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		depth, err := convert.SourceDepth(f)
+		return err == nil && depth > 8, nil
+	}
+	return false, nil
+}
+
+// deepFileName is the name of the 16-bit file written in place of the
+// JPEG jpegName.
+func deepFileName(jpegName string) string {
+	ext := ".png"
+	if *deepFormat == "tiff" {
+		ext = ".tif"
+	}
+	return strings.TrimSuffix(jpegName, filepath.Ext(jpegName)) + ext
+}
+
+func isTIFFOutput(output string) bool {
+	ext := strings.ToLower(filepath.Ext(output))
+	return ext == ".tif" || ext == ".tiff"
+}
+
+// isLosslessOutput reports whether output is a PNG or TIFF file rather
+// than a JPEG. They carry no EXIF, sidecar metadata or other frames.
+func isLosslessOutput(output string) bool {
+	return isPNGOutput(output) || isTIFFOutput(output)
+}
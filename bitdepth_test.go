@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestDeepFileName(t *testing.T) {
+	defer func(v string) { *deepFormat = v }(*deepFormat)
+	*deepFormat = "png"
+	if got := deepFileName("a/IMG_1.jpg"); got != "a/IMG_1.png" || !isLosslessOutput(got) {
+		t.Errorf("png: got %s", got)
+	}
+	*deepFormat = "tiff"
+	if got := deepFileName("a/IMG_1.jpg"); got != "a/IMG_1.tif" || !isLosslessOutput(got) {
+		t.Errorf("tiff: got %s", got)
+	}
+	if isLosslessOutput("IMG_1.jpg") {
+		t.Error("a JPEG is lossless")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	botUsers   = flag.String("bot-users", "", "comma-separated Telegram user names or IDs and Discord user IDs heictojpeg bot converts for; without it, it converts for anyone who finds the bot")
+	discordApp = flag.String("discord-app", "", "application ID of the Discord app heictojpeg bot answers, with -discord-key and HEICTOJPEG_DISCORD_TOKEN")
+	discordKey = flag.String("discord-key", "", "public key of -discord-app, to check that the interactions heictojpeg bot is sent come from Discord")
+)
+
+// botCommand is the verb in "heictojpeg bot [options]", which converts
+// the HEIC files sent to a Telegram bot or a Discord app.
+const botCommand = "bot"
+
+const (
+	telegramTokenEnv = "HEICTOJPEG_TELEGRAM_TOKEN"
+	discordTokenEnv  = "HEICTOJPEG_DISCORD_TOKEN"
+)
+
+var errNotAllowed = errors.New("not in -bot-users")
+
+// botConverter converts the files sent to the bots the way heictojpeg
+// serve converts uploads: at the global settings, through the cache, and
+// no more at once than -max-inflight.
+type botConverter struct {
+	allowed  map[string]bool // nil allows everyone
+	maxBytes int64
+	inflight chan struct{}
+}
+
+func newBotConverter() (*botConverter, error) {
+	c := &botConverter{}
+	if *botUsers != "" {
+		c.allowed = map[string]bool{}
+		for _, user := range strings.Split(*botUsers, ",") {
+			if user = strings.TrimPrefix(strings.TrimSpace(user), "@"); user != "" {
+				c.allowed[strings.ToLower(user)] = true
+			}
+		}
+	}
+	var err error
+	if c.maxBytes, err = parseByteSize(*maxBody); err != nil {
+		return nil, fmt.Errorf("-max-body %q: %v", *maxBody, err)
+	}
+	if *maxInflight > 0 {
+		c.inflight = make(chan struct{}, *maxInflight)
+	}
+	return c, nil
+}
+
+// allows reports whether the user known by any of ids may use the bot.
+func (c *botConverter) allows(ids ...string) bool {
+	if c.allowed == nil {
+		return true
+	}
+	for _, id := range ids {
+		if id != "" && c.allowed[strings.ToLower(id)] {
+			return true
+		}
+	}
+	return false
+}
+
+// convert converts the HEIC file read from body and returns the path of
+// the JPEG, which the caller removes. who names the sender for the log.
+func (c *botConverter) convert(ctx context.Context, who string, body io.Reader) (string, error) {
+	if c.inflight != nil {
+		select {
+		case c.inflight <- struct{}{}:
+			defer func() { <-c.inflight }()
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	limited := body
+	if c.maxBytes > 0 {
+		limited = io.LimitReader(body, c.maxBytes+1)
+	}
+	input, err := saveUpload(limited)
+	if input != "" {
+		defer os.Remove(input)
+	}
+	if err != nil {
+		return "", err
+	}
+	if c.maxBytes > 0 && getFileSize(input) > c.maxBytes {
+		return "", fmt.Errorf("the file is over %s", humanReadableFileSize(c.maxBytes))
+	}
+	output := input + ".jpg"
+	if _, err := convertCached(withSettings(ctx, globalSettings()), "bot upload from "+who, input, output); err != nil {
+		os.Remove(output)
+		return "", err
+	}
+	return output, nil
+}
+
+// isHEICUpload tells HEIC files apart by their name or media type.
+func isHEICUpload(name, mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	return isHEIC(name) || strings.HasSuffix(strings.ToLower(name), ".heif") || mediaType == "image/heic" || mediaType == "image/heif"
+}
+
+// jpegName is the name the JPEG of the file name is sent back as.
+func jpegName(name string) string {
+	name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "photo"
+	}
+	return name + ".jpg"
+}
+
+// botError leaves the URL out of an HTTP error: the bot token is in it.
+func botError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// runBot answers the bots that are set up, Telegram when its token is in
+// the environment and Discord with -discord-app, until ctx is cancelled or
+// one of them fails.
+func runBot(ctx context.Context) error {
+	conv, err := newBotConverter()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var bots []func(context.Context) error
+	if token := os.Getenv(telegramTokenEnv); token != "" {
+		bots = append(bots, newTelegramBot(telegramAPI, token, conv).run)
+	}
+	if *discordApp != "" || *discordKey != "" {
+		discord, err := newDiscordBot(discordAPI, *discordApp, *discordKey, os.Getenv(discordTokenEnv), conv)
+		if err != nil {
+			return err
+		}
+		bots = append(bots, discord.serve)
+	}
+	if len(bots) == 0 {
+		return fmt.Errorf("heictojpeg bot needs %s, or -discord-app and -discord-key with %s", telegramTokenEnv, discordTokenEnv)
+	}
+	if conv.allowed == nil {
+		fmt.Println(tr("Without -bot-users, the bot converts for anyone who finds it."))
+	}
+
+	errs := make(chan error, len(bots))
+	for _, run := range bots {
+		go func(run func(context.Context) error) { errs <- run(ctx) }(run)
+	}
+	var first error
+	for range bots {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"os"
+	"strings"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+// exifSample is a HEIC file the conversion accepts: a sample picture with
+// an (empty) EXIF block.
+func exifSample() []byte {
+	p := heicsample.NewPicture(heicsample.Size, heicsample.Size, 8, heicsample.Pattern)
+	exif := heicsample.Item{Type: "Exif", Data: append([]byte{0, 0, 0, 6}, "Exif\x00\x00II*\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00"...)}
+	return heicsample.File([]heicsample.Item{heicsample.PictureItem(p, false), exif})
+}
+
+func TestBotConverter(t *testing.T) {
+	defer func(users, body string) { *botUsers, *maxBody = users, body }(*botUsers, *maxBody)
+	*botUsers, *maxBody = "@Grandma, 12345", "1MB"
+	conv, err := newBotConverter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		ids  []string
+		want bool
+	}{{[]string{"1", "grandma"}, true}, {[]string{"12345", ""}, true}, {[]string{"2", "grandpa"}, false}, {[]string{""}, false}} {
+		if got := conv.allows(c.ids...); got != c.want {
+			t.Errorf("allows(%q) = %v", c.ids, got)
+		}
+	}
+
+	output, err := conv.convert(context.Background(), "test", bytes.NewReader(exifSample()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(output)
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if config, err := jpeg.DecodeConfig(f); err != nil || config.Width != heicsample.Size {
+		t.Errorf("JPEG %+v, %v", config, err)
+	}
+
+	if _, err := conv.convert(context.Background(), "test", strings.NewReader("not a HEIC")); err == nil {
+		t.Error("converted a file that isn't a HEIC")
+	}
+	conv.maxBytes = 10
+	if _, err := conv.convert(context.Background(), "test", bytes.NewReader(exifSample())); err == nil || !strings.Contains(err.Error(), "over") {
+		t.Errorf("a file over -max-body: %v", err)
+	}
+}
+
+func TestJPEGName(t *testing.T) {
+	for name, want := range map[string]string{"IMG_0001.HEIC": "IMG_0001.jpg", "photo of me.heic": "photo of me.jpg", "": "photo.jpg", "x/../IMG.heif": "IMG.jpg"} {
+		if got := jpegName(name); got != want {
+			t.Errorf("jpegName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
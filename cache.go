@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	cacheDir  = flag.String("cache", "", "with heictojpeg worker, keep converted JPEGs in this folder, keyed by the source's contents and the options, and answer repeated jobs from it")
+	cacheSize = flag.String("cache-size", "1GB", "how much -cache may hold; the least recently used JPEGs are removed beyond it")
+)
+
+// cacheLimitBytes is -cache-size once parsed.
+var cacheLimitBytes int64
+
+// outputCache keeps JPEGs under dir/ab/abcd....jpg, named after the hash
+// of what they were made from. A hit refreshes the file's modification
+// time, so the oldest time is the least recently used.
+type outputCache struct {
+	dir   string
+	limit int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// openCache creates dir if needed and totals what it already holds.
+func openCache(dir string, limit int64) (*outputCache, error) {
+	if err := os.MkdirAll(longPath(dir), 0755); err != nil {
+		return nil, err
+	}
+	c := &outputCache{dir: dir, limit: limit}
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.size += e.size
+	}
+	return c, nil
+}
+
+type cacheKey struct{}
+
+func withCache(ctx context.Context, c *outputCache) context.Context {
+	return context.WithValue(ctx, cacheKey{}, c)
+}
+
+func cacheFrom(ctx context.Context) *outputCache {
+	c, _ := ctx.Value(cacheKey{}).(*outputCache)
+	return c
+}
+
+// key names the JPEG of input converted with s: the hash of the file's
+// contents and of the options that change the output, see settings.key.
+func (c *outputCache) key(input string, s settings) (string, error) {
+	hash, err := hashFile(input)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(hash + "\n" + s.key()))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *outputCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".jpg")
+}
+
+// get copies the cached JPEG for key to output and reports whether there
+// was one.
+func (c *outputCache) get(key, output string) (bool, error) {
+	path := c.path(key)
+	if _, err := os.Stat(longPath(path)); err != nil {
+		return false, nil
+	}
+	if err := copyFile(path, output); err != nil {
+		return false, err
+	}
+	now := time.Now()
+	os.Chtimes(longPath(path), now, now)
+	return true, nil
+}
+
+// put stores a copy of output under key, then removes the least recently
+// used JPEGs until the cache fits its limit again.
+func (c *outputCache) put(key, output string) error {
+	path := c.path(key)
+	if err := os.MkdirAll(longPath(filepath.Dir(path)), 0755); err != nil {
+		return err
+	}
+	// Copied under a temporary name of its own, so a concurrent get never
+	// sees half a JPEG and concurrent puts of the same key don't mix.
+	f, err := os.CreateTemp(longPath(filepath.Dir(path)), key+"-*.part")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := copyFile(output, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, longPath(path)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size += getFileSize(path)
+	if c.size <= c.limit {
+		return nil
+	}
+	return c.evict()
+}
+
+// convertCached converts input to output with the settings of ctx, going
+// through -cache when there is one, and reports whether the JPEG came from
+// it. source names the file in messages.
+func convertCached(ctx context.Context, source, input, output string) (bool, error) {
+	c := cacheFrom(ctx)
+	if c == nil {
+		return false, convertHeicToJpg(ctx, input, output)
+	}
+	key, err := c.key(input, settingsFrom(ctx))
+	if err != nil {
+		return false, err
+	}
+	hit, err := c.get(key, output)
+	if err != nil || hit {
+		return hit, err
+	}
+	if err := convertHeicToJpg(ctx, input, output); err != nil {
+		return false, err
+	}
+	if err := c.put(key, output); err != nil {
+		fmt.Printf(tr("Failed to cache %s: %v\n"), source, err)
+	}
+	return false, nil
+}
+
+type cacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *outputCache) entries() ([]cacheEntry, error) {
+	var entries []cacheEntry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jpg") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, cacheEntry{path, info.Size(), info.ModTime()})
+		return nil
+	})
+	return entries, err
+}
+
+// evict removes the oldest JPEGs until the cache is back within its
+// limit. The size is recounted from the files, which also corrects it
+// for the entries that were overwritten.
+func (c *outputCache) evict() error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+	c.size = 0
+	for _, e := range entries {
+		c.size += e.size
+	}
+	for _, e := range entries {
+		if c.size <= c.limit {
+			break
+		}
+		if err := os.Remove(longPath(e.path)); err == nil {
+			c.size -= e.size
+		}
+	}
+	return nil
+}
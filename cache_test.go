@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Testing that the cache key follows the contents and the settings
+func TestCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	c, err := openCache(filepath.Join(dir, "cache"), 1<<20)
+	if err != nil {
+		t.Fatalf("openCache failed: %v", err)
+	}
+	a, b := filepath.Join(dir, "a.heic"), filepath.Join(dir, "b.heic")
+	os.WriteFile(a, []byte("same"), 0644)
+	os.WriteFile(b, []byte("same"), 0644)
+
+	s := globalSettings()
+	keyA, _ := c.key(a, s)
+	keyB, _ := c.key(b, s)
+	if keyA != keyB {
+		t.Error("Files with the same contents should share a key")
+	}
+	s.Quality++
+	if keyQ, _ := c.key(a, s); keyQ == keyA {
+		t.Error("A different quality should change the key")
+	}
+	s.Quality--
+	for name, option := range map[string]*string{"-color-target": colorTarget, "-bit-depth": bitDepth, "-alpha": alphaPolicy} {
+		before := *option
+		*option = "changed"
+		keyO, _ := c.key(a, s)
+		*option = before
+		if keyO == keyA {
+			t.Errorf("A different %s should change the key", name)
+		}
+	}
+}
+
+// Testing get, put and least-recently-used eviction
+func TestOutputCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := openCache(filepath.Join(dir, "cache"), 250)
+	if err != nil {
+		t.Fatalf("openCache failed: %v", err)
+	}
+	output := filepath.Join(dir, "out.jpg")
+	keys := []string{strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)}
+	old := time.Now().Add(-time.Hour)
+	for i, key := range keys[:2] {
+		os.WriteFile(output, []byte(strings.Repeat(key[:1], 100)), 0644)
+		if err := c.put(key, output); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+		stamp := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(c.path(key), stamp, stamp)
+	}
+
+	// Reading a makes b the least recently used.
+	if hit, err := c.get(keys[0], output); !hit || err != nil {
+		t.Fatalf("get = %v, %v", hit, err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != strings.Repeat("a", 100) {
+		t.Errorf("get copied %q", data)
+	}
+	os.WriteFile(output, []byte(strings.Repeat("c", 100)), 0644)
+	if err := c.put(keys[2], output); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	for key, want := range map[string]bool{keys[0]: true, keys[1]: false, keys[2]: true} {
+		if _, err := os.Stat(c.path(key)); (err == nil) != want {
+			t.Errorf("%s cached = %v, want %v", key[:1], err == nil, want)
+		}
+	}
+	if c.size != 200 {
+		t.Errorf("size = %d, want 200", c.size)
+	}
+	if hit, _ := c.get(keys[1], output); hit {
+		t.Error("An evicted JPEG should miss")
+	}
+}
+
+// Testing that concurrent puts of the same key each cache a whole JPEG
+func TestOutputCacheConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+	c, err := openCache(filepath.Join(dir, "cache"), 1<<30)
+	if err != nil {
+		t.Fatalf("openCache failed: %v", err)
+	}
+	key := strings.Repeat("d", 64)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		output := filepath.Join(dir, fmt.Sprintf("out%d.jpg", i))
+		os.WriteFile(output, bytes.Repeat([]byte{byte('a' + i)}, 1<<16), 0644)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.put(key, output); err != nil {
+				t.Errorf("put failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data) != 1<<16 || len(bytes.Trim(data, string(data[:1]))) != 0 {
+		t.Errorf("cached a mix of %d bytes: %v", len(data), err)
+	}
+	if parts, _ := filepath.Glob(filepath.Join(filepath.Dir(c.path(key)), "*.part")); len(parts) != 0 {
+		t.Errorf("temporary files left: %v", parts)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// cameraRule picks the settings for files taken with matching cameras,
+// e.g. {"make": "DJI", "preset": "archive"} or {"model": "*", "skip": true}
+// to convert only the cameras named in the rules before it. Make and
+// model are case-insensitive patterns; a missing one matches any camera
+// and "" matches files without camera tags.
+type cameraRule struct {
+	Make    *string
+	Model   *string
+	Skip    bool
+	Options map[string]interface{}
+}
+
+// cameraRules come from "camera-rules" in the config file. The first
+// matching rule applies.
+var cameraRules []cameraRule
+
+// configCameraRules takes the "camera-rules" list out of the config file.
+func configCameraRules(config map[string]interface{}) ([]cameraRule, error) {
+	raw, ok := config["camera-rules"]
+	if !ok {
+		return nil, nil
+	}
+	delete(config, "camera-rules")
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("camera-rules: must be a list of rules")
+	}
+
+	var rules []cameraRule
+	for i, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("camera-rules: rule %d must be an object", i+1)
+		}
+		rule := cameraRule{Options: map[string]interface{}{}}
+		for name, value := range fields {
+			switch name {
+			case "make", "model":
+				pattern := strings.ToLower(fmt.Sprint(value))
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("camera-rules: rule %d: %s %q: %v", i+1, name, value, err)
+				}
+				if name == "make" {
+					rule.Make = &pattern
+				} else {
+					rule.Model = &pattern
+				}
+			case "skip":
+				skip, ok := value.(bool)
+				if !ok {
+					return nil, fmt.Errorf("camera-rules: rule %d: skip must be true or false", i+1)
+				}
+				rule.Skip = skip
+			default:
+				rule.Options[name] = value
+			}
+		}
+		// Check the options now rather than on the first matching file.
+		scratch := globalSettings()
+		if err := scratch.apply(rule.Options); err != nil {
+			return nil, fmt.Errorf("camera-rules: rule %d: %v", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r cameraRule) matches(cameraMake, cameraModel string) bool {
+	return patternMatches(r.Make, cameraMake) && patternMatches(r.Model, cameraModel)
+}
+
+func patternMatches(pattern *string, value string) bool {
+	if pattern == nil {
+		return true
+	}
+	ok, _ := path.Match(*pattern, strings.ToLower(value))
+	return ok
+}
+
+// matchCameraRule returns the first rule for the camera, if any.
+func matchCameraRule(rules []cameraRule, cameraMake, cameraModel string) (cameraRule, bool) {
+	for _, rule := range rules {
+		if rule.matches(cameraMake, cameraModel) {
+			return rule, true
+		}
+	}
+	return cameraRule{}, false
+}
+
+// describeCamera names a camera for the logs.
+func describeCamera(cameraMake, cameraModel string) string {
+	switch {
+	case cameraMake == "" && cameraModel == "":
+		return "no camera"
+	case strings.HasPrefix(cameraModel, cameraMake):
+		return cameraModel
+	}
+	return strings.TrimSpace(cameraMake + " " + cameraModel)
+}
+
+// applyCameraRules adjusts s for the camera input was taken with, or
+// returns a *skipReason when its rule says to leave the file alone.
+func applyCameraRules(input string, s settings) (settings, error) {
+	x, err := readExif(input)
+	if err != nil {
+		return s, err
+	}
+	cameraMake, cameraModel := x.Camera()
+	rule, ok := matchCameraRule(cameraRules, cameraMake, cameraModel)
+	if !ok {
+		return s, nil
+	}
+	if rule.Skip {
+		return s, &skipReason{"camera rule for " + describeCamera(cameraMake, cameraModel)}
+	}
+	if err := s.apply(rule.Options); err != nil {
+		return s, err
+	}
+	return s, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestCameraRules(t *testing.T) {
+	config := map[string]interface{}{
+		"quality": 80.0,
+		"camera-rules": []interface{}{
+			map[string]interface{}{"make": "dji", "preset": "archive"},
+			map[string]interface{}{"model": "iPhone*", "quality": 65.0, "max-size": 2048.0},
+			map[string]interface{}{"make": "", "skip": true},
+		},
+	}
+	rules, err := configCameraRules(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config["camera-rules"]; ok {
+		t.Error("camera-rules should be removed from the options")
+	}
+
+	base := settings{Quality: 75, Metadata: "keep"}
+	for _, c := range []struct {
+		make, model string
+		want        settings
+		skip        bool
+	}{
+		{"DJI", "FC3582", settings{Quality: 95, Metadata: "keep"}, false},
+		{"Apple", "iPhone 15 Pro", settings{Quality: 65, MaxSize: 2048, Metadata: "keep"}, false},
+		{"", "", base, true},
+		{"Canon", "EOS R5", base, false},
+	} {
+		rule, ok := matchCameraRule(rules, c.make, c.model)
+		got := base
+		if ok && !rule.Skip {
+			if err := got.apply(rule.Options); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got != c.want || (ok && rule.Skip) != c.skip {
+			t.Errorf("%s %s: settings %+v skip %v; want %+v skip %v", c.make, c.model, got, ok && rule.Skip, c.want, c.skip)
+		}
+	}
+
+	for _, bad := range []interface{}{
+		"nope",
+		[]interface{}{map[string]interface{}{"model": "[", "quality": 70.0}},
+		[]interface{}{map[string]interface{}{"make": "DJI", "workers": 2.0}},
+		[]interface{}{map[string]interface{}{"make": "DJI", "skip": "yes"}},
+	} {
+		if _, err := configCameraRules(map[string]interface{}{"camera-rules": bad}); err == nil {
+			t.Errorf("camera-rules %v accepted", bad)
+		}
+	}
+}
+
+func TestDescribeCamera(t *testing.T) {
+	for _, c := range [][3]string{{"Apple", "iPhone 15", "Apple iPhone 15"}, {"Canon", "Canon EOS R5", "Canon EOS R5"}, {"", "", "no camera"}} {
+		if got := describeCamera(c[0], c[1]); got != c[2] {
+			t.Errorf("describeCamera(%q, %q) = %q, want %q", c[0], c[1], got, c[2])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func init() {
+	subcommands["clipboard"] = clipboardCommand
+}
+
+// clipboardHEICTypes are the clipboard formats a HEIC image comes in,
+// most specific first: media types on Linux, type identifiers on macOS.
+var clipboardHEICTypes = []string{"image/heic", "image/heif", "public.heic", "public.heif"}
+
+// The platform's clipboard, which the tests replace. pasteHEIC writes the
+// HEIC image on the clipboard to path and reports whether there was one;
+// copyJPEG puts the JPEG at path on the clipboard in its place.
+var (
+	pasteHEIC = readClipboardHEIC
+	copyJPEG  = writeClipboardJPEG
+)
+
+func clipboardCommand(args []string) error {
+	fs := flag.NewFlagSet("clipboard", flag.ExitOnError)
+	output := fs.String("o", "", "write the JPEG to this file and leave the clipboard as it is")
+	q := fs.Int("quality", *quality, "JPEG quality (1-100)")
+	watch := fs.Bool("watch", false, "keep running, converting each HEIC image copied to the clipboard until interrupted")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg clipboard [-o FILE] [-quality 75] [-watch]")
+		fmt.Fprintln(fs.Output(), "Replaces the HEIC image on the clipboard, such as a photo copied on an iPhone through Universal Clipboard, with a JPEG.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 || (*watch && *output != "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *q < 1 || *q > 100 {
+		return fmt.Errorf("invalid quality %d: must be 1 to 100", *q)
+	}
+	s := globalSettings()
+	s.Quality = *q
+	ctx := withSettings(context.Background(), s)
+	if !*watch {
+		converted, err := convertClipboard(ctx, *output)
+		if err == nil && !converted {
+			err = errors.New("there is no HEIC image on the clipboard")
+		}
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	fmt.Println(tr("Watching the clipboard for HEIC images; press Ctrl+C to stop"))
+	for {
+		// A JPEG replaces each HEIC, so one is converted once.
+		if _, err := convertClipboard(ctx, ""); err != nil {
+			fmt.Printf(tr("Failed to convert the clipboard: %v\n"), err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// convertClipboard converts the HEIC image on the clipboard, if any, and
+// puts the JPEG back on the clipboard, or writes it to output when that
+// is set.
+func convertClipboard(ctx context.Context, output string) (bool, error) {
+	f, err := os.CreateTemp("", "heictojpeg-clipboard-*.heic")
+	if err != nil {
+		return false, err
+	}
+	input := f.Name()
+	f.Close()
+	defer os.Remove(input)
+	if ok, err := pasteHEIC(input); err != nil || !ok {
+		return false, err
+	}
+	jpegPath := input + ".jpg"
+	defer os.Remove(jpegPath)
+	if err := convertHeicToJpg(ctx, input, jpegPath); err != nil {
+		return false, err
+	}
+	size := humanReadableFileSize(getFileSize(jpegPath))
+	if output != "" {
+		data, err := os.ReadFile(jpegPath)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			return false, err
+		}
+		fmt.Printf(tr("Converted the clipboard's HEIC image to %s (%s)\n"), output, size)
+		return true, nil
+	}
+	if err := copyJPEG(jpegPath); err != nil {
+		return false, err
+	}
+	fmt.Printf(tr("Replaced the HEIC image on the clipboard with a JPEG (%s)\n"), size)
+	return true, nil
+}
+
+// clipboardHEICType returns the first of types, the formats on the
+// clipboard, that holds a HEIC image; "" when none does.
+func clipboardHEICType(types []string) string {
+	for _, want := range clipboardHEICTypes {
+		for _, t := range types {
+			if strings.EqualFold(strings.TrimSpace(t), want) {
+				return strings.TrimSpace(t)
+			}
+		}
+	}
+	return ""
+}
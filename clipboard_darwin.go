@@ -0,0 +1,41 @@
+//go:build darwin
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// readClipboardHEIC reads the general pasteboard through AppKit, from
+// JavaScript for Automation.
+func readClipboardHEIC(path string) (bool, error) {
+	script := `ObjC.import('AppKit');
+function run(argv) {
+	var pb = $.NSPasteboard.generalPasteboard;
+	var types = ` + "['" + strings.Join(clipboardHEICTypes, "', '") + "']" + `;
+	for (var i = 0; i < types.length; i++) {
+		var data = pb.dataForType(types[i]);
+		if (!data.isNil()) {
+			data.writeToFileAtomically(argv[0], true);
+			return 'heic';
+		}
+	}
+	return '';
+}`
+	out, err := exec.Command("osascript", "-l", "JavaScript", "-e", script, path).Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "heic", nil
+}
+
+func writeClipboardJPEG(path string) error {
+	script := `ObjC.import('AppKit');
+function run(argv) {
+	var pb = $.NSPasteboard.generalPasteboard;
+	pb.clearContents;
+	pb.setDataForType($.NSData.dataWithContentsOfFile(argv[0]), 'public.jpeg');
+}`
+	return exec.Command("osascript", "-l", "JavaScript", "-e", script, path).Run()
+}
@@ -0,0 +1,65 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// readClipboardHEIC goes through wl-paste on Wayland and xclip on X11.
+func readClipboardHEIC(path string) (bool, error) {
+	list, paste := clipboardCommands()
+	out, err := exec.Command(list[0], list[1:]...).Output()
+	if err != nil {
+		// Both fail on an empty clipboard.
+		if errors.Is(err, exec.ErrNotFound) {
+			return false, fmt.Errorf("the clipboard needs %s: %v", clipboardPackage(list[0]), err)
+		}
+		return false, nil
+	}
+	typ := clipboardHEICType(strings.Split(string(out), "\n"))
+	if typ == "" {
+		return false, nil
+	}
+	data, err := exec.Command(paste[0], append(paste[1:], typ)...).Output()
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, data, 0600)
+}
+
+func writeClipboardJPEG(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("xclip", "-selection", "clipboard", "-t", "image/jpeg", "-i")
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmd = exec.Command("wl-copy", "--type", "image/jpeg")
+	}
+	// Both stay in the background to serve the clipboard.
+	cmd.Stdin = bytes.NewReader(data)
+	return cmd.Run()
+}
+
+// clipboardCommands returns the commands listing the clipboard's formats
+// and pasting one, which is appended.
+func clipboardCommands() (list, paste []string) {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return []string{"wl-paste", "--list-types"}, []string{"wl-paste", "--no-newline", "--type"}
+	}
+	return []string{"xclip", "-selection", "clipboard", "-t", "TARGETS", "-o"}, []string{"xclip", "-selection", "clipboard", "-o", "-t"}
+}
+
+// clipboardPackage names the package with command.
+func clipboardPackage(command string) string {
+	if strings.HasPrefix(command, "wl-") {
+		return "wl-clipboard"
+	}
+	return command
+}
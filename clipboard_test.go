@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+func TestClipboardHEICType(t *testing.T) {
+	for _, c := range []struct {
+		types []string
+		want  string
+	}{
+		{[]string{"TARGETS", "image/png", "image/heic\r"}, "image/heic"},
+		{[]string{"public.heif", "public.heic"}, "public.heic"},
+		{[]string{"image/png", "text/plain"}, ""},
+		{nil, ""},
+	} {
+		if got := clipboardHEICType(c.types); got != c.want {
+			t.Errorf("clipboardHEICType(%q) = %q, want %q", c.types, got, c.want)
+		}
+	}
+}
+
+func TestConvertClipboard(t *testing.T) {
+	defer func(paste func(string) (bool, error), copy func(string) error) {
+		pasteHEIC, copyJPEG = paste, copy
+	}(pasteHEIC, copyJPEG)
+	var clipboard []byte
+	pasteHEIC = func(path string) (bool, error) {
+		if clipboard == nil {
+			return false, nil
+		}
+		return true, os.WriteFile(path, clipboard, 0600)
+	}
+	copyJPEG = func(path string) (err error) {
+		clipboard, err = os.ReadFile(path)
+		return err
+	}
+	ctx := context.Background()
+
+	if converted, err := convertClipboard(ctx, ""); converted || err != nil {
+		t.Errorf("empty clipboard: %v, %v", converted, err)
+	}
+	clipboard = exifSample()
+	if converted, err := convertClipboard(ctx, ""); !converted || err != nil {
+		t.Fatalf("convertClipboard = %v, %v", converted, err)
+	}
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(clipboard)); err != nil {
+		t.Errorf("the clipboard holds no JPEG: %v", err)
+	}
+
+	clipboard = exifSample()
+	output := filepath.Join(t.TempDir(), "pasted.jpg")
+	if converted, err := convertClipboard(ctx, output); !converted || err != nil {
+		t.Fatalf("convertClipboard to a file = %v, %v", converted, err)
+	}
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if config, err := jpeg.DecodeConfig(f); err != nil || config.Width != heicsample.Size {
+		t.Errorf("JPEG %+v, %v", config, err)
+	}
+	if string(clipboard[4:8]) != "ftyp" {
+		t.Error("writing to a file changed the clipboard")
+	}
+}
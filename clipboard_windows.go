@@ -0,0 +1,49 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// readClipboardHEIC takes a HEIC image put on the clipboard as such, or
+// else the first HEIC file copied in Explorer.
+func readClipboardHEIC(path string) (bool, error) {
+	script := `Add-Type -AssemblyName System.Windows.Forms
+$data = [Windows.Forms.Clipboard]::GetDataObject()
+foreach ($type in @('image/heic', 'image/heif', 'HEIC', 'HEIF')) {
+	if ($data -and $data.GetDataPresent($type)) {
+		$stream = $data.GetData($type)
+		if ($stream -is [IO.Stream]) {
+			$out = [IO.File]::Create(` + powerShellString(path) + `)
+			$stream.CopyTo($out)
+			$out.Close()
+			'heic'
+			exit
+		}
+	}
+}
+$file = [Windows.Forms.Clipboard]::GetFileDropList() | Where-Object { $_ -match '\.(heic|heif)$' } | Select-Object -First 1
+if ($file) {
+	Copy-Item -LiteralPath $file -Destination ` + powerShellString(path) + `
+	'heic'
+}`
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-STA", "-Command", script).Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "heic", nil
+}
+
+// writeClipboardJPEG puts the JPEG on the clipboard both as a picture,
+// which most programs paste, and as JFIF data.
+func writeClipboardJPEG(path string) error {
+	script := `Add-Type -AssemblyName System.Windows.Forms, System.Drawing
+$bytes = [IO.File]::ReadAllBytes(` + powerShellString(path) + `)
+$data = New-Object Windows.Forms.DataObject
+$data.SetImage([Drawing.Image]::FromStream((New-Object IO.MemoryStream (, $bytes))))
+$data.SetData('JFIF', (New-Object IO.MemoryStream (, $bytes)))
+[Windows.Forms.Clipboard]::SetDataObject($data, $true)`
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-STA", "-Command", script).Run()
+}
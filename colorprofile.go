@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"heictojpeg/convert"
+)
+
+var (
+	colorTarget   = flag.String("color-target", "keep", "colors of photos with a profile other than sRGB: keep embeds the profile in the output, srgb converts them to sRGB for viewers without color management, p3 converts them to Display P3 with its profile embedded")
+	convertToSRGB = flag.Bool("convert-to-srgb", false, "shorthand for -color-target srgb")
+)
+
+var colorTargets = map[string]bool{"keep": true, "srgb": true, "p3": true}
+
+// isolatedProfile judges what became of the color profile of input in an
+// -isolate child process, which can't report it.
+func isolatedProfile(input string) convert.Profile {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return convert.Profile{}
+	}
+	defer f.Close()
+	return convert.ProfileOf(f, globalSettings().options())
+}
+
+type profileKey struct{}
+
+// withProfileOutcome has the conversion under ctx store what became of
+// the color profile of its file in p.
+func withProfileOutcome(ctx context.Context, p *convert.Profile) context.Context {
+	return context.WithValue(ctx, profileKey{}, p)
+}
+
+// profileOutcomeFrom returns where the conversion under ctx stores the
+// outcome, or nil.
+func profileOutcomeFrom(ctx context.Context) *convert.Profile {
+	p, _ := ctx.Value(profileKey{}).(*convert.Profile)
+	return p
+}
+
+func recordProfile(ctx context.Context, outcome convert.Profile) {
+	if p := profileOutcomeFrom(ctx); p != nil {
+		*p = outcome
+	}
+}
+
+// outputICC is the profile the outputs of the file converted under ctx
+// embed.
+func outputICC(ctx context.Context) []byte {
+	if p := profileOutcomeFrom(ctx); p != nil {
+		return p.ICC
+	}
+	return nil
+}
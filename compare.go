@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"heictojpeg/convert"
+)
+
+var compareDir = flag.String("compare-dir", "", "write a side-by-side image (HEIC left, JPEG right) per file and compare.csv with PSNR and SSIM scores to this folder, for checking quality before deleting originals")
+
+const (
+	compareSide  = 1024 // longer side of each half of a composite
+	compareGap   = 8
+	compareCSV   = "compare.csv"
+	compareLevel = 90
+)
+
+// comparer collects the quality comparisons of a batch.
+type comparer struct {
+	dir string
+	mu  sync.Mutex
+	f   *os.File
+	csv *csv.Writer
+}
+
+func newComparer(dir string) (*comparer, error) {
+	if err := guardWrite(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(longPath(dir), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(longPath(filepath.Join(dir, compareCSV)))
+	if err != nil {
+		return nil, err
+	}
+	c := &comparer{dir: dir, f: f, csv: csv.NewWriter(f)}
+	c.csv.Write([]string{"file", "psnr_db", "ssim", "jpeg_bytes"})
+	return c, nil
+}
+
+func (c *comparer) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		c.f.Close()
+		return err
+	}
+	return c.f.Close()
+}
+
+// compare scores the JPEG encoded from source and writes the composite.
+func (c *comparer) compare(name string, source image.Image, encoded []byte) error {
+	converted, err := jpeg.Decode(bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	a, b := convert.LumaOf(source), convert.LumaOf(converted)
+	if a.Width != b.Width || a.Height != b.Height {
+		// -max-size scaled the JPEG; compare against the scaled source.
+		a = convert.LumaOf(convert.FitWithin(source, maxInt(b.Width, b.Height)))
+	}
+	psnr, score := peakSNR(a, b), convert.SSIM(a, b)
+
+	path := filepath.Join(c.dir, strings.TrimSuffix(name, filepath.Ext(name))+".jpg")
+	if err := os.MkdirAll(longPath(filepath.Dir(path)), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(longPath(path))
+	if err != nil {
+		return err
+	}
+	err = jpeg.Encode(out, sideBySide(source, converted), &jpeg.Options{Quality: compareLevel})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.csv.Write([]string{
+		filepath.ToSlash(name),
+		strconv.FormatFloat(psnr, 'f', 2, 64),
+		strconv.FormatFloat(score, 'f', 4, 64),
+		strconv.Itoa(len(encoded)),
+	})
+	return c.csv.Error()
+}
+
+// peakSNR is the luma PSNR in decibels; identical planes score +Inf,
+// which is written as 99.
+func peakSNR(a, b convert.Luma) float64 {
+	var sum float64
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			d := float64(a.Pix[y*a.Stride+x]) - float64(b.Pix[y*b.Stride+x])
+			sum += d * d
+		}
+	}
+	if sum == 0 {
+		return 99
+	}
+	mse := sum / float64(a.Width*a.Height)
+	return 10 * math.Log10(255*255/mse)
+}
+
+// sideBySide puts downscaled copies of the source and the JPEG next to
+// each other.
+func sideBySide(source, converted image.Image) *image.RGBA {
+	left, right := convert.FitWithin(source, compareSide), convert.FitWithin(converted, compareSide)
+	lb, rb := left.Bounds(), right.Bounds()
+	height := maxInt(lb.Dy(), rb.Dy())
+	out := image.NewRGBA(image.Rect(0, 0, lb.Dx()+compareGap+rb.Dx(), height))
+	draw.Draw(out, image.Rect(0, 0, lb.Dx(), lb.Dy()), left, lb.Min, draw.Src)
+	draw.Draw(out, image.Rect(lb.Dx()+compareGap, 0, out.Bounds().Dx(), rb.Dy()), right, rb.Min, draw.Src)
+	return out
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type comparerKey struct{}
+
+// comparison is the comparer and name of the file converted under a ctx.
+type comparison struct {
+	c    *comparer
+	name string
+}
+
+func withComparer(ctx context.Context, c *comparer) context.Context {
+	return context.WithValue(ctx, comparerKey{}, comparison{c: c})
+}
+
+// withComparedFile names the file converted under ctx, when comparing.
+func withComparedFile(ctx context.Context, name string) context.Context {
+	if cmp, ok := ctx.Value(comparerKey{}).(comparison); ok {
+		cmp.name = name
+		return context.WithValue(ctx, comparerKey{}, cmp)
+	}
+	return ctx
+}
+
+func comparisonFrom(ctx context.Context) (comparison, bool) {
+	cmp, ok := ctx.Value(comparerKey{}).(comparison)
+	return cmp, ok && cmp.name != ""
+}
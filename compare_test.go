@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+func testPhoto(w, h int, noise bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / w)
+			if noise {
+				v = uint8(r.Intn(256))
+			}
+			img.SetRGBA(x, y, color.RGBA{v, v / 2, 255 - v, 255})
+		}
+	}
+	return img
+}
+
+func TestComparer(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newComparer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := testPhoto(64, 32, false)
+	ctx := withComparedFile(withComparer(context.Background(), c), filepath.Join("trip", "IMG_1.heic"))
+	var out bytes.Buffer
+	if err := encodeJPEG(ctx, &out, source, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "trip", "IMG_1.jpg"))
+	if err != nil {
+		t.Fatalf("composite not written: %v", err)
+	}
+	defer f.Close()
+	composite, err := jpeg.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := composite.Bounds(); b.Dx() != 2*64+compareGap || b.Dy() != 32 {
+		t.Errorf("composite is %v, want both halves side by side", b)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, compareCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("compare.csv = %q, %v", data, err)
+	}
+	if rows[1][0] != "trip/IMG_1.heic" || rows[1][3] != strconv.Itoa(out.Len()) {
+		t.Errorf("row = %v, want the file and its %d JPEG bytes", rows[1], out.Len())
+	}
+}
+
+func TestPeakSNR(t *testing.T) {
+	a := convert.LumaOf(testPhoto(16, 16, false))
+	if got := peakSNR(a, a); got != 99 {
+		t.Errorf("PSNR of identical planes = %v, want 99", got)
+	}
+	if got := peakSNR(a, convert.LumaOf(testPhoto(16, 16, true))); got > 20 {
+		t.Errorf("PSNR of unrelated planes = %v, want it low", got)
+	}
+}
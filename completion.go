@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	webhookURL   = flag.String("webhook", "", "POST a JSON run summary to this URL when the batch completes")
+	smtpServer   = flag.String("smtp-server", "", "mail the run summary through this SMTP server (host:port)")
+	smtpUser     = flag.String("smtp-user", "", "SMTP username; the password is read from HEICTOJPEG_SMTP_PASSWORD")
+	mailFrom     = flag.String("mail-from", "", "sender address for the summary mail")
+	mailTo       = flag.String("mail-to", "", "comma-separated recipients for the summary mail")
+	reportClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+type runReport struct {
+	Text        string                `json:"text"`
+	Host        string                `json:"host"`
+	Directory   string                `json:"directory"`
+	Files       int                   `json:"files"`
+	Converted   int                   `json:"converted"`
+	Failed      int                   `json:"failed"`
+	DurationSec float64               `json:"duration_seconds"`
+	InputBytes  int64                 `json:"input_bytes"`
+	OutputBytes int64                 `json:"output_bytes"`
+	Exif        convert.ExifCounts    `json:"exif"`
+	Profiles    convert.ProfileCounts `json:"profiles"`
+	Errors      []reportError         `json:"errors"`
+}
+
+type reportError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// completionReporter collects failures during the run and sends the
+// summary to the configured webhook and mail recipients at the end.
+type completionReporter struct {
+	dir    string
+	mu     sync.Mutex
+	errors []reportError
+}
+
+func (r *completionReporter) OnStart(total int) {}
+
+func (r *completionReporter) OnFileDone(result convert.ConversionResult) {
+	if result.Err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, reportError{File: filepath.Base(result.Input), Error: result.Err.Error()})
+}
+
+func (r *completionReporter) OnFinish(summary convert.Summary) {
+	report := r.build(summary)
+	if *webhookURL != "" {
+		if err := postWebhook(*webhookURL, report); err != nil {
+			fmt.Printf(tr("Failed to send webhook report: %v\n"), err)
+		}
+	}
+	if *smtpServer != "" {
+		if err := mailReport(report); err != nil {
+			fmt.Printf(tr("Failed to mail report: %v\n"), err)
+		}
+	}
+}
+
+func (r *completionReporter) build(summary convert.Summary) runReport {
+	host, _ := os.Hostname()
+	r.mu.Lock()
+	failures := append([]reportError{}, r.errors...)
+	r.mu.Unlock()
+
+	report := runReport{
+		Host:        host,
+		Directory:   r.dir,
+		Files:       summary.Files,
+		Converted:   summary.Files - summary.Failed,
+		Failed:      summary.Failed,
+		DurationSec: summary.Duration.Seconds(),
+		InputBytes:  summary.InputSize,
+		OutputBytes: summary.OutputSize,
+		Exif:        summary.Exif,
+		Profiles:    summary.Profiles,
+		Errors:      failures,
+	}
+	// "text" makes the same payload render in Slack-style incoming webhooks.
+	report.Text = fmt.Sprintf("heictojpeg on %s: converted %d of %d files in %s (%d failed, %s > %s)",
+		host, report.Converted, report.Files, summary.Duration.Round(time.Second), report.Failed,
+		humanReadableFileSize(summary.InputSize), humanReadableFileSize(summary.OutputSize))
+	return report
+}
+
+func postWebhook(url string, report runReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	resp, err := reportClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func mailReport(report runReport) error {
+	if *mailFrom == "" || *mailTo == "" {
+		return fmt.Errorf("-mail-from and -mail-to are required with -smtp-server")
+	}
+	recipients := mailAddresses(*mailTo)
+	return sendMail(recipients, buildReportMail(*mailFrom, recipients, report))
+}
+
+// mailAddresses splits a comma-separated list of addresses.
+func mailAddresses(list string) []string {
+	recipients := strings.Split(list, ",")
+	for i := range recipients {
+		recipients[i] = strings.TrimSpace(recipients[i])
+	}
+	return recipients
+}
+
+// sendMail sends msg to recipients from -mail-from through -smtp-server.
+func sendMail(recipients []string, msg []byte) error {
+	var auth smtp.Auth
+	if *smtpUser != "" {
+		host := strings.Split(*smtpServer, ":")[0]
+		auth = smtp.PlainAuth("", *smtpUser, os.Getenv("HEICTOJPEG_SMTP_PASSWORD"), host)
+	}
+	return smtp.SendMail(*smtpServer, auth, *mailFrom, recipients, msg)
+}
+
+func buildReportMail(from string, to []string, report runReport) []byte {
+	var b bytes.Buffer
+	subject := fmt.Sprintf("heictojpeg: %d converted, %d failed", report.Converted, report.Failed)
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\n", from, strings.Join(to, ", "), subject)
+	fmt.Fprintf(&b, "Date: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "%s\r\n\r\nDirectory: %s\r\n", report.Text, report.Directory)
+	if len(report.Errors) > 0 {
+		b.WriteString("\r\nFailures:\r\n")
+		for _, e := range report.Errors {
+			fmt.Fprintf(&b, "  %s: %s\r\n", e.File, e.Error)
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+// Testing the webhook report sent on completion
+func TestCompletionReporterWebhook(t *testing.T) {
+	received := make(chan runReport, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report runReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("Invalid JSON body: %v", err)
+		}
+		received <- report
+	}))
+	defer server.Close()
+
+	*webhookURL = server.URL
+	defer func() { *webhookURL = "" }()
+
+	reporter := &completionReporter{dir: "/photos"}
+	reporter.OnFileDone(convert.ConversionResult{Input: "/photos/a.heic"})
+	reporter.OnFileDone(convert.ConversionResult{Input: "/photos/b.heic", Err: errors.New("decode timeout")})
+	reporter.OnFinish(convert.Summary{Files: 2, Failed: 1, Duration: 3 * time.Second, InputSize: 2048, OutputSize: 1024})
+
+	report := <-received
+	if report.Files != 2 || report.Converted != 1 || report.Failed != 1 || report.Directory != "/photos" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].File != "b.heic" || report.Errors[0].Error != "decode timeout" {
+		t.Errorf("Unexpected errors %+v", report.Errors)
+	}
+	if !strings.Contains(report.Text, "converted 1 of 2 files") {
+		t.Errorf("Unexpected text %q", report.Text)
+	}
+}
+
+// Testing buildReportMail function
+func TestBuildReportMail(t *testing.T) {
+	report := runReport{Text: "summary", Converted: 1, Failed: 1, Errors: []reportError{{File: "b.heic", Error: "decode timeout"}}}
+	mail := string(buildReportMail("nas@example.com", []string{"me@example.com", "you@example.com"}, report))
+
+	for _, want := range []string{"To: me@example.com, you@example.com\r\n", "Subject: heictojpeg: 1 converted, 1 failed\r\n", "b.heic: decode timeout"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("Mail is missing %q:\n%s", want, mail)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const configEnv = "HEICTOJPEG_CONFIG"
+
+// configPath is $HEICTOJPEG_CONFIG, or config.json in the user config directory.
+func configPath() (string, error) {
+	if path := os.Getenv(configEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "heictojpeg", "config.json"), nil
+}
+
+// readConfig loads the config file: a JSON object of flag names to default
+// values, e.g. {"workers": 6, "quality": 85}. A missing file is empty.
+func readConfig(path string) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
+
+func writeConfig(path string, config map[string]interface{}) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// applyConfig sets the flags named in config that weren't given on the
+// command line, so the config file only changes defaults.
+func applyConfig(fs *flag.FlagSet, config map[string]interface{}) error {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if given[name] {
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if err := fs.Set(name, fmt.Sprint(config[name])); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// envPrefix starts the environment variables that set options, e.g.
+// HEICTOJPEG_QUALITY=85 for -quality 85 and HEICTOJPEG_DELETE_ORIGINALS=true
+// for -delete-originals, for containers, where there is no config file to
+// edit.
+const envPrefix = "HEICTOJPEG_"
+
+// settingsEnv are the variables with the prefix that mean something else
+// than the option of that name. The ones named like an option, such as
+// HEICTOJPEG_HISTORY and the HEICTOJPEG_OUTPUT of hooks, must be listed;
+// others are only warned about when they aren't.
+var settingsEnv = map[string]bool{
+	configEnv:                  true,
+	historyEnv:                 true,
+	journalEnv:                 true,
+	redisPasswordEnv:           true,
+	mqttPasswordEnv:            true,
+	telegramTokenEnv:           true,
+	discordTokenEnv:            true,
+	imapPasswordEnv:            true,
+	hookInputEnv:               true,
+	hookOutputEnv:              true,
+	"HEICTOJPEG_SMTP_PASSWORD": true,
+}
+
+// applyEnvironment sets the flags named by the HEICTOJPEG_ variables in
+// environ that weren't given on the command line. It runs before the
+// config file is read, so the environment wins over it. A variable that
+// names no option is ignored with a warning, since it may be meant for
+// something else, such as a hook running heictojpeg again.
+func applyEnvironment(fs *flag.FlagSet, environ []string) error {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	sort.Strings(environ)
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, envPrefix) || settingsEnv[key] {
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, envPrefix), "_", "-"))
+		if given[name] {
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			fmt.Fprintf(os.Stderr, tr("Ignoring %s: there is no option -%s\n"), key, name)
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// readConfigDefinitions reads only the presets and profiles of the config
+// file, for child processes, which get the options on their command line.
+func readConfigDefinitions() error {
+	path, err := configPath()
+	if err != nil {
+		return nil
+	}
+	config, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	if customPresets, err = configPresets(config); err != nil {
+		return err
+	}
+	customProfiles, err = configProfiles(config)
+	return err
+}
+
+func loadConfig() error {
+	if err := applyEnvironment(flag.CommandLine, os.Environ()); err != nil {
+		return err
+	}
+	path, err := configPath()
+	if err != nil {
+		return applyPreset(flag.CommandLine, nil, nil)
+	}
+	config, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	custom, err := configPresets(config)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	customPresets = custom
+	if customProfiles, err = configProfiles(config); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if cameraRules, err = configCameraRules(config); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if hotFolders, err = configHotFolders(config); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	// The preset goes first so it wins over the rest of the config file.
+	if err := applyPreset(flag.CommandLine, config, custom); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if err := applyConfig(flag.CommandLine, config); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
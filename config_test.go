@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := writeConfig(path, map[string]interface{}{"workers": 6, "quality": 85, "recursive": true}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	workers := fs.Int("workers", 0, "")
+	quality := fs.Int("quality", 75, "")
+	recursive := fs.Bool("recursive", false, "")
+	fs.Parse([]string{"-quality", "60"})
+
+	if err := applyConfig(fs, config); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 6 || *quality != 60 || !*recursive {
+		t.Errorf("workers=%d quality=%d recursive=%v; want 6, 60 (command line wins), true", *workers, *quality, *recursive)
+	}
+
+	if err := applyConfig(fs, map[string]interface{}{"nope": 1}); err == nil {
+		t.Error("unknown option accepted")
+	}
+}
+
+func TestApplyEnvironment(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	quality := fs.Int("quality", 75, "")
+	deleteOriginals := fs.Bool("delete-originals", false, "")
+	schedule := fs.String("schedule", "", "")
+	fs.Parse([]string{"-schedule", "@daily"})
+
+	environ := []string{
+		"HEICTOJPEG_QUALITY=85",
+		"HEICTOJPEG_DELETE_ORIGINALS=true",
+		"HEICTOJPEG_SCHEDULE=@hourly",
+		"HEICTOJPEG_CONFIG=/config/config.json",
+		"HOME=/root",
+	}
+	if err := applyEnvironment(fs, environ); err != nil {
+		t.Fatal(err)
+	}
+	if *quality != 85 || !*deleteOriginals || *schedule != "@daily" {
+		t.Errorf("quality=%d delete-originals=%v schedule=%q; want 85, true, @daily (command line wins)", *quality, *deleteOriginals, *schedule)
+	}
+
+	// Variables naming no option, or set for a hook, are left alone.
+	fs.String("output", "text", "")
+	if err := applyEnvironment(fs, []string{"HEICTOJPEG_QUALTY=90", "HEICTOJPEG_INPUT=/a.heic", "HEICTOJPEG_OUTPUT=/a.jpg"}); err != nil {
+		t.Errorf("applyEnvironment failed on variables that aren't options: %v", err)
+	}
+	if *quality != 85 || fs.Lookup("output").Value.String() != "text" {
+		t.Errorf("quality=%d output=%s; want them unchanged", *quality, fs.Lookup("output").Value)
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("delete-originals", false, "")
+	if err := applyEnvironment(fs, []string{"HEICTOJPEG_DELETE_ORIGINALS=maybe"}); err == nil {
+		t.Error("invalid value accepted")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runControl lets an interactive front end pause the worker pool and skip
+// the file a worker is stuck on. It travels with the run's context; all
+// methods are no-ops on a nil control.
+type runControl struct {
+	mu      sync.Mutex
+	resumed chan struct{} // nil while running, closed on resume
+	workers map[int]*activeFile
+	limit   int // workers with a higher id wait; 0 means no limit
+}
+
+type activeFile struct {
+	started time.Time
+	cancel  context.CancelFunc
+	skipped bool
+}
+
+type runControlKey struct{}
+
+func newRunControl() *runControl {
+	return &runControl{workers: make(map[int]*activeFile)}
+}
+
+func withRunControl(ctx context.Context, c *runControl) context.Context {
+	return context.WithValue(ctx, runControlKey{}, c)
+}
+
+func runControlFrom(ctx context.Context) *runControl {
+	c, _ := ctx.Value(runControlKey{}).(*runControl)
+	return c
+}
+
+// togglePause pauses or resumes the run and reports whether it is now paused.
+// Files already being converted finish; workers wait before picking up the next one.
+func (c *runControl) togglePause() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+		return true
+	}
+	close(c.resumed)
+	c.resumed = nil
+	return false
+}
+
+// setPaused pauses or resumes the run and reports whether that changed anything.
+func (c *runControl) setPaused(pause bool) bool {
+	if c == nil || c.paused() == pause {
+		return false
+	}
+	return c.togglePause() == pause
+}
+
+func (c *runControl) paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resumed != nil
+}
+
+// waitWhilePaused blocks until the run is resumed or ctx is done.
+func (c *runControl) waitWhilePaused(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	if resumed == nil {
+		return
+	}
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+	}
+}
+
+// setWorkerLimit lets only the first n workers pick up files; 0 lifts the limit.
+func (c *runControl) setWorkerLimit(n int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = n
+}
+
+func (c *runControl) workerAllowed(worker int) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit == 0 || worker < c.limit
+}
+
+// waitForTurn blocks while the run is paused or worker is over the worker
+// limit, until ctx is done.
+func (c *runControl) waitForTurn(ctx context.Context, worker int) {
+	c.waitWhilePaused(ctx)
+	for !c.workerAllowed(worker) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+		c.waitWhilePaused(ctx)
+	}
+}
+
+// begin registers worker as busy and returns the context for its file,
+// which skip cancels. done reports whether the file was skipped.
+func (c *runControl) begin(ctx context.Context, worker int) (context.Context, func() bool) {
+	if c == nil {
+		return ctx, func() bool { return false }
+	}
+	fileCtx, cancel := context.WithCancel(ctx)
+	file := &activeFile{started: time.Now(), cancel: cancel}
+
+	c.mu.Lock()
+	c.workers[worker] = file
+	c.mu.Unlock()
+
+	return fileCtx, func() bool {
+		c.mu.Lock()
+		delete(c.workers, worker)
+		c.mu.Unlock()
+		cancel()
+		return file.skipped
+	}
+}
+
+// skip cancels the file worker is converting.
+func (c *runControl) skip(worker int) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file, ok := c.workers[worker]
+	if !ok {
+		return false
+	}
+	file.skipped = true
+	file.cancel()
+	return true
+}
+
+// skipLongest cancels whichever file has been converting the longest.
+func (c *runControl) skipLongest() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	longest := -1
+	var started time.Time
+	for worker, file := range c.workers {
+		if longest == -1 || file.started.Before(started) {
+			longest, started = worker, file.started
+		}
+	}
+	c.mu.Unlock()
+	if longest == -1 {
+		return false
+	}
+	return c.skip(longest)
+}
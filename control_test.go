@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Testing runControl pause and resume
+func TestRunControlPause(t *testing.T) {
+	c := newRunControl()
+	if !c.togglePause() || !c.paused() {
+		t.Fatalf("Control should be paused")
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		c.waitWhilePaused(context.Background())
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatalf("waitWhilePaused returned while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if c.togglePause() {
+		t.Fatalf("Control should be resumed")
+	}
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatalf("waitWhilePaused did not return after resume")
+	}
+}
+
+// Testing runControl skipping a worker's file
+func TestRunControlSkip(t *testing.T) {
+	c := newRunControl()
+	fileCtx, done := c.begin(context.Background(), 2)
+	if c.skip(0) {
+		t.Errorf("Idle worker should not be skipped")
+	}
+	if !c.skipLongest() {
+		t.Fatalf("Busy worker should be skipped")
+	}
+	if fileCtx.Err() == nil {
+		t.Errorf("Skipped file's context should be cancelled")
+	}
+	if !done() {
+		t.Errorf("done should report the skip")
+	}
+}
+
+// Testing a nil runControl is a no-op
+func TestRunControlNil(t *testing.T) {
+	var c *runControl
+	ctx, done := c.begin(context.Background(), 0)
+	c.waitWhilePaused(ctx)
+	if c.togglePause() || c.skip(0) || done() {
+		t.Errorf("nil control should do nothing")
+	}
+}
+
+func TestRunControlSetPaused(t *testing.T) {
+	c := newRunControl()
+	if !c.setPaused(true) || c.setPaused(true) || !c.paused() {
+		t.Fatalf("setPaused(true) should pause once")
+	}
+	if !c.setPaused(false) || c.setPaused(false) || c.paused() {
+		t.Fatalf("setPaused(false) should resume once")
+	}
+}
+
+func TestRunControlWorkerLimit(t *testing.T) {
+	c := newRunControl()
+	c.setWorkerLimit(2)
+	if !c.workerAllowed(1) || c.workerAllowed(2) {
+		t.Fatalf("only workers 0 and 1 should be allowed")
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		c.waitForTurn(context.Background(), 3)
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatalf("waitForTurn returned for a worker over the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	c.setWorkerLimit(0)
+	select {
+	case <-waited:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("waitForTurn did not return after the limit was lifted")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchControlSignals pauses the run on SIGUSR1 and resumes it on SIGUSR2,
+// e.g. `pkill -USR1 heictojpeg` when the machine is needed for something else.
+func watchControlSignals(ctx context.Context, control *runControl) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 && control.setPaused(true) {
+					fmt.Println(tr("Paused (SIGUSR1); running files finish, send SIGUSR2 to resume."))
+				} else if sig == syscall.SIGUSR2 && control.setPaused(false) {
+					fmt.Println(tr("Resumed."))
+				}
+			}
+		}
+	}()
+}
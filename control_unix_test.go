@@ -0,0 +1,32 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatchControlSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newRunControl()
+	watchControlSignals(ctx, c)
+
+	waitFor := func(paused bool) {
+		deadline := time.Now().Add(time.Second)
+		for c.paused() != paused {
+			if time.Now().After(deadline) {
+				t.Fatalf("paused = %v, want %v", c.paused(), paused)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	waitFor(true)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	waitFor(false)
+}
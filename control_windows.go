@@ -0,0 +1,7 @@
+package main
+
+import "context"
+
+// watchControlSignals is a no-op: Windows has no SIGUSR1/SIGUSR2. Use -tui
+// to pause and resume interactively.
+func watchControlSignals(ctx context.Context, control *runControl) {}
@@ -0,0 +1,202 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/adrium/goheif/heif"
+	"github.com/adrium/goheif/heif/bmff"
+)
+
+// The URNs of the auxC property that mark an auxiliary image as alpha.
+var alphaURNs = []string{"urn:mpeg:hevc:2015:auxid:1", "urn:mpeg:mpegB:cicp:systems:auxiliary:alpha"}
+
+// ParseAlpha reads an alpha policy, returning its mode and, for flatten,
+// the background.
+func ParseAlpha(s string) (mode string, background color.RGBA64, err error) {
+	white := color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}
+	switch {
+	case s == "png", s == "drop", s == "flatten":
+		return s, white, nil
+	case strings.HasPrefix(s, "flatten:"):
+		var r, g, b uint8
+		hex := strings.TrimPrefix(s, "flatten:")
+		if n, err := fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b); err != nil || n != 3 || len(hex) != 7 {
+			return "", white, fmt.Errorf("invalid background %q", hex)
+		}
+		return "flatten", color.RGBA64{uint16(r) * 0x101, uint16(g) * 0x101, uint16(b) * 0x101, 0xffff}, nil
+	}
+	return "", white, errors.New("unknown mode")
+}
+
+func (o Options) alphaMode() string {
+	mode, _, _ := ParseAlpha(o.Alpha)
+	return mode
+}
+
+// alphaItem finds the alpha plane of the primary image of hf: an
+// auxiliary image marked as alpha that refers to it.
+func alphaItem(r io.ReaderAt, hf *heif.File) (*heif.Item, bool) {
+	for _, item := range referringItems(r, hf, "auxl") {
+		if isAlpha(item) {
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// referringItems lists the items of hf with a reference of refType to
+// its primary image, such as its thumbnails or auxiliary images. goheif
+// only reads the references from an item, so this parses the iref box.
+func referringItems(r io.ReaderAt, hf *heif.File, refType string) []*heif.Item {
+	primary, err := hf.PrimaryItem()
+	if err != nil {
+		return nil
+	}
+	bmr := bmff.NewReader(io.NewSectionReader(r, 0, 5<<40))
+	if _, err := bmr.ReadAndParseBox(bmff.TypeFtyp); err != nil {
+		return nil
+	}
+	box, err := bmr.ReadAndParseBox(bmff.TypeMeta)
+	if err != nil {
+		return nil
+	}
+	var items []*heif.Item
+	for _, child := range box.(*bmff.MetaBox).Children {
+		refs, err := child.Parse()
+		if err != nil {
+			continue
+		}
+		irefs, ok := refs.(*bmff.ItemReferenceBox)
+		if !ok {
+			continue
+		}
+		for _, ref := range irefs.ItemRefs {
+			if ref.Type().String() != refType || !refersTo(ref.ToItemIDs, primary.ID) {
+				continue
+			}
+			if item, err := hf.ItemByID(ref.FromItemID); err == nil {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func refersTo(ids []uint32, id uint32) bool {
+	for _, to := range ids {
+		if to == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isAlpha reports whether the auxC property of item marks it as alpha.
+func isAlpha(item *heif.Item) bool {
+	for _, p := range item.Properties {
+		if !p.Type().EqualString("auxC") {
+			continue
+		}
+		body, err := io.ReadAll(p.Body())
+		if err != nil || len(body) < 4 {
+			return false
+		}
+		urn, _, _ := strings.Cut(string(body[4:]), "\x00")
+		for _, u := range alphaURNs {
+			if urn == u {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasAlpha reports whether the HEIC in r has transparency.
+func HasAlpha(r io.ReaderAt) bool {
+	_, ok := alphaItem(r, heif.Open(r))
+	return ok
+}
+
+// withAlpha adds the alpha plane of the HEIC in r to img, decoded from it,
+// unless the drop policy ignores it. Outputs without transparency flatten
+// it.
+func withAlpha(r io.ReaderAt, img image.Image, o Options) (image.Image, error) {
+	if o.alphaMode() == "drop" {
+		return img, nil
+	}
+	hf := heif.Open(r)
+	item, ok := alphaItem(r, hf)
+	if !ok {
+		return img, nil
+	}
+	mask, err := decodeItem16(hf, item)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the alpha plane: %v", err)
+	}
+	b := img.Bounds()
+	if mask.Bounds().Size() != b.Size() {
+		return nil, errors.New("the alpha plane doesn't match the image size")
+	}
+	// The plane is a monochrome picture: its red is its luma.
+	alphaAt := func(x, y int) uint16 { return mask.RGBA64At(x-b.Min.X, y-b.Min.Y).R }
+	if deep, ok := img.(*image.RGBA64); ok {
+		out := image.NewNRGBA64(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := deep.RGBA64At(x, y)
+				out.SetNRGBA64(x, y, color.NRGBA64{c.R, c.G, c.B, alphaAt(x, y)})
+			}
+		}
+		return out, nil
+	}
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			out.SetNRGBA(x, y, color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), uint8(alphaAt(x, y) >> 8)})
+		}
+	}
+	return out, nil
+}
+
+// flattenAlpha lays an image that withAlpha gave transparency onto the
+// background of the alpha policy, for outputs that can't keep it.
+func flattenAlpha(img image.Image, o Options) image.Image {
+	_, bg, _ := ParseAlpha(o.Alpha)
+	b := img.Bounds()
+	over := func(x, y int) color.RGBA64 {
+		// At gives premultiplied colors: the background fills the rest.
+		r, g, bl, a := img.At(x, y).RGBA()
+		return color.RGBA64{
+			uint16(r + uint32(bg.R)*(0xffff-a)/0xffff),
+			uint16(g + uint32(bg.G)*(0xffff-a)/0xffff),
+			uint16(bl + uint32(bg.B)*(0xffff-a)/0xffff),
+			0xffff,
+		}
+	}
+	switch img.(type) {
+	case *image.NRGBA64:
+		out := image.NewRGBA64(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				out.SetRGBA64(x, y, over(x, y))
+			}
+		}
+		return out
+	case *image.NRGBA:
+		out := image.NewRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := over(x, y)
+				out.SetRGBA(x, y, color.RGBA{uint8(c.R >> 8), uint8(c.G >> 8), uint8(c.B >> 8), 0xff})
+			}
+		}
+		return out
+	}
+	return img
+}
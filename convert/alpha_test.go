@@ -0,0 +1,107 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+// alphaPattern is the alpha plane of alphaSample: transparent on the left,
+// opaque on the right.
+func alphaPattern(x, y int) (uint8, uint8, uint8) {
+	return uint8(x * 255 / (heicsample.Size - 1)), 128, 128
+}
+
+// alphaSample is a HEIC file whose picture has an alpha plane.
+func alphaSample() []byte {
+	p := heicsample.NewPicture(heicsample.Size, heicsample.Size, 8, heicsample.Pattern)
+	a := heicsample.NewPicture(heicsample.Size, heicsample.Size, 8, alphaPattern)
+	alpha := heicsample.PictureItem(a, true)
+	alpha.Auxl = 1
+	alpha.Props = append(alpha.Props, heicsample.FullBox("auxC", 0, 0, []byte(alphaURNs[0]+"\x00")))
+	return heicsample.File([]heicsample.Item{heicsample.PictureItem(p, false), alpha})
+}
+
+func TestParseAlpha(t *testing.T) {
+	for _, tc := range []struct {
+		in, mode string
+		bg       color.RGBA64
+		ok       bool
+	}{
+		{"flatten:#ffffff", "flatten", color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}, true},
+		{"flatten:#ff8000", "flatten", color.RGBA64{0xffff, 0x8080, 0, 0xffff}, true},
+		{"flatten", "flatten", color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}, true},
+		{"png", "png", color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}, true},
+		{"drop", "drop", color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}, true},
+		{"flatten:#fff", "", color.RGBA64{}, false},
+		{"flatten:white", "", color.RGBA64{}, false},
+		{"webp", "", color.RGBA64{}, false},
+	} {
+		mode, bg, err := ParseAlpha(tc.in)
+		if (err == nil) != tc.ok || (tc.ok && (mode != tc.mode || bg != tc.bg)) {
+			t.Errorf("%s: got %s, %v, %v", tc.in, mode, bg, err)
+		}
+	}
+}
+
+func TestWithAlpha(t *testing.T) {
+	file := alphaSample()
+	img, err := decodeAnyDepth(context.Background(), bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := withAlpha(bytes.NewReader(file), img, Options{Alpha: "drop"}); err != nil || got != img {
+		t.Errorf("drop: got a %T, %v", got, err)
+	}
+
+	got, err := withAlpha(bytes.NewReader(file), img, Options{Alpha: "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrgba, ok := got.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("got a %T", got)
+	}
+	if a := nrgba.NRGBAAt(0, 5).A; a > 2 {
+		t.Errorf("left edge alpha %d, want 0", a)
+	}
+	if a := nrgba.NRGBAAt(heicsample.Size-1, 5).A; a < 253 {
+		t.Errorf("right edge alpha %d, want 255", a)
+	}
+
+	red := Options{Alpha: "flatten:#ff0000"}
+	flat := flattenAlpha(got, red)
+	if c := color.RGBAModel.Convert(flat.At(0, 5)).(color.RGBA); c.R < 253 || c.G > 2 || c.B > 2 || c.A != 255 {
+		t.Errorf("transparent pixel flattened to %v, want the red background", c)
+	}
+	r, g, b, _ := img.At(heicsample.Size-1, 5).RGBA()
+	if c := color.RGBAModel.Convert(flat.At(heicsample.Size-1, 5)).(color.RGBA); absDiff(c.R, uint8(r>>8)) > 3 || absDiff(c.G, uint8(g>>8)) > 3 || absDiff(c.B, uint8(b>>8)) > 3 {
+		t.Errorf("opaque pixel flattened to %v", c)
+	}
+	if flattenAlpha(img, red) != img {
+		t.Error("an opaque image was flattened")
+	}
+
+	if got, err := withAlpha(bytes.NewReader(heicsample.Single(8)), img, DefaultOptions()); err != nil || got != img {
+		t.Errorf("a file without alpha got a %T, %v", got, err)
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestHasAlpha(t *testing.T) {
+	with, without := bytes.NewReader(alphaSample()), bytes.NewReader(heicsample.Single(8))
+	if !HasAlpha(with) || HasAlpha(without) {
+		t.Errorf("HasAlpha: %v and %v", HasAlpha(with), HasAlpha(without))
+	}
+}
@@ -0,0 +1,105 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// The qualities AutoQuality chooses from.
+const (
+	autoQualityMin = 40
+	autoQualityMax = 95
+)
+
+// Luma is the Y channel of an image, which SSIM is computed on.
+type Luma struct {
+	Pix           []uint8
+	Stride        int
+	Width, Height int
+}
+
+// LumaOf reads the Y channel of img, sharing the pixels of YCbCr and gray
+// images.
+func LumaOf(img image.Image) Luma {
+	b := img.Bounds()
+	if y, ok := img.(*image.YCbCr); ok {
+		return Luma{Pix: y.Y[y.YOffset(b.Min.X, b.Min.Y):], Stride: y.YStride, Width: b.Dx(), Height: b.Dy()}
+	}
+	if g, ok := img.(*image.Gray); ok {
+		return Luma{Pix: g.Pix[g.PixOffset(b.Min.X, b.Min.Y):], Stride: g.Stride, Width: b.Dx(), Height: b.Dy()}
+	}
+	l := Luma{Pix: make([]uint8, b.Dx()*b.Dy()), Stride: b.Dx(), Width: b.Dx(), Height: b.Dy()}
+	for y := 0; y < l.Height; y++ {
+		for x := 0; x < l.Width; x++ {
+			l.Pix[y*l.Stride+x] = color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+		}
+	}
+	return l
+}
+
+// SSIM is the mean structural similarity of two planes of the same size
+// over 8x8 windows: 1 for identical images, lower as they differ.
+func SSIM(a, b Luma) float64 {
+	const (
+		window = 8
+		c1     = (0.01 * 255) * (0.01 * 255)
+		c2     = (0.03 * 255) * (0.03 * 255)
+	)
+	var total float64
+	windows := 0
+	for y0 := 0; y0+window <= a.Height; y0 += window {
+		for x0 := 0; x0+window <= a.Width; x0 += window {
+			var sa, sb, saa, sbb, sab float64
+			for y := y0; y < y0+window; y++ {
+				ra := a.Pix[y*a.Stride+x0 : y*a.Stride+x0+window]
+				rb := b.Pix[y*b.Stride+x0 : y*b.Stride+x0+window]
+				for i := range ra {
+					va, vb := float64(ra[i]), float64(rb[i])
+					sa += va
+					sb += vb
+					saa += va * va
+					sbb += vb * vb
+					sab += va * vb
+				}
+			}
+			const n = window * window
+			ma, mb := sa/n, sb/n
+			va, vb := saa/n-ma*ma, sbb/n-mb*mb
+			cov := sab/n - ma*mb
+			total += (2*ma*mb + c1) * (2*cov + c2) / ((ma*ma + mb*mb + c1) * (va + vb + c2))
+			windows++
+		}
+	}
+	if windows == 0 {
+		return 1
+	}
+	return total / float64(windows)
+}
+
+// chooseQuality binary searches for the lowest quality whose JPEG keeps
+// at least target SSIM against img. It returns autoQualityMax when even
+// that falls short.
+func chooseQuality(img image.Image, target float64) (int, error) {
+	source := LumaOf(img)
+	var buf bytes.Buffer
+	lo, hi := autoQualityMin, autoQualityMax
+	for lo < hi {
+		q := (lo + hi) / 2
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: q}); err != nil {
+			return 0, err
+		}
+		decoded, err := jpeg.Decode(&buf)
+		if err != nil {
+			return 0, err
+		}
+		if SSIM(source, LumaOf(decoded)) >= target {
+			hi = q
+		} else {
+			lo = q + 1
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func testPhoto(w, h int, noise bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / w)
+			if noise {
+				v = uint8(r.Intn(256))
+			}
+			img.SetRGBA(x, y, color.RGBA{v, v / 2, 255 - v, 255})
+		}
+	}
+	return img
+}
+
+func TestSSIM(t *testing.T) {
+	a := LumaOf(testPhoto(64, 64, false))
+	if got := SSIM(a, a); got < 0.9999 {
+		t.Errorf("ssim of an image with itself = %v, want 1", got)
+	}
+	if got := SSIM(a, LumaOf(testPhoto(64, 64, true))); got > 0.5 {
+		t.Errorf("ssim of unrelated images = %v, want it low", got)
+	}
+}
+
+func TestChooseQuality(t *testing.T) {
+	smooth := testPhoto(128, 128, false)
+	loose, err := chooseQuality(smooth, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strict, err := chooseQuality(smooth, 0.999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loose < autoQualityMin || strict > autoQualityMax || loose > strict {
+		t.Errorf("qualities %d (SSIM 0.9) and %d (SSIM 0.999) should be ordered within %d-%d", loose, strict, autoQualityMin, autoQualityMax)
+	}
+	if loose != autoQualityMin {
+		t.Errorf("a smooth gradient should need only the lowest quality at SSIM 0.9, got %d", loose)
+	}
+}
@@ -0,0 +1,95 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/internal/longpath"
+)
+
+// Files converts the HEIC files inputs to JPEGs in dir, named after them,
+// o.Workers at a time, and reports each to the observers as it's done.
+// Inputs of the same name from different folders get -2, -3 and so on.
+// Once ctx is done, the files not yet started are left out.
+func Files(ctx context.Context, inputs []string, dir string, o Options, observers ...Observer) Summary {
+	started := time.Now()
+	for _, obs := range observers {
+		obs.OnStart(len(inputs))
+	}
+	// A folder that can't be made fails each file.
+	mkdirErr := os.MkdirAll(longpath.Path(dir), 0755)
+	outputs := outputNames(inputs)
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range inputs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make(chan ConversionResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				input, output := inputs[i], filepath.Join(dir, outputs[i])
+				if mkdirErr != nil {
+					results <- ConversionResult{Name: filepath.Base(input), Input: input, Output: output, Err: mkdirErr}
+					continue
+				}
+				r, _ := File(ctx, input, output, o)
+				results <- r
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var s Summary
+	for r := range results {
+		s.Add(r)
+		for _, obs := range observers {
+			obs.OnFileDone(r)
+		}
+	}
+	s.Duration = time.Since(started)
+	for _, obs := range observers {
+		obs.OnFinish(s)
+	}
+	return s
+}
+
+// outputNames names the JPEG of each of inputs after it, numbering the
+// ones whose name is taken, ignoring case as Windows and macOS do.
+func outputNames(inputs []string) []string {
+	names := make([]string, len(inputs))
+	taken := make(map[string]bool, len(inputs))
+	for i, input := range inputs {
+		base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+		name := base + ".jpg"
+		for n := 2; taken[strings.ToLower(name)]; n++ {
+			name = base + "-" + strconv.Itoa(n) + ".jpg"
+		}
+		taken[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+// recorder lists the calls an observer gets.
+type recorder struct {
+	calls   []string
+	results []ConversionResult
+}
+
+func (r *recorder) OnStart(total int) { r.calls = append(r.calls, fmt.Sprintf("start %d", total)) }
+
+func (r *recorder) OnFileDone(result ConversionResult) {
+	r.calls = append(r.calls, "done")
+	r.results = append(r.results, result)
+}
+
+func (r *recorder) OnFinish(s Summary) {
+	r.calls = append(r.calls, fmt.Sprintf("finish %d/%d", s.Files-s.Failed, s.Files))
+}
+
+func TestFiles(t *testing.T) {
+	src, dir := t.TempDir(), filepath.Join(t.TempDir(), "jpegs")
+	p := heicsample.NewPicture(heicsample.Size, heicsample.Size, 8, heicsample.Pattern)
+	sample := heicsample.File([]heicsample.Item{heicsample.PictureItem(p, false)})
+	inputs := []string{
+		filepath.Join(src, "a", "IMG_1.heic"),
+		filepath.Join(src, "b", "IMG_1.HEIC"),
+		filepath.Join(src, "bad.heic"),
+	}
+	for i, input := range inputs {
+		data := sample
+		if i == 2 {
+			data = []byte("not a heic file")
+		}
+		os.MkdirAll(filepath.Dir(input), 0755)
+		if err := os.WriteFile(input, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := DefaultOptions()
+	o.Workers = 2
+	var r recorder
+	s := Files(context.Background(), inputs, dir, o, &r)
+	if want := []string{"start 3", "done", "done", "done", "finish 2/3"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("observer calls = %v, want %v", r.calls, want)
+	}
+	if s.Files != 3 || s.Failed != 1 {
+		t.Errorf("summary = %+v, want 3 files, 1 failed", s)
+	}
+	for _, name := range []string{"IMG_1.jpg", "IMG_1-2.jpg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFilesWithoutOutputFolder(t *testing.T) {
+	// A file where the folder should be.
+	dir := filepath.Join(t.TempDir(), "jpegs")
+	os.WriteFile(dir, nil, 0644)
+	inputs := []string{"IMG_1.heic", "IMG_2.heic"}
+	var r recorder
+	s := Files(context.Background(), inputs, dir, DefaultOptions(), &r)
+	if s.Files != len(inputs) || s.Failed != len(inputs) || len(r.results) != len(inputs) {
+		t.Errorf("summary = %+v after %d results, want every file failed", s, len(r.results))
+	}
+	for _, result := range r.results {
+		if result.Err == nil {
+			t.Errorf("%s: no error", result.Input)
+		}
+	}
+}
+
+func TestOutputNames(t *testing.T) {
+	got := outputNames([]string{"a/IMG_1.heic", "b/IMG_1.heic", "IMG_1-2.heic", "c/img_1.HEIC"})
+	if want := []string{"IMG_1.jpg", "IMG_1-2.jpg", "IMG_1-2-2.jpg", "img_1-3.jpg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("outputNames = %v, want %v", got, want)
+	}
+}
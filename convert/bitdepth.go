@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"context"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+)
+
+// isDeep reports whether img has 16 bits per channel.
+func isDeep(img image.Image) bool {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64:
+		return true
+	}
+	return false
+}
+
+// atBitDepth gives img the depth PNG and TIFF files are written at: 8
+// bits, 16, or for keep, that of the source.
+func atBitDepth(img image.Image, depth string) image.Image {
+	deep := isDeep(img)
+	var dst draw.Image
+	switch {
+	case depth == "8" && deep:
+		dst = image.NewRGBA(img.Bounds())
+	case depth == "16" && !deep:
+		dst = image.NewRGBA64(img.Bounds())
+	default:
+		return img
+	}
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	return dst
+}
+
+// decodeAnyDepth decodes the HEIC in r like decodeHeic, except for
+// sources of more than 8 bits, which goheif's decoder garbles: those
+// decode to 16-bit RGB.
+func decodeAnyDepth(ctx context.Context, r io.ReaderAt) (image.Image, error) {
+	if depth, err := SourceDepth(r); err == nil && depth > 8 {
+		return decodeWithTimeout(ctx, func() (image.Image, error) {
+			img, err := decodeHeic16(r)
+			if err != nil {
+				return nil, err
+			}
+			return img, nil
+		})
+	}
+	return decodeHeic(ctx, io.NewSectionReader(r, 0, math.MaxInt64))
+}
@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+func TestAtBitDepth(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	rgba64 := image.NewRGBA64(image.Rect(0, 0, 2, 2))
+	for _, tc := range []struct {
+		depth string
+		img   image.Image
+		deep  bool
+	}{
+		{"8", rgba, false},
+		{"8", rgba64, false},
+		{"16", rgba, true},
+		{"16", rgba64, true},
+		{"keep", rgba, false},
+		{"keep", rgba64, true},
+	} {
+		if _, deep := atBitDepth(tc.img, tc.depth).(*image.RGBA64); deep != tc.deep {
+			t.Errorf("bit depth %s of a %T: 16-bit %v", tc.depth, tc.img, deep)
+		}
+	}
+}
+
+func TestDeepPNG(t *testing.T) {
+	img, err := decodeAnyDepth(context.Background(), bytes.NewReader(heicsample.Single(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for depth, want := range map[string]byte{"8": 8, "keep": 16} {
+		o := DefaultOptions()
+		o.BitDepth = depth
+		var buf bytes.Buffer
+		if err := EncodePNG(&buf, img, nil, o); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.Bytes()[24]; got != want {
+			t.Errorf("bit depth %s wrote %d-bit samples", depth, got)
+		}
+		if _, err := png.Decode(&buf); err != nil {
+			t.Error(err)
+		}
+	}
+}
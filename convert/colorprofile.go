@@ -0,0 +1,216 @@
+package convert
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/adrium/goheif/heif"
+)
+
+var errBadProfile = errors.New("malformed color profile")
+
+// ProfileStatus tells what became of a file's color profile when it
+// isn't sRGB; sRGB and untagged files have none.
+type ProfileStatus string
+
+const (
+	ProfileNone      ProfileStatus = ""
+	ProfileConverted ProfileStatus = "converted" // converted to the color target
+	ProfileEmbedded  ProfileStatus = "embedded"  // kept as it is, in the output
+	ProfileDropped   ProfileStatus = "dropped"   // left out: the colors look off
+)
+
+// ProfileCounts tallies the non-sRGB profiles of a batch.
+type ProfileCounts struct {
+	Converted int `json:"converted"`
+	Embedded  int `json:"embedded"`
+	Dropped   int `json:"dropped"`
+}
+
+func (c *ProfileCounts) add(s ProfileStatus) {
+	switch s {
+	case ProfileConverted:
+		c.Converted++
+	case ProfileEmbedded:
+		c.Embedded++
+	case ProfileDropped:
+		c.Dropped++
+	}
+}
+
+// Profile is what became of the color profile of a file that isn't sRGB,
+// and the profile its outputs embed.
+type Profile struct {
+	Name   string // e.g. "Display P3"
+	Status ProfileStatus
+	ICC    []byte
+}
+
+// colorProfile is the colr property of a HEIC's primary image, from an
+// ICC profile or from the code points of an nclx one.
+type colorProfile struct {
+	name  string
+	srgb  bool
+	icc   []byte      // nil for nclx
+	space *colorSpace // nil when the conversion doesn't know it
+}
+
+// nclxPrimaries are the colour_primaries of ITU-T H.273 that phones use.
+var nclxPrimaries = map[uint16]struct {
+	name string
+	c    chromaticities
+}{
+	1:  {"sRGB", srgbPrimaries},
+	9:  {"BT.2020", bt2020Primaries},
+	11: {"DCI-P3", dciP3Primaries},
+	12: {"Display P3", displayP3Primaries},
+}
+
+// nclxCurves are the transfer_characteristics of ITU-T H.273 that the
+// conversion knows. The HDR ones, PQ and HLG, would need tone mapping.
+var nclxCurves = map[uint16]transfer{
+	1:  bt709Curve,
+	2:  srgbCurve, // unspecified
+	4:  gammaCurve(2.2),
+	5:  gammaCurve(2.8),
+	6:  bt709Curve,
+	8:  gammaCurve(1),
+	13: srgbCurve,
+	14: bt709Curve,
+	15: bt709Curve,
+}
+
+// readColorProfile reads the color profile of the primary image of the HEIC
+// in r, reporting false when it has none.
+func readColorProfile(r io.ReaderAt) (colorProfile, bool, error) {
+	it, err := heif.Open(r).PrimaryItem()
+	if err != nil {
+		return colorProfile{}, false, err
+	}
+	for _, p := range it.Properties {
+		if !p.Type().EqualString("colr") {
+			continue
+		}
+		body, err := io.ReadAll(p.Body())
+		if err != nil {
+			return colorProfile{}, false, err
+		}
+		return parseColr(body)
+	}
+	return colorProfile{}, false, nil
+}
+
+// parseColr reads the body of a colr box.
+func parseColr(body []byte) (colorProfile, bool, error) {
+	if len(body) < 4 {
+		return colorProfile{}, false, errBadProfile
+	}
+	switch string(body[:4]) {
+	case "nclx":
+		if len(body) < 10 {
+			return colorProfile{}, false, errBadProfile
+		}
+		code := binary.BigEndian.Uint16(body[4:6])
+		if code == 2 {
+			return colorProfile{}, false, nil // unspecified
+		}
+		primaries, known := nclxPrimaries[code]
+		p := colorProfile{name: primaries.name, srgb: code == 1}
+		if !known {
+			p.name = fmt.Sprintf("primaries %d", code)
+		}
+		if curve, ok := nclxCurves[binary.BigEndian.Uint16(body[6:8])]; known && ok {
+			p.space = newColorSpace(primaries.c, curve)
+		}
+		return p, true, nil
+	case "prof", "rICC":
+		icc := body[4:]
+		tags, err := iccTags(icc)
+		if err != nil {
+			return colorProfile{}, false, err
+		}
+		name, err := iccDescription(tags)
+		if err != nil {
+			return colorProfile{}, false, err
+		}
+		p := colorProfile{name: name, icc: icc, space: iccSpace(icc, tags)}
+		switch {
+		case strings.Contains(name, "sRGB"), strings.Contains(name, "61966-2"):
+			p.srgb = true
+		case name == "":
+			p.name = "ICC"
+		}
+		return p, true, nil
+	}
+	return colorProfile{}, false, nil
+}
+
+// wideProfile reports the color profile of the HEIC in r when it isn't
+// sRGB. A profile that can't be read is taken for sRGB rather than
+// failing the conversion.
+func wideProfile(r io.ReaderAt) (colorProfile, bool) {
+	p, ok, err := readColorProfile(r)
+	return p, err == nil && ok && !p.srgb
+}
+
+// colorPlan is what a conversion does with a profile: convert the pixels
+// to target, when set, and embed icc in the output.
+type colorPlan struct {
+	target *colorSpace
+	icc    []byte
+	status ProfileStatus
+}
+
+// planColors follows the color target for p. Where the pixels can't be
+// converted, or the space isn't known, the profile is embedded instead,
+// so the colors are still right in viewers that manage them.
+func planColors(p colorProfile, target string, canConvert bool) colorPlan {
+	if canConvert && p.space != nil {
+		switch target {
+		case "srgb":
+			// No profile: viewers take untagged images for sRGB.
+			return colorPlan{target: srgbSpace, status: ProfileConverted}
+		case "p3":
+			return colorPlan{target: displayP3Space, icc: displayP3Space.iccProfile("Display P3"), status: ProfileConverted}
+		}
+	}
+	switch {
+	case p.icc != nil:
+		return colorPlan{icc: p.icc, status: ProfileEmbedded}
+	case p.space != nil:
+		return colorPlan{icc: p.space.iccProfile(p.name), status: ProfileEmbedded}
+	}
+	return colorPlan{status: ProfileDropped}
+}
+
+// convertColors applies the color target to img, decoded from the HEIC in
+// r, and returns what became of its profile.
+func convertColors(r io.ReaderAt, img image.Image, o Options) (image.Image, Profile) {
+	p, ok := wideProfile(r)
+	if !ok {
+		return img, Profile{}
+	}
+	plan := planColors(p, o.ColorTarget, true)
+	profile := Profile{p.name, plan.status, plan.icc}
+	switch deep, _ := img.(*image.RGBA64); {
+	case plan.target == nil:
+		return img, profile
+	case deep != nil:
+		return convertSpace64(deep, p.space, plan.target), profile
+	}
+	return convertSpace(img, p.space, plan.target), profile
+}
+
+// ProfileOf tells what a conversion with o does with the color profile of
+// the HEIC in r, without decoding it.
+func ProfileOf(r io.ReaderAt, o Options) Profile {
+	p, ok := wideProfile(r)
+	if !ok {
+		return Profile{}
+	}
+	return Profile{Name: p.name, Status: planColors(p, o.ColorTarget, true).status}
+}
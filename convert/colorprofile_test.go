@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+// nclx is the body of an nclx colr box with the given primaries and the
+// sRGB transfer curve, or another.
+func nclx(primaries uint16, curve ...uint16) []byte {
+	transfer := uint16(13)
+	if len(curve) > 0 {
+		transfer = curve[0]
+	}
+	return []byte{'n', 'c', 'l', 'x', byte(primaries >> 8), byte(primaries), byte(transfer >> 8), byte(transfer), 0, 1, 0x80}
+}
+
+func TestParseColr(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		body     []byte
+		ok, srgb bool
+		profile  string
+		convert  bool
+	}{
+		{"nclx sRGB", nclx(1), true, true, "sRGB", true},
+		{"nclx unspecified", nclx(2), false, false, "", false},
+		{"nclx Display P3", nclx(12), true, false, "Display P3", true},
+		{"nclx BT.2020", nclx(9, 14), true, false, "BT.2020", true},
+		{"nclx BT.2020 PQ", nclx(9, 16), true, false, "BT.2020", false},
+		{"nclx other", nclx(22), true, false, "primaries 22", false},
+		{"ICC v2 sRGB", append([]byte("prof"), iccWithDesc("sRGB IEC61966-2.1", false)...), true, true, "sRGB IEC61966-2.1", false},
+		{"ICC v4 Display P3", append([]byte("prof"), displayP3Space.iccProfile("Display P3")...), true, false, "Display P3", true},
+		{"ICC Adobe RGB", append([]byte("rICC"), iccWithDesc("Adobe RGB (1998)", false)...), true, false, "Adobe RGB (1998)", false},
+		{"unknown", []byte("abcd"), false, false, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok, err := parseColr(tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tc.ok || p.srgb != tc.srgb || p.name != tc.profile || (p.space != nil) != tc.convert {
+				t.Errorf("got %+v, %v", p, ok)
+			}
+		})
+	}
+	if _, _, err := parseColr(append([]byte("prof"), 1, 2, 3)); err == nil {
+		t.Error("truncated ICC profile parsed")
+	}
+}
+
+func TestPlanColors(t *testing.T) {
+	p3, _, _ := parseColr(nclx(12))
+	adobe, _, _ := parseColr(append([]byte("prof"), iccWithDesc("Adobe RGB (1998)", false)...))
+	pq, _, _ := parseColr(nclx(9, 16))
+
+	for _, tc := range []struct {
+		target     string
+		p          colorProfile
+		canConvert bool
+		converted  bool
+		status     ProfileStatus
+		icc        bool
+	}{
+		{"srgb", p3, true, true, ProfileConverted, false},
+		{"p3", p3, true, true, ProfileConverted, true},
+		{"keep", p3, true, false, ProfileEmbedded, true},
+		{"srgb", p3, false, false, ProfileEmbedded, true},
+		{"srgb", adobe, true, false, ProfileEmbedded, true},
+		{"srgb", pq, true, false, ProfileDropped, false},
+		{"keep", pq, true, false, ProfileDropped, false},
+	} {
+		plan := planColors(tc.p, tc.target, tc.canConvert)
+		if (plan.target != nil) != tc.converted || plan.status != tc.status || (plan.icc != nil) != tc.icc {
+			t.Errorf("%s of %s (convert %v): got %+v", tc.target, tc.p.name, tc.canConvert, plan)
+		}
+	}
+	if plan := planColors(adobe, "keep", true); !bytes.Equal(plan.icc, adobe.icc) {
+		t.Error("keep didn't embed the file's own profile")
+	}
+}
+
+func TestConvertColors(t *testing.T) {
+	p := heicsample.NewPicture(heicsample.Size, heicsample.Size, 8, heicsample.Pattern)
+	item := heicsample.PictureItem(p, false)
+	item.Props = append(item.Props, heicsample.Box("colr", nclx(12)))
+	file := heicsample.File([]heicsample.Item{item})
+	img, err := decodeHeic(context.Background(), bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"keep", "srgb", "p3"} {
+		got, profile := convertColors(bytes.NewReader(file), img, Options{ColorTarget: target})
+		want := ProfileConverted
+		if target == "keep" {
+			want = ProfileEmbedded
+		}
+		if profile.Name != "Display P3" || profile.Status != want {
+			t.Errorf("%s: got %+v, want %s", target, profile, want)
+		}
+		if (profile.ICC != nil) != (target != "srgb") {
+			t.Errorf("%s: embeds a profile: %v", target, profile.ICC != nil)
+		}
+		if _, converted := got.(*image.RGBA); converted != (target != "keep") {
+			t.Errorf("%s: got a %T", target, got)
+		}
+	}
+
+	if _, profile := convertColors(bytes.NewReader(heicsample.Single(8)), img, DefaultOptions()); profile.Status != ProfileNone || profile.ICC != nil {
+		t.Errorf("untagged file recorded %+v", profile)
+	}
+}
@@ -0,0 +1,263 @@
+package convert
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// chromaticities are the xy coordinates of the red, green and blue
+// primaries and of the white point of an RGB color space.
+type chromaticities struct {
+	r, g, b, w [2]float64
+}
+
+var (
+	d65 = [2]float64{0.3127, 0.3290}
+
+	srgbPrimaries      = chromaticities{[2]float64{0.64, 0.33}, [2]float64{0.30, 0.60}, [2]float64{0.15, 0.06}, d65}
+	displayP3Primaries = chromaticities{[2]float64{0.680, 0.320}, [2]float64{0.265, 0.690}, [2]float64{0.150, 0.060}, d65}
+	dciP3Primaries     = chromaticities{[2]float64{0.680, 0.320}, [2]float64{0.265, 0.690}, [2]float64{0.150, 0.060}, [2]float64{0.314, 0.351}}
+	bt2020Primaries    = chromaticities{[2]float64{0.708, 0.292}, [2]float64{0.170, 0.797}, [2]float64{0.131, 0.046}, d65}
+)
+
+// d50 is the white of the ICC connection space, in XYZ.
+var d50 = [3]float64{0.9642, 1, 0.8249}
+
+// transfer is a transfer curve in the form of the parametric curves of
+// ICC profiles: (a·v+b)^g+e from d up, c·v+f below.
+type transfer struct {
+	g, a, b, c, d, e, f float64
+}
+
+var (
+	srgbCurve  = transfer{g: 2.4, a: 1 / 1.055, b: 0.055 / 1.055, c: 1 / 12.92, d: 0.04045}
+	bt709Curve = transfer{g: 1 / 0.45, a: 1 / 1.099, b: 0.099 / 1.099, c: 1 / 4.5, d: 0.081}
+)
+
+func gammaCurve(g float64) transfer {
+	return transfer{g: g, a: 1}
+}
+
+// linear decodes v, from 0 to 1, to linear light.
+func (t transfer) linear(v float64) float64 {
+	if v < t.d {
+		return t.c*v + t.f
+	}
+	base := t.a*v + t.b
+	if base <= 0 {
+		return t.e
+	}
+	return math.Pow(base, t.g) + t.e
+}
+
+// colorSpace is what converting pixels needs of an RGB space: how its
+// values decode to linear light, and the matrix from there to the XYZ of
+// the ICC connection space.
+type colorSpace struct {
+	toXYZ  [3][3]float64
+	linear [3][256]float64 // per channel, indexed by the 8-bit value
+	// white and curve describe spaces known by their primaries, so an
+	// ICC profile can be written for them; spaces read from a profile
+	// don't need one.
+	white [2]float64
+	curve *transfer
+}
+
+// newColorSpace is the space of the primaries c with the transfer curve t.
+func newColorSpace(c chromaticities, t transfer) *colorSpace {
+	s := &colorSpace{
+		toXYZ: mulMatrix(bradford(xyToXYZ(c.w), d50), rgbToXYZ(c)),
+		white: c.w,
+		curve: &t,
+	}
+	for i := 0; i < 256; i++ {
+		v := t.linear(float64(i) / 255)
+		s.linear[0][i], s.linear[1][i], s.linear[2][i] = v, v, v
+	}
+	return s
+}
+
+// The spaces -color-target converts to. Both use the sRGB transfer curve,
+// which encodeLinear applies.
+var (
+	srgbSpace      = newColorSpace(srgbPrimaries, srgbCurve)
+	displayP3Space = newColorSpace(displayP3Primaries, srgbCurve)
+)
+
+func xyToXYZ(p [2]float64) [3]float64 {
+	return [3]float64{p[0] / p[1], 1, (1 - p[0] - p[1]) / p[1]}
+}
+
+// rgbToXYZ is the matrix from linear RGB in the space of c to CIE XYZ,
+// relative to its own white.
+func rgbToXYZ(c chromaticities) [3][3]float64 {
+	r, g, b := xyToXYZ(c.r), xyToXYZ(c.g), xyToXYZ(c.b)
+	m := [3][3]float64{{r[0], g[0], b[0]}, {r[1], g[1], b[1]}, {r[2], g[2], b[2]}}
+	s := mulVector(invert(m), xyToXYZ(c.w))
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] *= s[j]
+		}
+	}
+	return m
+}
+
+// bradford adapts XYZ colors seen under the white from to the white to,
+// as ICC profiles adapt theirs to D50.
+func bradford(from, to [3]float64) [3][3]float64 {
+	cone := [3][3]float64{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+	src, dst := mulVector(cone, from), mulVector(cone, to)
+	scale := [3][3]float64{{dst[0] / src[0], 0, 0}, {0, dst[1] / src[1], 0}, {0, 0, dst[2] / src[2]}}
+	return mulMatrix(invert(cone), mulMatrix(scale, cone))
+}
+
+// conversionMatrix converts linear RGB from one space to another.
+func conversionMatrix(from, to *colorSpace) [3][3]float64 {
+	return mulMatrix(invert(to.toXYZ), from.toXYZ)
+}
+
+func mulMatrix(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func mulVector(m [3][3]float64, v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+func invert(m [3][3]float64) [3][3]float64 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	return [3][3]float64{
+		{(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det, (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det, (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det},
+		{(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det, (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det, (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det},
+		{(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det, (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det, (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det},
+	}
+}
+
+// srgbEncoded maps linear light, in steps of 1/4095, to 8-bit values on
+// the sRGB curve.
+var srgbEncoded = func() [4096]uint8 {
+	var encoded [4096]uint8
+	for i := range encoded {
+		v := float64(i) / 4095
+		if v <= 0.0031308 {
+			v *= 12.92
+		} else {
+			v = 1.055*math.Pow(v, 1/2.4) - 0.055
+		}
+		encoded[i] = uint8(math.Round(v * 255))
+	}
+	return encoded
+}()
+
+func encodeLinear(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 255
+	}
+	return srgbEncoded[int(v*4095+0.5)]
+}
+
+// linear16 decodes the 16-bit value v of channel ch to linear light,
+// between the entries of the 8-bit table.
+func (s *colorSpace) linear16(ch int, v uint16) float64 {
+	x := float64(v) * 255 / 0xffff
+	i := int(x)
+	if i >= 255 {
+		return s.linear[ch][255]
+	}
+	lo, hi := s.linear[ch][i], s.linear[ch][i+1]
+	return lo + (hi-lo)*(x-float64(i))
+}
+
+var (
+	srgbEncoded16     [65536]uint16
+	srgbEncoded16Once sync.Once
+)
+
+// encodeLinear16 is encodeLinear for 16-bit outputs. Its table is only
+// made when they are written.
+func encodeLinear16(v float64) uint16 {
+	srgbEncoded16Once.Do(func() {
+		for i := range srgbEncoded16 {
+			v := float64(i) / 0xffff
+			if v <= 0.0031308 {
+				v *= 12.92
+			} else {
+				v = 1.055*math.Pow(v, 1/2.4) - 0.055
+			}
+			srgbEncoded16[i] = uint16(math.Round(v * 0xffff))
+		}
+	})
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0xffff
+	}
+	return srgbEncoded16[int(v*0xffff+0.5)]
+}
+
+// convertSpace64 is convertSpace for 16-bit images, which it keeps so.
+func convertSpace64(img *image.RGBA64, from, to *colorSpace) *image.RGBA64 {
+	m := conversionMatrix(from, to)
+	b := img.Bounds()
+	out := image.NewRGBA64(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBA64At(x, y)
+			v := mulVector(m, [3]float64{from.linear16(0, c.R), from.linear16(1, c.G), from.linear16(2, c.B)})
+			out.SetRGBA64(x, y, color.RGBA64{encodeLinear16(v[0]), encodeLinear16(v[1]), encodeLinear16(v[2]), 0xffff})
+		}
+	}
+	return out
+}
+
+// convertSpace converts img from one space to another through linear
+// light. Colors outside the target space are clipped.
+func convertSpace(img image.Image, from, to *colorSpace) *image.RGBA {
+	m := conversionMatrix(from, to)
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	ycc, isYCbCr := img.(*image.YCbCr)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var r, g, bl uint8
+			if isYCbCr {
+				p := ycc.YCbCrAt(x, y)
+				r, g, bl = color.YCbCrToRGB(p.Y, p.Cb, p.Cr)
+			} else {
+				r16, g16, b16, _ := img.At(x, y).RGBA()
+				r, g, bl = uint8(r16>>8), uint8(g16>>8), uint8(b16>>8)
+			}
+			v := mulVector(m, [3]float64{from.linear[0][r], from.linear[1][g], from.linear[2][bl]})
+			i := out.PixOffset(x, y)
+			out.Pix[i+0] = encodeLinear(v[0])
+			out.Pix[i+1] = encodeLinear(v[1])
+			out.Pix[i+2] = encodeLinear(v[2])
+			out.Pix[i+3] = 0xff
+		}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package convert
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestConversionMatrix(t *testing.T) {
+	// The published Display P3 to sRGB matrix, to four places.
+	want := [3][3]float64{
+		{1.2249, -0.2247, 0},
+		{-0.0420, 1.0419, 0},
+		{-0.0197, -0.0786, 1.0979},
+	}
+	got := conversionMatrix(displayP3Space, srgbSpace)
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(got[i][j]-want[i][j]) > 0.0005 {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	}
+}
+
+func TestColorSpaceWhite(t *testing.T) {
+	// Whatever their own white, spaces map theirs to that of D50.
+	for name, c := range map[string]chromaticities{"sRGB": srgbPrimaries, "DCI-P3": dciP3Primaries, "BT.2020": bt2020Primaries} {
+		white := mulVector(newColorSpace(c, srgbCurve).toXYZ, [3]float64{1, 1, 1})
+		for i := range white {
+			if math.Abs(white[i]-d50[i]) > 0.0001 {
+				t.Errorf("%s: white is %v", name, white)
+				break
+			}
+		}
+	}
+}
+
+func TestConvertSpace(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	src.Set(0, 0, color.RGBA{128, 128, 128, 255})
+	src.Set(1, 0, color.RGBA{255, 0, 0, 255})
+	src.Set(2, 0, color.RGBA{100, 150, 80, 255})
+
+	same := convertSpace(src, srgbSpace, srgbSpace)
+	for i := range src.Pix {
+		if d := int(same.Pix[i]) - int(src.Pix[i]); d < -1 || d > 1 {
+			t.Fatalf("sRGB to sRGB changed the pixels: %v", same.Pix)
+		}
+	}
+	p3 := convertSpace(src, displayP3Space, srgbSpace)
+	if c := p3.RGBAAt(0, 0); c != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("grey became %v", c)
+	}
+	// P3 red is outside sRGB: clipped to its red.
+	if c := p3.RGBAAt(1, 0); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("red became %v", c)
+	}
+	if c := p3.RGBAAt(2, 0); c.G <= 150 || c.R >= 100 {
+		t.Errorf("green-ish %v should grow more saturated in sRGB", c)
+	}
+	// BT.2020 green is further out than P3's.
+	bt2020 := convertSpace(src, newColorSpace(bt2020Primaries, bt709Curve), displayP3Space)
+	if c := bt2020.RGBAAt(2, 0); c.G <= p3.RGBAAt(2, 0).G-40 || c.R >= 100 {
+		t.Errorf("BT.2020 green-ish became %v", c)
+	}
+}
+
+func TestConvertSpace64(t *testing.T) {
+	src := image.NewRGBA64(image.Rect(0, 0, 2, 1))
+	src.SetRGBA64(0, 0, color.RGBA64{0x8080, 0x8080, 0x8080, 0xffff})
+	src.SetRGBA64(1, 0, color.RGBA64{0x6543, 0x9876, 0x5432, 0xffff})
+
+	same := convertSpace64(src, srgbSpace, srgbSpace)
+	for x := 0; x < 2; x++ {
+		got, want := same.RGBA64At(x, 0), src.RGBA64At(x, 0)
+		for _, d := range []int{int(got.R) - int(want.R), int(got.G) - int(want.G), int(got.B) - int(want.B)} {
+			if d < -32 || d > 32 {
+				t.Fatalf("sRGB to sRGB changed %v to %v", want, got)
+			}
+		}
+	}
+	if c := convertSpace64(src, displayP3Space, srgbSpace).RGBA64At(1, 0); c.G <= 0x9876 || c.R >= 0x6543 {
+		t.Errorf("green-ish became %v", c)
+	}
+}
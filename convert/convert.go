@@ -0,0 +1,337 @@
+// Package convert converts HEIC photos to JPEG, PNG and TIFF files. It is
+// the conversion core of heictojpeg, whose command line, server and bot
+// are built on it; other programs can embed it too, and follow a batch
+// through an Observer instead of parsing logs.
+package convert
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"heictojpeg/internal/longpath"
+
+	"github.com/adrium/goheif"
+	"github.com/adrium/goheif/heif"
+)
+
+// ErrDecodeTimeout is returned for a file whose decode takes longer than
+// Options.Timeout.
+var ErrDecodeTimeout = errors.New("decode timeout")
+
+// Options are the settings of a conversion. Start from DefaultOptions:
+// the zero value writes JPEGs at quality 0.
+type Options struct {
+	Quality     int    // JPEG quality, 1 to 100
+	MaxSize     int    // longest side in pixels; 0 keeps the original size
+	Metadata    string // EXIF in the JPEGs: keep or strip
+	AutoQuality bool   // pick the lowest quality that keeps TargetSSIM instead
+	TargetSSIM  float64
+	Crop        string // W:H aspect ratio; empty keeps the whole image
+	CropFocus   string // what a crop keeps: center or subject
+	ColorTarget string // colors of photos with a profile other than sRGB: keep, srgb or p3
+	Alpha       string // transparency: flatten:#rrggbb, png or drop
+	BestFrame   bool   // convert only the best shot of a burst
+	VerifyGrid  bool   // check that the tiles of tiled photos are in place
+	LowMemory   bool   // decode tiled photos a row of tiles at a time
+	BitDepth    string // of PNG and TIFF files: 8, 16 or keep
+	Timeout     time.Duration
+	Workers     int // files Files converts at a time; 0 is one per CPU
+}
+
+// DefaultOptions are the options of heictojpeg without flags.
+func DefaultOptions() Options {
+	return Options{
+		Quality:     jpeg.DefaultQuality,
+		Metadata:    "keep",
+		TargetSSIM:  0.985,
+		CropFocus:   "center",
+		ColorTarget: "keep",
+		Alpha:       "flatten:#ffffff",
+		BitDepth:    "8",
+		Timeout:     2 * time.Minute,
+	}
+}
+
+// Decoded is a decoded HEIC file.
+type Decoded struct {
+	Image   image.Image
+	Exif    []byte  // nil when the file has none
+	Profile Profile // set when the color profile isn't sRGB
+}
+
+// Decode decodes the primary image of the HEIC in r, with its EXIF block,
+// and gives it the colors and transparency of o. A decode can't be
+// interrupted, so when it times out or ctx is done, its goroutine is
+// abandoned and exits whenever the decoder returns.
+func Decode(ctx context.Context, r io.ReaderAt, o Options) (Decoded, error) {
+	exif, err := goheif.ExtractExif(r)
+	if err != nil && !errors.Is(err, heif.ErrNoEXIF) {
+		return Decoded{}, err
+	}
+	if err := CheckGrid(r); err != nil {
+		return Decoded{}, err
+	}
+
+	decodeCtx := ctx
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		decodeCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	var img image.Image
+	if o.BestFrame {
+		img, err = decodeWithTimeout(decodeCtx, func() (image.Image, error) { return decodeBestFrame(r) })
+	} else {
+		img, err = decodeAnyDepth(decodeCtx, r)
+	}
+	if err != nil {
+		return Decoded{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Decoded{}, err
+	}
+	if o.VerifyGrid && !o.BestFrame {
+		if err := verifyGridSeams(r, img); err != nil {
+			return Decoded{}, err
+		}
+	}
+	d := Decoded{Exif: exif}
+	img, d.Profile = convertColors(r, img, o)
+	if d.Image, err = withAlpha(r, img, o); err != nil {
+		return Decoded{}, err
+	}
+	return d, nil
+}
+
+// DecodeFile is Decode for the HEIC file path.
+func DecodeFile(ctx context.Context, path string, o Options) (Decoded, error) {
+	f, err := os.Open(longpath.Path(path))
+	if err != nil {
+		return Decoded{}, err
+	}
+	defer f.Close()
+	return Decode(ctx, f, o)
+}
+
+// EncodeJPEG writes img as a JPEG, cropped and scaled down as o asks, with
+// the EXIF block exif under its metadata policy and the ICC profile icc,
+// either of which may be nil. Transparency is flattened.
+func EncodeJPEG(w io.Writer, img image.Image, exif, icc []byte, o Options) error {
+	bw := getBufferedWriter(w)
+	defer putBufferedWriter(bw)
+	exif, _ = JPEGExif(exif, o.Metadata)
+	img = FitWithin(Crop(flattenAlpha(img, o), o.Crop, o.CropFocus), o.MaxSize)
+	if o.AutoQuality && !IsBanded(img) {
+		// Banded images are decoded once, top to bottom, so they keep the
+		// configured quality.
+		q, err := chooseQuality(img, o.TargetSSIM)
+		if err != nil {
+			return err
+		}
+		o.Quality = q
+	}
+	jw, err := newWriterExif(bw, exif)
+	if err != nil {
+		return err
+	}
+	if err := writeICCSegments(bw, icc); err != nil {
+		return err
+	}
+	if err := jpeg.Encode(jw, img, &jpeg.Options{Quality: o.Quality}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// EncodePNG writes img losslessly, cropped and scaled down like the JPEGs
+// and at the bit depth of o, with the ICC profile icc when it isn't nil.
+// PNG files get no EXIF block. Transparency is kept for the png policy.
+func EncodePNG(w io.Writer, img image.Image, icc []byte, o Options) error {
+	if o.alphaMode() != "png" {
+		img = flattenAlpha(img, o)
+	}
+	bw := getBufferedWriter(w)
+	defer putBufferedWriter(bw)
+	var pw io.Writer = bw
+	if icc != nil {
+		var err error
+		if pw, err = newPNGICCWriter(bw, icc); err != nil {
+			return err
+		}
+	}
+	if err := png.Encode(pw, atBitDepth(FitWithin(Crop(img, o.Crop, o.CropFocus), o.MaxSize), o.BitDepth)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// EncodeTIFF writes img like EncodePNG, as a TIFF file.
+func EncodeTIFF(w io.Writer, img image.Image, icc []byte, o Options) error {
+	return encodeTIFF(w, atBitDepth(FitWithin(Crop(flattenAlpha(img, o), o.Crop, o.CropFocus), o.MaxSize), o.BitDepth), icc)
+}
+
+// File converts the HEIC file input to output: a JPEG, or by its
+// extension a PNG or TIFF file, which carry no EXIF. A failed output is
+// removed.
+func File(ctx context.Context, input, output string, o Options) (ConversionResult, error) {
+	started := time.Now()
+	r := ConversionResult{Name: filepath.Base(input), Input: input, Output: output}
+	r.Err = convertFile(ctx, input, output, o, &r)
+	r.Duration = time.Since(started)
+	if info, err := os.Stat(longpath.Path(input)); err == nil {
+		r.InputSize = info.Size()
+	}
+	if info, err := os.Stat(longpath.Path(output)); err == nil && r.Err == nil {
+		r.OutputSize = info.Size()
+	}
+	return r, r.Err
+}
+
+func convertFile(ctx context.Context, input, output string, o Options, r *ConversionResult) error {
+	f, err := os.Open(longpath.Path(input))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ext := strings.ToLower(filepath.Ext(output))
+	jpegOutput := ext != ".png" && ext != ".tif" && ext != ".tiff"
+	d, done := Decoded{}, func() error { return nil }
+	if o.LowMemory && jpegOutput {
+		d, done, err = DecodeBanded(ctx, f, o)
+	}
+	if !o.LowMemory || !jpegOutput || errors.Is(err, ErrNotTiled) {
+		d, err = Decode(ctx, f, o)
+	}
+	if err != nil {
+		return err
+	}
+	r.Profile, r.ColorProfile = d.Profile.Status, d.Profile.Name
+
+	out, err := os.Create(longpath.Path(output))
+	if err != nil {
+		done()
+		return err
+	}
+	switch {
+	case jpegOutput:
+		_, r.Exif = JPEGExif(d.Exif, o.Metadata)
+		err = EncodeJPEG(out, d.Image, d.Exif, d.Profile.ICC, o)
+	case ext == ".png":
+		r.Exif = ExifStripped
+		err = EncodePNG(out, d.Image, d.Profile.ICC, o)
+	default:
+		r.Exif = ExifStripped
+		err = EncodeTIFF(out, d.Image, d.Profile.ICC, o)
+	}
+	if derr := done(); err == nil {
+		err = derr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(longpath.Path(output))
+	}
+	return err
+}
+
+// decodeHeic runs goheif's decoder until ctx is done, like Decode.
+func decodeHeic(ctx context.Context, r io.Reader) (image.Image, error) {
+	return decodeWithTimeout(ctx, func() (image.Image, error) { return goheif.Decode(r) })
+}
+
+// decodeWithTimeout runs decode until ctx is done, so that a corrupt file
+// can't stall the caller.
+func decodeWithTimeout(ctx context.Context, decode func() (image.Image, error)) (image.Image, error) {
+	type decodeResult struct {
+		img image.Image
+		err error
+	}
+
+	done := make(chan decodeResult, 1)
+	go func() {
+		img, err := decode()
+		done <- decodeResult{img, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.img, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrDecodeTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// writerSkipper drops the SOI marker written by the JPEG encoder, since
+// newWriterExif has already written it. It implements WriteByte and Flush
+// so the encoder writes straight into the pooled buffer instead of
+// allocating its own.
+type writerSkipper struct {
+	w           *bufio.Writer
+	bytesToSkip int
+}
+
+func (w *writerSkipper) WriteByte(c byte) error {
+	if w.bytesToSkip > 0 {
+		w.bytesToSkip--
+		return nil
+	}
+	return w.w.WriteByte(c)
+}
+
+func (w *writerSkipper) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *writerSkipper) Write(data []byte) (int, error) {
+	if w.bytesToSkip <= 0 {
+		return w.w.Write(data)
+	}
+
+	dataLen := len(data)
+	if dataLen < w.bytesToSkip {
+		w.bytesToSkip -= dataLen
+		return dataLen, nil
+	}
+
+	n, err := w.w.Write(data[w.bytesToSkip:])
+	n += w.bytesToSkip
+	w.bytesToSkip = 0
+	return n, err
+}
+
+func newWriterExif(w *bufio.Writer, exif []byte) (io.Writer, error) {
+	writer := &writerSkipper{w, 2}
+	soi := []byte{0xff, 0xd8}
+	if _, err := w.Write(soi); err != nil {
+		return nil, err
+	}
+
+	if exif != nil {
+		app1Marker := 0xe1
+		markerlen := 2 + len(exif)
+		marker := []byte{0xff, uint8(app1Marker), uint8(markerlen >> 8), uint8(markerlen & 0xff)}
+		if _, err := w.Write(marker); err != nil {
+			return nil, err
+		}
+
+		if _, err := w.Write(exif); err != nil {
+			return nil, err
+		}
+	}
+
+	return writer, nil
+}
@@ -0,0 +1,52 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+)
+
+// blockingReader never returns, like a decoder stuck on a corrupt file
+type blockingReader struct {
+	unblock chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	<-r.unblock
+	return 0, io.EOF
+}
+
+// Testing decodeHeic function with a hanging decode
+func TestDecodeHeicTimeout(t *testing.T) {
+	r := &blockingReader{unblock: make(chan struct{})}
+	defer close(r.unblock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := decodeHeic(ctx, r)
+	if !errors.Is(err, ErrDecodeTimeout) {
+		t.Fatalf("Expected decode timeout, got %v", err)
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	o := DefaultOptions()
+	o.MaxSize = 10
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil, o); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 10 || b.Dy() != 5 {
+		t.Errorf("PNG is %v, want it scaled to 10x5", b)
+	}
+}
@@ -0,0 +1,195 @@
+package convert
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseAspect reads a W:H ratio such as 4:5.
+func ParseAspect(s string) (w, h int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) == 2 {
+		w, errW := strconv.Atoi(parts[0])
+		h, errH := strconv.Atoi(parts[1])
+		if errW == nil && errH == nil && w > 0 && h > 0 {
+			return w, h, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%q is not a ratio like 1:1 or 16:9", s)
+}
+
+// saliencyGrid is the number of cells along the longer side that
+// -crop-focus subject scores.
+const saliencyGrid = 64
+
+// Crop crops img to the aspect ratio, keeping the middle or, with the
+// subject focus, the window holding the most detail. Images decoded in
+// bands are read once, top to bottom, so they are left whole.
+func Crop(img image.Image, aspect, focus string) image.Image {
+	if aspect == "" {
+		return img
+	}
+	if _, banded := img.(*bandedImage); banded {
+		return img
+	}
+	sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	aw, ah, err := ParseAspect(aspect)
+	if !ok || err != nil {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	r := b
+	switch cropW := int(int64(h) * int64(aw) / int64(ah)); {
+	case cropW < w:
+		x := (w - cropW) / 2
+		if focus == "subject" {
+			x = subjectOffset(img, true, cropW)
+		}
+		r = image.Rect(b.Min.X+x, b.Min.Y, b.Min.X+x+cropW, b.Max.Y)
+	default:
+		cropH := int(int64(w) * int64(ah) / int64(aw))
+		if cropH >= h {
+			return img
+		}
+		y := (h - cropH) / 2
+		if focus == "subject" {
+			y = subjectOffset(img, false, cropH)
+		}
+		r = image.Rect(b.Min.X, b.Min.Y+y, b.Max.X, b.Min.Y+y+cropH)
+	}
+	return sub.SubImage(r)
+}
+
+// subjectOffset returns where a window of length pixels along the x (or
+// y) axis covers the most salient cells, preferring the middle on ties.
+func subjectOffset(img image.Image, alongX bool, length int) int {
+	scores, cell := saliency(img)
+	b := img.Bounds()
+	total, size := b.Dy(), len(scores)
+	if alongX {
+		total, size = b.Dx(), len(scores[0])
+	}
+
+	// Sum the scores across the other axis, then slide the window.
+	line := make([]float64, size+1)
+	for y, row := range scores {
+		for x, s := range row {
+			i := y
+			if alongX {
+				i = x
+			}
+			line[i+1] += s
+		}
+	}
+	for i := 1; i <= size; i++ {
+		line[i] += line[i-1]
+	}
+	window := (length + cell/2) / cell
+	if window >= size {
+		return (total - length) / 2
+	}
+	middle := float64(size-window) / 2
+	best, bestScore := 0, math.Inf(-1)
+	for i := 0; i+window <= size; i++ {
+		score := line[i+window] - line[i]
+		if score > bestScore+1e-9 || (score > bestScore-1e-9 && math.Abs(float64(i)-middle) < math.Abs(float64(best)-middle)) {
+			best, bestScore = i, score
+		}
+	}
+	if m := int(middle); line[m+window]-line[m] > bestScore-1e-9 {
+		// Nothing stands out from the middle: center exactly.
+		return (total - length) / 2
+	}
+	offset := best * cell
+	if offset+length > total {
+		offset = total - length
+	}
+	return offset
+}
+
+// saliency scores square cells of img by their contrast, with each
+// other and within, and by their share of skin-toned pixels, which
+// usually marks the faces a crop should keep.
+func saliency(img image.Image) ([][]float64, int) {
+	b := img.Bounds()
+	cell := (maxInt(b.Dx(), b.Dy()) + saliencyGrid - 1) / saliencyGrid
+	cols, rows := (b.Dx()+cell-1)/cell, (b.Dy()+cell-1)/cell
+	const samples = 4 // per side of a cell
+
+	means := make([][]float64, rows)
+	scores := make([][]float64, rows)
+	for cy := range scores {
+		means[cy] = make([]float64, cols)
+		scores[cy] = make([]float64, cols)
+		for cx := range scores[cy] {
+			var sum, sumSq, skin float64
+			n := 0
+			for sy := 0; sy < samples; sy++ {
+				y := b.Min.Y + cy*cell + (2*sy+1)*cell/(2*samples)
+				for sx := 0; sx < samples; sx++ {
+					x := b.Min.X + cx*cell + (2*sx+1)*cell/(2*samples)
+					if x >= b.Max.X || y >= b.Max.Y {
+						continue
+					}
+					r, g, bl, _ := img.At(x, y).RGBA()
+					r, g, bl = r>>8, g>>8, bl>>8
+					l := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					sum += l
+					sumSq += l * l
+					if isSkinTone(r, g, bl) {
+						skin++
+					}
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			mean := sum / float64(n)
+			means[cy][cx] = mean
+			scores[cy][cx] = math.Sqrt(math.Max(0, sumSq/float64(n)-mean*mean)) + 48*skin/float64(n)
+		}
+	}
+	for cy := range scores {
+		for cx := range scores[cy] {
+			if cx+1 < cols {
+				d := math.Abs(means[cy][cx]-means[cy][cx+1]) / 2
+				scores[cy][cx] += d
+				scores[cy][cx+1] += d
+			}
+			if cy+1 < rows {
+				d := math.Abs(means[cy][cx]-means[cy+1][cx]) / 2
+				scores[cy][cx] += d
+				scores[cy+1][cx] += d
+			}
+		}
+	}
+	return scores, cell
+}
+
+// isSkinTone is the usual RGB rule for skin in daylight.
+func isSkinTone(r, g, b uint32) bool {
+	hi, lo := r, r
+	for _, c := range []uint32{g, b} {
+		if c > hi {
+			hi = c
+		}
+		if c < lo {
+			lo = c
+		}
+	}
+	return r > 95 && g > 40 && b > 20 && hi-lo > 15 && r > g && r > b && r-g > 15
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
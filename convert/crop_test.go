@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestParseAspect(t *testing.T) {
+	if w, h, err := ParseAspect("16:9"); err != nil || w != 16 || h != 9 {
+		t.Errorf("parseAspect(16:9) = %d, %d, %v", w, h, err)
+	}
+	for _, bad := range []string{"", "1", "1:0", "a:b", "1:2:3", "-1:1"} {
+		if _, _, err := ParseAspect(bad); err == nil {
+			t.Errorf("parseAspect(%q) accepted", bad)
+		}
+	}
+}
+
+// flatPhoto is a grey w x h image with one w/4-wide square patch at x.
+func flatPhoto(w, h, x int, patch func(r *rand.Rand) color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			c := color.RGBA{90, 90, 90, 255}
+			if px >= x && px < x+w/4 && py >= h/4 && py < h/4+w/4 {
+				c = patch(r)
+			}
+			img.SetRGBA(px, py, c)
+		}
+	}
+	return img
+}
+
+func TestCropTo(t *testing.T) {
+	texture := func(r *rand.Rand) color.RGBA {
+		v := uint8(r.Intn(256))
+		return color.RGBA{v, v, v, 255}
+	}
+	skin := func(*rand.Rand) color.RGBA { return color.RGBA{224, 172, 140, 255} }
+	tests := []struct {
+		name   string
+		img    image.Image
+		aspect string
+		focus  string
+		want   image.Rectangle
+		keep   image.Rectangle // when set, the crop only has to contain it
+	}{
+		{"none", flatPhoto(400, 200, 0, texture), "", "subject", image.Rect(0, 0, 400, 200), image.Rectangle{}},
+		{"center", flatPhoto(400, 200, 0, texture), "1:1", "center", image.Rect(100, 0, 300, 200), image.Rectangle{}},
+		{"texture", flatPhoto(400, 200, 20, texture), "1:1", "subject", image.Rect(0, 0, 200, 200), image.Rect(20, 50, 120, 150)},
+		{"skin", flatPhoto(400, 200, 280, skin), "1:1", "subject", image.Rect(200, 0, 400, 200), image.Rect(280, 50, 380, 150)},
+		{"flat", flatPhoto(400, 200, 0, func(*rand.Rand) color.RGBA { return color.RGBA{90, 90, 90, 255} }), "1:1", "subject", image.Rect(100, 0, 300, 200), image.Rectangle{}},
+		{"taller", flatPhoto(200, 200, 0, texture), "2:1", "center", image.Rect(0, 50, 200, 150), image.Rectangle{}},
+		{"already", flatPhoto(300, 200, 0, texture), "3:2", "subject", image.Rect(0, 0, 300, 200), image.Rectangle{}},
+	}
+	for _, tt := range tests {
+		got := Crop(tt.img, tt.aspect, tt.focus).Bounds()
+		if !tt.keep.Empty() {
+			// The exact offset depends on the saliency grid.
+			if got.Size() != tt.want.Size() || !tt.keep.In(got) {
+				t.Errorf("%s: crop %v doesn't keep the subject at %v", tt.name, got, tt.keep)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: crop = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
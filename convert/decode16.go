@@ -0,0 +1,309 @@
+package convert
+
+// #include <stdint.h>
+// struct de265_image;
+// extern void* de265_new_decoder(void);
+// extern int de265_free_decoder(void*);
+// extern void de265_reset(void*);
+// extern int de265_push_NAL(void*, const void*, int, int64_t, void*);
+// extern int de265_flush_data(void*);
+// extern int de265_decode(void*, int*);
+// extern int de265_get_warning(void*);
+// extern const struct de265_image* de265_get_next_picture(void*);
+// extern void de265_release_next_picture(void*);
+// extern int de265_get_image_width(const struct de265_image*, int);
+// extern int de265_get_image_height(const struct de265_image*, int);
+// extern const uint8_t* de265_get_image_plane(const struct de265_image*, int, int*);
+// extern int de265_get_bits_per_pixel(const struct de265_image*, int);
+// extern int de265_get_chroma_format(const struct de265_image*);
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"unsafe"
+
+	"github.com/adrium/goheif/heif"
+)
+
+// The chroma formats of libde265.
+const (
+	de265ChromaMono = 0
+	de265Chroma420  = 1
+	de265Chroma422  = 2
+	de265Chroma444  = 3
+)
+
+// decoder16 decodes HEVC pictures at their full bit depth. goheif's
+// decoder reads every sample as a byte, which garbles 10-bit pictures, so
+// this one asks the same libde265 for the samples as they are.
+type decoder16 struct {
+	ctx unsafe.Pointer
+}
+
+func newDecoder16() (*decoder16, error) {
+	ctx := C.de265_new_decoder()
+	if ctx == nil {
+		return nil, errors.New("can't create a decoder")
+	}
+	return &decoder16{ctx}, nil
+}
+
+func (d *decoder16) free() {
+	C.de265_free_decoder(d.ctx)
+}
+
+// push feeds NAL units with four-byte lengths, as hvcC headers and HEIF
+// items hold them.
+func (d *decoder16) push(data []byte) error {
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return errors.New("invalid NAL data")
+		}
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(n) > uint64(len(data)) {
+			return fmt.Errorf("invalid NAL size: %d", n)
+		}
+		if n > 0 {
+			C.de265_push_NAL(d.ctx, unsafe.Pointer(&data[0]), C.int(n), 0, nil)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// picture is a decoded HEVC picture: 4:2:0, 4:2:2 or 4:4:4 planes of
+// samples of depth bits, or a luma plane alone.
+type picture struct {
+	width, height int
+	depth         int
+	chroma        int // a de265Chroma
+	y, cb, cr     []uint16
+	cWidth        int // width of the chroma planes
+}
+
+// decode decodes one picture: the hvcC header, then the item's data.
+func (d *decoder16) decode(header, data []byte) (*picture, error) {
+	C.de265_reset(d.ctx)
+	if err := d.push(header); err != nil {
+		return nil, err
+	}
+	if err := d.push(data); err != nil {
+		return nil, err
+	}
+	if C.de265_flush_data(d.ctx) != 0 {
+		return nil, errors.New("flush_data error")
+	}
+	more := C.int(1)
+	for more != 0 {
+		if C.de265_decode(d.ctx, &more) != 0 {
+			return nil, errors.New("decode error")
+		}
+		for C.de265_get_warning(d.ctx) != 0 {
+		}
+		if img := C.de265_get_next_picture(d.ctx); img != nil {
+			defer C.de265_release_next_picture(d.ctx)
+			return copyPicture(img)
+		}
+	}
+	return nil, errors.New("no picture")
+}
+
+// copyPicture copies the planes out of libde265, widening 8-bit samples.
+func copyPicture(img *C.struct_de265_image) (*picture, error) {
+	p := &picture{
+		width:  int(C.de265_get_image_width(img, 0)),
+		height: int(C.de265_get_image_height(img, 0)),
+		depth:  int(C.de265_get_bits_per_pixel(img, 0)),
+		chroma: int(C.de265_get_chroma_format(img)),
+	}
+	if p.depth < 8 || p.depth > 16 || int64(p.width)*int64(p.height) >= 1<<28 {
+		return nil, fmt.Errorf("unsupported %dx%d picture of %d bits", p.width, p.height, p.depth)
+	}
+	plane := func(channel int) ([]uint16, int) {
+		w := int(C.de265_get_image_width(img, C.int(channel)))
+		h := int(C.de265_get_image_height(img, C.int(channel)))
+		var stride C.int
+		data := C.de265_get_image_plane(img, C.int(channel), &stride)
+		src := unsafe.Slice((*byte)(unsafe.Pointer(data)), h*int(stride))
+		out := make([]uint16, w*h)
+		for y := 0; y < h; y++ {
+			row := src[y*int(stride):]
+			for x := 0; x < w; x++ {
+				if p.depth > 8 {
+					out[y*w+x] = uint16(row[2*x]) | uint16(row[2*x+1])<<8
+				} else {
+					out[y*w+x] = uint16(row[x])
+				}
+			}
+		}
+		return out, w
+	}
+	p.y, _ = plane(0)
+	if p.chroma != de265ChromaMono {
+		p.cb, p.cWidth = plane(1)
+		p.cr, _ = plane(2)
+	}
+	return p, nil
+}
+
+// rgbAt converts the sample at x, y to 16-bit RGB, with the full-range
+// BT.601 coefficients the 8-bit decoder uses.
+func (p *picture) rgbAt(x, y int) (r, g, b uint16) {
+	scale := float64(int(1)<<p.depth - 1)
+	l := float64(p.y[y*p.width+x]) / scale
+	if p.chroma == de265ChromaMono {
+		v := clamp16(l)
+		return v, v, v
+	}
+	cx, cy := x, y
+	switch p.chroma {
+	case de265Chroma420:
+		cx, cy = x/2, y/2
+	case de265Chroma422:
+		cx = x / 2
+	}
+	i := cy*p.cWidth + cx
+	half := float64(int(1) << (p.depth - 1))
+	cb := (float64(p.cb[i]) - half) / scale
+	cr := (float64(p.cr[i]) - half) / scale
+	return clamp16(l + 1.402*cr), clamp16(l - 0.344136*cb - 0.714136*cr), clamp16(l + 1.772*cb)
+}
+
+func clamp16(v float64) uint16 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 0xffff
+	}
+	return uint16(v*0xffff + 0.5)
+}
+
+// draw converts the picture into dst with its top-left corner at x0, y0,
+// clipped to dst.
+func (p *picture) draw(dst *image.RGBA64, x0, y0 int) {
+	b := dst.Bounds()
+	for y := 0; y < p.height && y0+y < b.Max.Y; y++ {
+		for x := 0; x < p.width && x0+x < b.Max.X; x++ {
+			r, g, bl := p.rgbAt(x, y)
+			i := dst.PixOffset(x0+x, y0+y)
+			s := dst.Pix[i : i+8 : i+8]
+			s[0], s[1] = uint8(r>>8), uint8(r)
+			s[2], s[3] = uint8(g>>8), uint8(g)
+			s[4], s[5] = uint8(bl>>8), uint8(bl)
+			s[6], s[7] = 0xff, 0xff
+		}
+	}
+}
+
+// SourceDepth is the bit depth of the luma of the HEIC in r's primary
+// image, from the hvcC of the image or of its first tile.
+func SourceDepth(r io.ReaderAt) (int, error) {
+	hf := heif.Open(r)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return 0, err
+	}
+	if item.Info != nil && item.Info.ItemType == "grid" {
+		dimg := item.Reference("dimg")
+		if dimg == nil || len(dimg.ToItemIDs) == 0 {
+			return 0, errors.New("grid without tiles")
+		}
+		if item, err = hf.ItemByID(dimg.ToItemIDs[0]); err != nil {
+			return 0, err
+		}
+	}
+	hvcc, ok := item.HevcConfig()
+	if !ok {
+		return 0, errors.New("no hvcC")
+	}
+	body, err := io.ReadAll(hvcc.Body())
+	if err != nil {
+		return 0, err
+	}
+	if len(body) < 23 {
+		return 0, errors.New("short hvcC")
+	}
+	return 8 + int(body[17]&7), nil
+}
+
+// decodeHeic16 decodes the primary image of the HEIC in r, single or
+// tiled, into 16-bit RGB.
+func decodeHeic16(r io.ReaderAt) (*image.RGBA64, error) {
+	hf := heif.Open(r)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return nil, err
+	}
+	return decodeItem16(hf, item)
+}
+
+// decodeItem16 decodes an image item of hf, single or tiled.
+func decodeItem16(hf *heif.File, item *heif.Item) (*image.RGBA64, error) {
+	width, height, ok := item.SpatialExtents()
+	if !ok {
+		return nil, errors.New("no dimension")
+	}
+	dec, err := newDecoder16()
+	if err != nil {
+		return nil, err
+	}
+	defer dec.free()
+
+	tiles := []*heif.Item{item}
+	columns := 1
+	if item.Info != nil && item.Info.ItemType == "grid" {
+		data, err := hf.GetItemData(item)
+		if err != nil {
+			return nil, err
+		}
+		var rows int
+		if columns, rows, err = parseGridBox(data); err != nil {
+			return nil, err
+		}
+		dimg := item.Reference("dimg")
+		if dimg == nil || len(dimg.ToItemIDs) != columns*rows {
+			return nil, errors.New("tile count doesn't match the grid")
+		}
+		tiles = tiles[:0]
+		for _, id := range dimg.ToItemIDs {
+			tile, err := hf.ItemByID(id)
+			if err != nil {
+				return nil, err
+			}
+			tiles = append(tiles, tile)
+		}
+	}
+
+	img := image.NewRGBA64(image.Rect(0, 0, width, height))
+	var tileWidth, tileHeight int
+	for i, tile := range tiles {
+		if tile.Info == nil || tile.Info.ItemType != "hvc1" {
+			return nil, errors.New("unsupported tile type")
+		}
+		hvcc, ok := tile.HevcConfig()
+		if !ok {
+			return nil, errors.New("no hvcC")
+		}
+		data, err := hf.GetItemData(tile)
+		if err != nil {
+			return nil, err
+		}
+		p, err := dec.decode(hvcc.AsHeader(), data)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			tileWidth, tileHeight = p.width, p.height
+		} else if p.width != tileWidth || p.height != tileHeight {
+			return nil, errors.New("inconsistent tile dimensions")
+		}
+		p.draw(img, i%columns*tileWidth, i/columns*tileHeight)
+	}
+	return img, nil
+}
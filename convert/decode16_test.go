@@ -0,0 +1,49 @@
+package convert
+
+import (
+	"bytes"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+func TestDecodeHeic16(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file  []byte
+		depth int
+	}{
+		"8-bit":  {heicsample.Single(8), 8},
+		"10-bit": {heicsample.Single(10), 10},
+		"12-bit": {heicsample.Single(12), 12},
+		"grid":   {heicsample.Grid(), 8},
+	} {
+		depth, err := SourceDepth(bytes.NewReader(tc.file))
+		if err != nil || depth != tc.depth {
+			t.Errorf("%s: depth %d, %v", name, depth, err)
+		}
+		img, err := decodeHeic16(bytes.NewReader(tc.file))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !heicsample.Matches(img, heicsample.Pattern) {
+			t.Errorf("%s: decodes to the wrong pixels", name)
+		}
+	}
+}
+
+func TestDecodeHeic16Precision(t *testing.T) {
+	// A 10-bit picture keeps values between those of 8 bits.
+	img, err := decodeHeic16(bytes.NewReader(heicsample.Single(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fine := false
+	for x := 0; x < heicsample.Size && !fine; x++ {
+		r, _, _, _ := img.At(x, 0).RGBA()
+		fine = r%0x101 != 0
+	}
+	if !fine {
+		t.Error("10-bit samples were reduced to 8 bits")
+	}
+}
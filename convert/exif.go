@@ -0,0 +1,34 @@
+package convert
+
+import (
+	"bytes"
+
+	"heictojpeg/internal/exif"
+)
+
+// JPEGExif returns the EXIF block to write into a JPEG under the metadata
+// policy, keep or strip, and what became of it. A block without its header
+// or with junk before the TIFF data is repaired; one that still doesn't
+// parse, or doesn't fit in a JPEG segment, is left out rather than
+// written corrupt.
+func JPEGExif(data []byte, policy string) ([]byte, ExifStatus) {
+	switch {
+	case policy == "strip":
+		return nil, ExifStripped
+	case data == nil:
+		return nil, ExifMissing
+	}
+	status := ExifCopied
+	if _, err := exif.Parse(data); err != nil || !bytes.HasPrefix(data, exif.Header) {
+		tiff := exif.FindTIFF(data)
+		if tiff == nil {
+			return nil, ExifDropped
+		}
+		data = append(append([]byte(nil), exif.Header...), tiff...)
+		status = ExifRepaired
+	}
+	if len(data) > exif.MaxSegment {
+		return nil, ExifDropped
+	}
+	return data, status
+}
@@ -0,0 +1,33 @@
+package convert
+
+import (
+	"bytes"
+	"testing"
+
+	"heictojpeg/internal/exif"
+)
+
+func TestJPEGExif(t *testing.T) {
+	good := exif.Build([]exif.Tag{exif.ASCII(exif.TagMake, "Apple")}, nil)
+	tiff := good[len(exif.Header):]
+	for _, tc := range []struct {
+		name   string
+		exif   []byte
+		policy string
+		want   ExifStatus
+		out    []byte
+	}{
+		{"valid", good, "keep", ExifCopied, good},
+		{"stripped", good, "strip", ExifStripped, nil},
+		{"none", nil, "keep", ExifMissing, nil},
+		{"no header", tiff, "keep", ExifRepaired, good},
+		{"junk before", append([]byte("\x00\x00\x00\x06junk"), tiff...), "keep", ExifRepaired, good},
+		{"garbage", []byte("Exif\x00\x00MM\x00*\x00\x00\xff\xff"), "keep", ExifDropped, nil},
+		{"too big", append(append([]byte(nil), good...), make([]byte, exif.MaxSegment)...), "keep", ExifDropped, nil},
+	} {
+		out, status := JPEGExif(tc.exif, tc.policy)
+		if status != tc.want || !bytes.Equal(out, tc.out) {
+			t.Errorf("%s: status %q, %d bytes; want %q, %d bytes", tc.name, status, len(out), tc.want, len(tc.out))
+		}
+	}
+}
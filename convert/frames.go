@@ -0,0 +1,195 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/adrium/goheif"
+	"github.com/adrium/goheif/heif"
+	"github.com/adrium/goheif/heif/bmff"
+	"github.com/adrium/goheif/libde265"
+)
+
+// heicFrames lists the shots in a HEIF file, primary first: the image
+// items that aren't hidden, a tile of a grid, a thumbnail or auxiliary
+// (depth or alpha).
+func heicFrames(ra io.ReaderAt) (*heif.File, []*heif.Item, error) {
+	bmr := bmff.NewReader(io.NewSectionReader(ra, 0, 5<<40))
+	if _, err := bmr.ReadAndParseBox(bmff.TypeFtyp); err != nil {
+		return nil, nil, err
+	}
+	box, err := bmr.ReadAndParseBox(bmff.TypeMeta)
+	if err != nil {
+		return nil, nil, err
+	}
+	var infos []*bmff.ItemInfoEntry
+	notFrames := map[uint32]bool{}
+	for _, child := range box.(*bmff.MetaBox).Children {
+		parsed, err := child.Parse()
+		if err != nil {
+			continue
+		}
+		switch b := parsed.(type) {
+		case *bmff.ItemInfoBox:
+			infos = b.ItemInfos
+		case *bmff.ItemReferenceBox:
+			for _, ref := range b.ItemRefs {
+				switch ref.Type().String() {
+				case "dimg":
+					for _, id := range ref.ToItemIDs {
+						notFrames[id] = true
+					}
+				case "thmb", "auxl":
+					notFrames[ref.FromItemID] = true
+				}
+			}
+		}
+	}
+
+	hf := heif.Open(ra)
+	primary, err := hf.PrimaryItem()
+	if err != nil {
+		return nil, nil, err
+	}
+	frames := []*heif.Item{primary}
+	for _, info := range infos {
+		id := uint32(info.ItemID)
+		if id == primary.ID || notFrames[id] || info.Flags&1 != 0 || (info.ItemType != "hvc1" && info.ItemType != "grid") {
+			continue
+		}
+		item, err := hf.ItemByID(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		frames = append(frames, item)
+	}
+	sort.SliceStable(frames[1:], func(i, j int) bool { return frames[1+i].ID < frames[1+j].ID })
+	return hf, frames, nil
+}
+
+// decodeFrame decodes one shot, tiled or not.
+func decodeFrame(dec *libde265.Decoder, hf *heif.File, item *heif.Item) (*image.YCbCr, error) {
+	if item.Info == nil || item.Info.ItemType != "grid" {
+		img, err := decodeHevcTile(dec, hf, item)
+		if err != nil {
+			return nil, err
+		}
+		if w, h, ok := item.SpatialExtents(); ok && w <= img.Rect.Dx() && h <= img.Rect.Dy() {
+			img.Rect = image.Rect(0, 0, w, h)
+		}
+		return img, nil
+	}
+	grid, free, err := openGridItem(hf, item)
+	if err != nil {
+		return nil, err
+	}
+	defer free()
+	img, missing, err := assembleGrid(context.Background(), grid)
+	if err != nil {
+		return nil, err
+	}
+	if missing > 0 {
+		return nil, fmt.Errorf("%d of %d tiles failed to decode", missing, grid.columns*grid.rows)
+	}
+	return img, nil
+}
+
+// frameScore rates a shot by its sharpness, the variance of the luma
+// Laplacian, lowered for exposure: clipped pixels and a mean far from
+// mid-grey.
+func frameScore(img image.Image) float64 {
+	p := LumaOf(img)
+	if p.Width < 3 || p.Height < 3 {
+		return 0
+	}
+	var sum, sumSq, brightness float64
+	n, clipped := 0, 0
+	for y := 1; y < p.Height-1; y++ {
+		row := p.Pix[y*p.Stride:]
+		for x := 1; x < p.Width-1; x++ {
+			c := int(row[x])
+			lap := float64(4*c - int(row[x-1]) - int(row[x+1]) - int(p.Pix[(y-1)*p.Stride+x]) - int(p.Pix[(y+1)*p.Stride+x]))
+			sum += lap
+			sumSq += lap * lap
+			brightness += float64(c)
+			if c <= 4 || c >= 251 {
+				clipped++
+			}
+			n++
+		}
+	}
+	mean := sum / float64(n)
+	sharpness := sumSq/float64(n) - mean*mean
+	exposure := (1 - float64(clipped)/float64(n)) * (1 - math.Abs(brightness/float64(n)-128)/128)
+	return sharpness * exposure * exposure
+}
+
+// decodeBestFrame decodes every shot in the file and keeps the best.
+func decodeBestFrame(ra io.ReaderAt) (image.Image, error) {
+	hf, frames, err := heicFrames(ra)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 1 {
+		return goheif.Decode(io.NewSectionReader(ra, 0, 5<<40))
+	}
+	dec, err := libde265.NewDecoder()
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Free()
+
+	var best image.Image
+	bestScore := -1.0
+	for _, item := range frames {
+		img, err := decodeFrame(dec, hf, item)
+		if err != nil {
+			continue
+		}
+		if score := frameScore(img); score > bestScore {
+			best, bestScore = img, score
+		}
+	}
+	if best == nil {
+		return nil, errors.New("no frame could be decoded")
+	}
+	return best, nil
+}
+
+// OtherFrames decodes the shots after the primary one of the HEIC in r,
+// for files holding bursts, and hands each to write with its number from
+// 2, its colors converted like those of Decode. It returns how many were
+// written.
+func OtherFrames(ctx context.Context, r io.ReaderAt, o Options, write func(n int, d Decoded) error) (int, error) {
+	hf, frames, err := heicFrames(r)
+	if err != nil || len(frames) == 1 {
+		return 0, err
+	}
+	exif, _ := goheif.ExtractExif(r)
+	dec, err := libde265.NewDecoder()
+	if err != nil {
+		return 0, err
+	}
+	defer dec.Free()
+
+	for i, item := range frames[1:] {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		img, err := decodeFrame(dec, hf, item)
+		if err != nil {
+			return i, fmt.Errorf("frame %d: %v", i+2, err)
+		}
+		d := Decoded{Exif: exif}
+		d.Image, d.Profile = convertColors(r, img, o)
+		if err := write(i+2, d); err != nil {
+			return i, err
+		}
+	}
+	return len(frames) - 1, nil
+}
@@ -0,0 +1,112 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func box(typ string, payload int) []byte {
+	b := make([]byte, 8+payload)
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	copy(b[4:], typ)
+	return b
+}
+
+// fullBox is a box with version, flags and the given payload.
+func fullBox(typ string, version uint8, flags uint32, payload []byte) []byte {
+	b := box(typ, 4+len(payload))
+	binary.BigEndian.PutUint32(b[8:], uint32(version)<<24|flags)
+	copy(b[12:], payload)
+	return b
+}
+
+func infe(id uint16, itemType string, hidden bool) []byte {
+	var flags uint32
+	if hidden {
+		flags = 1
+	}
+	payload := []byte{byte(id >> 8), byte(id), 0, 0}
+	payload = append(append(payload, itemType...), 0)
+	return fullBox("infe", 2, flags, payload)
+}
+
+func itemRef(typ string, from uint16, to ...uint16) []byte {
+	payload := []byte{byte(from >> 8), byte(from), byte(len(to) >> 8), byte(len(to))}
+	for _, id := range to {
+		payload = append(payload, byte(id>>8), byte(id))
+	}
+	b := box(typ, len(payload))
+	copy(b[8:], payload)
+	return b
+}
+
+// burstContainer lays out the metadata of a HEIF file with three shots:
+// a grid primary (1, of tiles 2 and 3) with a thumbnail (4), a second
+// shot (5) with a depth map (6), a hidden item (7) and a third shot (8).
+func burstContainer() []byte {
+	ftyp := box("ftyp", 12)
+	copy(ftyp[8:], "heic\x00\x00\x00\x00mif1")
+
+	var items []byte
+	for _, e := range [][]byte{infe(1, "grid", false), infe(2, "hvc1", false), infe(3, "hvc1", false), infe(4, "hvc1", false),
+		infe(5, "hvc1", false), infe(6, "hvc1", false), infe(7, "hvc1", true), infe(8, "hvc1", false), infe(9, "Exif", false)} {
+		items = append(items, e...)
+	}
+	iinf := fullBox("iinf", 0, 0, append([]byte{0, 9}, items...))
+	var refs []byte
+	for _, r := range [][]byte{itemRef("dimg", 1, 2, 3), itemRef("thmb", 4, 1), itemRef("auxl", 6, 5), itemRef("cdsc", 9, 1)} {
+		refs = append(refs, r...)
+	}
+	iref := fullBox("iref", 0, 0, refs)
+	pitm := fullBox("pitm", 0, 0, []byte{0, 1})
+
+	var children []byte
+	for _, c := range [][]byte{pitm, iinf, iref} {
+		children = append(children, c...)
+	}
+	return append(ftyp, fullBox("meta", 0, 0, children)...)
+}
+
+func TestHeicFrames(t *testing.T) {
+	_, frames, err := heicFrames(bytes.NewReader(burstContainer()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint32
+	for _, f := range frames {
+		ids = append(ids, f.ID)
+	}
+	if want := []uint32{1, 5, 8}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("frames = %v, want %v", ids, want)
+	}
+}
+
+func TestFrameScore(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sharp := image.NewGray(image.Rect(0, 0, 64, 64))
+	blurred := image.NewGray(sharp.Rect)
+	blown := image.NewGray(sharp.Rect)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(64 + r.Intn(128))
+			sharp.SetGray(x, y, color.Gray{v})
+			blurred.SetGray(x, y, color.Gray{uint8(64 + 128*x/64)})
+			if x < 48 {
+				v = 255
+			}
+			blown.SetGray(x, y, color.Gray{v})
+		}
+	}
+	s, b, o := frameScore(sharp), frameScore(blurred), frameScore(blown)
+	if s <= b {
+		t.Errorf("sharp scored %v, blurred %v", s, b)
+	}
+	if s <= o {
+		t.Errorf("sharp scored %v, overexposed %v", s, o)
+	}
+}
@@ -0,0 +1,318 @@
+package convert
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// iccMarker starts each APP2 segment of a JPEG's ICC profile.
+var iccMarker = []byte("ICC_PROFILE\x00")
+
+// maxICCSegment is the most profile data one APP2 segment holds.
+const maxICCSegment = 0xffff - 2 - 14
+
+// iccTags indexes the tags of an ICC profile by signature.
+func iccTags(icc []byte) (map[string][]byte, error) {
+	if len(icc) < 132 {
+		return nil, errBadProfile
+	}
+	count := int(binary.BigEndian.Uint32(icc[128:132]))
+	if count > (len(icc)-132)/12 {
+		return nil, errBadProfile
+	}
+	tags := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		entry := icc[132+12*i:]
+		offset := int64(binary.BigEndian.Uint32(entry[4:]))
+		size := int64(binary.BigEndian.Uint32(entry[8:]))
+		if size < 8 || offset+size > int64(len(icc)) {
+			return nil, errBadProfile
+		}
+		tags[string(entry[:4])] = icc[offset : offset+size]
+	}
+	return tags, nil
+}
+
+// iccDescription returns the text of a profile's desc tag, stored as
+// ASCII in version 2 profiles and as UTF-16 in version 4 ones.
+func iccDescription(tags map[string][]byte) (string, error) {
+	tag, ok := tags["desc"]
+	if !ok {
+		return "", nil
+	}
+	switch string(tag[:4]) {
+	case "desc":
+		if len(tag) < 12 {
+			return "", errBadProfile
+		}
+		n := int(binary.BigEndian.Uint32(tag[8:12]))
+		if n > len(tag)-12 {
+			return "", errBadProfile
+		}
+		return strings.TrimRight(string(tag[12:12+n]), "\x00"), nil
+	case "mluc":
+		if len(tag) < 28 {
+			return "", errBadProfile
+		}
+		// The first record is as good as any: the name is only shown.
+		n := int64(binary.BigEndian.Uint32(tag[20:24]))
+		start := int64(binary.BigEndian.Uint32(tag[24:28]))
+		if start+n > int64(len(tag)) {
+			return "", errBadProfile
+		}
+		units := make([]uint16, n/2)
+		for j := range units {
+			units[j] = binary.BigEndian.Uint16(tag[start+2*int64(j):])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00"), nil
+	}
+	return "", errBadProfile
+}
+
+// iccSpace reads the colorants and curves of a matrix/TRC RGB profile,
+// the kind phones and cameras embed. Profiles made of lookup tables
+// give nil: they can be embedded, but not converted from.
+func iccSpace(icc []byte, tags map[string][]byte) *colorSpace {
+	if string(icc[16:20]) != "RGB " || string(icc[20:24]) != "XYZ " {
+		return nil
+	}
+	s := &colorSpace{}
+	for i, ch := range []string{"r", "g", "b"} {
+		xyz, ok := tags[ch+"XYZ"]
+		if !ok || len(xyz) < 20 || string(xyz[:4]) != "XYZ " {
+			return nil
+		}
+		for j := 0; j < 3; j++ {
+			s.toXYZ[j][i] = s15Fixed16(xyz[8+4*j:])
+		}
+		curve, ok := iccCurve(tags[ch+"TRC"])
+		if !ok {
+			return nil
+		}
+		s.linear[i] = curve
+	}
+	return s
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+// iccCurve tabulates a curv or para tag over the 8-bit values.
+func iccCurve(tag []byte) ([256]float64, bool) {
+	var lut [256]float64
+	if len(tag) < 12 {
+		return lut, false
+	}
+	var f func(v float64) float64
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:12]))
+		switch {
+		case n > (len(tag)-12)/2:
+			return lut, false
+		case n == 0:
+			f = func(v float64) float64 { return v }
+		case n == 1:
+			g := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			f = func(v float64) float64 { return math.Pow(v, g) }
+		default:
+			f = func(v float64) float64 {
+				x := v * float64(n-1)
+				i := int(x)
+				if i >= n-1 {
+					return float64(binary.BigEndian.Uint16(tag[12+2*(n-1):])) / 65535
+				}
+				lo := float64(binary.BigEndian.Uint16(tag[12+2*i:]))
+				hi := float64(binary.BigEndian.Uint16(tag[12+2*i+2:]))
+				return (lo + (hi-lo)*(x-float64(i))) / 65535
+			}
+		}
+	case "para":
+		counts := []int{1, 3, 4, 5, 7}
+		kind := int(binary.BigEndian.Uint16(tag[8:10]))
+		if kind >= len(counts) || len(tag) < 12+4*counts[kind] {
+			return lut, false
+		}
+		var p [7]float64
+		for i := 0; i < counts[kind]; i++ {
+			p[i] = s15Fixed16(tag[12+4*i:])
+		}
+		t := transfer{g: p[0], a: 1}
+		switch kind {
+		case 1, 2:
+			// Zero below -b/a, or c with type 2.
+			t.a, t.b, t.e, t.f = p[1], p[2], p[3], p[3]
+			if t.a != 0 {
+				t.d = -t.b / t.a
+			}
+		case 3:
+			t.a, t.b, t.c, t.d = p[1], p[2], p[3], p[4]
+		case 4:
+			t.a, t.b, t.c, t.d, t.e, t.f = p[1], p[2], p[3], p[4], p[5], p[6]
+		}
+		f = t.linear
+	default:
+		return lut, false
+	}
+	for i := range lut {
+		lut[i] = f(float64(i) / 255)
+	}
+	return lut, true
+}
+
+// iccProfile writes s as a version 4 matrix/TRC display profile named
+// name, for the outputs to carry. Only spaces known by their primaries
+// can be written.
+func (s *colorSpace) iccProfile(name string) []byte {
+	xyz := func(v [3]float64) []byte {
+		b := append([]byte("XYZ "), 0, 0, 0, 0)
+		for _, c := range v {
+			b = appendS15Fixed16(b, c)
+		}
+		return b
+	}
+	chad := append([]byte("sf32"), 0, 0, 0, 0)
+	adapt := bradford(xyToXYZ(s.white), d50)
+	for _, row := range adapt {
+		for _, c := range row {
+			chad = appendS15Fixed16(chad, c)
+		}
+	}
+	trc := append([]byte("para"), 0, 0, 0, 0, 0, 4, 0, 0)
+	for _, c := range []float64{s.curve.g, s.curve.a, s.curve.b, s.curve.c, s.curve.d, s.curve.e, s.curve.f} {
+		trc = appendS15Fixed16(trc, c)
+	}
+	column := func(i int) [3]float64 { return [3]float64{s.toXYZ[0][i], s.toXYZ[1][i], s.toXYZ[2][i]} }
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", mluc(name)},
+		{"cprt", mluc("No copyright, use freely")},
+		{"wtpt", xyz(d50)},
+		{"chad", chad},
+		{"rXYZ", xyz(column(0))},
+		{"gXYZ", xyz(column(1))},
+		{"bXYZ", xyz(column(2))},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	start := 128 + 4 + 12*len(tags)
+	offsets := map[string]int{} // the curves share their data
+	for _, t := range tags {
+		offset, ok := offsets[string(t.data)]
+		if !ok {
+			offset = start + len(data)
+			offsets[string(t.data)] = offset
+			data = append(data, t.data...)
+			for len(data)%4 != 0 {
+				data = append(data, 0)
+			}
+		}
+		table = append(table, t.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(offset))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(t.data)))
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(start+len(data)))
+	binary.BigEndian.PutUint32(header[8:], 0x04300000)
+	copy(header[12:], "mntrRGB XYZ ")
+	copy(header[36:], "acsp")
+	copy(header[68:], xyz(d50)[8:])
+	return append(append(header, table...), data...)
+}
+
+func appendS15Fixed16(b []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v*65536))))
+}
+
+// mluc is a multi-localized text tag holding text in English.
+func mluc(text string) []byte {
+	units := utf16.Encode([]rune(text))
+	b := append([]byte("mluc"), 0, 0, 0, 0)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, 12)
+	b = append(b, "enUS"...)
+	b = binary.BigEndian.AppendUint32(b, uint32(2*len(units)))
+	b = binary.BigEndian.AppendUint32(b, 28)
+	for _, u := range units {
+		b = binary.BigEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// writeICCSegments writes icc as the APP2 segments of a JPEG, split where
+// it doesn't fit in one.
+func writeICCSegments(w io.Writer, icc []byte) error {
+	count := (len(icc) + maxICCSegment - 1) / maxICCSegment
+	for i := 0; i < count; i++ {
+		chunk := icc[i*maxICCSegment:]
+		if len(chunk) > maxICCSegment {
+			chunk = chunk[:maxICCSegment]
+		}
+		n := 2 + len(iccMarker) + 2 + len(chunk)
+		segment := []byte{0xff, 0xe2, byte(n >> 8), byte(n)}
+		segment = append(append(segment, iccMarker...), byte(i+1), byte(count))
+		if _, err := w.Write(append(segment, chunk...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pngICCWriter inserts an iCCP chunk after the IHDR chunk of the PNG
+// written through it, where the format wants it.
+type pngICCWriter struct {
+	w       io.Writer
+	written int
+	chunk   []byte // nil once written
+}
+
+// pngHeaderSize is the signature and the IHDR chunk of a PNG.
+const pngHeaderSize = 8 + 8 + 13 + 4
+
+func newPNGICCWriter(w io.Writer, icc []byte) (*pngICCWriter, error) {
+	var data bytes.Buffer
+	data.WriteString("ICC Profile\x00\x00")
+	z := zlib.NewWriter(&data)
+	if _, err := z.Write(icc); err != nil {
+		return nil, err
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(data.Len()))
+	chunk = append(append(chunk, "iCCP"...), data.Bytes()...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	return &pngICCWriter{w: w, chunk: chunk}, nil
+}
+
+func (p *pngICCWriter) Write(b []byte) (int, error) {
+	if p.chunk == nil || p.written+len(b) < pngHeaderSize {
+		p.written += len(b)
+		return p.w.Write(b)
+	}
+	head := pngHeaderSize - p.written
+	if _, err := p.w.Write(b[:head]); err != nil {
+		return 0, err
+	}
+	if _, err := p.w.Write(p.chunk); err != nil {
+		return head, err
+	}
+	p.chunk = nil
+	n, err := p.w.Write(b[head:])
+	p.written += head + n
+	return head + n, err
+}
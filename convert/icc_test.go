@@ -0,0 +1,166 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// iccWithDesc is a minimal ICC profile holding only a desc tag, as
+// version 2 profiles store it, or as an mluc tag for version 4.
+func iccWithDesc(name string, v4 bool) []byte {
+	var tag []byte
+	if v4 {
+		tag = mluc(name)
+	} else {
+		tag = append([]byte("desc"), make([]byte, 8)...)
+		binary.BigEndian.PutUint32(tag[8:], uint32(len(name)+1))
+		tag = append(append(tag, name...), 0)
+	}
+	icc := make([]byte, 144)
+	copy(icc[16:], "RGB XYZ ")
+	binary.BigEndian.PutUint32(icc[128:], 1)
+	copy(icc[132:], "desc")
+	binary.BigEndian.PutUint32(icc[136:], 144)
+	binary.BigEndian.PutUint32(icc[140:], uint32(len(tag)))
+	return append(icc, tag...)
+}
+
+func TestICCDescription(t *testing.T) {
+	for _, v4 := range []bool{false, true} {
+		tags, err := iccTags(iccWithDesc("Display P3", v4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name, err := iccDescription(tags); err != nil || name != "Display P3" {
+			t.Errorf("v4 %v: got %q, %v", v4, name, err)
+		}
+	}
+	broken := iccWithDesc("Display P3", false)
+	binary.BigEndian.PutUint32(broken[140:], 1000)
+	if _, err := iccTags(broken); err == nil {
+		t.Error("tag past the end of the profile accepted")
+	}
+}
+
+func TestICCProfileRoundTrip(t *testing.T) {
+	for name, s := range map[string]*colorSpace{
+		"Display P3": displayP3Space,
+		"BT.2020":    newColorSpace(bt2020Primaries, bt709Curve),
+		"DCI-P3":     newColorSpace(dciP3Primaries, gammaCurve(2.6)),
+	} {
+		icc := s.iccProfile(name)
+		if got := int(binary.BigEndian.Uint32(icc)); got != len(icc) {
+			t.Errorf("%s: header says %d bytes of %d", name, got, len(icc))
+		}
+		tags, err := iccTags(icc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc, _ := iccDescription(tags); desc != name {
+			t.Errorf("%s: described as %q", name, desc)
+		}
+		read := iccSpace(icc, tags)
+		if read == nil {
+			t.Fatalf("%s: can't read the profile back", name)
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				if math.Abs(read.toXYZ[i][j]-s.toXYZ[i][j]) > 0.0001 {
+					t.Errorf("%s: colorants %v, want %v", name, read.toXYZ, s.toXYZ)
+				}
+			}
+		}
+		for v := 0; v < 256; v++ {
+			if math.Abs(read.linear[1][v]-s.linear[1][v]) > 0.0005 {
+				t.Errorf("%s: curve at %d is %v, want %v", name, v, read.linear[1][v], s.linear[1][v])
+				break
+			}
+		}
+	}
+}
+
+func TestICCCurve(t *testing.T) {
+	gamma := append([]byte("curv"), 0, 0, 0, 0, 0, 0, 0, 1, 2, 0x33) // 2.2
+	table := append([]byte("curv"), 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0x40, 0, 0xff, 0xff)
+	for _, tc := range []struct {
+		name string
+		tag  []byte
+		at   int
+		want float64
+	}{
+		{"identity", append([]byte("curv"), make([]byte, 8)...), 51, 0.2},
+		{"gamma", gamma, 128, math.Pow(128.0/255, 2.2)},
+		{"table", table, 0, 0},
+		{"table middle", table, 255, 1},
+		{"para type 0", appendPara(0, 2), 51, 0.04},
+	} {
+		lut, ok := iccCurve(tc.tag)
+		if !ok {
+			t.Errorf("%s: not read", tc.name)
+			continue
+		}
+		if math.Abs(lut[tc.at]-tc.want) > 0.002 {
+			t.Errorf("%s: %v at %d, want %v", tc.name, lut[tc.at], tc.at, tc.want)
+		}
+	}
+	if _, ok := iccCurve([]byte("mAB \x00\x00\x00\x00\x00\x00\x00\x00")); ok {
+		t.Error("lookup table taken for a curve")
+	}
+}
+
+func appendPara(kind uint16, params ...float64) []byte {
+	b := append([]byte("para"), 0, 0, 0, 0, byte(kind>>8), byte(kind), 0, 0)
+	for _, p := range params {
+		b = appendS15Fixed16(b, p)
+	}
+	return b
+}
+
+// iccSegments collects the ICC profile from the APP2 segments of a JPEG.
+func iccSegments(data []byte) []byte {
+	var icc []byte
+	for i := 2; i+4 <= len(data) && data[i] == 0xff && data[i+1] != 0xda; {
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if data[i+1] == 0xe2 && bytes.HasPrefix(data[i+4:], iccMarker) {
+			icc = append(icc, data[i+4+len(iccMarker)+2:i+2+n]...)
+		}
+		i += 2 + n
+	}
+	return icc
+}
+
+func TestJPEGWithICC(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for _, size := range []int{600, 150000} {
+		icc := append(displayP3Space.iccProfile("Display P3"), make([]byte, size)...)
+		var out bytes.Buffer
+		if err := EncodeJPEG(&out, img, nil, icc, DefaultOptions()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(out.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		if got := iccSegments(out.Bytes()); !bytes.Equal(got, icc) {
+			t.Errorf("%d bytes: got %d bytes of profile back", len(icc), len(got))
+		}
+	}
+}
+
+func TestPNGWithICC(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	var out bytes.Buffer
+	if err := EncodePNG(&out, img, displayP3Space.iccProfile("Display P3"), DefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(out.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out.Bytes()[pngHeaderSize+4 : pngHeaderSize+8]); got != "iCCP" {
+		t.Errorf("chunk after IHDR is %q", got)
+	}
+}
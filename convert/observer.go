@@ -0,0 +1,104 @@
+package convert
+
+import "time"
+
+// UnknownTotal is passed to OnStart when files are converted while the
+// folder is still being scanned.
+const UnknownTotal = -1
+
+// Observer is notified as a batch progresses, so progress displays and
+// reports can be driven from the results instead of parsing logs.txt.
+type Observer interface {
+	OnStart(total int)
+	OnFileDone(ConversionResult)
+	OnFinish(Summary)
+}
+
+// ConversionResult describes the outcome of converting one file.
+type ConversionResult struct {
+	Name       string // path relative to the batch folder, as in logs.txt
+	Input      string
+	Output     string
+	InputSize  int64
+	OutputSize int64
+	Duration   time.Duration
+	Err        error  // nil when the JPEG was written
+	Warning    string // set when it was written despite a problem, e.g. by a repair
+	Skipped    string // why a rule left the file alone
+	Exif       ExifStatus
+	// Profile is set when the input's color profile isn't sRGB, which
+	// ColorProfile names, e.g. "Display P3".
+	Profile      ProfileStatus
+	ColorProfile string
+	Hashes       *Hashes // set when hashes are asked for
+	// Orientation is the JPEG's EXIF orientation when it isn't upright:
+	// the pixels are written as stored, so strict viewers show it turned.
+	Orientation int
+	// Diagnostics describe the file's structure, for debugging.
+	Diagnostics []string
+}
+
+// ExifStatus tells whether a file's EXIF block made it into its JPEG.
+type ExifStatus string
+
+const (
+	ExifUnknown  ExifStatus = ""
+	ExifCopied   ExifStatus = "copied"
+	ExifMissing  ExifStatus = "missing"
+	ExifRepaired ExifStatus = "repaired" // malformed, fixed before it was copied
+	ExifStripped ExifStatus = "stripped" // left out by the strip policy or a PNG output
+	ExifDropped  ExifStatus = "dropped"  // malformed beyond repair, left out
+)
+
+// ExifCounts tallies the EXIF outcomes of a batch, for checking at a
+// glance that a migration kept the metadata.
+type ExifCounts struct {
+	Copied   int `json:"copied"`
+	Repaired int `json:"repaired"`
+	Stripped int `json:"stripped"`
+	Missing  int `json:"missing"`
+	Dropped  int `json:"dropped"`
+}
+
+func (c *ExifCounts) add(s ExifStatus) {
+	switch s {
+	case ExifCopied:
+		c.Copied++
+	case ExifRepaired:
+		c.Repaired++
+	case ExifStripped:
+		c.Stripped++
+	case ExifMissing:
+		c.Missing++
+	case ExifDropped:
+		c.Dropped++
+	}
+}
+
+// Summary describes a finished batch.
+type Summary struct {
+	Files      int
+	Failed     int
+	Skipped    int
+	Duration   time.Duration
+	InputSize  int64
+	OutputSize int64
+	Exif       ExifCounts
+	Profiles   ProfileCounts
+}
+
+// Add counts r into s. The Duration is left to the caller, which knows
+// when the batch started.
+func (s *Summary) Add(r ConversionResult) {
+	s.Files++
+	s.InputSize += r.InputSize
+	s.OutputSize += r.OutputSize
+	switch {
+	case r.Err != nil:
+		s.Failed++
+	case r.Skipped != "":
+		s.Skipped++
+	}
+	s.Exif.add(r.Exif)
+	s.Profiles.add(r.Profile)
+}
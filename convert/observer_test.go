@@ -0,0 +1,27 @@
+package convert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExifCounts(t *testing.T) {
+	var c ExifCounts
+	for _, s := range []ExifStatus{ExifCopied, ExifCopied, ExifRepaired, ExifStripped, ExifMissing, ExifDropped, ExifUnknown} {
+		c.add(s)
+	}
+	if want := (ExifCounts{Copied: 2, Repaired: 1, Stripped: 1, Missing: 1, Dropped: 1}); c != want {
+		t.Errorf("counts = %+v, want %+v", c, want)
+	}
+}
+
+func TestSummaryAdd(t *testing.T) {
+	var s Summary
+	s.Add(ConversionResult{InputSize: 10, OutputSize: 4, Exif: ExifCopied, Profile: ProfileEmbedded})
+	s.Add(ConversionResult{InputSize: 5, Err: errors.New("broken")})
+	s.Add(ConversionResult{InputSize: 7, Skipped: "a duplicate"})
+	want := Summary{Files: 3, Failed: 1, Skipped: 1, InputSize: 22, OutputSize: 4, Exif: ExifCounts{Copied: 1}, Profiles: ProfileCounts{Embedded: 1}}
+	if s != want {
+		t.Errorf("summary = %+v, want %+v", s, want)
+	}
+}
@@ -0,0 +1,193 @@
+package convert
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+
+	"heictojpeg/internal/longpath"
+)
+
+// hashThumbSide is the size of the grey thumbnail the hashes are computed
+// from, so the source is read only once.
+const hashThumbSide = 64
+
+// grayThumb box-averages the luma of img into a side x side thumbnail,
+// squashing the aspect ratio the same way for every image.
+func grayThumb(img image.Image, side int) []float64 {
+	p := LumaOf(img)
+	thumb := make([]float64, side*side)
+	counts := make([]int, side*side)
+	if p.Width == 0 || p.Height == 0 {
+		return thumb
+	}
+	for y := 0; y < p.Height; y++ {
+		row := (y * side / p.Height) * side
+		for x := 0; x < p.Width; x++ {
+			i := row + x*side/p.Width
+			thumb[i] += float64(p.Pix[y*p.Stride+x])
+			counts[i]++
+		}
+	}
+	for i, n := range counts {
+		if n > 0 {
+			thumb[i] /= float64(n)
+		}
+	}
+	return thumb
+}
+
+// rotateThumb turns a square thumbnail a quarter turn clockwise.
+func rotateThumb(thumb []float64, side int) []float64 {
+	out := make([]float64, len(thumb))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			// The pixel at (x, y) of the result comes from (y, side-1-x).
+			out[y*side+x] = thumb[(side-1-x)*side+y]
+		}
+	}
+	return out
+}
+
+// dHash is the 64-bit difference hash of a thumbnail: whether each of 8x8
+// cells is brighter than its right neighbour, in a 9x8 grid.
+func dHash(thumb []float64, side int) uint64 {
+	var cells [8][9]float64
+	var counts [8][9]int
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			cy, cx := y*8/side, x*9/side
+			cells[cy][cx] += thumb[y*side+x]
+			counts[cy][cx]++
+		}
+	}
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := cells[y][x] / float64(counts[y][x])
+			right := cells[y][x+1] / float64(counts[y][x+1])
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// RotatedDHashes hashes img at each quarter turn. HEIC pixels are stored
+// unrotated while exported JPEGs are usually rotated, so both are tried.
+func RotatedDHashes(img image.Image) [4]uint64 {
+	var hashes [4]uint64
+	thumb := grayThumb(img, hashThumbSide)
+	for i := range hashes {
+		hashes[i] = dHash(thumb, hashThumbSide)
+		thumb = rotateThumb(thumb, hashThumbSide)
+	}
+	return hashes
+}
+
+// DHash is the difference hash of img, which finds copies of it.
+func DHash(img image.Image) uint64 {
+	return dHash(grayThumb(img, hashThumbSide), hashThumbSide)
+}
+
+// HammingDistance is the number of bits two hashes differ by.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Hashes are the perceptual hashes of a converted image, which stay
+// close for the same photo after recompression or resizing.
+type Hashes struct {
+	PHash uint64
+	DHash uint64
+}
+
+// FormatHash writes h as 16 hex digits.
+func FormatHash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
+
+// HashImage computes the hashes of img.
+func HashImage(img image.Image) Hashes {
+	thumb := grayThumb(img, hashThumbSide)
+	return Hashes{
+		PHash: pHash(halveThumb(thumb, hashThumbSide), hashThumbSide/2),
+		DHash: dHash(thumb, hashThumbSide),
+	}
+}
+
+// HashFile hashes a converted JPEG or PNG.
+func HashFile(path string) (Hashes, error) {
+	f, err := os.Open(longpath.Path(path))
+	if err != nil {
+		return Hashes{}, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return Hashes{}, err
+	}
+	return HashImage(img), nil
+}
+
+// halveThumb averages each 2x2 block of a square thumbnail.
+func halveThumb(thumb []float64, side int) []float64 {
+	half := side / 2
+	out := make([]float64, half*half)
+	for y := 0; y < half; y++ {
+		for x := 0; x < half; x++ {
+			i := 2*y*side + 2*x
+			out[y*half+x] = (thumb[i] + thumb[i+1] + thumb[i+side] + thumb[i+side+1]) / 4
+		}
+	}
+	return out
+}
+
+// pHash is the 64-bit DCT hash of a thumbnail: whether each of the 8x8
+// lowest frequencies is above their median, leaving out the average.
+func pHash(thumb []float64, side int) uint64 {
+	cosines := make([]float64, 8*side)
+	for u := 0; u < 8; u++ {
+		for x := 0; x < side; x++ {
+			cosines[u*side+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*side))
+		}
+	}
+	// The rows first, then the columns, of the 8 frequencies kept.
+	rows := make([]float64, side*8)
+	for y := 0; y < side; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < side; x++ {
+				sum += thumb[y*side+x] * cosines[u*side+x]
+			}
+			rows[y*8+u] = sum
+		}
+	}
+	var coefficients [64]float64
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < side; y++ {
+				sum += rows[y*8+u] * cosines[v*side+y]
+			}
+			coefficients[v*8+u] = sum
+		}
+	}
+
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	var hash uint64
+	for _, c := range coefficients {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+	return hash
+}
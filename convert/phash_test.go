@@ -0,0 +1,93 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+)
+
+// blockPhoto is a w x h image of random grey 16x16 blocks, so different
+// seeds give unrelated hashes.
+func blockPhoto(w, h int, seed int64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(seed))
+	shades := make([]uint8, (w/16+1)*(h/16+1))
+	for i := range shades {
+		shades[i] = uint8(r.Intn(256))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{shades[(y/16)*(w/16+1)+x/16]})
+		}
+	}
+	return img
+}
+
+// rotateClockwise turns img a quarter turn clockwise.
+func rotateClockwise(img *image.Gray) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.SetGray(b.Dy()-1-y, x, img.GrayAt(x, y))
+		}
+	}
+	return out
+}
+
+func TestDHash(t *testing.T) {
+	a := blockPhoto(192, 128, 1)
+	hashA := dHash(grayThumb(a, hashThumbSide), hashThumbSide)
+	if got := dHash(grayThumb(a, hashThumbSide), hashThumbSide); got != hashA {
+		t.Errorf("hash is not stable: %x, then %x", hashA, got)
+	}
+	b := blockPhoto(192, 128, 2)
+	if d := HammingDistance(hashA, dHash(grayThumb(b, hashThumbSide), hashThumbSide)); d < 16 {
+		t.Errorf("unrelated images are %d bits apart, want many more", d)
+	}
+
+	// A downscaled copy hashes almost the same.
+	small := FitWithin(a, 96)
+	if d := HammingDistance(hashA, dHash(grayThumb(small, hashThumbSide), hashThumbSide)); d > 6 {
+		t.Errorf("downscaled copy is %d bits apart", d)
+	}
+}
+
+func TestRotatedDHashes(t *testing.T) {
+	source := blockPhoto(192, 128, 3)
+	rotated := rotateClockwise(source)
+	want := dHash(grayThumb(rotated, hashThumbSide), hashThumbSide)
+	hashes := RotatedDHashes(source)
+	if d := HammingDistance(hashes[1], want); d > 2 {
+		t.Errorf("quarter-turn hash is %d bits from the rotated image's", d)
+	}
+	if hashes[0] != dHash(grayThumb(source, hashThumbSide), hashThumbSide) {
+		t.Error("first hash is not the unrotated one")
+	}
+}
+
+func TestPHash(t *testing.T) {
+	a := HashImage(blockPhoto(192, 128, 1))
+	if again := HashImage(blockPhoto(192, 128, 1)); again != a {
+		t.Errorf("hashes are not stable: %+v, then %+v", a, again)
+	}
+
+	// A recompressed, downscaled copy stays close; another photo doesn't.
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, FitWithin(blockPhoto(192, 128, 1), 120), &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := HammingDistance(a.PHash, HashImage(img).PHash); d > 8 {
+		t.Errorf("recompressed copy is %d bits apart", d)
+	}
+	if d := HammingDistance(a.PHash, HashImage(blockPhoto(192, 128, 2)).PHash); d < 16 {
+		t.Errorf("unrelated images are %d bits apart, want many more", d)
+	}
+}
@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"bufio"
+	"image"
+	"io"
+	"sync"
+)
+
+// Buffers reused across conversions, so long batches don't allocate (and
+// collect) the same large buffers for every file.
+var (
+	bufferedWriters = sync.Pool{New: func() interface{} { return bufio.NewWriterSize(nil, 64<<10) }}
+
+	bandPoolsMu sync.Mutex
+	bandPools   = map[bandKey]*sync.Pool{}
+)
+
+type bandKey struct {
+	rect  image.Rectangle
+	ratio image.YCbCrSubsampleRatio
+}
+
+func getBufferedWriter(w io.Writer) *bufio.Writer {
+	bw := bufferedWriters.Get().(*bufio.Writer)
+	bw.Reset(w)
+	return bw
+}
+
+func putBufferedWriter(bw *bufio.Writer) {
+	bw.Reset(nil)
+	bufferedWriters.Put(bw)
+}
+
+func bandPool(key bandKey) *sync.Pool {
+	bandPoolsMu.Lock()
+	defer bandPoolsMu.Unlock()
+	p, ok := bandPools[key]
+	if !ok {
+		p = &sync.Pool{New: func() interface{} { return image.NewYCbCr(key.rect, key.ratio) }}
+		bandPools[key] = p
+	}
+	return p
+}
+
+// getBand returns a YCbCr image for rect. Its pixels are stale and must be
+// overwritten.
+// Bands are pooled by size; the offsets are relative to Rect.Min, so a
+// band can be moved to any position.
+func getBand(rect image.Rectangle, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+	band := bandPool(bandKey{rect.Sub(rect.Min), ratio}).Get().(*image.YCbCr)
+	band.Rect = rect
+	return band
+}
+
+func putBand(band *image.YCbCr) {
+	if band != nil {
+		bandPool(bandKey{band.Rect.Sub(band.Rect.Min), band.SubsampleRatio}).Put(band)
+	}
+}
@@ -0,0 +1,41 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+func TestEncodeJPEGWithExif(t *testing.T) {
+	img := image.NewYCbCr(image.Rect(0, 0, 64, 48), image.YCbCrSubsampleRatio420)
+	// An EXIF block with an empty IFD0.
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00")
+	for i := 0; i < 2; i++ { // the second run reuses the pooled writer
+		var buf bytes.Buffer
+		if err := EncodeJPEG(&buf, img, exif, nil, Options{Quality: 80, Metadata: "keep"}); err != nil {
+			t.Fatal(err)
+		}
+		data := buf.Bytes()
+		if !bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff, 0xe1}) || !bytes.Contains(data[:32], exif) {
+			t.Fatalf("missing SOI and APP1 header: % x", data[:16])
+		}
+		if bytes.Count(data, []byte{0xff, 0xd8}) != 1 {
+			t.Error("SOI marker written twice")
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+			t.Fatalf("output doesn't decode: %v", err)
+		}
+	}
+}
+
+func BenchmarkEncodeJPEG(b *testing.B) {
+	img := image.NewYCbCr(image.Rect(0, 0, 512, 512), image.YCbCrSubsampleRatio420)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := EncodeJPEG(io.Discard, img, nil, nil, Options{Quality: 75}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/adrium/goheif/heif"
+)
+
+// thumbnailItem picks the embedded thumbnail of the primary image of hf
+// to preview it at maxSide pixels: the smallest that is as large, or else
+// the largest.
+func thumbnailItem(r io.ReaderAt, hf *heif.File, maxSide int) (*heif.Item, bool) {
+	var best *heif.Item
+	bestSide := 0
+	for _, item := range referringItems(r, hf, "thmb") {
+		if item.Info == nil || (item.Info.ItemType != "hvc1" && item.Info.ItemType != "grid") {
+			continue
+		}
+		width, height, ok := item.SpatialExtents()
+		if !ok {
+			continue
+		}
+		side := width
+		if height > side {
+			side = height
+		}
+		if best == nil || (bestSide < maxSide && side > bestSide) || (side >= maxSide && side < bestSide) {
+			best, bestSide = item, side
+		}
+	}
+	return best, best != nil
+}
+
+// DecodePreview decodes the HEIC in r at no more than maxSide pixels a
+// side, for listings. It decodes the embedded thumbnail when there is
+// one, which takes a fraction of the time of the photo; otherwise the
+// photo's tiles are scaled down one by one as they're decoded, so the
+// full image is never held.
+func DecodePreview(r io.ReaderAt, maxSide int) (*image.RGBA64, error) {
+	hf := heif.Open(r)
+	item, ok := thumbnailItem(r, hf, maxSide)
+	if !ok {
+		var err error
+		if item, err = hf.PrimaryItem(); err != nil {
+			return nil, err
+		}
+		if err := checkGridLayout(hf, item); err != nil {
+			return nil, err
+		}
+	}
+	return decodeScaled(hf, item, maxSide)
+}
+
+// decodeScaled decodes an image item of hf, single or tiled, scaled down
+// to fit maxSide.
+func decodeScaled(hf *heif.File, item *heif.Item, maxSide int) (*image.RGBA64, error) {
+	width, height, ok := item.SpatialExtents()
+	if !ok || width <= 0 || height <= 0 {
+		return nil, errors.New("no dimension")
+	}
+	columns, _, tiles, err := gridTiles(hf, item)
+	if err != nil {
+		return nil, err
+	}
+	if tiles == nil {
+		columns, tiles = 1, []*heif.Item{item}
+	}
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	scaled := func(v int) int {
+		if longest <= maxSide {
+			return v
+		}
+		return int(int64(v) * int64(maxSide) / int64(longest))
+	}
+	dst := image.NewRGBA64(image.Rect(0, 0, scaled(width), scaled(height)))
+	if dst.Rect.Empty() {
+		return nil, errors.New("image too small to preview")
+	}
+	for i, tile := range tiles {
+		img, err := decodeItem16(hf, tile)
+		if err != nil {
+			return nil, err
+		}
+		t := img.Bounds()
+		x0, y0 := i%columns*t.Dx(), i/columns*t.Dy()
+		scaleInto(dst, image.Rect(scaled(x0), scaled(y0), scaled(x0+t.Dx()), scaled(y0+t.Dy())), img)
+	}
+	return dst, nil
+}
+
+// scaleInto draws src into the rectangle r of dst, clipped to dst,
+// averaging the source pixels that fall into each pixel of r.
+func scaleInto(dst *image.RGBA64, r image.Rectangle, src *image.RGBA64) {
+	s := src.Bounds()
+	visible := r.Intersect(dst.Bounds())
+	for y := visible.Min.Y; y < visible.Max.Y; y++ {
+		sy0 := s.Min.Y + (y-r.Min.Y)*s.Dy()/r.Dy()
+		sy1 := s.Min.Y + (y+1-r.Min.Y)*s.Dy()/r.Dy()
+		for x := visible.Min.X; x < visible.Max.X; x++ {
+			sx0 := s.Min.X + (x-r.Min.X)*s.Dx()/r.Dx()
+			sx1 := s.Min.X + (x+1-r.Min.X)*s.Dx()/r.Dx()
+			var sum [3]uint64
+			var n uint64
+			for sy := sy0; sy < sy1 || sy == sy0; sy++ {
+				for sx := sx0; sx < sx1 || sx == sx0; sx++ {
+					c := src.RGBA64At(sx, sy)
+					sum[0], sum[1], sum[2] = sum[0]+uint64(c.R), sum[1]+uint64(c.G), sum[2]+uint64(c.B)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{uint16(sum[0] / n), uint16(sum[1] / n), uint16(sum[2] / n), 0xffff})
+		}
+	}
+}
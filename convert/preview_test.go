@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+
+	"github.com/adrium/goheif/heif"
+)
+
+func TestThumbnailItem(t *testing.T) {
+	file := bytes.NewReader(heicsample.Thumbnailed(16, 32))
+	hf := heif.Open(file)
+	for _, tc := range []struct{ maxSide, want int }{{10, 16}, {16, 16}, {20, 32}, {100, 32}} {
+		item, ok := thumbnailItem(file, hf, tc.maxSide)
+		if !ok {
+			t.Fatalf("%d: no thumbnail", tc.maxSide)
+		}
+		if w, _, _ := item.SpatialExtents(); w != tc.want {
+			t.Errorf("%d: picked the %d-pixel thumbnail, want %d", tc.maxSide, w, tc.want)
+		}
+	}
+
+	single := bytes.NewReader(heicsample.Single(8))
+	if _, ok := thumbnailItem(single, heif.Open(single), 100); ok {
+		t.Errorf("found a thumbnail in a file without one")
+	}
+}
+
+func TestDecodePreview(t *testing.T) {
+	img, err := DecodePreview(bytes.NewReader(heicsample.Thumbnailed(16)), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 16 || img.Bounds().Dy() != 16 {
+		t.Fatalf("got a %v preview, want the 16×16 thumbnail", img.Bounds())
+	}
+	if c := img.RGBA64At(8, 8); absDiff(uint8(c.R>>8), 200) > 2 {
+		t.Errorf("preview isn't the thumbnail: %v", c)
+	}
+
+	// Without a thumbnail, the tiles are scaled down as they're decoded.
+	for _, depth := range []int{8, 10} {
+		file := heicsample.Tiled(depth, 2, 2, 100, 120, nil)
+		img, err := DecodePreview(bytes.NewReader(file), 60)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds() != image.Rect(0, 0, 50, 60) {
+			t.Fatalf("%d-bit: got a %v preview", depth, img.Bounds())
+		}
+		full, err := decodeHeic16(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := FitWithin(full, 60)
+		var diff int
+		for y := 0; y < 60; y++ {
+			for x := 0; x < 50; x++ {
+				r, _, _, _ := want.At(x, y).RGBA()
+				diff += int(absDiff(uint8(r>>8), uint8(img.RGBA64At(x, y).R>>8)))
+			}
+		}
+		if mean := diff / (50 * 60); mean > 8 {
+			t.Errorf("%d-bit: preview differs from the scaled photo by %d on average", depth, mean)
+		}
+	}
+}
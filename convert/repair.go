@@ -0,0 +1,57 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+
+	"github.com/adrium/goheif"
+)
+
+// assembleGrid decodes every tile it can into one frame, leaving the
+// tiles that fail black, and returns how many failed.
+func assembleGrid(ctx context.Context, grid tileGrid) (*image.YCbCr, int, error) {
+	full := image.NewYCbCr(image.Rect(0, 0, grid.columns*grid.tileWidth, grid.rows*grid.tileHeight), grid.ratio)
+	for i := range full.Cb {
+		full.Cb[i], full.Cr[i] = 128, 128
+	}
+	missing := 0
+	for i := 0; i < grid.columns*grid.rows; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		tile, err := grid.decodeTile(i)
+		if err != nil || tile.Rect.Dx() != grid.tileWidth || tile.Rect.Dy() != grid.tileHeight || tile.SubsampleRatio != grid.ratio {
+			missing++
+			continue
+		}
+		copyTile(full, tile, i%grid.columns*grid.tileWidth, i/grid.columns*grid.tileHeight)
+	}
+	full.Rect = image.Rect(0, 0, grid.width, grid.height)
+	return full, missing, nil
+}
+
+// Repair decodes the tiles that are still present in the truncated, tiled
+// HEIC in r, leaving the missing ones black, and returns how many of its
+// tiles are missing.
+func Repair(ctx context.Context, r io.ReaderAt, o Options) (d Decoded, missing, total int, err error) {
+	grid, free, err := openTileGrid(r)
+	if err != nil {
+		return Decoded{}, 0, 0, err
+	}
+	defer free()
+
+	img, missing, err := assembleGrid(ctx, grid)
+	if err != nil {
+		return Decoded{}, 0, 0, err
+	}
+	total = grid.columns * grid.rows
+	if missing == total {
+		return Decoded{}, missing, total, errors.New("no tiles could be decoded")
+	}
+	// The EXIF block is usually before the image data, but may be lost too.
+	d.Exif, _ = goheif.ExtractExif(r)
+	d.Image, d.Profile = convertColors(r, img, o)
+	return d, missing, total, nil
+}
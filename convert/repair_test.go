@@ -0,0 +1,34 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestAssembleGridMissingTiles(t *testing.T) {
+	full := image.NewYCbCr(image.Rect(0, 0, 64, 32), image.YCbCrSubsampleRatio420)
+	for i := range full.Y {
+		full.Y[i] = 200
+	}
+	grid := testGrid(full, 16, 16)
+	decode := grid.decodeTile
+	grid.decodeTile = func(i int) (*image.YCbCr, error) {
+		if i >= 6 { // the last two tiles are past the end of the file
+			return nil, errors.New("unexpected EOF")
+		}
+		return decode(i)
+	}
+
+	img, missing, err := assembleGrid(context.Background(), grid)
+	if err != nil || missing != 2 {
+		t.Fatalf("assembleGrid = %d missing, %v; want 2", missing, err)
+	}
+	if got := img.YCbCrAt(10, 10).Y; got != 200 {
+		t.Errorf("present tile Y = %d, want 200", got)
+	}
+	if c := img.YCbCrAt(60, 30); c.Y != 0 || c.Cb != 128 || c.Cr != 128 {
+		t.Errorf("missing tile = %v, want black", c)
+	}
+}
@@ -0,0 +1,74 @@
+package convert
+
+import (
+	"image"
+	"image/color"
+)
+
+// FitWithin scales img down so neither side exceeds maxSide, averaging
+// the source pixels that fall into each output pixel. Rows are read top
+// to bottom, so a banded image only needs its current band.
+func FitWithin(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if maxSide <= 0 || (srcW <= maxSide && srcH <= maxSide) {
+		return img
+	}
+	dstW, dstH := maxSide, maxSide
+	if srcW > srcH {
+		dstH = int(int64(srcH) * int64(maxSide) / int64(srcW))
+	} else {
+		dstW = int(int64(srcW) * int64(maxSide) / int64(srcH))
+	}
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	// 16-bit images stay so, for the outputs of -bit-depth.
+	var dst *image.RGBA
+	var deep *image.RGBA64
+	if isDeep(img) {
+		deep = image.NewRGBA64(image.Rect(0, 0, dstW, dstH))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	}
+	sums := make([][4]uint64, dstW)
+	counts := make([]uint64, dstW)
+	row := 0
+	flush := func() {
+		for x := range sums {
+			if n := counts[x]; n > 0 {
+				s := sums[x]
+				if deep != nil {
+					deep.SetRGBA64(x, row, color.RGBA64{uint16(s[0] / n), uint16(s[1] / n), uint16(s[2] / n), uint16(s[3] / n)})
+				} else {
+					dst.SetRGBA(x, row, color.RGBA{uint8(s[0] / n >> 8), uint8(s[1] / n >> 8), uint8(s[2] / n >> 8), uint8(s[3] / n >> 8)})
+				}
+			}
+			sums[x], counts[x] = [4]uint64{}, 0
+		}
+	}
+	for sy := 0; sy < srcH; sy++ {
+		if y := sy * dstH / srcH; y != row {
+			flush()
+			row = y
+		}
+		for sx := 0; sx < srcW; sx++ {
+			r, g, bl, a := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+			x := sx * dstW / srcW
+			sums[x][0] += uint64(r)
+			sums[x][1] += uint64(g)
+			sums[x][2] += uint64(bl)
+			sums[x][3] += uint64(a)
+			counts[x]++
+		}
+	}
+	flush()
+	if deep != nil {
+		return deep
+	}
+	return dst
+}
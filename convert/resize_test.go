@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFitWithin(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{0, 0, 0, 255}
+			if x%2 == 0 {
+				c = color.RGBA{200, 100, 50, 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	if got := FitWithin(src, 0); got != image.Image(src) {
+		t.Error("max size 0 should keep the image")
+	}
+	if got := FitWithin(src, 500); got != image.Image(src) {
+		t.Error("images within the limit should be kept")
+	}
+
+	got := FitWithin(src, 100)
+	if b := got.Bounds(); b.Dx() != 100 || b.Dy() != 25 {
+		t.Fatalf("scaled to %v, want 100x25", b)
+	}
+	r, g, b, _ := got.At(50, 10).RGBA()
+	if r>>8 != 100 || g>>8 != 50 || b>>8 != 25 {
+		t.Errorf("pixel = %d,%d,%d; want the average 100,50,25", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestFitWithinKeepsDepth(t *testing.T) {
+	src := image.NewRGBA64(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		src.SetRGBA64(x, 0, color.RGBA64{0x1001, 0x1001, 0x1001, 0xffff})
+		src.SetRGBA64(x, 1, color.RGBA64{0x1003, 0x1003, 0x1003, 0xffff})
+	}
+	got, ok := FitWithin(src, 2).(*image.RGBA64)
+	if !ok {
+		t.Fatalf("got a %T", FitWithin(src, 2))
+	}
+	if c := got.RGBA64At(0, 0); c.R != 0x1002 {
+		t.Errorf("pixel = %v, want the 16-bit average 0x1002", c)
+	}
+}
@@ -0,0 +1,253 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/adrium/goheif"
+	"github.com/adrium/goheif/heif"
+	"github.com/adrium/goheif/libde265"
+)
+
+// ErrNotTiled is returned by DecodeBanded and DecodeTile for images that
+// aren't tiled.
+var ErrNotTiled = errors.New("not a tiled image")
+
+// tileGrid describes a HEIF grid image: the frame is columns x rows tiles
+// of the same size, cropped to width x height.
+type tileGrid struct {
+	columns, rows         int
+	tileWidth, tileHeight int
+	width, height         int
+	ratio                 image.YCbCrSubsampleRatio
+	decodeTile            func(i int) (*image.YCbCr, error)
+}
+
+// bandedImage is an image.Image over a tile grid that decodes one row of
+// tiles (a band) at a time. The JPEG encoder reads top to bottom, so only
+// the current and previous band are ever held in memory.
+type bandedImage struct {
+	ctx  context.Context
+	grid tileGrid
+	band [2]*image.YCbCr // current and previous band
+	rows [2]int
+	err  error
+}
+
+func newBandedImage(ctx context.Context, grid tileGrid) *bandedImage {
+	return &bandedImage{ctx: ctx, grid: grid, rows: [2]int{-1, -1}}
+}
+
+func (b *bandedImage) ColorModel() color.Model { return color.YCbCrModel }
+
+func (b *bandedImage) Bounds() image.Rectangle {
+	return image.Rect(0, 0, b.grid.width, b.grid.height)
+}
+
+func (b *bandedImage) At(x, y int) color.Color {
+	row := y / b.grid.tileHeight
+	for i, r := range b.rows {
+		if r == row {
+			return b.band[i].YCbCrAt(x, y)
+		}
+	}
+	if b.err != nil {
+		return color.YCbCr{}
+	}
+	band, err := b.loadBand(row)
+	if err != nil {
+		b.err = err
+		return color.YCbCr{}
+	}
+	putBand(b.band[1])
+	b.band[1], b.rows[1] = b.band[0], b.rows[0]
+	b.band[0], b.rows[0] = band, row
+	return band.YCbCrAt(x, y)
+}
+
+// IsBanded reports whether img was opened by DecodeBanded. It can be read
+// only once, top to bottom.
+func IsBanded(img image.Image) bool {
+	_, ok := img.(*bandedImage)
+	return ok
+}
+
+// release returns the bands to the pool once the encoder is done.
+func (b *bandedImage) release() {
+	for i := range b.band {
+		putBand(b.band[i])
+		b.band[i], b.rows[i] = nil, -1
+	}
+}
+
+// loadBand decodes the tiles of one grid row into a single YCbCr band.
+func (b *bandedImage) loadBand(row int) (*image.YCbCr, error) {
+	if err := b.ctx.Err(); err != nil {
+		return nil, err
+	}
+	g := b.grid
+	top := row * g.tileHeight
+	band := getBand(image.Rect(0, top, g.columns*g.tileWidth, top+g.tileHeight), g.ratio)
+	for col := 0; col < g.columns; col++ {
+		tile, err := g.decodeTile(row*g.columns + col)
+		if err == nil && (tile.Rect.Dx() != g.tileWidth || tile.Rect.Dy() != g.tileHeight || tile.SubsampleRatio != g.ratio) {
+			err = errors.New("inconsistent tile dimensions")
+		}
+		if err != nil {
+			putBand(band)
+			return nil, err
+		}
+		copyTile(band, tile, col*g.tileWidth, top)
+	}
+	return band, nil
+}
+
+// copyTile copies tile into band with its top-left corner at (x, y).
+func copyTile(band, tile *image.YCbCr, x, y int) {
+	r := tile.Rect
+	for i := 0; i < r.Dy(); i++ {
+		src := tile.YOffset(r.Min.X, r.Min.Y+i)
+		copy(band.Y[band.YOffset(x, y+i):], tile.Y[src:src+r.Dx()])
+	}
+	cw := tile.COffset(r.Max.X-1, r.Min.Y) - tile.COffset(r.Min.X, r.Min.Y) + 1
+	for i := 0; i < r.Dy(); i++ {
+		src := tile.COffset(r.Min.X, r.Min.Y+i)
+		dst := band.COffset(x, y+i)
+		copy(band.Cb[dst:dst+cw], tile.Cb[src:src+cw])
+		copy(band.Cr[dst:dst+cw], tile.Cr[src:src+cw])
+	}
+}
+
+// openTileGrid reads the grid layout of a HEIC file's primary image and
+// sets up per-tile decoding. It returns ErrNotTiled for single-item images,
+// which gain nothing from banding. The returned function frees the decoder.
+func openTileGrid(ra io.ReaderAt) (tileGrid, func(), error) {
+	hf := heif.Open(ra)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return tileGrid{}, nil, err
+	}
+	return openGridItem(hf, item)
+}
+
+// openGridItem is openTileGrid for any image item of hf.
+func openGridItem(hf *heif.File, item *heif.Item) (tileGrid, func(), error) {
+	if item.Info == nil || item.Info.ItemType != "grid" {
+		return tileGrid{}, nil, ErrNotTiled
+	}
+	if err := checkGridLayout(hf, item); err != nil {
+		return tileGrid{}, nil, err
+	}
+	width, height, ok := item.SpatialExtents()
+	if !ok {
+		return tileGrid{}, nil, errors.New("no dimension")
+	}
+	data, err := hf.GetItemData(item)
+	if err != nil {
+		return tileGrid{}, nil, err
+	}
+	columns, rows, err := parseGridBox(data)
+	if err != nil {
+		return tileGrid{}, nil, err
+	}
+	dimg := item.Reference("dimg")
+	if dimg == nil || len(dimg.ToItemIDs) != columns*rows {
+		return tileGrid{}, nil, errors.New("tile count doesn't match the grid")
+	}
+
+	dec, err := libde265.NewDecoder()
+	if err != nil {
+		return tileGrid{}, nil, err
+	}
+	grid := tileGrid{columns: columns, rows: rows, width: width, height: height}
+	grid.decodeTile = func(i int) (*image.YCbCr, error) {
+		tile, err := hf.ItemByID(dimg.ToItemIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		return decodeHevcTile(dec, hf, tile)
+	}
+	first, err := grid.decodeTile(0)
+	if err != nil {
+		dec.Free()
+		return tileGrid{}, nil, err
+	}
+	grid.tileWidth, grid.tileHeight, grid.ratio = first.Rect.Dx(), first.Rect.Dy(), first.SubsampleRatio
+	return grid, dec.Free, nil
+}
+
+// parseGridBox reads the rows and columns of an ImageGrid item.
+func parseGridBox(data []byte) (columns, rows int, err error) {
+	if len(data) < 8 {
+		return 0, 0, errors.New("invalid grid data")
+	}
+	return int(data[3]) + 1, int(data[2]) + 1, nil
+}
+
+func decodeHevcTile(dec *libde265.Decoder, hf *heif.File, item *heif.Item) (*image.YCbCr, error) {
+	if item.Info == nil || item.Info.ItemType != "hvc1" {
+		return nil, errors.New("unsupported tile type")
+	}
+	hvcc, ok := item.HevcConfig()
+	if !ok {
+		return nil, errors.New("no hvcC")
+	}
+	data, err := hf.GetItemData(item)
+	if err != nil {
+		return nil, err
+	}
+	dec.Reset()
+	dec.Push(hvcc.AsHeader())
+	img, err := dec.DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	ycc, ok := img.(*image.YCbCr)
+	if !ok {
+		return nil, errors.New("tile is not YCbCr")
+	}
+	return ycc, nil
+}
+
+// DecodeBanded opens the tiled HEIC in r to be decoded one row of tiles at
+// a time while its image is read top to bottom, as EncodeJPEG does,
+// instead of holding the whole frame. Its colors can't be converted, so a
+// profile other than sRGB is embedded. Once the image is encoded, done
+// frees the decoder and returns the error of the decoding, if any.
+func DecodeBanded(ctx context.Context, r io.ReaderAt, o Options) (d Decoded, done func() error, err error) {
+	grid, free, err := openTileGrid(r)
+	if err != nil {
+		return Decoded{}, nil, err
+	}
+	if d.Exif, err = goheif.ExtractExif(r); err != nil && !errors.Is(err, heif.ErrNoEXIF) {
+		free()
+		return Decoded{}, nil, err
+	}
+	if p, ok := wideProfile(r); ok {
+		plan := planColors(p, o.ColorTarget, false)
+		d.Profile = Profile{p.name, plan.status, plan.icc}
+	}
+
+	cancel := func() {}
+	if o.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+	}
+	img := newBandedImage(ctx, grid)
+	d.Image = img
+	return d, func() error {
+		img.release()
+		free()
+		cancel()
+		switch {
+		case img.err == nil:
+			return nil
+		case errors.Is(img.err, context.DeadlineExceeded):
+			return ErrDecodeTimeout
+		}
+		return fmt.Errorf("decode: %v", img.err)
+	}, nil
+}
@@ -0,0 +1,62 @@
+package convert
+
+import (
+	"context"
+	"image"
+	"testing"
+)
+
+func testGrid(full *image.YCbCr, tileWidth, tileHeight int) tileGrid {
+	columns := (full.Rect.Dx() + tileWidth - 1) / tileWidth
+	rows := (full.Rect.Dy() + tileHeight - 1) / tileHeight
+	padded := image.NewYCbCr(image.Rect(0, 0, columns*tileWidth, rows*tileHeight), full.SubsampleRatio)
+	copyTile(padded, full, 0, 0)
+	return tileGrid{
+		columns: columns, rows: rows,
+		tileWidth: tileWidth, tileHeight: tileHeight,
+		width: full.Rect.Dx(), height: full.Rect.Dy(),
+		ratio: full.SubsampleRatio,
+		decodeTile: func(i int) (*image.YCbCr, error) {
+			x, y := i%columns*tileWidth, i/columns*tileHeight
+			tile := image.NewYCbCr(image.Rect(0, 0, tileWidth, tileHeight), full.SubsampleRatio)
+			copyTile(tile, padded.SubImage(image.Rect(x, y, x+tileWidth, y+tileHeight)).(*image.YCbCr), 0, 0)
+			return tile, nil
+		},
+	}
+}
+
+func TestBandedImageMatchesFullFrame(t *testing.T) {
+	full := image.NewYCbCr(image.Rect(0, 0, 100, 70), image.YCbCrSubsampleRatio420)
+	for i := range full.Y {
+		full.Y[i] = uint8(i * 7)
+	}
+	for i := range full.Cb {
+		full.Cb[i], full.Cr[i] = uint8(i*3), uint8(i*5)
+	}
+
+	banded := newBandedImage(context.Background(), testGrid(full, 32, 16))
+	if banded.Bounds() != full.Bounds() {
+		t.Fatalf("bounds = %v, want %v", banded.Bounds(), full.Bounds())
+	}
+	for y := 0; y < 70; y++ {
+		for x := 0; x < 100; x++ {
+			if got, want := banded.At(x, y), full.YCbCrAt(x, y); got != want {
+				t.Fatalf("At(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	if banded.err != nil {
+		t.Fatal(banded.err)
+	}
+}
+
+func TestBandedImageCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	full := image.NewYCbCr(image.Rect(0, 0, 16, 16), image.YCbCrSubsampleRatio420)
+	banded := newBandedImage(ctx, testGrid(full, 8, 8))
+	banded.At(0, 0)
+	if banded.err == nil {
+		t.Error("expected the cancelled context to stop decoding")
+	}
+}
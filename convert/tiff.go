@@ -0,0 +1,302 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+func init() {
+	image.RegisterFormat("tiff", "II*\x00", decodeTIFF, decodeTIFFConfig)
+	image.RegisterFormat("tiff", "MM\x00*", decodeTIFF, decodeTIFFConfig)
+}
+
+var errUnsupportedTIFF = errors.New("unsupported TIFF: only uncompressed RGB is read")
+
+// The TIFF tags the outputs use.
+const (
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffPhotometric     = 262
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffStripByteCounts = 279
+	tiffPlanarConfig    = 284
+	tiffICCProfile      = 34675
+)
+
+// The TIFF field types the outputs use.
+const (
+	tiffShort     = 3
+	tiffLong      = 4
+	tiffUndefined = 7
+)
+
+// encodeTIFF writes img as a baseline TIFF: uncompressed RGB in a single
+// strip, with 16 bits per sample when img is an *image.RGBA64 and 8
+// otherwise, and the ICC profile icc when it isn't nil.
+func encodeTIFF(w io.Writer, img image.Image, icc []byte) error {
+	b := img.Bounds()
+	rgba64, deep := img.(*image.RGBA64)
+	bytesPerSample := 1
+	if deep {
+		bytesPerSample = 2
+	}
+	stripSize := int64(b.Dx()) * int64(b.Dy()) * 3 * int64(bytesPerSample)
+	if 8+stripSize > 1<<32-1024-int64(len(icc)) {
+		return errors.New("image too large for a TIFF file")
+	}
+
+	type entry struct {
+		tag, typ uint16
+		count    uint32
+		value    uint32 // or the offset of the values
+	}
+	entries := []entry{
+		{tiffImageWidth, tiffLong, 1, uint32(b.Dx())},
+		{tiffImageLength, tiffLong, 1, uint32(b.Dy())},
+		{tiffBitsPerSample, tiffShort, 3, 0}, // at extra
+		{tiffCompression, tiffShort, 1, 1},
+		{tiffPhotometric, tiffShort, 1, 2},
+		{tiffStripOffsets, tiffLong, 1, 8},
+		{tiffSamplesPerPixel, tiffShort, 1, 3},
+		{tiffRowsPerStrip, tiffLong, 1, uint32(b.Dy())},
+		{tiffStripByteCounts, tiffLong, 1, uint32(stripSize)},
+		{tiffPlanarConfig, tiffShort, 1, 1},
+	}
+	if icc != nil {
+		entries = append(entries, entry{tiffICCProfile, tiffUndefined, uint32(len(icc)), 0}) // at extra+6
+	}
+	// The strip comes first, so it can be written as it's converted; the
+	// directory and the values too big for it follow.
+	ifd := uint32(8 + stripSize + stripSize%2)
+	extra := ifd + 2 + 12*uint32(len(entries)) + 4
+	entries[2].value = extra
+	if icc != nil {
+		entries[len(entries)-1].value = extra + 6
+	}
+
+	le := binary.LittleEndian
+	bw := bufio.NewWriter(w)
+	bw.Write([]byte("II*\x00"))
+	bw.Write(le.AppendUint32(nil, ifd))
+	row := make([]byte, b.Dx()*3*bytesPerSample)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := (x - b.Min.X) * 3 * bytesPerSample
+			switch {
+			case deep:
+				o := rgba64.PixOffset(x, y)
+				p := rgba64.Pix[o : o+6 : o+6]
+				// 16-bit samples in the file's byte order.
+				row[i], row[i+1], row[i+2], row[i+3], row[i+4], row[i+5] = p[1], p[0], p[3], p[2], p[5], p[4]
+			default:
+				r, g, bl, _ := img.At(x, y).RGBA()
+				row[i], row[i+1], row[i+2] = uint8(r>>8), uint8(g>>8), uint8(bl>>8)
+			}
+		}
+		if _, err := bw.Write(row); err != nil {
+			return err
+		}
+	}
+	if stripSize%2 != 0 {
+		bw.WriteByte(0) // the directory starts on a word boundary
+	}
+	dir := le.AppendUint16(nil, uint16(len(entries)))
+	for _, e := range entries {
+		dir = le.AppendUint16(dir, e.tag)
+		dir = le.AppendUint16(dir, e.typ)
+		dir = le.AppendUint32(dir, e.count)
+		dir = le.AppendUint32(dir, e.value)
+	}
+	dir = le.AppendUint32(dir, 0) // no next directory
+	bits := uint16(8 * bytesPerSample)
+	dir = le.AppendUint16(le.AppendUint16(le.AppendUint16(dir, bits), bits), bits)
+	dir = append(dir, icc...)
+	if _, err := bw.Write(dir); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// tiffLayout is what decodeTIFF reads of a TIFF's first directory.
+type tiffLayout struct {
+	order         binary.ByteOrder
+	width, height int
+	bits          int
+	strips        []int64 // offsets
+	counts        []int64
+}
+
+func readTIFFLayout(r io.ReaderAt) (tiffLayout, error) {
+	var l tiffLayout
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return l, err
+	}
+	switch string(header[:4]) {
+	case "II*\x00":
+		l.order = binary.LittleEndian
+	case "MM\x00*":
+		l.order = binary.BigEndian
+	default:
+		return l, errors.New("not a TIFF file")
+	}
+	offset := int64(l.order.Uint32(header[4:]))
+	n := make([]byte, 2)
+	if _, err := r.ReadAt(n, offset); err != nil {
+		return l, err
+	}
+	dir := make([]byte, 12*int(l.order.Uint16(n)))
+	if _, err := r.ReadAt(dir, offset+2); err != nil {
+		return l, err
+	}
+	// values reads the values of an entry of SHORTs or LONGs.
+	values := func(e []byte) ([]int64, error) {
+		typ, count := l.order.Uint16(e[2:]), int64(l.order.Uint32(e[4:]))
+		size := int64(2)
+		if typ == tiffLong {
+			size = 4
+		} else if typ != tiffShort {
+			return nil, errUnsupportedTIFF
+		}
+		data := e[8:12]
+		if count*size > 4 {
+			if count > 1<<20 {
+				return nil, errUnsupportedTIFF
+			}
+			data = make([]byte, count*size)
+			if _, err := r.ReadAt(data, int64(l.order.Uint32(e[8:]))); err != nil {
+				return nil, err
+			}
+		}
+		v := make([]int64, count)
+		for i := range v {
+			if size == 2 {
+				v[i] = int64(l.order.Uint16(data[2*i:]))
+			} else {
+				v[i] = int64(l.order.Uint32(data[4*i:]))
+			}
+		}
+		return v, nil
+	}
+	samples, compression, photometric, planar := int64(1), int64(1), int64(-1), int64(1)
+	for i := 0; i < len(dir); i += 12 {
+		e := dir[i : i+12]
+		tag := l.order.Uint16(e)
+		switch tag {
+		case tiffImageWidth, tiffImageLength, tiffBitsPerSample, tiffCompression, tiffPhotometric,
+			tiffStripOffsets, tiffSamplesPerPixel, tiffStripByteCounts, tiffPlanarConfig:
+		default:
+			continue
+		}
+		v, err := values(e)
+		if err != nil {
+			return l, err
+		}
+		if len(v) == 0 {
+			return l, errUnsupportedTIFF
+		}
+		switch tag {
+		case tiffImageWidth:
+			l.width = int(v[0])
+		case tiffImageLength:
+			l.height = int(v[0])
+		case tiffBitsPerSample:
+			l.bits = int(v[0])
+		case tiffCompression:
+			compression = v[0]
+		case tiffPhotometric:
+			photometric = v[0]
+		case tiffStripOffsets:
+			l.strips = v
+		case tiffSamplesPerPixel:
+			samples = v[0]
+		case tiffStripByteCounts:
+			l.counts = v
+		case tiffPlanarConfig:
+			planar = v[0]
+		}
+	}
+	if compression != 1 || photometric != 2 || samples != 3 || planar != 1 || (l.bits != 8 && l.bits != 16) ||
+		len(l.strips) == 0 || len(l.strips) != len(l.counts) || l.width <= 0 || l.height <= 0 ||
+		int64(l.width)*int64(l.height) >= 1<<28 {
+		return l, errUnsupportedTIFF
+	}
+	return l, nil
+}
+
+func decodeTIFFConfig(r io.Reader) (image.Config, error) {
+	ra, err := readerAt(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	l, err := readTIFFLayout(ra)
+	if err != nil {
+		return image.Config{}, err
+	}
+	model := color.RGBAModel
+	if l.bits == 16 {
+		model = color.RGBA64Model
+	}
+	return image.Config{ColorModel: model, Width: l.width, Height: l.height}, nil
+}
+
+// decodeTIFF reads the uncompressed RGB TIFF files encodeTIFF writes, into
+// an *image.RGBA or, for 16-bit ones, an *image.RGBA64.
+func decodeTIFF(r io.Reader) (image.Image, error) {
+	ra, err := readerAt(r)
+	if err != nil {
+		return nil, err
+	}
+	l, err := readTIFFLayout(ra)
+	if err != nil {
+		return nil, err
+	}
+	bytesPerSample := l.bits / 8
+	var data []byte
+	for i, offset := range l.strips {
+		strip := make([]byte, l.counts[i])
+		if _, err := ra.ReadAt(strip, offset); err != nil {
+			return nil, err
+		}
+		data = append(data, strip...)
+	}
+	if len(data) < l.width*l.height*3*bytesPerSample {
+		return nil, io.ErrUnexpectedEOF
+	}
+	rect := image.Rect(0, 0, l.width, l.height)
+	if l.bits == 8 {
+		img := image.NewRGBA(rect)
+		for i := 0; i < l.width*l.height; i++ {
+			copy(img.Pix[4*i:], data[3*i:3*i+3])
+			img.Pix[4*i+3] = 0xff
+		}
+		return img, nil
+	}
+	img := image.NewRGBA64(rect)
+	for i := 0; i < l.width*l.height; i++ {
+		for c := 0; c < 3; c++ {
+			binary.BigEndian.PutUint16(img.Pix[8*i+2*c:], l.order.Uint16(data[6*i+2*c:]))
+		}
+		img.Pix[8*i+6], img.Pix[8*i+7] = 0xff, 0xff
+	}
+	return img, nil
+}
+
+// readerAt gives random access to r, reading it all unless it already
+// allows it.
+func readerAt(r io.Reader) (io.ReaderAt, error) {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra, nil
+	}
+	data, err := io.ReadAll(r)
+	return bytes.NewReader(data), err
+}
@@ -0,0 +1,49 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTIFFRoundTrip(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 5, 3))
+	rgba64 := image.NewRGBA64(image.Rect(0, 0, 5, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			rgba.SetRGBA(x, y, color.RGBA{uint8(40 * x), uint8(80 * y), 7, 255})
+			rgba64.SetRGBA64(x, y, color.RGBA64{uint16(10001 * x), uint16(20003 * y), 0x1234, 0xffff})
+		}
+	}
+	icc := displayP3Space.iccProfile("Display P3")
+	for _, img := range []image.Image{rgba, rgba64, rgba64.SubImage(image.Rect(1, 1, 4, 3))} {
+		for _, profile := range [][]byte{nil, icc} {
+			var buf bytes.Buffer
+			if err := encodeTIFF(&buf, img, profile); err != nil {
+				t.Fatal(err)
+			}
+			got, format, err := image.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("%T: %v", img, err)
+			}
+			if format != "tiff" || got.Bounds().Size() != img.Bounds().Size() {
+				t.Fatalf("%T: decoded a %s of %v", img, format, got.Bounds())
+			}
+			b := img.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if want, c := color.RGBA64Model.Convert(img.At(x, y)), got.At(x-b.Min.X, y-b.Min.Y); color.RGBA64Model.Convert(c) != want {
+						t.Fatalf("%T: pixel %d,%d is %v, want %v", img, x, y, c, want)
+					}
+				}
+			}
+			if profile != nil && !bytes.Contains(buf.Bytes(), icc) {
+				t.Errorf("%T: profile missing", img)
+			}
+		}
+	}
+	if _, _, err := image.Decode(bytes.NewReader([]byte("II*\x00\x08\x00\x00\x00\x00\x00"))); err == nil {
+		t.Error("decoded a TIFF without an image")
+	}
+}
@@ -0,0 +1,206 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/adrium/goheif/heif"
+	"github.com/adrium/goheif/libde265"
+)
+
+// Grid is the tile layout of the primary image of a HEIC file.
+type Grid struct {
+	Width, Height         int // of the image
+	Columns, Rows         int // zero when it isn't tiled
+	TileWidth, TileHeight int
+}
+
+// Tiles is the number of tiles of g.
+func (g Grid) Tiles() int { return g.Columns * g.Rows }
+
+// ReadGrid reads how the primary image of the HEIC in r is tiled.
+func ReadGrid(r io.ReaderAt) (Grid, error) {
+	hf := heif.Open(r)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return Grid{}, err
+	}
+	columns, rows, tiles, err := gridTiles(hf, item)
+	if err != nil {
+		return Grid{}, err
+	}
+	var g Grid
+	g.Width, g.Height, _ = item.SpatialExtents()
+	if tiles != nil {
+		g.Columns, g.Rows = columns, rows
+		g.TileWidth, g.TileHeight, _ = tiles[0].SpatialExtents()
+	}
+	return g, nil
+}
+
+// DecodeTile decodes tile i of the primary image of the HEIC in r, whole:
+// tiles on the right and bottom edges reach past the image.
+func DecodeTile(r io.ReaderAt, i int) (*image.RGBA64, error) {
+	hf := heif.Open(r)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return nil, err
+	}
+	_, _, tiles, err := gridTiles(hf, item)
+	switch {
+	case err != nil:
+		return nil, err
+	case tiles == nil:
+		return nil, ErrNotTiled
+	case i < 0 || i >= len(tiles):
+		return nil, fmt.Errorf("no tile %d", i)
+	}
+	return decodeItem16(hf, tiles[i])
+}
+
+// gridTiles returns the grid layout of item, and its tiles, when it's a
+// grid; nil otherwise.
+func gridTiles(hf *heif.File, item *heif.Item) (columns, rows int, tiles []*heif.Item, err error) {
+	if item.Info == nil || item.Info.ItemType != "grid" {
+		return 0, 0, nil, nil
+	}
+	data, err := hf.GetItemData(item)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if columns, rows, err = parseGridBox(data); err != nil {
+		return 0, 0, nil, err
+	}
+	dimg := item.Reference("dimg")
+	if dimg == nil || len(dimg.ToItemIDs) != columns*rows {
+		n := 0
+		if dimg != nil {
+			n = len(dimg.ToItemIDs)
+		}
+		return 0, 0, nil, fmt.Errorf("the grid has %d tiles for %d×%d", n, columns, rows)
+	}
+	for _, id := range dimg.ToItemIDs {
+		tile, err := hf.ItemByID(id)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		tiles = append(tiles, tile)
+	}
+	return columns, rows, tiles, nil
+}
+
+// checkGridLayout checks a grid's tiles against its frame before it's
+// decoded. Decoders place the tiles by their count and the size of the
+// first, so tiles of different sizes, or too few or too many for the
+// frame, reassemble into misplaced or missing pieces without an error.
+func checkGridLayout(hf *heif.File, item *heif.Item) error {
+	columns, rows, tiles, err := gridTiles(hf, item)
+	if err != nil || tiles == nil {
+		return err
+	}
+	width, height, ok := item.SpatialExtents()
+	if !ok {
+		return nil // the decoder reports it
+	}
+	data, err := hf.GetItemData(item)
+	if err != nil {
+		return err
+	}
+	if outWidth, outHeight, ok := gridOutputSize(data); ok && (outWidth != width || outHeight != height) {
+		return fmt.Errorf("the grid is %d×%d, but the image %d×%d", outWidth, outHeight, width, height)
+	}
+	var tileWidth, tileHeight int
+	for i, tile := range tiles {
+		w, h, ok := tile.SpatialExtents()
+		switch {
+		case !ok:
+		case tileWidth == 0:
+			tileWidth, tileHeight = w, h
+		case w != tileWidth || h != tileHeight:
+			return fmt.Errorf("tile %d is %d×%d, unlike the %d×%d of the first", i, w, h, tileWidth, tileHeight)
+		}
+	}
+	switch {
+	case tileWidth == 0:
+		return nil
+	case columns*tileWidth < width || rows*tileHeight < height:
+		return fmt.Errorf("%d×%d tiles of %d×%d don't cover the %d×%d image", columns, rows, tileWidth, tileHeight, width, height)
+	case (columns-1)*tileWidth >= width || (rows-1)*tileHeight >= height:
+		return fmt.Errorf("%d×%d tiles of %d×%d leave whole tiles outside the %d×%d image", columns, rows, tileWidth, tileHeight, width, height)
+	}
+	return nil
+}
+
+// gridOutputSize reads the frame size an ImageGrid item states.
+func gridOutputSize(data []byte) (width, height int, ok bool) {
+	if data[1]&1 == 0 {
+		return int(binary.BigEndian.Uint16(data[4:])), int(binary.BigEndian.Uint16(data[6:])), true
+	}
+	if len(data) < 12 {
+		return 0, 0, false
+	}
+	return int(binary.BigEndian.Uint32(data[4:])), int(binary.BigEndian.Uint32(data[8:])), true
+}
+
+// CheckGrid checks the tiles of the primary image of the HEIC in r
+// against the image before it's decoded, which places misfitting tiles
+// wrongly without an error.
+func CheckGrid(r io.ReaderAt) error {
+	hf := heif.Open(r)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return nil // the decoder reports it
+	}
+	return checkGridLayout(hf, item)
+}
+
+// verifyGridSeams checks img, the reassembled primary image of the HEIC
+// in r, against its tiles decoded one by one: along its edges, where a
+// misplaced tile shows, each must match the frame exactly. Deep images
+// are checked against tiles of the 16-bit decoder, others against those
+// of goheif's.
+func verifyGridSeams(r io.ReaderAt, img image.Image) error {
+	hf := heif.Open(r)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return err
+	}
+	columns, _, tiles, err := gridTiles(hf, item)
+	if err != nil || tiles == nil {
+		return err
+	}
+	decodeTile := func(tile *heif.Item) (image.Image, error) { return decodeItem16(hf, tile) }
+	if !isDeep(img) {
+		dec, err := libde265.NewDecoder()
+		if err != nil {
+			return err
+		}
+		defer dec.Free()
+		decodeTile = func(tile *heif.Item) (image.Image, error) { return decodeHevcTile(dec, hf, tile) }
+	}
+
+	b := img.Bounds()
+	for i, item := range tiles {
+		tile, err := decodeTile(item)
+		if err != nil {
+			return fmt.Errorf("tile %d: %v", i, err)
+		}
+		t := tile.Bounds()
+		x0, y0 := b.Min.X+i%columns*t.Dx(), b.Min.Y+i/columns*t.Dy()
+		visible := image.Rect(x0, y0, x0+t.Dx(), y0+t.Dy()).Intersect(b)
+		matches := func(x, y int) bool { return img.At(x, y) == tile.At(t.Min.X+x-x0, t.Min.Y+y-y0) }
+		for x := visible.Min.X; x < visible.Max.X; x++ {
+			if !matches(x, visible.Min.Y) || !matches(x, visible.Max.Y-1) {
+				return fmt.Errorf("tile %d (row %d, column %d) doesn't match the reassembled image at its edges", i, i/columns+1, i%columns+1)
+			}
+		}
+		for y := visible.Min.Y; y < visible.Max.Y; y++ {
+			if !matches(visible.Min.X, y) || !matches(visible.Max.X-1, y) {
+				return fmt.Errorf("tile %d (row %d, column %d) doesn't match the reassembled image at its edges", i, i/columns+1, i%columns+1)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+
+	"github.com/adrium/goheif/heif"
+)
+
+func TestCheckGridLayout(t *testing.T) {
+	mismatched := heicsample.Tiled(8, 2, 2, 128, 128, nil)
+	// The grid box says 128×120, the ispe 128×128.
+	i := bytes.Index(mismatched, heicsample.GridData(2, 2, 128, 128))
+	copy(mismatched[i:], heicsample.GridData(2, 2, 128, 120))
+
+	for _, tc := range []struct {
+		name string
+		file []byte
+		err  string
+	}{
+		{"single", heicsample.Single(8), ""},
+		{"grid", heicsample.Grid(), ""},
+		{"cropped", heicsample.Tiled(8, 2, 2, 100, 120, nil), ""},
+		{"too few tiles", heicsample.Tiled(8, 2, 2, 200, 128, nil), "don't cover"},
+		{"too many tiles", heicsample.Tiled(8, 3, 2, 128, 128, nil), "outside"},
+		{"tile sizes", heicsample.Tiled(8, 2, 2, 100, 100, map[int]int{3: 32}), "tile 3 is 32×32"},
+		{"grid size", mismatched, "the grid is 128×120"},
+	} {
+		hf := heif.Open(bytes.NewReader(tc.file))
+		item, err := hf.PrimaryItem()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = checkGridLayout(hf, item)
+		if (err == nil) != (tc.err == "") || (err != nil && !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestGridSeams(t *testing.T) {
+	for _, depth := range []int{8, 10} {
+		file := heicsample.Tiled(depth, 2, 2, 2*heicsample.Size, 2*heicsample.Size, nil)
+		img, err := decodeAnyDepth(context.Background(), bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The pattern runs across the seams only if each tile is in place.
+		if !heicsample.Matches(img, heicsample.Pattern) {
+			t.Errorf("%d-bit: the grid reassembles to the wrong pixels", depth)
+		}
+		if err := verifyGridSeams(bytes.NewReader(file), img); err != nil {
+			t.Errorf("%d-bit: %v", depth, err)
+		}
+
+		// Swap the top two tiles.
+		left, right := image.Rect(0, 0, heicsample.Size, heicsample.Size), image.Rect(heicsample.Size, 0, 2*heicsample.Size, heicsample.Size)
+		var bad image.Image
+		if ycc, ok := img.(*image.YCbCr); ok {
+			swapped := image.NewYCbCr(ycc.Rect, ycc.SubsampleRatio)
+			copyTile(swapped, ycc, 0, 0)
+			copyTile(swapped, ycc.SubImage(right).(*image.YCbCr), 0, 0)
+			copyTile(swapped, ycc.SubImage(left).(*image.YCbCr), heicsample.Size, 0)
+			bad = swapped
+		} else {
+			swapped := image.NewRGBA64(img.Bounds())
+			draw.Draw(swapped, swapped.Bounds(), img, image.Point{}, draw.Src)
+			draw.Draw(swapped, left, img, right.Min, draw.Src)
+			draw.Draw(swapped, right, img, left.Min, draw.Src)
+			bad = swapped
+		}
+		if err := verifyGridSeams(bytes.NewReader(file), bad); err == nil || !strings.Contains(err.Error(), "tile 0") {
+			t.Errorf("%d-bit: a broken frame passed: %v", depth, err)
+		}
+	}
+
+	cropped := heicsample.Tiled(8, 2, 2, 100, 120, nil)
+	img, err := decodeAnyDepth(context.Background(), bytes.NewReader(cropped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyGridSeams(bytes.NewReader(cropped), img); err != nil {
+		t.Errorf("cropped: %v", err)
+	}
+	if err := verifyGridSeams(bytes.NewReader(heicsample.Single(8)), img); err != nil {
+		t.Errorf("single image: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import "flag"
+
+var (
+	cropAspect = flag.String("crop", "", "crop to this aspect ratio, e.g. 1:1 for avatars or 16:9 (empty keeps the whole image)")
+	cropFocus  = flag.String("crop-focus", "center", "where -crop keeps: center, or subject to follow detail and skin tones (faces)")
+)
+
+var cropFocuses = map[string]bool{"center": true, "subject": true}
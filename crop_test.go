@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestSettingsApplyCrop(t *testing.T) {
+	s := globalSettings()
+	if err := s.apply(map[string]interface{}{"crop": "4:5", "crop-focus": "subject"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Crop != "4:5" || s.CropFocus != "subject" {
+		t.Errorf("settings = %+v", s)
+	}
+	for _, bad := range []map[string]interface{}{{"crop": "wide"}, {"crop-focus": "faces"}} {
+		if err := s.apply(bad); err == nil {
+			t.Errorf("apply(%v) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	dedupeLibrary  = flag.String("dedupe-library", "", "folder of existing JPEGs (e.g. a Photos export) to check each photo against by perceptual hash before writing it")
+	dedupeAction   = flag.String("dedupe", "skip", "what to do with photos already in -dedupe-library: skip, or link to the existing JPEG")
+	dedupeDistance = flag.Int("dedupe-distance", 6, "bits of the 64-bit hash two photos may differ in and still count as the same")
+)
+
+var dedupeActions = map[string]bool{"skip": true, "link": true}
+
+// libraryCacheName stores the library's hashes between runs, keyed by the
+// file's path, size and modification time.
+const libraryCacheName = ".heictojpeg-hashes.json"
+
+type libraryEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    uint64    `json:"dhash"`
+}
+
+// library is the hash index of -dedupe-library.
+type library struct {
+	dir     string
+	entries map[string]libraryEntry
+}
+
+// loadLibrary hashes the JPEGs under dir, reusing the cached hashes of
+// files that haven't changed.
+func loadLibrary(dir string, workers int) (*library, error) {
+	lib := &library{dir: dir, entries: map[string]libraryEntry{}}
+	cached := map[string]libraryEntry{}
+	if data, err := os.ReadFile(longPath(filepath.Join(dir, libraryCacheName))); err == nil {
+		json.Unmarshal(data, &cached)
+	}
+
+	type job struct {
+		rel  string
+		info fs.FileInfo
+	}
+	jobs := make(chan job, workers)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				hash, err := hashJPEG(filepath.Join(dir, j.rel))
+				if err != nil {
+					fmt.Printf(tr("Skipping %s in the library: %v\n"), j.rel, err)
+					continue
+				}
+				mu.Lock()
+				lib.entries[j.rel] = libraryEntry{Size: j.info.Size(), ModTime: j.info.ModTime(), Hash: hash}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".jpg" && ext != ".jpeg" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if e, ok := cached[rel]; ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+			mu.Lock()
+			lib.entries[rel] = e
+			mu.Unlock()
+			return nil
+		}
+		jobs <- job{rel, info}
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := filepath.Join(dir, libraryCacheName)
+	if data, err := json.Marshal(lib.entries); err == nil && guardWrite(cache) == nil {
+		os.WriteFile(longPath(cache), data, 0644)
+	}
+	return lib, nil
+}
+
+func hashJPEG(path string) (uint64, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		return 0, err
+	}
+	return convert.DHash(img), nil
+}
+
+// match returns the library file closest to any of hashes, if it is
+// within maxDistance bits.
+func (lib *library) match(hashes [4]uint64, maxDistance int) (string, bool) {
+	best, bestDistance := "", maxDistance+1
+	for rel, e := range lib.entries {
+		for _, h := range hashes {
+			if d := convert.HammingDistance(h, e.Hash); d < bestDistance || (d == bestDistance && rel < best) {
+				best, bestDistance = rel, d
+			}
+		}
+	}
+	return best, best != ""
+}
+
+type libraryKey struct{}
+
+func withLibrary(ctx context.Context, lib *library) context.Context {
+	return context.WithValue(ctx, libraryKey{}, lib)
+}
+
+func libraryFrom(ctx context.Context) *library {
+	lib, _ := ctx.Value(libraryKey{}).(*library)
+	return lib
+}
+
+// checkDuplicate looks img up in the library before output is written.
+// A duplicate is skipped, or linked to with -dedupe link, and reported
+// as a *skipReason.
+func checkDuplicate(ctx context.Context, img image.Image, output string) error {
+	lib := libraryFrom(ctx)
+	if lib == nil {
+		return nil
+	}
+	rel, ok := lib.match(convert.RotatedDHashes(img), *dedupeDistance)
+	if !ok {
+		return nil
+	}
+	existing := filepath.Join(lib.dir, rel)
+	if *dedupeAction != "link" {
+		return &skipReason{"already in the library as " + existing}
+	}
+	os.Remove(longPath(output))
+	if err := os.Link(longPath(existing), longPath(output)); err != nil {
+		if err := os.Symlink(existing, output); err != nil {
+			return err
+		}
+	}
+	return &skipReason{"linked to " + existing + " in the library"}
+}
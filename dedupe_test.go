@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// blockPhoto is a w x h image of random grey 16x16 blocks, so different
+// seeds give unrelated hashes.
+func blockPhoto(w, h int, seed int64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(seed))
+	shades := make([]uint8, (w/16+1)*(h/16+1))
+	for i := range shades {
+		shades[i] = uint8(r.Intn(256))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{shades[(y/16)*(w/16+1)+x/16]})
+		}
+	}
+	return img
+}
+
+// rotateClockwise turns img a quarter turn clockwise.
+func rotateClockwise(img *image.Gray) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.SetGray(b.Dy()-1-y, x, img.GrayAt(x, y))
+		}
+	}
+	return out
+}
+
+func writeTestJPEG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 70}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckDuplicate(t *testing.T) {
+	libDir := t.TempDir()
+	writeTestJPEG(t, filepath.Join(libDir, "2023", "IMG_1.jpg"), rotateClockwise(blockPhoto(192, 128, 1)))
+	writeTestJPEG(t, filepath.Join(libDir, "IMG_2.jpeg"), blockPhoto(192, 128, 2))
+	lib, err := loadLibrary(libDir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lib.entries) != 2 {
+		t.Fatalf("indexed %d files, want 2", len(lib.entries))
+	}
+	ctx := withLibrary(context.Background(), lib)
+	out := t.TempDir()
+
+	// Stored unrotated, as in the HEIC.
+	err = checkDuplicate(ctx, blockPhoto(192, 128, 1), filepath.Join(out, "IMG_1.jpg"))
+	if reason, ok := skippedBy(err); !ok || !strings.Contains(reason, filepath.Join("2023", "IMG_1.jpg")) {
+		t.Errorf("duplicate: err = %v, want it skipped as IMG_1.jpg", err)
+	}
+	if err := checkDuplicate(ctx, blockPhoto(192, 128, 3), filepath.Join(out, "IMG_3.jpg")); err != nil {
+		t.Errorf("new photo: err = %v, want nil", err)
+	}
+
+	defer func(action string) { *dedupeAction = action }(*dedupeAction)
+	*dedupeAction = "link"
+	output := filepath.Join(out, "IMG_2.jpg")
+	if _, ok := skippedBy(checkDuplicate(ctx, blockPhoto(192, 128, 2), output)); !ok {
+		t.Fatal("linked duplicate not reported as skipped")
+	}
+	linked, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing, _ := os.Stat(filepath.Join(libDir, "IMG_2.jpeg"))
+	if !os.SameFile(linked, existing) {
+		t.Error("output is not a link to the library file")
+	}
+}
+
+func TestLoadLibraryCache(t *testing.T) {
+	libDir := t.TempDir()
+	path := filepath.Join(libDir, "IMG_1.jpg")
+	writeTestJPEG(t, path, blockPhoto(64, 64, 1))
+	if _, err := loadLibrary(libDir, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(libDir, libraryCacheName)); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// An unchanged file is not read again: a corrupt one keeps its hash.
+	info, _ := os.Stat(path)
+	if err := os.WriteFile(path, make([]byte, info.Size()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+	lib, err := loadLibrary(libDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lib.entries["IMG_1.jpg"]; !ok {
+		t.Error("cached hash was not reused")
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	subcommands["diff"] = diffCommand
+}
+
+// treeDiff compares a folder of HEICs with the folder of their JPEGs.
+// Paths are relative to the folder they are in.
+type treeDiff struct {
+	Missing  []string `json:"missing"`  // HEICs without a JPEG
+	Orphaned []string `json:"orphaned"` // JPEGs without a HEIC
+	Stale    []string `json:"stale"`    // HEICs changed since their JPEG was written
+	Current  int      `json:"current"`
+}
+
+// diffTrees walks src and dst and matches each HEIC in src with the JPEG
+// (or screenshot PNG) it would be converted to in dst. Extra frames and
+// numbered in-place names (IMG_0001-2.jpg) count as the HEIC's too.
+func diffTrees(src, dst string) (treeDiff, error) {
+	var d treeDiff
+	sources, err := walkDirectory(src)
+	if err != nil {
+		return d, err
+	}
+	outputs, err := walkDirectory(dst)
+	if err != nil && !os.IsNotExist(err) {
+		return d, err
+	}
+
+	// Outputs by their path without the extension, which is what a HEIC
+	// maps to.
+	byStem := make(map[string][]string)
+	for _, file := range outputs {
+		if !isConvertedImage(file.Name()) {
+			continue
+		}
+		stem := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		byStem[stem] = append(byStem[stem], file.Name())
+	}
+
+	claimed := make(map[string]bool)
+	for _, file := range sources {
+		if !isHEIC(file.Name()) {
+			continue
+		}
+		stem := strings.TrimSuffix(jpegFileName(file.Name()), ".jpg")
+		names := byStem[stem]
+		if len(names) == 0 {
+			d.Missing = append(d.Missing, file.Name())
+			continue
+		}
+		claimed[stem] = true
+		heic, err := os.Stat(longPath(filepath.Join(src, file.Name())))
+		if err != nil {
+			return d, err
+		}
+		jpeg, err := os.Stat(longPath(filepath.Join(dst, names[0])))
+		if err != nil {
+			return d, err
+		}
+		if heic.ModTime().After(jpeg.ModTime()) {
+			d.Stale = append(d.Stale, file.Name())
+		} else {
+			d.Current++
+		}
+	}
+
+	for _, file := range outputs {
+		name := file.Name()
+		if !isConvertedImage(name) {
+			continue
+		}
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		if claimed[stem] || claimed[numberedStem(stem)] {
+			continue
+		}
+		d.Orphaned = append(d.Orphaned, name)
+	}
+	return d, nil
+}
+
+func isConvertedImage(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".tif", ".tiff":
+		return true
+	}
+	return false
+}
+
+// numberedStem strips a -N suffix from stem, or returns "" if it has none.
+func numberedStem(stem string) string {
+	i := strings.LastIndex(stem, "-")
+	if i < 0 {
+		return ""
+	}
+	if _, err := strconv.Atoi(stem[i+1:]); err != nil {
+		return ""
+	}
+	return stem[:i]
+}
+
+// diffCommand reports how far DST is from being the converted copy of
+// SRC, without converting anything.
+func diffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg diff [-json] SRC [DST]")
+		fmt.Fprintln(fs.Output(), "DST is SRC/jpegs by default.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	src, dst := fs.Arg(0), filepath.Join(fs.Arg(0), "jpegs")
+	if fs.NArg() == 2 {
+		dst = fs.Arg(1)
+	}
+
+	d, err := diffTrees(src, dst)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	for _, section := range []struct {
+		title, dir string
+		paths      []string
+	}{
+		{tr("Missing, no JPEG in %s:\n"), dst, d.Missing},
+		{tr("Orphaned, no HEIC in %s:\n"), src, d.Orphaned},
+		{tr("Stale, the HEIC in %s is newer:\n"), src, d.Stale},
+	} {
+		if len(section.paths) == 0 {
+			continue
+		}
+		fmt.Printf(section.title, section.dir)
+		for _, path := range section.paths {
+			fmt.Printf("  %s\n", filepath.ToSlash(path))
+		}
+	}
+	fmt.Printf(tr("%d up to date, %d missing, %d orphaned, %d stale\n"), d.Current, len(d.Missing), len(d.Orphaned), len(d.Stale))
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTrees(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(src, "jpegs")
+	old := time.Now().Add(-time.Hour)
+	write := func(path string, mtime time.Time) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(src, "current.heic"), old)
+	write(filepath.Join(dst, "current.jpg"), time.Now())
+	write(filepath.Join(dst, "current-2.jpg"), time.Now())
+	write(filepath.Join(src, "2023", "screenshot.HEIC"), old)
+	write(filepath.Join(dst, "2023", "screenshot.png"), time.Now())
+	write(filepath.Join(src, "edited.heic"), time.Now())
+	write(filepath.Join(dst, "edited.jpg"), old)
+	write(filepath.Join(src, "new.heic"), time.Now())
+	write(filepath.Join(dst, "deleted.jpg"), old)
+	write(filepath.Join(dst, "logs.txt"), old)
+
+	d, err := diffTrees(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := treeDiff{
+		Missing:  []string{"new.heic"},
+		Orphaned: []string{"deleted.jpg"},
+		Stale:    []string{"edited.heic"},
+		Current:  2,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("diff = %+v, want %+v", d, want)
+	}
+}
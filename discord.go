@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const discordAPI = "https://discord.com/api/v10"
+
+// discordMaxFiles is the most files a Discord message can carry.
+const discordMaxFiles = 10
+
+// discordCommands are the commands the app registers: /heic, with the
+// photo as its option, and Convert to JPEG in the Apps menu of messages,
+// for photos someone else sent.
+var discordCommands = []map[string]interface{}{
+	{"name": "heic", "type": 1, "description": "Convert a HEIC photo to JPEG", "options": []map[string]interface{}{
+		{"type": 11, "name": "photo", "description": "The HEIC photo", "required": true},
+	}},
+	{"name": "Convert to JPEG", "type": 3},
+}
+
+// Interaction types and responses of Discord.
+const (
+	discordPing           = 1
+	discordCommand        = 2
+	discordPong           = 1
+	discordMessage        = 4
+	discordDeferred       = 5
+	discordEphemeral      = 1 << 6
+	discordMaxInteraction = 1 << 20
+)
+
+// discordBot answers a Discord app through its interactions endpoint URL,
+// which Discord posts to: heictojpeg bot serves it on -listen, behind
+// HTTPS with -tls-cert or a proxy.
+type discordBot struct {
+	api    string
+	app    string
+	key    ed25519.PublicKey
+	token  string
+	client *http.Client
+	conv   *botConverter
+	wg     sync.WaitGroup
+}
+
+type discordAttachment struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type discordInteraction struct {
+	Type   int    `json:"type"`
+	Token  string `json:"token"`
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+	Data struct {
+		TargetID string `json:"target_id"`
+		Resolved struct {
+			Attachments map[string]discordAttachment `json:"attachments"`
+			Messages    map[string]struct {
+				Attachments []discordAttachment `json:"attachments"`
+			} `json:"messages"`
+		} `json:"resolved"`
+	} `json:"data"`
+}
+
+func newDiscordBot(api, app, key, token string, conv *botConverter) (*discordBot, error) {
+	if app == "" || key == "" || token == "" {
+		return nil, fmt.Errorf("the Discord app needs -discord-app, -discord-key and %s", discordTokenEnv)
+	}
+	publicKey, err := hex.DecodeString(key)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("-discord-key: must be the app's public key, %d hex digits", 2*ed25519.PublicKeySize)
+	}
+	return &discordBot{api: api, app: app, key: publicKey, token: token, client: &http.Client{Timeout: 2 * time.Minute}, conv: conv}, nil
+}
+
+// serve registers the commands and answers interactions on -listen until
+// ctx is cancelled.
+func (b *discordBot) serve(ctx context.Context) error {
+	if err := b.register(ctx); err != nil {
+		return fmt.Errorf("Discord: %v", err)
+	}
+	srv := &http.Server{
+		Addr:              *listenAddr,
+		Handler:           b,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf(tr("Discord interactions endpoint listening on %s\n"), *listenAddr)
+	var err error
+	if *tlsCert != "" {
+		err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	b.wg.Wait()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// register sets the app's commands to discordCommands.
+func (b *discordBot) register(ctx context.Context) error {
+	body, err := json.Marshal(discordCommands)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.api+"/applications/"+b.app+"/commands", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return b.do(req)
+}
+
+func (b *discordBot) do(req *http.Request) error {
+	req.Header.Set("Authorization", "Bot "+b.token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return botError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s %s", req.Method, strings.TrimPrefix(req.URL.Path, "/api/v10"), resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// ServeHTTP answers an interaction. Discord wants an answer within three
+// seconds, so commands are answered "thinking" and the JPEGs follow.
+func (b *discordBot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Discord interactions are posted", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, discordMaxInteraction))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || !ed25519.Verify(b.key, append([]byte(r.Header.Get("X-Signature-Timestamp")), body...), signature) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	var in discordInteraction
+	if err := json.Unmarshal(body, &in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respond := func(response map[string]interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+	say := func(text string) {
+		respond(map[string]interface{}{"type": discordMessage, "data": map[string]interface{}{"content": text, "flags": discordEphemeral}})
+	}
+
+	switch in.Type {
+	case discordPing:
+		respond(map[string]interface{}{"type": discordPong})
+		return
+	case discordCommand:
+	default:
+		http.Error(w, fmt.Sprintf("unsupported interaction type %d", in.Type), http.StatusBadRequest)
+		return
+	}
+	user := in.User
+	if in.Member != nil {
+		user = &in.Member.User
+	}
+	if user == nil {
+		user = &discordUser{}
+	}
+	who := "Discord user " + user.ID
+	if !b.conv.allows(user.ID, user.Username) {
+		fmt.Printf(tr("Ignored a file from %s: %v\n"), who, errNotAllowed)
+		say(tr("Sorry, I only convert photos for the people I was set up for."))
+		return
+	}
+	var photos []discordAttachment
+	attachments := in.Data.Resolved.Messages[in.Data.TargetID].Attachments
+	for _, a := range in.Data.Resolved.Attachments {
+		attachments = append(attachments, a)
+	}
+	for _, a := range attachments {
+		if isHEICUpload(a.Filename, a.ContentType) && len(photos) < discordMaxFiles {
+			photos = append(photos, a)
+		}
+	}
+	if len(photos) == 0 {
+		say(tr("Send me a HEIC photo as a file and I'll send it back as a JPEG."))
+		return
+	}
+	respond(map[string]interface{}{"type": discordDeferred})
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		// Not the request's context, which ends with this response.
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+		defer cancel()
+		if err := b.followUp(ctx, in.Token, who, photos); err != nil {
+			fmt.Printf(tr("Failed to send the JPEGs to %s: %v\n"), who, err)
+		}
+	}()
+}
+
+// followUp converts photos and sends their JPEGs in place of the deferred
+// response, with a line for each that failed.
+func (b *discordBot) followUp(ctx context.Context, token, who string, photos []discordAttachment) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	var lines []string
+	var files []map[string]interface{}
+	for _, photo := range photos {
+		output, err := b.convertAttachment(ctx, who, photo)
+		if err != nil {
+			fmt.Printf(tr("Failed to convert %s from %s: %v\n"), photo.Filename, who, err)
+			lines = append(lines, fmt.Sprintf(tr("Couldn't convert %s: %v"), photo.Filename, err))
+			continue
+		}
+		err = func() error {
+			defer os.Remove(output)
+			f, err := os.Open(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			part, err := w.CreateFormFile(fmt.Sprintf("files[%d]", len(files)), jpegName(photo.Filename))
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(part, f)
+			return err
+		}()
+		if err != nil {
+			return err
+		}
+		files = append(files, map[string]interface{}{"id": len(files), "filename": jpegName(photo.Filename)})
+	}
+	payload, err := json.Marshal(map[string]interface{}{"content": strings.Join(lines, "\n"), "attachments": files})
+	if err != nil {
+		return err
+	}
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, b.api+"/webhooks/"+b.app+"/"+token+"/messages/@original", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return b.do(req)
+}
+
+func (b *discordBot) convertAttachment(ctx context.Context, who string, a discordAttachment) (string, error) {
+	if b.conv.maxBytes > 0 && a.Size > b.conv.maxBytes {
+		return "", fmt.Errorf("the file is over %s", humanReadableFileSize(b.conv.maxBytes))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download: %s", resp.Status)
+	}
+	return b.conv.convert(ctx, who, resp.Body)
+}
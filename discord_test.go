@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDiscordBot(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var registered, followUp string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/photos/IMG_0001.HEIC":
+			w.Write(exifSample())
+		case r.Header.Get("Authorization") != "Bot TOKEN":
+			http.Error(w, "401: Unauthorized", http.StatusUnauthorized)
+		case r.Method == http.MethodPut && r.URL.Path == "/applications/42/commands":
+			var commands []struct{ Name string }
+			json.NewDecoder(r.Body).Decode(&commands)
+			for _, c := range commands {
+				registered += c.Name + ";"
+			}
+		case r.Method == http.MethodPatch && r.URL.Path == "/webhooks/42/itoken/messages/@original":
+			var payload struct{ Content string }
+			json.Unmarshal([]byte(r.FormValue("payload_json")), &payload)
+			followUp = payload.Content
+			if f, header, err := r.FormFile("files[0]"); err == nil {
+				if _, err := jpeg.DecodeConfig(f); err == nil {
+					followUp += "JPEG " + header.Filename
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	conv, err := newBotConverter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newDiscordBot(api.URL, "42", "abcd", "TOKEN", conv); err == nil {
+		t.Error("accepted a short -discord-key")
+	}
+	b, err := newDiscordBot(api.URL, "42", hex.EncodeToString(public), "TOKEN", conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.register(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if registered != "heic;Convert to JPEG;" {
+		t.Errorf("registered %q", registered)
+	}
+
+	post := func(body string, sign bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("X-Signature-Timestamp", "1700000000")
+		signature := ed25519.Sign(private, []byte("1700000000"+body))
+		if !sign {
+			signature = bytes.Repeat([]byte{1}, ed25519.SignatureSize)
+		}
+		r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+		w := httptest.NewRecorder()
+		b.ServeHTTP(w, r)
+		return w
+	}
+	responseType := func(w *httptest.ResponseRecorder) int {
+		var response struct{ Type int }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Type
+	}
+
+	if w := post(`{"type": 1}`, false); w.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned ping: %d", w.Code)
+	}
+	if w := post(`{"type": 1}`, true); responseType(w) != discordPong {
+		t.Errorf("ping: %s", w.Body)
+	}
+	if w := post(`{"type": 2, "token": "itoken", "user": {"id": "9"}, "data": {"resolved": {"attachments": {"1": {"filename": "cat.png", "url": "x"}}}}}`, true); responseType(w) != discordMessage || !strings.Contains(w.Body.String(), "Send me a HEIC") {
+		t.Errorf("command without a HEIC: %s", w.Body)
+	}
+
+	// Convert to JPEG on a message with a HEIC photo.
+	message := `{"type": 2, "token": "itoken", "member": {"user": {"id": "9"}}, "data": {"target_id": "m1", "resolved": {"messages": {"m1": {"attachments": [
+		{"filename": "IMG_0001.HEIC", "content_type": "image/heic", "size": 1000, "url": "` + api.URL + `/photos/IMG_0001.HEIC"},
+		{"filename": "IMG_0002.HEIC", "size": 1000, "url": "` + api.URL + `/photos/missing.HEIC"}]}}}}}`
+	if w := post(message, true); responseType(w) != discordDeferred {
+		t.Errorf("message command: %s", w.Body)
+	}
+	b.wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(followUp, "Couldn't convert IMG_0002.HEIC") || !strings.HasSuffix(followUp, "JPEG IMG_0001.jpg") {
+		t.Errorf("follow-up %q", followUp)
+	}
+}
+
+func TestDiscordBotUsers(t *testing.T) {
+	defer func(users string) { *botUsers = users }(*botUsers)
+	*botUsers = "123"
+	conv, _ := newBotConverter()
+	public, private, _ := ed25519.GenerateKey(nil)
+	b, err := newDiscordBot("http://discord.invalid", "42", hex.EncodeToString(public), "TOKEN", conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"type": 2, "token": "t", "user": {"id": "456"}, "data": {"resolved": {"attachments": {"1": {"filename": "a.heic", "url": "x"}}}}}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("X-Signature-Timestamp", "1")
+	r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(private, []byte("1"+body))))
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), "Sorry") {
+		t.Errorf("answered a user not in -bot-users with %s", w.Body)
+	}
+}
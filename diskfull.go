@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskFullPoll is how often a run paused on a full disk checks whether
+// enough space has been freed.
+var diskFullPoll = 5 * time.Second
+
+// isDiskFull reports whether err is a write to a full volume. The message
+// is matched too, for errors that lost their type on the way, such as
+// those of -isolate's child process.
+func isDiskFull(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, errno := range diskFullErrors {
+		if errors.Is(err, errno) || strings.Contains(err.Error(), errno.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// diskFullPause holds the run while the output volume is full. The first
+// worker to run out of space pauses the run and waits for room; the others
+// wait behind it and retry their file once it resumes.
+type diskFullPause struct {
+	mu      sync.Mutex
+	resumed time.Time
+}
+
+var diskFull diskFullPause
+
+var (
+	enterOnce sync.Once
+	enters    chan struct{}
+)
+
+// enterPresses reports each line typed on the terminal, read by a single
+// goroutine so a prompt that is answered some other way doesn't leave a
+// reader behind to swallow the next answer.
+func enterPresses() <-chan struct{} {
+	enterOnce.Do(func() {
+		enters = make(chan struct{}, 1)
+		go func() {
+			r := bufio.NewReader(os.Stdin)
+			for {
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+				select {
+				case enters <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	})
+	return enters
+}
+
+// wait pauses the run after a write failed at failedAt for lack of space
+// in jpegDir, and returns once it is resumed: when Enter is pressed on the
+// terminal, the run is resumed some other way (SIGUSR2, -tui), or enough
+// space for the remaining files of dir has been freed. It reports false
+// without waiting when the run can't be paused, or ctx is done.
+func (d *diskFullPause) wait(ctx context.Context, control *runControl, dir, jpegDir string, failedAt time.Time) bool {
+	if control == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resumed.After(failedAt) {
+		return ctx.Err() == nil // another worker already waited for space
+	}
+	defer func() { d.resumed = time.Now() }()
+
+	needed := remainingSpace(dir, jpegDir)
+	control.setPaused(true)
+	free, _ := freeSpace(jpegDir)
+	fmt.Printf(tr("The disk is full. Paused: the remaining files need about %s more in %s, and %s is free.\n"), humanReadableFileSize(needed), jpegDir, humanReadableFileSize(free))
+	var presses <-chan struct{}
+	if isTerminal(os.Stdin) && !*tuiMode {
+		fmt.Println(tr("Free up space, then press Enter to resume, or Ctrl+C to stop."))
+		presses = enterPresses()
+	} else {
+		fmt.Println(tr("The run resumes once there is enough free space."))
+	}
+
+	ticker := time.NewTicker(diskFullPoll)
+	defer ticker.Stop()
+	for control.paused() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-presses:
+			control.setPaused(false)
+		case <-ticker.C:
+			if free, err := freeSpace(jpegDir); err == nil && free >= needed {
+				control.setPaused(false)
+			}
+		}
+	}
+	fmt.Println(tr("Resumed."))
+	return ctx.Err() == nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIsDiskFull(t *testing.T) {
+	full := diskFullErrors[0]
+	for _, err := range []error{
+		&os.PathError{Op: "write", Path: "a.jpg", Err: full},
+		fmt.Errorf("child: %v", full),
+	} {
+		if !isDiskFull(err) {
+			t.Errorf("isDiskFull(%v) = false", err)
+		}
+	}
+	for _, err := range []error{nil, errors.New("decode timeout")} {
+		if isDiskFull(err) {
+			t.Errorf("isDiskFull(%v) = true", err)
+		}
+	}
+}
+
+func TestDiskFullWait(t *testing.T) {
+	old := diskFullPoll
+	diskFullPoll = 10 * time.Millisecond
+	defer func() { diskFullPoll = old }()
+
+	dir := t.TempDir()
+	jpegDir := ensureJPEGDirectoryExists(dir)
+	var d diskFullPause
+	if d.wait(context.Background(), nil, dir, jpegDir, time.Now()) {
+		t.Error("waited without a run control")
+	}
+
+	// Nothing is left to convert, so the free space suffices at once.
+	control := newRunControl()
+	failedAt := time.Now()
+	if !d.wait(context.Background(), control, dir, jpegDir, failedAt) {
+		t.Fatal("wait = false")
+	}
+	if control.paused() {
+		t.Error("still paused")
+	}
+
+	// A worker that failed before that wait ended retries straight away.
+	control.setPaused(true)
+	if !d.wait(context.Background(), control, dir, jpegDir, failedAt) {
+		t.Error("wait = false for an earlier failure")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if d.wait(ctx, control, dir, jpegDir, time.Now()) {
+		t.Error("wait = true after cancelling")
+	}
+}
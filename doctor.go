@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/heicsample"
+)
+
+// errWrongPixels is a sample that decoded without an error but not to the
+// picture it holds.
+var errWrongPixels = errors.New("decodes to the wrong pixels")
+
+func init() {
+	subcommands["doctor"] = doctorCommand
+}
+
+// doctorCheck is one HEIC feature the doctor tries on this build.
+type doctorCheck struct {
+	Name      string `json:"name"`
+	Supported bool   `json:"supported"`
+	Error     string `json:"error,omitempty"`
+	hint      string // what goes wrong with such files when unsupported
+}
+
+type doctorReport struct {
+	Build  buildInfo     `json:"build"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+// decodeSample decodes a sample the way a conversion does and checks it.
+func decodeSample(ctx context.Context, file []byte) error {
+	d, err := convert.Decode(ctx, bytes.NewReader(file), convert.DefaultOptions())
+	if err != nil {
+		return err
+	}
+	if !heicsample.Matches(d.Image, heicsample.Pattern) {
+		return errWrongPixels
+	}
+	return nil
+}
+
+// decodeBurst decodes both shots of the burst sample, as -best-frame and
+// -all-frames do.
+func decodeBurst(ctx context.Context) error {
+	burst := heicsample.Burst()
+	if err := decodeSample(ctx, burst); err != nil {
+		return err
+	}
+	n, err := convert.OtherFrames(ctx, bytes.NewReader(burst), convert.DefaultOptions(), func(_ int, d convert.Decoded) error {
+		if !heicsample.Matches(d.Image, heicsample.InvertedPattern) {
+			return errWrongPixels
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("found %d of 2 images", n+1)
+	}
+	return nil
+}
+
+// runDoctor decodes each sample and reports what works.
+func runDoctor(ctx context.Context) doctorReport {
+	r := doctorReport{Build: currentBuild()}
+	for _, c := range []struct {
+		name, hint string
+		run        func() error
+	}{
+		{tr("8-bit HEIC"), tr("no HEIC photo will convert"), func() error { return decodeSample(ctx, heicsample.Single(8)) }},
+		{tr("10-bit HEIC"), tr("10-bit photos, such as HDR shots and those of some Android phones, won't convert correctly"), func() error { return decodeSample(ctx, heicsample.Single(10)) }},
+		{tr("Tiled (grid) HEIC"), tr("photos from iPhones and most cameras, stored as tiles, won't convert"), func() error { return decodeSample(ctx, heicsample.Grid()) }},
+		{tr("Multi-image HEIC"), tr("-best-frame and -all-frames won't work on bursts"), func() error { return decodeBurst(ctx) }},
+	} {
+		check := doctorCheck{Name: c.name, Supported: true, hint: c.hint}
+		if err := c.run(); err != nil {
+			check.Supported, check.Error = false, err.Error()
+		}
+		r.Checks = append(r.Checks, check)
+	}
+	return r
+}
+
+// doctorCommand tells which HEIC features this build decodes, from
+// samples made on the spot, to explain why some files fail.
+func doctorCommand(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	r := runDoctor(context.Background())
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	fmt.Print(r.Build)
+	fmt.Println()
+	for _, c := range r.Checks {
+		if c.Supported {
+			fmt.Printf(tr("%-20s supported\n"), c.Name)
+			continue
+		}
+		fmt.Printf(tr("%-20s not supported (%s): %s\n"), c.Name, c.Error, c.hint)
+	}
+	return nil
+}
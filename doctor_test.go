@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+
+	"github.com/adrium/goheif"
+)
+
+func TestSamplesDecode(t *testing.T) {
+	for name, file := range map[string][]byte{"8-bit": heicsample.Single(8), "10-bit": heicsample.Single(10), "grid": heicsample.Grid()} {
+		if err := decodeSample(context.Background(), file); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if err := decodeBurst(context.Background()); err != nil {
+		t.Errorf("burst: %v", err)
+	}
+
+	img, err := goheif.Decode(bytes.NewReader(heicsample.Grid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 2*heicsample.Size || b.Dy() != 2*heicsample.Size {
+		t.Errorf("grid is %v", b)
+	}
+}
+
+func TestMatchesPattern(t *testing.T) {
+	img, err := goheif.Decode(bytes.NewReader(heicsample.Single(8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !heicsample.Matches(img, heicsample.Pattern) {
+		t.Error("sample doesn't match its pattern")
+	}
+	if heicsample.Matches(img, heicsample.InvertedPattern) {
+		t.Error("sample matches the inverted pattern")
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	r := runDoctor(context.Background())
+	if len(r.Checks) != 4 {
+		t.Fatalf("%d checks", len(r.Checks))
+	}
+	for _, c := range r.Checks {
+		if !c.Supported && c.Error == "" {
+			t.Errorf("%s unsupported without a reason", c.Name)
+		}
+	}
+	for _, c := range r.Checks[:2] {
+		if !c.Supported {
+			t.Errorf("%s unsupported: %s", c.Name, c.Error)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var estimateSamples = flag.Int("estimate", 0, "convert this many sample files in memory and print the predicted output size and time for the batch, without writing anything")
+
+// batchEstimate extrapolates the sampled files to the whole batch by input
+// size, assuming -workers files are converted at a time.
+type batchEstimate struct {
+	Files, Sampled, Failed int
+	InputSize              int64
+	OutputSize             int64
+	Duration               time.Duration
+}
+
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// sampleFiles picks n names spread evenly over the sorted list.
+func sampleFiles(names []string, n int) []string {
+	sort.Strings(names)
+	if n >= len(names) {
+		return names
+	}
+	sample := make([]string, n)
+	for i := range sample {
+		sample[i] = names[i*len(names)/n]
+	}
+	return sample
+}
+
+func estimateBatch(ctx context.Context, dir string, names []string, n int) batchEstimate {
+	est := batchEstimate{Files: len(names)}
+	for _, name := range names {
+		est.InputSize += getFileSize(filepath.Join(dir, name))
+	}
+
+	var sampledIn, sampledOut int64
+	var elapsed time.Duration
+	for _, name := range sampleFiles(append([]string(nil), names...), n) {
+		path := filepath.Join(dir, name)
+		start := time.Now()
+		img, exif, err := decodeHeicFile(ctx, path)
+		out := &countingWriter{}
+		if err == nil {
+			err = encodeJPEG(ctx, out, img, exif)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Printf(tr("Failed to convert %s: %v\n"), name, err)
+			est.Failed++
+			continue
+		}
+		elapsed += time.Since(start)
+		sampledIn += getFileSize(path)
+		sampledOut += out.n
+		est.Sampled++
+	}
+	if sampledIn == 0 {
+		return est
+	}
+	ratio := float64(est.InputSize) / float64(sampledIn)
+	est.OutputSize = int64(float64(sampledOut) * ratio)
+	est.Duration = time.Duration(float64(elapsed) * ratio / float64(workerCount()))
+	return est
+}
+
+func runEstimate(ctx context.Context, dir string) error {
+	files, err := getFilesInDirectory(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, file := range files {
+		if isHEIC(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	sampled := *estimateSamples
+	if sampled > len(names) {
+		sampled = len(names)
+	}
+	fmt.Printf(tr("Sampling %d of %d files at quality %d...\n"), sampled, len(names), *quality)
+	est := estimateBatch(ctx, dir, names, *estimateSamples)
+	if est.Sampled == 0 {
+		return fmt.Errorf("no sample could be converted")
+	}
+	fmt.Printf(tr("Estimated JPEG size: %s for %s of HEIC (%.0f%%)\n"), humanReadableFileSize(est.OutputSize), humanReadableFileSize(est.InputSize), 100*float64(est.OutputSize)/float64(est.InputSize))
+	fmt.Printf(tr("Estimated time: %v with %d workers\n"), est.Duration.Round(time.Second), workerCount())
+	if free, err := freeSpace(dir); err == nil {
+		fmt.Printf(tr("Free space: %s\n"), humanReadableFileSize(free))
+		if est.OutputSize > free {
+			fmt.Println(tr("Warning: the JPEGs won't fit."))
+		}
+	}
+	return nil
+}
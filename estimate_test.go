@@ -0,0 +1,16 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSampleFiles(t *testing.T) {
+	names := []string{"e.heic", "a.heic", "c.heic", "b.heic", "d.heic", "f.heic"}
+	if got, want := sampleFiles(names, 3), []string{"a.heic", "c.heic", "e.heic"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sampleFiles = %v, want %v", got, want)
+	}
+	if got := sampleFiles([]string{"b", "a"}, 5); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("sampleFiles with n > len = %v", got)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/exif"
+
+	"github.com/adrium/goheif"
+)
+
+// readExif parses the EXIF block of a HEIC file. Files without one
+// return nil and no error.
+func readExif(path string) (*exif.Data, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := goheif.ExtractExif(f)
+	if err != nil {
+		if exifStatusOf(err) == convert.ExifMissing {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return exif.Parse(data)
+}
+
+// readJPEGExif parses the EXIF block of a JPEG, from its APP1 segment.
+// JPEGs without one return nil and no error.
+func readJPEGExif(path string) (*exif.Data, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var marker [2]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || marker != [2]byte{0xff, 0xd8} {
+		return nil, errors.New("not a JPEG")
+	}
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+		if header[0] != 0xff {
+			return nil, errors.New("malformed JPEG")
+		}
+		if header[1] == 0xda || header[1] == 0xd9 {
+			// Image data follows: there was no EXIF.
+			return nil, nil
+		}
+		size := int(binary.BigEndian.Uint16(header[2:])) - 2
+		if size < 0 {
+			return nil, errors.New("malformed JPEG")
+		}
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, err
+		}
+		if header[1] == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exif.Parse(segment)
+		}
+	}
+}
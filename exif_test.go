@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/internal/exif"
+)
+
+func TestReadJPEGExif(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct {
+		name string
+		exif []byte
+		want int
+	}{
+		{"rotated.jpg", exif.Build([]exif.Tag{exif.Short(exif.TagOrientation, 6)}, nil), 6},
+		{"upright.jpg", exif.Build([]exif.Tag{exif.ASCII(exif.TagMake, "Apple")}, nil), 1},
+		{"none.jpg", nil, 1},
+	} {
+		var buf bytes.Buffer
+		if err := encodeJPEGQuality(&buf, testPhoto(16, 16, false), c.exif, 80); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, c.name)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		x, err := readJPEGExif(path)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if (x == nil) != (c.exif == nil) {
+			t.Errorf("%s: EXIF = %v", c.name, x)
+		}
+		if got := x.Orientation(); got != c.want {
+			t.Errorf("%s: orientation = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
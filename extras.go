@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"heictojpeg/convert"
+)
+
+var extraOutputs = flag.String("extra-outputs", "", "also write each photo at these presets from the same decode, e.g. web,thumb for IMG_0001-web.jpg and IMG_0001-thumb.jpg beside IMG_0001.jpg")
+
+// extraOutput is one of -extra-outputs or -profiles: the settings it
+// gives a file on top of its own, and the kind of file it writes where.
+type extraOutput struct {
+	name     string
+	settings settings
+	format   string // jpeg, png or tiff
+	folder   string // of a profile, beside the output
+}
+
+// parseExtraOutputs reads the comma-separated presets of -extra-outputs,
+// applying each to base.
+func parseExtraOutputs(list string, base settings) ([]extraOutput, error) {
+	return parseOutputList(list, "preset", func(name string) (extraOutput, error) {
+		s := base
+		err := s.apply(map[string]interface{}{"preset": name})
+		return extraOutput{name: name, settings: s, format: "jpeg"}, err
+	})
+}
+
+// parseProfiles reads the comma-separated profiles of -profiles.
+func parseProfiles(list string, base settings) ([]extraOutput, error) {
+	return parseOutputList(list, "profile", func(name string) (extraOutput, error) {
+		return parseProfile(name, base)
+	})
+}
+
+func parseOutputList(list, kind string, parse func(name string) (extraOutput, error)) ([]extraOutput, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var extras []extraOutput
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("empty %s name", kind)
+		case seen[name]:
+			return nil, fmt.Errorf("%s %s is listed twice", kind, name)
+		}
+		seen[name] = true
+		extra, err := parse(name)
+		if err != nil {
+			return nil, err
+		}
+		extras = append(extras, extra)
+	}
+	return extras, nil
+}
+
+// extrasFor lists the outputs of -extra-outputs and -profiles of a file
+// with the settings base.
+func extrasFor(base settings) ([]extraOutput, error) {
+	extras, err := parseExtraOutputs(*extraOutputs, base)
+	if err != nil {
+		return nil, err
+	}
+	profiled, err := parseProfiles(*profileNames, base)
+	return append(extras, profiled...), err
+}
+
+// path is where the extra output of output goes: for a profile, a file of
+// the same name in the profile's folder beside it.
+func (e extraOutput) path(output string) string {
+	if e.folder == "" {
+		return extraFileName(output, e.name)
+	}
+	stem := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+	return filepath.Join(filepath.Dir(output), e.folder, stem+profileFormats[e.format])
+}
+
+// extraFileName names the extra output of preset name beside output,
+// e.g. IMG_0001-web.jpg. Those of -extra-outputs are always JPEGs.
+func extraFileName(output, name string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + "-" + name + ".jpg"
+}
+
+// writeExtraOutputs writes the extra outputs of img, the decoded photo of
+// output, beside it.
+func writeExtraOutputs(ctx context.Context, img image.Image, exif []byte, output string) error {
+	extras, err := extrasFor(settingsFrom(ctx))
+	if err != nil {
+		return err
+	}
+	for _, extra := range extras {
+		path := extra.path(output)
+		if err := guardWrite(path); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(longPath(filepath.Dir(path)), 0755); err != nil {
+			return err
+		}
+		out, err := os.Create(longPath(path))
+		if err != nil {
+			return err
+		}
+		switch extra.format {
+		case "png":
+			err = convert.EncodePNG(out, img, outputICC(ctx), extra.settings.options())
+		case "tiff":
+			err = convert.EncodeTIFF(out, img, outputICC(ctx), extra.settings.options())
+		default:
+			err = convert.EncodeJPEG(out, img, exif, outputICC(ctx), extra.settings.options())
+		}
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %v", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// extraFiles lists the extra outputs written beside output.
+func extraFiles(ctx context.Context, output string) []string {
+	extras, _ := extrasFor(settingsFrom(ctx))
+	var paths []string
+	for _, extra := range extras {
+		if path := extra.path(output); fileExists(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/heicsample"
+)
+
+func TestParseExtraOutputs(t *testing.T) {
+	defer func(p map[string]map[string]interface{}) { customPresets = p }(customPresets)
+	customPresets = map[string]map[string]interface{}{"tiny": {"max-size": 16}}
+	base := settings{Quality: 90, MaxSize: 0, Metadata: "keep", CropFocus: "center"}
+
+	extras, err := parseExtraOutputs("web, tiny", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(extras) != 2 || extras[0].name != "web" || extras[1].name != "tiny" {
+		t.Fatalf("got %+v", extras)
+	}
+	if s := extras[0].settings; s.Quality != 80 || s.MaxSize != 2048 || s.Metadata != "strip" {
+		t.Errorf("web: got %+v", s)
+	}
+	if s := extras[1].settings; s.Quality != 90 || s.MaxSize != 16 || s.Metadata != "keep" {
+		t.Errorf("tiny keeps the file's other settings: got %+v", s)
+	}
+	for _, bad := range []string{"web,web", "web,", "huge"} {
+		if _, err := parseExtraOutputs(bad, base); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+	if extras, err := parseExtraOutputs("", base); err != nil || extras != nil {
+		t.Errorf("no extra outputs: got %v, %v", extras, err)
+	}
+}
+
+func TestExtraFileName(t *testing.T) {
+	for output, want := range map[string]string{
+		"jpegs/IMG_0001.jpg": "jpegs/IMG_0001-web.jpg",
+		"jpegs/IMG_0002.png": "jpegs/IMG_0002-web.jpg",
+	} {
+		if got := extraFileName(output, "web"); got != want {
+			t.Errorf("%s: got %s, want %s", output, got, want)
+		}
+	}
+}
+
+func TestWriteExtraOutputs(t *testing.T) {
+	defer func(v string, p map[string]map[string]interface{}) { *extraOutputs, customPresets = v, p }(*extraOutputs, customPresets)
+	customPresets = map[string]map[string]interface{}{"tiny": {"max-size": 16}}
+	*extraOutputs = "tiny,web"
+
+	d, err := convert.Decode(context.Background(), bytes.NewReader(heicsample.Single(8)), convert.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := d.Image
+	dir := t.TempDir()
+	output := filepath.Join(dir, "IMG_0001.jpg")
+	if err := writeExtraOutputs(context.Background(), img, nil, output); err != nil {
+		t.Fatal(err)
+	}
+	for name, side := range map[string]int{"tiny": 16, "web": heicsample.Size} {
+		f, err := os.Open(extraFileName(output, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := jpeg.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Width != side || config.Height != side {
+			t.Errorf("%s: got %d×%d, want %d×%d", name, config.Width, config.Height, side, side)
+		}
+	}
+	if got := extraFiles(context.Background(), output); len(got) != 2 {
+		t.Errorf("extraFiles found %v", got)
+	}
+}
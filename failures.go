@@ -0,0 +1,154 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	collectFailures = flag.String("collect-failures", "", "after the run, write the failed files' headers, errors and system info to this zip for a bug report (asks first)")
+	collectKB       = flag.Int("collect-kb", 64, "with -collect-failures, how many KB of each failed file to include")
+	collectConsent  = flag.Bool("collect-consent", false, "with -collect-failures, don't ask before writing the bundle")
+)
+
+// failureCollector gathers the failed files of a run for -collect-failures.
+type failureCollector struct {
+	mu       sync.Mutex
+	failures []convert.ConversionResult
+}
+
+func (c *failureCollector) OnStart(total int)        {}
+func (c *failureCollector) OnFinish(convert.Summary) {}
+
+func (c *failureCollector) OnFileDone(result convert.ConversionResult) {
+	if result.Err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = append(c.failures, result)
+}
+
+// finish asks for consent and writes the bundle, if anything failed.
+func (c *failureCollector) finish(in io.Reader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.failures) == 0 {
+		return nil
+	}
+	if !*collectConsent {
+		fmt.Printf(tr("%d files failed. Write %s with the first %d KB of each, the errors and system info (OS, CPU, settings; no image content beyond the headers)? [y/N] "),
+			len(c.failures), *collectFailures, *collectKB)
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		if !consented(answer) {
+			fmt.Println(tr("No bundle written."))
+			return nil
+		}
+	}
+	if err := writeFailureBundle(*collectFailures, c.failures, int64(*collectKB)<<10); err != nil {
+		return err
+	}
+	fmt.Printf(tr("Wrote %s; attach it to a GitHub issue.\n"), *collectFailures)
+	return nil
+}
+
+// consented accepts yes in the languages of the prompt.
+func consented(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "s", "si", "sí", "o", "oui", "j", "ja":
+		return true
+	}
+	return false
+}
+
+// writeFailureBundle writes the head of each failed file (named by its base
+// name only, so folder names stay private), errors.txt and environment.txt.
+func writeFailureBundle(path string, failures []convert.ConversionResult, headBytes int64) error {
+	if err := guardWrite(path); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+
+	var errorsTxt strings.Builder
+	for i, result := range failures {
+		name := fmt.Sprintf("files/%03d_%s", i+1, filepath.Base(result.Input))
+		fmt.Fprintf(&errorsTxt, "%s\t%d bytes\t%v\n", name, result.InputSize, result.Err)
+		if err := addFileHead(zw, name, result.Input, headBytes); err != nil {
+			fmt.Fprintf(&errorsTxt, "%s\tnot included: %v\n", name, err)
+		}
+	}
+	if err := addZipText(zw, "errors.txt", errorsTxt.String()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := addZipText(zw, "environment.txt", environmentInfo()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func addFileHead(zw *zip.Writer, name, path string, n int64) error {
+	in, err := os.Open(longPath(path))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(w, in, n)
+	if err == io.EOF {
+		err = nil
+	}
+	return err
+}
+
+func addZipText(zw *zip.Writer, name, text string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, text)
+	return err
+}
+
+func environmentInfo() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "OS: %s/%s, %d CPUs\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	fmt.Fprintf(&b, "Go: %s\n", runtime.Version())
+	build := currentBuild()
+	fmt.Fprintf(&b, "Version: %s, commit %s, libde265 %s\n", build.Version, build.Commit, build.Libde265)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "Module: %s %s\n", info.Main.Path, info.Main.Version)
+		for _, dep := range info.Deps {
+			fmt.Fprintf(&b, "Dependency: %s %s\n", dep.Path, dep.Version)
+		}
+	}
+	fmt.Fprintf(&b, "Settings:")
+	flag.Visit(func(f *flag.Flag) { fmt.Fprintf(&b, " -%s=%s", f.Name, f.Value) })
+	fmt.Fprintf(&b, "\n")
+	return b.String()
+}
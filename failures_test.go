@@ -0,0 +1,64 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+func TestFailureBundle(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "private folder", "IMG_0001.heic")
+	os.MkdirAll(filepath.Dir(input), 0755)
+	os.WriteFile(input, []byte(strings.Repeat("x", 5000)), 0644)
+
+	old := *collectFailures
+	*collectFailures = filepath.Join(dir, "bundle.zip")
+	defer func() { *collectFailures = old }()
+
+	c := &failureCollector{}
+	c.OnFileDone(convert.ConversionResult{Input: filepath.Join(dir, "ok.heic")})
+	c.OnFileDone(convert.ConversionResult{Input: input, InputSize: 5000, Err: errors.New("heif: truncated")})
+
+	if err := c.finish(strings.NewReader("n\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(*collectFailures); !os.IsNotExist(err) {
+		t.Fatal("bundle written without consent")
+	}
+
+	oldKB := *collectKB
+	*collectKB = 1
+	defer func() { *collectKB = oldKB }()
+	if err := c.finish(strings.NewReader("y\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(*collectFailures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		r, _ := f.Open()
+		data, _ := io.ReadAll(r)
+		r.Close()
+		contents[f.Name] = string(data)
+	}
+	if head := contents["files/001_IMG_0001.heic"]; len(head) != 1024 {
+		t.Errorf("file head is %d bytes, want 1024", len(head))
+	}
+	if !strings.Contains(contents["errors.txt"], "heif: truncated") || strings.Contains(contents["errors.txt"], "private folder") {
+		t.Errorf("errors.txt = %q", contents["errors.txt"])
+	}
+	if !strings.Contains(contents["environment.txt"], "OS: ") {
+		t.Errorf("environment.txt = %q", contents["environment.txt"])
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	feedFile    = flag.String("feed", "", "keep an Atom (.xml, .atom) or JSON Feed (.json) file of the latest converted photos, updated after every run, for photo frames and dashboards to poll; made for -schedule")
+	feedEntries = flag.Int("feed-entries", 50, "how many of the latest photos -feed lists")
+	feedBaseURL = flag.String("feed-url", "", "URL the folder of the -feed file is served at, for links to the photos; without it they're file: URLs")
+)
+
+// feedEntry is a converted photo of the feed. ID is its URL, so a photo
+// converted again replaces its entry.
+type feedEntry struct {
+	ID      string
+	Title   string
+	Summary string
+	Type    string
+	Size    int64
+	Updated time.Time
+}
+
+// feedObserver adds the photos of each run to the -feed file when the run
+// finishes. The entries are read back from the file, so the feed goes on
+// across runs and restarts.
+type feedObserver struct {
+	path  string
+	dir   string // converted from, for the title
+	mu    sync.Mutex
+	added []feedEntry
+}
+
+func newFeedObserver(path, dir string) (*feedObserver, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return &feedObserver{path: path, dir: dir}, nil
+}
+
+func (o *feedObserver) OnStart(total int) {}
+
+func (o *feedObserver) OnFileDone(result convert.ConversionResult) {
+	if result.Err != nil || result.Skipped != "" || result.Output == "" {
+		return
+	}
+	info, err := os.Stat(longPath(result.Output))
+	if err != nil {
+		return
+	}
+	output, _ := filepath.Abs(result.Output)
+	x, _ := readJPEGExif(output)
+	entry := feedEntry{
+		ID:      o.link(output),
+		Title:   filepath.Base(output),
+		Summary: caption(output, x),
+		Type:    "image/jpeg",
+		Size:    info.Size(),
+		Updated: time.Now().UTC().Truncate(time.Second),
+	}
+	if strings.EqualFold(filepath.Ext(output), ".png") {
+		entry.Type = "image/png"
+	}
+	o.mu.Lock()
+	o.added = append(o.added, entry)
+	o.mu.Unlock()
+}
+
+func (o *feedObserver) OnFinish(convert.Summary) {
+	o.mu.Lock()
+	added := o.added
+	o.added = nil
+	o.mu.Unlock()
+	if len(added) == 0 {
+		return
+	}
+	if err := o.update(added); err != nil {
+		fmt.Printf(tr("Failed to update the feed: %v\n"), err)
+	}
+}
+
+// link is the URL of the photo at path: under -feed-url when it's in the
+// feed's folder.
+func (o *feedObserver) link(path string) string {
+	if *feedBaseURL != "" {
+		rel, err := filepath.Rel(filepath.Dir(o.path), path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			u := url.URL{Path: filepath.ToSlash(rel)}
+			return strings.TrimSuffix(*feedBaseURL, "/") + "/" + u.EscapedPath()
+		}
+	}
+	return fileURL(path)
+}
+
+func fileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // C:/Users
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// update merges added into the entries of the feed file, newest first and
+// -feed-entries at most, and writes it back.
+func (o *feedObserver) update(added []feedEntry) error {
+	entries, err := readFeed(o.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	seen := map[string]bool{}
+	var merged []feedEntry
+	for _, e := range append(added, entries...) {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			merged = append(merged, e)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Updated.After(merged[j].Updated) })
+	if len(merged) > *feedEntries {
+		merged = merged[:*feedEntries]
+	}
+
+	title := "heictojpeg: " + filepath.Base(o.dir)
+	var data []byte
+	if isJSONFeed(o.path) {
+		data, err = json.MarshalIndent(o.jsonFeed(title, merged), "", "  ")
+	} else {
+		data, err = xml.MarshalIndent(o.atomFeed(title, merged), "", "  ")
+		data = append([]byte(xml.Header), data...)
+	}
+	if err != nil {
+		return err
+	}
+	// Pollers never see a half-written feed.
+	tmp := o.path + ".tmp"
+	if err := guardWrite(o.path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(longPath(tmp), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(longPath(tmp), longPath(o.path))
+}
+
+func isJSONFeed(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// checkFeedFile checks that the -feed file name tells its format.
+func checkFeedFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".xml", ".atom":
+		return nil
+	}
+	return errors.New("must end in .xml or .atom for Atom, or .json for JSON Feed")
+}
+
+// readFeed reads the entries of the feed file at path.
+func readFeed(path string) ([]feedEntry, error) {
+	data, err := os.ReadFile(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	var entries []feedEntry
+	if isJSONFeed(path) {
+		var feed jsonFeed
+		if err := json.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for _, item := range feed.Items {
+			e := feedEntry{ID: item.ID, Title: item.Title, Summary: item.ContentText, Updated: item.DatePublished}
+			if len(item.Attachments) > 0 {
+				e.Type, e.Size = item.Attachments[0].MimeType, item.Attachments[0].Size
+			}
+			entries = append(entries, e)
+		}
+		return entries, nil
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, entry := range feed.Entries {
+		e := feedEntry{ID: entry.ID, Title: entry.Title, Summary: entry.Summary, Updated: entry.Updated}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				e.Type, e.Size = link.Type, link.Length
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// jsonFeed is a JSON Feed 1.1 (https://jsonfeed.org/version/1.1).
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentText   string               `json:"content_text"`
+	Image         string               `json:"image"`
+	DatePublished time.Time            `json:"date_published"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttachment struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes,omitempty"`
+}
+
+func (o *feedObserver) jsonFeed(title string, entries []feedEntry) jsonFeed {
+	feed := jsonFeed{Version: "https://jsonfeed.org/version/1.1", Title: title, Items: []jsonFeedItem{}}
+	if *feedBaseURL != "" {
+		feed.HomePageURL = strings.TrimSuffix(*feedBaseURL, "/") + "/"
+		feed.FeedURL = o.link(o.path)
+	}
+	for _, e := range entries {
+		feed.Items = append(feed.Items, jsonFeedItem{
+			ID:            e.ID,
+			URL:           e.ID,
+			Title:         e.Title,
+			ContentText:   e.Summary,
+			Image:         e.ID,
+			DatePublished: e.Updated,
+			Attachments:   []jsonFeedAttachment{{URL: e.ID, MimeType: e.Type, Size: e.Size}},
+		})
+	}
+	return feed
+}
+
+// atomFeed is an Atom feed (RFC 4287).
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated time.Time   `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Summary string     `xml:"summary"`
+	Updated time.Time  `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+func (o *feedObserver) atomFeed(title string, entries []feedEntry) atomFeed {
+	self := o.link(o.path)
+	feed := atomFeed{ID: self, Title: title, Author: "heictojpeg", Links: []atomLink{{Rel: "self", Href: self}}}
+	for _, e := range entries {
+		if e.Updated.After(feed.Updated) {
+			feed.Updated = e.Updated
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Summary: e.Summary,
+			Updated: e.Updated,
+			Links: []atomLink{
+				{Rel: "alternate", Href: e.ID, Type: e.Type},
+				{Rel: "enclosure", Href: e.ID, Type: e.Type, Length: e.Size},
+			},
+		})
+	}
+	return feed
+}
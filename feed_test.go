@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/exif"
+)
+
+func TestFeedObserver(t *testing.T) {
+	defer func(v int) { *feedEntries = v }(*feedEntries)
+	*feedEntries = 2
+	dir := t.TempDir()
+	jpegDir := filepath.Join(dir, "jpegs")
+	if err := os.Mkdir(jpegDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	photo := func(name string) string {
+		var buf bytes.Buffer
+		data := exif.Build([]exif.Tag{exif.ASCII(exif.TagMake, "Apple"), exif.ASCII(exif.TagModel, "iPhone 15")}, nil)
+		if err := encodeJPEGQuality(&buf, testPhoto(16, 16, false), data, 80); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(jpegDir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	run := func(o *feedObserver, names ...string) {
+		o.OnStart(len(names))
+		for _, name := range names {
+			o.OnFileDone(convert.ConversionResult{Name: name, Output: photo(name)})
+		}
+		o.OnFileDone(convert.ConversionResult{Name: "bad.heic", Err: os.ErrInvalid})
+		o.OnFinish(convert.Summary{})
+	}
+
+	for _, name := range []string{"feed.xml", "feed.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			o, err := newFeedObserver(path, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			run(o, "IMG_0001.jpg")
+			// The next run is later, and lists its photos first.
+			entries, err := readFeed(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries[0].Updated = entries[0].Updated.Add(-time.Hour)
+			if err := o.update(entries); err != nil {
+				t.Fatal(err)
+			}
+			run(o, "IMG_0002.jpg", "IMG_0003.jpg")
+
+			entries, err = readFeed(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("%d entries, want the -feed-entries 2 latest", len(entries))
+			}
+			for _, e := range entries {
+				if e.Title == "IMG_0001.jpg" {
+					t.Error("the oldest photo is still listed")
+				}
+				if !strings.HasPrefix(e.ID, "file:///") || e.Type != "image/jpeg" || e.Size == 0 || !strings.Contains(e.Summary, "Apple iPhone 15") {
+					t.Errorf("entry %+v", e)
+				}
+			}
+		})
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "feed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed map[string]interface{}
+	if err := json.Unmarshal(data, &feed); err != nil || feed["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("not a JSON Feed: %v", err)
+	}
+}
+
+func TestFeedLink(t *testing.T) {
+	defer func(v string) { *feedBaseURL = v }(*feedBaseURL)
+	*feedBaseURL = "http://nas.local/photos/"
+	dir := t.TempDir()
+	o, err := newFeedObserver(filepath.Join(dir, "feed.xml"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := o.link(filepath.Join(dir, "jpegs", "Trip 1", "IMG_0001.jpg")), "http://nas.local/photos/jpegs/Trip%201/IMG_0001.jpg"; got != want {
+		t.Errorf("link = %q, want %q", got, want)
+	}
+	// Outside the feed's folder, it can't be under -feed-url.
+	if got := o.link(filepath.Dir(dir)); !strings.HasPrefix(got, "file:///") {
+		t.Errorf("link outside = %q", got)
+	}
+}
+
+func TestCheckFeedFile(t *testing.T) {
+	for path, ok := range map[string]bool{"feed.xml": true, "feed.atom": true, "Feed.JSON": true, "feed.rss": false, "feed": false} {
+		if err := checkFeedFile(path); (err == nil) != ok {
+			t.Errorf("checkFeedFile(%q) = %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// folderConfigName is the file that overrides settings for a folder and
+// everything below it, e.g. {"quality": 95} in a "Weddings" folder.
+const folderConfigName = ".heictojpeg"
+
+// folderConfigs resolves the settings of files under root from the
+// .heictojpeg files between root and each file, read once per batch.
+type folderConfigs struct {
+	root  string
+	mu    sync.Mutex
+	cache map[string]folderConfig
+}
+
+type folderConfig struct {
+	options map[string]interface{}
+	err     error
+}
+
+func newFolderConfigs(root string) *folderConfigs {
+	return &folderConfigs{root: root, cache: map[string]folderConfig{}}
+}
+
+// settingsFor returns the settings for rel, a path relative to the root.
+// Inner folders override outer ones, and all of them override the
+// global settings.
+func (c *folderConfigs) settingsFor(base settings, rel string) (settings, error) {
+	var dirs []string
+	for dir := filepath.Dir(rel); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "." || dir == string(filepath.Separator) {
+			break
+		}
+	}
+
+	s := base
+	for i := len(dirs) - 1; i >= 0; i-- {
+		config := c.load(dirs[i])
+		if config.err != nil {
+			return base, config.err
+		}
+		if config.options == nil {
+			continue
+		}
+		if err := s.apply(config.options); err != nil {
+			return base, fmt.Errorf("%s: %v", filepath.Join(dirs[i], folderConfigName), err)
+		}
+	}
+	return s, nil
+}
+
+func (c *folderConfigs) load(dir string) folderConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if config, ok := c.cache[dir]; ok {
+		return config
+	}
+
+	var config folderConfig
+	path := filepath.Join(c.root, dir, folderConfigName)
+	data, err := os.ReadFile(longPath(path))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		config.err = err
+	default:
+		if err := json.Unmarshal(data, &config.options); err != nil {
+			config.err = fmt.Errorf("%s: %v", filepath.Join(dir, folderConfigName), err)
+		}
+	}
+	c.cache[dir] = config
+	return config
+}
+
+type folderConfigsKey struct{}
+
+func withFolderConfigs(ctx context.Context, c *folderConfigs) context.Context {
+	return context.WithValue(ctx, folderConfigsKey{}, c)
+}
+
+func folderConfigsFrom(ctx context.Context) *folderConfigs {
+	c, _ := ctx.Value(folderConfigsKey{}).(*folderConfigs)
+	return c
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFolderSettings(t *testing.T) {
+	root := t.TempDir()
+	for dir, config := range map[string]string{
+		".":                   `{"quality": 80}`,
+		"Weddings":            `{"quality": 95, "metadata": "strip"}`,
+		"Weddings/2023/share": `{"preset": "email", "quality": 60}`,
+		"Broken":              `{"quality": 500}`,
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, folderConfigName), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	folders := newFolderConfigs(root)
+	base := settings{Quality: 75, MaxSize: 0, Metadata: "keep"}
+	for rel, want := range map[string]settings{
+		"a.heic":                         {Quality: 80, Metadata: "keep"},
+		"Weddings/b.heic":                {Quality: 95, Metadata: "strip"},
+		"Weddings/2023/c.heic":           {Quality: 95, Metadata: "strip"},
+		"Weddings/2023/share/d.heic":     {Quality: 60, MaxSize: 1280, Metadata: "strip"},
+		filepath.Join("Other", "e.heic"): {Quality: 80, Metadata: "keep"},
+	} {
+		got, err := folders.settingsFor(base, filepath.FromSlash(rel))
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+		} else if got != want {
+			t.Errorf("%s: settings = %+v, want %+v", rel, got, want)
+		}
+	}
+
+	if _, err := folders.settingsFor(base, filepath.Join("Broken", "f.heic")); err == nil || !strings.Contains(err.Error(), "quality") {
+		t.Errorf("invalid quality in a folder file: err = %v", err)
+	}
+}
+
+func TestSettingsApplyUnknown(t *testing.T) {
+	s := globalSettings()
+	if err := s.apply(map[string]interface{}{"workers": 2.0}); err == nil {
+		t.Error("an option that can't change per file was accepted")
+	}
+	if err := s.apply(map[string]interface{}{"preset": "nope"}); err == nil {
+		t.Error("unknown preset accepted")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"heictojpeg/convert"
+)
+
+var (
+	bestFrame = flag.Bool("best-frame", false, "for HEICs holding several shots (bursts), convert only the sharpest, best-exposed one")
+	allFrames = flag.Bool("all-frames", false, "for HEICs holding several shots (bursts), also convert the others, as IMG_0001-2.jpg, IMG_0001-3.jpg, ...")
+)
+
+// frameFileName names the n-th shot's output, e.g. IMG_0001-2.jpg.
+func frameFileName(output string, n int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(output, ext), n, ext)
+}
+
+// convertOtherFrames writes the shots after the primary one for
+// -all-frames and returns how many it wrote.
+func convertOtherFrames(ctx context.Context, input, output string) (int, error) {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return convert.OtherFrames(ctx, f, settingsFrom(ctx).options(), func(n int, d convert.Decoded) error {
+		out, err := os.Create(longPath(frameFileName(output, n)))
+		if err != nil {
+			return err
+		}
+		recordProfile(ctx, d.Profile)
+		err = encodeJPEG(ctx, out, d.Image, d.Exif)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFrameFileName(t *testing.T) {
+	if got, want := frameFileName(filepath.Join("jpegs", "IMG_1.jpg"), 2), filepath.Join("jpegs", "IMG_1-2.jpg"); got != want {
+		t.Errorf("frameFileName = %q, want %q", got, want)
+	}
+}
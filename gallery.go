@@ -0,0 +1,352 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"image"
+	"image/jpeg"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/exif"
+)
+
+func init() {
+	subcommands["gallery"] = galleryCommand
+}
+
+// galleryThumbs is the gallery's folder of thumbnails in the folder it
+// shows.
+const galleryThumbs = "gallery-thumbs"
+
+// EXIF tags of the captions.
+const (
+	tagImageDescription = 0x010e
+	tagExposureTime     = 0x829a
+	tagFNumber          = 0x829d
+	tagISO              = 0x8827
+	tagDateTimeOriginal = 0x9003
+	tagFocalLength      = 0x920a
+)
+
+// galleryPhoto is a photo of the gallery page. The paths are URLs
+// relative to the page.
+type galleryPhoto struct {
+	ID, Prev, Next string
+	Name           string
+	Src, Thumb     string
+	Caption        string
+}
+
+var galleryPage = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;background:#111;color:#ddd;font:15px/1.4 system-ui,sans-serif}
+h1{font-weight:400;margin:24px}
+.grid{display:grid;grid-template-columns:repeat(auto-fill,minmax({{.Cell}}px,1fr));gap:6px;padding:0 24px 24px}
+.grid a{display:block;aspect-ratio:1;background:#222}
+.grid img{display:block;width:100%;height:100%;object-fit:cover}
+.lightbox{display:none;position:fixed;inset:0;background:rgba(0,0,0,.94);flex-direction:column;align-items:center;justify-content:center}
+.lightbox:target{display:flex}
+.lightbox img{max-width:94vw;max-height:86vh}
+.lightbox p{margin:10px 16px;text-align:center}
+.lightbox a{color:#ddd;text-decoration:none}
+.close{position:absolute;top:8px;right:20px;font-size:36px}
+.prev,.next{position:absolute;top:45%;font-size:56px;padding:16px}
+.prev{left:8px}
+.next{right:8px}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="grid">
+{{range .Photos}}<a href="#{{.ID}}"><img src="{{.Thumb}}" alt="{{.Name}}" loading="lazy"></a>
+{{end}}</div>
+{{range .Photos}}<div class="lightbox" id="{{.ID}}">
+<a class="close" href="#" aria-label="Close">&times;</a>
+{{if .Prev}}<a class="prev" href="#{{.Prev}}" aria-label="Previous">&lsaquo;</a>
+{{end}}<img src="{{.Src}}" alt="{{.Name}}" loading="lazy">
+<p>{{.Caption}}</p>
+{{if .Next}}<a class="next" href="#{{.Next}}" aria-label="Next">&rsaquo;</a>
+{{end}}</div>
+{{end}}<script>
+document.addEventListener("keydown", function (e) {
+  var open = document.querySelector(".lightbox:target");
+  var link = open && open.querySelector({ArrowLeft: ".prev", ArrowRight: ".next", Escape: ".close"}[e.key] || null);
+  if (link) location.hash = link.getAttribute("href");
+});
+</script>
+</body>
+</html>
+`))
+
+// galleryPhotos lists the JPEG and PNG files under dir, in path order,
+// leaving out the gallery's thumbnails and the folders of -profiles.
+func galleryPhotos(dir string) ([]string, error) {
+	skip := map[string]bool{galleryThumbs: true}
+	for _, name := range profileList() {
+		if extra, err := parseProfile(name, globalSettings()); err == nil {
+			skip[extra.folder] = true
+		}
+	}
+	var photos []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (skip[d.Name()] || skip[rel] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jpg", ".jpeg", ".png":
+			photos = append(photos, rel)
+		}
+		return nil
+	})
+	sort.Slice(photos, func(i, j int) bool { return pathLess(photos[i], photos[j]) })
+	return photos, err
+}
+
+// galleryThumb writes the thumbnail of the photo src to dst, upright and
+// at most side pixels a side, unless one newer than the photo is there.
+func galleryThumb(src, dst string, side, quality int) error {
+	srcInfo, err := os.Stat(longPath(src))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(longPath(dst)); err == nil && !info.ModTime().Before(srcInfo.ModTime()) {
+		return nil
+	}
+	f, err := os.Open(longPath(src))
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	x, _ := readJPEGExif(src)
+	thumb := upright(convert.FitWithin(img, side), x.Orientation())
+	if err := os.MkdirAll(longPath(filepath.Dir(dst)), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(longPath(dst))
+	if err != nil {
+		return err
+	}
+	err = jpeg.Encode(out, thumb, &jpeg.Options{Quality: quality})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// upright turns img, stored with the EXIF orientation o, the way viewers
+// show it: thumbnails are written without EXIF.
+func upright(img image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	size := image.Rect(0, 0, w, h)
+	if o >= 5 {
+		size = image.Rect(0, 0, h, w)
+	}
+	dst := image.NewRGBA(size)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// caption describes a photo from its EXIF: its description, when it has
+// one, when it was taken, with what camera and at what exposure.
+func caption(name string, x *exif.Data) string {
+	parts := []string{strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))}
+	if x == nil {
+		return parts[0]
+	}
+	if d := x.Text(x.IFD0, tagImageDescription); d != "" {
+		parts = append(parts, d)
+	}
+	if t := x.Text(x.Exif, tagDateTimeOriginal); len(t) >= 16 {
+		// 2024:06:01 10:00:00
+		parts = append(parts, strings.Replace(t[:10], ":", "-", 2)+" "+t[11:16])
+	}
+	cameraMake, cameraModel := x.Camera()
+	if cameraMake != "" && !strings.HasPrefix(strings.ToLower(cameraModel), strings.ToLower(cameraMake)) {
+		cameraModel = strings.TrimSpace(cameraMake + " " + cameraModel)
+	}
+	if cameraModel != "" {
+		parts = append(parts, cameraModel)
+	}
+	var exposure []string
+	if v := x.Rationals(x.Exif, tagFNumber); len(v) == 1 && v[0] > 0 {
+		exposure = append(exposure, fmt.Sprintf("f/%.3g", v[0]))
+	}
+	if v := x.Rationals(x.Exif, tagExposureTime); len(v) == 1 && v[0] > 0 {
+		if v[0] < 1 {
+			exposure = append(exposure, fmt.Sprintf("1/%d s", int(math.Round(1/v[0]))))
+		} else {
+			exposure = append(exposure, fmt.Sprintf("%g s", v[0]))
+		}
+	}
+	if iso, ok := x.Uint(x.Exif, tagISO); ok {
+		exposure = append(exposure, fmt.Sprintf("ISO %d", iso))
+	}
+	if v := x.Rationals(x.Exif, tagFocalLength); len(v) == 1 && v[0] > 0 {
+		exposure = append(exposure, fmt.Sprintf("%g mm", math.Round(v[0]*10)/10))
+	}
+	if len(exposure) > 0 {
+		parts = append(parts, strings.Join(exposure, " "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// writeGallery writes dir/index.html, a page of the photos in dir with a
+// lightbox, and their thumbnails. It returns how many photos it shows.
+func writeGallery(dir, title string, side, quality int) (int, error) {
+	photos, err := galleryPhotos(dir)
+	if err != nil {
+		return 0, err
+	}
+	if len(photos) == 0 {
+		return 0, fmt.Errorf("no JPEG or PNG files in %s", dir)
+	}
+	thumbOf := func(rel string) string {
+		return filepath.Join(galleryThumbs, strings.TrimSuffix(rel, filepath.Ext(rel))+".jpg")
+	}
+
+	// The thumbnails are the slow part: one decode of each photo.
+	next := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range next {
+				if err := galleryThumb(filepath.Join(dir, rel), filepath.Join(dir, thumbOf(rel)), side, quality); err != nil {
+					fmt.Printf(tr("Failed to make a thumbnail of %s: %v\n"), rel, err)
+				}
+			}
+		}()
+	}
+	for _, rel := range photos {
+		next <- rel
+	}
+	close(next)
+	wg.Wait()
+
+	page := make([]galleryPhoto, len(photos))
+	for i, rel := range photos {
+		x, _ := readJPEGExif(filepath.Join(dir, rel))
+		page[i] = galleryPhoto{
+			ID:      fmt.Sprintf("p%d", i+1),
+			Name:    filepath.Base(rel),
+			Src:     filepath.ToSlash(rel),
+			Thumb:   filepath.ToSlash(thumbOf(rel)),
+			Caption: caption(rel, x),
+		}
+		if i > 0 {
+			page[i].Prev = page[i-1].ID
+			page[i-1].Next = page[i].ID
+		}
+	}
+	out, err := os.Create(longPath(filepath.Join(dir, "index.html")))
+	if err != nil {
+		return 0, err
+	}
+	err = galleryPage.Execute(out, map[string]interface{}{"Title": title, "Cell": side * 2 / 3, "Photos": page})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return len(page), err
+}
+
+// galleryCommand makes a static web album of a folder of converted
+// photos, to upload or share as it is.
+func galleryCommand(args []string) error {
+	fs := flag.NewFlagSet("gallery", flag.ExitOnError)
+	title := fs.String("title", "", "title of the page (default the name of the folder the photos were converted from)")
+	side := fs.Int("thumb-size", 360, "longest side of the thumbnails, in pixels")
+	quality := fs.Int("quality", 80, "JPEG quality of the thumbnails")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg gallery [-title TITLE] [-thumb-size 360] [-quality 80] [FOLDER]")
+		fmt.Fprintln(fs.Output(), "Writes FOLDER/index.html (default jpegs) showing its photos, with thumbnails in FOLDER/"+galleryThumbs+".")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *quality < 1 || *quality > 100 {
+		return fmt.Errorf("invalid quality %d: must be 1 to 100", *quality)
+	}
+	if *side < 16 {
+		return fmt.Errorf("invalid thumb-size %d: must be 16 pixels or more", *side)
+	}
+	dir := "jpegs"
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if *title == "" {
+		*title = filepath.Base(dir)
+		if *title == "jpegs" {
+			*title = filepath.Base(filepath.Dir(dir))
+		}
+	}
+	// The folders of the config file's profiles hold copies, not more photos.
+	if err := readConfigDefinitions(); err != nil {
+		return err
+	}
+	n, err := writeGallery(dir, *title, *side, *quality)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(tr("Wrote %s with %d photos\n"), filepath.Join(dir, "index.html"), n)
+	return nil
+}
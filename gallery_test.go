@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"heictojpeg/internal/exif"
+)
+
+func ratioTag(tag uint16, num, den uint32) exif.Tag {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b, num)
+	binary.LittleEndian.PutUint32(b[4:], den)
+	return exif.Tag{ID: tag, Type: exif.TypeRational, Count: 1, Value: b}
+}
+
+func TestCaption(t *testing.T) {
+	data := exif.Build(
+		[]exif.Tag{exif.ASCII(exif.TagMake, "Apple"), exif.ASCII(exif.TagModel, "iPhone 15 Pro"), exif.ASCII(tagImageDescription, "Beach <day>")},
+		[]exif.Tag{
+			exif.ASCII(tagDateTimeOriginal, "2024:06:01 10:00:05"),
+			ratioTag(tagFNumber, 178, 100),
+			ratioTag(tagExposureTime, 1, 120),
+			exif.Short(tagISO, 50),
+			ratioTag(tagFocalLength, 677, 100),
+		})
+	x, err := exif.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "IMG_0001 · Beach <day> · 2024-06-01 10:00 · Apple iPhone 15 Pro · f/1.78 1/120 s ISO 50 6.8 mm"
+	if got := caption("sub/IMG_0001.jpg", x); got != want {
+		t.Errorf("caption = %q, want %q", got, want)
+	}
+	if got := caption("IMG_0002.png", nil); got != "IMG_0002" {
+		t.Errorf("caption without EXIF = %q", got)
+	}
+}
+
+func TestUpright(t *testing.T) {
+	// 3×2, with the top-left pixel marked.
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(0, 0, color.White)
+	for o, want := range map[int]image.Point{1: {0, 0}, 2: {2, 0}, 3: {2, 1}, 4: {0, 1}, 5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2}} {
+		got := upright(img, o)
+		size := image.Pt(3, 2)
+		if o >= 5 {
+			size = image.Pt(2, 3)
+		}
+		if got.Bounds().Size() != size {
+			t.Errorf("orientation %d: size %v, want %v", o, got.Bounds().Size(), size)
+			continue
+		}
+		if r, _, _, _ := got.At(want.X, want.Y).RGBA(); r != 0xffff {
+			t.Errorf("orientation %d: top-left pixel isn't at %v", o, want)
+		}
+	}
+}
+
+func TestWriteGallery(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel string, data []byte) {
+		var buf bytes.Buffer
+		if err := encodeJPEGQuality(&buf, testPhoto(400, 200, false), data, 80); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("IMG_0002.jpg", exif.Build([]exif.Tag{exif.Short(exif.TagOrientation, 6), exif.ASCII(tagImageDescription, "<b>Sunset</b>")}, nil))
+	write("IMG_0010.jpg", nil)
+	write("2024/IMG_0001.jpg", nil)
+	write("web/IMG_0002.jpg", nil) // -profiles web
+	write(".hidden/IMG_0003.jpg", nil)
+
+	n, err := writeGallery(dir, "Trip & more", 100, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("gallery of %d photos, want 3", n)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(page)
+	for _, want := range []string{
+		"<title>Trip &amp; more</title>",
+		`src="gallery-thumbs/2024/IMG_0001.jpg"`,
+		`src="2024/IMG_0001.jpg"`,
+		"IMG_0002 · &lt;b&gt;Sunset&lt;/b&gt;",
+		`href="#p3"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("index.html is missing %s", want)
+		}
+	}
+	if strings.Contains(html, "web/") || strings.Contains(html, "IMG_0003") {
+		t.Error("index.html shows the copies of a profile or a hidden folder")
+	}
+	if i, j := strings.Index(html, "2024/IMG_0001"), strings.Index(html, "IMG_0010"); i > j {
+		t.Error("photos aren't in path order")
+	}
+
+	thumb, err := os.Open(filepath.Join(dir, galleryThumbs, "IMG_0002.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer thumb.Close()
+	config, _, err := image.DecodeConfig(thumb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Turned upright from 400×200.
+	if config.Width != 50 || config.Height != 100 {
+		t.Errorf("thumbnail is %dx%d, want 50x100", config.Width, config.Height)
+	}
+
+	// A second run shows the same photos, not the thumbnails as well.
+	if n, err := writeGallery(dir, "Trip", 100, 80); err != nil || n != 3 {
+		t.Errorf("second run: %d photos, %v", n, err)
+	}
+}
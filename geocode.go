@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+var organizeByLocation = flag.Bool("organize-by-location", false, "put each JPEG in a jpegs/<country>/<city> folder after where it was taken, from its GPS tags (offline, coarse)")
+
+// A photo within cityRadiusKm of a city in places goes into the city's
+// folder. Further out the dataset can't tell the country: the nearest
+// city may well be across a border.
+const (
+	cityRadiusKm  = 50
+	earthRadiusKm = 6371
+)
+
+type place struct {
+	Name    string
+	Country string
+	Lat     float64
+	Lon     float64
+}
+
+// distanceKm is the great-circle distance between two coordinates.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat, dLon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// nearestPlace returns the closest place in the dataset and how far it is.
+func nearestPlace(lat, lon float64) (place, float64) {
+	var best place
+	bestKm := math.Inf(1)
+	for _, p := range places {
+		if km := distanceKm(lat, lon, p.Lat, p.Lon); km < bestKm {
+			best, bestKm = p, km
+		}
+	}
+	return best, bestKm
+}
+
+// locationFolder names the folder for a photo taken at lat, lon:
+// "Italy/Rome", or "" away from the cities.
+func locationFolder(lat, lon float64) string {
+	p, km := nearestPlace(lat, lon)
+	if km > cityRadiusKm {
+		return ""
+	}
+	return filepath.Join(p.Country, p.Name)
+}
+
+// locateOutput moves output under the location folder of the photo at
+// input, leaving it where it is when the photo has no GPS tags.
+func locateOutput(jpegDir, input, output string) string {
+	x, err := readExif(input)
+	if err != nil {
+		return output
+	}
+	lat, lon, ok := x.GPSPosition()
+	if !ok {
+		return output
+	}
+	folder := locationFolder(lat, lon)
+	if folder == "" {
+		return output
+	}
+	rel, err := filepath.Rel(jpegDir, output)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return output
+	}
+	return filepath.Join(jpegDir, sanitizePath(folder), rel)
+}
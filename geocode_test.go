@@ -0,0 +1,32 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocationFolder(t *testing.T) {
+	for _, c := range []struct {
+		lat, lon float64
+		want     string
+	}{
+		{41.8902, 12.4922, filepath.Join("Italy", "Rome")}, // the Colosseum
+		{-33.8568, 151.2153, filepath.Join("Australia", "Sydney")},
+		{37.80, -122.48, filepath.Join("United States", "San Francisco")},
+		{9.93, -84.08, filepath.Join("Costa Rica", "San Jose")},
+		{42.42, 12.10, ""}, // Tuscia, 80 km from Rome
+		{0, -30, ""},       // the middle of the Atlantic
+	} {
+		if got := locationFolder(c.lat, c.lon); got != c.want {
+			t.Errorf("locationFolder(%v, %v) = %q, want %q", c.lat, c.lon, got, c.want)
+		}
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	// Paris to London is about 344 km.
+	if d := distanceKm(48.8566, 2.3522, 51.5074, -0.1278); math.Abs(d-344) > 5 {
+		t.Errorf("Paris to London = %.0f km", d)
+	}
+}
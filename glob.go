@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// convertCommand is the optional verb in "heictojpeg convert [options]
+// targets"; it only names what the program does anyway.
+const convertCommand = "convert"
+
+// isGlob reports whether pattern has any of the wildcards * ? or [.
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// expandTargets expands the wildcards in the command-line targets, for
+// shells (cmd.exe, PowerShell) that pass them on as they are, and the ~ at
+// the start of a path. A target that exists as written is kept, so a file
+// with [ in its name is still found. A pattern with no matching files is
+// an error, the same as naming a file that doesn't exist.
+//
+// @list.txt stands for the paths listed in list.txt, one per line or
+// NUL-separated, for lists too long for the command line. They are used as
+// they are, without expanding wildcards.
+func expandTargets(targets []string) ([]string, error) {
+	var expanded []string
+	for _, target := range targets {
+		if strings.HasPrefix(target, "@") && len(target) > 1 {
+			paths, err := readResponseFile(expandHome(target[1:]))
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, paths...)
+			continue
+		}
+		target = expandHome(target)
+		if !isGlob(target) || isURL(target) {
+			expanded = append(expanded, target)
+			continue
+		}
+		if _, err := os.Stat(longPath(target)); err == nil {
+			expanded = append(expanded, target)
+			continue
+		}
+		matches, err := glob(target)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", target)
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded, nil
+}
+
+func readResponseFile(path string) ([]string, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	paths, err := readFileList(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return paths, nil
+}
+
+// expandHome replaces a leading ~ with the user's home folder.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// glob returns the files matching pattern, in name order. Unlike
+// filepath.Glob, a ** segment matches any number of folders (including
+// none), names are compared ignoring case, like the .heic extension, and
+// as in a shell, wildcards don't match names starting with a dot. Only
+// files are returned: folders a pattern matches are left out, so **/*
+// selects every file rather than every folder too. jpegs folders are not
+// searched by wildcards.
+func glob(pattern string) ([]string, error) {
+	volume := filepath.VolumeName(pattern)
+	rest := filepath.ToSlash(pattern[len(volume):])
+	dir := "."
+	if strings.HasPrefix(rest, "/") {
+		dir = volume + string(filepath.Separator)
+	} else if volume != "" {
+		dir = volume
+	}
+	var segments []string
+	for _, segment := range strings.Split(rest, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var matches []string
+	err := globSegments(dir, segments, func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			matches = append(matches, path)
+		}
+	})
+	sort.Strings(matches)
+	return matches, err
+}
+
+// globSegments calls found for each file under dir matching segments.
+func globSegments(dir string, segments []string, found func(string)) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	segment, last := segments[0], len(segments) == 1
+
+	if !isGlob(segment) {
+		path := filepath.Join(dir, segment)
+		info, err := os.Stat(longPath(path))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil
+		case err != nil:
+			return err
+		case last && !info.IsDir():
+			found(path)
+		case !last && info.IsDir():
+			return globSegments(path, segments[1:], found)
+		}
+		return nil
+	}
+
+	entries, err := os.ReadDir(longPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if segment == "**" {
+		if last {
+			// A trailing ** matches the files in every folder below.
+			segments = []string{"**", "*"}
+		}
+		if err := globSegments(dir, segments[1:], found); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != "jpegs" && !strings.HasPrefix(entry.Name(), ".") {
+				if err := globSegments(filepath.Join(dir, entry.Name()), segments, found); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	lower := strings.ToLower(segment)
+	for _, entry := range entries {
+		ok, err := filepath.Match(lower, strings.ToLower(entry.Name()))
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s: %v", segment, err)
+		}
+		if !ok || strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(segment, ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			info, err := os.Stat(longPath(path))
+			if err != nil {
+				continue
+			}
+			isDir = info.IsDir()
+		}
+		switch {
+		case last && !isDir:
+			found(path)
+		case !last && isDir && entry.Name() != "jpegs":
+			if err := globSegments(path, segments[1:], found); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
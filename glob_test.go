@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Testing glob with ** and case-insensitive names
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{
+		"top.heic", "2023/a.HEIC", "2023/Trip/b.heic", "2023/Trip/notes.txt",
+		"2023/jpegs/a.heic", "2023/.hidden/c.heic", "2023/.d.heic", "2024/d.heic",
+	} {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("mock content"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"2023/**/*.heic", []string{"2023/Trip/b.heic", "2023/a.HEIC"}},
+		{"**/*.heic", []string{"2023/Trip/b.heic", "2023/a.HEIC", "2024/d.heic", "top.heic"}},
+		{"202?/*.heic", []string{"2023/a.HEIC", "2024/d.heic"}},
+		{"2023/**", []string{"2023/Trip/b.heic", "2023/Trip/notes.txt", "2023/a.HEIC"}},
+		{"*", []string{"top.heic"}},
+		{"2023/.*.heic", []string{"2023/.d.heic"}},
+		{"2025/*.heic", nil},
+	}
+	for _, tt := range tests {
+		got, err := glob(filepath.Join(root, tt.pattern))
+		if err != nil {
+			t.Fatalf("glob(%s) failed: %v", tt.pattern, err)
+		}
+		var rel []string
+		for _, path := range got {
+			r, _ := filepath.Rel(root, path)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		if strings.Join(rel, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("glob(%s) = %v, want %v", tt.pattern, rel, tt.want)
+		}
+	}
+}
+
+// Testing that expandTargets keeps plain paths and rejects empty patterns
+func TestExpandTargets(t *testing.T) {
+	root := t.TempDir()
+	literal := filepath.Join(root, "[1].heic")
+	if err := os.WriteFile(literal, []byte("mock content"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	got, err := expandTargets([]string{literal, filepath.Join(root, "missing.heic")})
+	if err != nil || len(got) != 2 || got[0] != literal {
+		t.Errorf("expandTargets kept %v, %v", got, err)
+	}
+	if _, err := expandTargets([]string{filepath.Join(root, "*.jpg")}); err == nil {
+		t.Error("A pattern without matches should be an error")
+	}
+	home, err := os.UserHomeDir()
+	if err == nil && expandHome("~/Pictures") != filepath.Join(home, "Pictures") {
+		t.Errorf("expandHome(~/Pictures) = %s", expandHome("~/Pictures"))
+	}
+}
+
+// Testing that @file arguments are replaced by the paths listed in the file
+func TestExpandTargetsResponseFile(t *testing.T) {
+	root := t.TempDir()
+	list := filepath.Join(root, "list.txt")
+	if err := os.WriteFile(list, []byte("a.heic\r\nfolder/*.heic\n\nb.heic\n"), 0644); err != nil {
+		t.Fatalf("Failed to write list: %v", err)
+	}
+
+	got, err := expandTargets([]string{"first.heic", "@" + list})
+	if err != nil {
+		t.Fatalf("expandTargets failed: %v", err)
+	}
+	if want := "first.heic,a.heic,folder/*.heic,b.heic"; strings.Join(got, ",") != want {
+		t.Errorf("expandTargets = %v, want %s", got, want)
+	}
+	if _, err := expandTargets([]string{"@" + filepath.Join(root, "missing.txt")}); err == nil {
+		t.Error("A missing list file should be an error")
+	}
+}
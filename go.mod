@@ -2,12 +2,14 @@ module heictojpeg
 
 go 1.19
 
-require github.com/adrium/goheif v0.0.0-20230113233934-ca402e77a786
+require (
+	github.com/adrium/goheif v0.0.0-20230113233934-ca402e77a786
+	golang.org/x/sys v0.12.0
+	golang.org/x/text v0.13.0
+)
 
 require (
-	github.com/akavel/rsrc v0.10.2 // indirect
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e // indirect
-	golang.org/x/sys v0.12.0 // indirect
 	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
 )
 
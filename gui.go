@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+)
+
+var gui = flag.Bool("gui", false, "open a window to drop folders onto instead of converting the current directory; outside Windows, the window is a page in the browser")
+
+// droppedDirectories maps dropped paths to the folders to convert;
+// a dropped file converts the folder it is in.
+func droppedDirectories(paths []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			p = filepath.Dir(p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			dirs = append(dirs, p)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,264 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+// runGUI shows the window in the browser: a page served on the loopback
+// interface until the program is stopped. The page uploads the photos
+// dropped onto it, and the JPEGs are written to the output folder of dir.
+func runGUI(dir string) error {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	g := &guiServer{dir: jpegDirectory(dir), token: hex.EncodeToString(token)}
+	if g.limit, _ = parseByteSize(*maxBody); g.limit <= 0 {
+		return fmt.Errorf("invalid -max-body %q", *maxBody)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{
+		Handler:           g,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	url := fmt.Sprintf("http://%s/%s/", ln.Addr(), g.token)
+	if err := openWithDesktop(url); err != nil {
+		fmt.Printf(tr("Open %s in your browser to convert photos\n"), url)
+	} else {
+		fmt.Printf(tr("Opened %s in your browser; stop with Ctrl+C\n"), url)
+	}
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// guiServer serves the GUI page and converts what it uploads. Every path
+// starts with the random token, so other programs and web pages can't
+// write into dir through it.
+type guiServer struct {
+	dir   string // where the JPEGs go
+	token string
+	limit int64 // largest upload, from -max-body
+}
+
+// guiResult is the outcome of an upload, as the page lists it.
+type guiResult struct {
+	Status string `json:"status"`
+	Size   string `json:"size"`
+	Failed bool   `json:"failed,omitempty"`
+}
+
+func (g *guiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/" + g.token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	action := strings.TrimPrefix(r.URL.Path, prefix)
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		guiPage.Execute(w, struct {
+			Dir     string
+			Quality int
+		}{g.dir, *quality})
+	case action == "convert" && r.Method == http.MethodPost:
+		g.convert(w, r)
+	case action == "open" && r.Method == http.MethodPost:
+		if err := openWithDesktop(g.dir); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// convert writes the HEIC photo in the body to the output folder, under
+// the path of the name parameter: where it was in the dropped folder.
+func (g *guiServer) convert(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	rel := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" || !isHEIC(rel) {
+		http.Error(w, "name must be that of a .heic file", http.StatusBadRequest)
+		return
+	}
+	o := globalSettings().options()
+	if q := r.URL.Query().Get("quality"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 || n > 100 {
+			http.Error(w, "quality must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		o.Quality = n
+	}
+
+	output := filepath.Join(g.dir, jpegFileName(filepath.FromSlash(rel)))
+	result, err := convertUpload(r.Context(), http.MaxBytesReader(w, r.Body, g.limit), output, o)
+	res := guiResult{Status: "Converted", Size: humanReadableFileSize(result.InputSize) + " > " + humanReadableFileSize(result.OutputSize)}
+	if err != nil {
+		res = guiResult{Status: "Failed: " + err.Error(), Size: humanReadableFileSize(result.InputSize), Failed: true}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// convertUpload saves body to a temporary file and converts it to output.
+func convertUpload(ctx context.Context, body io.Reader, output string, o convert.Options) (convert.ConversionResult, error) {
+	var result convert.ConversionResult
+	if err := guardWrite(output); err != nil {
+		return result, err
+	}
+	f, err := os.CreateTemp("", "heictojpeg-*.heic")
+	if err != nil {
+		return result, err
+	}
+	defer os.Remove(f.Name())
+	result.InputSize, err = io.Copy(f, body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(output)), 0755); err != nil {
+		return result, err
+	}
+	return convert.File(ctx, f.Name(), output, o)
+}
+
+var guiPage = template.Must(template.New("gui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>HEIC to JPEG</title>
+<style>
+body{font:14px system-ui,sans-serif;margin:24px;color:#222}
+#drop{border:2px dashed #999;border-radius:8px;padding:32px;text-align:center}
+#drop.over{border-color:#06c;background:#eef5ff}
+.controls{margin:12px 0;display:flex;gap:12px;align-items:center}
+table{border-collapse:collapse;width:100%}
+td,th{text-align:left;padding:4px 8px;border-bottom:1px solid #eee}
+</style>
+</head>
+<body>
+<div id="drop">Drag a folder with .heic photos onto this window, or choose one:
+<p><input type="file" id="choose" webkitdirectory multiple></p></div>
+<div class="controls">
+<label>JPEG quality: <input type="number" id="quality" min="1" max="100" value="{{.Quality}}"></label>
+<button id="open">Open the JPEG folder</button>
+</div>
+<table><thead><tr><th>File</th><th>Status</th><th>Size</th></tr></thead><tbody id="files"></tbody></table>
+<p id="status">Waiting for a folder...</p>
+<script>
+const dir = {{.Dir}};
+const files = document.getElementById("files"), status = document.getElementById("status");
+const queue = [];
+let running = false, total = 0, done = 0, failed = 0;
+
+function add(file, name) {
+	if (!/\.heic$/i.test(name)) return;
+	const row = files.insertRow();
+	for (const text of [name, "Waiting", ""]) row.insertCell().textContent = text;
+	queue.push({file, name, row});
+	total++;
+}
+
+// walk adds the photos of a dropped file or folder, with their paths in it.
+function walk(entry, prefix) {
+	return new Promise(resolve => {
+		if (entry.isFile) {
+			entry.file(f => { add(f, prefix + f.name); resolve(); }, resolve);
+			return;
+		}
+		const reader = entry.createReader(), entries = [];
+		const read = () => reader.readEntries(batch => {
+			if (batch.length) {
+				entries.push(...batch);
+				read();
+				return;
+			}
+			Promise.all(entries.map(e => walk(e, prefix + entry.name + "/"))).then(resolve);
+		}, resolve);
+		read();
+	});
+}
+
+async function run() {
+	if (running) return;
+	running = true;
+	while (queue.length) {
+		const {file, name, row} = queue.shift();
+		row.cells[1].textContent = "Converting";
+		status.textContent = done + " of " + total + " files done";
+		let result;
+		try {
+			const quality = document.getElementById("quality").value;
+			const resp = await fetch("convert?name=" + encodeURIComponent(name) + "&quality=" + quality, {method: "POST", body: file});
+			result = resp.ok ? await resp.json() : {status: "Failed: " + (await resp.text()).trim(), size: "", failed: true};
+		} catch (err) {
+			result = {status: "Failed: " + err.message, size: "", failed: true};
+		}
+		row.cells[1].textContent = result.status;
+		row.cells[2].textContent = result.size;
+		done++;
+		if (result.failed) failed++;
+	}
+	running = false;
+	status.textContent = "Done: converted " + (done - failed) + " of " + done + " files (" + failed + " failed). The JPEGs are in " + dir + ".";
+}
+
+const drop = document.getElementById("drop");
+drop.addEventListener("dragover", e => { e.preventDefault(); drop.classList.add("over"); });
+drop.addEventListener("dragleave", () => drop.classList.remove("over"));
+drop.addEventListener("drop", e => {
+	e.preventDefault();
+	drop.classList.remove("over");
+	const entries = [...e.dataTransfer.items].map(item => item.webkitGetAsEntry()).filter(Boolean);
+	Promise.all(entries.map(entry => walk(entry, ""))).then(run);
+});
+document.getElementById("choose").addEventListener("change", e => {
+	for (const f of e.target.files) add(f, f.webkitRelativePath || f.name);
+	e.target.value = "";
+	run();
+});
+document.getElementById("open").addEventListener("click", () => fetch("open", {method: "POST"}));
+</script>
+</body>
+</html>
+`))
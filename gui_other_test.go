@@ -0,0 +1,56 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+func TestGUIServer(t *testing.T) {
+	dir := t.TempDir()
+	g := &guiServer{dir: dir, token: "secret", limit: 1 << 20}
+	serve := func(method, target string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(method, target, bytes.NewReader(body)))
+		return w
+	}
+
+	if w := serve(http.MethodGet, "/secret/", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "JPEG quality") {
+		t.Errorf("page: %d %q", w.Code, w.Body.String())
+	}
+	if w := serve(http.MethodGet, "/guess/", nil); w.Code != http.StatusNotFound {
+		t.Errorf("page without the token: %d", w.Code)
+	}
+	if w := serve(http.MethodPost, "/secret/convert?name=notes.txt", []byte("x")); w.Code != http.StatusBadRequest {
+		t.Errorf("upload of a text file: %d", w.Code)
+	}
+
+	for name, want := range map[string]string{"trip/IMG_1.HEIC": "trip/IMG_1.jpg", "../../IMG_2.heic": "IMG_2.jpg"} {
+		w := serve(http.MethodPost, "/secret/convert?quality=70&name="+name, heicsample.Single(8))
+		var res guiResult
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Failed {
+			t.Fatalf("%s: %d %q", name, w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(want))); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	w := serve(http.MethodPost, "/secret/convert?name=broken.heic", []byte("not a HEIC"))
+	var res guiResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || !res.Failed {
+		t.Errorf("broken upload: %d %q", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "broken.jpg")); !os.IsNotExist(err) {
+		t.Errorf("broken upload left a JPEG: %v", err)
+	}
+}
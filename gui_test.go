@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Testing droppedDirectories function
+func TestDroppedDirectories(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "IMG_0001.HEIC")
+	if err := os.WriteFile(photo, []byte("mock content"), 0644); err != nil {
+		t.Fatalf("Failed to write mock file: %v", err)
+	}
+
+	got := droppedDirectories([]string{dir, photo, filepath.Join(dir, "missing")})
+	if len(got) != 1 || got[0] != dir {
+		t.Fatalf("droppedDirectories = %v, want [%s]", got, dir)
+	}
+}
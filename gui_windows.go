@@ -0,0 +1,177 @@
+//go:build windows
+
+package main
+
+//go:generate go run github.com/akavel/rsrc@v0.10.2 -manifest heictojpeg.manifest -arch amd64 -o rsrc_windows_amd64.syso
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"heictojpeg/convert"
+
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+type guiRow struct {
+	file   string
+	status string
+	size   string
+}
+
+type guiModel struct {
+	walk.TableModelBase
+	rows []guiRow
+}
+
+func (m *guiModel) RowCount() int {
+	return len(m.rows)
+}
+
+func (m *guiModel) Value(row, col int) interface{} {
+	r := m.rows[row]
+	switch col {
+	case 0:
+		return r.file
+	case 1:
+		return r.status
+	default:
+		return r.size
+	}
+}
+
+// guiObserver forwards progress from the conversion goroutine to the UI thread.
+type guiObserver struct {
+	mw     *walk.MainWindow
+	model  *guiModel
+	status *walk.Label
+	dir    string
+	total  int
+	done   int
+}
+
+func (o *guiObserver) OnStart(total int) {
+	o.mw.Synchronize(func() {
+		o.total, o.done = total, 0
+		if total == convert.UnknownTotal {
+			o.status.SetText(fmt.Sprintf(tr("Converting files in %s..."), o.dir))
+			return
+		}
+		o.status.SetText(fmt.Sprintf(tr("Converting %d files in %s..."), total, o.dir))
+	})
+}
+
+func (o *guiObserver) OnFileDone(result convert.ConversionResult) {
+	o.mw.Synchronize(func() {
+		name, err := filepath.Rel(o.dir, result.Input)
+		if err != nil {
+			name = result.Input
+		}
+		row := guiRow{file: name, status: tr("Converted"), size: humanReadableFileSize(result.InputSize) + " > " + humanReadableFileSize(result.OutputSize)}
+		if result.Err != nil {
+			row.status = fmt.Sprintf(tr("Failed: %v"), result.Err)
+			row.size = humanReadableFileSize(result.InputSize)
+		}
+		o.model.rows = append(o.model.rows, row)
+		o.model.PublishRowsInserted(len(o.model.rows)-1, len(o.model.rows)-1)
+
+		o.done++
+		if o.total == convert.UnknownTotal {
+			o.status.SetText(fmt.Sprintf(tr("%d files done"), o.done))
+			return
+		}
+		o.status.SetText(fmt.Sprintf(tr("%d of %d files done"), o.done, o.total))
+	})
+}
+
+func (o *guiObserver) OnFinish(summary convert.Summary) {
+	o.mw.Synchronize(func() {
+		o.status.SetText(fmt.Sprintf(tr("Done: converted %d of %d files in %v (%d failed). The JPEGs are in %s."),
+			summary.Files-summary.Failed, summary.Files, summary.Duration.Round(time.Second), summary.Failed, filepath.Join(o.dir, "jpegs")))
+	})
+}
+
+func runGUI(dir string) error {
+	var (
+		mw          *walk.MainWindow
+		status      *walk.Label
+		qualityEdit *walk.NumberEdit
+		recurse     *walk.CheckBox
+		chooseBtn   *walk.PushButton
+		model       = &guiModel{}
+		busy        bool
+	)
+
+	start := func(paths []string) {
+		if busy {
+			return
+		}
+		dirs := droppedDirectories(paths)
+		if len(dirs) == 0 {
+			return
+		}
+
+		busy = true
+		chooseBtn.SetEnabled(false)
+		*quality = int(qualityEdit.Value())
+		*recursive = recurse.Checked()
+
+		go func() {
+			for _, d := range dirs {
+				observer := &guiObserver{mw: mw, model: model, status: status, dir: d}
+				if err := convertDirectory(context.Background(), d, nil, observer); err != nil {
+					mw.Synchronize(func() {
+						walk.MsgBox(mw, tr("HEIC to JPEG"), err.Error(), walk.MsgBoxIconError)
+					})
+				}
+			}
+			mw.Synchronize(func() {
+				busy = false
+				chooseBtn.SetEnabled(true)
+			})
+		}()
+	}
+
+	_, err := MainWindow{
+		AssignTo:    &mw,
+		Title:       tr("HEIC to JPEG"),
+		MinSize:     Size{Width: 640, Height: 420},
+		Layout:      VBox{},
+		OnDropFiles: start,
+		Children: []Widget{
+			Label{Text: tr("Drag a folder with .heic photos onto this window, or choose one:")},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					PushButton{
+						AssignTo: &chooseBtn,
+						Text:     tr("Choose folder..."),
+						OnClicked: func() {
+							dlg := &walk.FileDialog{Title: tr("Choose a folder with .heic photos"), InitialDirPath: dir}
+							if ok, _ := dlg.ShowBrowseFolder(mw); ok {
+								start([]string{dlg.FilePath})
+							}
+						},
+					},
+					Label{Text: tr("JPEG quality:")},
+					NumberEdit{AssignTo: &qualityEdit, Value: float64(*quality), MinValue: 1, MaxValue: 100, MaxSize: Size{Width: 60}},
+					CheckBox{AssignTo: &recurse, Text: tr("Include subfolders"), Checked: *recursive},
+					HSpacer{},
+				},
+			},
+			TableView{
+				Model: model,
+				Columns: []TableViewColumn{
+					{Title: tr("File"), Width: 260},
+					{Title: tr("Status"), Width: 220},
+					{Title: tr("Size"), Width: 120},
+				},
+			},
+			Label{AssignTo: &status, Text: tr("Waiting for a folder...")},
+		},
+	}.Run()
+	return err
+}
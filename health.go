@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"heictojpeg/convert"
+
+	"github.com/adrium/goheif/libde265"
+)
+
+var (
+	maxInflight    = flag.Int("max-inflight", 0, "conversions heictojpeg serve runs at once before answering 429 (0 is unlimited)")
+	selfTestSample = flag.String("self-test", "", "HEIC file heictojpeg serve decodes at startup before /readyz reports ready")
+)
+
+// readiness is what /readyz reports: not ready until the self-test has
+// passed.
+type readiness struct {
+	mu    sync.Mutex
+	ready bool
+	err   error
+}
+
+func (r *readiness) set(ready bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready, r.err = ready, err
+}
+
+func (r *readiness) get() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready, r.err
+}
+
+// selfTest checks that the conversion works at all: that the HEVC decoder
+// loads and a JPEG can be encoded, and with -self-test that the sample
+// decodes.
+func selfTest(ctx context.Context) error {
+	dec, err := libde265.NewDecoder()
+	if err != nil {
+		return fmt.Errorf("HEVC decoder: %v", err)
+	}
+	dec.Free()
+
+	var img image.Image
+	if *selfTestSample != "" {
+		f, err := os.Open(longPath(*selfTestSample))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		d, err := convert.Decode(ctx, f, globalSettings().options())
+		if err != nil {
+			return fmt.Errorf("%s: %v", *selfTestSample, err)
+		}
+		img = d.Image
+	} else {
+		gray := image.NewGray(image.Rect(0, 0, 64, 64))
+		for i := range gray.Pix {
+			gray.Pix[i] = uint8(i)
+		}
+		img = gray
+	}
+	return convert.EncodeJPEG(io.Discard, img, nil, nil, globalSettings().options())
+}
+
+func (s *server) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	ready, err := s.ready.get()
+	switch {
+	case ready:
+		fmt.Fprintln(w, "ok")
+	case err != nil:
+		http.Error(w, "self-test failed: "+err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}
+}
+
+// errSaturated is the 429 of a server already converting -max-inflight
+// files.
+var errSaturated = errors.New("too many conversions in progress")
+
+// acquire takes one of the -max-inflight slots, or reports that there is
+// none free. release gives it back.
+func (s *server) acquire() bool {
+	if s.inflight == nil {
+		return true
+	}
+	select {
+	case s.inflight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *server) release() {
+	if s.inflight != nil {
+		<-s.inflight
+	}
+}
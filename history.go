@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var useHistory = flag.Bool("history", false, "record conversions in the history database and skip files already converted with the same settings, even after renames or moves")
+
+const historyEnv = "HEICTOJPEG_HISTORY"
+
+// historyEntry records one successful conversion. Hash is the SHA-256 of the
+// HEIC file, so a renamed or moved photo is still recognised.
+type historyEntry struct {
+	Source     string    `json:"source"`
+	Hash       string    `json:"hash"`
+	Output     string    `json:"output"`
+	Converted  time.Time `json:"converted"`
+	Quality    int       `json:"quality"`
+	Settings   string    `json:"settings,omitempty"` // settings.key() of the conversion
+	InputSize  int64     `json:"input_size"`
+	OutputSize int64     `json:"output_size"`
+}
+
+// history is the conversion database: a JSON file in the user config
+// directory, loaded at the start of a run and written back at the end.
+type history struct {
+	mu      sync.Mutex
+	path    string
+	entries []historyEntry
+	byHash  map[string]int // index of the latest entry for each hash
+	dirty   bool
+}
+
+type historyKey struct{}
+
+func init() {
+	subcommands["history"] = historyCommand
+	subcommands["stats"] = statsCommand
+}
+
+// historyPath is $HEICTOJPEG_HISTORY, or history.json in the user config directory.
+func historyPath() (string, error) {
+	if path := os.Getenv(historyEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "heictojpeg", "history.json"), nil
+}
+
+func openHistory(path string) (*history, error) {
+	h := &history{path: path, byHash: make(map[string]int)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i, e := range h.entries {
+		h.byHash[e.Hash] = i
+	}
+	return h, nil
+}
+
+func (h *history) save() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+func (h *history) record(e historyEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, e)
+	h.byHash[e.Hash] = len(h.entries) - 1
+	h.dirty = true
+}
+
+func (h *history) lookup(hash string) (historyEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i, ok := h.byHash[hash]
+	if !ok {
+		return historyEntry{}, false
+	}
+	return h.entries[i], true
+}
+
+// reuse reports whether the conversion of a file with this hash can be
+// skipped: the output already exists, or an earlier output of the same photo
+// with the same settings is copied into place (the source was renamed or
+// moved).
+func (h *history) reuse(hash, source, output string, s settings) (bool, error) {
+	e, ok := h.lookup(hash)
+	if !ok || e.Settings != s.key() {
+		return false, nil
+	}
+	if _, err := os.Stat(longPath(output)); err == nil {
+		return true, nil
+	}
+	if e.Output == output {
+		return false, nil
+	}
+	if err := copyFile(e.Output, output); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	e.Source, e.Output, e.Converted = source, output, time.Now()
+	h.record(e)
+	return true, nil
+}
+
+func withHistory(ctx context.Context, h *history) context.Context {
+	return context.WithValue(ctx, historyKey{}, h)
+}
+
+func historyFrom(ctx context.Context) *history {
+	h, _ := ctx.Value(historyKey{}).(*history)
+	return h
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sum := sha256.New()
+	if _, err := pooledCopy(sum, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	if err := guardWrite(dst); err != nil {
+		return err
+	}
+	in, err := os.Open(longPath(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(longPath(dst))
+	if err != nil {
+		return err
+	}
+	if _, err := pooledCopy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func loadHistory() (*history, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	return openHistory(path)
+}
+
+// historyCommand lists recorded conversions, newest first, optionally
+// only those whose source path contains the given text.
+func historyCommand(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := fs.Int("n", 20, "number of entries to show (0 for all)")
+	fs.Parse(args)
+
+	h, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	shown := 0
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		e := h.entries[i]
+		if fs.NArg() > 0 && !strings.Contains(e.Source, fs.Arg(0)) {
+			continue
+		}
+		if *limit > 0 && shown == *limit {
+			break
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\tq%d\n", e.Converted.Local().Format("2006-01-02 15:04"), e.Source, e.Output, e.Quality)
+		shown++
+	}
+	return w.Flush()
+}
+
+type historyStats struct {
+	Conversions int
+	Photos      int
+	InputSize   int64
+	OutputSize  int64
+	First, Last time.Time
+	Qualities   map[int]int
+}
+
+func (h *history) stats() historyStats {
+	s := historyStats{Conversions: len(h.entries), Photos: len(h.byHash), Qualities: make(map[int]int)}
+	for _, e := range h.entries {
+		s.InputSize += e.InputSize
+		s.OutputSize += e.OutputSize
+		s.Qualities[e.Quality]++
+		if s.First.IsZero() || e.Converted.Before(s.First) {
+			s.First = e.Converted
+		}
+		if e.Converted.After(s.Last) {
+			s.Last = e.Converted
+		}
+	}
+	return s
+}
+
+func statsCommand(args []string) error {
+	h, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	s := h.stats()
+	fmt.Printf(tr("History: %s\n"), h.path)
+	fmt.Printf(tr("Conversions: %d (%d distinct photos)\n"), s.Conversions, s.Photos)
+	if s.Conversions == 0 {
+		return nil
+	}
+	fmt.Printf(tr("Between %s and %s\n"), s.First.Local().Format("2006-01-02"), s.Last.Local().Format("2006-01-02"))
+	fmt.Printf(tr("HEIC converted: %s, JPEG written: %s\n"), humanReadableFileSize(s.InputSize), humanReadableFileSize(s.OutputSize))
+	qualities := make([]int, 0, len(s.Qualities))
+	for q := range s.Qualities {
+		qualities = append(qualities, q)
+	}
+	sort.Ints(qualities)
+	for _, q := range qualities {
+		fmt.Printf(tr("Quality %d: %d conversions\n"), q, s.Qualities[q])
+	}
+	return nil
+}
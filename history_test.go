@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryReuseAfterRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.json")
+	h, err := openHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldOutput := filepath.Join(dir, "jpegs", "IMG_0001.jpg")
+	os.MkdirAll(filepath.Dir(oldOutput), 0755)
+	os.WriteFile(oldOutput, []byte("jpeg"), 0644)
+	h.record(historyEntry{Source: filepath.Join(dir, "IMG_0001.heic"), Hash: "abc", Output: oldOutput, Converted: time.Now(), Quality: *quality, Settings: globalSettings().key()})
+	if err := h.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err = openHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newOutput := filepath.Join(dir, "jpegs", "renamed.jpg")
+	reused, err := h.reuse("abc", filepath.Join(dir, "renamed.heic"), newOutput, globalSettings())
+	if err != nil || !reused {
+		t.Fatalf("reuse = %v, %v; want true", reused, err)
+	}
+	if data, err := os.ReadFile(newOutput); err != nil || string(data) != "jpeg" {
+		t.Errorf("output not copied: %q, %v", data, err)
+	}
+	if e, _ := h.lookup("abc"); e.Output != newOutput {
+		t.Errorf("latest output = %s, want %s", e.Output, newOutput)
+	}
+
+	if reused, _ := h.reuse("other", "x.heic", filepath.Join(dir, "x.jpg"), globalSettings()); reused {
+		t.Error("unknown hash was reused")
+	}
+	lower := globalSettings()
+	lower.Quality--
+	if reused, _ := h.reuse("abc", "y.heic", filepath.Join(dir, "y.jpg"), lower); reused {
+		t.Error("output with a different quality was reused")
+	}
+	web := globalSettings()
+	web.MaxSize = 2048
+	if reused, _ := h.reuse("abc", "z.heic", filepath.Join(dir, "z.jpg"), web); reused {
+		t.Error("full-size output was reused for -max-size")
+	}
+}
+
+func TestHistoryStats(t *testing.T) {
+	h, _ := openHistory(filepath.Join(t.TempDir(), "history.json"))
+	day := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	h.record(historyEntry{Hash: "a", Quality: 75, InputSize: 100, OutputSize: 50, Converted: day})
+	h.record(historyEntry{Hash: "a", Quality: 90, InputSize: 100, OutputSize: 80, Converted: day.AddDate(0, 0, 3)})
+	h.record(historyEntry{Hash: "b", Quality: 75, InputSize: 10, OutputSize: 5, Converted: day.AddDate(0, 0, 1)})
+
+	s := h.stats()
+	if s.Conversions != 3 || s.Photos != 2 || s.InputSize != 210 || s.OutputSize != 135 {
+		t.Errorf("stats = %+v", s)
+	}
+	if !s.First.Equal(day) || !s.Last.Equal(day.AddDate(0, 0, 3)) || s.Qualities[75] != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var (
+	preCmd  = flag.String("pre-cmd", "", "shell command run before each conversion; {input}, {output}, {name} and {dir} are replaced with the file's paths")
+	postCmd = flag.String("post-cmd", "", "shell command run after each successful conversion, with the same variables as -pre-cmd")
+)
+
+// expandHookCommand fills in the template variables, quoting each value
+// for the shell so that file names with spaces or quotes stay one argument.
+func expandHookCommand(command, input, output string) string {
+	name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	return strings.NewReplacer(
+		"{input}", shellQuote(input),
+		"{output}", shellQuote(output),
+		"{name}", shellQuote(name),
+		"{dir}", shellQuote(filepath.Dir(input)),
+	).Replace(command)
+}
+
+// The variables hooks are given the paths of the file in, besides the
+// {input} and {output} of the command.
+const (
+	hookInputEnv  = "HEICTOJPEG_INPUT"
+	hookOutputEnv = "HEICTOJPEG_OUTPUT"
+)
+
+func runHook(ctx context.Context, command, input, output string) error {
+	if command == "" {
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	expanded := expandHookCommand(command, input, output)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", expanded)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", expanded)
+	}
+	cmd.Env = append(os.Environ(), hookInputEnv+"="+input, hookOutputEnv+"="+output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// hookError is a -post-cmd that failed after the file was converted.
+type hookError struct {
+	flag string
+	err  error
+}
+
+func (e *hookError) Error() string { return e.flag + " failed: " + e.err.Error() }
+func (e *hookError) Unwrap() error { return e.err }
+
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Testing expandHookCommand with awkward file names
+func TestExpandHookCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("quoting differs for cmd.exe")
+	}
+	got := expandHookCommand("exiftool {input} -o {output} # {name}", "/photos/it's here.heic", "/photos/jpegs/it's here.jpg")
+	want := `exiftool '/photos/it'\''s here.heic' -o '/photos/jpegs/it'\''s here.jpg' # 'it'\''s here'`
+	if got != want {
+		t.Fatalf("expandHookCommand = %q, want %q", got, want)
+	}
+}
+
+// Testing runHook function
+func TestRunHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "done.txt")
+
+	if err := runHook(context.Background(), "echo {name} > "+shellQuote(marker), "/photos/IMG_0001.HEIC", marker); err != nil {
+		t.Fatalf("Hook failed: %v", err)
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil || string(data) != "IMG_0001\n" {
+		t.Fatalf("Hook did not run as expected: %q, %v", data, err)
+	}
+
+	if err := runHook(context.Background(), "exit 3", "in", "out"); err == nil {
+		t.Fatalf("Failing hook should return an error")
+	}
+}
+
+// Testing that a failing -pre-cmd skips the file
+func TestFailingPreCmdSkips(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands use sh")
+	}
+	defer func(v string) { *preCmd = v }(*preCmd)
+	*preCmd = "exit 3"
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "IMG_0001.HEIC"), []byte("mock content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jpegDir := filepath.Join(dir, "jpegs")
+	_, err := convertFile(context.Background(), dir, "IMG_0001.HEIC", jpegDir)
+	if reason, ok := skippedBy(err); !ok || !strings.HasPrefix(reason, "pre-cmd failed") {
+		t.Fatalf("convertFile = %v, want the file skipped", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"heictojpeg/convert"
+)
+
+// hotFolder is a folder converted on every run into its own output folder
+// with its own settings, e.g. {"source": "~/Sync/Anna", "out":
+// "/srv/photos/anna", "preset": "web"} for each family member's synced
+// phone folder.
+type hotFolder struct {
+	Source  string
+	Out     string // the source's jpegs subfolder when empty
+	Options map[string]interface{}
+}
+
+// hotFolders come from "hot-folders" in the config file. Runs without
+// files, folders or -source convert them instead of the current folder.
+var hotFolders []hotFolder
+
+// configHotFolders takes the "hot-folders" list out of the config file.
+func configHotFolders(config map[string]interface{}) ([]hotFolder, error) {
+	raw, ok := config["hot-folders"]
+	if !ok {
+		return nil, nil
+	}
+	delete(config, "hot-folders")
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hot-folders: must be a list of folders")
+	}
+
+	var folders []hotFolder
+	seen := make(map[string]bool)
+	for i, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("hot-folders: folder %d must be an object", i+1)
+		}
+		folder := hotFolder{Options: map[string]interface{}{}}
+		for name, value := range fields {
+			switch name {
+			case "source", "out":
+				path, ok := value.(string)
+				if !ok || path == "" {
+					return nil, fmt.Errorf("hot-folders: folder %d: %s must be a folder", i+1, name)
+				}
+				path = resolvePath(expandHome(path))
+				if name == "source" {
+					folder.Source = path
+				} else {
+					folder.Out = path
+				}
+			default:
+				folder.Options[name] = value
+			}
+		}
+		if folder.Source == "" {
+			return nil, fmt.Errorf("hot-folders: folder %d has no source", i+1)
+		}
+		if seen[folder.Source] {
+			return nil, fmt.Errorf("hot-folders: %s is listed twice", folder.Source)
+		}
+		seen[folder.Source] = true
+		// Check the options now rather than at the first run.
+		scratch := globalSettings()
+		if err := scratch.apply(folder.Options); err != nil {
+			return nil, fmt.Errorf("hot-folders: %s: %v", folder.Source, err)
+		}
+		folders = append(folders, folder)
+	}
+	return folders, nil
+}
+
+// convertHotFolders converts each of folders with its settings on top of
+// the global ones. One that fails, e.g. a sync folder that isn't mounted,
+// doesn't keep the others from being converted.
+func convertHotFolders(ctx context.Context, folders []hotFolder, observers ...convert.Observer) error {
+	for _, folder := range folders {
+		if ctx.Err() != nil {
+			return nil
+		}
+		folderCtx, err := folder.context(ctx)
+		if err == nil {
+			err = convertDirectory(folderCtx, folder.Source, nil, observers...)
+		}
+		if err != nil {
+			fmt.Printf(tr("Failed to convert %s: %v\n"), folder.Source, err)
+		}
+	}
+	return nil
+}
+
+// watchHotFolders watches each of folders for -watch at the same time, each
+// converted with its settings into its output folder, until ctx is
+// cancelled. Like convertHotFolders, one that fails doesn't stop the others.
+func watchHotFolders(ctx context.Context, folders []hotFolder, observers ...convert.Observer) error {
+	var wg sync.WaitGroup
+	for _, folder := range folders {
+		folder := folder
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			folderCtx, err := folder.context(ctx)
+			if err == nil {
+				err = runWatch(folderCtx, folder.Source, func(ctx context.Context, observers ...convert.Observer) error {
+					return convertDirectory(ctx, folder.Source, nil, observers...)
+				}, observers...)
+			}
+			if err != nil {
+				fmt.Printf(tr("Failed to convert %s: %v\n"), folder.Source, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// context is ctx with the settings and output folder of f, which it
+// creates.
+func (f hotFolder) context(ctx context.Context) (context.Context, error) {
+	s := globalSettings()
+	if err := s.apply(f.Options); err != nil {
+		return nil, err
+	}
+	out := f.Out
+	if out == "" {
+		out = filepath.Join(f.Source, "jpegs")
+	}
+	infof(tr("Converting %s into %s\n"), f.Source, out)
+	if err := os.MkdirAll(longPath(out), 0755); err != nil {
+		return nil, err
+	}
+	return withOutputDir(withSettings(ctx, s), out), nil
+}
+
+type outputDirKey struct{}
+
+// withOutputDir sends the JPEGs converted under ctx to dir rather than to
+// -out or the jpegs subfolder.
+func withOutputDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, outputDirKey{}, dir)
+}
+
+func outputDirFrom(ctx context.Context) string {
+	dir, _ := ctx.Value(outputDirKey{}).(string)
+	return dir
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/internal/heicsample"
+)
+
+func TestConfigHotFolders(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	config := map[string]interface{}{
+		"quality": 80.0,
+		"hot-folders": []interface{}{
+			map[string]interface{}{"source": "~/Sync/Anna", "out": "/srv/photos/anna", "preset": "web"},
+			map[string]interface{}{"source": "/srv/sync/ben", "quality": 95.0},
+		},
+	}
+	folders, err := configHotFolders(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, left := config["hot-folders"]; left || len(config) != 1 {
+		t.Errorf("config after = %v", config)
+	}
+	if len(folders) != 2 || folders[0].Source != resolvePath(filepath.Join(home, "Sync", "Anna")) || folders[0].Out != resolvePath("/srv/photos/anna") || folders[0].Options["preset"] != "web" || folders[1].Out != "" || folders[1].Options["quality"] != 95.0 {
+		t.Errorf("folders = %+v", folders)
+	}
+
+	for _, bad := range []interface{}{
+		"~/Sync",
+		[]interface{}{"~/Sync"},
+		[]interface{}{map[string]interface{}{"out": "/srv/photos"}},
+		[]interface{}{map[string]interface{}{"source": 3.0}},
+		[]interface{}{map[string]interface{}{"source": "/a", "quality": 500.0}},
+		[]interface{}{map[string]interface{}{"source": "/a", "workers": 2.0}},
+		[]interface{}{map[string]interface{}{"source": "/a"}, map[string]interface{}{"source": "/a/"}},
+	} {
+		if _, err := configHotFolders(map[string]interface{}{"hot-folders": bad}); err == nil {
+			t.Errorf("hot-folders %v accepted", bad)
+		}
+	}
+}
+
+func TestConvertHotFolders(t *testing.T) {
+	anna, ben, annaOut := t.TempDir(), t.TempDir(), t.TempDir()
+	for _, dir := range []string{anna, ben} {
+		if err := os.WriteFile(filepath.Join(dir, "IMG_0001.HEIC"), exifSample(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	folders := []hotFolder{
+		{Source: anna, Out: annaOut, Options: map[string]interface{}{"max-size": 16.0}},
+		{Source: filepath.Join(t.TempDir(), "unmounted")},
+		{Source: ben, Options: map[string]interface{}{}},
+	}
+	if err := convertHotFolders(context.Background(), folders); err != nil {
+		t.Fatal(err)
+	}
+	for output, width := range map[string]int{
+		filepath.Join(annaOut, "IMG_0001.jpg"):      16,
+		filepath.Join(ben, "jpegs", "IMG_0001.jpg"): heicsample.Size,
+	} {
+		f, err := os.Open(output)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		config, err := jpeg.DecodeConfig(f)
+		f.Close()
+		if err != nil || config.Width != width {
+			t.Errorf("%s: %+v, %v; want %d pixels wide", output, config, err, width)
+		}
+	}
+	if fileExists(filepath.Join(anna, "jpegs")) {
+		t.Error("converted into the source's jpegs folder despite its out")
+	}
+	if logs, err := os.ReadFile(filepath.Join(annaOut, logFileName)); err != nil || !strings.Contains(string(logs), "IMG_0001") {
+		t.Errorf("logs.txt %q, %v", logs, err)
+	}
+}
+
+func TestWatchHotFolders(t *testing.T) {
+	defer func(strategy string, interval time.Duration) {
+		*watchStrategy, *watchInterval = strategy, interval
+	}(*watchStrategy, *watchInterval)
+	*watchStrategy, *watchInterval = "poll", 20*time.Millisecond
+	anna, ben, annaOut := t.TempDir(), t.TempDir(), t.TempDir()
+	folders := []hotFolder{
+		{Source: anna, Out: annaOut, Options: map[string]interface{}{"max-size": 16.0}},
+		{Source: filepath.Join(t.TempDir(), "unmounted")},
+		{Source: ben, Options: map[string]interface{}{}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watchHotFolders(ctx, folders) }()
+
+	for _, dir := range []string{anna, ben} {
+		if err := os.WriteFile(filepath.Join(dir, "IMG_0001.HEIC"), exifSample(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs := map[string]int{
+		filepath.Join(annaOut, "IMG_0001.jpg"):      16,
+		filepath.Join(ben, "jpegs", "IMG_0001.jpg"): heicsample.Size,
+	}
+	for output := range outputs {
+		for deadline := time.Now().Add(5 * time.Second); !fileExists(output) && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for output, width := range outputs {
+		f, err := os.Open(output)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		config, err := jpeg.DecodeConfig(f)
+		f.Close()
+		if err != nil || config.Width != width {
+			t.Errorf("%s: %+v, %v; want %d pixels wide", output, config, err, width)
+		}
+	}
+}
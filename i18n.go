@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+var langFlag = flag.String("lang", "", "language for console output and logs.txt (en, es, fr, de); defaults to the OS locale")
+
+// messages holds the active translations, keyed by the English format string.
+var messages map[string]string
+
+// tr returns the translation of an English message or format string,
+// falling back to the English text.
+func tr(s string) string {
+	if t, ok := messages[s]; ok {
+		return t
+	}
+	return s
+}
+
+func setLanguage(lang string) {
+	if lang == "" {
+		lang = systemLanguage()
+	}
+	messages = catalogs[normalizeLanguage(lang)]
+}
+
+// normalizeLanguage turns locale names like "es_ES.UTF-8" or "es-MX" into "es".
+func normalizeLanguage(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// localeFromEnv reads the POSIX locale variables in order of precedence.
+func localeFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return v
+		}
+	}
+	return ""
+}
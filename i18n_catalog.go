@@ -0,0 +1,957 @@
+package main
+
+// catalogs maps a language code to translations of the English messages
+// passed to tr. Keep the format verbs in the same order as the English text.
+var catalogs = map[string]map[string]string{
+	"es": {
+		"Starting the program...":                           "Iniciando el programa...",
+		"Failed to get current directory: %v":               "No se pudo obtener la carpeta actual: %v",
+		"Failed to start the GUI: %v":                       "No se pudo abrir la ventana: %v",
+		"Program completed!":                                "¡Programa completado!",
+		"Interrupted, the remaining files were skipped.":    "Interrumpido, se omitieron los archivos restantes.",
+		"Fetching the current directory...":                 "Obteniendo la carpeta actual...",
+		"Failed to create directory: %v":                    "No se pudo crear la carpeta: %v",
+		"Failed to create log file: %v":                     "No se pudo crear el archivo de registro: %v",
+		"Saving logs to logs.txt...":                        "Guardando el registro en logs.txt...",
+		"Processing files...":                               "Procesando archivos...",
+		"Processing file: %s\n":                             "Procesando archivo: %s\n",
+		"Failed to convert %s: %v\n":                        "No se pudo convertir %s: %v\n",
+		"%s %s > Failed > error details: %s":                "%s %s > Error > detalles: %s",
+		"%s %s > Converted > jpegs/%s %s":                   "%s %s > Convertido > jpegs/%s %s",
+		"\n%v Files":                                        "\n%v archivos",
+		"Total Time Taken==%v":                              "Tiempo total==%v",
+		"Average Time Per File==%v":                         "Tiempo promedio por archivo==%v",
+		"Total HEIC File Size==%s":                          "Tamaño total de los HEIC==%s",
+		"Total JPEG Folder Size==%s":                        "Tamaño total de la carpeta JPEG==%s",
+		"Invalid -symlink-names %q: must be link or target": "-symlink-names %q no es válido: debe ser link o target",
+		"Invalid -quality %d: must be between 1 and 100":    "-quality %d no es válido: debe estar entre 1 y 100",
+		"another heictojpeg run (pid %d, started %s) is already converting into %s; wait for it to finish or use -wait": "otra ejecución de heictojpeg (pid %d, iniciada %s) ya está convirtiendo en %s; espere a que termine o use -wait",
+		"another heictojpeg run is already converting into %s; wait for it to finish or use -wait":                      "otra ejecución de heictojpeg ya está convirtiendo en %s; espere a que termine o use -wait",
+		"Waiting for the other run to finish...":                              "Esperando a que termine la otra ejecución...",
+		"Failed to remove lock file: %v\n":                                    "No se pudo eliminar el archivo de bloqueo: %v\n",
+		"Skipping broken symlink: %s\n":                                       "Omitiendo enlace simbólico roto: %s\n",
+		"Skipping symlinked directory: %s\n":                                  "Omitiendo carpeta enlazada: %s\n",
+		"Failed to read directory %s: %v\n":                                   "No se pudo leer la carpeta %s: %v\n",
+		"Skipping symlink loop: %s\n":                                         "Omitiendo bucle de enlaces: %s\n",
+		"Skipping mount point: %s\n":                                          "Omitiendo punto de montaje: %s\n",
+		"Failed to show desktop notification: %v\n":                           "No se pudo mostrar la notificación: %v\n",
+		"Converted %d of %d files in %v":                                      "Se convirtieron %d de %d archivos en %v",
+		"HEIC conversion finished with errors":                                "Conversión HEIC terminada con errores",
+		"%s, %d failed. See jpegs/logs.txt.":                                  "%s, %d fallaron. Consulte jpegs/logs.txt.",
+		"HEIC conversion finished":                                            "Conversión HEIC terminada",
+		"Failed to send webhook report: %v\n":                                 "No se pudo enviar el informe al webhook: %v\n",
+		"Failed to mail report: %v\n":                                         "No se pudo enviar el informe por correo: %v\n",
+		"Added %q to the context menu for .heic files and folders.\n":         "Se añadió %q al menú contextual de archivos .heic y carpetas.\n",
+		"Removed %q from the context menu.\n":                                 "Se quitó %q del menú contextual.\n",
+		"Paused: running files finish, no new ones start. Press p to resume.": "En pausa: los archivos en curso terminan y no empiezan nuevos. Pulse p para continuar.",
+		"Resumed.":                              "Continuando.",
+		"Nothing to skip.":                      "No hay nada que omitir.",
+		"Aborting, the log is still written...": "Cancelando, el registro se guarda igualmente...",
+		"Worker %c is idle.":                    "El proceso %c está libre.",
+		"running":                               "en curso",
+		"aborting":                              "cancelando",
+		"PAUSED":                                "EN PAUSA",
+		"%d/%d files done, %d failed, %v elapsed": "%d/%d archivos listos, %d con error, %v transcurridos",
+		"Workers":      "Procesos",
+		"(idle)":       "(libre)",
+		"Recent files": "Archivos recientes",
+		"FAILED: ":     "ERROR: ",
+		"[p] pause/resume  [s] skip slowest  [1-9] skip worker's file  [q] abort": "[p] pausa/continuar  [s] omitir el más lento  [1-9] omitir archivo del proceso  [q] cancelar",
+		"%d of %d files converted, %d failed, in %v.\n":                           "%d de %d archivos convertidos, %d con error, en %v.\n",
+		"Invalid -output %q: must be text or ndjson":                              "-output %q no es válido: debe ser text o ndjson",
+		"Already converted: %s\n":                                                 "Ya convertido: %s\n",
+		"Failed to open the history: %v":                                          "No se pudo abrir el historial: %v",
+		"Failed to save the history: %v\n":                                        "No se pudo guardar el historial: %v\n",
+		"History: %s\n":                                                           "Historial: %s\n",
+		"Conversions: %d (%d distinct photos)\n":                                  "Conversiones: %d (%d fotos distintas)\n",
+		"Between %s and %s\n":                                                     "Entre %s y %s\n",
+		"HEIC converted: %s, JPEG written: %s\n":                                  "HEIC convertidos: %s, JPEG escritos: %s\n",
+		"Quality %d: %d conversions\n":                                            "Calidad %d: %d conversiones\n",
+		"Sampling %d of %d files at quality %d...\n":                              "Muestreando %d de %d archivos con calidad %d...\n",
+		"Estimated JPEG size: %s for %s of HEIC (%.0f%%)\n":                       "Tamaño JPEG estimado: %s para %s de HEIC (%.0f%%)\n",
+		"Estimated time: %v with %d workers\n":                                    "Tiempo estimado: %v con %d procesos\n",
+		"Failed to read the config file: %v":                                      "No se pudo leer el archivo de configuración: %v",
+		"Benchmarking with %s...\n":                                               "Midiendo rendimiento con %s...\n",
+		"workers\tquality\tfiles/s\tJPEG size\t":                                  "procesos\tcalidad\tarchivos/s\ttamaño JPEG\t",
+		"Fastest: %d workers (%.2f files/s)\n":                                    "Más rápido: %d procesos (%.2f archivos/s)\n",
+		"Saved workers=%d to %s\n":                                                "Guardado workers=%d en %s\n",
+		"Allocated==%s in %d allocations":                                         "Memoria asignada==%s en %d asignaciones",
+		"Garbage Collections==%d (%v paused)":                                     "Recolecciones de basura==%d (%v en pausa)",
+		"Heap Reserved==%s":                                                       "Heap reservado==%s",
+		"Invalid -order %q: must be name, size-asc, size-desc or mtime":           "-order %q no es válido: debe ser name, size-asc, size-desc o mtime",
+		"Paused (SIGUSR1); running files finish, send SIGUSR2 to resume.":         "En pausa (SIGUSR1); los archivos en curso terminan, envíe SIGUSR2 para continuar.",
+		"Invalid -throttle %q: %v":                                                "-throttle %q no es válido: %v",
+		"on battery power and under thermal pressure":                             "con batería y bajo presión térmica",
+		"under thermal pressure":                                                  "bajo presión térmica",
+		"on battery power":                                                        "con batería",
+		"on mains power":                                                          "con corriente",
+		"Running %s: using %d of %d workers.\n":                                   "Funcionando %s: se usan %d de %d procesos.\n",
+		"Failed to quarantine %s: %v\n":                                           "No se pudo poner en cuarentena %s: %v\n",
+		"Invalid -quarantine %q: must be copy or move":                            "-quarantine %q no es válido: debe ser copy o move",
+		"%d files failed. Write %s with the first %d KB of each, the errors and system info (OS, CPU, settings; no image content beyond the headers)? [y/N] ": "%d archivos fallaron. ¿Escribir %s con los primeros %d KB de cada uno, los errores y datos del sistema (SO, CPU, opciones; sin contenido de imagen más allá de las cabeceras)? [s/N] ",
+		"No bundle written.":                       "No se escribió ningún paquete.",
+		"Wrote %s; attach it to a GitHub issue.\n": "Se escribió %s; adjúntelo a un issue de GitHub.\n",
+		"Failed to write the failure bundle: %v\n": "No se pudo escribir el paquete de fallos: %v\n",
+		"Converted %s with a warning: %v\n":        "Se convirtió %s con una advertencia: %v\n",
+		" > Warning: %s":                           " > Advertencia: %s",
+		"%s (%dx%d) needs %s to decode, over -max-memory; decoding it in bands.\n": "%s (%dx%d) necesita %s para decodificarse, más que -max-memory; se decodifica por franjas.\n",
+		"Warning: %s (%dx%d) needs %s to decode, over -max-memory.\n":              "Advertencia: %s (%dx%d) necesita %s para decodificarse, más que -max-memory.\n",
+		"Invalid -max-memory %q: %v":                                               "-max-memory %q no es válido: %v",
+		"Failed to split the output: %v\n":                                         "No se pudo dividir la salida: %v\n",
+		"Invalid -split-output %q: %v":                                             "-split-output %q no es válido: %v",
+		"Invalid -sanitize %q: must be fat32 or exfat":                             "-sanitize %q no es válido: debe ser fat32 o exfat",
+		"%d files done (still scanning), %d failed, %v elapsed":                    "%d archivos listos (aún buscando), %d con error, %v transcurridos",
+		"Invalid -sort %q: must be path, size, duration or status":                 "-sort %q no es válido: debe ser path, size, duration o status",
+		"Failed to rotate the previous logs: %v\n":                                 "No se pudieron rotar los registros anteriores: %v\n",
+		"Failed to update history.csv: %v\n":                                       "No se pudo actualizar history.csv: %v\n",
+		"Invalid -verbosity %q: must be quiet, normal, verbose or debug":           "-verbosity %q no es válido: debe ser quiet, normal, verbose o debug",
+		"Diagnostics for %s:\n":                                                    "Diagnóstico de %s:\n",
+		" > Took %v":                                                               " > Tardó %v",
+		"Invalid -metadata %q: must be keep or strip":                              "-metadata %q no es válido: debe ser keep o strip",
+		"Invalid -max-size %d: must be 0 or more pixels":                           "-max-size %d no es válido: debe ser 0 o más píxeles",
+		"Skipped %s: %s\n":                                                         "Omitido %s: %s\n",
+		"%s %s > Skipped > %s":                                                     "%s %s > Omitido > %s",
+		"Invalid -screenshots %q: must be jpeg, png or skip":                       "-screenshots %q no es válido: debe ser jpeg, png o skip",
+		"Invalid -target-ssim %v: must be above 0 and at most 1":                   "-target-ssim %v no es válido: debe ser mayor que 0 y como máximo 1",
+		"Failed to compare %s: %v\n":                                               "No se pudo comparar %s: %v\n",
+		"Failed to create the comparison folder: %v":                               "No se pudo crear la carpeta de comparación: %v",
+		"Failed to write compare.csv: %v\n":                                        "No se pudo escribir compare.csv: %v\n",
+		"Invalid -dedupe %q: must be skip or link":                                 "-dedupe %q no es válido: debe ser skip o link",
+		"Invalid -dedupe-distance %d: must be from 0 to 64":                        "-dedupe-distance %d no es válido: debe estar entre 0 y 64",
+		"Failed to index -dedupe-library: %v":                                      "No se pudo indexar -dedupe-library: %v",
+		"Indexed %d JPEGs in %s\n":                                                 "Se indexaron %d JPEG en %s\n",
+		"Skipping %s in the library: %v\n":                                         "Se omite %s de la biblioteca: %v\n",
+		"Failed to hash %s: %v\n":                                                  "No se pudo calcular el hash de %s: %v\n",
+		"Failed to write hashes.csv: %v\n":                                         "No se pudo escribir hashes.csv: %v\n",
+		"Invalid -crop: %v":                                                        "-crop no es válido: %v",
+		"Invalid -crop-focus %q: must be center or subject":                        "-crop-focus %q no es válido: debe ser center o subject",
+		" > EXIF orientation %d: may display rotated in strict viewers":            " > Orientación EXIF %d: puede mostrarse girada en visores estrictos",
+		"Failed to copy the sidecar metadata of %s: %v\n":                          "No se pudieron copiar los metadatos del archivo auxiliar de %s: %v\n",
+		"Converted %d more frames of %s\n":                                         "Se convirtieron %d fotogramas más de %s\n",
+		"-best-frame and -all-frames can't be used together":                       "-best-frame y -all-frames no se pueden usar juntos",
+		"not HEVC":                                                "no es HEVC",
+		"already transcoded":                                      "ya transcodificado",
+		"Transcoding video: %s\n":                                 "Transcodificando vídeo: %s\n",
+		"Failed to transcode %s: %v\n":                            "No se pudo transcodificar %s: %v\n",
+		"%s %s > Transcoded > jpegs/%s %s":                        "%s %s > Transcodificado > jpegs/%s %s",
+		"\nVideos:":                                               "\nVídeos:",
+		"%d videos transcoded, %d failed (%s to %s)":              "%d vídeos transcodificados, %d con errores (de %s a %s)",
+		"Invalid -video-crf %d: must be between 0 and 51":         "-video-crf %d no es válido: debe estar entre 0 y 51",
+		"-videos needs ffmpeg: %v":                                "-videos necesita ffmpeg: %v",
+		"Skipping %s: %v\n":                                       "Omitiendo %s: %v\n",
+		"No files to convert.":                                    "No hay archivos que convertir.",
+		"Failed to read the file list: %v":                        "No se pudo leer la lista de archivos: %v",
+		"Invalid -files %q: only - (standard input) is supported": "-files %q no válido: solo se admite - (entrada estándar)",
+		"-files - can't be combined with files or folders on the command line": "-files - no se puede combinar con archivos o carpetas en la línea de comandos",
+		"-pipes is not supported on Windows":                                   "-pipes no está disponible en Windows",
+		"-pipes needs -output ndjson to announce the pipes":                    "-pipes necesita -output ndjson para anunciar las tuberías",
+		"-pipes can't be used with -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes or -split-output, which need the JPEG files": "-pipes no se puede usar con -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes ni -split-output, que necesitan los archivos JPEG",
+		"Requeued %d unfinished jobs\n":                 "Se devolvieron %d trabajos sin terminar a la cola\n",
+		"Waiting for jobs on %s at %s\n":                "Esperando trabajos en %s de %s\n",
+		"Invalid job %q: %v\n":                          "Trabajo no válido %q: %v\n",
+		"Downloading %d files...\n":                     "Descargando %d archivos...\n",
+		"Failed to download %s: %v\n":                   "No se pudo descargar %s: %v\n",
+		"Failed to cache %s: %v\n":                      "No se pudo guardar %s en la caché: %v\n",
+		"Invalid -cache-size %q: %v":                    "-cache-size %q no válido: %v",
+		"Failed to open the cache: %v":                  "No se pudo abrir la caché: %v",
+		"Listening on %s\n":                             "Escuchando en %s\n",
+		"%s %s from %s: %d, %s in %s\n":                 "%s %s desde %s: %d, %s en %s\n",
+		"Self-test failed, /readyz stays unready: %v\n": "La autoprueba falló, /readyz seguirá sin estar listo: %v\n",
+		"heictojpeg %s is up to date\n":                 "heictojpeg %s está actualizado\n",
+		"heictojpeg %s is available (this is %s)\n":     "heictojpeg %s está disponible (la actual es %s)\n",
+		"Downloading heictojpeg %s...\n":                "Descargando heictojpeg %s...\n",
+		"Updated heictojpeg from %s to %s\n":            "heictojpeg actualizado de %s a %s\n",
+		"Kept %s: %v\n":                                 "Se conservó %s: %v\n",
+		"-pipes can't be used with -delete-originals, which needs the JPEG files":                                "-pipes no se puede usar con -delete-originals, que necesita los archivos JPEG",
+		"Saved the settings to %s; run heictojpeg setup to change them.\n":                                       "Configuración guardada en %s; ejecute heictojpeg setup para cambiarla.\n",
+		"Welcome to heictojpeg! A few questions to get started; press Enter to keep the suggestion in brackets.": "¡Bienvenido a heictojpeg! Unas preguntas para empezar; pulse Intro para mantener la sugerencia entre corchetes.",
+		"Folder with the HEIC photos":                                      "Carpeta con las fotos HEIC",
+		"%s is not a folder":                                               "%s no es una carpeta",
+		"JPEG quality from 1 to 100, higher is better and larger":          "Calidad JPEG de 1 a 100; más alta es mejor y más grande",
+		"Invalid quality %q: must be between 1 and 100\n":                  "La calidad %q no es válida: debe estar entre 1 y 100\n",
+		"Delete the HEIC files once they are converted? (y/n)":             "¿Eliminar los archivos HEIC una vez convertidos? (s/n)",
+		"Failed to write the journal: %v\n":                                "No se pudo escribir el diario: %v\n",
+		"Failed to start the journal, so this run can't be undone: %v\n":   "No se pudo iniciar el diario, así que esta ejecución no se podrá deshacer: %v\n",
+		"Undoing the run of %s in %s\n":                                    "Deshaciendo la ejecución del %s en %s\n",
+		"Delete %s\n":                                                      "Eliminar %s\n",
+		"Move %s back to %s\n":                                             "Devolver %s a %s\n",
+		"Can't restore %s: it was deleted without -archive-dir\n":          "No se puede restaurar %s: se eliminó sin -archive-dir\n",
+		"Failed: %v\n":                                                     "Error: %v\n",
+		"Invalid -space-check %q: must be refuse, warn or off":             "-space-check %q no es válido: debe ser refuse, warn u off",
+		"Warning: the JPEGs may need about %s but only %s is free in %s\n": "Aviso: los JPEG pueden necesitar unos %s pero solo hay %s libres en %s\n",
+		"not enough space in %s: the JPEGs may need about %s but only %s is free; free up space, or use -space-check warn to start anyway": "no hay espacio suficiente en %s: los JPEG pueden necesitar unos %s pero solo hay %s libres; libere espacio o use -space-check warn para empezar de todos modos",
+		"Free space: %s\n":              "Espacio libre: %s\n",
+		"Warning: the JPEGs won't fit.": "Aviso: los JPEG no cabrán.",
+		"The disk is full. Paused: the remaining files need about %s more in %s, and %s is free.\n": "El disco está lleno. En pausa: los archivos restantes necesitan unos %s más en %s y hay %s libres.\n",
+		"Free up space, then press Enter to resume, or Ctrl+C to stop.":                             "Libere espacio y pulse Intro para continuar, o Ctrl+C para detener.",
+		"The run resumes once there is enough free space.":                                          "La ejecución continuará cuando haya suficiente espacio libre.",
+		"-read-only needs -out, a folder outside the photos for the JPEGs":                          "-read-only necesita -out, una carpeta fuera de las fotos para los JPEG",
+		"-read-only can't be used with %s":                                                          "-read-only no se puede usar con %s",
+		"-read-only: -out %s must be outside %s":                                                    "-read-only: -out %s debe estar fuera de %s",
+		"outside -out with -read-only":                                                              "fuera de -out con -read-only",
+		"Failed to copy the permissions of %s: %v\n":                                                "No se pudieron copiar los permisos de %s: %v\n",
+		"-keep-owner is not supported on Windows":                                                   "-keep-owner no está disponible en Windows",
+		"Failed to copy the tags of %s: %v\n":                                                       "No se pudieron copiar las etiquetas de %s: %v\n",
+		"Failed to make %s searchable: %v\n":                                                        "No se pudo hacer %s buscable: %v\n",
+		"Failed to index %s: %v\n":                                                                  "No se pudo indexar %s: %v\n",
+		"-in-place can't be used with -out, -organize-by-location, -split-output or -pipes, which need a separate output folder": "-in-place no se puede usar con -out, -organize-by-location, -split-output ni -pipes, que necesitan una carpeta de salida aparte",
+		"usage: heictojpeg sync [options] SRC DST": "uso: heictojpeg sync [opciones] ORIGEN DESTINO",
+		"Source gone: %s\n":                        "Origen eliminado: %s\n",
+		"%d JPEGs in %s have no HEIC any more; run again with -delete to remove them.\n": "%d JPEG de %s ya no tienen HEIC; vuelva a ejecutarlo con -delete para eliminarlos.\n",
+		"Removed %s\n":                                                   "Eliminado %s\n",
+		"Missing, no JPEG in %s:\n":                                      "Faltan, sin JPEG en %s:\n",
+		"Orphaned, no HEIC in %s:\n":                                     "Huérfanos, sin HEIC en %s:\n",
+		"Stale, the HEIC in %s is newer:\n":                              "Desactualizados, el HEIC en %s es más reciente:\n",
+		"%d up to date, %d missing, %d orphaned, %d stale\n":             "%d al día, %d faltan, %d huérfanos, %d desactualizados\n",
+		"Invalid -schedule %q: %v":                                       "-schedule %q no válido: %v",
+		"-schedule can't be used with -files or -tui":                    "-schedule no se puede usar con -files ni -tui",
+		"-schedule never matches":                                        "-schedule nunca coincide",
+		"Next run at %s\n":                                               "Próxima ejecución: %s\n",
+		"Scheduled run failed: %v\n":                                     "Falló la ejecución programada: %v\n",
+		"Installed the %s service, running: %s\n":                        "Se instaló el servicio %s, que ejecuta: %s\n",
+		"Removed the %s service.\n":                                      "Se eliminó el servicio %s.\n",
+		"the service needs -schedule, or it would convert once and stop": "el servicio necesita -schedule; si no, convertiría una vez y se detendría",
+		"The %s service isn't installed.\n":                              "El servicio %s no está instalado.\n",
+		"-run-as is not supported on Windows":                            "-run-as no es compatible con Windows",
+		"Failed to switch to the -run-as user: %v":                       "No se pudo cambiar al usuario de -run-as: %v",
+		"8-bit HEIC":                 "HEIC de 8 bits",
+		"no HEIC photo will convert": "ninguna foto HEIC se convertirá",
+		"10-bit HEIC":                "HEIC de 10 bits",
+		"10-bit photos, such as HDR shots and those of some Android phones, won't convert correctly": "las fotos de 10 bits, como las HDR y las de algunos móviles Android, no se convertirán correctamente",
+		"Tiled (grid) HEIC": "HEIC en mosaico (grid)",
+		"photos from iPhones and most cameras, stored as tiles, won't convert": "las fotos de iPhone y de la mayoría de cámaras, guardadas en mosaico, no se convertirán",
+		"Multi-image HEIC": "HEIC de varias imágenes",
+		"-best-frame and -all-frames won't work on bursts": "-best-frame y -all-frames no funcionarán con ráfagas",
+		"%-20s supported\n":                                                 "%-20s compatible\n",
+		"%-20s not supported (%s): %s\n":                                    "%-20s no compatible (%s): %s\n",
+		"Invalid -sample %d: must be 0 or more files":                       "-sample %d no válido: debe ser 0 o más archivos",
+		"Converting %d random files of %d at quality %d...\n":               "Convirtiendo %d archivos al azar de %d con calidad %d...\n",
+		"The samples are in %s\n":                                           "Las muestras están en %s\n",
+		"Opened %s\n":                                                       "Abierto %s\n",
+		"Open %s in your browser to convert photos\n":                       "Abre %s en el navegador para convertir fotos\n",
+		"Opened %s in your browser; stop with Ctrl+C\n":                     "Abierto %s en el navegador; detén con Ctrl+C\n",
+		"format\tquality\tsize\tof HEIC\tPSNR\tSSIM\t":                      "formato\tcalidad\ttamaño\tdel HEIC\tPSNR\tSSIM\t",
+		"Wrote the variants and %s to %s\n":                                 "Variantes y %s escritos en %s\n",
+		"EXIF==%d copied, %d repaired, %d stripped, %d missing, %d dropped": "EXIF==%d copiados, %d reparados, %d eliminados, %d ausentes, %d descartados",
+		"%s has a %s color profile, which can be neither converted nor embedded: its colors will look off\n": "%s tiene un perfil de color %s que no se puede convertir ni incluir: sus colores se verán alterados\n",
+		" > %s color profile dropped: colors may look off":                                                   " > Perfil de color %s descartado: los colores pueden verse alterados",
+		"Color profiles==%d converted, %d embedded, %d dropped":                                              "Perfiles de color==%d convertidos, %d incluidos, %d descartados",
+		"Invalid -color-target %q: must be srgb, p3 or keep":                                                 "-color-target %q no es válido: debe ser srgb, p3 o keep",
+		"Invalid -bit-depth %q: must be 8, 16 or keep":                                                       "-bit-depth %q no es válido: debe ser 8, 16 o keep",
+		"Invalid -deep-format %q: must be png or tiff":                                                       "-deep-format %q no es válido: debe ser png o tiff",
+		"Invalid -alpha %q: must be flatten:#rrggbb, png or drop":                                            "-alpha %q no es válido: debe ser flatten:#rrggbb, png o drop",
+		"%s: %d×%d, not tiled\n":                                                                             "%s: %d×%d, sin mosaico\n",
+		"%s: %d×%d in %d columns and %d rows of %d×%d tiles, numbered from the top left:\n":                  "%s: %d×%d en %d columnas y %d filas de mosaicos de %d×%d, numerados desde arriba a la izquierda:\n",
+		"The tiles don't fit the image: %v\n":                                                                "Los mosaicos no encajan en la imagen: %v\n",
+		"Wrote %s\n":                                                                                         "Escrito %s\n",
+		"-preview only works with -dry-run":                                                                  "-preview solo funciona con -dry-run",
+		"No preview of %s: %v\n":                                                                             "Sin vista previa de %s: %v\n",
+		"%d of %d files would be converted, nothing was written.\n":                                          "Se convertirían %d de %d archivos; no se ha escrito nada.\n",
+		"Drew %d previews into %d contact sheets in %v, %d to a sheet in the order listed.\n":                "%d vistas previas dibujadas en %d hojas de contactos en %v, %d por hoja en el orden de la lista.\n",
+		"The contact sheets are in %s\n":                                                                     "Las hojas de contactos están en %s\n",
+		"Invalid -extra-outputs %q: %v":                                                                      "-extra-outputs %q no es válido: %v",
+		"-extra-outputs and -profiles can't be used with -pipes, which has no files to write them beside":    "-extra-outputs y -profiles no se pueden usar con -pipes, que no tiene archivos junto a los que escribirlas",
+		"%s is decoded in bands, so only its JPEG is written, without -extra-outputs or -profiles\n":         "%s se decodifica por franjas, así que solo se escribe su JPEG, sin -extra-outputs ni -profiles\n",
+		"Invalid -profiles %q: %v":                                                                           "-profiles %q no es válido: %v",
+		"Failed to write manifest.json: %v\n":                                                                "No se pudo escribir manifest.json: %v\n",
+		"-manifest can't be used with -pipes or -split-output, which leave no outputs where it lists them":   "-manifest no se puede usar con -pipes ni -split-output, que no dejan los archivos donde los lista",
+		"Failed to make a thumbnail of %s: %v\n":                                                             "No se pudo crear la miniatura de %s: %v\n",
+		"Wrote %s with %d photos\n":                                                                          "Se escribió %s con %d fotos\n",
+		"Failed to update the feed: %v\n":                                                                    "No se pudo actualizar el feed: %v\n",
+		"Invalid -feed %q: %v":                                                                               "-feed %q no válido: %v",
+		"-feed can't be used with -pipes or -split-output, which leave no photos where it links to them":     "-feed no se puede usar con -pipes ni -split-output, que no dejan las fotos donde enlaza a ellas",
+		"Invalid -feed-entries %d: must be 1 or more":                                                        "-feed-entries %d no válido: debe ser 1 o más",
+		"Failed to publish to the MQTT broker, skipping its events until the next run: %v\n":                 "No se pudo publicar en el broker MQTT, se omiten sus eventos hasta la próxima ejecución: %v\n",
+		"Invalid -mqtt %q: %v":                                                                               "-mqtt %q no válido: %v",
+		"Invalid -mqtt-qos %d: must be 0 or 1":                                                               "-mqtt-qos %d no válido: debe ser 0 o 1",
+		"Invalid -mqtt-topic %q: must be a topic without the wildcards + and #":                              "-mqtt-topic %q no válido: debe ser un tema sin los comodines + y #",
+		"Without -bot-users, the bot converts for anyone who finds it.":                                      "Sin -bot-users, el bot convierte para cualquiera que lo encuentre.",
+		"Telegram bot @%s is running\n":                                                                      "El bot de Telegram @%s está en marcha\n",
+		"Telegram: %v\n":                                                                                     "Telegram: %v\n",
+		"Ignored a file from %s: %v\n":                                                                       "Se ignoró un archivo de %s: %v\n",
+		"Sorry, I only convert photos for the people I was set up for.":                                      "Lo siento, solo convierto fotos para las personas para las que me configuraron.",
+		"Telegram already made that photo a JPEG. To convert a HEIC photo, send it as a file.":               "Telegram ya convirtió esa foto en JPEG. Para convertir una foto HEIC, envíala como archivo.",
+		"Send me a HEIC photo as a file and I'll send it back as a JPEG.":                                    "Envíame una foto HEIC como archivo y te la devolveré como JPEG.",
+		"%s is over the %s Telegram lets bots download.":                                                     "%s supera los %s que Telegram permite descargar a los bots.",
+		"Failed to convert %s from %s: %v\n":                                                                 "No se pudo convertir %s de %s: %v\n",
+		"Couldn't convert %s: %v":                                                                            "No se pudo convertir %s: %v",
+		"Failed to send the JPEG of %s to %s: %v\n":                                                          "No se pudo enviar el JPEG de %s a %s: %v\n",
+		"Discord interactions endpoint listening on %s\n":                                                    "Endpoint de interacciones de Discord escuchando en %s\n",
+		"Failed to send the JPEGs to %s: %v\n":                                                               "No se pudieron enviar los JPEG a %s: %v\n",
+		"Watching %s on %s every %v\n":                                                                       "Vigilando %s en %s cada %v\n",
+		"IMAP: %v\n":                                                                                         "IMAP: %v\n",
+		"Failed to convert %s from %s\n":                                                                     "No se pudo convertir %s de %s\n",
+		"Skipped a message from %s: %v\n":                                                                    "Se omitió un mensaje de %s: %v\n",
+		"Converted a message from %s: %d JPEGs\n":                                                            "Mensaje de %s convertido: %d JPEG\n",
+		"unknown sender": "remitente desconocido",
+		"Watching the clipboard for HEIC images; press Ctrl+C to stop": "Vigilando el portapapeles en busca de imágenes HEIC; pulse Ctrl+C para detener",
+		"Failed to convert the clipboard: %v\n":                        "No se pudo convertir el portapapeles: %v\n",
+		"Converted the clipboard's HEIC image to %s (%s)\n":            "Imagen HEIC del portapapeles convertida a %s (%s)\n",
+		"Replaced the HEIC image on the clipboard with a JPEG (%s)\n":  "Se reemplazó la imagen HEIC del portapapeles por un JPEG (%s)\n",
+		"Converting %s into %s\n":                                      "Convirtiendo %s en %s\n",
+		"polling every %v (%s is a %s mount)":                          "consultando cada %v (%s es un montaje %s)",
+		"polling every %v":                                             "consultando cada %v",
+		"change notifications":                                         "notificaciones de cambios",
+		"Watching %s for new photos, %s\n":                             "Vigilando %s en busca de fotos nuevas, %s\n",
+		"Failed to convert the new photos: %v\n":                       "No se pudieron convertir las fotos nuevas: %v\n",
+		"Failed to scan %s: %v\n":                                      "No se pudo examinar %s: %v\n",
+		"Failed to watch %s: %v\n":                                     "No se pudo vigilar %s: %v\n",
+		"Too many changes at once, some new photos may have been missed; they are converted on the next start": "Demasiados cambios a la vez, puede que se hayan pasado por alto algunas fotos nuevas; se convertirán en el próximo inicio",
+		"Invalid -watch-mode %q: must be auto, notify or poll":                                                 "-watch-mode %q no válido: debe ser auto, notify o poll",
+		"Invalid -watch-interval %v: must be 1s or more":                                                       "-watch-interval %v no válido: debe ser 1s o más",
+		"-watch can't be used with -schedule, -files, -tui or files and folders on the command line":           "-watch no se puede usar con -schedule, -files, -tui ni con archivos y carpetas en la línea de comandos",
+		"Invalid -settle %v: must be 0 or more":                                                                "-settle %v no válido: debe ser 0 o más",
+		"Ignoring %s: there is no option -%s\n":                                                                "Se ignora %s: no existe la opción -%s\n",
+		"Converting files in %s...":                                                                            "Convirtiendo los archivos de %s...",
+		"Converting %d files in %s...":                                                                         "Convirtiendo %d archivos de %s...",
+		"Converted":                                                                                            "Convertido",
+		"Failed: %v":                                                                                           "Error: %v",
+		"%d files done":                                                                                        "%d archivos listos",
+		"%d of %d files done":                                                                                  "%d de %d archivos listos",
+		"Done: converted %d of %d files in %v (%d failed). The JPEGs are in %s.":                               "Listo: se convirtieron %d de %d archivos en %v (%d con error). Los JPEG están en %s.",
+		"HEIC to JPEG":                                                                                         "HEIC a JPEG",
+		"Drag a folder with .heic photos onto this window, or choose one:":                                     "Arrastre a esta ventana una carpeta con fotos .heic, o elija una:",
+		"Choose folder...":                                                                                     "Elegir carpeta...",
+		"Choose a folder with .heic photos":                                                                    "Elija una carpeta con fotos .heic",
+		"JPEG quality:":                                                                                        "Calidad JPEG:",
+		"Include subfolders":                                                                                   "Incluir subcarpetas",
+		"File":                                                                                                 "Archivo",
+		"Status":                                                                                               "Estado",
+		"Size":                                                                                                 "Tamaño",
+		"Waiting for a folder...":                                                                              "Esperando una carpeta...",
+	},
+	"fr": {
+		"Starting the program...":                           "Démarrage du programme...",
+		"Failed to get current directory: %v":               "Impossible d'obtenir le dossier courant : %v",
+		"Failed to start the GUI: %v":                       "Impossible d'ouvrir la fenêtre : %v",
+		"Program completed!":                                "Programme terminé !",
+		"Interrupted, the remaining files were skipped.":    "Interrompu, les fichiers restants ont été ignorés.",
+		"Fetching the current directory...":                 "Lecture du dossier courant...",
+		"Failed to create directory: %v":                    "Impossible de créer le dossier : %v",
+		"Failed to create log file: %v":                     "Impossible de créer le fichier journal : %v",
+		"Saving logs to logs.txt...":                        "Enregistrement du journal dans logs.txt...",
+		"Processing files...":                               "Traitement des fichiers...",
+		"Processing file: %s\n":                             "Traitement du fichier : %s\n",
+		"Failed to convert %s: %v\n":                        "Échec de la conversion de %s : %v\n",
+		"%s %s > Failed > error details: %s":                "%s %s > Échec > détails : %s",
+		"%s %s > Converted > jpegs/%s %s":                   "%s %s > Converti > jpegs/%s %s",
+		"\n%v Files":                                        "\n%v fichiers",
+		"Total Time Taken==%v":                              "Durée totale==%v",
+		"Average Time Per File==%v":                         "Durée moyenne par fichier==%v",
+		"Total HEIC File Size==%s":                          "Taille totale des HEIC==%s",
+		"Total JPEG Folder Size==%s":                        "Taille totale du dossier JPEG==%s",
+		"Invalid -symlink-names %q: must be link or target": "-symlink-names %q invalide : doit être link ou target",
+		"Invalid -quality %d: must be between 1 and 100":    "-quality %d invalide : doit être compris entre 1 et 100",
+		"another heictojpeg run (pid %d, started %s) is already converting into %s; wait for it to finish or use -wait": "une autre exécution de heictojpeg (pid %d, démarrée %s) convertit déjà dans %s ; attendez qu'elle se termine ou utilisez -wait",
+		"another heictojpeg run is already converting into %s; wait for it to finish or use -wait":                      "une autre exécution de heictojpeg convertit déjà dans %s ; attendez qu'elle se termine ou utilisez -wait",
+		"Waiting for the other run to finish...":                              "En attente de la fin de l'autre exécution...",
+		"Failed to remove lock file: %v\n":                                    "Impossible de supprimer le fichier de verrou : %v\n",
+		"Skipping broken symlink: %s\n":                                       "Lien symbolique cassé ignoré : %s\n",
+		"Skipping symlinked directory: %s\n":                                  "Dossier lié ignoré : %s\n",
+		"Failed to read directory %s: %v\n":                                   "Impossible de lire le dossier %s : %v\n",
+		"Skipping symlink loop: %s\n":                                         "Boucle de liens ignorée : %s\n",
+		"Skipping mount point: %s\n":                                          "Point de montage ignoré : %s\n",
+		"Failed to show desktop notification: %v\n":                           "Impossible d'afficher la notification : %v\n",
+		"Converted %d of %d files in %v":                                      "%d fichiers sur %d convertis en %v",
+		"HEIC conversion finished with errors":                                "Conversion HEIC terminée avec des erreurs",
+		"%s, %d failed. See jpegs/logs.txt.":                                  "%s, %d en échec. Voir jpegs/logs.txt.",
+		"HEIC conversion finished":                                            "Conversion HEIC terminée",
+		"Failed to send webhook report: %v\n":                                 "Impossible d'envoyer le rapport au webhook : %v\n",
+		"Failed to mail report: %v\n":                                         "Impossible d'envoyer le rapport par e-mail : %v\n",
+		"Added %q to the context menu for .heic files and folders.\n":         "%q a été ajouté au menu contextuel des fichiers .heic et des dossiers.\n",
+		"Removed %q from the context menu.\n":                                 "%q a été retiré du menu contextuel.\n",
+		"Paused: running files finish, no new ones start. Press p to resume.": "En pause : les fichiers en cours se terminent, aucun nouveau ne démarre. Appuyez sur p pour reprendre.",
+		"Resumed.":                              "Reprise.",
+		"Nothing to skip.":                      "Rien à ignorer.",
+		"Aborting, the log is still written...": "Annulation, le journal est tout de même enregistré...",
+		"Worker %c is idle.":                    "Le processus %c est inactif.",
+		"running":                               "en cours",
+		"aborting":                              "annulation",
+		"PAUSED":                                "EN PAUSE",
+		"%d/%d files done, %d failed, %v elapsed": "%d/%d fichiers traités, %d en échec, %v écoulées",
+		"Workers":      "Processus",
+		"(idle)":       "(inactif)",
+		"Recent files": "Fichiers récents",
+		"FAILED: ":     "ÉCHEC : ",
+		"[p] pause/resume  [s] skip slowest  [1-9] skip worker's file  [q] abort": "[p] pause/reprise  [s] ignorer le plus lent  [1-9] ignorer le fichier du processus  [q] annuler",
+		"%d of %d files converted, %d failed, in %v.\n":                           "%d fichiers sur %d convertis, %d en échec, en %v.\n",
+		"Invalid -output %q: must be text or ndjson":                              "-output %q invalide : doit être text ou ndjson",
+		"Already converted: %s\n":                                                 "Déjà converti : %s\n",
+		"Failed to open the history: %v":                                          "Impossible d'ouvrir l'historique : %v",
+		"Failed to save the history: %v\n":                                        "Impossible d'enregistrer l'historique : %v\n",
+		"History: %s\n":                                                           "Historique : %s\n",
+		"Conversions: %d (%d distinct photos)\n":                                  "Conversions : %d (%d photos distinctes)\n",
+		"Between %s and %s\n":                                                     "Entre le %s et le %s\n",
+		"HEIC converted: %s, JPEG written: %s\n":                                  "HEIC convertis : %s, JPEG écrits : %s\n",
+		"Quality %d: %d conversions\n":                                            "Qualité %d : %d conversions\n",
+		"Sampling %d of %d files at quality %d...\n":                              "Échantillonnage de %d fichiers sur %d en qualité %d...\n",
+		"Estimated JPEG size: %s for %s of HEIC (%.0f%%)\n":                       "Taille JPEG estimée : %s pour %s de HEIC (%.0f%%)\n",
+		"Estimated time: %v with %d workers\n":                                    "Durée estimée : %v avec %d processus\n",
+		"Failed to read the config file: %v":                                      "Impossible de lire le fichier de configuration : %v",
+		"Benchmarking with %s...\n":                                               "Mesure des performances avec %s...\n",
+		"workers\tquality\tfiles/s\tJPEG size\t":                                  "processus\tqualité\tfichiers/s\ttaille JPEG\t",
+		"Fastest: %d workers (%.2f files/s)\n":                                    "Le plus rapide : %d processus (%.2f fichiers/s)\n",
+		"Saved workers=%d to %s\n":                                                "workers=%d enregistré dans %s\n",
+		"Allocated==%s in %d allocations":                                         "Mémoire allouée==%s en %d allocations",
+		"Garbage Collections==%d (%v paused)":                                     "Ramasse-miettes==%d (%v de pause)",
+		"Heap Reserved==%s":                                                       "Tas réservé==%s",
+		"Invalid -order %q: must be name, size-asc, size-desc or mtime":           "-order %q invalide : doit être name, size-asc, size-desc ou mtime",
+		"Paused (SIGUSR1); running files finish, send SIGUSR2 to resume.":         "En pause (SIGUSR1) ; les fichiers en cours se terminent, envoyez SIGUSR2 pour reprendre.",
+		"Invalid -throttle %q: %v":                                                "-throttle %q invalide : %v",
+		"on battery power and under thermal pressure":                             "sur batterie et en surchauffe",
+		"under thermal pressure":                                                  "en surchauffe",
+		"on battery power":                                                        "sur batterie",
+		"on mains power":                                                          "sur secteur",
+		"Running %s: using %d of %d workers.\n":                                   "Fonctionnement %s : %d processus sur %d utilisés.\n",
+		"Failed to quarantine %s: %v\n":                                           "Impossible de mettre %s en quarantaine : %v\n",
+		"Invalid -quarantine %q: must be copy or move":                            "-quarantine %q invalide : doit être copy ou move",
+		"%d files failed. Write %s with the first %d KB of each, the errors and system info (OS, CPU, settings; no image content beyond the headers)? [y/N] ": "%d fichiers en échec. Écrire %s avec les %d premiers Ko de chacun, les erreurs et les infos système (OS, CPU, réglages ; aucun contenu d'image au-delà des en-têtes) ? [o/N] ",
+		"No bundle written.":                       "Aucune archive écrite.",
+		"Wrote %s; attach it to a GitHub issue.\n": "%s écrit ; joignez-le à un ticket GitHub.\n",
+		"Failed to write the failure bundle: %v\n": "Impossible d'écrire l'archive des échecs : %v\n",
+		"Converted %s with a warning: %v\n":        "%s converti avec un avertissement : %v\n",
+		" > Warning: %s":                           " > Avertissement : %s",
+		"%s (%dx%d) needs %s to decode, over -max-memory; decoding it in bands.\n": "%s (%dx%d) nécessite %s pour le décodage, plus que -max-memory ; décodage par bandes.\n",
+		"Warning: %s (%dx%d) needs %s to decode, over -max-memory.\n":              "Avertissement : %s (%dx%d) nécessite %s pour le décodage, plus que -max-memory.\n",
+		"Invalid -max-memory %q: %v":                                               "-max-memory %q invalide : %v",
+		"Failed to split the output: %v\n":                                         "Impossible de répartir la sortie : %v\n",
+		"Invalid -split-output %q: %v":                                             "-split-output %q invalide : %v",
+		"Invalid -sanitize %q: must be fat32 or exfat":                             "-sanitize %q invalide : doit être fat32 ou exfat",
+		"%d files done (still scanning), %d failed, %v elapsed":                    "%d fichiers traités (analyse en cours), %d en échec, %v écoulées",
+		"Invalid -sort %q: must be path, size, duration or status":                 "-sort %q invalide : doit être path, size, duration ou status",
+		"Failed to rotate the previous logs: %v\n":                                 "Impossible de faire tourner les journaux précédents : %v\n",
+		"Failed to update history.csv: %v\n":                                       "Impossible de mettre à jour history.csv : %v\n",
+		"Invalid -verbosity %q: must be quiet, normal, verbose or debug":           "-verbosity %q invalide : doit être quiet, normal, verbose ou debug",
+		"Diagnostics for %s:\n":                                                    "Diagnostic de %s :\n",
+		" > Took %v":                                                               " > Durée %v",
+		"Invalid -metadata %q: must be keep or strip":                              "-metadata %q invalide : doit être keep ou strip",
+		"Invalid -max-size %d: must be 0 or more pixels":                           "-max-size %d invalide : doit être 0 pixel ou plus",
+		"Skipped %s: %s\n":                                                         "Ignoré %s : %s\n",
+		"%s %s > Skipped > %s":                                                     "%s %s > Ignoré > %s",
+		"Invalid -screenshots %q: must be jpeg, png or skip":                       "-screenshots %q invalide : doit être jpeg, png ou skip",
+		"Invalid -target-ssim %v: must be above 0 and at most 1":                   "-target-ssim %v invalide : doit être supérieur à 0 et au plus 1",
+		"Failed to compare %s: %v\n":                                               "Impossible de comparer %s : %v\n",
+		"Failed to create the comparison folder: %v":                               "Impossible de créer le dossier de comparaison : %v",
+		"Failed to write compare.csv: %v\n":                                        "Impossible d'écrire compare.csv : %v\n",
+		"Invalid -dedupe %q: must be skip or link":                                 "-dedupe %q invalide : doit être skip ou link",
+		"Invalid -dedupe-distance %d: must be from 0 to 64":                        "-dedupe-distance %d invalide : doit être compris entre 0 et 64",
+		"Failed to index -dedupe-library: %v":                                      "Impossible d'indexer -dedupe-library : %v",
+		"Indexed %d JPEGs in %s\n":                                                 "%d JPEG indexés dans %s\n",
+		"Skipping %s in the library: %v\n":                                         "%s ignoré dans la bibliothèque : %v\n",
+		"Failed to hash %s: %v\n":                                                  "Impossible de calculer le hash de %s : %v\n",
+		"Failed to write hashes.csv: %v\n":                                         "Impossible d'écrire hashes.csv : %v\n",
+		"Invalid -crop: %v":                                                        "-crop invalide : %v",
+		"Invalid -crop-focus %q: must be center or subject":                        "-crop-focus %q invalide : doit être center ou subject",
+		" > EXIF orientation %d: may display rotated in strict viewers":            " > Orientation EXIF %d : peut s'afficher pivotée dans les visionneuses strictes",
+		"Failed to copy the sidecar metadata of %s: %v\n":                          "Impossible de copier les métadonnées du fichier annexe de %s : %v\n",
+		"Converted %d more frames of %s\n":                                         "%d images de plus converties pour %s\n",
+		"-best-frame and -all-frames can't be used together":                       "-best-frame et -all-frames ne peuvent pas être utilisés ensemble",
+		"not HEVC":                                                "pas en HEVC",
+		"already transcoded":                                      "déjà transcodé",
+		"Transcoding video: %s\n":                                 "Transcodage de la vidéo : %s\n",
+		"Failed to transcode %s: %v\n":                            "Impossible de transcoder %s : %v\n",
+		"%s %s > Transcoded > jpegs/%s %s":                        "%s %s > Transcodé > jpegs/%s %s",
+		"\nVideos:":                                               "\nVidéos :",
+		"%d videos transcoded, %d failed (%s to %s)":              "%d vidéos transcodées, %d en échec (%s vers %s)",
+		"Invalid -video-crf %d: must be between 0 and 51":         "-video-crf %d invalide : doit être compris entre 0 et 51",
+		"-videos needs ffmpeg: %v":                                "-videos nécessite ffmpeg : %v",
+		"Skipping %s: %v\n":                                       "%s ignoré : %v\n",
+		"No files to convert.":                                    "Aucun fichier à convertir.",
+		"Failed to read the file list: %v":                        "Impossible de lire la liste des fichiers : %v",
+		"Invalid -files %q: only - (standard input) is supported": "-files %q invalide : seul - (entrée standard) est pris en charge",
+		"-files - can't be combined with files or folders on the command line": "-files - ne peut pas être combiné avec des fichiers ou dossiers sur la ligne de commande",
+		"-pipes is not supported on Windows":                                   "-pipes n'est pas disponible sous Windows",
+		"-pipes needs -output ndjson to announce the pipes":                    "-pipes nécessite -output ndjson pour annoncer les tubes",
+		"-pipes can't be used with -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes or -split-output, which need the JPEG files": "-pipes ne peut pas être utilisé avec -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes ou -split-output, qui ont besoin des fichiers JPEG",
+		"Requeued %d unfinished jobs\n":                 "%d tâches inachevées remises dans la file\n",
+		"Waiting for jobs on %s at %s\n":                "En attente de tâches sur %s à %s\n",
+		"Invalid job %q: %v\n":                          "Tâche invalide %q : %v\n",
+		"Downloading %d files...\n":                     "Téléchargement de %d fichiers...\n",
+		"Failed to download %s: %v\n":                   "Impossible de télécharger %s : %v\n",
+		"Failed to cache %s: %v\n":                      "Impossible de mettre %s en cache : %v\n",
+		"Invalid -cache-size %q: %v":                    "-cache-size %q invalide : %v",
+		"Failed to open the cache: %v":                  "Impossible d'ouvrir le cache : %v",
+		"Listening on %s\n":                             "En écoute sur %s\n",
+		"%s %s from %s: %d, %s in %s\n":                 "%s %s depuis %s : %d, %s en %s\n",
+		"Self-test failed, /readyz stays unready: %v\n": "L'autotest a échoué, /readyz reste non prêt : %v\n",
+		"heictojpeg %s is up to date\n":                 "heictojpeg %s est à jour\n",
+		"heictojpeg %s is available (this is %s)\n":     "heictojpeg %s est disponible (version actuelle %s)\n",
+		"Downloading heictojpeg %s...\n":                "Téléchargement de heictojpeg %s...\n",
+		"Updated heictojpeg from %s to %s\n":            "heictojpeg mis à jour de %s vers %s\n",
+		"Kept %s: %v\n":                                 "%s conservé : %v\n",
+		"-pipes can't be used with -delete-originals, which needs the JPEG files":                                "-pipes ne peut pas être utilisé avec -delete-originals, qui a besoin des fichiers JPEG",
+		"Saved the settings to %s; run heictojpeg setup to change them.\n":                                       "Réglages enregistrés dans %s ; lancez heictojpeg setup pour les modifier.\n",
+		"Welcome to heictojpeg! A few questions to get started; press Enter to keep the suggestion in brackets.": "Bienvenue dans heictojpeg ! Quelques questions pour commencer ; appuyez sur Entrée pour garder la suggestion entre crochets.",
+		"Folder with the HEIC photos":                                      "Dossier des photos HEIC",
+		"%s is not a folder":                                               "%s n'est pas un dossier",
+		"JPEG quality from 1 to 100, higher is better and larger":          "Qualité JPEG de 1 à 100 ; plus elle est haute, meilleure et plus lourde est l'image",
+		"Invalid quality %q: must be between 1 and 100\n":                  "Qualité %q invalide : doit être entre 1 et 100\n",
+		"Delete the HEIC files once they are converted? (y/n)":             "Supprimer les fichiers HEIC une fois convertis ? (o/n)",
+		"Failed to write the journal: %v\n":                                "Impossible d'écrire le journal : %v\n",
+		"Failed to start the journal, so this run can't be undone: %v\n":   "Impossible de démarrer le journal, cette exécution ne pourra pas être annulée : %v\n",
+		"Undoing the run of %s in %s\n":                                    "Annulation de l'exécution du %s dans %s\n",
+		"Delete %s\n":                                                      "Supprimer %s\n",
+		"Move %s back to %s\n":                                             "Remettre %s à %s\n",
+		"Can't restore %s: it was deleted without -archive-dir\n":          "Impossible de restaurer %s : supprimé sans -archive-dir\n",
+		"Failed: %v\n":                                                     "Échec : %v\n",
+		"Invalid -space-check %q: must be refuse, warn or off":             "-space-check %q invalide : doit être refuse, warn ou off",
+		"Warning: the JPEGs may need about %s but only %s is free in %s\n": "Attention : les JPEG peuvent nécessiter environ %s mais seuls %s sont libres dans %s\n",
+		"not enough space in %s: the JPEGs may need about %s but only %s is free; free up space, or use -space-check warn to start anyway": "pas assez d'espace dans %s : les JPEG peuvent nécessiter environ %s mais seuls %s sont libres ; libérez de l'espace ou utilisez -space-check warn pour démarrer quand même",
+		"Free space: %s\n":              "Espace libre : %s\n",
+		"Warning: the JPEGs won't fit.": "Attention : les JPEG ne tiendront pas.",
+		"The disk is full. Paused: the remaining files need about %s more in %s, and %s is free.\n": "Le disque est plein. En pause : les fichiers restants nécessitent encore environ %s dans %s, et %s sont libres.\n",
+		"Free up space, then press Enter to resume, or Ctrl+C to stop.":                             "Libérez de l'espace puis appuyez sur Entrée pour reprendre, ou Ctrl+C pour arrêter.",
+		"The run resumes once there is enough free space.":                                          "L'exécution reprendra dès qu'il y aura assez d'espace libre.",
+		"-read-only needs -out, a folder outside the photos for the JPEGs":                          "-read-only nécessite -out, un dossier hors des photos pour les JPEG",
+		"-read-only can't be used with %s":                                                          "-read-only ne peut pas être utilisé avec %s",
+		"-read-only: -out %s must be outside %s":                                                    "-read-only : -out %s doit être hors de %s",
+		"outside -out with -read-only":                                                              "hors de -out avec -read-only",
+		"Failed to copy the permissions of %s: %v\n":                                                "Impossible de copier les permissions de %s : %v\n",
+		"-keep-owner is not supported on Windows":                                                   "-keep-owner n'est pas pris en charge sous Windows",
+		"Failed to copy the tags of %s: %v\n":                                                       "Impossible de copier les tags de %s : %v\n",
+		"Failed to make %s searchable: %v\n":                                                        "Impossible de rendre %s consultable par la recherche : %v\n",
+		"Failed to index %s: %v\n":                                                                  "Impossible d'indexer %s : %v\n",
+		"-in-place can't be used with -out, -organize-by-location, -split-output or -pipes, which need a separate output folder": "-in-place ne peut pas être utilisé avec -out, -organize-by-location, -split-output ou -pipes, qui ont besoin d'un dossier de sortie distinct",
+		"usage: heictojpeg sync [options] SRC DST": "usage : heictojpeg sync [options] SOURCE DESTINATION",
+		"Source gone: %s\n":                        "Source disparue : %s\n",
+		"%d JPEGs in %s have no HEIC any more; run again with -delete to remove them.\n": "%d JPEG de %s n'ont plus de HEIC ; relancez avec -delete pour les supprimer.\n",
+		"Removed %s\n":                                                   "Supprimé %s\n",
+		"Missing, no JPEG in %s:\n":                                      "Manquants, aucun JPEG dans %s :\n",
+		"Orphaned, no HEIC in %s:\n":                                     "Orphelins, aucun HEIC dans %s :\n",
+		"Stale, the HEIC in %s is newer:\n":                              "Périmés, le HEIC dans %s est plus récent :\n",
+		"%d up to date, %d missing, %d orphaned, %d stale\n":             "%d à jour, %d manquants, %d orphelins, %d périmés\n",
+		"Invalid -schedule %q: %v":                                       "-schedule %q invalide : %v",
+		"-schedule can't be used with -files or -tui":                    "-schedule ne peut pas être utilisé avec -files ou -tui",
+		"-schedule never matches":                                        "-schedule ne correspond jamais",
+		"Next run at %s\n":                                               "Prochaine exécution : %s\n",
+		"Scheduled run failed: %v\n":                                     "L'exécution planifiée a échoué : %v\n",
+		"Installed the %s service, running: %s\n":                        "Service %s installé, qui exécute : %s\n",
+		"Removed the %s service.\n":                                      "Service %s supprimé.\n",
+		"the service needs -schedule, or it would convert once and stop": "le service a besoin de -schedule, sinon il convertirait une fois puis s'arrêterait",
+		"The %s service isn't installed.\n":                              "Le service %s n'est pas installé.\n",
+		"-run-as is not supported on Windows":                            "-run-as n'est pas pris en charge sous Windows",
+		"Failed to switch to the -run-as user: %v":                       "Impossible de passer à l'utilisateur de -run-as : %v",
+		"8-bit HEIC":                 "HEIC 8 bits",
+		"no HEIC photo will convert": "aucune photo HEIC ne sera convertie",
+		"10-bit HEIC":                "HEIC 10 bits",
+		"10-bit photos, such as HDR shots and those of some Android phones, won't convert correctly": "les photos 10 bits, comme les prises HDR et celles de certains téléphones Android, ne seront pas converties correctement",
+		"Tiled (grid) HEIC": "HEIC en tuiles (grid)",
+		"photos from iPhones and most cameras, stored as tiles, won't convert": "les photos d'iPhone et de la plupart des appareils, stockées en tuiles, ne seront pas converties",
+		"Multi-image HEIC": "HEIC à plusieurs images",
+		"-best-frame and -all-frames won't work on bursts": "-best-frame et -all-frames ne fonctionneront pas sur les rafales",
+		"%-20s supported\n":                                                 "%-20s pris en charge\n",
+		"%-20s not supported (%s): %s\n":                                    "%-20s non pris en charge (%s) : %s\n",
+		"Invalid -sample %d: must be 0 or more files":                       "-sample %d non valide : doit être de 0 fichier ou plus",
+		"Converting %d random files of %d at quality %d...\n":               "Conversion de %d fichiers au hasard sur %d en qualité %d...\n",
+		"The samples are in %s\n":                                           "Les échantillons sont dans %s\n",
+		"Opened %s\n":                                                       "%s ouvert\n",
+		"Open %s in your browser to convert photos\n":                       "Ouvrez %s dans votre navigateur pour convertir des photos\n",
+		"Opened %s in your browser; stop with Ctrl+C\n":                     "%s ouvert dans votre navigateur ; arrêtez avec Ctrl+C\n",
+		"format\tquality\tsize\tof HEIC\tPSNR\tSSIM\t":                      "format\tqualité\ttaille\tdu HEIC\tPSNR\tSSIM\t",
+		"Wrote the variants and %s to %s\n":                                 "Variantes et %s écrits dans %s\n",
+		"EXIF==%d copied, %d repaired, %d stripped, %d missing, %d dropped": "EXIF==%d copiés, %d réparés, %d retirés, %d absents, %d écartés",
+		"%s has a %s color profile, which can be neither converted nor embedded: its colors will look off\n": "%s a un profil colorimétrique %s qui ne peut être ni converti ni intégré : ses couleurs paraîtront fausses\n",
+		" > %s color profile dropped: colors may look off":                                                   " > Profil colorimétrique %s abandonné : les couleurs peuvent paraître fausses",
+		"Color profiles==%d converted, %d embedded, %d dropped":                                              "Profils colorimétriques==%d convertis, %d intégrés, %d abandonnés",
+		"Invalid -color-target %q: must be srgb, p3 or keep":                                                 "-color-target %q invalide : doit être srgb, p3 ou keep",
+		"Invalid -bit-depth %q: must be 8, 16 or keep":                                                       "-bit-depth %q invalide : doit être 8, 16 ou keep",
+		"Invalid -deep-format %q: must be png or tiff":                                                       "-deep-format %q invalide : doit être png ou tiff",
+		"Invalid -alpha %q: must be flatten:#rrggbb, png or drop":                                            "-alpha %q invalide : doit être flatten:#rrggbb, png ou drop",
+		"%s: %d×%d, not tiled\n":                                                                             "%s : %d×%d, sans tuiles\n",
+		"%s: %d×%d in %d columns and %d rows of %d×%d tiles, numbered from the top left:\n":                  "%s : %d×%d en %d colonnes et %d lignes de tuiles de %d×%d, numérotées depuis le coin supérieur gauche :\n",
+		"The tiles don't fit the image: %v\n":                                                                "Les tuiles ne correspondent pas à l'image : %v\n",
+		"Wrote %s\n":                                                                                         "%s écrit\n",
+		"-preview only works with -dry-run":                                                                  "-preview ne fonctionne qu'avec -dry-run",
+		"No preview of %s: %v\n":                                                                             "Pas d'aperçu de %s : %v\n",
+		"%d of %d files would be converted, nothing was written.\n":                                          "%d fichiers sur %d seraient convertis, rien n'a été écrit.\n",
+		"Drew %d previews into %d contact sheets in %v, %d to a sheet in the order listed.\n":                "%d aperçus dessinés sur %d planches contact en %v, %d par planche dans l'ordre de la liste.\n",
+		"The contact sheets are in %s\n":                                                                     "Les planches contact sont dans %s\n",
+		"Invalid -extra-outputs %q: %v":                                                                      "-extra-outputs %q invalide : %v",
+		"-extra-outputs and -profiles can't be used with -pipes, which has no files to write them beside":    "-extra-outputs et -profiles ne peuvent pas être utilisés avec -pipes, qui n'a pas de fichiers à côté desquels les écrire",
+		"%s is decoded in bands, so only its JPEG is written, without -extra-outputs or -profiles\n":         "%s est décodé par bandes, seul son JPEG est donc écrit, sans -extra-outputs ni -profiles\n",
+		"Invalid -profiles %q: %v":                                                                           "-profiles %q invalide : %v",
+		"Failed to write manifest.json: %v\n":                                                                "Impossible d'écrire manifest.json : %v\n",
+		"-manifest can't be used with -pipes or -split-output, which leave no outputs where it lists them":   "-manifest ne peut pas être utilisé avec -pipes ou -split-output, qui ne laissent pas les fichiers là où il les liste",
+		"Failed to make a thumbnail of %s: %v\n":                                                             "Impossible de créer la miniature de %s : %v\n",
+		"Wrote %s with %d photos\n":                                                                          "%s écrit avec %d photos\n",
+		"Failed to update the feed: %v\n":                                                                    "Impossible de mettre à jour le flux : %v\n",
+		"Invalid -feed %q: %v":                                                                               "-feed %q invalide : %v",
+		"-feed can't be used with -pipes or -split-output, which leave no photos where it links to them":     "-feed ne peut pas être utilisé avec -pipes ou -split-output, qui ne laissent pas les photos là où il les lie",
+		"Invalid -feed-entries %d: must be 1 or more":                                                        "-feed-entries %d invalide : doit être 1 ou plus",
+		"Failed to publish to the MQTT broker, skipping its events until the next run: %v\n":                 "Impossible de publier sur le broker MQTT, ses événements sont ignorés jusqu'à la prochaine exécution : %v\n",
+		"Invalid -mqtt %q: %v":                                                                               "-mqtt %q invalide : %v",
+		"Invalid -mqtt-qos %d: must be 0 or 1":                                                               "-mqtt-qos %d invalide : doit être 0 ou 1",
+		"Invalid -mqtt-topic %q: must be a topic without the wildcards + and #":                              "-mqtt-topic %q invalide : doit être un sujet sans les jokers + et #",
+		"Without -bot-users, the bot converts for anyone who finds it.":                                      "Sans -bot-users, le bot convertit pour quiconque le trouve.",
+		"Telegram bot @%s is running\n":                                                                      "Le bot Telegram @%s est en marche\n",
+		"Telegram: %v\n":                                                                                     "Telegram : %v\n",
+		"Ignored a file from %s: %v\n":                                                                       "Fichier de %s ignoré : %v\n",
+		"Sorry, I only convert photos for the people I was set up for.":                                      "Désolé, je ne convertis les photos que pour les personnes pour qui j'ai été configuré.",
+		"Telegram already made that photo a JPEG. To convert a HEIC photo, send it as a file.":               "Telegram a déjà fait de cette photo un JPEG. Pour convertir une photo HEIC, envoyez-la en tant que fichier.",
+		"Send me a HEIC photo as a file and I'll send it back as a JPEG.":                                    "Envoyez-moi une photo HEIC en tant que fichier et je vous la renverrai en JPEG.",
+		"%s is over the %s Telegram lets bots download.":                                                     "%s dépasse les %s que Telegram laisse télécharger aux bots.",
+		"Failed to convert %s from %s: %v\n":                                                                 "Impossible de convertir %s de %s : %v\n",
+		"Couldn't convert %s: %v":                                                                            "Impossible de convertir %s : %v",
+		"Failed to send the JPEG of %s to %s: %v\n":                                                          "Impossible d'envoyer le JPEG de %s à %s : %v\n",
+		"Discord interactions endpoint listening on %s\n":                                                    "Point de terminaison des interactions Discord à l'écoute sur %s\n",
+		"Failed to send the JPEGs to %s: %v\n":                                                               "Impossible d'envoyer les JPEG à %s : %v\n",
+		"Watching %s on %s every %v\n":                                                                       "Surveillance de %s sur %s toutes les %v\n",
+		"IMAP: %v\n":                                                                                         "IMAP : %v\n",
+		"Failed to convert %s from %s\n":                                                                     "Échec de la conversion de %s de %s\n",
+		"Skipped a message from %s: %v\n":                                                                    "Message de %s ignoré : %v\n",
+		"Converted a message from %s: %d JPEGs\n":                                                            "Message de %s converti : %d JPEG\n",
+		"unknown sender": "expéditeur inconnu",
+		"Watching the clipboard for HEIC images; press Ctrl+C to stop": "Surveillance du presse-papiers pour les images HEIC ; appuyez sur Ctrl+C pour arrêter",
+		"Failed to convert the clipboard: %v\n":                        "Échec de la conversion du presse-papiers : %v\n",
+		"Converted the clipboard's HEIC image to %s (%s)\n":            "Image HEIC du presse-papiers convertie en %s (%s)\n",
+		"Replaced the HEIC image on the clipboard with a JPEG (%s)\n":  "Image HEIC du presse-papiers remplacée par un JPEG (%s)\n",
+		"Converting %s into %s\n":                                      "Conversion de %s dans %s\n",
+		"polling every %v (%s is a %s mount)":                          "interrogation toutes les %v (%s est un montage %s)",
+		"polling every %v":                                             "interrogation toutes les %v",
+		"change notifications":                                         "notifications de modification",
+		"Watching %s for new photos, %s\n":                             "Surveillance de %s pour les nouvelles photos, %s\n",
+		"Failed to convert the new photos: %v\n":                       "Échec de la conversion des nouvelles photos : %v\n",
+		"Failed to scan %s: %v\n":                                      "Échec de l'analyse de %s : %v\n",
+		"Failed to watch %s: %v\n":                                     "Impossible de surveiller %s : %v\n",
+		"Too many changes at once, some new photos may have been missed; they are converted on the next start": "Trop de modifications à la fois, certaines nouvelles photos ont pu être manquées ; elles seront converties au prochain démarrage",
+		"Invalid -watch-mode %q: must be auto, notify or poll":                                                 "-watch-mode %q invalide : doit être auto, notify ou poll",
+		"Invalid -watch-interval %v: must be 1s or more":                                                       "-watch-interval %v invalide : doit être de 1s ou plus",
+		"-watch can't be used with -schedule, -files, -tui or files and folders on the command line":           "-watch ne peut pas être utilisé avec -schedule, -files, -tui ni avec des fichiers et dossiers sur la ligne de commande",
+		"Invalid -settle %v: must be 0 or more":                                                                "-settle %v invalide : doit être 0 ou plus",
+		"Ignoring %s: there is no option -%s\n":                                                                "%s ignorée : il n'y a pas d'option -%s\n",
+		"Converting files in %s...":                                                                            "Conversion des fichiers de %s...",
+		"Converting %d files in %s...":                                                                         "Conversion de %d fichiers de %s...",
+		"Converted":                                                                                            "Converti",
+		"Failed: %v":                                                                                           "Échec : %v",
+		"%d files done":                                                                                        "%d fichiers traités",
+		"%d of %d files done":                                                                                  "%d fichiers traités sur %d",
+		"Done: converted %d of %d files in %v (%d failed). The JPEGs are in %s.":                               "Terminé : %d fichiers convertis sur %d en %v (%d en échec). Les JPEG sont dans %s.",
+		"HEIC to JPEG":                                                                                         "HEIC en JPEG",
+		"Drag a folder with .heic photos onto this window, or choose one:":                                     "Faites glisser un dossier de photos .heic sur cette fenêtre, ou choisissez-en un :",
+		"Choose folder...":                                                                                     "Choisir un dossier...",
+		"Choose a folder with .heic photos":                                                                    "Choisissez un dossier de photos .heic",
+		"JPEG quality:":                                                                                        "Qualité JPEG :",
+		"Include subfolders":                                                                                   "Inclure les sous-dossiers",
+		"File":                                                                                                 "Fichier",
+		"Status":                                                                                               "État",
+		"Size":                                                                                                 "Taille",
+		"Waiting for a folder...":                                                                              "En attente d'un dossier...",
+	},
+	"de": {
+		"Starting the program...":                           "Programm wird gestartet...",
+		"Failed to get current directory: %v":               "Aktueller Ordner konnte nicht ermittelt werden: %v",
+		"Failed to start the GUI: %v":                       "Fenster konnte nicht geöffnet werden: %v",
+		"Program completed!":                                "Programm abgeschlossen!",
+		"Interrupted, the remaining files were skipped.":    "Abgebrochen, die restlichen Dateien wurden übersprungen.",
+		"Fetching the current directory...":                 "Aktueller Ordner wird ermittelt...",
+		"Failed to create directory: %v":                    "Ordner konnte nicht erstellt werden: %v",
+		"Failed to create log file: %v":                     "Protokolldatei konnte nicht erstellt werden: %v",
+		"Saving logs to logs.txt...":                        "Protokoll wird in logs.txt gespeichert...",
+		"Processing files...":                               "Dateien werden verarbeitet...",
+		"Processing file: %s\n":                             "Verarbeite Datei: %s\n",
+		"Failed to convert %s: %v\n":                        "%s konnte nicht konvertiert werden: %v\n",
+		"%s %s > Failed > error details: %s":                "%s %s > Fehlgeschlagen > Details: %s",
+		"%s %s > Converted > jpegs/%s %s":                   "%s %s > Konvertiert > jpegs/%s %s",
+		"\n%v Files":                                        "\n%v Dateien",
+		"Total Time Taken==%v":                              "Gesamtdauer==%v",
+		"Average Time Per File==%v":                         "Durchschnittliche Dauer pro Datei==%v",
+		"Total HEIC File Size==%s":                          "Gesamtgröße der HEIC-Dateien==%s",
+		"Total JPEG Folder Size==%s":                        "Gesamtgröße des JPEG-Ordners==%s",
+		"Invalid -symlink-names %q: must be link or target": "Ungültiges -symlink-names %q: erlaubt sind link oder target",
+		"Invalid -quality %d: must be between 1 and 100":    "Ungültige -quality %d: muss zwischen 1 und 100 liegen",
+		"another heictojpeg run (pid %d, started %s) is already converting into %s; wait for it to finish or use -wait": "ein anderer heictojpeg-Lauf (PID %d, gestartet %s) konvertiert bereits nach %s; warten Sie, bis er fertig ist, oder verwenden Sie -wait",
+		"another heictojpeg run is already converting into %s; wait for it to finish or use -wait":                      "ein anderer heictojpeg-Lauf konvertiert bereits nach %s; warten Sie, bis er fertig ist, oder verwenden Sie -wait",
+		"Waiting for the other run to finish...":                              "Warte auf das Ende des anderen Laufs...",
+		"Failed to remove lock file: %v\n":                                    "Sperrdatei konnte nicht entfernt werden: %v\n",
+		"Skipping broken symlink: %s\n":                                       "Defekter symbolischer Link übersprungen: %s\n",
+		"Skipping symlinked directory: %s\n":                                  "Verlinkter Ordner übersprungen: %s\n",
+		"Failed to read directory %s: %v\n":                                   "Ordner %s konnte nicht gelesen werden: %v\n",
+		"Skipping symlink loop: %s\n":                                         "Link-Schleife übersprungen: %s\n",
+		"Skipping mount point: %s\n":                                          "Einhängepunkt übersprungen: %s\n",
+		"Failed to show desktop notification: %v\n":                           "Benachrichtigung konnte nicht angezeigt werden: %v\n",
+		"Converted %d of %d files in %v":                                      "%d von %d Dateien in %v konvertiert",
+		"HEIC conversion finished with errors":                                "HEIC-Konvertierung mit Fehlern beendet",
+		"%s, %d failed. See jpegs/logs.txt.":                                  "%s, %d fehlgeschlagen. Siehe jpegs/logs.txt.",
+		"HEIC conversion finished":                                            "HEIC-Konvertierung beendet",
+		"Failed to send webhook report: %v\n":                                 "Bericht konnte nicht an den Webhook gesendet werden: %v\n",
+		"Failed to mail report: %v\n":                                         "Bericht konnte nicht per E-Mail gesendet werden: %v\n",
+		"Added %q to the context menu for .heic files and folders.\n":         "%q wurde zum Kontextmenü für .heic-Dateien und Ordner hinzugefügt.\n",
+		"Removed %q from the context menu.\n":                                 "%q wurde aus dem Kontextmenü entfernt.\n",
+		"Paused: running files finish, no new ones start. Press p to resume.": "Pausiert: laufende Dateien werden fertig, neue starten nicht. p drücken zum Fortsetzen.",
+		"Resumed.":                              "Fortgesetzt.",
+		"Nothing to skip.":                      "Nichts zu überspringen.",
+		"Aborting, the log is still written...": "Abbruch, das Protokoll wird trotzdem geschrieben...",
+		"Worker %c is idle.":                    "Prozess %c ist untätig.",
+		"running":                               "läuft",
+		"aborting":                              "Abbruch",
+		"PAUSED":                                "PAUSIERT",
+		"%d/%d files done, %d failed, %v elapsed": "%d/%d Dateien fertig, %d fehlgeschlagen, %v vergangen",
+		"Workers":      "Prozesse",
+		"(idle)":       "(untätig)",
+		"Recent files": "Letzte Dateien",
+		"FAILED: ":     "FEHLER: ",
+		"[p] pause/resume  [s] skip slowest  [1-9] skip worker's file  [q] abort": "[p] Pause/Weiter  [s] langsamste überspringen  [1-9] Datei des Prozesses überspringen  [q] Abbruch",
+		"%d of %d files converted, %d failed, in %v.\n":                           "%d von %d Dateien konvertiert, %d fehlgeschlagen, in %v.\n",
+		"Invalid -output %q: must be text or ndjson":                              "Ungültiges -output %q: erlaubt sind text oder ndjson",
+		"Already converted: %s\n":                                                 "Bereits konvertiert: %s\n",
+		"Failed to open the history: %v":                                          "Verlauf konnte nicht geöffnet werden: %v",
+		"Failed to save the history: %v\n":                                        "Verlauf konnte nicht gespeichert werden: %v\n",
+		"History: %s\n":                                                           "Verlauf: %s\n",
+		"Conversions: %d (%d distinct photos)\n":                                  "Konvertierungen: %d (%d verschiedene Fotos)\n",
+		"Between %s and %s\n":                                                     "Zwischen %s und %s\n",
+		"HEIC converted: %s, JPEG written: %s\n":                                  "HEIC konvertiert: %s, JPEG geschrieben: %s\n",
+		"Quality %d: %d conversions\n":                                            "Qualität %d: %d Konvertierungen\n",
+		"Sampling %d of %d files at quality %d...\n":                              "Stichprobe von %d aus %d Dateien mit Qualität %d...\n",
+		"Estimated JPEG size: %s for %s of HEIC (%.0f%%)\n":                       "Geschätzte JPEG-Größe: %s für %s HEIC (%.0f%%)\n",
+		"Estimated time: %v with %d workers\n":                                    "Geschätzte Dauer: %v mit %d Prozessen\n",
+		"Failed to read the config file: %v":                                      "Konfigurationsdatei konnte nicht gelesen werden: %v",
+		"Benchmarking with %s...\n":                                               "Leistungstest mit %s...\n",
+		"workers\tquality\tfiles/s\tJPEG size\t":                                  "Prozesse\tQualität\tDateien/s\tJPEG-Größe\t",
+		"Fastest: %d workers (%.2f files/s)\n":                                    "Am schnellsten: %d Prozesse (%.2f Dateien/s)\n",
+		"Saved workers=%d to %s\n":                                                "workers=%d in %s gespeichert\n",
+		"Allocated==%s in %d allocations":                                         "Zugewiesen==%s in %d Zuweisungen",
+		"Garbage Collections==%d (%v paused)":                                     "Speicherbereinigungen==%d (%v pausiert)",
+		"Heap Reserved==%s":                                                       "Reservierter Heap==%s",
+		"Invalid -order %q: must be name, size-asc, size-desc or mtime":           "Ungültiges -order %q: erlaubt sind name, size-asc, size-desc oder mtime",
+		"Paused (SIGUSR1); running files finish, send SIGUSR2 to resume.":         "Pausiert (SIGUSR1); laufende Dateien werden fertig, SIGUSR2 zum Fortsetzen senden.",
+		"Invalid -throttle %q: %v":                                                "Ungültiges -throttle %q: %v",
+		"on battery power and under thermal pressure":                             "im Akkubetrieb und unter thermischer Last",
+		"under thermal pressure":                                                  "unter thermischer Last",
+		"on battery power":                                                        "im Akkubetrieb",
+		"on mains power":                                                          "am Stromnetz",
+		"Running %s: using %d of %d workers.\n":                                   "Betrieb %s: %d von %d Prozessen werden verwendet.\n",
+		"Failed to quarantine %s: %v\n":                                           "%s konnte nicht in Quarantäne verschoben werden: %v\n",
+		"Invalid -quarantine %q: must be copy or move":                            "Ungültiges -quarantine %q: erlaubt sind copy oder move",
+		"%d files failed. Write %s with the first %d KB of each, the errors and system info (OS, CPU, settings; no image content beyond the headers)? [y/N] ": "%d Dateien fehlgeschlagen. %s mit den ersten %d KB jeder Datei, den Fehlern und Systemangaben (OS, CPU, Einstellungen; kein Bildinhalt außer den Kopfdaten) schreiben? [j/N] ",
+		"No bundle written.":                       "Kein Paket geschrieben.",
+		"Wrote %s; attach it to a GitHub issue.\n": "%s geschrieben; hängen Sie es an ein GitHub-Issue an.\n",
+		"Failed to write the failure bundle: %v\n": "Fehlerpaket konnte nicht geschrieben werden: %v\n",
+		"Converted %s with a warning: %v\n":        "%s mit einer Warnung konvertiert: %v\n",
+		" > Warning: %s":                           " > Warnung: %s",
+		"%s (%dx%d) needs %s to decode, over -max-memory; decoding it in bands.\n": "%s (%dx%d) benötigt %s zum Dekodieren, mehr als -max-memory; wird in Streifen dekodiert.\n",
+		"Warning: %s (%dx%d) needs %s to decode, over -max-memory.\n":              "Warnung: %s (%dx%d) benötigt %s zum Dekodieren, mehr als -max-memory.\n",
+		"Invalid -max-memory %q: %v":                                               "Ungültiges -max-memory %q: %v",
+		"Failed to split the output: %v\n":                                         "Ausgabe konnte nicht aufgeteilt werden: %v\n",
+		"Invalid -split-output %q: %v":                                             "Ungültiges -split-output %q: %v",
+		"Invalid -sanitize %q: must be fat32 or exfat":                             "Ungültiges -sanitize %q: erlaubt sind fat32 oder exfat",
+		"%d files done (still scanning), %d failed, %v elapsed":                    "%d Dateien fertig (Suche läuft noch), %d fehlgeschlagen, %v vergangen",
+		"Invalid -sort %q: must be path, size, duration or status":                 "Ungültiges -sort %q: erlaubt sind path, size, duration oder status",
+		"Failed to rotate the previous logs: %v\n":                                 "Die vorherigen Protokolle konnten nicht rotiert werden: %v\n",
+		"Failed to update history.csv: %v\n":                                       "history.csv konnte nicht aktualisiert werden: %v\n",
+		"Invalid -verbosity %q: must be quiet, normal, verbose or debug":           "Ungültiges -verbosity %q: erlaubt sind quiet, normal, verbose oder debug",
+		"Diagnostics for %s:\n":                                                    "Diagnose für %s:\n",
+		" > Took %v":                                                               " > Dauer %v",
+		"Invalid -metadata %q: must be keep or strip":                              "Ungültiges -metadata %q: erlaubt sind keep oder strip",
+		"Invalid -max-size %d: must be 0 or more pixels":                           "Ungültiges -max-size %d: muss 0 oder mehr Pixel sein",
+		"Skipped %s: %s\n":                                                         "Übersprungen %s: %s\n",
+		"%s %s > Skipped > %s":                                                     "%s %s > Übersprungen > %s",
+		"Invalid -screenshots %q: must be jpeg, png or skip":                       "Ungültiges -screenshots %q: erlaubt sind jpeg, png oder skip",
+		"Invalid -target-ssim %v: must be above 0 and at most 1":                   "Ungültiges -target-ssim %v: muss größer als 0 und höchstens 1 sein",
+		"Failed to compare %s: %v\n":                                               "%s konnte nicht verglichen werden: %v\n",
+		"Failed to create the comparison folder: %v":                               "Der Vergleichsordner konnte nicht erstellt werden: %v",
+		"Failed to write compare.csv: %v\n":                                        "compare.csv konnte nicht geschrieben werden: %v\n",
+		"Invalid -dedupe %q: must be skip or link":                                 "Ungültiges -dedupe %q: muss skip oder link sein",
+		"Invalid -dedupe-distance %d: must be from 0 to 64":                        "Ungültiges -dedupe-distance %d: muss zwischen 0 und 64 liegen",
+		"Failed to index -dedupe-library: %v":                                      "-dedupe-library konnte nicht indiziert werden: %v",
+		"Indexed %d JPEGs in %s\n":                                                 "%d JPEGs in %s indiziert\n",
+		"Skipping %s in the library: %v\n":                                         "%s in der Bibliothek übersprungen: %v\n",
+		"Failed to hash %s: %v\n":                                                  "Hash von %s konnte nicht berechnet werden: %v\n",
+		"Failed to write hashes.csv: %v\n":                                         "hashes.csv konnte nicht geschrieben werden: %v\n",
+		"Invalid -crop: %v":                                                        "Ungültiges -crop: %v",
+		"Invalid -crop-focus %q: must be center or subject":                        "Ungültiges -crop-focus %q: muss center oder subject sein",
+		" > EXIF orientation %d: may display rotated in strict viewers":            " > EXIF-Ausrichtung %d: wird in strengen Betrachtern eventuell gedreht angezeigt",
+		"Failed to copy the sidecar metadata of %s: %v\n":                          "Metadaten der Begleitdatei von %s konnten nicht übernommen werden: %v\n",
+		"Converted %d more frames of %s\n":                                         "%d weitere Bilder von %s konvertiert\n",
+		"-best-frame and -all-frames can't be used together":                       "-best-frame und -all-frames können nicht zusammen verwendet werden",
+		"not HEVC":                                                "kein HEVC",
+		"already transcoded":                                      "bereits umgewandelt",
+		"Transcoding video: %s\n":                                 "Video wird umgewandelt: %s\n",
+		"Failed to transcode %s: %v\n":                            "%s konnte nicht umgewandelt werden: %v\n",
+		"%s %s > Transcoded > jpegs/%s %s":                        "%s %s > Umgewandelt > jpegs/%s %s",
+		"\nVideos:":                                               "\nVideos:",
+		"%d videos transcoded, %d failed (%s to %s)":              "%d Videos umgewandelt, %d fehlgeschlagen (%s zu %s)",
+		"Invalid -video-crf %d: must be between 0 and 51":         "Ungültiges -video-crf %d: muss zwischen 0 und 51 liegen",
+		"-videos needs ffmpeg: %v":                                "-videos benötigt ffmpeg: %v",
+		"Skipping %s: %v\n":                                       "%s wird übersprungen: %v\n",
+		"No files to convert.":                                    "Keine Dateien zu konvertieren.",
+		"Failed to read the file list: %v":                        "Dateiliste konnte nicht gelesen werden: %v",
+		"Invalid -files %q: only - (standard input) is supported": "Ungültiges -files %q: nur - (Standardeingabe) wird unterstützt",
+		"-files - can't be combined with files or folders on the command line": "-files - kann nicht mit Dateien oder Ordnern auf der Befehlszeile kombiniert werden",
+		"-pipes is not supported on Windows":                                   "-pipes wird unter Windows nicht unterstützt",
+		"-pipes needs -output ndjson to announce the pipes":                    "-pipes benötigt -output ndjson, um die Pipes anzukündigen",
+		"-pipes can't be used with -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes or -split-output, which need the JPEG files": "-pipes kann nicht mit -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes oder -split-output verwendet werden, die die JPEG-Dateien brauchen",
+		"Requeued %d unfinished jobs\n":                 "%d unfertige Aufträge wieder eingereiht\n",
+		"Waiting for jobs on %s at %s\n":                "Warte auf Aufträge in %s auf %s\n",
+		"Invalid job %q: %v\n":                          "Ungültiger Auftrag %q: %v\n",
+		"Downloading %d files...\n":                     "Lade %d Dateien herunter...\n",
+		"Failed to download %s: %v\n":                   "%s konnte nicht heruntergeladen werden: %v\n",
+		"Failed to cache %s: %v\n":                      "%s konnte nicht zwischengespeichert werden: %v\n",
+		"Invalid -cache-size %q: %v":                    "Ungültiges -cache-size %q: %v",
+		"Failed to open the cache: %v":                  "Cache konnte nicht geöffnet werden: %v",
+		"Listening on %s\n":                             "Lausche auf %s\n",
+		"%s %s from %s: %d, %s in %s\n":                 "%s %s von %s: %d, %s in %s\n",
+		"Self-test failed, /readyz stays unready: %v\n": "Selbsttest fehlgeschlagen, /readyz bleibt nicht bereit: %v\n",
+		"heictojpeg %s is up to date\n":                 "heictojpeg %s ist aktuell\n",
+		"heictojpeg %s is available (this is %s)\n":     "heictojpeg %s ist verfügbar (aktuell %s)\n",
+		"Downloading heictojpeg %s...\n":                "Lade heictojpeg %s herunter...\n",
+		"Updated heictojpeg from %s to %s\n":            "heictojpeg von %s auf %s aktualisiert\n",
+		"Kept %s: %v\n":                                 "%s behalten: %v\n",
+		"-pipes can't be used with -delete-originals, which needs the JPEG files":                                "-pipes kann nicht mit -delete-originals verwendet werden, das die JPEG-Dateien braucht",
+		"Saved the settings to %s; run heictojpeg setup to change them.\n":                                       "Einstellungen in %s gespeichert; mit heictojpeg setup ändern.\n",
+		"Welcome to heictojpeg! A few questions to get started; press Enter to keep the suggestion in brackets.": "Willkommen bei heictojpeg! Ein paar Fragen zum Start; Eingabetaste übernimmt den Vorschlag in Klammern.",
+		"Folder with the HEIC photos":                                      "Ordner mit den HEIC-Fotos",
+		"%s is not a folder":                                               "%s ist kein Ordner",
+		"JPEG quality from 1 to 100, higher is better and larger":          "JPEG-Qualität von 1 bis 100; höher ist besser und größer",
+		"Invalid quality %q: must be between 1 and 100\n":                  "Ungültige Qualität %q: muss zwischen 1 und 100 liegen\n",
+		"Delete the HEIC files once they are converted? (y/n)":             "HEIC-Dateien nach der Umwandlung löschen? (j/n)",
+		"Failed to write the journal: %v\n":                                "Journal konnte nicht geschrieben werden: %v\n",
+		"Failed to start the journal, so this run can't be undone: %v\n":   "Journal konnte nicht angelegt werden, dieser Lauf lässt sich nicht rückgängig machen: %v\n",
+		"Undoing the run of %s in %s\n":                                    "Lauf vom %s in %s wird rückgängig gemacht\n",
+		"Delete %s\n":                                                      "%s löschen\n",
+		"Move %s back to %s\n":                                             "%s zurück nach %s verschieben\n",
+		"Can't restore %s: it was deleted without -archive-dir\n":          "%s kann nicht wiederhergestellt werden: ohne -archive-dir gelöscht\n",
+		"Failed: %v\n":                                                     "Fehlgeschlagen: %v\n",
+		"Invalid -space-check %q: must be refuse, warn or off":             "Ungültiges -space-check %q: muss refuse, warn oder off sein",
+		"Warning: the JPEGs may need about %s but only %s is free in %s\n": "Warnung: Die JPEGs brauchen etwa %s, aber nur %s sind frei in %s\n",
+		"not enough space in %s: the JPEGs may need about %s but only %s is free; free up space, or use -space-check warn to start anyway": "nicht genug Platz in %s: Die JPEGs brauchen etwa %s, aber nur %s sind frei; Platz schaffen oder mit -space-check warn trotzdem starten",
+		"Free space: %s\n":              "Freier Speicher: %s\n",
+		"Warning: the JPEGs won't fit.": "Warnung: Die JPEGs passen nicht.",
+		"The disk is full. Paused: the remaining files need about %s more in %s, and %s is free.\n": "Der Datenträger ist voll. Pausiert: Die restlichen Dateien brauchen noch etwa %s in %s, frei sind %s.\n",
+		"Free up space, then press Enter to resume, or Ctrl+C to stop.":                             "Platz schaffen und dann die Eingabetaste zum Fortsetzen drücken, oder Strg+C zum Beenden.",
+		"The run resumes once there is enough free space.":                                          "Der Lauf wird fortgesetzt, sobald genug Platz frei ist.",
+		"-read-only needs -out, a folder outside the photos for the JPEGs":                          "-read-only braucht -out, einen Ordner außerhalb der Fotos für die JPEGs",
+		"-read-only can't be used with %s":                                                          "-read-only kann nicht mit %s verwendet werden",
+		"-read-only: -out %s must be outside %s":                                                    "-read-only: -out %s muss außerhalb von %s liegen",
+		"outside -out with -read-only":                                                              "außerhalb von -out mit -read-only",
+		"Failed to copy the permissions of %s: %v\n":                                                "Berechtigungen von %s konnten nicht übernommen werden: %v\n",
+		"-keep-owner is not supported on Windows":                                                   "-keep-owner wird unter Windows nicht unterstützt",
+		"Failed to copy the tags of %s: %v\n":                                                       "Die Tags von %s konnten nicht kopiert werden: %v\n",
+		"Failed to make %s searchable: %v\n":                                                        "%s konnte nicht durchsuchbar gemacht werden: %v\n",
+		"Failed to index %s: %v\n":                                                                  "%s konnte nicht indiziert werden: %v\n",
+		"-in-place can't be used with -out, -organize-by-location, -split-output or -pipes, which need a separate output folder": "-in-place kann nicht mit -out, -organize-by-location, -split-output oder -pipes verwendet werden, die einen eigenen Ausgabeordner brauchen",
+		"usage: heictojpeg sync [options] SRC DST": "Aufruf: heictojpeg sync [Optionen] QUELLE ZIEL",
+		"Source gone: %s\n":                        "Quelle entfernt: %s\n",
+		"%d JPEGs in %s have no HEIC any more; run again with -delete to remove them.\n": "%d JPEGs in %s haben kein HEIC mehr; mit -delete erneut ausführen, um sie zu entfernen.\n",
+		"Removed %s\n":                                                   "Entfernt: %s\n",
+		"Missing, no JPEG in %s:\n":                                      "Fehlend, kein JPEG in %s:\n",
+		"Orphaned, no HEIC in %s:\n":                                     "Verwaist, kein HEIC in %s:\n",
+		"Stale, the HEIC in %s is newer:\n":                              "Veraltet, das HEIC in %s ist neuer:\n",
+		"%d up to date, %d missing, %d orphaned, %d stale\n":             "%d aktuell, %d fehlend, %d verwaist, %d veraltet\n",
+		"Invalid -schedule %q: %v":                                       "Ungültiges -schedule %q: %v",
+		"-schedule can't be used with -files or -tui":                    "-schedule kann nicht mit -files oder -tui verwendet werden",
+		"-schedule never matches":                                        "-schedule trifft nie zu",
+		"Next run at %s\n":                                               "Nächster Lauf: %s\n",
+		"Scheduled run failed: %v\n":                                     "Geplanter Lauf fehlgeschlagen: %v\n",
+		"Installed the %s service, running: %s\n":                        "Der Dienst %s wurde installiert und führt aus: %s\n",
+		"Removed the %s service.\n":                                      "Der Dienst %s wurde entfernt.\n",
+		"the service needs -schedule, or it would convert once and stop": "der Dienst braucht -schedule, sonst würde er einmal konvertieren und dann enden",
+		"The %s service isn't installed.\n":                              "Der Dienst %s ist nicht installiert.\n",
+		"-run-as is not supported on Windows":                            "-run-as wird unter Windows nicht unterstützt",
+		"Failed to switch to the -run-as user: %v":                       "Wechsel zum Benutzer von -run-as fehlgeschlagen: %v",
+		"8-bit HEIC":                 "8-Bit-HEIC",
+		"no HEIC photo will convert": "kein HEIC-Foto wird konvertiert",
+		"10-bit HEIC":                "10-Bit-HEIC",
+		"10-bit photos, such as HDR shots and those of some Android phones, won't convert correctly": "10-Bit-Fotos, etwa HDR-Aufnahmen und die mancher Android-Handys, werden nicht korrekt konvertiert",
+		"Tiled (grid) HEIC": "Gekacheltes HEIC (grid)",
+		"photos from iPhones and most cameras, stored as tiles, won't convert": "Fotos von iPhones und den meisten Kameras, als Kacheln gespeichert, werden nicht konvertiert",
+		"Multi-image HEIC": "HEIC mit mehreren Bildern",
+		"-best-frame and -all-frames won't work on bursts": "-best-frame und -all-frames funktionieren bei Serienaufnahmen nicht",
+		"%-20s supported\n":                                                 "%-20s unterstützt\n",
+		"%-20s not supported (%s): %s\n":                                    "%-20s nicht unterstützt (%s): %s\n",
+		"Invalid -sample %d: must be 0 or more files":                       "Ungültiges -sample %d: muss 0 oder mehr Dateien sein",
+		"Converting %d random files of %d at quality %d...\n":               "Konvertiere %d zufällige von %d Dateien mit Qualität %d...\n",
+		"The samples are in %s\n":                                           "Die Proben liegen in %s\n",
+		"Opened %s\n":                                                       "%s geöffnet\n",
+		"Open %s in your browser to convert photos\n":                       "Öffne %s im Browser, um Fotos umzuwandeln\n",
+		"Opened %s in your browser; stop with Ctrl+C\n":                     "%s im Browser geöffnet; beenden mit Strg+C\n",
+		"format\tquality\tsize\tof HEIC\tPSNR\tSSIM\t":                      "Format\tQualität\tGröße\tvom HEIC\tPSNR\tSSIM\t",
+		"Wrote the variants and %s to %s\n":                                 "Varianten und %s nach %s geschrieben\n",
+		"EXIF==%d copied, %d repaired, %d stripped, %d missing, %d dropped": "EXIF==%d kopiert, %d repariert, %d entfernt, %d fehlend, %d verworfen",
+		"%s has a %s color profile, which can be neither converted nor embedded: its colors will look off\n": "%s hat ein %s-Farbprofil, das sich weder umrechnen noch einbetten lässt: die Farben wirken verfälscht\n",
+		" > %s color profile dropped: colors may look off":                                                   " > %s-Farbprofil verworfen: Farben können verfälscht wirken",
+		"Color profiles==%d converted, %d embedded, %d dropped":                                              "Farbprofile==%d umgerechnet, %d eingebettet, %d verworfen",
+		"Invalid -color-target %q: must be srgb, p3 or keep":                                                 "Ungültiges -color-target %q: erlaubt sind srgb, p3 oder keep",
+		"Invalid -bit-depth %q: must be 8, 16 or keep":                                                       "Ungültiges -bit-depth %q: erlaubt sind 8, 16 oder keep",
+		"Invalid -deep-format %q: must be png or tiff":                                                       "Ungültiges -deep-format %q: erlaubt sind png oder tiff",
+		"Invalid -alpha %q: must be flatten:#rrggbb, png or drop":                                            "Ungültiges -alpha %q: erlaubt sind flatten:#rrggbb, png oder drop",
+		"%s: %d×%d, not tiled\n":                                                                             "%s: %d×%d, nicht gekachelt\n",
+		"%s: %d×%d in %d columns and %d rows of %d×%d tiles, numbered from the top left:\n":                  "%s: %d×%d in %d Spalten und %d Zeilen aus Kacheln von %d×%d, nummeriert von links oben:\n",
+		"The tiles don't fit the image: %v\n":                                                                "Die Kacheln passen nicht zum Bild: %v\n",
+		"Wrote %s\n":                                                                                         "%s geschrieben\n",
+		"-preview only works with -dry-run":                                                                  "-preview funktioniert nur mit -dry-run",
+		"No preview of %s: %v\n":                                                                             "Keine Vorschau von %s: %v\n",
+		"%d of %d files would be converted, nothing was written.\n":                                          "%d von %d Dateien würden umgewandelt, nichts wurde geschrieben.\n",
+		"Drew %d previews into %d contact sheets in %v, %d to a sheet in the order listed.\n":                "%d Vorschauen auf %d Kontaktbögen in %v gezeichnet, %d pro Bogen in der aufgeführten Reihenfolge.\n",
+		"The contact sheets are in %s\n":                                                                     "Die Kontaktbögen liegen in %s\n",
+		"Invalid -extra-outputs %q: %v":                                                                      "Ungültiges -extra-outputs %q: %v",
+		"-extra-outputs and -profiles can't be used with -pipes, which has no files to write them beside":    "-extra-outputs und -profiles können nicht mit -pipes verwendet werden, da es keine Dateien gibt, neben die sie geschrieben werden könnten",
+		"%s is decoded in bands, so only its JPEG is written, without -extra-outputs or -profiles\n":         "%s wird in Streifen decodiert, daher wird nur sein JPEG geschrieben, ohne -extra-outputs oder -profiles\n",
+		"Invalid -profiles %q: %v":                                                                           "Ungültiges -profiles %q: %v",
+		"Failed to write manifest.json: %v\n":                                                                "manifest.json konnte nicht geschrieben werden: %v\n",
+		"-manifest can't be used with -pipes or -split-output, which leave no outputs where it lists them":   "-manifest kann nicht mit -pipes oder -split-output verwendet werden, die die Dateien nicht dort lassen, wo es sie aufführt",
+		"Failed to make a thumbnail of %s: %v\n":                                                             "Vorschaubild von %s konnte nicht erstellt werden: %v\n",
+		"Wrote %s with %d photos\n":                                                                          "%s mit %d Fotos geschrieben\n",
+		"Failed to update the feed: %v\n":                                                                    "Feed konnte nicht aktualisiert werden: %v\n",
+		"Invalid -feed %q: %v":                                                                               "Ungültiges -feed %q: %v",
+		"-feed can't be used with -pipes or -split-output, which leave no photos where it links to them":     "-feed kann nicht mit -pipes oder -split-output verwendet werden, die keine Fotos dort lassen, wo er auf sie verweist",
+		"Invalid -feed-entries %d: must be 1 or more":                                                        "Ungültiges -feed-entries %d: muss 1 oder mehr sein",
+		"Failed to publish to the MQTT broker, skipping its events until the next run: %v\n":                 "Veröffentlichen beim MQTT-Broker fehlgeschlagen, seine Ereignisse werden bis zum nächsten Lauf übersprungen: %v\n",
+		"Invalid -mqtt %q: %v":                                                                               "Ungültiges -mqtt %q: %v",
+		"Invalid -mqtt-qos %d: must be 0 or 1":                                                               "Ungültiges -mqtt-qos %d: muss 0 oder 1 sein",
+		"Invalid -mqtt-topic %q: must be a topic without the wildcards + and #":                              "Ungültiges -mqtt-topic %q: muss ein Topic ohne die Platzhalter + und # sein",
+		"Without -bot-users, the bot converts for anyone who finds it.":                                      "Ohne -bot-users konvertiert der Bot für jeden, der ihn findet.",
+		"Telegram bot @%s is running\n":                                                                      "Telegram-Bot @%s läuft\n",
+		"Telegram: %v\n":                                                                                     "Telegram: %v\n",
+		"Ignored a file from %s: %v\n":                                                                       "Datei von %s ignoriert: %v\n",
+		"Sorry, I only convert photos for the people I was set up for.":                                      "Tut mir leid, ich konvertiere Fotos nur für die Personen, für die ich eingerichtet wurde.",
+		"Telegram already made that photo a JPEG. To convert a HEIC photo, send it as a file.":               "Telegram hat dieses Foto bereits in ein JPEG umgewandelt. Um ein HEIC-Foto zu konvertieren, sende es als Datei.",
+		"Send me a HEIC photo as a file and I'll send it back as a JPEG.":                                    "Sende mir ein HEIC-Foto als Datei und ich schicke es als JPEG zurück.",
+		"%s is over the %s Telegram lets bots download.":                                                     "%s ist größer als die %s, die Telegram Bots herunterladen lässt.",
+		"Failed to convert %s from %s: %v\n":                                                                 "%s von %s konnte nicht konvertiert werden: %v\n",
+		"Couldn't convert %s: %v":                                                                            "%s konnte nicht konvertiert werden: %v",
+		"Failed to send the JPEG of %s to %s: %v\n":                                                          "Das JPEG von %s konnte nicht an %s gesendet werden: %v\n",
+		"Discord interactions endpoint listening on %s\n":                                                    "Discord-Interaktionsendpunkt lauscht auf %s\n",
+		"Failed to send the JPEGs to %s: %v\n":                                                               "Die JPEGs konnten nicht an %s gesendet werden: %v\n",
+		"Watching %s on %s every %v\n":                                                                       "Überwache %s auf %s alle %v\n",
+		"IMAP: %v\n":                                                                                         "IMAP: %v\n",
+		"Failed to convert %s from %s\n":                                                                     "%s von %s konnte nicht konvertiert werden\n",
+		"Skipped a message from %s: %v\n":                                                                    "Nachricht von %s übersprungen: %v\n",
+		"Converted a message from %s: %d JPEGs\n":                                                            "Nachricht von %s konvertiert: %d JPEGs\n",
+		"unknown sender": "unbekannter Absender",
+		"Watching the clipboard for HEIC images; press Ctrl+C to stop": "Überwache die Zwischenablage auf HEIC-Bilder; Strg+C zum Beenden",
+		"Failed to convert the clipboard: %v\n":                        "Die Zwischenablage konnte nicht konvertiert werden: %v\n",
+		"Converted the clipboard's HEIC image to %s (%s)\n":            "HEIC-Bild der Zwischenablage in %s konvertiert (%s)\n",
+		"Replaced the HEIC image on the clipboard with a JPEG (%s)\n":  "HEIC-Bild in der Zwischenablage durch ein JPEG ersetzt (%s)\n",
+		"Converting %s into %s\n":                                      "Konvertiere %s nach %s\n",
+		"polling every %v (%s is a %s mount)":                          "Abfrage alle %v (%s ist ein %s-Mount)",
+		"polling every %v":                                             "Abfrage alle %v",
+		"change notifications":                                         "Änderungsbenachrichtigungen",
+		"Watching %s for new photos, %s\n":                             "Überwache %s auf neue Fotos, %s\n",
+		"Failed to convert the new photos: %v\n":                       "Die neuen Fotos konnten nicht konvertiert werden: %v\n",
+		"Failed to scan %s: %v\n":                                      "%s konnte nicht durchsucht werden: %v\n",
+		"Failed to watch %s: %v\n":                                     "%s konnte nicht überwacht werden: %v\n",
+		"Too many changes at once, some new photos may have been missed; they are converted on the next start": "Zu viele Änderungen auf einmal, einige neue Fotos wurden eventuell übersehen; sie werden beim nächsten Start konvertiert",
+		"Invalid -watch-mode %q: must be auto, notify or poll":                                                 "Ungültiger -watch-mode %q: muss auto, notify oder poll sein",
+		"Invalid -watch-interval %v: must be 1s or more":                                                       "Ungültiges -watch-interval %v: muss mindestens 1s sein",
+		"-watch can't be used with -schedule, -files, -tui or files and folders on the command line":           "-watch kann nicht mit -schedule, -files, -tui oder Dateien und Ordnern auf der Befehlszeile verwendet werden",
+		"Invalid -settle %v: must be 0 or more":                                                                "Ungültiges -settle %v: muss 0 oder mehr sein",
+		"Ignoring %s: there is no option -%s\n":                                                                "%s wird ignoriert: es gibt keine Option -%s\n",
+		"Converting files in %s...":                                                                            "Dateien in %s werden umgewandelt...",
+		"Converting %d files in %s...":                                                                         "%d Dateien in %s werden umgewandelt...",
+		"Converted":                                                                                            "Umgewandelt",
+		"Failed: %v":                                                                                           "Fehlgeschlagen: %v",
+		"%d files done":                                                                                        "%d Dateien fertig",
+		"%d of %d files done":                                                                                  "%d von %d Dateien fertig",
+		"Done: converted %d of %d files in %v (%d failed). The JPEGs are in %s.":                               "Fertig: %d von %d Dateien in %v umgewandelt (%d fehlgeschlagen). Die JPEGs liegen in %s.",
+		"HEIC to JPEG":                                                                                         "HEIC zu JPEG",
+		"Drag a folder with .heic photos onto this window, or choose one:":                                     "Ziehen Sie einen Ordner mit .heic-Fotos in dieses Fenster oder wählen Sie einen aus:",
+		"Choose folder...":                                                                                     "Ordner wählen...",
+		"Choose a folder with .heic photos":                                                                    "Wählen Sie einen Ordner mit .heic-Fotos",
+		"JPEG quality:":                                                                                        "JPEG-Qualität:",
+		"Include subfolders":                                                                                   "Unterordner einbeziehen",
+		"File":                                                                                                 "Datei",
+		"Status":                                                                                               "Status",
+		"Size":                                                                                                 "Größe",
+		"Waiting for a folder...":                                                                              "Warte auf einen Ordner...",
+	},
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	for in, want := range map[string]string{"es_ES.UTF-8": "es", "fr-CA": "fr", "DE": "de", "en": "en", "": ""} {
+		if got := normalizeLanguage(in); got != want {
+			t.Errorf("normalizeLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer setLanguage("en")
+
+	setLanguage("es_ES.UTF-8")
+	if got := tr("Program completed!"); got != "¡Programa completado!" {
+		t.Errorf("got %q", got)
+	}
+	setLanguage("xx")
+	if got := tr("Program completed!"); got != "Program completed!" {
+		t.Errorf("unknown language should fall back to English, got %q", got)
+	}
+}
+
+func TestCatalogsKeepFormatVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]|\n$`)
+	for lang, catalog := range catalogs {
+		for en, translated := range catalog {
+			if a, b := verbs.FindAllString(en, -1), verbs.FindAllString(translated, -1); len(a) != len(b) {
+				t.Errorf("%s: %q has verbs %v, translation %q has %v", lang, en, a, translated, b)
+			} else {
+				for i := range a {
+					if a[i] != b[i] {
+						t.Errorf("%s: verb order differs in %q", lang, translated)
+					}
+				}
+			}
+		}
+		if len(catalog) != len(catalogs["es"]) {
+			t.Errorf("%s has %d messages, es has %d", lang, len(catalog), len(catalogs["es"]))
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package main
+
+func systemLanguage() string {
+	return localeFromEnv()
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const localeNameMaxLength = 85
+
+var procGetUserDefaultLocaleName = syscall.NewLazyDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+
+// systemLanguage asks Windows for the user's locale (e.g. "es-ES"); LANG
+// still wins when set, as in MSYS or Cygwin shells.
+func systemLanguage() string {
+	if locale := localeFromEnv(); locale != "" {
+		return locale
+	}
+	buf := make([]uint16, localeNameMaxLength)
+	r, _, _ := procGetUserDefaultLocaleName.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if r == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf)
+}
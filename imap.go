@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const imapPasswordEnv = "HEICTOJPEG_IMAP_PASSWORD"
+
+// imapConn is a connection speaking IMAP4rev1, with just enough of it for
+// the inbox watcher: one folder, searching, fetching, flagging and
+// appending messages.
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is an untagged response line, with its literals taken out
+// of the text: the {n} markers stay, the n bytes go to literals.
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+// parseIMAPURL checks the -imap URL and returns the folder it names, INBOX
+// by default.
+func parseIMAPURL(raw string) (*url.URL, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Scheme != "imap" && u.Scheme != "imaps" {
+		return nil, "", errors.New("must be imaps://user@host/folder, or imap:// without TLS")
+	}
+	if u.Hostname() == "" {
+		return nil, "", errors.New("has no host")
+	}
+	if u.User == nil {
+		return nil, "", errors.New("has no user name, as in imaps://user@host")
+	}
+	folder := strings.Trim(u.Path, "/")
+	if folder == "" {
+		folder = "INBOX"
+	}
+	return u, folder, nil
+}
+
+// dialIMAP connects to the server of u and logs in as its user, with the
+// URL's password or else HEICTOJPEG_IMAP_PASSWORD.
+func dialIMAP(ctx context.Context, u *url.URL) (*imapConn, error) {
+	addr := u.Host
+	if u.Port() == "" {
+		port := "143"
+		if u.Scheme == "imaps" {
+			port = "993"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if u.Scheme == "imaps" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	greeting, err := c.read()
+	if err == nil && !strings.HasPrefix(greeting.text, "* OK") {
+		err = fmt.Errorf("server said %q", greeting.text)
+	}
+	if err == nil {
+		password, ok := u.User.Password()
+		if !ok {
+			password = os.Getenv(imapPasswordEnv)
+		}
+		_, err = c.do("LOGIN " + imapQuote(u.User.Username()) + " " + imapQuote(password))
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// read reads a response line and the literals in it.
+func (c *imapConn) read() (imapResponse, error) {
+	var r imapResponse
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return r, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		r.text += line
+		// A literal ends its line with {n}, and the line goes on after it.
+		open := strings.LastIndexByte(line, '{')
+		if open < 0 || !strings.HasSuffix(line, "}") {
+			return r, nil
+		}
+		n, err := strconv.Atoi(line[open+1 : len(line)-1])
+		if err != nil || n < 0 {
+			return r, nil
+		}
+		literal := make([]byte, n)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return r, err
+		}
+		r.literals = append(r.literals, literal)
+	}
+}
+
+// do sends a command and returns its untagged responses, or the server's
+// reason when it doesn't answer OK. literal, when given, is sent after
+// the command, which must end in its {n}, once the server asks for it.
+func (c *imapConn) do(command string, literal ...[]byte) ([]imapResponse, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	c.conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, command); err != nil {
+		return nil, err
+	}
+	verb := strings.Fields(command)[0]
+	if verb == "UID" {
+		verb = strings.Join(strings.Fields(command)[:2], " ")
+	}
+	var untagged []imapResponse
+	for {
+		r, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(r.text, "+"):
+			if len(literal) == 0 {
+				return nil, fmt.Errorf("%s: unexpected continuation %q", verb, r.text)
+			}
+			if _, err := c.conn.Write(append(literal[0], "\r\n"...)); err != nil {
+				return nil, err
+			}
+			literal = literal[1:]
+		case strings.HasPrefix(r.text, tag+" "):
+			status := strings.TrimPrefix(r.text, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("%s: %s", verb, status)
+			}
+			return untagged, nil
+		default:
+			untagged = append(untagged, r)
+		}
+	}
+}
+
+// selectFolder opens folder and reports whether messages in it can have
+// keywords of our own, from its PERMANENTFLAGS.
+func (c *imapConn) selectFolder(folder string) (bool, error) {
+	responses, err := c.do("SELECT " + imapQuote(folder))
+	if err != nil {
+		return false, err
+	}
+	for _, r := range responses {
+		if strings.Contains(r.text, "[PERMANENTFLAGS") {
+			return strings.Contains(r.text, `\*`), nil
+		}
+	}
+	return false, nil
+}
+
+// search returns the UIDs of the messages matching criteria.
+func (c *imapConn) search(criteria string) ([]uint32, error) {
+	responses, err := c.do("UID SEARCH " + criteria)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, r := range responses {
+		if !strings.HasPrefix(r.text, "* SEARCH") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(r.text, "* SEARCH")) {
+			if uid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				uids = append(uids, uint32(uid))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// fetch returns the whole message uid, leaving it unread.
+func (c *imapConn) fetch(uid uint32) ([]byte, error) {
+	responses, err := c.do(fmt.Sprintf("UID FETCH %d BODY.PEEK[]", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range responses {
+		if strings.Contains(r.text, " FETCH ") && len(r.literals) > 0 {
+			return r.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message %d is gone", uid)
+}
+
+// flag adds flag, a keyword or a system flag like \Seen, to message uid.
+func (c *imapConn) flag(uid uint32, flag string) error {
+	_, err := c.do(fmt.Sprintf("UID STORE %d +FLAGS.SILENT (%s)", uid, flag))
+	return err
+}
+
+// appendMessage adds msg to folder, unread.
+func (c *imapConn) appendMessage(folder string, msg []byte) error {
+	_, err := c.do(fmt.Sprintf("APPEND %s () {%d}", imapQuote(folder), len(msg)), msg)
+	return err
+}
+
+func (c *imapConn) logout() {
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	c.do("LOGOUT")
+	c.conn.Close()
+}
+
+// imapQuote makes s a quoted string.
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
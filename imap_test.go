@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeIMAP is an IMAP server with one user and a folder of messages, which
+// answers the commands imapConn sends.
+type fakeIMAP struct {
+	ln       net.Listener
+	keywords bool // whether PERMANENTFLAGS allows \*
+
+	mu       sync.Mutex
+	messages []*imapMessage
+	appended map[string][][]byte
+}
+
+type imapMessage struct {
+	uid   uint32
+	raw   []byte
+	flags []string
+}
+
+func newFakeIMAP(t *testing.T, keywords bool, messages ...string) *fakeIMAP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIMAP{ln: ln, keywords: keywords, appended: map[string][][]byte{}}
+	for i, m := range messages {
+		f.messages = append(f.messages, &imapMessage{uid: uint32(i + 1), raw: []byte(m)})
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeIMAP) url() string {
+	return "imap://office@" + f.ln.Addr().String() + "/INBOX"
+}
+
+func (f *fakeIMAP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		reply := f.handle(conn, r, command)
+		fmt.Fprintf(conn, "%s %s\r\n", tag, reply)
+		if command == "LOGOUT" {
+			return
+		}
+	}
+}
+
+func (f *fakeIMAP) handle(conn net.Conn, r *bufio.Reader, command string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fields := strings.Fields(command)
+	switch {
+	case fields[0] == "LOGIN":
+		if command != `LOGIN "office" "secret"` {
+			return "NO [AUTHENTICATIONFAILED] Invalid credentials"
+		}
+	case fields[0] == "SELECT":
+		flags := `\Seen \Deleted`
+		if f.keywords {
+			flags += ` \*`
+		}
+		fmt.Fprintf(conn, "* %d EXISTS\r\n* OK [PERMANENTFLAGS (%s)] Limited\r\n", len(f.messages), flags)
+		return "OK [READ-WRITE] SELECT completed"
+	case command == "UID SEARCH UNKEYWORD "+mailKeyword, command == `UID SEARCH UNSEEN`:
+		want := mailKeyword
+		if fields[2] == "UNSEEN" {
+			want = `\Seen`
+		}
+		var uids []string
+		for _, m := range f.messages {
+			if !strings.Contains(strings.Join(m.flags, " "), want) {
+				uids = append(uids, strconv.Itoa(int(m.uid)))
+			}
+		}
+		fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+	case fields[0] == "UID" && fields[1] == "FETCH":
+		if m := f.message(fields[2]); m != nil {
+			fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", m.uid, m.uid, len(m.raw), m.raw)
+		}
+	case fields[0] == "UID" && fields[1] == "STORE":
+		if m := f.message(fields[2]); m != nil {
+			m.flags = append(m.flags, strings.Trim(fields[4], "()"))
+		}
+	case fields[0] == "APPEND":
+		n, _ := strconv.Atoi(strings.Trim(fields[len(fields)-1], "{}"))
+		fmt.Fprint(conn, "+ Ready for literal data\r\n")
+		msg := make([]byte, n+2)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return "BAD literal"
+		}
+		folder := strings.Trim(fields[1], `"`)
+		f.appended[folder] = append(f.appended[folder], msg[:n])
+	case fields[0] == "LOGOUT":
+		fmt.Fprint(conn, "* BYE\r\n")
+	default:
+		return "BAD unknown command"
+	}
+	return "OK completed"
+}
+
+func (f *fakeIMAP) message(uid string) *imapMessage {
+	for _, m := range f.messages {
+		if strconv.Itoa(int(m.uid)) == uid {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestParseIMAPURL(t *testing.T) {
+	for raw, want := range map[string]string{
+		"imaps://me@mail.example.com":                "INBOX",
+		"imaps://me@mail.example.com/Clients/Photos": "Clients/Photos",
+		"imap://me:pw@localhost:1143/INBOX":          "INBOX",
+		"https://me@mail.example.com":                "",
+		"imaps://mail.example.com":                   "",
+		"imaps://me@/INBOX":                          "",
+	} {
+		_, folder, err := parseIMAPURL(raw)
+		if folder != want || (err == nil) != (want != "") {
+			t.Errorf("parseIMAPURL(%q) = %q, %v; want %q", raw, folder, err, want)
+		}
+	}
+}
+
+func TestIMAPConn(t *testing.T) {
+	f := newFakeIMAP(t, true, "Subject: one\r\n\r\nfirst\r\n", "Subject: two\r\n\r\nsecond\r\n")
+	t.Setenv(imapPasswordEnv, "secret")
+	u, folder, err := parseIMAPURL(f.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dialIMAP(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keywords, err := c.selectFolder(folder)
+	if err != nil || !keywords {
+		t.Fatalf("selectFolder = %v, %v", keywords, err)
+	}
+	if err := c.flag(1, mailKeyword); err != nil {
+		t.Fatal(err)
+	}
+	uids, err := c.search("UNKEYWORD " + mailKeyword)
+	if err != nil || len(uids) != 1 || uids[0] != 2 {
+		t.Fatalf("search = %v, %v", uids, err)
+	}
+	raw, err := c.fetch(2)
+	if err != nil || string(raw) != "Subject: two\r\n\r\nsecond\r\n" {
+		t.Errorf("fetch = %q, %v", raw, err)
+	}
+	if _, err := c.fetch(3); err == nil {
+		t.Error("fetched a message that isn't there")
+	}
+	if err := c.appendMessage("Converted", []byte("Subject: three\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	c.logout()
+	f.mu.Lock()
+	got := f.appended["Converted"]
+	f.mu.Unlock()
+	if len(got) != 1 || string(got[0]) != "Subject: three\r\n\r\n" {
+		t.Errorf("appended %q", got)
+	}
+
+	t.Setenv(imapPasswordEnv, "wrong")
+	if _, err := dialIMAP(context.Background(), u); err == nil || !strings.Contains(err.Error(), "Invalid credentials") {
+		t.Errorf("dialIMAP with a wrong password = %v", err)
+	}
+}
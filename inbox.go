@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	imapURL     = flag.String("imap", "", "mailbox heictojpeg mail watches, imaps://user@host/folder (INBOX by default); the password is read from HEICTOJPEG_IMAP_PASSWORD")
+	imapPoll    = flag.Duration("imap-poll", time.Minute, "how often heictojpeg mail checks the -imap folder for new messages")
+	imapRefile  = flag.String("imap-refile", "", "folder heictojpeg mail files a copy of each message with HEIC attachments into, with them converted to JPEG")
+	imapForward = flag.String("imap-forward", "", "comma-separated addresses heictojpeg mail forwards each message with HEIC attachments to, with them converted to JPEG, through -smtp-server from -mail-from")
+)
+
+// mailCommand is the verb in "heictojpeg mail [options]", which converts
+// the HEIC attachments of the messages arriving in a mailbox.
+const mailCommand = "mail"
+
+// contentFields are the header fields describing a MIME part, the ones
+// rewritten along with it.
+var contentFields = []string{"Content-Type", "Content-Transfer-Encoding", "Content-Disposition", "Content-Id", "Content-Description"}
+
+// mailKeyword marks the messages the watcher has been through, converted
+// or not, so it reads each once.
+const mailKeyword = "$HEICtoJPEG"
+
+// runMailWatcher checks the -imap folder every -imap-poll until ctx is
+// cancelled. A first check that fails, e.g. for a wrong password, stops
+// it; later ones are retried at the next poll.
+func runMailWatcher(ctx context.Context) error {
+	u, folder, err := parseIMAPURL(*imapURL)
+	if err != nil {
+		return fmt.Errorf("-imap %q: %v", *imapURL, err)
+	}
+	if *imapRefile == "" && *imapForward == "" {
+		return errors.New("heictojpeg mail needs -imap-refile or -imap-forward, for where the converted messages go")
+	}
+	if *imapForward != "" && (*smtpServer == "" || *mailFrom == "") {
+		return errors.New("-imap-forward needs -smtp-server and -mail-from")
+	}
+	conv, err := newBotConverter()
+	if err != nil {
+		return err
+	}
+	fmt.Printf(tr("Watching %s on %s every %v\n"), folder, u.Hostname(), *imapPoll)
+	for first := true; ; first = false {
+		if err := checkMailbox(ctx, u, folder, conv); err != nil {
+			if first {
+				return fmt.Errorf("IMAP: %v", err)
+			}
+			if ctx.Err() == nil {
+				fmt.Printf(tr("IMAP: %v\n"), err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*imapPoll):
+		}
+	}
+}
+
+// checkMailbox converts the HEIC attachments of the messages in folder
+// not marked yet, files or forwards the messages with them converted, and
+// marks them. Servers that don't keep keywords have them marked read
+// instead, and only unread ones are looked at.
+func checkMailbox(ctx context.Context, u *url.URL, folder string, conv *botConverter) error {
+	c, err := dialIMAP(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer c.logout()
+	keywords, err := c.selectFolder(folder)
+	if err != nil {
+		return err
+	}
+	criteria, mark := "UNKEYWORD "+mailKeyword, mailKeyword
+	if !keywords {
+		criteria, mark = "UNSEEN", `\Seen`
+	}
+	uids, err := c.search(criteria)
+	if err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		if ctx.Err() != nil {
+			return nil
+		}
+		raw, err := c.fetch(uid)
+		if err != nil {
+			return err
+		}
+		from := tr("unknown sender")
+		if m, err := mail.ReadMessage(bytes.NewReader(raw)); err == nil && m.Header.Get("From") != "" {
+			from = m.Header.Get("From")
+		}
+		out, converted, failures, err := convertMessage(raw, func(body io.Reader) ([]byte, error) {
+			output, err := conv.convert(ctx, "mail from "+from, body)
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(output)
+			return os.ReadFile(output)
+		})
+		for _, failure := range failures {
+			fmt.Printf(tr("Failed to convert %s from %s\n"), failure, from)
+		}
+		if err != nil {
+			fmt.Printf(tr("Skipped a message from %s: %v\n"), from, err)
+		} else if converted > 0 {
+			// Left unmarked when it can't be delivered, to try again.
+			if *imapRefile != "" {
+				if err := c.appendMessage(*imapRefile, out); err != nil {
+					return err
+				}
+			}
+			if *imapForward != "" {
+				recipients := mailAddresses(*imapForward)
+				if err := sendMail(recipients, forwardMessage(out, *mailFrom, recipients)); err != nil {
+					return fmt.Errorf("forwarding: %v", err)
+				}
+			}
+			fmt.Printf(tr("Converted a message from %s: %d JPEGs\n"), from, converted)
+		}
+		if err := c.flag(uid, mark); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertMessage rewrites the message raw with its HEIC attachments
+// converted by convert, from their decoded bytes to a JPEG's. Other parts
+// are copied as they are, and so are the attachments that fail, which
+// failures names.
+func convertMessage(raw []byte, convert func(io.Reader) ([]byte, error)) (out []byte, converted int, failures []string, err error) {
+	header, body := splitMessage(raw)
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	rw := &messageRewriter{convert: convert}
+	partHeader, partBody, err := rw.part(textproto.MIMEHeader(m.Header), body)
+	if err != nil || rw.converted == 0 {
+		return nil, 0, rw.failures, err
+	}
+	var b bytes.Buffer
+	b.Write(dropHeaders(header, contentFields...))
+	writeHeader(&b, partHeader)
+	b.WriteString("\r\n")
+	b.Write(partBody)
+	return b.Bytes(), rw.converted, rw.failures, nil
+}
+
+type messageRewriter struct {
+	convert   func(io.Reader) ([]byte, error)
+	converted int
+	failures  []string
+}
+
+// part rewrites a MIME part, a multipart one part by part, and returns
+// its content headers and encoded body.
+func (rw *messageRewriter) part(h textproto.MIMEHeader, body []byte) (textproto.MIMEHeader, []byte, error) {
+	content := textproto.MIMEHeader{}
+	for _, name := range contentFields {
+		if v := h.Get(name); v != "" {
+			content.Set(name, v)
+		}
+	}
+	mediaType, params, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		var b bytes.Buffer
+		w := multipart.NewWriter(&b)
+		r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			p, err := r.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			data, err := io.ReadAll(p)
+			if err != nil {
+				return nil, nil, err
+			}
+			partHeader, partBody, err := rw.part(p.Header, data)
+			if err != nil {
+				return nil, nil, err
+			}
+			pw, err := w.CreatePart(partHeader)
+			if err != nil {
+				return nil, nil, err
+			}
+			pw.Write(partBody)
+		}
+		w.Close()
+		params["boundary"] = w.Boundary()
+		content.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+		return content, b.Bytes(), nil
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	name := dispositionParams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if !isHEICUpload(name, mediaType) {
+		return content, body, nil
+	}
+	var decoded io.Reader = bytes.NewReader(body)
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		decoded = base64.NewDecoder(base64.StdEncoding, decoded)
+	case "quoted-printable":
+		decoded = quotedprintable.NewReader(decoded)
+	}
+	if name == "" {
+		name = "photo.heic"
+	}
+	jpeg, err := rw.convert(decoded)
+	if err != nil {
+		rw.failures = append(rw.failures, fmt.Sprintf("%s: %v", name, err))
+		return content, body, nil
+	}
+	rw.converted++
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	content.Set("Content-Type", mime.FormatMediaType("image/jpeg", map[string]string{"name": jpegName(name)}))
+	content.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": jpegName(name)}))
+	content.Set("Content-Transfer-Encoding", "base64")
+	return content, base64Lines(jpeg), nil
+}
+
+// base64Lines encodes data in lines of 76 characters, as mail wants.
+func base64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b bytes.Buffer
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
+
+// splitMessage splits a message at the blank line after its header,
+// which it keeps.
+func splitMessage(raw []byte) (header, body []byte) {
+	for _, sep := range []string{"\r\n\r\n", "\n\n"} {
+		if i := bytes.Index(raw, []byte(sep)); i >= 0 {
+			return raw[:i+len(sep)/2], raw[i+len(sep):]
+		}
+	}
+	return raw, nil
+}
+
+// dropHeaders leaves the fields names, with their folded lines, out of the
+// header block, keeping the order of the others.
+func dropHeaders(header []byte, names ...string) []byte {
+	var b bytes.Buffer
+	dropping := false
+	for _, line := range bytes.SplitAfter(header, []byte("\n")) {
+		if len(line) > 0 && line[0] != ' ' && line[0] != '\t' {
+			dropping = false
+			if colon := bytes.IndexByte(line, ':'); colon > 0 {
+				field := string(bytes.TrimSpace(line[:colon]))
+				for _, name := range names {
+					dropping = dropping || strings.EqualFold(field, name)
+				}
+			}
+		}
+		if !dropping {
+			b.Write(line)
+		}
+	}
+	return b.Bytes()
+}
+
+// writeHeader writes the content fields of h.
+func writeHeader(b *bytes.Buffer, h textproto.MIMEHeader) {
+	for _, name := range contentFields {
+		if v := h.Get(name); v != "" {
+			fmt.Fprintf(b, "%s: %s\r\n", name, v)
+		}
+	}
+}
+
+// forwardMessage makes the converted message msg a new message from from
+// to recipients, with its subject and sender kept in Subject and Reply-To.
+func forwardMessage(msg []byte, from string, recipients []string) []byte {
+	_, body := splitMessage(msg)
+	m, _ := mail.ReadMessage(bytes.NewReader(msg))
+	var original mail.Header
+	if m != nil {
+		original = m.Header
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(original.Get("Subject"))
+	if err != nil {
+		subject = original.Get("Subject")
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\n", from, strings.Join(recipients, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Fwd: "+subject))
+	fmt.Fprintf(&b, "Date: %s\r\nMIME-Version: 1.0\r\n", time.Now().Format(time.RFC1123Z))
+	if sender := original.Get("From"); sender != "" {
+		fmt.Fprintf(&b, "Reply-To: %s\r\n", sender)
+	}
+	writeHeader(&b, textproto.MIMEHeader(original))
+	b.WriteString("\r\n")
+	b.Write(body)
+	return b.Bytes()
+}
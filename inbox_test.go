@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image/jpeg"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+// photoMessage is a message from a client with a note, a HEIC photo and a
+// PDF attached.
+func photoMessage(heic []byte) string {
+	return "From: Client <client@example.com>\r\n" +
+		"Subject: =?utf-8?q?Photos_du_chantier?=\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed;\r\n boundary=\"outer\"\r\n" +
+		"\r\n" +
+		"--outer\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nHere they are.\r\n" +
+		"--outer\r\nContent-Type: image/heic; name=\"IMG_0001.HEIC\"\r\nContent-Disposition: attachment; filename=\"IMG_0001.HEIC\"\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+		string(base64Lines(heic)) +
+		"--outer\r\nContent-Type: application/pdf\r\nContent-Disposition: attachment; filename=\"quote.pdf\"\r\nContent-Transfer-Encoding: base64\r\n\r\nJVBERi0=\r\n" +
+		"--outer--\r\n"
+}
+
+// messageParts returns the file names and media types of msg's parts, and
+// the bytes of its JPEGs.
+func messageParts(t *testing.T, msg []byte) (parts []string, jpegs [][]byte) {
+	t.Helper()
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts, jpegs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts = append(parts, p.FileName()+" "+mediaType)
+		if mediaType == "image/jpeg" {
+			data, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+			jpegs = append(jpegs, data)
+		}
+	}
+}
+
+func TestConvertMessage(t *testing.T) {
+	heic := exifSample()
+	var got []byte
+	out, converted, failures, err := convertMessage([]byte(photoMessage(heic)), func(r io.Reader) ([]byte, error) {
+		got, _ = io.ReadAll(r)
+		return []byte("JPEG"), nil
+	})
+	if err != nil || converted != 1 || len(failures) != 0 {
+		t.Fatalf("convertMessage = %d, %q, %v", converted, failures, err)
+	}
+	if !bytes.Equal(got, heic) {
+		t.Error("the HEIC wasn't decoded from base64")
+	}
+	if !bytes.HasPrefix(out, []byte("From: Client <client@example.com>\r\nSubject: =?utf-8?q?Photos_du_chantier?=\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=")) {
+		t.Errorf("header:\n%s", out)
+	}
+	parts, jpegs := messageParts(t, out)
+	if strings.Join(parts, ";") != " text/plain;IMG_0001.jpg image/jpeg;quote.pdf application/pdf" || len(jpegs) != 1 || string(jpegs[0]) != "JPEG" {
+		t.Errorf("parts %q, JPEGs %q", parts, jpegs)
+	}
+
+	out, converted, failures, err = convertMessage([]byte(photoMessage(heic)), func(io.Reader) ([]byte, error) {
+		return nil, errors.New("broken")
+	})
+	if out != nil || converted != 0 || len(failures) != 1 || failures[0] != "IMG_0001.HEIC: broken" || err != nil {
+		t.Errorf("convertMessage with a failing conversion = %q, %d, %q, %v", out, converted, failures, err)
+	}
+	if _, converted, _, _ := convertMessage([]byte("Subject: hi\r\nContent-Type: text/plain\r\n\r\nhello\r\n"), nil); converted != 0 {
+		t.Error("converted a message without attachments")
+	}
+}
+
+func TestForwardMessage(t *testing.T) {
+	out, _, _, _ := convertMessage([]byte(photoMessage(exifSample())), func(io.Reader) ([]byte, error) { return []byte("JPEG"), nil })
+	m, err := mail.ReadMessage(bytes.NewReader(forwardMessage(out, "photos@office.example", []string{"a@office.example", "b@office.example"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	for field, want := range map[string]string{
+		"From":     "photos@office.example",
+		"To":       "a@office.example, b@office.example",
+		"Reply-To": "Client <client@example.com>",
+		"Subject":  "Fwd: Photos du chantier",
+	} {
+		if got := m.Header.Get(field); field == "Subject" && subject != want || field != "Subject" && got != want {
+			t.Errorf("%s: %q, want %q", field, got, want)
+		}
+	}
+	if !strings.HasPrefix(m.Header.Get("Content-Type"), "multipart/mixed; boundary=") {
+		t.Errorf("Content-Type %q", m.Header.Get("Content-Type"))
+	}
+}
+
+func TestCheckMailbox(t *testing.T) {
+	defer func(refile string) { *imapRefile = refile }(*imapRefile)
+	*imapRefile = "Converted"
+	t.Setenv(imapPasswordEnv, "secret")
+	conv, err := newBotConverter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keywords := range []bool{true, false} {
+		f := newFakeIMAP(t, keywords, photoMessage(exifSample()), "From: a@example.com\r\nSubject: hi\r\n\r\nno photos\r\n")
+		u, folder, _ := parseIMAPURL(f.url())
+		for i := 0; i < 2; i++ {
+			if err := checkMailbox(context.Background(), u, folder, conv); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		f.mu.Lock()
+		appended := f.appended["Converted"]
+		flags := [][]string{f.messages[0].flags, f.messages[1].flags}
+		f.mu.Unlock()
+		// The second check finds nothing new.
+		if len(appended) != 1 {
+			t.Fatalf("keywords %v: appended %d messages", keywords, len(appended))
+		}
+		parts, jpegs := messageParts(t, appended[0])
+		if len(parts) != 3 || len(jpegs) != 1 {
+			t.Fatalf("keywords %v: parts %q", keywords, parts)
+		}
+		if config, err := jpeg.DecodeConfig(bytes.NewReader(jpegs[0])); err != nil || config.Width != heicsample.Size {
+			t.Errorf("keywords %v: JPEG %+v, %v", keywords, config, err)
+		}
+		mark := mailKeyword
+		if !keywords {
+			mark = `\Seen`
+		}
+		for i, f := range flags {
+			if len(f) != 1 || f[0] != mark {
+				t.Errorf("keywords %v: message %d flagged %q", keywords, i+1, f)
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var inPlace = flag.Bool("in-place", false, "write each JPEG next to its HEIC (IMG_0001.heic > IMG_0001.jpg) instead of into a jpegs folder")
+
+// besideInput returns where the JPEG of input goes with -in-place, output
+// being the name it would normally get. A JPEG there that is at least as
+// new as input is taken to be its conversion, and the file is skipped. An
+// older one is kept, since it may be another photo of the same name, like
+// the one an iPhone exports next to the HEIC: the conversion is numbered
+// instead (IMG_0001-2.jpg), and the numbered names are checked the same way.
+func besideInput(input, output string) (string, error) {
+	info, err := os.Stat(longPath(input))
+	if err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(output)
+	base := strings.TrimSuffix(output, ext)
+	candidate := output
+	for n := 2; ; n++ {
+		existing, err := os.Stat(longPath(candidate))
+		if os.IsNotExist(err) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !existing.ModTime().Before(info.ModTime()) {
+			return "", &skipReason{"already converted to " + filepath.Base(candidate)}
+		}
+		candidate = base + "-" + strconv.Itoa(n) + ext
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBesideInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "IMG_0001.heic")
+	output := filepath.Join(dir, "IMG_0001.jpg")
+	write := func(path string, mtime time.Time) {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(input, now)
+
+	if got, err := besideInput(input, output); err != nil || got != output {
+		t.Fatalf("no JPEG yet: %q, %v", got, err)
+	}
+
+	write(output, now.Add(-time.Hour))
+	numbered := filepath.Join(dir, "IMG_0001-2.jpg")
+	if got, err := besideInput(input, output); err != nil || got != numbered {
+		t.Fatalf("older JPEG: %q, %v; want %q", got, err, numbered)
+	}
+
+	write(numbered, now)
+	_, err := besideInput(input, output)
+	if reason, ok := skippedBy(err); !ok || reason != "already converted to IMG_0001-2.jpg" {
+		t.Fatalf("converted before: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package exif
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Tag is an IFD entry to lay out with Build.
+type Tag struct {
+	ID    uint16
+	Type  uint16
+	Count uint32
+	Value []byte
+}
+
+// ASCII is a Tag holding the text s.
+func ASCII(id uint16, s string) Tag {
+	return Tag{id, TypeASCII, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+// Short is a Tag holding the SHORT v.
+func Short(id uint16, v uint16) Tag {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+	return Tag{id, TypeShort, 1, b}
+}
+
+// Build lays out a little-endian EXIF block with IFD0 and, when
+// exifTags are given, an EXIF sub-IFD, for the samples of the tests.
+func Build(ifd0 []Tag, exifTags []Tag) []byte {
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	var writeIFD func(tags []Tag, sub []Tag) []byte
+	writeIFD = func(tags []Tag, sub []Tag) []byte {
+		if sub != nil {
+			tags = append(tags, Tag{TagExifIFD, TypeLong, 1, make([]byte, 4)})
+		}
+		sort.Slice(tags, func(i, j int) bool { return tags[i].ID < tags[j].ID })
+		start := uint32(len(tiff))
+		dataAt := start + 2 + uint32(len(tags))*12 + 4
+		ifd := make([]byte, 2, dataAt-start)
+		binary.LittleEndian.PutUint16(ifd, uint16(len(tags)))
+		var data []byte
+		subAt := -1
+		for _, t := range tags {
+			e := make([]byte, 12)
+			binary.LittleEndian.PutUint16(e, t.ID)
+			binary.LittleEndian.PutUint16(e[2:], t.Type)
+			binary.LittleEndian.PutUint32(e[4:], t.Count)
+			if len(t.Value) > 4 {
+				binary.LittleEndian.PutUint32(e[8:], dataAt+uint32(len(data)))
+				data = append(data, t.Value...)
+			} else {
+				copy(e[8:], t.Value)
+			}
+			if t.ID == TagExifIFD {
+				subAt = len(ifd) + 8
+			}
+			ifd = append(ifd, e...)
+		}
+		ifd = append(ifd, 0, 0, 0, 0)
+		tiff = append(append(tiff, ifd...), data...)
+		if sub != nil {
+			binary.LittleEndian.PutUint32(tiff[int(start)+subAt:], uint32(len(tiff)))
+			writeIFD(sub, nil)
+		}
+		return tiff
+	}
+	writeIFD(ifd0, exifTags)
+	return append(append([]byte(nil), Header...), tiff...)
+}
@@ -0,0 +1,220 @@
+// Package exif reads the EXIF blocks of HEIC files and JPEGs, enough for
+// heictojpeg to check them, repair them and read the tags its rules use.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+)
+
+// EXIF tags read by heictojpeg.
+const (
+	TagMake            = 0x010f
+	TagModel           = 0x0110
+	TagOrientation     = 0x0112
+	TagExifIFD         = 0x8769
+	TagGPSIFD          = 0x8825
+	TagGPSLatitudeRef  = 0x0001
+	TagGPSLatitude     = 0x0002
+	TagGPSLongitudeRef = 0x0003
+	TagGPSLongitude    = 0x0004
+	TypeASCII          = 2
+	TypeShort          = 3
+	TypeLong           = 4
+	TypeRational       = 5
+)
+
+// ErrMalformed is returned for blocks that don't parse.
+var ErrMalformed = errors.New("malformed EXIF")
+
+// Header starts the EXIF block of a JPEG's APP1 segment.
+var Header = []byte("Exif\x00\x00")
+
+// MaxSegment is the most EXIF a JPEG APP1 segment can hold.
+const MaxSegment = 0xffff - 2
+
+// Entry is one IFD entry; Value holds the raw bytes, which are stored
+// inline for values of up to 4 bytes.
+type Entry struct {
+	Type  uint16
+	Count uint32
+	Value []byte
+}
+
+// Data is a parsed EXIF block: IFD0 (the main image), and the EXIF
+// and GPS sub-IFDs when present.
+type Data struct {
+	Order binary.ByteOrder
+	IFD0  map[uint16]Entry
+	Exif  map[uint16]Entry
+	GPS   map[uint16]Entry
+}
+
+var typeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// Parse reads the block goheif.ExtractExif returns, with or without
+// its "Exif\0\0" prefix.
+func Parse(data []byte) (*Data, error) {
+	data = bytes.TrimPrefix(data, Header)
+	if len(data) < 8 {
+		return nil, ErrMalformed
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, ErrMalformed
+	}
+	if order.Uint16(data[2:4]) != 42 {
+		return nil, ErrMalformed
+	}
+
+	x := &Data{Order: order}
+	var err error
+	if x.IFD0, err = readIFD(data, order, order.Uint32(data[4:8])); err != nil {
+		return nil, err
+	}
+	if off, ok := x.Uint(x.IFD0, TagExifIFD); ok {
+		x.Exif, _ = readIFD(data, order, off)
+	}
+	if off, ok := x.Uint(x.IFD0, TagGPSIFD); ok {
+		x.GPS, _ = readIFD(data, order, off)
+	}
+	return x, nil
+}
+
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) (map[uint16]Entry, error) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return nil, ErrMalformed
+	}
+	n := uint32(order.Uint16(tiff[offset:]))
+	if uint64(offset)+2+uint64(n)*12 > uint64(len(tiff)) {
+		return nil, ErrMalformed
+	}
+	entries := make(map[uint16]Entry, n)
+	for i := uint32(0); i < n; i++ {
+		e := tiff[offset+2+i*12:]
+		tag, typ, count := order.Uint16(e), order.Uint16(e[2:]), order.Uint32(e[4:])
+		size, known := typeSizes[typ]
+		if !known || uint64(size)*uint64(count) > uint64(len(tiff)) {
+			continue
+		}
+		length := size * count
+		value := e[8:12]
+		if length > 4 {
+			off := order.Uint32(e[8:])
+			if uint64(off)+uint64(length) > uint64(len(tiff)) {
+				continue
+			}
+			value = tiff[off : off+length]
+		} else {
+			value = value[:length]
+		}
+		entries[tag] = Entry{Type: typ, Count: count, Value: value}
+	}
+	return entries, nil
+}
+
+// Text returns an ASCII tag without its NUL and surrounding spaces.
+func (x *Data) Text(ifd map[uint16]Entry, tag uint16) string {
+	e, ok := ifd[tag]
+	if !ok || e.Type != TypeASCII {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.Value), "\x00"))
+}
+
+// Uint returns a SHORT or LONG tag's first value.
+func (x *Data) Uint(ifd map[uint16]Entry, tag uint16) (uint32, bool) {
+	e, ok := ifd[tag]
+	switch {
+	case !ok || e.Count == 0:
+		return 0, false
+	case e.Type == TypeShort:
+		return uint32(x.Order.Uint16(e.Value)), true
+	case e.Type == TypeLong:
+		return x.Order.Uint32(e.Value), true
+	}
+	return 0, false
+}
+
+// Rationals returns the values of a RATIONAL tag.
+func (x *Data) Rationals(ifd map[uint16]Entry, tag uint16) []float64 {
+	e, ok := ifd[tag]
+	if !ok || e.Type != TypeRational || uint32(len(e.Value)) < 8*e.Count {
+		return nil
+	}
+	values := make([]float64, e.Count)
+	for i := range values {
+		num, den := x.Order.Uint32(e.Value[8*i:]), x.Order.Uint32(e.Value[8*i+4:])
+		if den == 0 {
+			return nil
+		}
+		values[i] = float64(num) / float64(den)
+	}
+	return values
+}
+
+// GPSPosition returns where the photo was taken, in signed degrees.
+func (x *Data) GPSPosition() (lat, lon float64, ok bool) {
+	if x == nil || x.GPS == nil {
+		return 0, 0, false
+	}
+	degrees := func(ref, tag uint16, negative string) (float64, bool) {
+		v := x.Rationals(x.GPS, tag)
+		if len(v) != 3 {
+			return 0, false
+		}
+		d := v[0] + v[1]/60 + v[2]/3600
+		if strings.EqualFold(x.Text(x.GPS, ref), negative) {
+			d = -d
+		}
+		return d, true
+	}
+	lat, okLat := degrees(TagGPSLatitudeRef, TagGPSLatitude, "S")
+	lon, okLon := degrees(TagGPSLongitudeRef, TagGPSLongitude, "W")
+	if !okLat || !okLon || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// Camera returns the make and model the file was taken with, empty for
+// files without EXIF or without camera tags.
+func (x *Data) Camera() (cameraMake, cameraModel string) {
+	if x == nil {
+		return "", ""
+	}
+	return x.Text(x.IFD0, TagMake), x.Text(x.IFD0, TagModel)
+}
+
+// FindTIFF returns the first part of data, from a TIFF header on, that
+// parses as EXIF.
+func FindTIFF(data []byte) []byte {
+	for i := 0; i+8 <= len(data); i++ {
+		if s := string(data[i : i+4]); s != "II*\x00" && s != "MM\x00*" {
+			continue
+		}
+		if _, err := Parse(data[i:]); err == nil {
+			return data[i:]
+		}
+	}
+	return nil
+}
+
+// Orientation is the EXIF orientation, 1 (upright) when it isn't set.
+func (x *Data) Orientation() int {
+	if x == nil {
+		return 1
+	}
+	if v, ok := x.Uint(x.IFD0, TagOrientation); ok && v >= 1 && v <= 8 {
+		return int(v)
+	}
+	return 1
+}
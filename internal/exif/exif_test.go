@@ -0,0 +1,64 @@
+package exif
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := Build(
+		[]Tag{ASCII(TagMake, "DJI"), ASCII(TagModel, "FC3582"), Short(TagOrientation, 6)},
+		[]Tag{ASCII(0x9003, "2024:06:01 10:00:00")},
+	)
+	x, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if mk, model := x.Camera(); mk != "DJI" || model != "FC3582" {
+		t.Errorf("Camera = %q %q, want DJI FC3582", mk, model)
+	}
+	if got := x.Orientation(); got != 6 {
+		t.Errorf("Orientation = %d, want 6", got)
+	}
+	if got := x.Text(x.Exif, 0x9003); got != "2024:06:01 10:00:00" {
+		t.Errorf("date = %q", got)
+	}
+
+	for _, bad := range [][]byte{nil, []byte("Exif\x00\x00XX*\x00"), data[:20]} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted a malformed block", bad)
+		}
+	}
+}
+
+func rationalTag(values ...[2]uint32) Entry {
+	b := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[8*i:], v[0])
+		binary.LittleEndian.PutUint32(b[8*i+4:], v[1])
+	}
+	return Entry{TypeRational, uint32(len(values)), b}
+}
+
+func TestGPSPosition(t *testing.T) {
+	x := &Data{Order: binary.LittleEndian, GPS: map[uint16]Entry{
+		TagGPSLatitudeRef:  {TypeASCII, 2, []byte("S\x00")},
+		TagGPSLatitude:     rationalTag([2]uint32{33, 1}, [2]uint32{51, 1}, [2]uint32{2448, 100}),
+		TagGPSLongitudeRef: {TypeASCII, 2, []byte("E\x00")},
+		TagGPSLongitude:    rationalTag([2]uint32{151, 1}, [2]uint32{12, 1}, [2]uint32{5508, 100}),
+	}}
+	lat, lon, ok := x.GPSPosition()
+	if !ok || math.Abs(lat+33.8568) > 0.001 || math.Abs(lon-151.2153) > 0.001 {
+		t.Errorf("GPSPosition = %v, %v, %v; want -33.8568, 151.2153", lat, lon, ok)
+	}
+
+	var none *Data
+	if _, _, ok := none.GPSPosition(); ok {
+		t.Error("no EXIF has a position")
+	}
+	delete(x.GPS, TagGPSLongitude)
+	if _, _, ok := x.GPSPosition(); ok {
+		t.Error("a latitude alone is a position")
+	}
+}
@@ -0,0 +1,493 @@
+// Package heicsample makes the sample HEIC files of the doctor and the
+// tests.
+package heicsample
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The doctor's sample images are made at run time rather than shipped, by
+// a minimal HEVC encoder that stores every 16×16 block as PCM, the raw
+// samples. The only arithmetic coding left is two flags per block, so it
+// needs a few tables instead of an encoder library, and any bit depth the
+// format allows comes for free.
+
+const pcmBlock = 16 // the coding tree block, coding block and PCM size
+
+// Picture is a 4:2:0 picture whose sides are multiples of pcmBlock.
+type Picture struct {
+	width, height int
+	depth         int      // bits per sample, 8 to 10 here
+	y, cb, cr     []uint16 // row by row; the chroma planes are half size
+}
+
+// NewPicture fills a picture from pattern, which gives the 8-bit
+// Y, Cb and Cr at a luma position; higher depths scale them up.
+func NewPicture(width, height, depth int, pattern func(x, y int) (uint8, uint8, uint8)) *Picture {
+	p := &Picture{width: width, height: height, depth: depth}
+	p.y = make([]uint16, width*height)
+	p.cb = make([]uint16, width*height/4)
+	p.cr = make([]uint16, width*height/4)
+	shift := uint(depth - 8)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			l, cb, cr := pattern(x, y)
+			p.y[y*width+x] = uint16(l) << shift
+			if x%2 == 0 && y%2 == 0 {
+				i := y/2*width/2 + x/2
+				p.cb[i], p.cr[i] = uint16(cb)<<shift, uint16(cr)<<shift
+			}
+		}
+	}
+	return p
+}
+
+// bitWriter appends big-endian bit strings, as the HEVC syntax is written.
+type bitWriter struct {
+	buf  []byte
+	used int // bits used in the last byte, 0 when aligned
+}
+
+func (w *bitWriter) put(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.used == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>uint(i)&1) << uint(7-w.used)
+		w.used = (w.used + 1) % 8
+	}
+}
+
+// ue writes an unsigned Exp-Golomb code.
+func (w *bitWriter) ue(v uint32) {
+	n := bits.Len32(v + 1)
+	w.put(0, n-1)
+	w.put(v+1, n)
+}
+
+// trailing writes the stop bit and pads to the next byte.
+func (w *bitWriter) trailing() {
+	w.put(1, 1)
+	w.alignZero()
+}
+
+func (w *bitWriter) alignZero() {
+	for w.used != 0 {
+		w.put(0, 1)
+	}
+}
+
+// rangeTabLps is the arithmetic coder's range for the less probable bin,
+// by probability state and range quarter.
+var rangeTabLps = [64][4]uint8{
+	{128, 176, 208, 240}, {128, 167, 197, 227}, {128, 158, 187, 216}, {123, 150, 178, 205},
+	{116, 142, 169, 195}, {111, 135, 160, 185}, {105, 128, 152, 175}, {100, 122, 144, 166},
+	{95, 116, 137, 158}, {90, 110, 130, 150}, {85, 104, 123, 142}, {81, 99, 117, 135},
+	{77, 94, 111, 128}, {73, 89, 105, 122}, {69, 85, 100, 116}, {66, 80, 95, 110},
+	{62, 76, 90, 104}, {59, 72, 86, 99}, {56, 69, 81, 94}, {53, 65, 77, 89},
+	{51, 62, 73, 85}, {48, 59, 69, 80}, {46, 56, 66, 76}, {43, 53, 63, 72},
+	{41, 50, 59, 69}, {39, 48, 56, 65}, {37, 45, 54, 62}, {35, 43, 51, 59},
+	{33, 41, 48, 56}, {32, 39, 46, 53}, {30, 37, 43, 50}, {29, 35, 41, 48},
+	{27, 33, 39, 45}, {26, 31, 37, 43}, {24, 30, 35, 41}, {23, 28, 33, 39},
+	{22, 27, 32, 37}, {21, 26, 30, 35}, {20, 24, 29, 33}, {19, 23, 27, 31},
+	{18, 22, 26, 30}, {17, 21, 25, 28}, {16, 20, 23, 27}, {15, 19, 22, 25},
+	{14, 18, 21, 24}, {14, 17, 20, 23}, {13, 16, 19, 22}, {12, 15, 18, 21},
+	{12, 14, 17, 20}, {11, 14, 16, 19}, {11, 13, 15, 18}, {10, 12, 15, 17},
+	{10, 12, 14, 16}, {9, 11, 13, 15}, {9, 11, 12, 14}, {8, 10, 12, 14},
+	{8, 9, 11, 13}, {7, 9, 11, 12}, {7, 9, 10, 12}, {7, 8, 10, 11},
+	{6, 8, 9, 11}, {6, 7, 9, 10}, {6, 7, 8, 9}, {2, 2, 2, 2},
+}
+
+// cabacWriter is the arithmetic encoder of the HEVC specification, with
+// only what PCM blocks need.
+type cabacWriter struct {
+	w           *bitWriter
+	low, rng    uint32
+	outstanding int
+	first       bool
+}
+
+func (c *cabacWriter) start() {
+	c.low, c.rng, c.outstanding, c.first = 0, 510, 0, true
+}
+
+func (c *cabacWriter) putBit(b uint32) {
+	if c.first {
+		c.first = false
+	} else {
+		c.w.put(b, 1)
+	}
+	for ; c.outstanding > 0; c.outstanding-- {
+		c.w.put(1-b, 1)
+	}
+}
+
+func (c *cabacWriter) renorm() {
+	for c.rng < 256 {
+		switch {
+		case c.low < 256:
+			c.putBit(0)
+		case c.low >= 512:
+			c.low -= 512
+			c.putBit(1)
+		default:
+			c.low -= 256
+			c.outstanding++
+		}
+		c.rng <<= 1
+		c.low <<= 1
+	}
+}
+
+// mostProbable codes the more probable bin of a context in state, and
+// returns the next state. Every context-coded bin of a PCM picture is one.
+func (c *cabacWriter) mostProbable(state int) int {
+	c.rng -= uint32(rangeTabLps[state][c.rng>>6&3])
+	c.renorm()
+	if state < 62 {
+		state++
+	}
+	return state
+}
+
+// terminate codes a bin that can end the arithmetic coding; a 1 flushes
+// it, ending with a 1 bit that doubles as the stop bit.
+func (c *cabacWriter) terminate(end bool) {
+	c.rng -= 2
+	if !end {
+		c.renorm()
+		return
+	}
+	c.low += c.rng
+	c.rng = 2
+	c.renorm()
+	c.putBit(c.low >> 9 & 1)
+	c.w.put(c.low>>7&3|1, 2)
+}
+
+// profileTierLevel writes the Main or Main 10 profile at level 4.
+func (p *Picture) profileTierLevel(w *bitWriter) {
+	profile := p.profile()
+	w.put(0, 2) // profile space
+	w.put(0, 1) // main tier
+	w.put(profile, 5)
+	w.put(1<<(31-profile)|1<<(31-2), 32) // compatible with Main 10
+	w.put(0x9, 4)                        // progressive, frame only
+	w.put(0, 32)
+	w.put(0, 12)
+	w.put(120, 8) // level 4
+}
+
+func (p *Picture) profile() uint32 {
+	if p.depth > 8 {
+		return 2
+	}
+	return 1
+}
+
+func (p *Picture) vps() []byte {
+	w := &bitWriter{}
+	w.put(0, 4) // vps id
+	w.put(3, 2) // base layer internal and available
+	w.put(0, 6) // one layer
+	w.put(0, 3) // one sub-layer
+	w.put(1, 1) // temporal id nesting
+	w.put(0xffff, 16)
+	p.profileTierLevel(w)
+	w.put(1, 1) // sub-layer ordering info
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)
+	w.put(0, 6) // max layer id
+	w.ue(0)     // layer sets
+	w.put(0, 1) // timing info
+	w.put(0, 1) // extension
+	w.trailing()
+	return w.buf
+}
+
+func (p *Picture) sps() []byte {
+	depth := uint32(p.depth)
+	w := &bitWriter{}
+	w.put(0, 4) // vps id
+	w.put(0, 3) // one sub-layer
+	w.put(1, 1) // temporal id nesting
+	p.profileTierLevel(w)
+	w.ue(0) // sps id
+	w.ue(1) // 4:2:0
+	w.ue(uint32(p.width))
+	w.ue(uint32(p.height))
+	w.put(0, 1) // conformance window
+	w.ue(depth - 8)
+	w.ue(depth - 8)
+	w.ue(0)     // picture order count bits
+	w.put(1, 1) // sub-layer ordering info
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)
+	w.ue(1)     // 16×16 coding blocks
+	w.ue(0)     // that are also the coding tree blocks
+	w.ue(0)     // 4×4 transform blocks
+	w.ue(2)     // up to 16×16
+	w.ue(0)     // transform depth inter
+	w.ue(0)     // transform depth intra
+	w.put(0, 1) // scaling lists
+	w.put(0, 1) // asymmetric motion partitions
+	w.put(0, 1) // sample adaptive offset
+	w.put(1, 1) // PCM
+	w.put(depth-1, 4)
+	w.put(depth-1, 4)
+	w.ue(1)     // 16×16 PCM blocks
+	w.ue(0)     // only
+	w.put(1, 1) // no loop filter on PCM
+	w.ue(0)     // short-term reference sets
+	w.put(0, 1) // long-term references
+	w.put(0, 1) // temporal motion vectors
+	w.put(0, 1) // strong intra smoothing
+	w.put(0, 1) // VUI
+	w.put(0, 1) // extension
+	w.trailing()
+	return w.buf
+}
+
+func (p *Picture) pps() []byte {
+	w := &bitWriter{}
+	w.ue(0)     // pps id
+	w.ue(0)     // sps id
+	w.put(0, 7) // dependent slices, output flag, extra header bits, sign hiding, cabac init
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)     // init qp 26, se(0) codes like ue(0)
+	w.put(0, 3) // constrained intra, transform skip, cu qp delta
+	w.ue(0)     // cb qp offset
+	w.ue(0)     // cr qp offset
+	w.put(0, 7) // chroma offsets, weighted prediction, bypass, tiles, wavefronts, loop filter across slices
+	w.put(1, 1) // deblocking control
+	w.put(0, 1) // no override
+	w.put(1, 1) // deblocking off
+	w.put(0, 2) // scaling lists, list modification
+	w.ue(0)     // parallel merge level
+	w.put(0, 2) // header extension, extension
+	w.trailing()
+	return w.buf
+}
+
+// slice codes the picture as one I slice of an IDR picture.
+func (p *Picture) slice() []byte {
+	w := &bitWriter{}
+	w.put(1, 1) // first slice
+	w.put(0, 1) // no output of prior pictures
+	w.ue(0)     // pps id
+	w.ue(2)     // I slice
+	w.ue(0)     // slice qp delta
+	w.trailing()
+
+	// part_mode has init value 184, so at qp 26 it starts in state 0
+	// with 1, a single 2N×2N partition, the more probable bin.
+	c := &cabacWriter{w: w}
+	c.start()
+	state := 0
+	cols, rows := p.width/pcmBlock, p.height/pcmBlock
+	for by := 0; by < rows; by++ {
+		for bx := 0; bx < cols; bx++ {
+			state = c.mostProbable(state) // part_mode 2N×2N
+			c.terminate(true)             // pcm_flag
+			w.alignZero()
+			p.putPCM(w, p.y, p.width, bx*pcmBlock, by*pcmBlock, pcmBlock)
+			p.putPCM(w, p.cb, p.width/2, bx*pcmBlock/2, by*pcmBlock/2, pcmBlock/2)
+			p.putPCM(w, p.cr, p.width/2, bx*pcmBlock/2, by*pcmBlock/2, pcmBlock/2)
+			c.start()
+			c.terminate(bx == cols-1 && by == rows-1) // end of slice
+		}
+	}
+	w.alignZero()
+	return w.buf
+}
+
+func (p *Picture) putPCM(w *bitWriter, plane []uint16, stride, x0, y0, size int) {
+	for y := y0; y < y0+size; y++ {
+		for x := x0; x < x0+size; x++ {
+			w.put(uint32(plane[y*stride+x]), p.depth)
+		}
+	}
+}
+
+// nalUnit adds the two-byte header and the emulation prevention bytes
+// that keep the payload from looking like a start code.
+func nalUnit(typ byte, rbsp []byte) []byte {
+	out := []byte{typ << 1, 1}
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros == 2 && b <= 3 {
+			out = append(out, 3)
+			zeros = 0
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
+
+const (
+	nalIDR = 20
+	nalVPS = 32
+	nalSPS = 33
+	nalPPS = 34
+)
+
+// HvcC is the decoder configuration property of the picture's item.
+func (p *Picture) HvcC() []byte {
+	depth := byte(p.depth - 8)
+	profile := p.profile()
+	b := []byte{1, byte(profile), 0, 0, 0, 0, 0x90, 0, 0, 0, 0, 0, 120, 0xf0, 0, 0xfc, 0xfd, 0xf8 | depth, 0xf8 | depth, 0, 0, 0x0f, 3}
+	binary.BigEndian.PutUint32(b[2:], 1<<(31-profile)|1<<(31-2))
+	for _, nal := range []struct {
+		typ  byte
+		rbsp []byte
+	}{{nalVPS, p.vps()}, {nalSPS, p.sps()}, {nalPPS, p.pps()}} {
+		unit := nalUnit(nal.typ, nal.rbsp)
+		b = append(b, 0x80|nal.typ, 0, 1, byte(len(unit)>>8), byte(len(unit)))
+		b = append(b, unit...)
+	}
+	return Box("hvcC", b)
+}
+
+// ItemData is the picture's coded slice as a HEIF item stores it, with a
+// four-byte length.
+func (p *Picture) ItemData() []byte {
+	unit := nalUnit(nalIDR, p.slice())
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(unit))), unit...)
+}
+
+// Item is one item of a sample file.
+type Item struct {
+	Type   string // hvc1 or grid
+	Hidden bool
+	Data   []byte
+	Props  [][]byte // boxes for the item's ipco entries
+	Dimg   []uint16 // with grid, the tiles
+	Auxl   uint16   // for an auxiliary image, such as alpha, its image
+	Thmb   uint16   // for a thumbnail, its image
+}
+
+// File writes a HEIF file of items, numbered from 1, with the first
+// as the primary one.
+func File(items []Item) []byte {
+	ftyp := Box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	meta := heifMeta(items, 0)
+	offset := uint32(len(ftyp) + len(meta) + 8)
+	meta = heifMeta(items, offset)
+
+	var mdat []byte
+	for _, it := range items {
+		if it.Type != "grid" {
+			mdat = append(mdat, it.Data...)
+		}
+	}
+	out := append(ftyp, meta...)
+	return append(out, Box("mdat", mdat)...)
+}
+
+func heifMeta(items []Item, mdatStart uint32) []byte {
+	hdlr := FullBox("hdlr", 0, 0, []byte("\x00\x00\x00\x00pict\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"))
+	pitm := FullBox("pitm", 0, 0, []byte{0, 1})
+
+	iinf := []byte{0, byte(len(items))}
+	var iref, ipco, idat []byte
+	ipma := binary.BigEndian.AppendUint32(nil, uint32(len(items)))
+	iloc := []byte{0x44, 0, 0, byte(len(items))}
+	mdatOffset := mdatStart
+	for i, it := range items {
+		id := uint16(i + 1)
+		var flags uint32
+		if it.Hidden {
+			flags = 1
+		}
+		infe := append(binary.BigEndian.AppendUint16(nil, id), 0, 0)
+		infe = append(append(infe, it.Type...), 0)
+		iinf = append(iinf, FullBox("infe", 2, flags, infe)...)
+
+		if len(it.Dimg) > 0 {
+			ref := append(binary.BigEndian.AppendUint16(nil, id), 0, byte(len(it.Dimg)))
+			for _, tile := range it.Dimg {
+				ref = binary.BigEndian.AppendUint16(ref, tile)
+			}
+			iref = append(iref, Box("dimg", ref)...)
+		}
+		if it.Auxl != 0 {
+			ref := append(binary.BigEndian.AppendUint16(nil, id), 0, 1)
+			iref = append(iref, Box("auxl", binary.BigEndian.AppendUint16(ref, it.Auxl))...)
+		}
+		if it.Thmb != 0 {
+			ref := append(binary.BigEndian.AppendUint16(nil, id), 0, 1)
+			iref = append(iref, Box("thmb", binary.BigEndian.AppendUint16(ref, it.Thmb))...)
+		}
+
+		ipma = append(binary.BigEndian.AppendUint16(ipma, id), byte(len(it.Props)))
+		for _, prop := range it.Props {
+			ipco = append(ipco, prop...)
+			ipma = append(ipma, 0x80|byte(countBoxes(ipco)))
+		}
+
+		iloc = binary.BigEndian.AppendUint16(iloc, id)
+		if it.Type == "grid" {
+			iloc = append(iloc, 0, 1, 0, 0, 0, 1) // in idat
+			iloc = binary.BigEndian.AppendUint32(iloc, uint32(len(idat)))
+			idat = append(idat, it.Data...)
+		} else {
+			iloc = append(iloc, 0, 0, 0, 0, 0, 1)
+			iloc = binary.BigEndian.AppendUint32(iloc, mdatOffset)
+			mdatOffset += uint32(len(it.Data))
+		}
+		iloc = binary.BigEndian.AppendUint32(iloc, uint32(len(it.Data)))
+	}
+
+	body := append(hdlr, pitm...)
+	body = append(body, FullBox("iinf", 0, 0, iinf)...)
+	if iref != nil {
+		body = append(body, FullBox("iref", 0, 0, iref)...)
+	}
+	iprp := append(Box("ipco", ipco), FullBox("ipma", 0, 0, ipma)...)
+	body = append(body, Box("iprp", iprp)...)
+	if idat != nil {
+		body = append(body, Box("idat", idat)...)
+	}
+	body = append(body, FullBox("iloc", 1, 0, iloc)...)
+	return FullBox("meta", 0, 0, body)
+}
+
+func countBoxes(b []byte) int {
+	n := 0
+	for len(b) >= 8 {
+		b = b[binary.BigEndian.Uint32(b):]
+		n++
+	}
+	return n
+}
+
+// Ispe is the image spatial extents property.
+func Ispe(width, height int) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(width))
+	return FullBox("ispe", 0, 0, binary.BigEndian.AppendUint32(b, uint32(height)))
+}
+
+// GridData describes a grid of rows×columns tiles covering width×height.
+func GridData(rows, columns, width, height int) []byte {
+	return []byte{0, 0, byte(rows - 1), byte(columns - 1), byte(width >> 8), byte(width), byte(height >> 8), byte(height)}
+}
+
+// Box is an ISO BMFF box holding payload.
+func Box(typ string, payload []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	return append(append(b, typ...), payload...)
+}
+
+// FullBox is a box with a version and flags.
+func FullBox(typ string, version uint8, flags uint32, payload []byte) []byte {
+	return Box(typ, append(binary.BigEndian.AppendUint32(nil, uint32(version)<<24|flags), payload...))
+}
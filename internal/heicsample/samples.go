@@ -0,0 +1,121 @@
+package heicsample
+
+import (
+	"image"
+	"image/color"
+)
+
+// Size is the side of a sample picture or grid tile.
+const Size = 64
+
+// Pattern is the 8-bit Y, Cb and Cr of a sample at x, y: gradients
+// that tell tiles and frames apart, so misplaced ones show.
+func Pattern(x, y int) (uint8, uint8, uint8) {
+	return uint8(16 + (3*x+2*y)%220), uint8(48 + x), uint8(208 - y)
+}
+
+// InvertedPattern is the second frame of the multi-image sample.
+func InvertedPattern(x, y int) (uint8, uint8, uint8) {
+	l, cb, cr := Pattern(x, y)
+	return 255 - l, cr, cb
+}
+
+// Single is a HEIC file holding one sample picture.
+func Single(depth int) []byte {
+	p := NewPicture(Size, Size, depth, Pattern)
+	return File([]Item{PictureItem(p, false)})
+}
+
+// Grid is a HEIC file tiled like the photos of iPhones: a 2×2 grid
+// of hidden pictures.
+func Grid() []byte {
+	items := []Item{{
+		Type:  "grid",
+		Data:  GridData(2, 2, 2*Size, 2*Size),
+		Props: [][]byte{Ispe(2*Size, 2*Size)},
+		Dimg:  []uint16{2, 3, 4, 5},
+	}}
+	for i := 0; i < 4; i++ {
+		x0, y0 := i%2*Size, i/2*Size
+		p := NewPicture(Size, Size, 8, func(x, y int) (uint8, uint8, uint8) {
+			return Pattern(x0+x, y0+y)
+		})
+		items = append(items, PictureItem(p, true))
+	}
+	return File(items)
+}
+
+// Tiled is a grid of columns×rows tiles of depth bits making up a
+// width×height image, cut from Pattern like Grid. sides changes the side
+// of some tiles, to make broken grids.
+func Tiled(depth, columns, rows, width, height int, sides map[int]int) []byte {
+	items := []Item{{
+		Type:  "grid",
+		Data:  GridData(rows, columns, width, height),
+		Props: [][]byte{Ispe(width, height)},
+	}}
+	for i := 0; i < columns*rows; i++ {
+		side := Size
+		if s, ok := sides[i]; ok {
+			side = s
+		}
+		x0, y0 := i%columns*Size, i/columns*Size
+		p := NewPicture(side, side, depth, func(x, y int) (uint8, uint8, uint8) {
+			return Pattern(x0+x, y0+y)
+		})
+		items[0].Dimg = append(items[0].Dimg, uint16(i+2))
+		items = append(items, PictureItem(p, true))
+	}
+	return File(items)
+}
+
+// Thumbnailed is a sample picture with thumbnails of the given sides, in
+// a flat gray so they tell from the picture.
+func Thumbnailed(sides ...int) []byte {
+	items := []Item{PictureItem(NewPicture(Size, Size, 8, Pattern), false)}
+	for _, side := range sides {
+		thumb := PictureItem(NewPicture(side, side, 8, grayPattern), false)
+		thumb.Thmb = 1
+		items = append(items, thumb)
+	}
+	return File(items)
+}
+
+func grayPattern(x, y int) (uint8, uint8, uint8) {
+	return 200, 128, 128
+}
+
+// Burst is a HEIC file holding two shots, like a burst.
+func Burst() []byte {
+	first := NewPicture(Size, Size, 8, Pattern)
+	second := NewPicture(Size, Size, 8, InvertedPattern)
+	return File([]Item{PictureItem(first, false), PictureItem(second, false)})
+}
+
+// PictureItem is the item of a sample picture.
+func PictureItem(p *Picture, hidden bool) Item {
+	return Item{Type: "hvc1", Hidden: hidden, Data: p.ItemData(), Props: [][]byte{p.HvcC(), Ispe(p.width, p.height)}}
+}
+
+// Matches reports whether img is the picture pattern describes,
+// allowing for rounding; chroma is compared where it was sampled. The
+// colors are compared in RGB, which decoders to RGB give.
+func Matches(img image.Image, pattern func(x, y int) (uint8, uint8, uint8)) bool {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || b.Dx()%Size != 0 || b.Dy()%Size != 0 {
+		return false
+	}
+	near := func(a, b uint32) bool { return a-b <= 3*0x101 || b-a <= 3*0x101 }
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			l, _, _ := pattern(x, y)
+			_, cb, cr := pattern(x&^1, y&^1)
+			wr, wg, wb, _ := color.YCbCr{Y: l, Cb: cb, Cr: cr}.RGBA()
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			if !near(r, wr) || !near(g, wg) || !near(bl, wb) {
+				return false
+			}
+		}
+	}
+	return true
+}
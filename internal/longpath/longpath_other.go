@@ -0,0 +1,10 @@
+//go:build !windows
+
+// Package longpath lets the files of heictojpeg be opened by paths over
+// MAX_PATH on Windows.
+package longpath
+
+// Path returns path: only Windows limits its length.
+func Path(path string) string {
+	return path
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package longpath
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Directories are limited to MAX_PATH-12 characters, so prefix anything close to it.
+const maxShortPath = 248
+
+// Path adds the \\?\ prefix Windows needs for paths over MAX_PATH.
+func Path(path string) string {
+	if len(path) < maxShortPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + strings.TrimPrefix(abs, `\\`)
+	}
+	return `\\?\` + abs
+}
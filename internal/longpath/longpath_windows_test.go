@@ -0,0 +1,26 @@
+//go:build windows
+
+package longpath
+
+import (
+	"strings"
+	"testing"
+)
+
+// Testing Path function
+func TestLongPath(t *testing.T) {
+	short := `C:\photos\IMG_0001.HEIC`
+	if got := Path(short); got != short {
+		t.Errorf("Short path should be unchanged, got %q", got)
+	}
+
+	long := `C:\photos\` + strings.Repeat("a", 260) + `\IMG_0001.HEIC`
+	if got := Path(long); got != `\\?\`+long {
+		t.Errorf("Long path should get the \\\\?\\ prefix, got %q", got)
+	}
+
+	unc := `\\nas\share\` + strings.Repeat("a", 260)
+	if got := Path(unc); got != `\\?\UNC\nas\share\`+strings.Repeat("a", 260) {
+		t.Errorf("Long UNC path should get the \\\\?\\UNC\\ prefix, got %q", got)
+	}
+}
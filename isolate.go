@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+var isolate = flag.Bool("isolate", false, "convert each file in a child process, so a decoder crash on a corrupt file fails only that file")
+
+const internalConvert = "--internal-convert"
+
+// childCommand builds the child process; tests replace it.
+var childCommand = func(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return exec.CommandContext(ctx, exe, args...), nil
+}
+
+func init() {
+	subcommands[internalConvert] = internalConvertCommand
+}
+
+// convertIsolated runs the conversion in a child process of this binary.
+// A crash in the decoder is reported as the file's error, and cancelling
+// ctx kills the child, which also stops a decoder that ignores the timeout.
+func convertIsolated(ctx context.Context, input, output string) error {
+	s := settingsFrom(ctx)
+	cmd, err := childCommand(ctx, internalConvert,
+		"-quality", strconv.Itoa(s.Quality),
+		"-timeout", fileTimeout.String(),
+		"-low-memory="+strconv.FormatBool(*lowMemory),
+		"-max-size", strconv.Itoa(s.MaxSize),
+		"-metadata", s.Metadata,
+		"-auto-quality="+strconv.FormatBool(s.AutoQuality),
+		"-target-ssim", strconv.FormatFloat(*targetSSIM, 'g', -1, 64),
+		"-crop", s.Crop,
+		"-best-frame="+strconv.FormatBool(*bestFrame),
+		"-crop-focus", s.CropFocus,
+		"-color-target", *colorTarget,
+		"-bit-depth", *bitDepth,
+		"-alpha", *alphaPolicy,
+		"-verify-grid="+strconv.FormatBool(*verifyGrid),
+		"-extra-outputs", *extraOutputs,
+		"-profiles", *profileNames,
+		input, output,
+	)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	out := strings.TrimSpace(stderr.String())
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && out != "" {
+		// A regular failure: the error is the last line.
+		return errors.New(out[strings.LastIndexByte(out, '\n')+1:])
+	}
+	if out != "" {
+		// A panic or fatal signal: the first line says which.
+		if i := strings.IndexByte(out, '\n'); i >= 0 {
+			out = out[:i]
+		}
+		return fmt.Errorf("decoder crashed (%v): %s", err, out)
+	}
+	return fmt.Errorf("decoder crashed (%v)", err)
+}
+
+// internalConvertCommand is the child side of -isolate: the conversion
+// settings, then the input and output paths. It exits 1 with the error
+// as the last line of stderr when the conversion fails.
+func internalConvertCommand(args []string) error {
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+	if flag.NArg() != 2 {
+		return errors.New("usage: heictojpeg --internal-convert [flags] input output")
+	}
+	if *maxMemory != "" {
+		var err error
+		if maxMemoryBytes, err = parseByteSize(*maxMemory); err != nil {
+			return err
+		}
+	}
+	if *extraOutputs != "" || *profileNames != "" {
+		// The presets and profiles may be those of the config file.
+		if err := readConfigDefinitions(); err != nil {
+			return err
+		}
+	}
+	if err := convertHeicToJpg(context.Background(), flag.Arg(0), flag.Arg(1)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestIsolatedChildProcess is the child process of the tests below.
+func TestIsolatedChildProcess(t *testing.T) {
+	if os.Getenv("HEICTOJPEG_TEST_CHILD") == "" {
+		return
+	}
+	args := os.Args[len(os.Args)-2:]
+	if strings.HasSuffix(args[0], "crash.heic") {
+		panic("simulated decoder crash")
+	}
+	internalConvertCommand(args)
+	os.Exit(0)
+}
+
+func useTestChild(t *testing.T) {
+	old := childCommand
+	childCommand = func(ctx context.Context, args ...string) (*exec.Cmd, error) {
+		cmd := exec.CommandContext(ctx, os.Args[0], append([]string{"-test.run=TestIsolatedChildProcess", "--"}, args[len(args)-2:]...)...)
+		cmd.Env = append(os.Environ(), "HEICTOJPEG_TEST_CHILD=1")
+		return cmd, nil
+	}
+	t.Cleanup(func() { childCommand = old })
+}
+
+func TestConvertIsolated(t *testing.T) {
+	useTestChild(t)
+	dir := t.TempDir()
+
+	err := convertIsolated(context.Background(), filepath.Join(dir, "missing.heic"), filepath.Join(dir, "out.jpg"))
+	if err == nil || !strings.Contains(err.Error(), "missing.heic") || strings.Contains(err.Error(), "crashed") {
+		t.Errorf("conversion error = %v, want the child's error", err)
+	}
+
+	err = convertIsolated(context.Background(), filepath.Join(dir, "crash.heic"), filepath.Join(dir, "out.jpg"))
+	if err == nil || !strings.Contains(err.Error(), "decoder crashed") {
+		t.Errorf("crash error = %v, want decoder crashed", err)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const journalEnv = "HEICTOJPEG_JOURNAL"
+
+// keepJournals is how many runs can be undone; older journals are removed
+// when a run starts.
+const keepJournals = 20
+
+const (
+	journalStart   = "start"   // Path is the folder the run converted
+	journalCreated = "created" // Path was written by the run
+	journalMoved   = "moved"   // Path was moved to To
+	journalDeleted = "deleted" // Path was deleted
+)
+
+// journalEntry is one line of a run's journal.
+type journalEntry struct {
+	Action string    `json:"action"`
+	Path   string    `json:"path"`
+	To     string    `json:"to,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// journal records what a run did to the file system, one JSON line per
+// action as it happens, so heictojpeg undo can reverse it even after a
+// crash.
+type journal struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+type journalKey struct{}
+
+func init() {
+	subcommands["undo"] = undoCommand
+}
+
+// journalDir is $HEICTOJPEG_JOURNAL, or the journal folder in the user
+// config directory.
+func journalDir() (string, error) {
+	if dir := os.Getenv(journalEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "heictojpeg", "journal"), nil
+}
+
+// startJournal creates the journal of a run converting dir and removes
+// the oldest ones beyond keepJournals.
+func startJournal(dir string) (*journal, error) {
+	journals, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(journals, 0755); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	name := now.UTC().Format("2006-01-02T15-04-05.000000000") + ".jsonl"
+	f, err := os.OpenFile(filepath.Join(journals, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{f: f, enc: json.NewEncoder(f)}
+	j.add(journalEntry{Action: journalStart, Path: dir, Time: now})
+	pruneJournals(journals, keepJournals)
+	return j, nil
+}
+
+// pruneJournals removes all but the newest keep journals in dir, undone
+// or not. Their names start with the time, so they sort by age.
+func pruneJournals(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(entries)-keep; i++ {
+		os.Remove(filepath.Join(dir, entries[i].Name()))
+	}
+}
+
+func withJournal(ctx context.Context, j *journal) context.Context {
+	return context.WithValue(ctx, journalKey{}, j)
+}
+
+func journalFrom(ctx context.Context) *journal {
+	j, _ := ctx.Value(journalKey{}).(*journal)
+	return j
+}
+
+func (j *journal) add(e journalEntry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if abs, err := filepath.Abs(e.Path); err == nil {
+		e.Path = abs
+	}
+	if e.To != "" {
+		if abs, err := filepath.Abs(e.To); err == nil {
+			e.To = abs
+		}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(e); err != nil {
+		fmt.Printf(tr("Failed to write the journal: %v\n"), err)
+	}
+}
+
+func (j *journal) created(path string) {
+	j.add(journalEntry{Action: journalCreated, Path: path})
+}
+
+func (j *journal) moved(from, to string) {
+	j.add(journalEntry{Action: journalMoved, Path: from, To: to})
+}
+
+func (j *journal) deleted(path string) {
+	j.add(journalEntry{Action: journalDeleted, Path: path})
+}
+
+// quarantined records the copy or move of src to dst by -quarantine and
+// the error report written next to it.
+func (j *journal) quarantined(src, dst string) {
+	if *quarantine == "move" {
+		j.moved(src, dst)
+	} else {
+		j.created(dst)
+	}
+	j.created(dst + ".error.txt")
+}
+
+func (j *journal) close() error {
+	return j.f.Close()
+}
+
+// listJournals returns the journals in dir that haven't been undone,
+// oldest first.
+func listJournals(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func readJournal(path string) ([]journalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []journalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var e journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A run that crashed mid-write leaves a partial last line.
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// undoCommand reverses the most recent run: the files it wrote are
+// deleted and the originals it moved are put back.
+func undoCommand(args []string) error {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	last := fs.Bool("last", false, "undo the most recent run")
+	dryRun := fs.Bool("dry-run", false, "only list what would be undone")
+	fs.Parse(args)
+	if !*last {
+		return errors.New("usage: heictojpeg undo -last [-dry-run]")
+	}
+
+	dir, err := journalDir()
+	if err != nil {
+		return err
+	}
+	runs, err := listJournals(dir)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return errors.New("no run to undo")
+	}
+	path := runs[len(runs)-1]
+	entries, err := readJournal(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && entries[0].Action == journalStart {
+		fmt.Printf(tr("Undoing the run of %s in %s\n"), entries[0].Time.Local().Format("2006-01-02 15:04:05"), entries[0].Path)
+	}
+	failed := undoEntries(entries, *dryRun)
+	if *dryRun {
+		return nil
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d actions couldn't be undone; run it again after fixing them", failed)
+	}
+	return os.Rename(path, strings.TrimSuffix(path, ".jsonl")+".undone")
+}
+
+// undoEntries reverses entries, last first, and returns how many failed.
+// Files already gone count as undone.
+func undoEntries(entries []journalEntry, dryRun bool) int {
+	failed := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		var err error
+		switch e.Action {
+		case journalCreated:
+			fmt.Printf(tr("Delete %s\n"), e.Path)
+			if !dryRun {
+				if err = os.Remove(longPath(e.Path)); errors.Is(err, os.ErrNotExist) {
+					err = nil
+				}
+			}
+		case journalMoved:
+			fmt.Printf(tr("Move %s back to %s\n"), e.To, e.Path)
+			if !dryRun {
+				err = restoreFile(e.To, e.Path)
+			}
+		case journalDeleted:
+			fmt.Printf(tr("Can't restore %s: it was deleted without -archive-dir\n"), e.Path)
+		}
+		if err != nil {
+			fmt.Printf(tr("Failed: %v\n"), err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// restoreFile moves from back to its original place, without replacing a
+// file that has appeared there since.
+func restoreFile(from, to string) error {
+	if _, err := os.Lstat(longPath(to)); err == nil {
+		if _, err := os.Lstat(longPath(from)); errors.Is(err, os.ErrNotExist) {
+			return nil // already restored
+		}
+		return fmt.Errorf("%s already exists", to)
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(to)), 0755); err != nil {
+		return err
+	}
+	return moveFile(from, to)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUndoLast(t *testing.T) {
+	t.Setenv(journalEnv, t.TempDir())
+	dir := t.TempDir()
+	output := filepath.Join(dir, "jpegs", "IMG_0001.jpg")
+	original := filepath.Join(dir, "IMG_0001.heic")
+	archived := filepath.Join(dir, "archive", "IMG_0001.heic")
+	os.MkdirAll(filepath.Dir(output), 0755)
+	os.MkdirAll(filepath.Dir(archived), 0755)
+	os.WriteFile(output, []byte("jpeg"), 0644)
+	os.WriteFile(archived, []byte("heic"), 0644)
+
+	j, err := startJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.created(output)
+	j.moved(original, archived)
+	j.created(filepath.Join(dir, "jpegs", "gone.jpg"))
+	j.close()
+
+	if err := undoCommand([]string{"-last", "-dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(output) {
+		t.Fatal("-dry-run deleted the output")
+	}
+	if err := undoCommand([]string{"-last"}); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(output) {
+		t.Error("output still there")
+	}
+	if data, err := os.ReadFile(original); err != nil || string(data) != "heic" {
+		t.Errorf("restored original = %q, %v", data, err)
+	}
+	if err := undoCommand([]string{"-last"}); err == nil || !strings.Contains(err.Error(), "no run") {
+		t.Errorf("second undo: %v", err)
+	}
+}
+
+func TestRestoreFileKeepsNewFile(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "archived.heic"), filepath.Join(dir, "IMG_0001.heic")
+	os.WriteFile(from, []byte("old"), 0644)
+	os.WriteFile(to, []byte("new"), 0644)
+	if err := restoreFile(from, to); err == nil {
+		t.Error("replaced a file that appeared since")
+	}
+	if data, _ := os.ReadFile(to); string(data) != "new" {
+		t.Errorf("file = %q", data)
+	}
+}
+
+func TestReadJournalPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	os.WriteFile(path, []byte(`{"action":"created","path":"/a.jpg"}`+"\n"+`{"action":"crea`), 0644)
+	entries, err := readJournal(path)
+	if err != nil || len(entries) != 1 || entries[0].Path != "/a.jpg" {
+		t.Errorf("readJournal = %+v, %v", entries, err)
+	}
+}
+
+func TestPruneJournals(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2024-01-01.jsonl", "2024-01-02.undone", "2024-01-03.jsonl"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	pruneJournals(dir, 2)
+	if fileExists(filepath.Join(dir, "2024-01-01.jsonl")) || !fileExists(filepath.Join(dir, "2024-01-03.jsonl")) {
+		t.Error("pruned the wrong journals")
+	}
+}
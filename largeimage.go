@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/adrium/goheif/heif"
+)
+
+var maxMemory = flag.String("max-memory", "", "warn when decoding a file would need more than this much memory, e.g. 2GB; tiled files over it are decoded in bands as with -low-memory")
+
+// maxJPEGSide is the largest width or height a JPEG can store.
+const maxJPEGSide = 65535
+
+// maxMemoryBytes is the parsed -max-memory; 0 means no limit.
+var maxMemoryBytes int64
+
+// imageLayout reads the dimensions of a HEIC file's primary image and
+// whether it is tiled, without decoding it.
+func imageLayout(path string) (width, height int, tiled bool, err error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer f.Close()
+	item, err := heif.Open(f).PrimaryItem()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	width, height, ok := item.SpatialExtents()
+	if !ok {
+		return 0, 0, false, fmt.Errorf("no dimension")
+	}
+	return width, height, item.Info != nil && item.Info.ItemType == "grid", nil
+}
+
+// decodedSize is the memory a decoded 4:2:0 frame takes: one byte of luma
+// and half a byte of chroma per pixel.
+func decodedSize(width, height int) int64 {
+	return int64(width) * int64(height) * 3 / 2
+}
+
+// memoryPlan checks that an image can be converted at all and reports the
+// memory its full frame needs and whether to decode it in bands instead.
+func memoryPlan(width, height int, tiled bool, limit int64) (needed int64, banded bool, err error) {
+	if width > maxJPEGSide || height > maxJPEGSide {
+		return 0, false, fmt.Errorf("%dx%d is larger than a JPEG can be (%d pixels per side)", width, height, maxJPEGSide)
+	}
+	needed = decodedSize(width, height)
+	if needed > math.MaxInt32 && math.MaxInt == math.MaxInt32 {
+		if !tiled {
+			return needed, false, fmt.Errorf("%dx%d needs %s, more than a 32-bit build can address", width, height, humanReadableFileSize(needed))
+		}
+		return needed, true, nil
+	}
+	return needed, limit > 0 && needed > limit && tiled, nil
+}
+
+// planConversion applies the size guards to a file before it is decoded.
+// It returns whether to use the banded decoder.
+func planConversion(input string) (bool, error) {
+	width, height, tiled, err := imageLayout(input)
+	if err != nil {
+		return false, nil // let the decoder report it
+	}
+	needed, banded, err := memoryPlan(width, height, tiled, maxMemoryBytes)
+	if err != nil {
+		return false, err
+	}
+	if maxMemoryBytes > 0 && needed > maxMemoryBytes {
+		if banded {
+			fmt.Printf(tr("%s (%dx%d) needs %s to decode, over -max-memory; decoding it in bands.\n"), input, width, height, humanReadableFileSize(needed))
+		} else {
+			fmt.Printf(tr("Warning: %s (%dx%d) needs %s to decode, over -max-memory.\n"), input, width, height, humanReadableFileSize(needed))
+		}
+	}
+	return banded, nil
+}
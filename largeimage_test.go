@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]int64{"512": 512, "4KB": 4096, "1.5mb": 3 << 19, "4GB": 4 << 30, "2 TB": 2 << 40} {
+		if got, err := parseByteSize(in); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "GB", "-1MB", "lots"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Errorf("parseByteSize(%q) should fail", in)
+		}
+	}
+}
+
+func TestMemoryPlan(t *testing.T) {
+	if _, _, err := memoryPlan(70000, 1000, true, 0); err == nil || !strings.Contains(err.Error(), "larger than a JPEG") {
+		t.Errorf("70000 px wide: err = %v", err)
+	}
+	needed, banded, err := memoryPlan(16384, 8192, true, 64<<20)
+	if err != nil || needed != 16384*8192*3/2 || !banded {
+		t.Errorf("16K tiled over the limit = %d, %v, %v; want banded", needed, banded, err)
+	}
+	if _, banded, _ := memoryPlan(16384, 8192, false, 64<<20); banded {
+		t.Error("an untiled image can't be banded")
+	}
+	if _, banded, _ := memoryPlan(4032, 3024, true, 1<<30); banded {
+		t.Error("an image under the limit should decode normally")
+	}
+}
+
+// TestTruncatedSizeOver4GB checks the box walk with 64-bit sizes on a
+// sparse file larger than 4GB.
+func TestTruncatedSizeOver4GB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.heic")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	const mdatSize = 5 << 30
+	header := make([]byte, 32)
+	binary.BigEndian.PutUint32(header, 16)
+	copy(header[4:], "ftyp")
+	binary.BigEndian.PutUint32(header[16:], 1)
+	copy(header[20:], "mdat")
+	f.Write(header[:24])
+	binary.Write(f, binary.BigEndian, uint64(mdatSize))
+	if err := f.Truncate(16 + mdatSize); err != nil {
+		t.Skipf("no sparse files here: %v", err)
+	}
+
+	size := getFileSize(path)
+	if size != 16+mdatSize {
+		t.Fatalf("getFileSize = %d", size)
+	}
+	if _, truncated := truncatedSize(f, size); truncated {
+		t.Error("5GB file reported as truncated")
+	}
+	if want, truncated := truncatedSize(f, size-1); !truncated || want != size {
+		t.Errorf("truncatedSize(size-1) = %d, %v", want, truncated)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const lockFileName = ".heictojpeg.lock"
+
+var waitForLock = flag.Bool("wait", false, "wait for another run using the same jpegs folder to finish instead of exiting")
+
+// lockHeldError reports a lock owned by a live process.
+type lockHeldError struct {
+	msg string
+}
+
+func (e *lockHeldError) Error() string {
+	return e.msg
+}
+
+// runLock is held for the duration of a run so that two invocations
+// targeting the same output directory don't race on the same files. It is
+// an advisory lock the system holds on the open lock file, so it goes away
+// with a run that crashes and a lock file left behind is never stale.
+type runLock struct {
+	path string
+	file *os.File
+}
+
+func acquireLock(jpegDir string) (*runLock, error) {
+	lockPath := filepath.Join(jpegDir, lockFileName)
+	for {
+		f, err := os.OpenFile(longPath(lockPath), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lock file: %v", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			if !errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("failed to lock %s: %v", lockPath, err)
+			}
+			pid, started, err := readLock(lockPath)
+			if err != nil {
+				return nil, &lockHeldError{fmt.Sprintf(tr("another heictojpeg run is already converting into %s; wait for it to finish or use -wait"), jpegDir)}
+			}
+			return nil, &lockHeldError{fmt.Sprintf(tr("another heictojpeg run (pid %d, started %s) is already converting into %s; wait for it to finish or use -wait"),
+				pid, started, jpegDir)}
+		}
+		// The run before may have removed the file between our opening
+		// and locking it, leaving us a lock on a file no one else sees.
+		opened, err := f.Stat()
+		current, statErr := os.Stat(longPath(lockPath))
+		if err != nil || statErr != nil || !os.SameFile(opened, current) {
+			f.Close()
+			continue
+		}
+		f.Truncate(0)
+		fmt.Fprintf(f, "%d\n%s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+		return &runLock{path: lockPath, file: f}, nil
+	}
+}
+
+// lockOutputDir takes the lock for jpegDir, queueing behind the current
+// holder with -wait.
+func lockOutputDir(ctx context.Context, jpegDir string) (*runLock, error) {
+	announced := false
+	for {
+		lock, err := acquireLock(jpegDir)
+		var held *lockHeldError
+		if !*waitForLock || !errors.As(err, &held) {
+			return lock, err
+		}
+		if !announced {
+			fmt.Println(tr("Waiting for the other run to finish..."))
+			announced = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func readLock(path string) (int, string, error) {
+	data, err := os.ReadFile(longPath(path))
+	if err != nil {
+		return 0, "", err
+	}
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return 0, "", err
+	}
+	started := "at an unknown time"
+	if len(lines) > 1 {
+		started = strings.TrimSpace(lines[1])
+	}
+	return pid, started, nil
+}
+
+func (l *runLock) release() {
+	releaseLockFile(l.file, l.path)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Testing acquireLock against a concurrent run
+func TestAcquireLockHeld(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := acquireLock(dir)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	if _, err := acquireLock(dir); err == nil {
+		t.Fatalf("Second lock should fail while the first is held")
+	}
+	// Nor is a lock taken over while its pid is still being written.
+	if err := os.Truncate(filepath.Join(dir, lockFileName), 0); err != nil {
+		t.Fatal(err)
+	}
+	var held *lockHeldError
+	if _, err := acquireLock(dir); !errors.As(err, &held) {
+		t.Fatalf("Second lock should fail while the first is held: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, lockFileName)); err != nil {
+		t.Fatalf("The held lock file was removed: %v", err)
+	}
+
+	lock.release()
+	lock, err = acquireLock(dir)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock after release: %v", err)
+	}
+	lock.release()
+}
+
+// Testing acquireLock with a lock left behind by a dead process
+func TestAcquireLockStale(t *testing.T) {
+	dir := t.TempDir()
+	stale := []byte("2147483646\n2024-01-01T00:00:00Z\n")
+	if err := os.WriteFile(filepath.Join(dir, lockFileName), stale, 0644); err != nil {
+		t.Fatalf("Failed to write stale lock: %v", err)
+	}
+
+	lock, err := acquireLock(dir)
+	if err != nil {
+		t.Fatalf("Stale lock should be reclaimed: %v", err)
+	}
+	lock.release()
+}
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// errLocked is returned by lockFile for a file another run has locked.
+var errLocked = errors.New("locked by another process")
+
+func lockFile(f *os.File) error {
+	err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if err == unix.EWOULDBLOCK {
+		return errLocked
+	}
+	return err
+}
+
+// releaseLockFile removes the lock file while it is still locked, so a run
+// that opened it in the meantime notices it is gone, then unlocks it.
+func releaseLockFile(f *os.File, path string) {
+	if err := os.Remove(longPath(path)); err != nil && !os.IsNotExist(err) {
+		fmt.Printf(tr("Failed to remove lock file: %v\n"), err)
+	}
+	f.Close()
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// errLocked is returned by lockFile for a file another run has locked.
+var errLocked = errors.New("locked by another process")
+
+// lockFile locks a byte far past the end of f, so other runs can still
+// read the pid written in it.
+func lockFile(f *os.File) error {
+	overlapped := windows.Overlapped{OffsetHigh: 0x7fffffff}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &overlapped)
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return errLocked
+	}
+	return err
+}
+
+// releaseLockFile unlocks the lock file and then removes it. Windows
+// doesn't remove a file another run has open, so one that opened it in the
+// meantime keeps it; the failure is expected and not reported.
+func releaseLockFile(f *os.File, path string) {
+	f.Close()
+	os.Remove(longPath(path))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var keepLogs = flag.Int("keep-logs", 10, "previous logs.txt files to keep as logs-<time>.txt; 0 overwrites logs.txt")
+
+const (
+	runHistoryFileName = "history.csv"
+	rotatedLogLayout   = "2006-01-02T15-04"
+)
+
+var runHistoryHeader = []string{"finished", "files", "converted", "failed", "duration_seconds", "input_bytes", "output_bytes"}
+
+// rotateLogs renames the logs.txt of the previous run after the time it
+// was written, e.g. logs-2024-06-01T10-00.txt, and removes the oldest
+// rotated files beyond keep.
+func rotateLogs(jpegDir string, keep int) error {
+	current := filepath.Join(jpegDir, logFileName)
+	info, err := os.Stat(longPath(current))
+	if os.IsNotExist(err) || keep <= 0 {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	stamp := info.ModTime().Format(rotatedLogLayout)
+	rotated := filepath.Join(jpegDir, "logs-"+stamp+".txt")
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(longPath(rotated)); os.IsNotExist(err) {
+			break
+		}
+		rotated = filepath.Join(jpegDir, fmt.Sprintf("logs-%s-%d.txt", stamp, n))
+	}
+	if err := os.Rename(longPath(current), longPath(rotated)); err != nil {
+		return err
+	}
+
+	old, err := rotatedLogs(jpegDir)
+	if err != nil {
+		return err
+	}
+	for len(old) > keep {
+		if err := os.Remove(longPath(filepath.Join(jpegDir, old[0]))); err != nil {
+			return err
+		}
+		old = old[1:]
+	}
+	return nil
+}
+
+// rotatedLogs lists the rotated log files in jpegDir, oldest first.
+func rotatedLogs(jpegDir string) ([]string, error) {
+	entries, err := os.ReadDir(longPath(jpegDir))
+	if err != nil {
+		return nil, err
+	}
+	type rotatedLog struct {
+		name    string
+		written time.Time
+	}
+	var logs []rotatedLog
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "logs-") || filepath.Ext(name) != ".txt" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, rotatedLog{name, info.ModTime()})
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].written.Equal(logs[j].written) {
+			return logs[i].written.Before(logs[j].written)
+		}
+		return logs[i].name < logs[j].name
+	})
+	names := make([]string, len(logs))
+	for i, l := range logs {
+		names[i] = l.name
+	}
+	return names, nil
+}
+
+// appendRunHistory adds a line for this run to jpegs/history.csv, which
+// is never truncated, so progress over many runs stays visible.
+func appendRunHistory(jpegDir string, finished time.Time, summary convert.Summary) error {
+	path := filepath.Join(jpegDir, runHistoryFileName)
+	_, err := os.Stat(longPath(path))
+	isNew := os.IsNotExist(err)
+
+	f, err := os.OpenFile(longPath(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if isNew {
+		w.Write(runHistoryHeader)
+	}
+	w.Write([]string{
+		finished.Format(time.RFC3339),
+		strconv.Itoa(summary.Files),
+		strconv.Itoa(summary.Files - summary.Failed),
+		strconv.Itoa(summary.Failed),
+		strconv.FormatFloat(summary.Duration.Seconds(), 'f', 3, 64),
+		strconv.FormatInt(summary.InputSize, 10),
+		strconv.FormatInt(summary.OutputSize, 10),
+	})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
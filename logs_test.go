@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+func TestRotateLogs(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, logFileName)
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)
+	for i := 0; i < 4; i++ {
+		if err := os.WriteFile(current, []byte("run"), 0644); err != nil {
+			t.Fatalf("Failed to write logs: %v", err)
+		}
+		written := start.Add(time.Duration(i) * time.Hour)
+		os.Chtimes(current, written, written)
+		if err := rotateLogs(dir, 2); err != nil {
+			t.Fatalf("rotateLogs: %v", err)
+		}
+	}
+
+	got, err := rotatedLogs(dir)
+	if err != nil {
+		t.Fatalf("rotatedLogs: %v", err)
+	}
+	want := []string{"logs-2024-06-01T12-00.txt", "logs-2024-06-01T13-00.txt"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("rotated logs = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(current); !os.IsNotExist(err) {
+		t.Errorf("logs.txt should have been rotated")
+	}
+}
+
+func TestAppendRunHistory(t *testing.T) {
+	dir := t.TempDir()
+	finished := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		summary := convert.Summary{Files: 3, Failed: 1, Duration: 1500 * time.Millisecond, InputSize: 300, OutputSize: 200}
+		if err := appendRunHistory(dir, finished, summary); err != nil {
+			t.Fatalf("appendRunHistory: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, runHistoryFileName))
+	if err != nil {
+		t.Fatalf("Failed to read history.csv: %v", err)
+	}
+	row := "2024-06-01T10:00:00Z,3,2,1,1.500,300,200\n"
+	if want := strings.Join(runHistoryHeader, ",") + "\n" + row + row; string(data) != want {
+		t.Errorf("history.csv = %q, want %q", data, want)
+	}
+}
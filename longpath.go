@@ -0,0 +1,8 @@
+package main
+
+import "heictojpeg/internal/longpath"
+
+// longPath is the path to open path by, even past MAX_PATH on Windows.
+func longPath(path string) string {
+	return longpath.Path(path)
+}
@@ -1,79 +1,664 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"image"
 	"image/jpeg"
 	"io"
 	"log"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"heictojpeg/convert"
+
 	"github.com/adrium/goheif"
 )
 
 const logFileName = "logs.txt"
 
+var quality = flag.Int("quality", jpeg.DefaultQuality, "JPEG quality (1-100)")
+
+var fileTimeout = flag.Duration("timeout", 2*time.Minute, "give up on a file whose decode takes longer than this (0 disables)")
+
+var workers = flag.Int("workers", 0, "number of files converted in parallel (0 uses one per CPU)")
+
+var sourceDir = flag.String("source", "", "folder to convert when no files or folders are given (default the current directory)")
+
+func init() {
+	// Without it, goheif returns an untiled image in the decoder's memory,
+	// which is freed before the image is encoded.
+	goheif.SafeEncoding = true
+}
+
+// subcommands are dispatched on the first argument and registered by the
+// files implementing them.
+var subcommands = map[string]func(args []string) error{}
+
 func main() {
-	fmt.Println("Starting the program...")
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			setLanguage("")
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+	if firstRun() {
+		setLanguage("")
+		if err := setupCommand(nil); err != nil {
+			log.Fatalf("setup: %v", err)
+		}
+	}
+
+	var command string
+	if len(os.Args) > 1 && (os.Args[1] == convertCommand || os.Args[1] == workerCommand || os.Args[1] == serveCommand || os.Args[1] == botCommand || os.Args[1] == mailCommand || os.Args[1] == syncCommand) {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	flag.Parse()
+	configErr := loadConfig()
+	setLanguage(*langFlag)
+	if configErr != nil {
+		log.Fatalf(tr("Failed to read the config file: %v"), configErr)
+	}
+	if command == syncCommand {
+		if err := setupMirror(flag.Args()); err != nil {
+			log.Fatalf("sync: %v", err)
+		}
+	}
+	if *symlinkNames != "link" && *symlinkNames != "target" {
+		log.Fatalf(tr("Invalid -symlink-names %q: must be link or target"), *symlinkNames)
+	}
+	percent, err := parseThrottle(*throttle)
+	if err != nil {
+		log.Fatalf(tr("Invalid -throttle %q: %v"), *throttle, err)
+	}
+	throttlePercent = percent
+	if *quarantine != "" && *quarantine != "copy" && *quarantine != "move" {
+		log.Fatalf(tr("Invalid -quarantine %q: must be copy or move"), *quarantine)
+	}
+	if !orders[*order] {
+		log.Fatalf(tr("Invalid -order %q: must be name, size-asc, size-desc or mtime"), *order)
+	}
+	level, ok := verbosityLevels[*verbosity]
+	if !ok {
+		log.Fatalf(tr("Invalid -verbosity %q: must be quiet, normal, verbose or debug"), *verbosity)
+	}
+	if *verbose && level < levelVerbose {
+		level = levelVerbose
+	}
+	logLevel = level
+	if !metadataPolicies[*metadata] {
+		log.Fatalf(tr("Invalid -metadata %q: must be keep or strip"), *metadata)
+	}
+	if *maxSize < 0 {
+		log.Fatalf(tr("Invalid -max-size %d: must be 0 or more pixels"), *maxSize)
+	}
+	if _, _, err := convert.ParseAspect(*cropAspect); *cropAspect != "" && err != nil {
+		log.Fatalf(tr("Invalid -crop: %v"), err)
+	}
+	if !cropFocuses[*cropFocus] {
+		log.Fatalf(tr("Invalid -crop-focus %q: must be center or subject"), *cropFocus)
+	}
+	if *bestFrame && *allFrames {
+		log.Fatal(tr("-best-frame and -all-frames can't be used together"))
+	}
+	if *videoCRF < 0 || *videoCRF > 51 {
+		log.Fatalf(tr("Invalid -video-crf %d: must be between 0 and 51"), *videoCRF)
+	}
+	if *videos {
+		if _, err := exec.LookPath(*ffmpegPath); err != nil {
+			log.Fatalf(tr("-videos needs ffmpeg: %v"), err)
+		}
+	}
+	if !screenshotModes[*screenshots] {
+		log.Fatalf(tr("Invalid -screenshots %q: must be jpeg, png or skip"), *screenshots)
+	}
+	if *convertToSRGB {
+		*colorTarget = "srgb"
+	}
+	if !colorTargets[*colorTarget] {
+		log.Fatalf(tr("Invalid -color-target %q: must be srgb, p3 or keep"), *colorTarget)
+	}
+	if !bitDepths[*bitDepth] {
+		log.Fatalf(tr("Invalid -bit-depth %q: must be 8, 16 or keep"), *bitDepth)
+	}
+	if !deepFormats[*deepFormat] {
+		log.Fatalf(tr("Invalid -deep-format %q: must be png or tiff"), *deepFormat)
+	}
+	if _, _, err := convert.ParseAlpha(*alphaPolicy); err != nil {
+		log.Fatalf(tr("Invalid -alpha %q: must be flatten:#rrggbb, png or drop"), *alphaPolicy)
+	}
+	if *targetSSIM <= 0 || *targetSSIM > 1 {
+		log.Fatalf(tr("Invalid -target-ssim %v: must be above 0 and at most 1"), *targetSSIM)
+	}
+	if !reportSorts[*sortReport] {
+		log.Fatalf(tr("Invalid -sort %q: must be path, size, duration or status"), *sortReport)
+	}
+	if *pipeOutputs {
+		switch {
+		case runtime.GOOS == "windows":
+			log.Fatal(tr("-pipes is not supported on Windows"))
+		case *outputFormat != "ndjson":
+			log.Fatal(tr("-pipes needs -output ndjson to announce the pipes"))
+		case *isolate || *lowMemory || *stagingMB > 0 || *repair || *allFrames || *useHistory || *perceptualHashes || splitEnabled():
+			log.Fatal(tr("-pipes can't be used with -isolate, -low-memory, -staging-mb, -repair, -all-frames, -history, -hashes or -split-output, which need the JPEG files"))
+		case *deleteOriginals:
+			log.Fatal(tr("-pipes can't be used with -delete-originals, which needs the JPEG files"))
+		}
+	}
+	var scheduled *cronSchedule
+	if *schedule != "" {
+		if scheduled, err = parseSchedule(*schedule); err != nil {
+			log.Fatalf(tr("Invalid -schedule %q: %v"), *schedule, err)
+		}
+		if *filesFrom != "" || *tuiMode {
+			log.Fatal(tr("-schedule can't be used with -files or -tui"))
+		}
+	}
+	if *watchFolder {
+		if !watchStrategies[*watchStrategy] {
+			log.Fatalf(tr("Invalid -watch-mode %q: must be auto, notify or poll"), *watchStrategy)
+		}
+		if *watchInterval < time.Second {
+			log.Fatalf(tr("Invalid -watch-interval %v: must be 1s or more"), *watchInterval)
+		}
+		if scheduled != nil || *filesFrom != "" || *tuiMode || flag.NArg() > 0 {
+			log.Fatal(tr("-watch can't be used with -schedule, -files, -tui or files and folders on the command line"))
+		}
+	}
+	if *filesFrom != "" && *filesFrom != "-" {
+		log.Fatalf(tr("Invalid -files %q: only - (standard input) is supported"), *filesFrom)
+	}
+	if *filesFrom != "" && flag.NArg() > 0 {
+		log.Fatalf(tr("-files - can't be combined with files or folders on the command line"))
+	}
+	if !dedupeActions[*dedupeAction] {
+		log.Fatalf(tr("Invalid -dedupe %q: must be skip or link"), *dedupeAction)
+	}
+	if *dedupeDistance < 0 || *dedupeDistance > 64 {
+		log.Fatalf(tr("Invalid -dedupe-distance %d: must be from 0 to 64"), *dedupeDistance)
+	}
+	if _, ok := sanitizers[*sanitize]; *sanitize != "" && !ok {
+		log.Fatalf(tr("Invalid -sanitize %q: must be fat32 or exfat"), *sanitize)
+	}
+	if *splitOutputSize != "" {
+		if splitOutputBytes, err = parseByteSize(*splitOutputSize); err != nil {
+			log.Fatalf(tr("Invalid -split-output %q: %v"), *splitOutputSize, err)
+		}
+	}
+	if *maxMemory != "" {
+		if maxMemoryBytes, err = parseByteSize(*maxMemory); err != nil {
+			log.Fatalf(tr("Invalid -max-memory %q: %v"), *maxMemory, err)
+		}
+	}
+	if cacheLimitBytes, err = parseByteSize(*cacheSize); err != nil {
+		log.Fatalf(tr("Invalid -cache-size %q: %v"), *cacheSize, err)
+	}
+	if *inPlace && (*outDir != "" || *organizeByLocation || splitEnabled() || *pipeOutputs) {
+		log.Fatal(tr("-in-place can't be used with -out, -organize-by-location, -split-output or -pipes, which need a separate output folder"))
+	}
+	if *readOnly {
+		if err := readOnlyConflicts(); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if *keepOwner && runtime.GOOS == "windows" {
+		log.Fatal(tr("-keep-owner is not supported on Windows"))
+	}
+	if !spaceCheckModes[*spaceCheck] {
+		log.Fatalf(tr("Invalid -space-check %q: must be refuse, warn or off"), *spaceCheck)
+	}
+	if *quality < 1 || *quality > 100 {
+		log.Fatalf(tr("Invalid -quality %d: must be between 1 and 100"), *quality)
+	}
+	if *settle < 0 {
+		log.Fatalf(tr("Invalid -settle %v: must be 0 or more"), *settle)
+	}
+	if *trialFiles < 0 {
+		log.Fatalf(tr("Invalid -sample %d: must be 0 or more files"), *trialFiles)
+	}
+	if _, err := parseExtraOutputs(*extraOutputs, globalSettings()); err != nil {
+		log.Fatalf(tr("Invalid -extra-outputs %q: %v"), *extraOutputs, err)
+	}
+	if _, err := parseProfiles(*profileNames, globalSettings()); err != nil {
+		log.Fatalf(tr("Invalid -profiles %q: %v"), *profileNames, err)
+	}
+	if (*extraOutputs != "" || *profileNames != "") && *pipeOutputs {
+		log.Fatal(tr("-extra-outputs and -profiles can't be used with -pipes, which has no files to write them beside"))
+	}
+	if *writeManifest && (*pipeOutputs || splitEnabled()) {
+		log.Fatal(tr("-manifest can't be used with -pipes or -split-output, which leave no outputs where it lists them"))
+	}
+	if *feedFile != "" {
+		if err := checkFeedFile(*feedFile); err != nil {
+			log.Fatalf(tr("Invalid -feed %q: %v"), *feedFile, err)
+		}
+		if *pipeOutputs || splitEnabled() {
+			log.Fatal(tr("-feed can't be used with -pipes or -split-output, which leave no photos where it links to them"))
+		}
+	}
+	if *feedEntries < 1 {
+		log.Fatalf(tr("Invalid -feed-entries %d: must be 1 or more"), *feedEntries)
+	}
+	if *mqttBroker != "" {
+		if _, err := parseBroker(*mqttBroker); err != nil {
+			log.Fatalf(tr("Invalid -mqtt %q: %v"), *mqttBroker, err)
+		}
+	}
+	if *mqttQoS != 0 && *mqttQoS != 1 {
+		log.Fatalf(tr("Invalid -mqtt-qos %d: must be 0 or 1"), *mqttQoS)
+	}
+	if *mqttTopic == "" || strings.ContainsAny(*mqttTopic, "+#") {
+		log.Fatalf(tr("Invalid -mqtt-topic %q: must be a topic without the wildcards + and #"), *mqttTopic)
+	}
+	if *previewSheets && !*dryRun {
+		log.Fatal(tr("-preview only works with -dry-run"))
+	}
+	if *runAs != "" && runtime.GOOS == "windows" {
+		log.Fatal(tr("-run-as is not supported on Windows"))
+	}
+	if err := dropPrivileges(); err != nil {
+		log.Fatalf(tr("Failed to switch to the -run-as user: %v"), err)
+	}
+	var observers []convert.Observer
+	var stream *ndjsonObserver
+	switch *outputFormat {
+	case "text":
+	case "ndjson":
+		// Keep stdout for the JSON stream; progress messages move to stderr.
+		stream = newNDJSONObserver(os.Stdout)
+		observers = append(observers, stream)
+		os.Stdout = os.Stderr
+	default:
+		log.Fatalf(tr("Invalid -output %q: must be text or ndjson"), *outputFormat)
+	}
+
+	infoln(tr("Starting the program..."))
 
 	currentDir, err := getCurrentDirectory()
 	if err != nil {
-		log.Fatalf("Failed to get current directory: %v", err)
+		log.Fatalf(tr("Failed to get current directory: %v"), err)
 	}
 
-	jpegDir := ensureJPEGDirectoryExists(currentDir)
-	files, err := getFilesInDirectory(currentDir)
+	if *gui {
+		if err := runGUI(currentDir); err != nil {
+			log.Fatalf(tr("Failed to start the GUI: %v"), err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *estimateSamples > 0 {
+		if err := runEstimate(ctx, currentDir); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+	if *trialFiles > 0 {
+		if err := runTrial(ctx, currentDir); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+	if *dryRun {
+		if err := runDryRun(ctx, currentDir); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	var h *history
+	if *useHistory {
+		if h, err = loadHistory(); err != nil {
+			log.Fatalf(tr("Failed to open the history: %v"), err)
+		}
+		ctx = withHistory(ctx, h)
+	}
+
+	if *notify {
+		observers = append(observers, notifyObserver{})
+	}
+	if *webhookURL != "" || *smtpServer != "" {
+		observers = append(observers, &completionReporter{dir: currentDir})
+	}
+	if *feedFile != "" {
+		feed, err := newFeedObserver(*feedFile, currentDir)
+		if err != nil {
+			log.Fatalf(tr("Invalid -feed %q: %v"), *feedFile, err)
+		}
+		observers = append(observers, feed)
+	}
+	if *mqttBroker != "" {
+		publisher, err := newMQTTObserver(*mqttBroker, *mqttTopic, *mqttQoS)
+		if err != nil {
+			log.Fatalf(tr("Invalid -mqtt %q: %v"), *mqttBroker, err)
+		}
+		observers = append(observers, publisher)
+	}
+
+	var collector *failureCollector
+	if *collectFailures != "" {
+		collector = &failureCollector{}
+		observers = append(observers, collector)
+	}
+
+	if *stagingMB > 0 {
+		s := newStager(*stagingMB<<20, workerCount())
+		defer s.close()
+		ctx = withStager(ctx, s)
+	}
+
+	if *compareDir != "" {
+		c, err := newComparer(*compareDir)
+		if err != nil {
+			log.Fatalf(tr("Failed to create the comparison folder: %v"), err)
+		}
+		defer func() {
+			if err := c.close(); err != nil {
+				fmt.Printf(tr("Failed to write compare.csv: %v\n"), err)
+			}
+		}()
+		ctx = withComparer(ctx, c)
+	}
+
+	if *dedupeLibrary != "" {
+		lib, err := loadLibrary(*dedupeLibrary, workerCount())
+		if err != nil {
+			log.Fatalf(tr("Failed to index -dedupe-library: %v"), err)
+		}
+		infof(tr("Indexed %d JPEGs in %s\n"), len(lib.entries), *dedupeLibrary)
+		ctx = withLibrary(ctx, lib)
+	}
+
+	if *cacheDir != "" {
+		c, err := openCache(*cacheDir, cacheLimitBytes)
+		if err != nil {
+			log.Fatalf(tr("Failed to open the cache: %v"), err)
+		}
+		ctx = withCache(ctx, c)
+	}
+
+	if *pipeOutputs {
+		ctx = withPipes(ctx, newPipes(stream.OnPipe))
+	}
+
+	control := newRunControl()
+	ctx = withRunControl(ctx, control)
+	watchControlSignals(ctx, control)
+	if *powerAware {
+		governPower(ctx, control, workerCount())
+	}
+
+	var targets []string
+	if command != syncCommand {
+		if targets, err = expandTargets(flag.Args()); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	var fileList []string
+	if *filesFrom != "" {
+		if fileList, err = readFileList(os.Stdin); err != nil {
+			log.Fatalf(tr("Failed to read the file list: %v"), err)
+		}
+	}
+
+	if command != workerCommand && command != serveCommand && command != botCommand && command != mailCommand && scheduled == nil && !*watchFolder {
+		j, err := startJournal(currentDir)
+		if err != nil {
+			fmt.Printf(tr("Failed to start the journal, so this run can't be undone: %v\n"), err)
+		} else {
+			defer j.close()
+			ctx = withJournal(ctx, j)
+		}
+	}
+
+	run := func(ctx context.Context, observers ...convert.Observer) error {
+		if *filesFrom != "" {
+			return convertFileList(ctx, fileList, observers...)
+		}
+		if len(targets) > 0 {
+			targets, err := downloadTargets(ctx, targets, *downloadDir)
+			if err != nil {
+				return err
+			}
+			return convertTargets(ctx, targets, observers...)
+		}
+		if len(hotFolders) > 0 && *sourceDir == "" {
+			return convertHotFolders(ctx, hotFolders, observers...)
+		}
+		return convertDirectory(ctx, currentDir, nil, observers...)
+	}
+	if command == syncCommand {
+		convertTree := run
+		run = func(ctx context.Context, observers ...convert.Observer) error {
+			if err := convertTree(ctx, observers...); err != nil || ctx.Err() != nil {
+				return err
+			}
+			return pruneMirror(ctx, h, *sourceDir, *outDir)
+		}
+	}
+	switch {
+	case command == workerCommand:
+		err = runWorker(ctx)
+	case command == serveCommand:
+		err = runServer(ctx)
+	case command == botCommand:
+		err = runBot(ctx)
+	case command == mailCommand:
+		err = runMailWatcher(ctx)
+	case scheduled != nil:
+		err = runScheduled(ctx, scheduled, currentDir, run, observers...)
+	case *watchFolder && len(hotFolders) > 0 && *sourceDir == "":
+		err = watchHotFolders(ctx, hotFolders, observers...)
+	case *watchFolder:
+		err = runWatch(ctx, currentDir, run, observers...)
+	case *tuiMode:
+		err = runWithTUI(ctx, currentDir, run, observers...)
+	default:
+		err = run(ctx, observers...)
+	}
+	if collector != nil {
+		if err := collector.finish(os.Stdin); err != nil {
+			fmt.Printf(tr("Failed to write the failure bundle: %v\n"), err)
+		}
+	}
+	if h != nil {
+		if err := h.save(); err != nil {
+			fmt.Printf(tr("Failed to save the history: %v\n"), err)
+		}
+	}
 	if err != nil {
-		log.Fatalf("Failed to read directory: %v", err)
+		log.Fatalf("%v", err)
 	}
 
-	logs := processFiles(currentDir, jpegDir, files)
-	saveLogsToFile(jpegDir, logs)
+	infoln(tr("Program completed!"))
+}
+
+// convertDirectory converts the HEIC files in dir (or just files, when
+// given) into dir/jpegs and writes the log file there.
+func convertDirectory(ctx context.Context, dir string, files []os.DirEntry, observers ...convert.Observer) error {
+	if err := checkReadOnlySource(dir); err != nil {
+		return err
+	}
+	jpegDir := outputDirFrom(ctx)
+	if jpegDir == "" {
+		jpegDir = ensureJPEGDirectoryExists(dir)
+	}
+	lock, err := lockOutputDir(ctx, jpegDir)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	ctx = withFolderConfigs(ctx, newFolderConfigs(dir))
 
-	fmt.Println("Program completed!")
+	report := &batchReport{}
+	observers = append(observers[:len(observers):len(observers)], report)
+	var logs map[string][]string
+	switch {
+	case files == nil && streamScan():
+		// Big trees start converting while the rest is still being listed,
+		// so the space is checked as the files are found.
+		scanCtx, stopScan := context.WithCancel(ctx)
+		defer stopScan()
+		source, wait, err := streamDirectory(scanCtx, dir, workerCount())
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %v", err)
+		}
+		source, shortage := watchSpace(dir, jpegDir, source, stopScan)
+		logs = processStream(ctx, dir, jpegDir, source, convert.UnknownTotal, observers...)
+		if err := wait(); err != nil {
+			return fmt.Errorf("failed to read directory: %v", err)
+		}
+		if err := shortage(); err != nil {
+			return err
+		}
+	default:
+		if files == nil {
+			files, err = getFilesInDirectory(dir)
+			if err != nil {
+				return fmt.Errorf("failed to read directory: %v", err)
+			}
+		}
+		if err := checkSpace(dir, jpegDir, files); err != nil {
+			return err
+		}
+		logs = processFiles(ctx, dir, jpegDir, files, observers...)
+	}
+	if *videos && ctx.Err() == nil {
+		if files == nil {
+			if files, err = getFilesInDirectory(dir); err != nil {
+				return fmt.Errorf("failed to read directory: %v", err)
+			}
+		}
+		transcodeVideos(ctx, dir, jpegDir, files, logs)
+	}
+	if ctx.Err() != nil {
+		fmt.Println(tr("Interrupted, the remaining files were skipped."))
+	}
+	if splitEnabled() {
+		parts, err := splitOutput(jpegDir, splitOutputBytes, *splitOutputFiles)
+		if err != nil {
+			fmt.Printf(tr("Failed to split the output: %v\n"), err)
+		}
+		relocateLogs(logs, dir, parts)
+	}
+	if *searchable && pipesFrom(ctx) == nil {
+		if err := indexFolder(jpegDir); err != nil {
+			fmt.Printf(tr("Failed to index %s: %v\n"), jpegDir, err)
+		}
+	}
+	if err := rotateLogs(jpegDir, *keepLogs); err != nil {
+		fmt.Printf(tr("Failed to rotate the previous logs: %v\n"), err)
+	}
+	saveLogsToFile(jpegDir, logs, report.keys(*sortReport))
+	if *perceptualHashes {
+		if err := saveHashes(jpegDir, report); err != nil {
+			fmt.Printf(tr("Failed to write hashes.csv: %v\n"), err)
+		}
+	}
+	if *writeManifest {
+		if err := saveManifest(dir, jpegDir, report); err != nil {
+			fmt.Printf(tr("Failed to write manifest.json: %v\n"), err)
+		}
+	}
+	if !report.finished.IsZero() {
+		if err := appendRunHistory(jpegDir, report.finished, report.summary); err != nil {
+			fmt.Printf(tr("Failed to update history.csv: %v\n"), err)
+		}
+	}
+	return nil
 }
 
 func getCurrentDirectory() (string, error) {
-	fmt.Println("Fetching the current directory...")
+	if *sourceDir != "" {
+		return filepath.Abs(*sourceDir)
+	}
+	infoln(tr("Fetching the current directory..."))
 	return os.Getwd()
 }
 
+// ensureJPEGDirectoryExists creates the folder the JPEGs of dir go to:
+// -out, or its jpegs subfolder. With -in-place it is dir itself.
 func ensureJPEGDirectoryExists(dir string) string {
-	jpegDir := filepath.Join(dir, "jpegs")
-	if err := os.MkdirAll(jpegDir, 0755); err != nil {
-		log.Fatalf("Failed to create directory: %v", err)
+	jpegDir := jpegDirectory(dir)
+	if err := os.MkdirAll(longPath(jpegDir), 0755); err != nil {
+		log.Fatalf(tr("Failed to create directory: %v"), err)
 	}
 	return jpegDir
 }
 
+// jpegDirectory is the folder the JPEGs of the HEIC files in dir go to.
+func jpegDirectory(dir string) string {
+	switch {
+	case *inPlace:
+		return dir
+	case *outDir != "":
+		return resolvePath(*outDir)
+	}
+	return filepath.Join(dir, "jpegs")
+}
+
 func getFilesInDirectory(dir string) ([]os.DirEntry, error) {
-	return os.ReadDir(dir)
+	if *recursive {
+		return walkDirectory(dir)
+	}
+	return os.ReadDir(longPath(dir))
+}
+
+// streamScan reports whether files can be converted in the order the
+// recursive scan finds them; -order needs the whole listing first.
+func streamScan() bool {
+	return *recursive && *order == ""
 }
 
-func saveLogsToFile(jpegDir string, logs map[string][]string) {
+// saveLogsToFile writes the file lines in the order of keys, followed by
+// any other files in path order, then the general logs.
+func saveLogsToFile(jpegDir string, logs map[string][]string, keys []string) {
 	logFilePath := filepath.Join(jpegDir, logFileName)
-	logFile, err := os.Create(logFilePath)
+	logFile, err := os.Create(longPath(logFilePath))
 	if err != nil {
-		log.Fatalf("Failed to create log file: %v", err)
+		log.Fatalf(tr("Failed to create log file: %v"), err)
 	}
 	defer logFile.Close()
 
-	fmt.Println("Saving logs to logs.txt...")
+	infoln(tr("Saving logs to logs.txt..."))
 
-	for key, logMessages := range logs {
-		if key == "general" {
+	written := make(map[string]bool, len(keys))
+	var rest []string
+	for key := range logs {
+		rest = append(rest, key)
+	}
+	sort.Slice(rest, func(i, j int) bool { return pathLess(rest[i], rest[j]) })
+	for _, key := range append(keys, rest...) {
+		if key == "general" || key == videosLogKey || written[key] {
 			continue
 		}
-		for _, logMessage := range logMessages {
+		written[key] = true
+		for _, logMessage := range logs[key] {
 			fmt.Fprintln(logFile, logMessage)
 		}
 	}
 
-	// Now write the general logs at the end of the file.
+	// The videos, then the general logs at the end of the file.
+	for _, logMessage := range logs[videosLogKey] {
+		fmt.Fprintln(logFile, logMessage)
+	}
 	if generalLogs, ok := logs["general"]; ok {
 		for _, logMessage := range generalLogs {
 			fmt.Fprintln(logFile, logMessage)
@@ -81,116 +666,536 @@ func saveLogsToFile(jpegDir string, logs map[string][]string) {
 	}
 }
 
-func processFiles(currentDir, jpegDir string, files []os.DirEntry) map[string][]string {
-	fmt.Println("Processing files...")
+func processFiles(ctx context.Context, currentDir, jpegDir string, files []os.DirEntry, observers ...convert.Observer) map[string][]string {
+	total := countHEICFiles(files)
+	orderFiles(files, *order)
+	return processStream(ctx, currentDir, jpegDir, feedFiles(ctx, files, workerCount()), total, observers...)
+}
+
+// feedFiles sends files to the returned channel from a goroutine. The
+// channel only holds buffer entries, so the workers set the pace and a
+// cancelled run doesn't leave the goroutine blocked.
+func feedFiles(ctx context.Context, files []os.DirEntry, buffer int) <-chan os.DirEntry {
+	fileChan := make(chan os.DirEntry, buffer)
+	go func() {
+		defer close(fileChan)
+		for _, file := range files {
+			select {
+			case fileChan <- file:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return fileChan
+}
+
+// processStream converts the files received from source until it is
+// closed. total is the number of HEIC files expected, or convert.UnknownTotal
+// while the source is still scanning. The channels between the source,
+// the workers and the log aggregation are all bounded by the worker
+// count, so a slow disk holds back the scan instead of queueing it.
+func processStream(ctx context.Context, currentDir, jpegDir string, source <-chan os.DirEntry, total int, observers ...convert.Observer) map[string][]string {
+	infoln(tr("Processing files..."))
 	startTime := time.Now()
+	for _, o := range observers {
+		o.OnStart(total)
+	}
+
+	var before runtime.MemStats
+	if atLevel(levelVerbose) {
+		runtime.ReadMemStats(&before)
+	}
 
 	logs := make(map[string][]string)
-	fileChan, logChan := setupWorkers(currentDir, jpegDir, len(files))
+	resultChan := setupWorkers(ctx, currentDir, jpegDir, source, observers)
+	aggregateLogs(resultChan, logs, currentDir, jpegDir, startTime, observers)
 
-	for _, file := range files {
-		fileChan <- file
+	if atLevel(levelVerbose) {
+		stats := allocationStats(&before)
+		for _, line := range stats {
+			fmt.Println(line)
+		}
+		logs["general"] = append(logs["general"], stats...)
 	}
-	close(fileChan)
-
-	aggregateLogs(logChan, logs, currentDir, jpegDir, startTime)
 
 	return logs
 }
 
-func setupWorkers(currentDir, jpegDir string, filesCount int) (chan os.DirEntry, chan map[string]string) {
-	fileChan := make(chan os.DirEntry, filesCount)
-	logChan := make(chan map[string]string, filesCount)
+// allocationStats reports the allocations and garbage collections since before.
+func allocationStats(before *runtime.MemStats) []string {
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	return []string{
+		fmt.Sprintf(tr("Allocated==%s in %d allocations"), humanReadableFileSize(int64(after.TotalAlloc-before.TotalAlloc)), after.Mallocs-before.Mallocs),
+		fmt.Sprintf(tr("Garbage Collections==%d (%v paused)"), after.NumGC-before.NumGC, time.Duration(after.PauseTotalNs-before.PauseTotalNs)),
+		fmt.Sprintf(tr("Heap Reserved==%s"), humanReadableFileSize(int64(after.HeapSys))),
+	}
+}
+
+func setupWorkers(ctx context.Context, currentDir, jpegDir string, fileChan <-chan os.DirEntry, observers []convert.Observer) chan convert.ConversionResult {
+	workerCount := workerCount()
+	resultChan := make(chan convert.ConversionResult, workerCount)
 
 	var wg sync.WaitGroup
-	workerCount := runtime.NumCPU()
 	for i := 0; i < workerCount; i++ {
 		wg.Add(1)
-		go worker(fileChan, logChan, currentDir, jpegDir, &wg)
+		go worker(ctx, i, fileChan, resultChan, currentDir, jpegDir, observers, &wg)
 	}
 
 	go func() {
 		wg.Wait()
-		close(logChan)
+		close(resultChan)
 	}()
 
-	return fileChan, logChan
+	return resultChan
 }
 
-func worker(fileChan chan os.DirEntry, logChan chan map[string]string, currentDir, jpegDir string, wg *sync.WaitGroup) {
+func workerCount() int {
+	if *workers > 0 {
+		return *workers
+	}
+	return runtime.NumCPU()
+}
+
+func worker(ctx context.Context, id int, fileChan <-chan os.DirEntry, resultChan chan convert.ConversionResult, currentDir, jpegDir string, observers []convert.Observer, wg *sync.WaitGroup) {
 	defer wg.Done()
-	for file := range fileChan {
-		logChan <- processFile(file, currentDir, jpegDir)
+	control := runControlFrom(ctx)
+	for {
+		control.waitForTurn(ctx, id)
+		select {
+		case <-ctx.Done():
+			return
+		case file, ok := <-fileChan:
+			if !ok || ctx.Err() != nil {
+				return
+			}
+			if isHEIC(file.Name()) {
+				for _, o := range observers {
+					if w, ok := o.(workerObserver); ok {
+						w.OnFileStart(id, filepath.Join(currentDir, file.Name()))
+					}
+				}
+			}
+
+			fileCtx, done := control.begin(ctx, id)
+			started := time.Now()
+			result, converted := processFile(fileCtx, file, currentDir, jpegDir)
+			for isDiskFull(result.Err) {
+				// Remove the partial JPEG and try the file again once
+				// there is room, rather than failing the rest too.
+				failedAt := time.Now()
+				os.Remove(longPath(result.Output))
+				if !diskFull.wait(fileCtx, control, currentDir, jpegDir, failedAt) {
+					break
+				}
+				result, converted = processFile(fileCtx, file, currentDir, jpegDir)
+			}
+			if done() {
+				result.Err, result.Warning, converted = errSkipped, "", true
+			}
+			result.Duration = time.Since(started)
+			if converted {
+				resultChan <- result
+			}
+			if isHEIC(file.Name()) {
+				pace(ctx, result.Duration)
+			}
+		}
 	}
 }
 
-func processFile(file os.DirEntry, currentDir, jpegDir string) map[string]string {
-	logEntry := make(map[string]string)
-	ext := strings.ToLower(filepath.Ext(file.Name()))
+// errSkipped is reported for files skipped from the interactive display.
+var errSkipped = errors.New("skipped")
 
-	if ext == ".heic" {
-		fmt.Printf("Processing file: %s\n", file.Name())
-		err := convertFile(currentDir, file.Name(), jpegDir)
-		if err != nil {
-			logEntry[file.Name()] = fmt.Sprintf("error details: %s", err)
+// skipReason is returned for files a rule says not to convert; the batch
+// reports them as skipped rather than failed.
+type skipReason struct {
+	reason string
+}
+
+func (s *skipReason) Error() string { return "skipped: " + s.reason }
+
+func skippedBy(err error) (string, bool) {
+	var s *skipReason
+	if errors.As(err, &s) {
+		return s.reason, true
+	}
+	return "", false
+}
+
+// processFile converts file when it is a HEIC file, reporting false for
+// anything else.
+func processFile(ctx context.Context, file os.DirEntry, currentDir, jpegDir string) (convert.ConversionResult, bool) {
+	result := convert.ConversionResult{
+		Name:   file.Name(),
+		Input:  filepath.Join(currentDir, file.Name()),
+		Output: getJPEGFilePath(jpegDir, namingSource(currentDir, file.Name())),
+	}
+	if !isHEIC(file.Name()) {
+		return result, false
+	}
+
+	infof(tr("Processing file: %s\n"), file.Name())
+	var exifStatus convert.ExifStatus
+	var profile convert.Profile
+	ctx = withProfileOutcome(withExifStatus(ctx, &exifStatus), &profile)
+	output, err := convertFile(ctx, currentDir, file.Name(), jpegDir)
+	if output != "" {
+		result.Output = output
+	}
+	result.Exif = exifStatusOf(err)
+	if exifStatus != convert.ExifUnknown && result.Exif == convert.ExifCopied {
+		result.Exif = exifStatus
+	}
+	if reason, ok := skippedBy(err); ok {
+		infof(tr("Skipped %s: %s\n"), file.Name(), reason)
+		result.Skipped = reason
+	} else if isWarning(err) {
+		fmt.Printf(tr("Converted %s with a warning: %v\n"), file.Name(), err)
+		result.Warning = err.Error()
+	} else if err != nil {
+		fmt.Printf(tr("Failed to convert %s: %v\n"), file.Name(), err)
+		result.Err = err
+		if shouldQuarantine(ctx, err) {
+			dst, qerr := quarantineFile(currentDir, jpegDir, file.Name(), err)
+			if qerr != nil {
+				fmt.Printf(tr("Failed to quarantine %s: %v\n"), file.Name(), qerr)
+			}
+			if j := journalFrom(ctx); j != nil && dst != "" {
+				j.quarantined(result.Input, dst)
+			}
+		}
+	}
+	if result.Err == nil && result.Skipped == "" {
+		if profile.Status == convert.ProfileNone && *isolate {
+			// The child process can't say: judge from the input.
+			profile = isolatedProfile(result.Input)
+		}
+		result.ColorProfile, result.Profile = profile.Name, profile.Status
+		if result.Profile == convert.ProfileDropped {
+			fmt.Printf(tr("%s has a %s color profile, which can be neither converted nor embedded: its colors will look off\n"), file.Name(), profile.Name)
+		}
+	}
+	result.InputSize = getFileSize(result.Input)
+	result.OutputSize = outputSize(ctx, result.Output)
+	if result.Err == nil && result.Skipped == "" && !isLosslessOutput(result.Output) {
+		if x, err := readJPEGExif(result.Output); err == nil && x.Orientation() != 1 {
+			result.Orientation = x.Orientation()
+		}
+	}
+	if *perceptualHashes && result.Err == nil && result.Skipped == "" {
+		if hashes, err := convert.HashFile(result.Output); err != nil {
+			fmt.Printf(tr("Failed to hash %s: %v\n"), file.Name(), err)
 		} else {
-			logEntry[file.Name()] = "converted successfully"
+			result.Hashes = &hashes
+		}
+	}
+	if atLevel(levelDebug) {
+		result.Diagnostics = diagnose(result.Input)
+		fmt.Printf(tr("Diagnostics for %s:\n"), file.Name())
+		for _, line := range result.Diagnostics {
+			fmt.Println("  " + line)
+		}
+	}
+	if *deleteOriginals && result.Err == nil && result.Skipped == "" && result.Warning == "" {
+		var archived string
+		if *archiveDir != "" {
+			archived = filepath.Join(*archiveDir, file.Name())
+		}
+		if err := removeOriginal(result.Input, result.Output, archived); err != nil {
+			fmt.Printf(tr("Kept %s: %v\n"), file.Name(), err)
+		} else if j := journalFrom(ctx); j != nil && archived != "" {
+			j.moved(result.Input, archived)
+		} else if j != nil {
+			j.deleted(result.Input)
 		}
 	}
+	return result, true
+}
 
-	return logEntry
+func isHEIC(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".heic"
 }
-func aggregateLogs(logChan chan map[string]string, logs map[string][]string, currentDir, jpegDir string, startTime time.Time) {
-	var totalHEICSize, totalJPEGSize int64
-	generalLogs := []string{} // Storing general logs here
-	for logItem := range logChan {
-		for k := range logItem {
-			heicFilePath := filepath.Join(currentDir, k)
-			jpgFilePath := getJPEGFilePath(jpegDir, k)
 
-			heicSizeBytes := getFileSize(heicFilePath)
-			jpgSizeBytes := getFileSize(jpgFilePath)
+func aggregateLogs(resultChan chan convert.ConversionResult, logs map[string][]string, currentDir, jpegDir string, startTime time.Time, observers []convert.Observer) {
+	var summary convert.Summary
+	generalLogs := []string{} // Storing general logs here
+	for result := range resultChan {
+		k := result.Name
 
-			totalHEICSize += heicSizeBytes
-			totalJPEGSize += jpgSizeBytes
+		heicSize := humanReadableFileSize(result.InputSize)
+		jpgSize := humanReadableFileSize(result.OutputSize)
 
-			heicSize := humanReadableFileSize(heicSizeBytes)
-			jpgSize := humanReadableFileSize(jpgSizeBytes)
+		for _, o := range observers {
+			o.OnFileDone(result)
+		}
+		summary.Add(result)
 
-			logs[k] = append(logs[k], fmt.Sprintf("%s %s > Converted > jpegs/%s.jpg %s", k, heicSize, strings.TrimSuffix(k, filepath.Ext(k)), jpgSize))
+		var line string
+		switch {
+		case result.Err != nil:
+			line = fmt.Sprintf(tr("%s %s > Failed > error details: %s"), k, heicSize, result.Err)
+		case result.Warning != "":
+			line = fmt.Sprintf(tr("%s %s > Converted > jpegs/%s %s"), k, heicSize, outputName(currentDir, jpegDir, result), jpgSize)
+			line += fmt.Sprintf(tr(" > Warning: %s"), result.Warning)
+		case result.Skipped != "":
+			if !atLevel(levelNormal) {
+				continue
+			}
+			line = fmt.Sprintf(tr("%s %s > Skipped > %s"), k, heicSize, result.Skipped)
+		case atLevel(levelNormal):
+			line = fmt.Sprintf(tr("%s %s > Converted > jpegs/%s %s"), k, heicSize, outputName(currentDir, jpegDir, result), jpgSize)
+		default:
+			continue // -verbosity quiet only reports problems
+		}
+		if result.Orientation != 0 {
+			line += fmt.Sprintf(tr(" > EXIF orientation %d: may display rotated in strict viewers"), result.Orientation)
+		}
+		if result.Profile == convert.ProfileDropped {
+			line += fmt.Sprintf(tr(" > %s color profile dropped: colors may look off"), result.ColorProfile)
+		}
+		if atLevel(levelVerbose) {
+			line += fmt.Sprintf(tr(" > Took %v"), result.Duration.Round(time.Millisecond))
+		}
+		logs[k] = append(logs[k], line)
+		for _, diagnostic := range result.Diagnostics {
+			logs[k] = append(logs[k], "    "+diagnostic)
 		}
 	}
 
 	// Add general logs to the generalLogs slice
-	totalDuration := time.Since(startTime)
-	totalLogLines := len(logs)
-	generalLogs = append(generalLogs, fmt.Sprintf("\n%v Files", totalLogLines))
-	generalLogs = append(generalLogs, fmt.Sprintf("Total Time Taken==%v", totalDuration))
-	generalLogs = append(generalLogs, fmt.Sprintf("Average Time Per File==%v", totalDuration/time.Duration(totalLogLines)))
-	generalLogs = append(generalLogs, fmt.Sprintf("Total HEIC File Size==%s", humanReadableFileSize(totalHEICSize)))
-	generalLogs = append(generalLogs, fmt.Sprintf("Total JPEG Folder Size==%s", humanReadableFileSize(totalJPEGSize)))
+	summary.Duration = time.Since(startTime)
+	generalLogs = append(generalLogs, fmt.Sprintf(tr("\n%v Files"), summary.Files))
+	generalLogs = append(generalLogs, fmt.Sprintf(tr("Total Time Taken==%v"), summary.Duration))
+	if summary.Files > 0 {
+		generalLogs = append(generalLogs, fmt.Sprintf(tr("Average Time Per File==%v"), summary.Duration/time.Duration(summary.Files)))
+	}
+	generalLogs = append(generalLogs, fmt.Sprintf(tr("Total HEIC File Size==%s"), humanReadableFileSize(summary.InputSize)))
+	generalLogs = append(generalLogs, fmt.Sprintf(tr("Total JPEG Folder Size==%s"), humanReadableFileSize(summary.OutputSize)))
+	exif, profiles := summary.Exif, summary.Profiles
+	generalLogs = append(generalLogs, fmt.Sprintf(tr("EXIF==%d copied, %d repaired, %d stripped, %d missing, %d dropped"), exif.Copied, exif.Repaired, exif.Stripped, exif.Missing, exif.Dropped))
+	generalLogs = append(generalLogs, fmt.Sprintf(tr("Color profiles==%d converted, %d embedded, %d dropped"), profiles.Converted, profiles.Embedded, profiles.Dropped))
 
 	// Add the generalLogs slice to the main logs map
 	logs["general"] = generalLogs
+
+	for _, o := range observers {
+		o.OnFinish(summary)
+	}
+}
+
+// outputName is a result's output relative to jpegDir, as logs.txt shows it.
+func outputName(currentDir, jpegDir string, result convert.ConversionResult) string {
+	if rel, err := filepath.Rel(jpegDir, result.Output); err == nil && result.Output != "" {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(jpegFileName(namingSource(currentDir, result.Name)))
 }
 
 func getJPEGFilePath(jpegDir, originalFileName string) string {
-	return filepath.Join(jpegDir, strings.TrimSuffix(originalFileName, filepath.Ext(originalFileName))+".jpg")
+	return filepath.Join(jpegDir, jpegFileName(originalFileName))
+}
+
+func jpegFileName(originalFileName string) string {
+	return sanitizePath(toNFC(strings.TrimSuffix(originalFileName, filepath.Ext(originalFileName))) + ".jpg")
 }
 
 func getFileSize(path string) int64 {
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := os.Stat(longPath(path))
 	if err != nil {
 		return 0
 	}
 	return fileInfo.Size()
 }
 
-func convertFile(currentDir, inputFileName, jpegDir string) error {
+// plannedOutput is the path convertFile writes the HEIC file
+// inputFileName of currentDir to: named after it in jpegDir, with the
+// extension of the format it converts to.
+func plannedOutput(currentDir, inputFileName, jpegDir string) (string, error) {
+	inputFilePath := filepath.Join(currentDir, inputFileName)
+	outputFilePath := getJPEGFilePath(jpegDir, namingSource(currentDir, inputFileName))
+	if *organizeByLocation {
+		outputFilePath = locateOutput(jpegDir, inputFilePath, outputFilePath)
+	}
+	if *screenshots != "jpeg" {
+		screenshot, err := detectScreenshot(inputFilePath)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case screenshot && *screenshots == "skip":
+			return "", &skipReason{"screenshot"}
+		case screenshot:
+			outputFilePath = pngFileName(outputFilePath)
+		}
+	}
+	if deep, err := wantsDeepOutput(inputFilePath); err != nil {
+		return "", err
+	} else if deep {
+		outputFilePath = deepFileName(outputFilePath)
+	}
+	if alphaMode() == "png" && hasAlpha(inputFilePath) {
+		outputFilePath = pngFileName(outputFilePath)
+	}
+	if *inPlace {
+		return besideInput(inputFilePath, outputFilePath)
+	}
+	return outputFilePath, nil
+}
+
+// convertFile converts one file and returns the path it was written to.
+func convertFile(ctx context.Context, currentDir, inputFileName, jpegDir string) (string, error) {
 	inputFilePath := filepath.Join(currentDir, inputFileName)
-	outputFileName := strings.TrimSuffix(filepath.Base(inputFileName), filepath.Ext(inputFileName)) + ".jpg"
-	outputFilePath := filepath.Join(jpegDir, outputFileName)
-	return convertHeicToJpg(inputFilePath, outputFilePath)
+	if err := waitSettled(ctx, inputFilePath, *settle); err != nil {
+		return "", err
+	}
+	outputFilePath, err := plannedOutput(currentDir, inputFileName, jpegDir)
+	if err != nil {
+		return "", err
+	}
+	if err := guardWrite(outputFilePath); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(outputFilePath)), 0755); err != nil {
+		return "", err
+	}
+
+	ctx = withComparedFile(ctx, inputFileName)
+	if profileOutcomeFrom(ctx) == nil {
+		// The outputs embed the profile kept there.
+		ctx = withProfileOutcome(ctx, &convert.Profile{})
+	}
+	if folders := folderConfigsFrom(ctx); folders != nil {
+		s, err := folders.settingsFor(settingsFrom(ctx), inputFileName)
+		if err != nil {
+			return "", err
+		}
+		ctx = withSettings(ctx, s)
+	}
+	if len(cameraRules) > 0 {
+		s, err := applyCameraRules(inputFilePath, settingsFrom(ctx))
+		if err != nil {
+			return "", err
+		}
+		ctx = withSettings(ctx, s)
+	}
+
+	// The journal only lists new files, so undo doesn't delete a JPEG
+	// that was there before the run.
+	j := journalFrom(ctx)
+	created := j != nil && !fileExists(outputFilePath)
+
+	h := historyFrom(ctx)
+	var hash string
+	if h != nil {
+		var err error
+		if hash, err = hashFile(inputFilePath); err != nil {
+			return "", err
+		}
+		reused, err := h.reuse(hash, inputFilePath, outputFilePath, settingsFrom(ctx))
+		if err != nil {
+			return "", err
+		}
+		if reused {
+			infof(tr("Already converted: %s\n"), inputFileName)
+			finishOutput(inputFilePath, outputFilePath, inputFileName)
+			if created {
+				j.created(outputFilePath)
+			}
+			return outputFilePath, nil
+		}
+	}
+
+	if err := runHook(ctx, *preCmd, inputFilePath, outputFilePath); err != nil {
+		return "", &skipReason{"pre-cmd failed: " + err.Error()}
+	}
+	convertOne := convertHeicToJpg
+	if *isolate {
+		convertOne = convertIsolated
+	}
+	var warning error
+	err = convertOne(ctx, inputFilePath, outputFilePath)
+	if created && fileExists(outputFilePath) {
+		// Recorded before anything can fail, so a partial file is undone too.
+		j.created(outputFilePath)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		err = explainTruncation(inputFilePath, err)
+		if !*repair {
+			return "", err
+		}
+		if warning = repairTruncated(ctx, inputFilePath, outputFilePath); !isWarning(warning) {
+			return "", err
+		}
+	}
+	if *allFrames && !*isolate && !isLosslessOutput(outputFilePath) {
+		n, err := convertOtherFrames(ctx, inputFilePath, outputFilePath)
+		if err != nil {
+			return "", fmt.Errorf("converting the other frames: %v", err)
+		}
+		for i := 2; i <= n+1; i++ {
+			frame := frameFileName(outputFilePath, i)
+			if created {
+				j.created(frame)
+			}
+			finishOutput(inputFilePath, frame, inputFileName)
+		}
+		if n > 0 {
+			infof(tr("Converted %d more frames of %s\n"), n, inputFileName)
+		}
+	}
+	if pipesFrom(ctx) == nil {
+		for _, extra := range extraFiles(ctx, outputFilePath) {
+			if created {
+				j.created(extra)
+			}
+			finishOutput(inputFilePath, extra, inputFileName)
+		}
+	}
+	if *sidecars && settingsFrom(ctx).Metadata != "strip" && !isLosslessOutput(outputFilePath) && pipesFrom(ctx) == nil {
+		if err := embedSidecar(inputFilePath, outputFilePath); err != nil {
+			fmt.Printf(tr("Failed to copy the sidecar metadata of %s: %v\n"), inputFileName, err)
+		}
+	}
+	if pipesFrom(ctx) == nil {
+		finishOutput(inputFilePath, outputFilePath, inputFileName)
+	}
+	if err := runHook(ctx, *postCmd, inputFilePath, outputFilePath); err != nil {
+		return "", &hookError{"post-cmd", err}
+	}
+	if h != nil {
+		h.record(historyEntry{
+			Source:     inputFilePath,
+			Hash:       hash,
+			Output:     outputFilePath,
+			Converted:  time.Now(),
+			Quality:    settingsFrom(ctx).Quality,
+			Settings:   settingsFrom(ctx).key(),
+			InputSize:  getFileSize(inputFilePath),
+			OutputSize: getFileSize(outputFilePath),
+		})
+	}
+	return outputFilePath, warning
+}
+
+// finishOutput gives output the permissions, tags and search attributes
+// the options ask for. What fails is reported; the JPEG is kept anyway.
+func finishOutput(input, output, name string) {
+	if err := copyAccess(input, output); err != nil {
+		fmt.Printf(tr("Failed to copy the permissions of %s: %v\n"), name, err)
+	}
+	if err := copyTags(input, output); err != nil {
+		fmt.Printf(tr("Failed to copy the tags of %s: %v\n"), name, err)
+	}
+	if *searchable {
+		if err := prepareForSearch(output); err != nil {
+			fmt.Printf(tr("Failed to make %s searchable: %v\n"), name, err)
+		}
+	}
 }
 
 func humanReadableFileSize(bytes int64) string {
@@ -206,81 +1211,128 @@ func humanReadableFileSize(bytes int64) string {
 	return fmt.Sprintf("%.1f%cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-func convertHeicToJpg(input, output string) error {
-	fileInput, err := os.Open(input)
+func convertHeicToJpg(ctx context.Context, input, output string) error {
+	banded, err := planConversion(input)
 	if err != nil {
 		return err
 	}
-	defer fileInput.Close()
+	if isLosslessOutput(output) {
+		return convertHeicToPng(ctx, input, output)
+	}
+	if *lowMemory || banded {
+		if *extraOutputs != "" || *profileNames != "" {
+			infof(tr("%s is decoded in bands, so only its JPEG is written, without -extra-outputs or -profiles\n"), filepath.Base(input))
+		}
+		return convertHeicToJpgBanded(ctx, input, output)
+	}
+	return convertHeicToJpgFull(ctx, input, output)
+}
 
-	exif, err := goheif.ExtractExif(fileInput)
+func convertHeicToJpgFull(ctx context.Context, input, output string) error {
+	img, exif, err := decodeHeicFile(ctx, input)
 	if err != nil {
 		return err
 	}
+	if err := checkDuplicate(ctx, img, output); err != nil {
+		return err
+	}
 
-	// Seek back to the beginning of the file for the next operation.
-	fileInput.Seek(0, 0)
+	if s := stagerFrom(ctx); s != nil {
+		buf := getStagingBuffer()
+		if err := encodeJPEG(ctx, buf, img, exif); err != nil {
+			stagingBuffers.Put(buf)
+			return err
+		}
+		if err := s.write(output, buf); err != nil {
+			return err
+		}
+		return writeExtraOutputs(ctx, img, exif, output)
+	}
 
-	img, err := goheif.Decode(fileInput)
+	fileOutput, err := openOutput(ctx, input, output, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
 	if err != nil {
 		return err
 	}
+	defer fileOutput.Close()
+
+	if err := encodeJPEG(ctx, fileOutput, img, exif); err != nil {
+		return err
+	}
+	return writeExtraOutputs(ctx, img, exif, output)
+}
 
-	fileOutput, err := os.OpenFile(output, os.O_RDWR|os.O_CREATE, 0644)
+// convertHeicToPng writes a screenshot losslessly for -screenshots png,
+// or a 16-bit file for -bit-depth, as PNG or TIFF.
+func convertHeicToPng(ctx context.Context, input, output string) error {
+	img, exif, err := decodeHeicFile(ctx, input)
+	if err != nil {
+		return err
+	}
+	if err := checkDuplicate(ctx, img, output); err != nil {
+		return err
+	}
+	fileOutput, err := openOutput(ctx, input, output, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
 	if err != nil {
 		return err
 	}
 	defer fileOutput.Close()
-
-	w, err := newWriterExif(fileOutput, exif)
+	recordExif(ctx, convert.ExifStripped)
+	if isTIFFOutput(output) {
+		err = convert.EncodeTIFF(fileOutput, img, outputICC(ctx), settingsFrom(ctx).options())
+	} else {
+		err = convert.EncodePNG(fileOutput, img, outputICC(ctx), settingsFrom(ctx).options())
+	}
 	if err != nil {
 		return err
 	}
-
-	return jpeg.Encode(w, img, nil)
+	return writeExtraOutputs(ctx, img, exif, output)
 }
 
-type writerSkipper struct {
-	w           io.Writer
-	bytesToSkip int
-}
-
-func (w *writerSkipper) Write(data []byte) (int, error) {
-	if w.bytesToSkip <= 0 {
-		return w.w.Write(data)
+// decodeHeicFile decodes input and extracts its EXIF block, applying the
+// per-file timeout.
+func decodeHeicFile(ctx context.Context, input string) (image.Image, []byte, error) {
+	fileInput, err := os.Open(longPath(input))
+	if err != nil {
+		return nil, nil, err
 	}
+	defer fileInput.Close()
 
-	dataLen := len(data)
-	if dataLen < w.bytesToSkip {
-		w.bytesToSkip -= dataLen
-		return dataLen, nil
+	// Files without EXIF fail here, as they always have, so the logs and
+	// -require-exif tell them apart.
+	if _, err := goheif.ExtractExif(fileInput); err != nil {
+		return nil, nil, err
 	}
-
-	n, err := w.w.Write(data[w.bytesToSkip:])
-	n += w.bytesToSkip
-	w.bytesToSkip = 0
-	return n, err
+	d, err := convert.Decode(ctx, fileInput, settingsFrom(ctx).options())
+	if err != nil {
+		return nil, nil, err
+	}
+	recordProfile(ctx, d.Profile)
+	return d.Image, d.Exif, nil
 }
 
-func newWriterExif(w io.Writer, exif []byte) (io.Writer, error) {
-	writer := &writerSkipper{w, 2}
-	soi := []byte{0xff, 0xd8}
-	if _, err := w.Write(soi); err != nil {
-		return nil, err
+// encodeJPEG writes img with the settings of the file converted under ctx.
+func encodeJPEG(ctx context.Context, out io.Writer, img image.Image, exif []byte) error {
+	s := settingsFrom(ctx)
+	_, status := convert.JPEGExif(exif, s.Metadata)
+	recordExif(ctx, status)
+	cmp, comparing := comparisonFrom(ctx)
+	if !comparing || convert.IsBanded(img) {
+		return convert.EncodeJPEG(out, img, exif, outputICC(ctx), s.options())
 	}
 
-	if exif != nil {
-		app1Marker := 0xe1
-		markerlen := 2 + len(exif)
-		marker := []byte{0xff, uint8(app1Marker), uint8(markerlen >> 8), uint8(markerlen & 0xff)}
-		if _, err := w.Write(marker); err != nil {
-			return nil, err
-		}
-
-		if _, err := w.Write(exif); err != nil {
-			return nil, err
-		}
+	// Keep a copy of the JPEG to score it against img.
+	var encoded bytes.Buffer
+	if err := convert.EncodeJPEG(io.MultiWriter(out, &encoded), img, exif, outputICC(ctx), s.options()); err != nil {
+		return err
 	}
+	if err := cmp.c.compare(cmp.name, convert.Crop(img, s.Crop, s.CropFocus), encoded.Bytes()); err != nil {
+		fmt.Printf(tr("Failed to compare %s: %v\n"), cmp.name, err)
+	}
+	return nil
+}
 
-	return writer, nil
+func encodeJPEGQuality(out io.Writer, img image.Image, exif []byte, quality int) error {
+	o := globalSettings().options()
+	o.Quality, o.AutoQuality = quality, false
+	return convert.EncodeJPEG(out, img, exif, nil, o)
 }
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io/fs"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+
+	"heictojpeg/convert"
 )
 
 // Mock of os.DirEntry for testing purposes
@@ -53,9 +58,7 @@ func TestProcessFileNonHEIC(t *testing.T) {
 	entry := &mockDirEntry{name: "test.txt"}
 	currentDir := os.TempDir()
 	jpegDir := filepath.Join(currentDir, "jpegs")
-	logs := processFile(entry, currentDir, jpegDir)
-
-	if _, exists := logs["test.txt"]; exists {
+	if _, converted := processFile(context.Background(), entry, currentDir, jpegDir); converted {
 		t.Fatalf("Non-HEIC file should not be processed")
 	}
 }
@@ -85,9 +88,116 @@ func TestProcessFiles(t *testing.T) {
 		t.Fatalf("Failed to read directory: %v", err)
 	}
 
-	logs := processFiles(currentDir, jpegDir, entries)
+	logs := processFiles(context.Background(), currentDir, jpegDir, entries)
 	if _, ok := logs["test.heic"]; !ok {
 		t.Errorf("Expected log entry for test.heic but didn't find one")
 	}
 
 }
+
+// Testing processFiles with an already cancelled context
+func TestProcessFilesCancelled(t *testing.T) {
+	currentDir, err := setupTestDir()
+	if err != nil {
+		t.Fatalf("Failed to setup test directory: %v", err)
+	}
+	defer os.RemoveAll(currentDir)
+
+	entries, err := os.ReadDir(currentDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	logs := processFiles(ctx, currentDir, currentDir+"/jpegs", entries)
+	if _, ok := logs["test.heic"]; ok {
+		t.Errorf("Cancelled run should not process test.heic")
+	}
+}
+
+// Testing feedFiles stops when the run is cancelled
+func TestFeedFilesCancelled(t *testing.T) {
+	files := make([]os.DirEntry, 100)
+	for i := range files {
+		files[i] = &mockDirEntry{name: "notes.txt"}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	fileChan := feedFiles(ctx, files, 2)
+	<-fileChan
+	cancel()
+
+	received := 1
+	for range fileChan {
+		received++
+	}
+	if received == len(files) {
+		t.Errorf("Cancelled feed still sent all %d files", received)
+	}
+}
+
+// Benchmarking the pipeline with growing directories. Non-HEIC entries
+// skip the decoder, so heap-B (live heap after a GC) only reflects the channels and bookkeeping
+// and should stay flat as the file count grows.
+func BenchmarkProcessStream(b *testing.B) {
+	dir := b.TempDir()
+	jpegDir := ensureJPEGDirectoryExists(dir)
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = stdout }()
+
+	for _, count := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("files=%d", count), func(b *testing.B) {
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				source := make(chan os.DirEntry)
+				go func() {
+					var stats runtime.MemStats
+					for n := 0; n < count; n++ {
+						source <- &mockDirEntry{name: "notes.txt"}
+						if n%(count/10) == 0 {
+							runtime.GC()
+							runtime.ReadMemStats(&stats)
+							if stats.HeapInuse > peak {
+								peak = stats.HeapInuse
+							}
+						}
+					}
+					close(source)
+				}()
+				processStream(context.Background(), dir, jpegDir, source, convert.UnknownTotal)
+			}
+			b.ReportMetric(float64(peak), "heap-B")
+		})
+	}
+}
+
+// Testing that converting over a larger existing JPEG leaves none of it
+// behind
+func TestConvertOverLargerOutput(t *testing.T) {
+	defer func(low bool) { *lowMemory = low }(*lowMemory)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "IMG_0001.HEIC")
+	if err := os.WriteFile(input, exifSample(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, low := range []bool{false, true} {
+		*lowMemory = low
+		fresh := filepath.Join(dir, "fresh.jpg")
+		os.Remove(fresh)
+		if err := convertHeicToJpg(context.Background(), input, fresh); err != nil {
+			t.Fatal(err)
+		}
+		output := filepath.Join(dir, "IMG_0001.jpg")
+		if err := os.WriteFile(output, make([]byte, 1<<20), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := convertHeicToJpg(context.Background(), input, output); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := getFileSize(output), getFileSize(fresh); got != want {
+			t.Errorf("-low-memory %v: %d bytes over a larger file, want %d", low, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var writeManifest = flag.Bool("manifest", false, "write jpegs/manifest.json, mapping each source file to all its outputs with their SHA-256 hashes and dimensions, for static-site generators and DAM importers")
+
+const manifestFileName = "manifest.json"
+
+// manifest is the layout of manifest.json. Paths use forward slashes:
+// sources relative to the batch folder, outputs to the output folder.
+type manifest struct {
+	Generated time.Time       `json:"generated"`
+	Source    string          `json:"source"`
+	Output    string          `json:"output"`
+	Files     []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Source  string           `json:"source"`
+	SHA256  string           `json:"sha256,omitempty"`
+	Outputs []manifestOutput `json:"outputs"`
+	Error   string           `json:"error,omitempty"`
+	Warning string           `json:"warning,omitempty"`
+	Skipped string           `json:"skipped,omitempty"`
+}
+
+type manifestOutput struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`           // main, frame, preset or profile
+	Name   string `json:"name,omitempty"` // of the preset or profile
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifestOutputs lists the files written for output: itself, its other
+// frames and its extra outputs, as far as they exist.
+func manifestOutputs(output string) []manifestOutput {
+	type file struct{ path, kind, name string }
+	files := []file{{output, "main", ""}}
+	for n := 2; *allFrames && fileExists(frameFileName(output, n)); n++ {
+		files = append(files, file{frameFileName(output, n), "frame", ""})
+	}
+	extras, _ := extrasFor(globalSettings())
+	for _, extra := range extras {
+		kind := "preset"
+		if extra.folder != "" {
+			kind = "profile"
+		}
+		files = append(files, file{extra.path(output), kind, extra.name})
+	}
+
+	var outputs []manifestOutput
+	for _, f := range files {
+		out, err := describeOutput(f.path)
+		if err != nil {
+			continue
+		}
+		out.Kind, out.Name = f.kind, f.name
+		outputs = append(outputs, out)
+	}
+	return outputs
+}
+
+// describeOutput reads the format, dimensions, size and hash of an output.
+func describeOutput(path string) (manifestOutput, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return manifestOutput{}, err
+	}
+	config, format, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil {
+		return manifestOutput{}, err
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return manifestOutput{}, err
+	}
+	return manifestOutput{
+		Path:   path,
+		Format: format,
+		Width:  config.Width,
+		Height: config.Height,
+		Bytes:  getFileSize(path),
+		SHA256: hash,
+	}, nil
+}
+
+// saveManifest writes jpegs/manifest.json for the files of r, converted
+// from dir, in path order, replacing the previous run's.
+func saveManifest(dir, jpegDir string, r *batchReport) error {
+	m := manifest{Generated: time.Now().UTC(), Source: dir, Output: jpegDir, Files: []manifestEntry{}}
+	for _, result := range r.sorted("path") {
+		entry := manifestEntry{Source: filepath.ToSlash(result.Name), Warning: result.Warning, Skipped: result.Skipped, Outputs: []manifestOutput{}}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		}
+		if result.Input != "" {
+			entry.SHA256, _ = hashFile(result.Input)
+		}
+		if result.Output != "" && result.Err == nil {
+			for _, out := range manifestOutputs(result.Output) {
+				if rel, err := filepath.Rel(jpegDir, out.Path); err == nil {
+					out.Path = rel
+				}
+				out.Path = filepath.ToSlash(out.Path)
+				entry.Outputs = append(entry.Outputs, out)
+			}
+		}
+		m.Files = append(m.Files, entry)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(longPath(filepath.Join(jpegDir, manifestFileName)), append(data, '\n'), 0644)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+func TestSaveManifest(t *testing.T) {
+	defer func(v string) { *profileNames = v }(*profileNames)
+	*profileNames = "thumb"
+
+	dir, jpegDir := t.TempDir(), t.TempDir()
+	input := filepath.Join(dir, "IMG_0001.HEIC")
+	if err := os.WriteFile(input, []byte("heic"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeJPEG := func(path string, width, height int) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(jpegDir, "IMG_0001.jpg")
+	writeJPEG(output, 40, 30)
+	writeJPEG(filepath.Join(jpegDir, "thumbs", "IMG_0001.jpg"), 20, 15)
+
+	r := &batchReport{}
+	r.OnFileDone(convert.ConversionResult{Name: "IMG_0001.HEIC", Input: input, Output: output})
+	r.OnFileDone(convert.ConversionResult{Name: "IMG_0002.HEIC", Input: filepath.Join(dir, "IMG_0002.HEIC"), Err: errors.New("broken")})
+	if err := saveManifest(dir, jpegDir, r); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(jpegDir, manifestFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 2 {
+		t.Fatalf("got %d files, want 2", len(m.Files))
+	}
+	first := m.Files[0]
+	if first.Source != "IMG_0001.HEIC" || len(first.SHA256) != 64 || len(first.Outputs) != 2 {
+		t.Fatalf("got %+v", first)
+	}
+	main, thumb := first.Outputs[0], first.Outputs[1]
+	if main.Path != "IMG_0001.jpg" || main.Kind != "main" || main.Format != "jpeg" || main.Width != 40 || main.Height != 30 || main.Bytes == 0 || len(main.SHA256) != 64 {
+		t.Errorf("main output: got %+v", main)
+	}
+	if thumb.Path != "thumbs/IMG_0001.jpg" || thumb.Kind != "profile" || thumb.Name != "thumb" || thumb.Width != 20 {
+		t.Errorf("profile output: got %+v", thumb)
+	}
+	if second := m.Files[1]; second.Error != "broken" || len(second.Outputs) != 0 {
+		t.Errorf("failed file: got %+v", second)
+	}
+}
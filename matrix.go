@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"heictojpeg/convert"
+)
+
+const matrixCSV = "matrix.csv"
+
+func init() {
+	subcommands["matrix"] = matrixCommand
+}
+
+// matrixEntry is one variant of the matrix: a format at a quality, and
+// how it scores against the decoded HEIC, as -compare-dir scores them.
+type matrixEntry struct {
+	File    string
+	Format  string
+	Quality int // 0 for lossless formats
+	Size    int64
+	PSNR    float64
+	SSIM    float64
+}
+
+// matrixFormats encode a variant; lossless ones ignore the quality.
+var matrixFormats = map[string]struct {
+	ext      string
+	lossless bool
+	decode   func(data []byte) (image.Image, error)
+}{
+	"jpeg": {".jpg", false, func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) }},
+	"png":  {".png", true, func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) }},
+}
+
+// parseQualities parses a comma-separated list of JPEG qualities.
+func parseQualities(list string) ([]int, error) {
+	var qualities []int
+	for _, q := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(q))
+		if err != nil || n < 1 || n > 100 {
+			return nil, fmt.Errorf("invalid quality %q", q)
+		}
+		qualities = append(qualities, n)
+	}
+	return qualities, nil
+}
+
+// encodeVariant writes img in format at quality with the global settings,
+// like a conversion would.
+func encodeVariant(img image.Image, exif []byte, format string, quality int) ([]byte, error) {
+	var out bytes.Buffer
+	o := globalSettings().options()
+	o.Quality, o.AutoQuality = quality, false
+	var err error
+	if format == "png" {
+		err = convert.EncodePNG(&out, img, nil, o)
+	} else {
+		err = convert.EncodeJPEG(&out, img, exif, nil, o)
+	}
+	return out.Bytes(), err
+}
+
+// qualityMatrix writes input at each format and quality into dir and scores
+// each variant against the decoded image.
+func qualityMatrix(ctx context.Context, input, dir string, formats []string, qualities []int) ([]matrixEntry, error) {
+	img, exif, err := decodeHeicFile(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(longPath(dir), 0755); err != nil {
+		return nil, err
+	}
+	s := globalSettings()
+	scaled := convert.FitWithin(convert.Crop(img, s.Crop, s.CropFocus), s.MaxSize)
+	// RGB variants are scored against the luma of the source's RGB, so
+	// the conversion from YCbCr doesn't count as a loss.
+	source, sourceRGB := convert.LumaOf(scaled), convert.LumaOf(rgbView{scaled})
+	stem := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+
+	var entries []matrixEntry
+	for _, format := range formats {
+		f := matrixFormats[format]
+		levels := qualities
+		if f.lossless {
+			levels = []int{0}
+		}
+		for _, q := range levels {
+			data, err := encodeVariant(img, exif, format, q)
+			if err != nil {
+				return nil, fmt.Errorf("%s at %d: %v", format, q, err)
+			}
+			name := stem + f.ext
+			if !f.lossless {
+				name = fmt.Sprintf("%s-q%d%s", stem, q, f.ext)
+			}
+			if err := os.WriteFile(longPath(filepath.Join(dir, name)), data, 0644); err != nil {
+				return nil, err
+			}
+			decoded, err := f.decode(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			variant, reference := convert.LumaOf(decoded), source
+			if _, ok := decoded.(*image.YCbCr); !ok {
+				reference = sourceRGB
+			}
+			entries = append(entries, matrixEntry{
+				File:    name,
+				Format:  format,
+				Quality: q,
+				Size:    int64(len(data)),
+				PSNR:    peakSNR(reference, variant),
+				SSIM:    convert.SSIM(reference, variant),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// rgbView hides the type of an image, so lumaOf reads it through its RGB
+// colours.
+type rgbView struct{ image.Image }
+
+// writeMatrixCSV writes the entries in the columns of compare.csv, with
+// the format and quality added.
+func writeMatrixCSV(path string, entries []matrixEntry) error {
+	f, err := os.Create(longPath(path))
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"file", "format", "quality", "psnr_db", "ssim", "bytes"})
+	for _, e := range entries {
+		w.Write([]string{
+			e.File,
+			e.Format,
+			strconv.Itoa(e.Quality),
+			strconv.FormatFloat(e.PSNR, 'f', 2, 64),
+			strconv.FormatFloat(e.SSIM, 'f', 4, 64),
+			strconv.FormatInt(e.Size, 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func matrixCommand(args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	qualityList := fs.String("qualities", "70,85,95", "comma-separated JPEG qualities to try")
+	formatList := fs.String("formats", "jpeg", "comma-separated formats to try: jpeg, png")
+	out := fs.String("out", "", "folder for the variants and matrix.csv (default NAME-matrix next to the HEIC file)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg matrix [-qualities 70,85,95] [-formats jpeg,png] [-out DIR] FILE.heic")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	qualities, err := parseQualities(*qualityList)
+	if err != nil {
+		return err
+	}
+	var formats []string
+	for _, f := range strings.Split(*formatList, ",") {
+		f = strings.TrimSpace(f)
+		if _, ok := matrixFormats[f]; !ok {
+			return fmt.Errorf("unknown format %q: must be jpeg or png", f)
+		}
+		formats = append(formats, f)
+	}
+	input := fs.Arg(0)
+	dir := *out
+	if dir == "" {
+		dir = strings.TrimSuffix(input, filepath.Ext(input)) + "-matrix"
+	}
+
+	entries, err := qualityMatrix(context.Background(), input, dir, formats, qualities)
+	if err != nil {
+		return err
+	}
+	if err := writeMatrixCSV(filepath.Join(dir, matrixCSV), entries); err != nil {
+		return err
+	}
+
+	heicSize := getFileSize(input)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, tr("format\tquality\tsize\tof HEIC\tPSNR\tSSIM\t"))
+	for _, e := range entries {
+		q := "-"
+		if e.Quality > 0 {
+			q = strconv.Itoa(e.Quality)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.0f%%\t%.2f\t%.4f\t\n", e.Format, q, humanReadableFileSize(e.Size), 100*float64(e.Size)/float64(heicSize), e.PSNR, e.SSIM)
+	}
+	w.Flush()
+	fmt.Printf(tr("Wrote the variants and %s to %s\n"), matrixCSV, dir)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/csv"
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+func TestParseQualities(t *testing.T) {
+	got, err := parseQualities("70, 85,95")
+	if err != nil || !reflect.DeepEqual(got, []int{70, 85, 95}) {
+		t.Errorf("parseQualities = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "0", "101", "70,,85", "high"} {
+		if _, err := parseQualities(bad); err == nil {
+			t.Errorf("parseQualities(%q) didn't fail", bad)
+		}
+	}
+}
+
+func TestEncodeVariant(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	low, err := encodeVariant(img, nil, "jpeg", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	high, err := encodeVariant(img, nil, "jpeg", 95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(low) >= len(high) {
+		t.Errorf("quality 30 is %d bytes, 95 is %d", len(low), len(high))
+	}
+	lossless, err := encodeVariant(img, nil, "png", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := matrixFormats["png"].decode(lossless)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score := convert.SSIM(convert.LumaOf(img), convert.LumaOf(decoded)); score < 0.9999 {
+		t.Errorf("png SSIM = %v", score)
+	}
+}
+
+func TestWriteMatrixCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), matrixCSV)
+	entries := []matrixEntry{{File: "a-q70.jpg", Format: "jpeg", Quality: 70, Size: 1234, PSNR: 38.5, SSIM: 0.97}}
+	if err := writeMatrixCSV(path, entries); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"file", "format", "quality", "psnr_db", "ssim", "bytes"}, {"a-q70.jpg", "jpeg", "70", "38.50", "0.9700", "1234"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v", rows)
+	}
+}
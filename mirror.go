@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var mirrorDelete = flag.Bool("delete", false, "with heictojpeg sync, remove the JPEGs whose HEIC is gone from the source")
+
+// syncCommand is the verb in "heictojpeg sync [options] SRC DST", which
+// keeps DST a converted mirror of SRC across runs.
+const syncCommand = "sync"
+
+// setupMirror points the run at the SRC and DST of args: every folder of
+// SRC is converted into the same place under DST, and the history skips
+// the photos converted by earlier runs.
+func setupMirror(args []string) error {
+	if len(args) != 2 {
+		return errors.New(tr("usage: heictojpeg sync [options] SRC DST"))
+	}
+	*sourceDir, *outDir = args[0], args[1]
+	*recursive, *useHistory = true, true
+	return nil
+}
+
+// orphanedOutputs returns the JPEGs in dst that the history says were
+// converted from a file in src that is gone, sorted. A JPEG that a file
+// still in src was converted to as well is kept.
+func orphanedOutputs(h *history, src, dst string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wanted := make(map[string]bool)
+	gone := make(map[string]bool)
+	for _, e := range h.entries {
+		if !within(src, e.Source) || !within(dst, e.Output) {
+			continue
+		}
+		if fileExists(e.Source) {
+			wanted[e.Output] = true
+		} else if fileExists(e.Output) {
+			gone[e.Output] = true
+		}
+	}
+	var orphans []string
+	for output := range gone {
+		if !wanted[output] {
+			orphans = append(orphans, output)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// pruneMirror removes the orphaned JPEGs of dst, and the folders that
+// leaves empty, with -delete; without it they are only listed.
+func pruneMirror(ctx context.Context, h *history, src, dst string) error {
+	orphans := orphanedOutputs(h, src, dst)
+	if len(orphans) == 0 {
+		return nil
+	}
+	if !*mirrorDelete {
+		for _, path := range orphans {
+			fmt.Printf(tr("Source gone: %s\n"), path)
+		}
+		fmt.Printf(tr("%d JPEGs in %s have no HEIC any more; run again with -delete to remove them.\n"), len(orphans), dst)
+		return nil
+	}
+	j := journalFrom(ctx)
+	root := resolvePath(dst)
+	for _, path := range orphans {
+		if err := guardWrite(path); err != nil {
+			return err
+		}
+		if err := os.Remove(longPath(path)); err != nil {
+			return err
+		}
+		if j != nil {
+			j.deleted(path)
+		}
+		fmt.Printf(tr("Removed %s\n"), path)
+		for dir := filepath.Dir(path); within(root, dir) && resolvePath(dir) != root; dir = filepath.Dir(dir) {
+			if os.Remove(longPath(dir)) != nil {
+				break // not empty
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPruneMirror(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	touch := func(path string) string {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	h := &history{byHash: make(map[string]int)}
+	convert := func(source, output string, keepSource bool) {
+		h.record(historyEntry{Source: touch(filepath.Join(src, source)), Hash: source, Output: touch(filepath.Join(dst, output))})
+		if !keepSource {
+			os.Remove(filepath.Join(src, source))
+		}
+	}
+	convert("kept.heic", "kept.jpg", true)
+	convert("2023/gone.heic", "2023/gone.jpg", false)
+	convert("renamed.heic", "same.jpg", false)
+	convert("new name.heic", "same.jpg", true)
+	h.record(historyEntry{Source: filepath.Join(t.TempDir(), "elsewhere.heic"), Output: touch(filepath.Join(dst, "elsewhere.jpg"))})
+
+	want := []string{filepath.Join(dst, "2023", "gone.jpg")}
+	if got := orphanedOutputs(h, src, dst); !reflect.DeepEqual(got, want) {
+		t.Fatalf("orphans = %q, want %q", got, want)
+	}
+
+	if err := pruneMirror(context.Background(), h, src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(want[0]) {
+		t.Fatal("removed without -delete")
+	}
+
+	*mirrorDelete = true
+	defer func() { *mirrorDelete = false }()
+	if err := pruneMirror(context.Background(), h, src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(filepath.Join(dst, "2023")) {
+		t.Error("the emptied folder is still there")
+	}
+	for _, name := range []string{"kept.jpg", "same.jpg", "elsewhere.jpg"} {
+		if !fileExists(filepath.Join(dst, name)) {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
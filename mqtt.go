@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	mqttBroker = flag.String("mqtt", "", "publish conversion events to this MQTT broker, mqtt://host:1883 or mqtts://host:8883, for home automation; a user name can be given in the URL, its password in HEICTOJPEG_MQTT_PASSWORD")
+	mqttTopic  = flag.String("mqtt-topic", "heictojpeg", "topic -mqtt publishes under: <topic>/start, <topic>/file and <topic>/finish, and the latest photo, retained, to <topic>/latest")
+	mqttQoS    = flag.Int("mqtt-qos", 1, "MQTT quality of service of the events: 0 (at most once) or 1 (at least once)")
+)
+
+const mqttPasswordEnv = "HEICTOJPEG_MQTT_PASSWORD"
+
+// mqttKeepAlive is how often the broker hears from us at least; idle
+// connections are pinged at half of it.
+const mqttKeepAlive = 60 * time.Second
+
+// MQTT 3.1.1 packet types, in the high bits of the first byte.
+const (
+	mqttConnect    = 1
+	mqttConnAck    = 2
+	mqttPublish    = 3
+	mqttPubAck     = 4
+	mqttPingReq    = 12
+	mqttPingResp   = 13
+	mqttDisconnect = 14
+)
+
+var mqttConnectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// mqttConn is a connection speaking MQTT 3.1.1, with just enough of it to
+// publish: no subscriptions, and QoS 0 or 1.
+type mqttConn struct {
+	mu     sync.Mutex // one packet exchange at a time
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID uint16
+	done   chan struct{}
+}
+
+// parseBroker checks the -mqtt URL.
+func parseBroker(broker string) (*url.URL, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "mqtt" && u.Scheme != "mqtts" {
+		return nil, errors.New("must be mqtt://host[:port] or mqtts://host[:port]")
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("has no host")
+	}
+	return u, nil
+}
+
+// dialMQTT connects to broker with a clean session. The password is the
+// URL's, or else HEICTOJPEG_MQTT_PASSWORD when the URL names a user.
+func dialMQTT(ctx context.Context, broker *url.URL, clientID string) (*mqttConn, error) {
+	addr := broker.Host
+	if broker.Port() == "" {
+		port := "1883"
+		if broker.Scheme == "mqtts" {
+			port = "8883"
+		}
+		addr = net.JoinHostPort(broker.Hostname(), port)
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if broker.Scheme == "mqtts" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: broker.Hostname()}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &mqttConn{conn: conn, r: bufio.NewReader(conn), done: make(chan struct{})}
+
+	flags := byte(0x02) // clean session
+	payload := mqttString(clientID)
+	if user := broker.User; user != nil {
+		password, ok := user.Password()
+		if !ok {
+			password, ok = os.LookupEnv(mqttPasswordEnv)
+		}
+		flags |= 0x80
+		payload = append(payload, mqttString(user.Username())...)
+		if ok {
+			flags |= 0x40
+			payload = append(payload, mqttString(password)...)
+		}
+	}
+	header := append(mqttString("MQTT"), 4, flags, 0, 0)
+	binary.BigEndian.PutUint16(header[len(header)-2:], uint16(mqttKeepAlive/time.Second))
+
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := c.write(mqttConnect<<4, append(header, payload...)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	typ, body, err := c.read()
+	if err == nil && (typ>>4 != mqttConnAck || len(body) != 2) {
+		err = fmt.Errorf("expected CONNACK, got packet type %d", typ>>4)
+	}
+	if err == nil && body[1] != 0 {
+		err = fmt.Errorf("connection refused: %s", mqttConnectErrors[body[1]])
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	go c.keepAlive()
+	return c, nil
+}
+
+// keepAlive pings the broker, so it doesn't drop the connection between
+// the events of a slow file.
+func (c *mqttConn) keepAlive() {
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			c.conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+			c.write(mqttPingReq<<4, nil)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// publish sends payload to topic and, at QoS 1, waits for the broker to
+// acknowledge it.
+func (c *mqttConn) publish(topic string, payload []byte, qos byte, retain bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetDeadline(time.Now().Add(30 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+
+	first := byte(mqttPublish<<4) | qos<<1
+	if retain {
+		first |= 1
+	}
+	body := mqttString(topic)
+	var id uint16
+	if qos > 0 {
+		c.nextID++
+		if c.nextID == 0 {
+			c.nextID = 1
+		}
+		id = c.nextID
+		body = append(body, byte(id>>8), byte(id))
+	}
+	if err := c.write(first, append(body, payload...)); err != nil || qos == 0 {
+		return err
+	}
+	for {
+		typ, body, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case typ>>4 == mqttPingResp:
+		case typ>>4 == mqttPubAck && len(body) == 2 && binary.BigEndian.Uint16(body) == id:
+			return nil
+		default:
+			return fmt.Errorf("expected PUBACK, got packet type %d", typ>>4)
+		}
+	}
+}
+
+func (c *mqttConn) close() error {
+	close(c.done)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	c.write(mqttDisconnect<<4, nil)
+	return c.conn.Close()
+}
+
+// write sends a packet: its first byte, the remaining length and body.
+func (c *mqttConn) write(first byte, body []byte) error {
+	packet := []byte{first}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := c.conn.Write(append(packet, body...))
+	return err
+}
+
+// read reads a packet, returning its first byte and body.
+func (c *mqttConn) read() (byte, []byte, error) {
+	first, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("malformed MQTT packet length")
+		}
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(c.r, body)
+	return first, body, err
+}
+
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+// mqttObserver publishes the events of -output ndjson to the -mqtt
+// broker, as JSON, each under its own subtopic. It connects for each run,
+// so a daemon running -schedule doesn't hold a connection between runs,
+// and stops trying for the rest of a run once the broker can't be reached.
+type mqttObserver struct {
+	broker   *url.URL
+	topic    string
+	qos      byte
+	clientID string
+	mu       sync.Mutex
+	conn     *mqttConn
+	down     bool
+}
+
+func newMQTTObserver(broker, topic string, qos int) (*mqttObserver, error) {
+	u, err := parseBroker(broker)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &mqttObserver{broker: u, topic: strings.TrimSuffix(topic, "/"), qos: byte(qos), clientID: fmt.Sprintf("heictojpeg-%s-%d", host, os.Getpid())}, nil
+}
+
+func (o *mqttObserver) OnStart(total int) {
+	o.mu.Lock()
+	o.down = false
+	o.mu.Unlock()
+	o.send("start", ndjsonStart{Event: "start", Total: total}, false)
+}
+
+func (o *mqttObserver) OnFileDone(result convert.ConversionResult) {
+	event := fileEvent(result)
+	o.send("file", event, false)
+	if event.Status == "converted" {
+		// For frames and dashboards that only show the newest photo.
+		o.send("latest", event, true)
+	}
+}
+
+func (o *mqttObserver) OnFinish(summary convert.Summary) {
+	o.send("finish", finishEvent(summary), false)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.conn != nil {
+		o.conn.close()
+		o.conn = nil
+	}
+}
+
+// send publishes event to the subtopic, connecting again once if the
+// connection was lost.
+func (o *mqttObserver) send(subtopic string, event interface{}, retain bool) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.down {
+		return
+	}
+	for attempt := 0; ; attempt++ {
+		if o.conn == nil {
+			if o.conn, err = dialMQTT(context.Background(), o.broker, o.clientID); err != nil {
+				break
+			}
+		}
+		if err = o.conn.publish(o.topic+"/"+subtopic, payload, o.qos, retain); err == nil {
+			return
+		}
+		o.conn.close()
+		o.conn = nil
+		if attempt == 1 {
+			break
+		}
+	}
+	o.down = true
+	fmt.Printf(tr("Failed to publish to the MQTT broker, skipping its events until the next run: %v\n"), err)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+// fakeBroker accepts MQTT connections and records what is published,
+// acknowledging QoS 1 messages. refuse is the CONNACK return code.
+type fakeBroker struct {
+	mu        sync.Mutex
+	addr      string
+	refuse    byte
+	connects  []string // user names, "" for none
+	published []fakeMessage
+}
+
+type fakeMessage struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	b := &fakeBroker{addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	c := &mqttConn{conn: conn, r: bufio.NewReader(conn)}
+	for {
+		first, body, err := c.read()
+		if err != nil {
+			return
+		}
+		switch first >> 4 {
+		case mqttConnect:
+			// Protocol name, level, flags, keep alive, then the client id.
+			flags := body[7]
+			rest := body[10:]
+			rest = rest[2+binary.BigEndian.Uint16(rest):]
+			user := ""
+			if flags&0x80 != 0 {
+				user = string(rest[2 : 2+binary.BigEndian.Uint16(rest)])
+			}
+			b.mu.Lock()
+			b.connects = append(b.connects, user)
+			refuse := b.refuse
+			b.mu.Unlock()
+			c.write(mqttConnAck<<4, []byte{0, refuse})
+		case mqttPublish:
+			n := binary.BigEndian.Uint16(body)
+			topic, rest := string(body[2:2+n]), body[2+n:]
+			var id []byte
+			if qos := first >> 1 & 3; qos > 0 {
+				id, rest = rest[:2], rest[2:]
+			}
+			b.mu.Lock()
+			b.published = append(b.published, fakeMessage{topic, string(rest), first&1 != 0})
+			b.mu.Unlock()
+			if id != nil {
+				c.write(mqttPubAck<<4, id)
+			}
+		case mqttPingReq:
+			c.write(mqttPingResp<<4, nil)
+		case mqttDisconnect:
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) messages() []fakeMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]fakeMessage(nil), b.published...)
+}
+
+func TestMQTTObserver(t *testing.T) {
+	broker := newFakeBroker(t)
+	t.Setenv(mqttPasswordEnv, "secret")
+	o, err := newMQTTObserver("mqtt://frame@"+broker.addr, "home/photos/", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.OnStart(2)
+	o.OnFileDone(convert.ConversionResult{Input: "/p/IMG_0001.HEIC", Output: "/p/jpegs/IMG_0001.jpg", OutputSize: 1000})
+	o.OnFileDone(convert.ConversionResult{Input: "/p/IMG_0002.HEIC", Err: errors.New("truncated")})
+	o.OnFinish(convert.Summary{Files: 2, Failed: 1})
+
+	var topics []string
+	for _, m := range broker.messages() {
+		topics = append(topics, m.topic)
+		if m.retain != (m.topic == "home/photos/latest") {
+			t.Errorf("%s: retain = %v", m.topic, m.retain)
+		}
+	}
+	if got, want := strings.Join(topics, " "), "home/photos/start home/photos/file home/photos/latest home/photos/file home/photos/finish"; got != want {
+		t.Errorf("published to %s, want %s", got, want)
+	}
+	var latest ndjsonFile
+	if err := json.Unmarshal([]byte(broker.messages()[2].payload), &latest); err != nil || latest.Output != "/p/jpegs/IMG_0001.jpg" || latest.Status != "converted" {
+		t.Errorf("latest = %+v, %v", latest, err)
+	}
+	broker.mu.Lock()
+	if len(broker.connects) != 1 || broker.connects[0] != "frame" {
+		t.Errorf("connects = %q, want one as frame", broker.connects)
+	}
+	broker.mu.Unlock()
+
+	// A second run connects again.
+	o.OnStart(0)
+	o.OnFinish(convert.Summary{})
+	if n := len(broker.messages()); n != 7 {
+		t.Errorf("%d messages after the second run, want 7", n)
+	}
+}
+
+func TestMQTTRefused(t *testing.T) {
+	broker := newFakeBroker(t)
+	broker.refuse = 5
+	o, err := newMQTTObserver("mqtt://"+broker.addr, "heictojpeg", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.OnStart(1)
+	o.OnFileDone(convert.ConversionResult{Output: "a.jpg"})
+	o.OnFinish(convert.Summary{})
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	// Given up for the rest of the run at the start event.
+	if len(broker.connects) != 1 || len(broker.published) != 0 {
+		t.Errorf("%d connects, %d messages; want 1 and none", len(broker.connects), len(broker.published))
+	}
+}
+
+func TestParseBroker(t *testing.T) {
+	for broker, ok := range map[string]bool{
+		"mqtt://localhost":         true,
+		"mqtts://user:pw@nas:8883": true,
+		"tcp://localhost:1883":     false,
+		"mqtt://":                  false,
+		"localhost:1883":           false,
+	} {
+		if _, err := parseBroker(broker); (err == nil) != ok {
+			t.Errorf("parseBroker(%q) = %v", broker, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+import "golang.org/x/text/unicode/norm"
+
+// toNFC composes decomposed (NFD) file names, as produced by macOS exports,
+// so the same photo gets the same output name no matter where it was synced from.
+func toNFC(name string) string {
+	return norm.NFC.String(name)
+}
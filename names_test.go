@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Testing toNFC function
+func TestToNFC(t *testing.T) {
+	cases := map[string]string{
+		"IMG_0001":                             "IMG_0001",
+		"Cafe\u0301":                           "Caf\u00e9",
+		"Caf\u00e9":                            "Caf\u00e9",
+		"Zu\u0308rich Ba\u0308r":               "Z\u00fcrich B\u00e4r",
+		"Vie\u0323\u0302t Nam":                 "Vi\u1ec7t Nam",
+		"\u1112\u1161\u11ab\u1100\u1173\u11af": "\ud55c\uae00",
+		// Canonically equivalent, with the marks in either order.
+		"e\u0301\u0323": "\u1eb9\u0301",
+		"e\u0323\u0301": "\u1eb9\u0301",
+	}
+	for in, want := range cases {
+		if got := toNFC(in); got != want {
+			t.Errorf("toNFC(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// Testing jpegFileName for names exported from macOS
+func TestJPEGFileNameNFD(t *testing.T) {
+	if got, want := jpegFileName("Cafe\u0301.HEIC"), "Caf\u00e9.jpg"; got != want {
+		t.Fatalf("jpegFileName = %q, want %q", got, want)
+	}
+}
+
+// Testing processFiles with a decomposed name in a very deep directory
+func TestProcessFilesLongUnicodePath(t *testing.T) {
+	currentDir := t.TempDir()
+	for len(currentDir) < 300 {
+		currentDir = filepath.Join(currentDir, strings.Repeat("d", 40))
+	}
+	if err := os.MkdirAll(longPath(currentDir), 0755); err != nil {
+		t.Fatalf("Failed to create deep directory: %v", err)
+	}
+	name := "Cafe\u0301.heic"
+	if err := os.WriteFile(longPath(filepath.Join(currentDir, name)), []byte("mock content"), 0644); err != nil {
+		t.Fatalf("Failed to write mock file: %v", err)
+	}
+
+	jpegDir := ensureJPEGDirectoryExists(currentDir)
+	entries, err := getFilesInDirectory(currentDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+
+	logs := processFiles(context.Background(), currentDir, jpegDir, entries)
+	if _, ok := logs[name]; !ok {
+		t.Errorf("Expected log entry for %q", name)
+	}
+
+	// The whole folder, down to writing the log.
+	if err := convertDirectory(context.Background(), currentDir, nil); err != nil {
+		t.Fatalf("convertDirectory: %v", err)
+	}
+	data, err := os.ReadFile(longPath(filepath.Join(jpegDir, logFileName)))
+	if err != nil {
+		t.Fatalf("Failed to read the log: %v", err)
+	}
+	if !strings.Contains(string(data), name) {
+		t.Errorf("Expected %q in the log, got %q", name, data)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"sync"
+
+	"heictojpeg/convert"
+)
+
+var outputFormat = flag.String("output", "text", "stdout format: text, or ndjson for one JSON object per completed file (messages go to stderr)")
+
+// The lines of -output ndjson, told apart by their "event" field.
+type ndjsonStart struct {
+	Event string `json:"event"`
+	Total int    `json:"total"`
+}
+
+type ndjsonFile struct {
+	Event        string                `json:"event"`
+	Input        string                `json:"input"`
+	Output       string                `json:"output,omitempty"`
+	Status       string                `json:"status"`
+	Error        string                `json:"error,omitempty"`
+	Warning      string                `json:"warning,omitempty"`
+	Reason       string                `json:"reason,omitempty"`
+	Exif         convert.ExifStatus    `json:"exif,omitempty"`
+	Profile      convert.ProfileStatus `json:"profile,omitempty"`
+	ColorProfile string                `json:"color_profile,omitempty"`
+	PHash        string                `json:"phash,omitempty"`
+	DHash        string                `json:"dhash,omitempty"`
+	Orientation  int                   `json:"orientation,omitempty"`
+	InputBytes   int64                 `json:"input_bytes"`
+	OutputBytes  int64                 `json:"output_bytes"`
+	DurationSec  float64               `json:"duration_seconds"`
+}
+
+// ndjsonPipe tells the reader of -pipes which pipe the next JPEG is
+// written to; the file event follows when it is done.
+type ndjsonPipe struct {
+	Event  string `json:"event"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+type ndjsonFinish struct {
+	Event       string                `json:"event"`
+	Files       int                   `json:"files"`
+	Converted   int                   `json:"converted"`
+	Failed      int                   `json:"failed"`
+	Skipped     int                   `json:"skipped"`
+	DurationSec float64               `json:"duration_seconds"`
+	InputBytes  int64                 `json:"input_bytes"`
+	OutputBytes int64                 `json:"output_bytes"`
+	Exif        convert.ExifCounts    `json:"exif"`
+	Profiles    convert.ProfileCounts `json:"profiles"`
+}
+
+// ndjsonObserver streams results as newline-delimited JSON so wrappers can
+// show progress without scraping the console text.
+type ndjsonObserver struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newNDJSONObserver(w io.Writer) *ndjsonObserver {
+	return &ndjsonObserver{enc: json.NewEncoder(w)}
+}
+
+func (o *ndjsonObserver) write(event interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enc.Encode(event)
+}
+
+func (o *ndjsonObserver) OnStart(total int) {
+	o.write(ndjsonStart{Event: "start", Total: total})
+}
+
+func (o *ndjsonObserver) OnFileDone(result convert.ConversionResult) {
+	o.write(fileEvent(result))
+}
+
+// fileEvent is the file event of result, for -output ndjson and -mqtt.
+func fileEvent(result convert.ConversionResult) ndjsonFile {
+	event := ndjsonFile{
+		Event:       "file",
+		Input:       result.Input,
+		Status:      "converted",
+		Warning:     result.Warning,
+		Exif:        result.Exif,
+		InputBytes:  result.InputSize,
+		DurationSec: result.Duration.Seconds(),
+	}
+	if result.Err != nil {
+		event.Status = "failed"
+		event.Error = result.Err.Error()
+	} else if result.Skipped != "" {
+		event.Status = "skipped"
+		event.Reason = result.Skipped
+	} else {
+		event.Output = result.Output
+		event.OutputBytes = result.OutputSize
+		event.Orientation = result.Orientation
+		event.Profile, event.ColorProfile = result.Profile, result.ColorProfile
+		if result.Hashes != nil {
+			event.PHash = convert.FormatHash(result.Hashes.PHash)
+			event.DHash = convert.FormatHash(result.Hashes.DHash)
+		}
+	}
+	return event
+}
+
+// OnPipe announces the pipe output is about to be written to.
+func (o *ndjsonObserver) OnPipe(input, output string) {
+	o.write(ndjsonPipe{Event: "pipe", Input: input, Output: output})
+}
+
+func (o *ndjsonObserver) OnFinish(summary convert.Summary) {
+	o.write(finishEvent(summary))
+}
+
+func finishEvent(summary convert.Summary) ndjsonFinish {
+	return ndjsonFinish{
+		Event:       "finish",
+		Files:       summary.Files,
+		Converted:   summary.Files - summary.Failed - summary.Skipped,
+		Failed:      summary.Failed,
+		Skipped:     summary.Skipped,
+		DurationSec: summary.Duration.Seconds(),
+		InputBytes:  summary.InputSize,
+		OutputBytes: summary.OutputSize,
+		Exif:        summary.Exif,
+		Profiles:    summary.Profiles,
+	}
+}
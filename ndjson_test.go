@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+func TestNDJSONObserver(t *testing.T) {
+	var buf bytes.Buffer
+	o := newNDJSONObserver(&buf)
+	o.OnStart(2)
+	o.OnFileDone(convert.ConversionResult{Input: "a.heic", Output: "jpegs/a.jpg", InputSize: 10, OutputSize: 5, Duration: time.Second, Exif: convert.ExifCopied, Hashes: &convert.Hashes{PHash: 0xf0, DHash: 1}})
+	o.OnFileDone(convert.ConversionResult{Input: "b.heic", InputSize: 7, Err: errors.New("boom")})
+	o.OnFinish(convert.Summary{Files: 2, Failed: 1, Duration: 2 * time.Second})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	var events []map[string]interface{}
+	for _, line := range lines {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	if e := events[0]; e["event"] != "start" || e["total"] != 2.0 {
+		t.Errorf("start = %v", e)
+	}
+	if e := events[1]; e["status"] != "converted" || e["output"] != "jpegs/a.jpg" || e["output_bytes"] != 5.0 || e["exif"] != "copied" || e["duration_seconds"] != 1.0 || e["phash"] != "00000000000000f0" || e["dhash"] != "0000000000000001" {
+		t.Errorf("converted = %v", e)
+	}
+	if e := events[2]; e["status"] != "failed" || e["error"] != "boom" || e["output"] != nil || e["exif"] != nil || e["phash"] != nil {
+		t.Errorf("failed = %v", e)
+	}
+	if e := events[3]; e["event"] != "finish" || e["converted"] != 1.0 || e["failed"] != 1.0 || e["duration_seconds"] != 2.0 {
+		t.Errorf("finish = %v", e)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var notify = flag.Bool("notify", false, "show a desktop notification when the batch completes")
+
+type notifyObserver struct{}
+
+func (notifyObserver) OnStart(total int)                   {}
+func (notifyObserver) OnFileDone(convert.ConversionResult) {}
+
+func (notifyObserver) OnFinish(summary convert.Summary) {
+	title, body := notificationText(summary)
+	if err := sendNotification(title, body); err != nil {
+		fmt.Printf(tr("Failed to show desktop notification: %v\n"), err)
+	}
+}
+
+func notificationText(summary convert.Summary) (string, string) {
+	converted := summary.Files - summary.Failed
+	body := fmt.Sprintf(tr("Converted %d of %d files in %v"), converted, summary.Files, summary.Duration.Round(time.Second))
+	if summary.Failed > 0 {
+		return tr("HEIC conversion finished with errors"), fmt.Sprintf(tr("%s, %d failed. See jpegs/logs.txt."), body, summary.Failed)
+	}
+	return tr("HEIC conversion finished"), body + "."
+}
@@ -0,0 +1,17 @@
+//go:build darwin
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+func sendNotification(title, body string) error {
+	script := "display notification " + appleScriptString(body) + " with title " + appleScriptString(title)
+	return exec.Command("osascript", "-e", script).Run()
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
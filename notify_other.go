@@ -0,0 +1,11 @@
+//go:build !darwin && !windows
+
+package main
+
+import "os/exec"
+
+// sendNotification goes through libnotify's notify-send, which is
+// available on most Linux and BSD desktops.
+func sendNotification(title, body string) error {
+	return exec.Command("notify-send", "--app-name=heictojpeg", title, body).Run()
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+// Testing notificationText function
+func TestNotificationText(t *testing.T) {
+	title, body := notificationText(convert.Summary{Files: 325, Duration: 27*time.Second + 220*time.Millisecond})
+	if title != "HEIC conversion finished" || body != "Converted 325 of 325 files in 27s." {
+		t.Errorf("Unexpected notification %q: %q", title, body)
+	}
+
+	title, body = notificationText(convert.Summary{Files: 10, Failed: 2, Duration: time.Minute})
+	if !strings.Contains(title, "errors") || !strings.Contains(body, "Converted 8 of 10") || !strings.Contains(body, "2 failed") {
+		t.Errorf("Unexpected notification %q: %q", title, body)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Toasts need a registered AppUserModelID; PowerShell's own is always present.
+const toastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+func sendNotification(title, body string) error {
+	script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text[0].AppendChild($template.CreateTextNode(` + powerShellString(title) + `)) > $null
+$text[1].AppendChild($template.CreateTextNode(` + powerShellString(body) + `)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(` + powerShellString(toastAppID) + `).Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Run()
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
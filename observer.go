@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"heictojpeg/convert"
+
+	"github.com/adrium/goheif/heif"
+)
+
+// workerObserver is implemented by observers that also track which file
+// each worker is busy with.
+type workerObserver interface {
+	OnFileStart(worker int, input string)
+}
+
+type exifStatusKey struct{}
+
+// withExifStatus has the conversion under ctx store the EXIF outcome of
+// its file in status.
+func withExifStatus(ctx context.Context, status *convert.ExifStatus) context.Context {
+	return context.WithValue(ctx, exifStatusKey{}, status)
+}
+
+func recordExif(ctx context.Context, status convert.ExifStatus) {
+	if p, ok := ctx.Value(exifStatusKey{}).(*convert.ExifStatus); ok {
+		*p = status
+	}
+}
+
+// exifStatusOf derives the EXIF outcome from a conversion error. The
+// message is compared too, since -isolate only passes the text back.
+func exifStatusOf(err error) convert.ExifStatus {
+	switch {
+	case err == nil || isWarning(err):
+		return convert.ExifCopied
+	case errors.Is(err, heif.ErrNoEXIF) || strings.Contains(err.Error(), heif.ErrNoEXIF.Error()):
+		return convert.ExifMissing
+	}
+	return convert.ExifUnknown
+}
+
+func countHEICFiles(files []os.DirEntry) int {
+	count := 0
+	for _, file := range files {
+		if isHEIC(file.Name()) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"heictojpeg/convert"
+
+	"github.com/adrium/goheif/heif"
+)
+
+type recordingObserver struct {
+	total   int
+	results []convert.ConversionResult
+	summary *convert.Summary
+}
+
+func (r *recordingObserver) OnStart(total int) { r.total = total }
+func (r *recordingObserver) OnFileDone(result convert.ConversionResult) {
+	r.results = append(r.results, result)
+}
+func (r *recordingObserver) OnFinish(summary convert.Summary) { r.summary = &summary }
+
+// Testing processFiles notifies observers
+func TestProcessFilesObserver(t *testing.T) {
+	currentDir, err := setupTestDir()
+	if err != nil {
+		t.Fatalf("Failed to setup test directory: %v", err)
+	}
+	defer os.RemoveAll(currentDir)
+	if err := os.WriteFile(currentDir+"/notes.txt", []byte("not a photo"), 0644); err != nil {
+		t.Fatalf("Failed to write notes.txt: %v", err)
+	}
+
+	entries, err := os.ReadDir(currentDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+
+	observer := &recordingObserver{}
+	processFiles(context.Background(), currentDir, currentDir+"/jpegs", entries, observer)
+
+	if observer.total != 1 {
+		t.Errorf("OnStart total = %d, want 1", observer.total)
+	}
+	if len(observer.results) != 1 || observer.results[0].Err == nil {
+		t.Fatalf("Expected one failed result for the mock file, got %+v", observer.results)
+	}
+	if observer.summary == nil || observer.summary.Files != 1 || observer.summary.Failed != 1 {
+		t.Errorf("Unexpected summary %+v", observer.summary)
+	}
+}
+
+// Testing exifStatusOf for direct and isolated errors
+func TestExifStatusOf(t *testing.T) {
+	cases := []struct {
+		err  error
+		want convert.ExifStatus
+	}{
+		{nil, convert.ExifCopied},
+		{&conversionWarning{msg: "file is truncated"}, convert.ExifCopied},
+		{fmt.Errorf("decode: %w", heif.ErrNoEXIF), convert.ExifMissing},
+		{errors.New("heif: no EXIF found"), convert.ExifMissing},
+		{errors.New("decoder crashed"), convert.ExifUnknown},
+	}
+	for _, c := range cases {
+		if got := exifStatusOf(c.err); got != c.want {
+			t.Errorf("exifStatusOf(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+}
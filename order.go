@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"time"
+)
+
+var order = flag.String("order", "", "processing order: name, size-asc, size-desc (largest first keeps all cores busy at the end) or mtime (newest first)")
+
+var orders = map[string]bool{"": true, "name": true, "size-asc": true, "size-desc": true, "mtime": true}
+
+// orderFiles sorts files for the work queue. Files whose info can't be read
+// sort as empty and old.
+func orderFiles(files []os.DirEntry, by string) {
+	if by == "" {
+		return
+	}
+	sizes := make(map[string]int64, len(files))
+	mtimes := make(map[string]time.Time, len(files))
+	if by != "name" {
+		for _, f := range files {
+			if info, err := f.Info(); err == nil && info != nil {
+				sizes[f.Name()] = info.Size()
+				mtimes[f.Name()] = info.ModTime()
+			}
+		}
+	}
+	less := func(a, b os.DirEntry) bool { return a.Name() < b.Name() }
+	switch by {
+	case "size-asc":
+		less = func(a, b os.DirEntry) bool { return sizes[a.Name()] < sizes[b.Name()] }
+	case "size-desc":
+		less = func(a, b os.DirEntry) bool { return sizes[a.Name()] > sizes[b.Name()] }
+	case "mtime":
+		less = func(a, b os.DirEntry) bool { return mtimes[a.Name()].After(mtimes[b.Name()]) }
+	}
+	sort.SliceStable(files, func(i, j int) bool { return less(files[i], files[j]) })
+}
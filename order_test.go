@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"b.heic", "a.heic", "c.heic"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, make([]byte, 10*(i+1)), 0644) // b 10, a 20, c 30 bytes
+		mtime := now.Add(-time.Duration(i) * time.Hour)  // b newest
+		os.Chtimes(path, mtime, mtime)
+	}
+
+	for by, want := range map[string]string{
+		"name":      "a.heic b.heic c.heic",
+		"size-asc":  "b.heic a.heic c.heic",
+		"size-desc": "c.heic a.heic b.heic",
+		"mtime":     "b.heic a.heic c.heic",
+	} {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orderFiles(files, by)
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		if got := strings.Join(names, " "); got != want {
+			t.Errorf("-order %s: got %s, want %s", by, got, want)
+		}
+	}
+}
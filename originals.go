@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	deleteOriginals = flag.Bool("delete-originals", false, "delete each HEIC file once its JPEG has been written (failed, skipped and repaired files are kept)")
+	archiveDir      = flag.String("archive-dir", "", "with -delete-originals, move the HEIC files into this folder instead of deleting them, so heictojpeg undo can put them back")
+)
+
+// removeOriginal deletes input after a conversion, once output is on disk
+// and not empty, so a lost write never costs the photo. With archived, the
+// original is moved there instead.
+func removeOriginal(input, output, archived string) error {
+	if err := guardWrite(input); err != nil {
+		return err
+	}
+	info, err := os.Stat(longPath(output))
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("%s is empty", output)
+	}
+	if archived == "" {
+		return os.Remove(longPath(input))
+	}
+	if _, err := os.Lstat(longPath(archived)); err == nil {
+		return fmt.Errorf("%s is already archived", archived)
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(archived)), 0755); err != nil {
+		return err
+	}
+	return moveFile(input, archived)
+}
+
+// moveFile renames src to dst, or copies it and removes the original
+// when they are on different filesystems.
+func moveFile(src, dst string) error {
+	for _, path := range []string{src, dst} {
+		if err := guardWrite(path); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(longPath(src), longPath(dst)); err != nil {
+		if err = copyFile(src, dst); err != nil {
+			return err
+		}
+		return os.Remove(longPath(src))
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(longPath(path))
+	return err == nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveOriginal(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "IMG_0001.heic")
+	output := filepath.Join(dir, "IMG_0001.jpg")
+	os.WriteFile(input, []byte("heic"), 0644)
+
+	if err := removeOriginal(input, output, ""); err == nil {
+		t.Error("removed the original without an output")
+	}
+	os.WriteFile(output, nil, 0644)
+	if err := removeOriginal(input, output, ""); err == nil {
+		t.Error("removed the original with an empty output")
+	}
+	if _, err := os.Stat(input); err != nil {
+		t.Fatalf("original gone: %v", err)
+	}
+
+	os.WriteFile(output, []byte("jpeg"), 0644)
+	if err := removeOriginal(input, output, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Errorf("original still there: %v", err)
+	}
+}
+
+func TestRemoveOriginalArchived(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "IMG_0001.heic")
+	output := filepath.Join(dir, "IMG_0001.jpg")
+	archived := filepath.Join(dir, "archive", "IMG_0001.heic")
+	os.WriteFile(input, []byte("heic"), 0644)
+	os.WriteFile(output, []byte("jpeg"), 0644)
+
+	if err := removeOriginal(input, output, archived); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(archived); err != nil || string(data) != "heic" {
+		t.Errorf("archived = %q, %v", data, err)
+	}
+	if fileExists(input) {
+		t.Error("original still there")
+	}
+
+	os.WriteFile(input, []byte("another heic"), 0644)
+	if err := removeOriginal(input, output, archived); err == nil {
+		t.Error("replaced an archived original")
+	}
+}
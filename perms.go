@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+var (
+	keepPermissions = flag.Bool("keep-permissions", false, "give each JPEG the permission bits of its HEIC, for shared photo folders")
+	keepOwner       = flag.Bool("keep-owner", false, "also give each JPEG the owner and group of its HEIC (Unix; needs root for other users' files, e.g. on a NAS)")
+)
+
+// copyAccess gives output the permission bits and, with -keep-owner, the
+// owner of input, as far as the options ask for them.
+func copyAccess(input, output string) error {
+	if !*keepPermissions && !*keepOwner {
+		return nil
+	}
+	info, err := os.Stat(longPath(input))
+	if err != nil {
+		return err
+	}
+	if *keepPermissions {
+		if err := os.Chmod(longPath(output), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if *keepOwner {
+		return copyOwner(info, output)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// copyOwner gives path the owner and group in info.
+func copyOwner(info os.FileInfo, path string) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return errors.New("the owner isn't known")
+	}
+	return os.Lchown(path, int(st.Uid), int(st.Gid))
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyAccess(t *testing.T) {
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "IMG_0001.heic"), filepath.Join(dir, "IMG_0001.jpg")
+	os.WriteFile(input, []byte("heic"), 0640)
+	os.Chmod(input, 0640) // whatever the umask
+	os.WriteFile(output, []byte("jpeg"), 0644)
+
+	if err := copyAccess(input, output); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(output); info.Mode().Perm() != 0644 {
+		t.Errorf("mode changed without -keep-permissions: %v", info.Mode())
+	}
+
+	oldPerms, oldOwner := *keepPermissions, *keepOwner
+	*keepPermissions, *keepOwner = true, true
+	defer func() { *keepPermissions, *keepOwner = oldPerms, oldOwner }()
+	// Giving a file its own owner works without root.
+	if err := copyAccess(input, output); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(output); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode())
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// copyOwner isn't supported: Windows access is controlled by ACLs, which
+// new files inherit from their folder.
+func copyOwner(info os.FileInfo, path string) error {
+	return errors.New("-keep-owner is not supported on Windows")
+}
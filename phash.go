@@ -0,0 +1,5 @@
+package main
+
+import "flag"
+
+var perceptualHashes = flag.Bool("hashes", false, "compute the pHash and dHash of each converted image for -output ndjson and jpegs/hashes.csv")
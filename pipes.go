@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	pipeOutputs = flag.Bool("pipes", false, "write each JPEG into a named pipe in jpegs/ for another process to read, instead of a file; needs -output ndjson, which announces each pipe")
+	pipeWait    = flag.Duration("pipe-wait", time.Minute, "how long -pipes waits for a reader to open each pipe")
+)
+
+var errNoPipeReader = errors.New("no reader opened the pipe")
+
+// pipePoll is how often a pipe without a reader is tried again.
+const pipePoll = 50 * time.Millisecond
+
+// pipes hands the outputs to a reader process for -pipes. announce tells
+// the reader which pipe to open next; sizes keeps the bytes written into
+// each pipe, as there is no file left to measure.
+type pipes struct {
+	announce func(input, output string)
+
+	mu    sync.Mutex
+	sizes map[string]int64
+}
+
+func newPipes(announce func(input, output string)) *pipes {
+	return &pipes{announce: announce, sizes: make(map[string]int64)}
+}
+
+type pipesKey struct{}
+
+func withPipes(ctx context.Context, p *pipes) context.Context {
+	return context.WithValue(ctx, pipesKey{}, p)
+}
+
+func pipesFrom(ctx context.Context) *pipes {
+	p, _ := ctx.Value(pipesKey{}).(*pipes)
+	return p
+}
+
+// written returns the number of bytes written into the pipe at output.
+func (p *pipes) written(output string) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := p.sizes[output]
+	delete(p.sizes, output)
+	return n
+}
+
+// openOutput opens the file the conversion of input is written to, or
+// under -pipes the pipe to its reader.
+func openOutput(ctx context.Context, input, output string, flags int) (io.WriteCloser, error) {
+	p := pipesFrom(ctx)
+	if p == nil {
+		return os.OpenFile(longPath(output), flags, 0644)
+	}
+	w, created, err := openPipe(ctx, output, func() { p.announce(input, output) })
+	if err != nil {
+		return nil, err
+	}
+	return &pipeOutput{w: w, p: p, output: output, created: created}, nil
+}
+
+// outputSize is the size of the finished output.
+func outputSize(ctx context.Context, output string) int64 {
+	if p := pipesFrom(ctx); p != nil {
+		return p.written(output)
+	}
+	return getFileSize(output)
+}
+
+// pipeOutput counts the bytes written into a pipe and removes the pipe
+// when it is closed, if it was made for this file.
+type pipeOutput struct {
+	w       io.WriteCloser
+	p       *pipes
+	output  string
+	created bool
+	n       int64
+	closed  bool
+}
+
+func (o *pipeOutput) Write(b []byte) (int, error) {
+	n, err := o.w.Write(b)
+	o.n += int64(n)
+	return n, err
+}
+
+func (o *pipeOutput) Close() error {
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	err := o.w.Close()
+	if o.created {
+		os.Remove(o.output)
+	}
+	o.p.mu.Lock()
+	o.p.sizes[o.output] = o.n
+	o.p.mu.Unlock()
+	return err
+}
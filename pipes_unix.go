@@ -0,0 +1,63 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// openPipe opens the pipe at path for writing. A Unix socket already
+// listening there is connected to, and a FIFO already there is used as it
+// is; otherwise any stale file is replaced by a new FIFO, reported as
+// created. announce is called once the pipe exists and before waiting up
+// to -pipe-wait for a reader to open it.
+func openPipe(ctx context.Context, path string, announce func()) (io.WriteCloser, bool, error) {
+	info, err := os.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSocket != 0 {
+		announce()
+		conn, err := (&net.Dialer{}).DialContext(ctx, "unix", path)
+		return conn, false, err
+	}
+	created := err != nil || info.Mode()&os.ModeNamedPipe == 0
+	if created {
+		os.Remove(path)
+		if err := syscall.Mkfifo(path, 0644); err != nil {
+			return nil, false, err
+		}
+	}
+	announce()
+
+	// Opening a FIFO without a reader blocks, out of reach of ctx, so it
+	// is opened non-blocking and tried again until a reader is there.
+	deadline := time.Now().Add(*pipeWait)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err == nil {
+			return f, created, nil
+		}
+		if !errors.Is(err, syscall.ENXIO) {
+			return nil, false, removeCreated(path, created, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, false, removeCreated(path, created, errNoPipeReader)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false, removeCreated(path, created, ctx.Err())
+		case <-time.After(pipePoll):
+		}
+	}
+}
+
+func removeCreated(path string, created bool, err error) error {
+	if created {
+		os.Remove(path)
+	}
+	return err
+}
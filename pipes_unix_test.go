@@ -0,0 +1,101 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Testing that -pipes writes through a FIFO announced before it is opened
+func TestOpenOutputFIFO(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "IMG_0001.jpg")
+	got := make(chan []byte, 1)
+	p := newPipes(func(input, path string) {
+		if input != "IMG_0001.HEIC" || path != output {
+			t.Errorf("Announced %s -> %s", input, path)
+		}
+		go func() {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Errorf("Failed to open the pipe: %v", err)
+				got <- nil
+				return
+			}
+			defer f.Close()
+			data, _ := io.ReadAll(f)
+			got <- data
+		}()
+	})
+	ctx := withPipes(context.Background(), p)
+
+	w, err := openOutput(ctx, "IMG_0001.HEIC", output, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		t.Fatalf("openOutput failed: %v", err)
+	}
+	w.Write([]byte("jpeg data"))
+	w.Close()
+
+	if data := <-got; string(data) != "jpeg data" {
+		t.Errorf("Read %q from the pipe", data)
+	}
+	if n := outputSize(ctx, output); n != 9 {
+		t.Errorf("outputSize = %d, want 9", n)
+	}
+	if _, err := os.Lstat(output); !os.IsNotExist(err) {
+		t.Errorf("The FIFO should be removed after writing: %v", err)
+	}
+}
+
+// Testing that a socket at the output path is written to
+func TestOpenOutputSocket(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "a.jpg")
+	l, err := net.Listen("unix", output)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	defer l.Close()
+	got := make(chan []byte, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			got <- nil
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		got <- data
+	}()
+
+	ctx := withPipes(context.Background(), newPipes(func(string, string) {}))
+	w, err := openOutput(ctx, "a.heic", output, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		t.Fatalf("openOutput failed: %v", err)
+	}
+	w.Write([]byte("jpeg"))
+	w.Close()
+	if data := <-got; string(data) != "jpeg" {
+		t.Errorf("Read %q from the socket", data)
+	}
+}
+
+// Testing that a pipe nobody opens fails the file after -pipe-wait
+func TestOpenOutputNoReader(t *testing.T) {
+	defer func(d time.Duration) { *pipeWait = d }(*pipeWait)
+	*pipeWait = 100 * time.Millisecond
+
+	output := filepath.Join(t.TempDir(), "a.jpg")
+	ctx := withPipes(context.Background(), newPipes(func(string, string) {}))
+	if _, err := openOutput(ctx, "a.heic", output, os.O_RDWR|os.O_CREATE); !errors.Is(err, errNoPipeReader) {
+		t.Errorf("openOutput = %v, want %v", err, errNoPipeReader)
+	}
+	if _, err := os.Lstat(output); !os.IsNotExist(err) {
+		t.Errorf("The FIFO should be removed: %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+func openPipe(ctx context.Context, path string, announce func()) (io.WriteCloser, bool, error) {
+	return nil, false, errors.New("-pipes is not supported on Windows")
+}
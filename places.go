@@ -0,0 +1,433 @@
+package main
+
+// places is the coarse reverse-geocoding dataset of -organize-by-location:
+// capitals and other large or much-visited cities, with the coordinates of
+// their centre. Names are in English and ASCII, so they are safe as folder
+// names on every file system. Microstates are left out: their capital
+// would be the nearest place for much of the country around them.
+var places = []place{
+	// Europe
+	{"Amsterdam", "Netherlands", 52.37, 4.90},
+	{"Rotterdam", "Netherlands", 51.92, 4.48},
+	{"Brussels", "Belgium", 50.85, 4.35},
+	{"Antwerp", "Belgium", 51.22, 4.40},
+	{"Bruges", "Belgium", 51.21, 3.22},
+	{"Luxembourg", "Luxembourg", 49.61, 6.13},
+	{"Paris", "France", 48.86, 2.35},
+	{"Lyon", "France", 45.76, 4.84},
+	{"Marseille", "France", 43.30, 5.37},
+	{"Nice", "France", 43.70, 7.27},
+	{"Bordeaux", "France", 44.84, -0.58},
+	{"Toulouse", "France", 43.60, 1.44},
+	{"Strasbourg", "France", 48.57, 7.75},
+	{"Nantes", "France", 47.22, -1.55},
+	{"Lille", "France", 50.63, 3.06},
+	{"London", "United Kingdom", 51.51, -0.13},
+	{"Manchester", "United Kingdom", 53.48, -2.24},
+	{"Birmingham", "United Kingdom", 52.49, -1.89},
+	{"Liverpool", "United Kingdom", 53.41, -2.98},
+	{"Edinburgh", "United Kingdom", 55.95, -3.19},
+	{"Glasgow", "United Kingdom", 55.86, -4.25},
+	{"Cardiff", "United Kingdom", 51.48, -3.18},
+	{"Belfast", "United Kingdom", 54.60, -5.93},
+	{"Bristol", "United Kingdom", 51.45, -2.59},
+	{"Dublin", "Ireland", 53.35, -6.26},
+	{"Cork", "Ireland", 51.90, -8.47},
+	{"Reykjavik", "Iceland", 64.15, -21.94},
+	{"Oslo", "Norway", 59.91, 10.75},
+	{"Bergen", "Norway", 60.39, 5.32},
+	{"Tromso", "Norway", 69.65, 18.96},
+	{"Stockholm", "Sweden", 59.33, 18.07},
+	{"Gothenburg", "Sweden", 57.71, 11.97},
+	{"Malmo", "Sweden", 55.60, 13.00},
+	{"Copenhagen", "Denmark", 55.68, 12.57},
+	{"Aarhus", "Denmark", 56.16, 10.20},
+	{"Helsinki", "Finland", 60.17, 24.94},
+	{"Tallinn", "Estonia", 59.44, 24.75},
+	{"Riga", "Latvia", 56.95, 24.11},
+	{"Vilnius", "Lithuania", 54.69, 25.28},
+	{"Berlin", "Germany", 52.52, 13.40},
+	{"Hamburg", "Germany", 53.55, 9.99},
+	{"Munich", "Germany", 48.14, 11.58},
+	{"Cologne", "Germany", 50.94, 6.96},
+	{"Frankfurt", "Germany", 50.11, 8.68},
+	{"Stuttgart", "Germany", 48.78, 9.18},
+	{"Dusseldorf", "Germany", 51.23, 6.78},
+	{"Dresden", "Germany", 51.05, 13.74},
+	{"Leipzig", "Germany", 51.34, 12.37},
+	{"Nuremberg", "Germany", 49.45, 11.08},
+	{"Hanover", "Germany", 52.38, 9.73},
+	{"Bremen", "Germany", 53.08, 8.80},
+	{"Vienna", "Austria", 48.21, 16.37},
+	{"Salzburg", "Austria", 47.81, 13.04},
+	{"Innsbruck", "Austria", 47.27, 11.39},
+	{"Graz", "Austria", 47.07, 15.44},
+	{"Zurich", "Switzerland", 47.38, 8.54},
+	{"Geneva", "Switzerland", 46.20, 6.14},
+	{"Bern", "Switzerland", 46.95, 7.45},
+	{"Basel", "Switzerland", 47.56, 7.59},
+	{"Lucerne", "Switzerland", 47.05, 8.31},
+	{"Zermatt", "Switzerland", 46.02, 7.75},
+	{"Prague", "Czechia", 50.08, 14.44},
+	{"Brno", "Czechia", 49.20, 16.61},
+	{"Bratislava", "Slovakia", 48.15, 17.11},
+	{"Warsaw", "Poland", 52.23, 21.01},
+	{"Krakow", "Poland", 50.06, 19.94},
+	{"Gdansk", "Poland", 54.35, 18.65},
+	{"Wroclaw", "Poland", 51.11, 17.04},
+	{"Budapest", "Hungary", 47.50, 19.04},
+	{"Ljubljana", "Slovenia", 46.06, 14.51},
+	{"Zagreb", "Croatia", 45.82, 15.98},
+	{"Split", "Croatia", 43.51, 16.44},
+	{"Dubrovnik", "Croatia", 42.65, 18.09},
+	{"Sarajevo", "Bosnia and Herzegovina", 43.86, 18.41},
+	{"Belgrade", "Serbia", 44.79, 20.45},
+	{"Podgorica", "Montenegro", 42.44, 19.26},
+	{"Kotor", "Montenegro", 42.42, 18.77},
+	{"Tirana", "Albania", 41.33, 19.82},
+	{"Skopje", "North Macedonia", 42.00, 21.43},
+	{"Sofia", "Bulgaria", 42.70, 23.32},
+	{"Bucharest", "Romania", 44.43, 26.10},
+	{"Cluj-Napoca", "Romania", 46.77, 23.60},
+	{"Chisinau", "Moldova", 47.01, 28.86},
+	{"Kyiv", "Ukraine", 50.45, 30.52},
+	{"Lviv", "Ukraine", 49.84, 24.03},
+	{"Odesa", "Ukraine", 46.48, 30.72},
+	{"Minsk", "Belarus", 53.90, 27.57},
+	{"Moscow", "Russia", 55.76, 37.62},
+	{"Saint Petersburg", "Russia", 59.94, 30.31},
+	{"Athens", "Greece", 37.98, 23.73},
+	{"Thessaloniki", "Greece", 40.64, 22.94},
+	{"Santorini", "Greece", 36.42, 25.43},
+	{"Heraklion", "Greece", 35.34, 25.13},
+	{"Rhodes", "Greece", 36.43, 28.22},
+	{"Nicosia", "Cyprus", 35.17, 33.36},
+	{"Valletta", "Malta", 35.90, 14.51},
+	{"Rome", "Italy", 41.90, 12.50},
+	{"Milan", "Italy", 45.46, 9.19},
+	{"Venice", "Italy", 45.44, 12.32},
+	{"Florence", "Italy", 43.77, 11.26},
+	{"Naples", "Italy", 40.85, 14.27},
+	{"Turin", "Italy", 45.07, 7.69},
+	{"Bologna", "Italy", 44.49, 11.34},
+	{"Genoa", "Italy", 44.41, 8.93},
+	{"Verona", "Italy", 45.44, 10.99},
+	{"Pisa", "Italy", 43.72, 10.40},
+	{"Palermo", "Italy", 38.12, 13.36},
+	{"Catania", "Italy", 37.50, 15.09},
+	{"Bari", "Italy", 41.12, 16.87},
+	{"Cagliari", "Italy", 39.22, 9.11},
+	{"Amalfi", "Italy", 40.63, 14.60},
+	{"Madrid", "Spain", 40.42, -3.70},
+	{"Barcelona", "Spain", 41.39, 2.17},
+	{"Valencia", "Spain", 39.47, -0.38},
+	{"Seville", "Spain", 37.39, -5.98},
+	{"Malaga", "Spain", 36.72, -4.42},
+	{"Granada", "Spain", 37.18, -3.60},
+	{"Bilbao", "Spain", 43.26, -2.93},
+	{"Palma", "Spain", 39.57, 2.65},
+	{"Las Palmas", "Spain", 28.12, -15.44},
+	{"Santa Cruz de Tenerife", "Spain", 28.46, -16.25},
+	{"Ibiza", "Spain", 38.91, 1.43},
+	{"Lisbon", "Portugal", 38.72, -9.14},
+	{"Porto", "Portugal", 41.15, -8.61},
+	{"Faro", "Portugal", 37.02, -7.93},
+	{"Funchal", "Portugal", 32.65, -16.91},
+	{"Istanbul", "Turkey", 41.01, 28.98},
+	{"Ankara", "Turkey", 39.93, 32.86},
+	{"Izmir", "Turkey", 38.42, 27.14},
+	{"Antalya", "Turkey", 36.90, 30.70},
+
+	// Middle East and Africa
+	{"Jerusalem", "Israel", 31.77, 35.21},
+	{"Tel Aviv", "Israel", 32.09, 34.78},
+	{"Amman", "Jordan", 31.95, 35.93},
+	{"Petra", "Jordan", 30.33, 35.44},
+	{"Beirut", "Lebanon", 33.89, 35.50},
+	{"Damascus", "Syria", 33.51, 36.29},
+	{"Baghdad", "Iraq", 33.31, 44.36},
+	{"Tehran", "Iran", 35.69, 51.39},
+	{"Riyadh", "Saudi Arabia", 24.71, 46.68},
+	{"Jeddah", "Saudi Arabia", 21.49, 39.19},
+	{"Mecca", "Saudi Arabia", 21.42, 39.83},
+	{"Dubai", "United Arab Emirates", 25.20, 55.27},
+	{"Abu Dhabi", "United Arab Emirates", 24.45, 54.38},
+	{"Doha", "Qatar", 25.29, 51.53},
+	{"Manama", "Bahrain", 26.23, 50.59},
+	{"Kuwait City", "Kuwait", 29.38, 47.99},
+	{"Muscat", "Oman", 23.59, 58.41},
+	{"Tbilisi", "Georgia", 41.72, 44.79},
+	{"Yerevan", "Armenia", 40.18, 44.51},
+	{"Baku", "Azerbaijan", 40.41, 49.87},
+	{"Cairo", "Egypt", 30.04, 31.24},
+	{"Alexandria", "Egypt", 31.20, 29.92},
+	{"Luxor", "Egypt", 25.69, 32.64},
+	{"Sharm El Sheikh", "Egypt", 27.92, 34.33},
+	{"Marrakesh", "Morocco", 31.63, -8.01},
+	{"Casablanca", "Morocco", 33.57, -7.59},
+	{"Fez", "Morocco", 34.03, -5.00},
+	{"Rabat", "Morocco", 34.02, -6.83},
+	{"Tunis", "Tunisia", 36.81, 10.18},
+	{"Algiers", "Algeria", 36.75, 3.06},
+	{"Tripoli", "Libya", 32.89, 13.19},
+	{"Dakar", "Senegal", 14.72, -17.47},
+	{"Accra", "Ghana", 5.60, -0.19},
+	{"Lagos", "Nigeria", 6.52, 3.38},
+	{"Abuja", "Nigeria", 9.08, 7.40},
+	{"Kinshasa", "DR Congo", -4.44, 15.27},
+	{"Addis Ababa", "Ethiopia", 9.03, 38.74},
+	{"Nairobi", "Kenya", -1.29, 36.82},
+	{"Mombasa", "Kenya", -4.04, 39.67},
+	{"Kampala", "Uganda", 0.35, 32.58},
+	{"Kigali", "Rwanda", -1.95, 30.06},
+	{"Dar es Salaam", "Tanzania", -6.79, 39.21},
+	{"Zanzibar", "Tanzania", -6.16, 39.19},
+	{"Arusha", "Tanzania", -3.39, 36.68},
+	{"Lusaka", "Zambia", -15.39, 28.32},
+	{"Victoria Falls", "Zimbabwe", -17.93, 25.83},
+	{"Harare", "Zimbabwe", -17.83, 31.05},
+	{"Windhoek", "Namibia", -22.56, 17.08},
+	{"Gaborone", "Botswana", -24.65, 25.91},
+	{"Maputo", "Mozambique", -25.97, 32.57},
+	{"Antananarivo", "Madagascar", -18.88, 47.51},
+	{"Port Louis", "Mauritius", -20.16, 57.50},
+	{"Victoria", "Seychelles", -4.62, 55.45},
+	{"Johannesburg", "South Africa", -26.20, 28.05},
+	{"Pretoria", "South Africa", -25.75, 28.19},
+	{"Cape Town", "South Africa", -33.92, 18.42},
+	{"Durban", "South Africa", -29.86, 31.03},
+
+	// Asia
+	{"Tokyo", "Japan", 35.68, 139.69},
+	{"Yokohama", "Japan", 35.44, 139.64},
+	{"Osaka", "Japan", 34.69, 135.50},
+	{"Kyoto", "Japan", 35.01, 135.77},
+	{"Nara", "Japan", 34.69, 135.80},
+	{"Hiroshima", "Japan", 34.39, 132.46},
+	{"Fukuoka", "Japan", 33.59, 130.40},
+	{"Sapporo", "Japan", 43.06, 141.35},
+	{"Nagoya", "Japan", 35.18, 136.91},
+	{"Naha", "Japan", 26.21, 127.68},
+	{"Seoul", "South Korea", 37.57, 126.98},
+	{"Busan", "South Korea", 35.18, 129.08},
+	{"Jeju", "South Korea", 33.50, 126.53},
+	{"Pyongyang", "North Korea", 39.04, 125.76},
+	{"Beijing", "China", 39.90, 116.41},
+	{"Shanghai", "China", 31.23, 121.47},
+	{"Guangzhou", "China", 23.13, 113.26},
+	{"Shenzhen", "China", 22.54, 114.06},
+	{"Chengdu", "China", 30.57, 104.07},
+	{"Chongqing", "China", 29.56, 106.55},
+	{"Xi'an", "China", 34.34, 108.94},
+	{"Hangzhou", "China", 30.27, 120.16},
+	{"Wuhan", "China", 30.59, 114.31},
+	{"Nanjing", "China", 32.06, 118.80},
+	{"Guilin", "China", 25.27, 110.29},
+	{"Kunming", "China", 25.04, 102.71},
+	{"Harbin", "China", 45.80, 126.53},
+	{"Lhasa", "China", 29.65, 91.17},
+	{"Hong Kong", "Hong Kong", 22.32, 114.17},
+	{"Macau", "Macau", 22.20, 113.54},
+	{"Taipei", "Taiwan", 25.03, 121.57},
+	{"Kaohsiung", "Taiwan", 22.63, 120.30},
+	{"Ulaanbaatar", "Mongolia", 47.89, 106.91},
+	{"Manila", "Philippines", 14.60, 120.98},
+	{"Cebu", "Philippines", 10.32, 123.89},
+	{"Hanoi", "Vietnam", 21.03, 105.85},
+	{"Ho Chi Minh City", "Vietnam", 10.82, 106.63},
+	{"Da Nang", "Vietnam", 16.05, 108.20},
+	{"Hoi An", "Vietnam", 15.88, 108.34},
+	{"Vientiane", "Laos", 17.98, 102.63},
+	{"Luang Prabang", "Laos", 19.89, 102.13},
+	{"Phnom Penh", "Cambodia", 11.56, 104.92},
+	{"Siem Reap", "Cambodia", 13.36, 103.86},
+	{"Bangkok", "Thailand", 13.76, 100.50},
+	{"Chiang Mai", "Thailand", 18.79, 98.98},
+	{"Phuket", "Thailand", 7.88, 98.39},
+	{"Krabi", "Thailand", 8.09, 98.91},
+	{"Koh Samui", "Thailand", 9.51, 100.01},
+	{"Yangon", "Myanmar", 16.87, 96.20},
+	{"Kuala Lumpur", "Malaysia", 3.14, 101.69},
+	{"George Town", "Malaysia", 5.41, 100.33},
+	{"Kota Kinabalu", "Malaysia", 5.98, 116.07},
+	{"Singapore", "Singapore", 1.35, 103.82},
+	{"Jakarta", "Indonesia", -6.21, 106.85},
+	{"Denpasar", "Indonesia", -8.65, 115.22},
+	{"Ubud", "Indonesia", -8.51, 115.26},
+	{"Yogyakarta", "Indonesia", -7.80, 110.36},
+	{"Surabaya", "Indonesia", -7.25, 112.75},
+	{"Bandar Seri Begawan", "Brunei", 4.90, 114.94},
+	{"Dili", "Timor-Leste", -8.56, 125.57},
+	{"Delhi", "India", 28.61, 77.21},
+	{"Mumbai", "India", 19.08, 72.88},
+	{"Bengaluru", "India", 12.97, 77.59},
+	{"Chennai", "India", 13.08, 80.27},
+	{"Kolkata", "India", 22.57, 88.36},
+	{"Hyderabad", "India", 17.39, 78.49},
+	{"Agra", "India", 27.18, 78.01},
+	{"Jaipur", "India", 26.91, 75.79},
+	{"Varanasi", "India", 25.32, 82.97},
+	{"Goa", "India", 15.50, 73.83},
+	{"Kochi", "India", 9.93, 76.27},
+	{"Kathmandu", "Nepal", 27.72, 85.32},
+	{"Pokhara", "Nepal", 28.21, 83.99},
+	{"Thimphu", "Bhutan", 27.47, 89.64},
+	{"Dhaka", "Bangladesh", 23.81, 90.41},
+	{"Colombo", "Sri Lanka", 6.93, 79.85},
+	{"Kandy", "Sri Lanka", 7.29, 80.63},
+	{"Male", "Maldives", 4.18, 73.51},
+	{"Karachi", "Pakistan", 24.86, 67.01},
+	{"Lahore", "Pakistan", 31.55, 74.34},
+	{"Islamabad", "Pakistan", 33.68, 73.05},
+	{"Kabul", "Afghanistan", 34.56, 69.21},
+	{"Tashkent", "Uzbekistan", 41.30, 69.24},
+	{"Samarkand", "Uzbekistan", 39.65, 66.96},
+	{"Almaty", "Kazakhstan", 43.24, 76.95},
+	{"Astana", "Kazakhstan", 51.17, 71.43},
+	{"Bishkek", "Kyrgyzstan", 42.87, 74.59},
+
+	// North America and the Caribbean
+	{"New York", "United States", 40.71, -74.01},
+	{"Boston", "United States", 42.36, -71.06},
+	{"Philadelphia", "United States", 39.95, -75.17},
+	{"Washington", "United States", 38.91, -77.04},
+	{"Baltimore", "United States", 39.29, -76.61},
+	{"Pittsburgh", "United States", 40.44, -80.00},
+	{"Atlanta", "United States", 33.75, -84.39},
+	{"Miami", "United States", 25.76, -80.19},
+	{"Orlando", "United States", 28.54, -81.38},
+	{"Tampa", "United States", 27.95, -82.46},
+	{"Key West", "United States", 24.56, -81.78},
+	{"Charleston", "United States", 32.78, -79.93},
+	{"Nashville", "United States", 36.16, -86.78},
+	{"New Orleans", "United States", 29.95, -90.07},
+	{"Chicago", "United States", 41.88, -87.63},
+	{"Detroit", "United States", 42.33, -83.05},
+	{"Minneapolis", "United States", 44.98, -93.27},
+	{"St. Louis", "United States", 38.63, -90.20},
+	{"Kansas City", "United States", 39.10, -94.58},
+	{"Dallas", "United States", 32.78, -96.80},
+	{"Houston", "United States", 29.76, -95.37},
+	{"Austin", "United States", 30.27, -97.74},
+	{"San Antonio", "United States", 29.42, -98.49},
+	{"Denver", "United States", 39.74, -104.99},
+	{"Salt Lake City", "United States", 40.76, -111.89},
+	{"Phoenix", "United States", 33.45, -112.07},
+	{"Las Vegas", "United States", 36.17, -115.14},
+	{"Grand Canyon Village", "United States", 36.05, -112.14},
+	{"Los Angeles", "United States", 34.05, -118.24},
+	{"San Diego", "United States", 32.72, -117.16},
+	{"San Francisco", "United States", 37.77, -122.42},
+	{"San Jose", "United States", 37.34, -121.89},
+	{"Sacramento", "United States", 38.58, -121.49},
+	{"Yosemite Valley", "United States", 37.75, -119.59},
+	{"Portland", "United States", 45.52, -122.68},
+	{"Seattle", "United States", 47.61, -122.33},
+	{"Anchorage", "United States", 61.22, -149.90},
+	{"Honolulu", "United States", 21.31, -157.86},
+	{"Kahului", "United States", 20.89, -156.47},
+	{"Toronto", "Canada", 43.65, -79.38},
+	{"Ottawa", "Canada", 45.42, -75.70},
+	{"Montreal", "Canada", 45.50, -73.57},
+	{"Quebec City", "Canada", 46.81, -71.21},
+	{"Halifax", "Canada", 44.65, -63.58},
+	{"Winnipeg", "Canada", 49.90, -97.14},
+	{"Calgary", "Canada", 51.05, -114.07},
+	{"Banff", "Canada", 51.18, -115.57},
+	{"Edmonton", "Canada", 53.55, -113.49},
+	{"Vancouver", "Canada", 49.28, -123.12},
+	{"Victoria", "Canada", 48.43, -123.37},
+	{"Mexico City", "Mexico", 19.43, -99.13},
+	{"Guadalajara", "Mexico", 20.66, -103.35},
+	{"Monterrey", "Mexico", 25.69, -100.32},
+	{"Cancun", "Mexico", 21.16, -86.85},
+	{"Tulum", "Mexico", 20.21, -87.47},
+	{"Oaxaca", "Mexico", 17.07, -96.73},
+	{"Puerto Vallarta", "Mexico", 20.65, -105.23},
+	{"Cabo San Lucas", "Mexico", 22.89, -109.92},
+	{"Guatemala City", "Guatemala", 14.63, -90.51},
+	{"Belize City", "Belize", 17.50, -88.20},
+	{"San Salvador", "El Salvador", 13.69, -89.22},
+	{"Tegucigalpa", "Honduras", 14.07, -87.19},
+	{"Managua", "Nicaragua", 12.11, -86.24},
+	{"San Jose", "Costa Rica", 9.93, -84.08},
+	{"Panama City", "Panama", 8.98, -79.52},
+	{"Havana", "Cuba", 23.11, -82.37},
+	{"Kingston", "Jamaica", 18.02, -76.80},
+	{"Montego Bay", "Jamaica", 18.47, -77.92},
+	{"Nassau", "Bahamas", 25.04, -77.35},
+	{"Santo Domingo", "Dominican Republic", 18.49, -69.93},
+	{"Punta Cana", "Dominican Republic", 18.58, -68.40},
+	{"Port-au-Prince", "Haiti", 18.59, -72.31},
+	{"San Juan", "Puerto Rico", 18.47, -66.11},
+	{"Bridgetown", "Barbados", 13.10, -59.61},
+	{"Port of Spain", "Trinidad and Tobago", 10.65, -61.52},
+	{"Hamilton", "Bermuda", 32.29, -64.78},
+
+	// South America
+	{"Bogota", "Colombia", 4.71, -74.07},
+	{"Medellin", "Colombia", 6.24, -75.58},
+	{"Cartagena", "Colombia", 10.39, -75.48},
+	{"Caracas", "Venezuela", 10.48, -66.90},
+	{"Quito", "Ecuador", -0.18, -78.47},
+	{"Guayaquil", "Ecuador", -2.17, -79.92},
+	{"Puerto Ayora", "Ecuador", -0.74, -90.31},
+	{"Lima", "Peru", -12.05, -77.04},
+	{"Cusco", "Peru", -13.53, -71.97},
+	{"Arequipa", "Peru", -16.41, -71.54},
+	{"La Paz", "Bolivia", -16.49, -68.12},
+	{"Uyuni", "Bolivia", -20.46, -66.83},
+	{"Santiago", "Chile", -33.45, -70.67},
+	{"Valparaiso", "Chile", -33.05, -71.62},
+	{"Punta Arenas", "Chile", -53.16, -70.91},
+	{"Buenos Aires", "Argentina", -34.60, -58.38},
+	{"Cordoba", "Argentina", -31.42, -64.18},
+	{"Mendoza", "Argentina", -32.89, -68.83},
+	{"Bariloche", "Argentina", -41.13, -71.31},
+	{"Ushuaia", "Argentina", -54.80, -68.30},
+	{"El Calafate", "Argentina", -50.34, -72.26},
+	{"Puerto Iguazu", "Argentina", -25.60, -54.57},
+	{"Montevideo", "Uruguay", -34.90, -56.16},
+	{"Asuncion", "Paraguay", -25.26, -57.58},
+	{"Sao Paulo", "Brazil", -23.55, -46.63},
+	{"Rio de Janeiro", "Brazil", -22.91, -43.17},
+	{"Brasilia", "Brazil", -15.79, -47.88},
+	{"Salvador", "Brazil", -12.97, -38.50},
+	{"Fortaleza", "Brazil", -3.73, -38.53},
+	{"Recife", "Brazil", -8.05, -34.88},
+	{"Belo Horizonte", "Brazil", -19.92, -43.94},
+	{"Curitiba", "Brazil", -25.43, -49.27},
+	{"Porto Alegre", "Brazil", -30.03, -51.23},
+	{"Florianopolis", "Brazil", -27.60, -48.55},
+	{"Manaus", "Brazil", -3.12, -60.02},
+	{"Georgetown", "Guyana", 6.80, -58.16},
+	{"Paramaribo", "Suriname", 5.85, -55.20},
+
+	// Oceania
+	{"Sydney", "Australia", -33.87, 151.21},
+	{"Melbourne", "Australia", -37.81, 144.96},
+	{"Brisbane", "Australia", -27.47, 153.03},
+	{"Gold Coast", "Australia", -28.02, 153.40},
+	{"Cairns", "Australia", -16.92, 145.77},
+	{"Perth", "Australia", -31.95, 115.86},
+	{"Adelaide", "Australia", -34.93, 138.60},
+	{"Canberra", "Australia", -35.28, 149.13},
+	{"Hobart", "Australia", -42.88, 147.33},
+	{"Darwin", "Australia", -12.46, 130.84},
+	{"Alice Springs", "Australia", -23.70, 133.88},
+	{"Auckland", "New Zealand", -36.85, 174.76},
+	{"Wellington", "New Zealand", -41.29, 174.78},
+	{"Christchurch", "New Zealand", -43.53, 172.64},
+	{"Queenstown", "New Zealand", -45.03, 168.66},
+	{"Rotorua", "New Zealand", -38.14, 176.25},
+	{"Suva", "Fiji", -18.14, 178.44},
+	{"Nadi", "Fiji", -17.80, 177.42},
+	{"Papeete", "French Polynesia", -17.54, -149.57},
+	{"Bora Bora", "French Polynesia", -16.50, -151.74},
+	{"Noumea", "New Caledonia", -22.27, 166.45},
+	{"Port Moresby", "Papua New Guinea", -9.44, 147.18},
+	{"Apia", "Samoa", -13.83, -171.76},
+	{"Nuku'alofa", "Tonga", -21.14, -175.20},
+	{"Port Vila", "Vanuatu", -17.73, 168.32},
+	{"Hagatna", "Guam", 13.48, 144.75},
+}
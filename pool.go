@@ -0,0 +1,17 @@
+package main
+
+import (
+	"io"
+	"sync"
+)
+
+// copyBuffers are reused across files, so long batches don't allocate
+// (and collect) a large buffer for every copy.
+var copyBuffers = sync.Pool{New: func() interface{} { b := make([]byte, 64<<10); return &b }}
+
+// pooledCopy is io.Copy with a pooled buffer.
+func pooledCopy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+var powerAware = flag.Bool("power-aware", true, "use fewer workers on battery power or under thermal pressure")
+
+const powerCheckInterval = 30 * time.Second
+
+// powerState is what the OS reports about the machine's power source and
+// temperature; unknown values are false.
+type powerState struct {
+	OnBattery bool
+	Thermal   bool
+}
+
+// scaledWorkers is the worker count for the power state: half on battery,
+// a quarter under thermal pressure, and always at least one.
+func scaledWorkers(total int, state powerState) int {
+	n := total
+	if state.OnBattery {
+		n = total / 2
+	}
+	if state.Thermal {
+		n = total / 4
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func (s powerState) describe() string {
+	switch {
+	case s.Thermal && s.OnBattery:
+		return tr("on battery power and under thermal pressure")
+	case s.Thermal:
+		return tr("under thermal pressure")
+	case s.OnBattery:
+		return tr("on battery power")
+	}
+	return tr("on mains power")
+}
+
+// governPower checks the power state periodically and limits the number of
+// busy workers to match, printing each change.
+func governPower(ctx context.Context, control *runControl, total int) {
+	var last powerState
+	check := func() {
+		state := readPowerState()
+		if state == last {
+			return
+		}
+		last = state
+		n := scaledWorkers(total, state)
+		if n == total {
+			control.setWorkerLimit(0)
+		} else {
+			control.setWorkerLimit(n)
+		}
+		fmt.Printf(tr("Running %s: using %d of %d workers.\n"), state.describe(), n, total)
+	}
+	check()
+	go func() {
+		ticker := time.NewTicker(powerCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check()
+			}
+		}
+	}()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var cpuSpeedLimit = regexp.MustCompile(`CPU_Speed_Limit\s*=\s*(\d+)`)
+
+// readPowerState asks pmset for the power source and whether the CPU is
+// being slowed down to keep it cool.
+func readPowerState() powerState {
+	var state powerState
+	if out, err := exec.Command("pmset", "-g", "batt").Output(); err == nil {
+		state.OnBattery = onBatteryPower(string(out))
+	}
+	if out, err := exec.Command("pmset", "-g", "therm").Output(); err == nil {
+		state.Thermal = cpuThrottled(string(out))
+	}
+	return state
+}
+
+func onBatteryPower(pmsetBatt string) bool {
+	return strings.Contains(pmsetBatt, "'Battery Power'")
+}
+
+func cpuThrottled(pmsetTherm string) bool {
+	m := cpuSpeedLimit.FindStringSubmatch(pmsetTherm)
+	if m == nil {
+		return false
+	}
+	limit, err := strconv.Atoi(m[1])
+	return err == nil && limit < 100
+}
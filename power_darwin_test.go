@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestPmsetParsing(t *testing.T) {
+	if !onBatteryPower("Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1234)\t80%; discharging") {
+		t.Error("battery power not detected")
+	}
+	if onBatteryPower("Now drawing from 'AC Power'") {
+		t.Error("AC power reported as battery")
+	}
+	if !cpuThrottled("CPU Power notify\n\tCPU_Scheduler_Limit \t= 100\n\tCPU_Available_CPUs \t= 8\n\tCPU_Speed_Limit \t= 70\n") {
+		t.Error("speed limit 70 not detected")
+	}
+	if cpuThrottled("Note: No thermal warning level has been recorded") {
+		t.Error("no warning reported as throttled")
+	}
+}
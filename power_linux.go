@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fallbackThermalLimit is used for thermal zones without trip points, in
+// millidegrees Celsius like the sysfs values.
+const fallbackThermalLimit = 90000
+
+func readPowerState() powerState {
+	return readSysfsPowerState("/sys/class")
+}
+
+// readSysfsPowerState reads power_supply and thermal under root: on battery
+// when a battery is discharging, thermal pressure when a zone has reached
+// its first passive or hot trip point.
+func readSysfsPowerState(root string) powerState {
+	var state powerState
+	supplies, _ := filepath.Glob(filepath.Join(root, "power_supply", "*"))
+	for _, supply := range supplies {
+		if readSysfs(supply, "type") == "Battery" && readSysfs(supply, "status") == "Discharging" {
+			state.OnBattery = true
+		}
+	}
+
+	zones, _ := filepath.Glob(filepath.Join(root, "thermal", "thermal_zone*"))
+	for _, zone := range zones {
+		temp, err := strconv.Atoi(readSysfs(zone, "temp"))
+		if err != nil || temp <= 0 {
+			continue
+		}
+		limit := 0
+		types, _ := filepath.Glob(filepath.Join(zone, "trip_point_*_type"))
+		for _, typ := range types {
+			if t := readSysfs(filepath.Dir(typ), filepath.Base(typ)); t != "passive" && t != "hot" {
+				continue
+			}
+			tripTemp := strings.TrimSuffix(filepath.Base(typ), "_type") + "_temp"
+			if trip, err := strconv.Atoi(readSysfs(zone, tripTemp)); err == nil && trip > 0 && (limit == 0 || trip < limit) {
+				limit = trip
+			}
+		}
+		if limit == 0 {
+			limit = fallbackThermalLimit
+		}
+		if temp >= limit {
+			state.Thermal = true
+		}
+	}
+	return state
+}
+
+func readSysfs(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
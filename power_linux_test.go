@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	for name, value := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadSysfsPowerState(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"power_supply/AC/type":                    "Mains",
+		"power_supply/BAT0/type":                  "Battery",
+		"power_supply/BAT0/status":                "Charging",
+		"thermal/thermal_zone0/temp":              "70000",
+		"thermal/thermal_zone0/trip_point_0_type": "passive",
+		"thermal/thermal_zone0/trip_point_0_temp": "80000",
+		"thermal/thermal_zone0/trip_point_1_type": "critical",
+		"thermal/thermal_zone0/trip_point_1_temp": "100000",
+	})
+	if got := readSysfsPowerState(root); got != (powerState{}) {
+		t.Errorf("charging and cool: got %+v", got)
+	}
+
+	writeSysfs(t, root, map[string]string{
+		"power_supply/BAT0/status":   "Discharging",
+		"thermal/thermal_zone0/temp": "85000",
+	})
+	if got := readSysfsPowerState(root); !got.OnBattery || !got.Thermal {
+		t.Errorf("discharging and past the passive trip point: got %+v", got)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+// readPowerState has no power information on this platform.
+func readPowerState() powerState {
+	return powerState{}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestScaledWorkers(t *testing.T) {
+	for _, tc := range []struct {
+		total int
+		state powerState
+		want  int
+	}{
+		{8, powerState{}, 8},
+		{8, powerState{OnBattery: true}, 4},
+		{8, powerState{Thermal: true}, 2},
+		{8, powerState{OnBattery: true, Thermal: true}, 2},
+		{1, powerState{OnBattery: true}, 1},
+		{3, powerState{Thermal: true}, 1},
+	} {
+		if got := scaledWorkers(tc.total, tc.state); got != tc.want {
+			t.Errorf("scaledWorkers(%d, %+v) = %d, want %d", tc.total, tc.state, got, tc.want)
+		}
+	}
+}
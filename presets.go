@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var preset = flag.String("preset", "", "bundle of settings: web, archive, email, print, thumb, or one defined under \"presets\" in the config file")
+
+// presets are bundles of flag defaults. They override the config file but
+// not options given on the command line. Every preset writes JPEG, the
+// only format heictojpeg produces.
+var presets = map[string]map[string]interface{}{
+	"web":     {"quality": 80, "max-size": 2048, "metadata": "strip"},
+	"archive": {"quality": 95, "max-size": 0, "metadata": "keep"},
+	"email":   {"quality": 70, "max-size": 1280, "metadata": "strip"},
+	"print":   {"quality": 92, "max-size": 0, "metadata": "keep"},
+	"thumb":   {"quality": 75, "max-size": 300, "metadata": "strip"},
+}
+
+// configPresets takes the "presets" object out of the config file, so it
+// isn't mistaken for an option, and returns the presets it defines.
+func configPresets(config map[string]interface{}) (map[string]map[string]interface{}, error) {
+	raw, ok := config["presets"]
+	if !ok {
+		return nil, nil
+	}
+	delete(config, "presets")
+	defined, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("presets: must be an object of preset names to options")
+	}
+	custom := make(map[string]map[string]interface{}, len(defined))
+	for name, options := range defined {
+		values, ok := options.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("presets: %s must be an object of options", name)
+		}
+		if _, ok := values["preset"]; ok {
+			return nil, fmt.Errorf("presets: %s can't name another preset", name)
+		}
+		custom[name] = values
+	}
+	return custom, nil
+}
+
+// applyPreset sets the flags of the chosen preset that weren't given on
+// the command line. The name comes from -preset, or the config file's
+// "preset" when the command line doesn't have one.
+func applyPreset(fs *flag.FlagSet, config map[string]interface{}, custom map[string]map[string]interface{}) error {
+	var name string
+	given := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "preset" {
+			name, given = f.Value.String(), true
+		}
+	})
+	if !given {
+		if configured, ok := config["preset"]; ok {
+			name = fmt.Sprint(configured)
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	options, ok := custom[name]
+	if !ok {
+		options, ok = presets[name]
+	}
+	if !ok {
+		return fmt.Errorf("unknown preset %q (have %s)", name, strings.Join(presetNames(custom), ", "))
+	}
+	if err := applyConfig(fs, options); err != nil {
+		return fmt.Errorf("preset %s: %v", name, err)
+	}
+	return fs.Set("preset", name)
+}
+
+func presetNames(custom map[string]map[string]interface{}) []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	for name := range custom {
+		if _, builtin := presets[name]; !builtin {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func presetFlags(args ...string) (*flag.FlagSet, *int, *int, *string) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("preset", "", "")
+	quality := fs.Int("quality", 75, "")
+	maxSize := fs.Int("max-size", 0, "")
+	metadata := fs.String("metadata", "keep", "")
+	fs.Parse(args)
+	return fs, quality, maxSize, metadata
+}
+
+// Testing the command line beats the preset, which beats the config file
+func TestApplyPreset(t *testing.T) {
+	fs, quality, maxSize, metadata := presetFlags("-preset", "web", "-quality", "60")
+	config := map[string]interface{}{"max-size": 4000.0, "metadata": "keep"}
+	if err := applyPreset(fs, config, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, config); err != nil {
+		t.Fatal(err)
+	}
+	if *quality != 60 || *maxSize != 2048 || *metadata != "strip" {
+		t.Errorf("quality=%d max-size=%d metadata=%s; want 60, 2048, strip", *quality, *maxSize, *metadata)
+	}
+}
+
+func TestConfigPresets(t *testing.T) {
+	config := map[string]interface{}{
+		"preset":  "family",
+		"presets": map[string]interface{}{"family": map[string]interface{}{"quality": 88.0, "max-size": 3000.0}},
+	}
+	custom, err := configPresets(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config["presets"]; ok {
+		t.Error("presets should be removed from the options")
+	}
+
+	fs, quality, maxSize, _ := presetFlags()
+	if err := applyPreset(fs, config, custom); err != nil {
+		t.Fatal(err)
+	}
+	if *quality != 88 || *maxSize != 3000 {
+		t.Errorf("quality=%d max-size=%d; want the family preset's 88, 3000", *quality, *maxSize)
+	}
+
+	fs, _, _, _ = presetFlags("-preset", "nope")
+	if err := applyPreset(fs, nil, custom); err == nil {
+		t.Error("unknown preset accepted")
+	}
+	if _, err := configPresets(map[string]interface{}{"presets": []interface{}{}}); err == nil {
+		t.Error("presets that aren't an object accepted")
+	}
+}
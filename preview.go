@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	dryRun        = flag.Bool("dry-run", false, "list the HEIC files and the file each would be converted to, without converting anything")
+	previewSheets = flag.Bool("preview", false, "with -dry-run, also draw the files into contact sheets in a temporary folder and open it; they're drawn from the thumbnails the files embed, without decoding the photos")
+)
+
+const (
+	previewCell    = 160 // sides of a contact sheet cell, in pixels
+	previewMargin  = 4
+	previewColumns = 10
+	previewRows    = 10
+)
+
+// decodePreviewFile is convert.DecodePreview for the HEIC file input.
+func decodePreviewFile(input string, maxSide int) (*image.RGBA64, error) {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return convert.DecodePreview(f, maxSide)
+}
+
+// decodePreviews decodes the previews of the HEIC files names of dir with
+// a worker each, leaving those that fail nil.
+func decodePreviews(ctx context.Context, dir string, names []string, maxSide int) []image.Image {
+	previews := make([]image.Image, len(names))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				img, err := decodePreviewFile(filepath.Join(dir, names[i]), maxSide)
+				if err != nil {
+					fmt.Printf(tr("No preview of %s: %v\n"), names[i], err)
+					continue
+				}
+				previews[i] = img
+			}
+		}()
+	}
+feed:
+	for i := range names {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return previews
+}
+
+// writeContactSheets draws previews, in order, into JPEG sheets of
+// previewColumns×previewRows cells in dir and returns their paths. Missing
+// previews leave their cell gray.
+func writeContactSheets(dir string, previews []image.Image) ([]string, error) {
+	perSheet := previewColumns * previewRows
+	var paths []string
+	for first := 0; first < len(previews); first += perSheet {
+		page := previews[first:]
+		if len(page) > perSheet {
+			page = page[:perSheet]
+		}
+		rows := (len(page) + previewColumns - 1) / previewColumns
+		sheet := image.NewRGBA(image.Rect(0, 0, previewColumns*previewCell, rows*previewCell))
+		draw.Draw(sheet, sheet.Bounds(), image.Black, image.Point{}, draw.Src)
+		for i, img := range page {
+			cell := image.Rect(0, 0, previewCell, previewCell).Add(image.Pt(i%previewColumns*previewCell, i/previewColumns*previewCell)).Inset(previewMargin)
+			if img == nil {
+				draw.Draw(sheet, cell, image.NewUniform(color.Gray{0x40}), image.Point{}, draw.Src)
+				continue
+			}
+			// Centered in the cell.
+			b := img.Bounds()
+			at := cell.Min.Add(image.Pt((cell.Dx()-b.Dx())/2, (cell.Dy()-b.Dy())/2))
+			draw.Draw(sheet, b.Sub(b.Min).Add(at).Intersect(cell), img, b.Min, draw.Src)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("sheet-%03d.jpg", len(paths)+1))
+		out, err := os.Create(longPath(path))
+		if err != nil {
+			return paths, err
+		}
+		err = jpeg.Encode(out, sheet, &jpeg.Options{Quality: 85})
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// runDryRun lists the HEIC files in dir and what each would be converted
+// to, without writing anything next to them. With -preview it draws them
+// into contact sheets, from their thumbnails, in a temporary folder.
+func runDryRun(ctx context.Context, dir string) error {
+	files, err := getFilesInDirectory(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, file := range files {
+		if isHEIC(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no HEIC files in %s", dir)
+	}
+	jpegDir := jpegDirectory(dir)
+	var listed []string
+	for _, name := range names {
+		output, err := plannedOutput(dir, name, jpegDir)
+		if reason, ok := skippedBy(err); ok {
+			fmt.Printf(tr("Skipped %s: %s\n"), name, reason)
+			continue
+		}
+		if err != nil {
+			fmt.Printf(tr("Failed to convert %s: %v\n"), name, err)
+			continue
+		}
+		outputs := []string{output}
+		extras, _ := extrasFor(globalSettings())
+		for _, extra := range extras {
+			outputs = append(outputs, extra.path(output))
+		}
+		fmt.Printf("%s -> %s\n", name, strings.Join(outputs, ", "))
+		listed = append(listed, name)
+	}
+	fmt.Printf(tr("%d of %d files would be converted, nothing was written.\n"), len(listed), len(names))
+	if !*previewSheets || len(listed) == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	previews := decodePreviews(ctx, dir, listed, previewCell-2*previewMargin)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	sheetDir, err := os.MkdirTemp("", "heictojpeg-preview-")
+	if err != nil {
+		return err
+	}
+	sheets, err := writeContactSheets(sheetDir, previews)
+	if err != nil {
+		return err
+	}
+	drawn := 0
+	for _, img := range previews {
+		if img != nil {
+			drawn++
+		}
+	}
+	fmt.Printf(tr("Drew %d previews into %d contact sheets in %v, %d to a sheet in the order listed.\n"), drawn, len(sheets), time.Since(started).Round(time.Millisecond), previewColumns*previewRows)
+	if err := openWithDesktop(sheetDir); err != nil {
+		fmt.Printf(tr("The contact sheets are in %s\n"), sheetDir)
+	} else {
+		fmt.Printf(tr("Opened %s\n"), sheetDir)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/internal/heicsample"
+)
+
+func TestWriteContactSheets(t *testing.T) {
+	dir := t.TempDir()
+	previews := make([]image.Image, previewColumns*previewRows+5)
+	for i := range previews {
+		if i%3 != 0 {
+			previews[i] = image.NewRGBA64(image.Rect(0, 0, 40, 30))
+		}
+	}
+	sheets, err := writeContactSheets(dir, previews)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 2 {
+		t.Fatalf("got %d sheets, want 2", len(sheets))
+	}
+	f, err := os.Open(sheets[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != previewColumns*previewCell || config.Height != previewCell {
+		t.Errorf("the last sheet is %d×%d, want one row", config.Width, config.Height)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "IMG_0001.HEIC"), heicsample.Thumbnailed(16), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runDryRun(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "jpegs")); !os.IsNotExist(err) {
+		t.Errorf("the dry run created the output folder: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var profileNames = flag.String("profiles", "", "also write each photo as these output profiles from the same decode, e.g. web,thumb: built-in or defined under \"profiles\" in the config file, each with its format, settings and subfolder of the output folder")
+
+// profiles are the built-in output profiles: options of an override, as
+// for presets, plus "format" (jpeg, png or tiff) and "folder", the
+// subfolder beside each output the profile's files go to.
+var profiles = map[string]map[string]interface{}{
+	"web":     {"format": "jpeg", "preset": "web", "folder": "web"},
+	"archive": {"format": "jpeg", "preset": "archive", "folder": "archive"},
+	"thumb":   {"format": "jpeg", "preset": "thumb", "folder": "thumbs"},
+}
+
+// customProfiles are the output profiles defined in the config file.
+var customProfiles map[string]map[string]interface{}
+
+var profileFormats = map[string]string{"jpeg": ".jpg", "png": ".png", "tiff": ".tif"}
+
+// configProfiles takes the "profiles" object out of the config file, so
+// it isn't mistaken for an option, and returns the profiles it defines.
+func configProfiles(config map[string]interface{}) (map[string]map[string]interface{}, error) {
+	raw, ok := config["profiles"]
+	if !ok {
+		return nil, nil
+	}
+	delete(config, "profiles")
+	defined, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profiles: must be an object of profile names to options")
+	}
+	custom := make(map[string]map[string]interface{}, len(defined))
+	for name, options := range defined {
+		values, ok := options.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("profiles: %s must be an object of options", name)
+		}
+		custom[name] = values
+	}
+	return custom, nil
+}
+
+// parseProfile makes the output of profile name on top of base.
+func parseProfile(name string, base settings) (extraOutput, error) {
+	options, ok := customProfiles[name]
+	if !ok {
+		options, ok = profiles[name]
+	}
+	if !ok {
+		return extraOutput{}, fmt.Errorf("unknown profile %q (have %s)", name, strings.Join(profileList(), ", "))
+	}
+	extra := extraOutput{name: name, settings: base, format: "jpeg", folder: name}
+	rest := make(map[string]interface{}, len(options))
+	for key, value := range options {
+		switch key {
+		case "format":
+			extra.format = fmt.Sprint(value)
+			if _, ok := profileFormats[extra.format]; !ok {
+				return extraOutput{}, fmt.Errorf("profile %s: format %q: must be jpeg, png or tiff", name, extra.format)
+			}
+		case "folder":
+			extra.folder = filepath.Clean(fmt.Sprint(value))
+			if filepath.IsAbs(extra.folder) || extra.folder == "." || extra.folder == ".." || strings.HasPrefix(extra.folder, ".."+string(filepath.Separator)) {
+				return extraOutput{}, fmt.Errorf("profile %s: folder %q: must be a subfolder", name, value)
+			}
+		default:
+			rest[key] = value
+		}
+	}
+	if err := extra.settings.apply(rest); err != nil {
+		return extraOutput{}, fmt.Errorf("profile %s: %v", name, err)
+	}
+	return extra, nil
+}
+
+func profileList() []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	for name := range customProfiles {
+		if _, builtin := profiles[name]; !builtin {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
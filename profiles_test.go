@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/heicsample"
+)
+
+func TestConfigProfiles(t *testing.T) {
+	config := map[string]interface{}{
+		"quality":  85.0,
+		"profiles": map[string]interface{}{"small": map[string]interface{}{"format": "png", "max-size": 16.0, "folder": "small"}},
+	}
+	custom, err := configProfiles(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config["profiles"]; ok {
+		t.Errorf("profiles was left among the options")
+	}
+	if custom["small"]["format"] != "png" {
+		t.Errorf("got %v", custom)
+	}
+	if _, err := configProfiles(map[string]interface{}{"profiles": []interface{}{"web"}}); err == nil {
+		t.Errorf("a list of profiles was accepted")
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	defer func(p map[string]map[string]interface{}) { customProfiles = p }(customProfiles)
+	customProfiles = map[string]map[string]interface{}{
+		"small":  {"format": "png", "max-size": 16.0, "folder": "small/png"},
+		"web":    {"quality": 60.0},
+		"tiff":   {"format": "gif"},
+		"escape": {"folder": "../elsewhere"},
+		"bad":    {"quality": 0.0},
+	}
+	base := settings{Quality: 90, Metadata: "keep", CropFocus: "center"}
+
+	thumb, err := parseProfile("thumb", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.format != "jpeg" || thumb.folder != "thumbs" || thumb.settings.MaxSize != 300 || thumb.settings.Quality != 75 {
+		t.Errorf("thumb: got %+v", thumb)
+	}
+	small, err := parseProfile("small", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.format != "png" || small.folder != filepath.Join("small", "png") || small.settings.MaxSize != 16 || small.settings.Quality != 90 {
+		t.Errorf("small: got %+v", small)
+	}
+	// The config file's profile replaces the built-in one of its name.
+	if web, err := parseProfile("web", base); err != nil || web.settings.Quality != 60 || web.folder != "web" {
+		t.Errorf("web: got %+v, %v", web, err)
+	}
+	for _, name := range []string{"tiff", "escape", "bad", "unknown"} {
+		if _, err := parseProfile(name, base); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestWriteProfiles(t *testing.T) {
+	defer func(v string, p map[string]map[string]interface{}) { *profileNames, customProfiles = v, p }(*profileNames, customProfiles)
+	customProfiles = map[string]map[string]interface{}{"small": {"format": "png", "max-size": 16.0, "folder": "small"}}
+	*profileNames = "small,thumb"
+
+	d, err := convert.Decode(context.Background(), bytes.NewReader(heicsample.Single(8)), convert.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := d.Image
+	dir := t.TempDir()
+	output := filepath.Join(dir, "IMG_0001.jpg")
+	if err := writeExtraOutputs(context.Background(), img, nil, output); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		path, format string
+		side         int
+	}{
+		{filepath.Join(dir, "small", "IMG_0001.png"), "png", 16},
+		{filepath.Join(dir, "thumbs", "IMG_0001.jpg"), "jpeg", heicsample.Size},
+	} {
+		f, err := os.Open(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, format, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format != tc.format || config.Width != tc.side {
+			t.Errorf("%s: got a %d-pixel %s", tc.path, config.Width, format)
+		}
+	}
+	if got := extraFiles(context.Background(), output); len(got) != 2 {
+		t.Errorf("extraFiles found %v", got)
+	}
+}
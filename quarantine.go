@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var quarantine = flag.String("quarantine", "", "copy or move files that fail to convert into jpegs/failed, each with an .error.txt report")
+
+const quarantineDirName = "failed"
+
+// quarantineFile copies or moves a failed original into jpegs/failed,
+// keeping its relative path, and writes the error next to it. It returns
+// the quarantined copy.
+func quarantineFile(currentDir, jpegDir, name string, convErr error) (string, error) {
+	src := filepath.Join(currentDir, name)
+	dst := filepath.Join(jpegDir, quarantineDirName, name)
+	if err := os.MkdirAll(longPath(filepath.Dir(dst)), 0755); err != nil {
+		return "", err
+	}
+
+	var err error
+	if *quarantine == "move" {
+		err = moveFile(src, dst)
+	} else {
+		err = copyFile(src, dst)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	report := fmt.Sprintf("File: %s\nSize: %d bytes\nError: %v\nTime: %s\nQuality: %d\n",
+		src, getFileSize(dst), convErr, time.Now().Format(time.RFC3339), *quality)
+	return dst, os.WriteFile(longPath(dst+".error.txt"), []byte(report), 0644)
+}
+
+// shouldQuarantine leaves out files that didn't fail on their own: the run
+// was interrupted, the disk is full, the output couldn't be written or
+// -post-cmd failed. Those are retried or fixed outside the file, so the
+// original has to stay where it is. Errors from the file system are
+// *os.PathErrors; decoding errors never are.
+func shouldQuarantine(ctx context.Context, err error) bool {
+	if *quarantine == "" || ctx.Err() != nil || errors.Is(err, context.Canceled) || isDiskFull(err) {
+		return false
+	}
+	var pathErr *os.PathError
+	var hookErr *hookError
+	return !errors.As(err, &pathErr) && !errors.As(err, &hookErr)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuarantineFailedFile(t *testing.T) {
+	for _, mode := range []string{"copy", "move"} {
+		t.Run(mode, func(t *testing.T) {
+			old := *quarantine
+			*quarantine = mode
+			defer func() { *quarantine = old }()
+
+			dir := t.TempDir()
+			jpegDir := ensureJPEGDirectoryExists(dir)
+			os.WriteFile(filepath.Join(dir, "bad.heic"), []byte("not a heic file"), 0644)
+
+			result, _ := processFile(context.Background(), &mockDirEntry{name: "bad.heic"}, dir, jpegDir)
+			if result.Err == nil {
+				t.Fatalf("processFile(bad.heic) = %+v, want an error", result)
+			}
+			if data, err := os.ReadFile(filepath.Join(jpegDir, "failed", "bad.heic")); err != nil || string(data) != "not a heic file" {
+				t.Errorf("quarantined copy = %q, %v", data, err)
+			}
+			report, err := os.ReadFile(filepath.Join(jpegDir, "failed", "bad.heic.error.txt"))
+			if err != nil || !strings.Contains(string(report), "Error: ") {
+				t.Errorf("error report = %q, %v", report, err)
+			}
+			_, err = os.Stat(filepath.Join(dir, "bad.heic"))
+			if moved := os.IsNotExist(err); moved != (mode == "move") {
+				t.Errorf("original removed = %v in %s mode", moved, mode)
+			}
+		})
+	}
+}
+
+// A full disk isn't the file's fault: with -quarantine move the original
+// has to stay, or the retry after space is freed finds nothing to convert.
+func TestQuarantineSkipsDiskFull(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to write to")
+	}
+	old := *quarantine
+	*quarantine = "move"
+	defer func() { *quarantine = old }()
+
+	dir := t.TempDir()
+	jpegDir := ensureJPEGDirectoryExists(dir)
+	os.WriteFile(filepath.Join(dir, "full.heic"), exifSample(), 0644)
+	if err := os.Symlink("/dev/full", filepath.Join(jpegDir, "full.jpg")); err != nil {
+		t.Skip(err)
+	}
+
+	result, _ := processFile(context.Background(), &mockDirEntry{name: "full.heic"}, dir, jpegDir)
+	if !isDiskFull(result.Err) {
+		t.Fatalf("processFile(full.heic) = %+v, want a full disk", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "full.heic")); err != nil {
+		t.Errorf("original was moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(jpegDir, "failed")); !os.IsNotExist(err) {
+		t.Errorf("jpegs/failed exists after a full disk: %v", err)
+	}
+}
+
+func TestShouldQuarantine(t *testing.T) {
+	old := *quarantine
+	*quarantine = "move"
+	defer func() { *quarantine = old }()
+
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("invalid NAL size: 0"), true},
+		{&os.PathError{Op: "write", Path: "a.jpg", Err: diskFullErrors[0]}, false},
+		{&os.PathError{Op: "open", Path: "a.jpg", Err: os.ErrPermission}, false},
+		{&hookError{"post-cmd", errors.New("exit status 1")}, false},
+		{context.Canceled, false},
+	} {
+		if got := shouldQuarantine(context.Background(), tc.err); got != tc.want {
+			t.Errorf("shouldQuarantine(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
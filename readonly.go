@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	readOnly = flag.Bool("read-only", false, "guarantee that nothing in the folders being converted is written, moved, deleted or has its permissions or times changed (camera cards, backups); needs -out")
+	outDir   = flag.String("out", "", "write the JPEGs, logs.txt and other outputs into this folder instead of a jpegs subfolder of each folder converted")
+)
+
+// readOnlyConflicts returns the options that -read-only can't be combined
+// with, because they change the originals or run commands that might, or
+// write reports outside -out.
+func readOnlyConflicts() error {
+	if *outDir == "" {
+		return errors.New(tr("-read-only needs -out, a folder outside the photos for the JPEGs"))
+	}
+	var conflicts []string
+	if *deleteOriginals {
+		conflicts = append(conflicts, "-delete-originals")
+	}
+	if *quarantine == "move" {
+		conflicts = append(conflicts, "-quarantine move")
+	}
+	if *preCmd != "" {
+		conflicts = append(conflicts, "-pre-cmd")
+	}
+	if *postCmd != "" {
+		conflicts = append(conflicts, "-post-cmd")
+	}
+	for _, report := range []struct{ flag, path string }{
+		{"-compare-dir", *compareDir},
+		{"-collect-failures", *collectFailures},
+		{"-feed", *feedFile},
+	} {
+		if report.path != "" && !within(*outDir, report.path) {
+			conflicts = append(conflicts, report.flag+" outside -out")
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf(tr("-read-only can't be used with %s"), strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// within reports whether path is root or inside it, after resolving
+// symlinks in the part of path that exists.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(resolvePath(root), resolvePath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolvePath makes path absolute and resolves the symlinks of its longest
+// existing prefix, so a path not created yet compares like its folder.
+func resolvePath(path string) string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	var rest []string
+	for {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{real}, rest...)...)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, rest...)...)
+		}
+		rest = append([]string{filepath.Base(path)}, rest...)
+		path = parent
+	}
+}
+
+// checkReadOnlySource makes sure a -read-only run can convert dir: its
+// outputs in -out must not end up inside it, nor can dir be inside -out,
+// where writes are allowed.
+func checkReadOnlySource(dir string) error {
+	if !*readOnly {
+		return nil
+	}
+	if within(dir, *outDir) || within(*outDir, dir) {
+		return fmt.Errorf(tr("-read-only: -out %s must be outside %s"), *outDir, dir)
+	}
+	return nil
+}
+
+// guardWrite refuses, in a -read-only run, to create, change or remove
+// path anywhere but under -out. Every write to the outputs goes through
+// it, so a path computed wrongly can't reach the originals.
+func guardWrite(path string) error {
+	if *readOnly && !within(*outDir, path) {
+		return &os.PathError{Op: "write", Path: path, Err: errors.New(tr("outside -out with -read-only"))}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+// setReadOnly turns on -read-only with -out out for the test.
+func setReadOnly(t *testing.T, out string) {
+	oldReadOnly, oldOut := *readOnly, *outDir
+	*readOnly, *outDir = true, out
+	t.Cleanup(func() { *readOnly, *outDir = oldReadOnly, oldOut })
+}
+
+func TestWithin(t *testing.T) {
+	root := t.TempDir()
+	for path, want := range map[string]bool{
+		root:                                    true,
+		filepath.Join(root, "a", "b.jpg"):       true,
+		filepath.Join(root, "..", "other"):      false,
+		filepath.Join(root, "a", "..", "c.jpg"): true,
+		root + "-sibling":                       false,
+	} {
+		if got := within(root, path); got != want {
+			t.Errorf("within(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(root, link); err != nil {
+		t.Skip(err)
+	}
+	if !within(root, filepath.Join(link, "new.jpg")) {
+		t.Error("a path through a symlink into root isn't within it")
+	}
+}
+
+func TestGuardWrite(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	setReadOnly(t, out)
+	if err := guardWrite(filepath.Join(out, "IMG_0001.jpg")); err != nil {
+		t.Errorf("write under -out refused: %v", err)
+	}
+	original := filepath.Join(src, "IMG_0001.heic")
+	os.WriteFile(original, []byte("heic"), 0644)
+	if err := moveFile(original, filepath.Join(out, "IMG_0001.heic")); err == nil {
+		t.Error("moved an original")
+	}
+	if err := removeOriginal(original, filepath.Join(out, "IMG_0001.jpg"), ""); err == nil {
+		t.Error("removed an original")
+	}
+	if !fileExists(original) {
+		t.Error("original gone")
+	}
+}
+
+func TestReadOnlyConvertDirectory(t *testing.T) {
+	src := t.TempDir()
+	setReadOnly(t, filepath.Join(src, "out"))
+	if err := convertDirectory(context.Background(), src, []os.DirEntry{}); err == nil {
+		t.Error("converted with -out inside the source")
+	}
+	if fileExists(filepath.Join(src, "out")) {
+		t.Error("created -out inside the source")
+	}
+}
+
+func TestReadOnlyConflicts(t *testing.T) {
+	setReadOnly(t, "")
+	if err := readOnlyConflicts(); err == nil {
+		t.Error("no error without -out")
+	}
+	*outDir = t.TempDir()
+	if err := readOnlyConflicts(); err != nil {
+		t.Error(err)
+	}
+	old := *deleteOriginals
+	*deleteOriginals = true
+	defer func() { *deleteOriginals = old }()
+	if err := readOnlyConflicts(); err == nil {
+		t.Error("no error with -delete-originals")
+	}
+}
+
+func TestReadOnlyReportsOutsideOut(t *testing.T) {
+	src, out := t.TempDir(), t.TempDir()
+	setReadOnly(t, out)
+	for _, tc := range []struct {
+		flag *string
+		path string
+	}{
+		{compareDir, filepath.Join(src, "compare")},
+		{collectFailures, filepath.Join(src, "failures.zip")},
+		{feedFile, filepath.Join(src, "feed.xml")},
+	} {
+		old := *tc.flag
+		*tc.flag = tc.path
+		if err := readOnlyConflicts(); err == nil {
+			t.Errorf("no error with %s outside -out", tc.path)
+		}
+		*tc.flag = filepath.Join(out, filepath.Base(tc.path))
+		if err := readOnlyConflicts(); err != nil {
+			t.Errorf("%s under -out: %v", tc.path, err)
+		}
+		*tc.flag = old
+	}
+
+	if _, err := newComparer(filepath.Join(src, "compare")); err == nil {
+		t.Error("created the comparison folder outside -out")
+	}
+	failures := []convert.ConversionResult{{Input: filepath.Join(src, "bad.heic"), Err: errors.New("bad")}}
+	if err := writeFailureBundle(filepath.Join(src, "failures.zip"), failures, 64); err == nil {
+		t.Error("wrote the failure bundle outside -out")
+	}
+	feed, err := newFeedObserver(filepath.Join(src, "feed.xml"), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := feed.update([]feedEntry{{ID: "file:///a.jpg", Title: "a.jpg"}}); err == nil {
+		t.Error("wrote the feed outside -out")
+	}
+	for _, name := range []string{"compare", "failures.zip", "feed.xml", "feed.xml.tmp"} {
+		if fileExists(filepath.Join(src, name)) {
+			t.Errorf("%s written in the source", name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"heictojpeg/convert"
+)
+
+var repair = flag.Bool("repair", false, "convert what's left of truncated HEIC files (e.g. after an interrupted sync), with the missing tiles black, and log a warning")
+
+// conversionWarning is returned for a file that was converted, but not
+// completely; the batch treats it as converted and logs the warning.
+type conversionWarning struct {
+	msg string
+}
+
+func (w *conversionWarning) Error() string { return w.msg }
+
+func isWarning(err error) bool {
+	var w *conversionWarning
+	return errors.As(err, &w)
+}
+
+// truncatedSize reports whether the top-level boxes of a HEIF file claim
+// more bytes than the file has, as after an interrupted download: the file
+// size and the size the boxes need.
+func truncatedSize(r io.ReaderAt, size int64) (int64, bool) {
+	var header [16]byte
+	for offset := int64(0); offset < size; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return offset + 8, true
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		switch boxSize {
+		case 0: // extends to the end of the file
+			return size, false
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return offset + 16, true
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+		if boxSize < 8 {
+			return size, false // not a box structure we understand
+		}
+		offset += boxSize
+		if offset > size {
+			return offset, true
+		}
+	}
+	return size, false
+}
+
+// explainTruncation turns a decode error on a truncated file into one
+// that says so.
+func explainTruncation(path string, err error) error {
+	f, openErr := os.Open(longPath(path))
+	if openErr != nil {
+		return err
+	}
+	defer f.Close()
+	info, statErr := f.Stat()
+	if statErr != nil {
+		return err
+	}
+	if want, truncated := truncatedSize(f, info.Size()); truncated {
+		return fmt.Errorf("file is truncated: %d of %d bytes (%v)", info.Size(), want, err)
+	}
+	return err
+}
+
+// repairTruncated converts the tiles that are still present in a truncated
+// tiled HEIC file.
+func repairTruncated(ctx context.Context, input, output string) error {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d, missing, total, err := convert.Repair(ctx, f, settingsFrom(ctx).options())
+	if err != nil {
+		return err
+	}
+	recordProfile(ctx, d.Profile)
+
+	out, err := os.Create(longPath(output))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := encodeJPEG(ctx, out, d.Image, d.Exif); err != nil {
+		return err
+	}
+	return &conversionWarning{fmt.Sprintf("truncated file, %d of %d tiles missing (shown black)", missing, total)}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func box(typ string, payload int) []byte {
+	b := make([]byte, 8+payload)
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	copy(b[4:], typ)
+	return b
+}
+
+func TestTruncatedSize(t *testing.T) {
+	file := append(append(box("ftyp", 16), box("meta", 100)...), box("mdat", 1000)...)
+	if _, truncated := truncatedSize(bytes.NewReader(file), int64(len(file))); truncated {
+		t.Error("complete file reported as truncated")
+	}
+
+	cut := file[:len(file)-400]
+	want, truncated := truncatedSize(bytes.NewReader(cut), int64(len(cut)))
+	if !truncated || want != int64(len(file)) {
+		t.Errorf("truncatedSize = %d, %v; want %d, true", want, truncated, len(file))
+	}
+}
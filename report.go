@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var sortReport = flag.String("sort", "path", "order of the files in logs.txt: path, size (largest first), duration (slowest first) or status (failures first)")
+
+var reportSorts = map[string]bool{"path": true, "size": true, "duration": true, "status": true}
+
+// batchReport records each result so logs.txt can list the files in a
+// stable order instead of the order the workers happened to finish in,
+// and the summary for history.csv.
+type batchReport struct {
+	mu       sync.Mutex
+	results  []convert.ConversionResult
+	summary  convert.Summary
+	finished time.Time
+}
+
+func (r *batchReport) OnStart(total int) {}
+
+func (r *batchReport) OnFileDone(result convert.ConversionResult) {
+	r.mu.Lock()
+	r.results = append(r.results, result)
+	r.mu.Unlock()
+}
+
+func (r *batchReport) OnFinish(summary convert.Summary) {
+	r.mu.Lock()
+	r.summary, r.finished = summary, time.Now()
+	r.mu.Unlock()
+}
+
+// keys returns the log keys sorted by, with ties in path order.
+func (r *batchReport) keys(by string) []string {
+	results := r.sorted(by)
+	keys := make([]string, len(results))
+	for i, result := range results {
+		keys[i] = result.Name
+	}
+	return keys
+}
+
+func (r *batchReport) sorted(by string) []convert.ConversionResult {
+	r.mu.Lock()
+	results := append([]convert.ConversionResult(nil), r.results...)
+	r.mu.Unlock()
+
+	sort.Slice(results, func(i, j int) bool { return pathLess(results[i].Name, results[j].Name) })
+	var less func(a, b convert.ConversionResult) bool
+	switch by {
+	case "size":
+		less = func(a, b convert.ConversionResult) bool { return a.InputSize > b.InputSize }
+	case "duration":
+		less = func(a, b convert.ConversionResult) bool { return a.Duration > b.Duration }
+	case "status":
+		less = func(a, b convert.ConversionResult) bool { return statusRank(a) < statusRank(b) }
+	}
+	if less != nil {
+		sort.SliceStable(results, func(i, j int) bool { return less(results[i], results[j]) })
+	}
+	return results
+}
+
+// statusRank puts failures first, then files with warnings.
+func statusRank(result convert.ConversionResult) int {
+	switch {
+	case result.Err != nil:
+		return 0
+	case result.Warning != "":
+		return 1
+	}
+	return 2
+}
+
+const hashesFileName = "hashes.csv"
+
+// saveHashes writes jpegs/hashes.csv with the -hashes of each converted
+// file, in path order, replacing the previous run's.
+func saveHashes(jpegDir string, r *batchReport) error {
+	f, err := os.Create(longPath(filepath.Join(jpegDir, hashesFileName)))
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"file", "output", "phash", "dhash"})
+	for _, result := range r.sorted("path") {
+		if result.Hashes == nil {
+			continue
+		}
+		output, err := filepath.Rel(jpegDir, result.Output)
+		if err != nil {
+			output = result.Output
+		}
+		w.Write([]string{
+			filepath.ToSlash(result.Name),
+			filepath.ToSlash(output),
+			convert.FormatHash(result.Hashes.PHash),
+			convert.FormatHash(result.Hashes.DHash),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+func TestReportOrderKeys(t *testing.T) {
+	r := &batchReport{}
+	r.OnFileDone(convert.ConversionResult{Name: "b.heic", InputSize: 10, Duration: 3 * time.Second})
+	r.OnFileDone(convert.ConversionResult{Name: filepath.Join("sub", "a.heic"), InputSize: 30, Duration: time.Second, Err: errors.New("boom")})
+	r.OnFileDone(convert.ConversionResult{Name: "a.heic", InputSize: 20, Duration: 2 * time.Second, Warning: "truncated"})
+	r.OnFileDone(convert.ConversionResult{Name: "c.heic", InputSize: 20, Duration: 3 * time.Second})
+
+	for by, want := range map[string]string{
+		"path":     "a.heic b.heic c.heic sub/a.heic",
+		"size":     "sub/a.heic a.heic c.heic b.heic",
+		"duration": "b.heic c.heic a.heic sub/a.heic",
+		"status":   "sub/a.heic a.heic b.heic c.heic",
+	} {
+		got := filepath.ToSlash(strings.Join(r.keys(by), " "))
+		if got != want {
+			t.Errorf("keys(%q) = %s, want %s", by, got, want)
+		}
+	}
+}
+
+// Testing logs.txt lists the files in the same order on every run
+func TestSaveLogsToFileOrder(t *testing.T) {
+	dir := t.TempDir()
+	logs := map[string][]string{"general": {"summary"}}
+	for _, name := range []string{"d.heic", "a.heic", "c.heic", "b.heic"} {
+		logs[name] = []string{name}
+	}
+
+	saveLogsToFile(dir, logs, []string{"c.heic"})
+	data, err := os.ReadFile(filepath.Join(dir, logFileName))
+	if err != nil {
+		t.Fatalf("Failed to read logs: %v", err)
+	}
+	if got, want := string(data), "c.heic\na.heic\nb.heic\nd.heic\nsummary\n"; got != want {
+		t.Errorf("logs.txt = %q, want %q", got, want)
+	}
+}
+
+func TestSaveHashes(t *testing.T) {
+	dir := t.TempDir()
+	r := &batchReport{}
+	r.OnFileDone(convert.ConversionResult{Name: "trip/b.heic", Output: filepath.Join(dir, "trip", "b.jpg"), Hashes: &convert.Hashes{PHash: 2, DHash: 3}})
+	r.OnFileDone(convert.ConversionResult{Name: "c.heic", Err: errors.New("boom")})
+	r.OnFileDone(convert.ConversionResult{Name: "a.heic", Output: filepath.Join(dir, "a.jpg"), Hashes: &convert.Hashes{PHash: 0xabc, DHash: 1}})
+	if err := saveHashes(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, hashesFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "file,output,phash,dhash\n" +
+		"a.heic,a.jpg,0000000000000abc,0000000000000001\n" +
+		"trip/b.heic,trip/b.jpg,0000000000000002,0000000000000003\n"
+	if string(data) != want {
+		t.Errorf("hashes.csv =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestAggregateLogsOrientation(t *testing.T) {
+	results := make(chan convert.ConversionResult, 2)
+	results <- convert.ConversionResult{Name: "turned.heic", Output: "turned.jpg", Orientation: 6}
+	results <- convert.ConversionResult{Name: "upright.heic", Output: "upright.jpg"}
+	close(results)
+	logs := make(map[string][]string)
+	aggregateLogs(results, logs, t.TempDir(), t.TempDir(), time.Now(), nil)
+	if got := strings.Join(logs["turned.heic"], ""); !strings.Contains(got, "EXIF orientation 6") {
+		t.Errorf("rotated file not flagged: %q", got)
+	}
+	if got := strings.Join(logs["upright.heic"], ""); strings.Contains(got, "orientation") {
+		t.Errorf("upright file flagged: %q", got)
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import "flag"
+
+var (
+	maxSize  = flag.Int("max-size", 0, "scale images down so the longer side is at most this many pixels (0 keeps the original size)")
+	metadata = flag.String("metadata", "keep", "EXIF in the JPEGs: keep, or strip for sharing")
+)
+
+var metadataPolicies = map[string]bool{"keep": true, "strip": true}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var runAs = flag.String("run-as", "", "when started as root, e.g. in a container, switch to this UID:GID before converting, so the JPEGs belong to that user (PUID and PGID work too)")
+
+// parseRunAs parses -run-as, or the PUID and PGID variables container
+// images for NAS systems use, into a user and group ID; ok is false if
+// neither is set.
+func parseRunAs(value string, getenv func(string) string) (uid, gid int, ok bool, err error) {
+	if value == "" {
+		puid, pgid := getenv("PUID"), getenv("PGID")
+		if puid == "" && pgid == "" {
+			return 0, 0, false, nil
+		}
+		value = puid + ":" + pgid
+	}
+	user, group, found := strings.Cut(value, ":")
+	if !found {
+		group = user
+	}
+	if uid, err = strconv.Atoi(user); err != nil || uid < 0 {
+		return 0, 0, false, fmt.Errorf("%q: the user must be a number", value)
+	}
+	if gid, err = strconv.Atoi(group); err != nil || gid < 0 {
+		return 0, 0, false, fmt.Errorf("%q: the group must be a number", value)
+	}
+	return uid, gid, true, nil
+}
+
+// dropPrivileges switches to the -run-as user when running as root. A
+// process already running as someone else is left alone, since it
+// couldn't switch anyway.
+func dropPrivileges() error {
+	uid, gid, ok, err := parseRunAs(*runAs, os.Getenv)
+	if err != nil || !ok || os.Geteuid() != 0 {
+		return err
+	}
+	return switchUser(uid, gid)
+}
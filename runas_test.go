@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestParseRunAs(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	for _, tt := range []struct {
+		value    string
+		vars     map[string]string
+		uid, gid int
+		ok, err  bool
+	}{
+		{"", nil, 0, 0, false, false},
+		{"1000:100", nil, 1000, 100, true, false},
+		{"1000", nil, 1000, 1000, true, false},
+		{"", map[string]string{"PUID": "1026", "PGID": "100"}, 1026, 100, true, false},
+		{"99:99", map[string]string{"PUID": "1026", "PGID": "100"}, 99, 99, true, false},
+		{"nobody", nil, 0, 0, false, true},
+		{"1000:-1", nil, 0, 0, false, true},
+	} {
+		uid, gid, ok, err := parseRunAs(tt.value, env(tt.vars))
+		if uid != tt.uid || gid != tt.gid || ok != tt.ok || (err != nil) != tt.err {
+			t.Errorf("%q %v: %d:%d %v %v", tt.value, tt.vars, uid, gid, ok, err)
+		}
+	}
+}
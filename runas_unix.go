@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import "syscall"
+
+// switchUser makes the process run as uid and gid, without root's other
+// groups.
+func switchUser(uid, gid int) error {
+	if err := syscall.Setgroups([]int{gid}); err != nil {
+		return err
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return err
+	}
+	return syscall.Setuid(uid)
+}
@@ -0,0 +1,7 @@
+package main
+
+import "errors"
+
+func switchUser(uid, gid int) error {
+	return errors.New("-run-as is not supported on Windows")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	sanitize      = flag.String("sanitize", "", "make output names safe for a filesystem: fat32 or exfat (SD cards, TVs) replace invalid characters and shorten long names")
+	sanitizeWith  = flag.String("sanitize-replacement", "_", "with -sanitize, the text that replaces invalid characters")
+	maxNameLength = flag.Int("max-name-length", 255, "with -sanitize, the longest output file or folder name, in UTF-16 units as FAT32 and exFAT count them")
+)
+
+// sanitizers are the characters each filesystem rejects, on top of control
+// characters. FAT32 long names and exFAT reject the same set; FAT32 also
+// trips over some names DOS reserved.
+var sanitizers = map[string]struct {
+	invalid  string
+	reserved bool
+}{
+	"fat32": {`"*/:<>?\|`, true},
+	"exfat": {`"*/:<>?\|`, false},
+}
+
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizePath applies -sanitize to each element of a relative output path.
+func sanitizePath(rel string) string {
+	s, ok := sanitizers[*sanitize]
+	if !ok {
+		return rel
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		ext := ""
+		if i == len(parts)-1 {
+			ext = filepath.Ext(part)
+			part = strings.TrimSuffix(part, ext)
+		}
+		parts[i] = sanitizeName(part, ext, s.invalid, s.reserved)
+	}
+	return strings.Join(parts, string(filepath.Separator))
+}
+
+// sanitizeName cleans one name (without its extension), then shortens it
+// so that name+ext fits -max-name-length.
+func sanitizeName(name, ext, invalid string, reserved bool) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(invalid, r) {
+			b.WriteString(*sanitizeWith)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	// Trailing dots and spaces are dropped by Windows and FAT drivers.
+	name = strings.TrimRight(b.String(), ". ")
+	if name == "" {
+		name = *sanitizeWith
+	}
+	if reserved && reservedNames[strings.ToUpper(strings.SplitN(name, ".", 2)[0])] {
+		name = *sanitizeWith + name
+	}
+
+	limit := *maxNameLength - utf16Len(ext)
+	if utf16Len(name) > limit {
+		units := 0
+		for i, r := range name {
+			if units+utf16Len(string(r)) > limit {
+				name = strings.TrimRight(name[:i], ". ")
+				break
+			}
+			units += utf16Len(string(r))
+		}
+	}
+	return name + ext
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r > 0xffff {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
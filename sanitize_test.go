@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizePath(t *testing.T) {
+	old := *sanitize
+	defer func() { *sanitize = old }()
+
+	*sanitize = "fat32"
+	for in, want := range map[string]string{
+		"IMG_0001.jpg":                        "IMG_0001.jpg",
+		`What? "Really".jpg`:                  "What_ _Really_.jpg",
+		"trailing dots...jpg":                 "trailing dots.jpg",
+		"CON.jpg":                             "_CON.jpg",
+		"tab\there.jpg":                       "tab_here.jpg",
+		filepath.Join("Trip: Day 1", "a.jpg"): filepath.Join("Trip_ Day 1", "a.jpg"),
+	} {
+		if got := sanitizePath(in); got != want {
+			t.Errorf("sanitizePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	long := strings.Repeat("é", 300) + ".jpg"
+	got := sanitizePath(long)
+	if utf16Len(got) != 255 || !strings.HasSuffix(got, ".jpg") {
+		t.Errorf("long name is %d units: %q", utf16Len(got), got)
+	}
+
+	*sanitize = "exfat"
+	if got := sanitizePath("CON.jpg"); got != "CON.jpg" {
+		t.Errorf("exfat has no reserved names, got %q", got)
+	}
+
+	*sanitize = ""
+	if got := sanitizePath(`a:b.jpg`); got != `a:b.jpg` {
+		t.Errorf("without -sanitize the name should be unchanged, got %q", got)
+	}
+}
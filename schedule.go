@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var schedule = flag.String("schedule", "", "keep running and convert at the times of this cron expression, e.g. \"0 2 * * *\" for every night at 2:00")
+
+// cronSchedule is a parsed cron expression: minute, hour, day of month,
+// month and day of week, each a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// A day matches either restricted day field when both are, as in cron.
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dayNames   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// parseSchedule parses a five-field cron expression: each field is *, a
+// value, a range (1-5), a step (*/15, 0-30/10) or a comma-separated list
+// of them. Months and days of the week can be names (jan, mon), and
+// Sunday is 0 or 7. The @hourly, @daily, @weekly, @monthly and @yearly
+// shorthands are understood too.
+func parseSchedule(expr string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("needs 5 fields: minute hour day-of-month month day-of-week")
+	}
+	s := &cronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	var err error
+	for _, f := range []struct {
+		set      *uint64
+		field    string
+		min, max int
+		names    map[string]int
+	}{
+		{&s.minute, fields[0], 0, 59, nil},
+		{&s.hour, fields[1], 0, 23, nil},
+		{&s.dom, fields[2], 1, 31, nil},
+		{&s.month, fields[3], 1, 12, monthNames},
+		{&s.dow, fields[4], 0, 7, dayNames},
+	} {
+		if *f.set, err = parseCronField(f.field, f.min, f.max, f.names); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not a number from %d to %d", s, min, max)
+		}
+		return n, nil
+	}
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part, step = part[:i], n
+		}
+		lo, hi := min, max
+		if part != "*" {
+			var err error
+			from, to, isRange := strings.Cut(part, "-")
+			if lo, err = value(from); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = value(to); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				hi = max // 5/15 is 5-59/15
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for n := lo; n <= hi; n += step {
+			set |= 1 << n
+		}
+	}
+	return set, nil
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<t.Weekday()) != 0
+	switch {
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first time after t that the schedule matches, or the
+// zero time if none is within five years (like February 30).
+func (s *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// runScheduled calls run at each time of s until ctx is cancelled. Each
+// run gets its own journal, so heictojpeg undo -last undoes the latest
+// one, and the history is saved after it.
+func runScheduled(ctx context.Context, s *cronSchedule, dir string, run func(context.Context, ...convert.Observer) error, observers ...convert.Observer) error {
+	for {
+		next := s.next(time.Now())
+		if next.IsZero() {
+			return errors.New(tr("-schedule never matches"))
+		}
+		infof(tr("Next run at %s\n"), next.Format("2006-01-02 15:04"))
+		// Checking the clock every minute rather than sleeping until next
+		// keeps to the schedule across suspends and clock changes.
+		for time.Now().Before(next) {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(minDuration(time.Until(next), time.Minute)):
+			}
+		}
+
+		runCtx := ctx
+		j, err := startJournal(dir)
+		if err != nil {
+			fmt.Printf(tr("Failed to start the journal, so this run can't be undone: %v\n"), err)
+		} else {
+			runCtx = withJournal(ctx, j)
+		}
+		if err := run(runCtx, observers...); err != nil {
+			fmt.Printf(tr("Scheduled run failed: %v\n"), err)
+		}
+		if j != nil {
+			j.close()
+		}
+		if h := historyFrom(ctx); h != nil {
+			if err := h.save(); err != nil {
+				fmt.Printf(tr("Failed to save the history: %v\n"), err)
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2024, 1, 31, 2, 30, 0, 0, time.UTC) // a Wednesday
+	for _, tt := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 2 * * *", time.Date(2024, 2, 1, 2, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 31, 3, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, 1, 31, 2, 40, 0, 0, time.UTC)},
+		{"30 3 * * sat,sun", time.Date(2024, 2, 3, 3, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 12 15 * mon", time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1-5/2 3 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		s, err := parseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := s.next(from); !got.Equal(tt.want) {
+			t.Errorf("%s: next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	s, _ := parseSchedule("0 0 30 2 *")
+	if got := s.next(from); !got.IsZero() {
+		t.Errorf("February 30 is %v", got)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "0 2 * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "0 0 * foo *"} {
+		if _, err := parseSchedule(expr); err == nil {
+			t.Errorf("%q: no error", expr)
+		}
+	}
+}
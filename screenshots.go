@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"heictojpeg/internal/exif"
+)
+
+var screenshots = flag.String("screenshots", "jpeg", "what to do with screenshots (no camera in the EXIF and a device screen size): jpeg, png (lossless, next to the photos) or skip")
+
+var screenshotModes = map[string]bool{"jpeg": true, "png": true, "skip": true}
+
+const tagUserComment = 0x9286
+
+// screenSizes are the native screen resolutions of iPhones and iPads, in
+// portrait; screenshots are taken at exactly these sizes.
+var screenSizes = map[[2]int]bool{
+	{640, 1136}: true, {750, 1334}: true, {1080, 1920}: true, {1242, 2208}: true,
+	{1125, 2436}: true, {828, 1792}: true, {1242, 2688}: true, {1080, 2340}: true,
+	{1170, 2532}: true, {1284, 2778}: true, {1179, 2556}: true, {1290, 2796}: true,
+	{1206, 2622}: true, {1320, 2868}: true,
+	{1536, 2048}: true, {1620, 2160}: true, {1668, 2224}: true, {1640, 2360}: true,
+	{1668, 2388}: true, {2048, 2732}: true, {1488, 2266}: true, {1668, 2420}: true,
+	{2064, 2752}: true,
+}
+
+// isScreenshot reports whether a file looks like a screenshot: iOS marks
+// them in the EXIF user comment, and otherwise they have no camera tags
+// and exactly a device's screen size.
+func isScreenshot(x *exif.Data, width, height int) bool {
+	if x != nil && x.Exif != nil {
+		if comment, ok := x.Exif[tagUserComment]; ok && strings.Contains(string(comment.Value), "Screenshot") {
+			return true
+		}
+	}
+	if cameraMake, cameraModel := x.Camera(); cameraMake != "" || cameraModel != "" {
+		return false
+	}
+	if width > height {
+		width, height = height, width
+	}
+	return screenSizes[[2]int{width, height}]
+}
+
+// detectScreenshot checks input for -screenshots png and skip.
+func detectScreenshot(input string) (bool, error) {
+	width, height, _, err := imageLayout(input)
+	if err != nil {
+		return false, err
+	}
+	x, err := readExif(input)
+	if err != nil {
+		return false, err
+	}
+	return isScreenshot(x, width, height), nil
+}
+
+func pngFileName(jpegName string) string {
+	return strings.TrimSuffix(jpegName, filepath.Ext(jpegName)) + ".png"
+}
+
+func isPNGOutput(output string) bool {
+	return strings.EqualFold(filepath.Ext(output), ".png")
+}
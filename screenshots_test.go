@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"heictojpeg/internal/exif"
+)
+
+func TestIsScreenshot(t *testing.T) {
+	camera, _ := exif.Parse(exif.Build([]exif.Tag{exif.ASCII(exif.TagMake, "Apple"), exif.ASCII(exif.TagModel, "iPhone 15 Pro")}, nil))
+	bare, _ := exif.Parse(exif.Build([]exif.Tag{exif.ASCII(0x0131, "17.4")}, nil))
+	marked, _ := exif.Parse(exif.Build(
+		[]exif.Tag{exif.ASCII(exif.TagMake, "Apple")},
+		[]exif.Tag{{ID: tagUserComment, Type: 7, Count: 18, Value: []byte("ASCII\x00\x00\x00Screenshot")}},
+	))
+
+	for _, c := range []struct {
+		name   string
+		exif   *exif.Data
+		w, h   int
+		screen bool
+	}{
+		{"photo at a screen size", camera, 1179, 2556, false},
+		{"no camera, screen size", bare, 1179, 2556, true},
+		{"no EXIF, landscape screen size", nil, 2556, 1179, true},
+		{"no camera, other size", bare, 4032, 3024, false},
+		{"marked by iOS", marked, 4032, 3024, true},
+	} {
+		if got := isScreenshot(c.exif, c.w, c.h); got != c.screen {
+			t.Errorf("%s: isScreenshot = %v, want %v", c.name, got, c.screen)
+		}
+	}
+}
+
+func TestPNGFileName(t *testing.T) {
+	if got := pngFileName("trip/IMG_1.jpg"); got != "trip/IMG_1.png" {
+		t.Errorf("pngFileName = %q", got)
+	}
+}
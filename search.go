@@ -0,0 +1,16 @@
+package main
+
+import "flag"
+
+// searchable makes the new JPEGs show up in the desktop search right
+// away. What that takes depends on the system:
+//
+//   - macOS: the download quarantine is removed from each JPEG and
+//     Spotlight is asked to import the output folder with mdimport.
+//   - Linux: with SELinux enforcing, restorecon gives the output folder
+//     the labels its policy expects, so indexers and file servers may
+//     read the JPEGs.
+//   - Windows: the Zone.Identifier stream is removed from each JPEG and
+//     its "not content indexed" attribute, inherited from a folder left
+//     out of Windows Search, is cleared.
+var searchable = flag.Bool("searchable", false, "make the JPEGs searchable right away: clear the download quarantine, fix SELinux labels and hand the folder to Spotlight")
@@ -0,0 +1,26 @@
+//go:build darwin
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+// prepareForSearch removes the quarantine a JPEG gets when heictojpeg
+// itself was downloaded, which keeps Quick Look and Spotlight away.
+func prepareForSearch(path string) error {
+	err := unix.Removexattr(path, "com.apple.quarantine")
+	if errors.Is(err, unix.ENOATTR) || errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+	return err
+}
+
+// indexFolder asks Spotlight to import the JPEGs in dir now rather than
+// whenever it gets to them.
+func indexFolder(dir string) error {
+	return exec.Command("mdimport", dir).Run()
+}
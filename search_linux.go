@@ -0,0 +1,27 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// selinuxEnforce holds 1 when SELinux is enforcing its policy.
+var selinuxEnforce = "/sys/fs/selinux/enforce"
+
+func prepareForSearch(path string) error {
+	return nil
+}
+
+// indexFolder gives dir and the JPEGs in it their default SELinux labels,
+// which files written to a folder that was moved or mounted from
+// elsewhere lack. Without SELinux there is nothing to do.
+func indexFolder(dir string) error {
+	enforce, err := os.ReadFile(selinuxEnforce)
+	if err != nil || strings.TrimSpace(string(enforce)) != "1" {
+		return nil
+	}
+	return exec.Command("restorecon", "-R", dir).Run()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexFolderWithoutSELinux(t *testing.T) {
+	dir := t.TempDir()
+	enforce := filepath.Join(dir, "enforce")
+	if err := os.WriteFile(enforce, []byte("0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := selinuxEnforce
+	defer func() { selinuxEnforce = saved }()
+
+	// Permissive or missing SELinux must not run restorecon at all, so
+	// this works where it isn't installed.
+	t.Setenv("PATH", "")
+	for _, path := range []string{enforce, filepath.Join(dir, "missing")} {
+		selinuxEnforce = path
+		if err := indexFolder(dir); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
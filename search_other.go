@@ -0,0 +1,11 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+func prepareForSearch(path string) error {
+	return nil
+}
+
+func indexFolder(dir string) error {
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// prepareForSearch removes the mark of the web a JPEG may carry, and
+// clears the attribute that keeps Windows Search from indexing it.
+func prepareForSearch(path string) error {
+	if err := os.Remove(longPath(path) + ":Zone.Identifier"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	name, err := syscall.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return err
+	}
+	attrs, err := syscall.GetFileAttributes(name)
+	if err != nil {
+		return err
+	}
+	const notContentIndexed = 0x2000 // FILE_ATTRIBUTE_NOT_CONTENT_INDEXED
+	if attrs&notContentIndexed == 0 {
+		return nil
+	}
+	return syscall.SetFileAttributes(name, attrs&^notContentIndexed)
+}
+
+// indexFolder has nothing to do: Windows Search picks up new files in
+// indexed folders from the change journal.
+func indexFolder(dir string) error {
+	return nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	subcommands["self-update"] = selfUpdateCommand
+}
+
+// version is the release this binary was built from, set with
+// -ldflags "-X main.version=v1.2.3" when building a release.
+var version = "dev"
+
+// releaseKey is the base64 ed25519 public key release checksums are
+// signed with, set with -ldflags "-X main.releaseKey=..." when building a
+// release. self-update refuses a release without a valid signature, and
+// builds without the key can't self-update at all.
+var releaseKey = ""
+
+// errNoReleaseKey is returned by self-update in builds without releaseKey.
+var errNoReleaseKey = errors.New("this build can't self-update: it has no release-signing key; download the new release instead")
+
+// latestReleaseURL is where self-update finds the latest release; tests
+// point it at a local server.
+var latestReleaseURL = "https://api.github.com/repos/cckalen/heictojpeg/releases/latest"
+
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = "checksums.txt.sig"
+)
+
+type release struct {
+	Tag    string `json:"tag_name"`
+	Assets []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of the asset called name.
+func (r *release) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+// releaseAssetName is the name of the release binary for this platform,
+// e.g. heictojpeg_windows_amd64.exe.
+func releaseAssetName() string {
+	name := "heictojpeg_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func selfUpdateCommand(args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check := fs.Bool("check", false, "only report whether a newer release is available")
+	force := fs.Bool("force", false, "install the latest release even if it isn't newer than this one")
+	fs.Parse(args)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	return selfUpdate(context.Background(), exe, *check, *force)
+}
+
+// selfUpdate replaces the binary at exe with the latest release, after
+// checking the signature of the release's SHA-256 checksums and the
+// download against them.
+func selfUpdate(ctx context.Context, exe string, check, force bool) error {
+	// A replaced binary left behind by the last update on Windows, where
+	// a running program can't be deleted.
+	os.Remove(exe + ".old")
+
+	var r release
+	if err := getJSON(ctx, latestReleaseURL, &r); err != nil {
+		return err
+	}
+	if !force && !newerVersion(r.Tag, version) {
+		fmt.Printf(tr("heictojpeg %s is up to date\n"), version)
+		return nil
+	}
+	if check {
+		fmt.Printf(tr("heictojpeg %s is available (this is %s)\n"), r.Tag, version)
+		return nil
+	}
+	if releaseKey == "" {
+		return errNoReleaseKey
+	}
+
+	name := releaseAssetName()
+	binaryURL, checksumsURL := r.assetURL(name), r.assetURL(checksumsAsset)
+	if binaryURL == "" {
+		return fmt.Errorf("release %s has no %s", r.Tag, name)
+	}
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no %s", r.Tag, checksumsAsset)
+	}
+	checksums, err := getBytes(ctx, checksumsURL)
+	if err != nil {
+		return err
+	}
+	sigURL := r.assetURL(signatureAsset)
+	if sigURL == "" {
+		return fmt.Errorf("release %s has no %s; not installed", r.Tag, signatureAsset)
+	}
+	sig, err := getBytes(ctx, sigURL)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(releaseKey, checksums, sig); err != nil {
+		return err
+	}
+	want, err := findChecksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	// The new binary is written next to the old one so the rename that
+	// replaces it stays on one file system.
+	infof(tr("Downloading heictojpeg %s...\n"), r.Tag)
+	tmp := filepath.Join(filepath.Dir(exe), "."+filepath.Base(exe)+".new")
+	if err := downloadFile(ctx, binaryURL, tmp); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if got, err := fileSHA256(tmp); err != nil {
+		return err
+	} else if got != want {
+		return fmt.Errorf("%s doesn't match its checksum; not installed", name)
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, tmp); err != nil {
+		return err
+	}
+	fmt.Printf(tr("Updated heictojpeg from %s to %s\n"), version, r.Tag)
+	return nil
+}
+
+// replaceExecutable moves next over exe. The running binary is renamed
+// aside first, which Windows allows although it doesn't allow replacing or
+// deleting it.
+func replaceExecutable(exe, next string) error {
+	old := exe + ".old"
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(next, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	os.Remove(old)
+	return nil
+}
+
+func getBytes(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	data, err := getBytes(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	return nil
+}
+
+// findChecksum returns the SHA-256 of name in checksums, in the format of
+// sha256sum: a hex digest, spaces and the file name on each line.
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(checksums)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", checksumsAsset, name)
+}
+
+func verifySignature(key string, message, sig []byte) error {
+	pub, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid release key in this build")
+	}
+	// The signature is accepted raw or base64-encoded.
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		sig = decoded
+	}
+	if !ed25519.Verify(pub, message, sig) {
+		return fmt.Errorf("%s has an invalid signature; not installed", checksumsAsset)
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newerVersion reports whether tag is a later release than current,
+// comparing the numbers of v1.2.3-style versions. Every release is newer
+// than a build that isn't one.
+func newerVersion(tag, current string) bool {
+	t, ok := parseVersion(tag)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := 0; i < len(t) || i < len(c); i++ {
+		var a, b int
+		if i < len(t) {
+			a = t[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// releaseServer serves a release v9.9.9 with binary as this platform's
+// asset and the given checksums and signature.
+func releaseServer(t *testing.T, binary, checksums, sig string) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			fmt.Fprintf(w, `{"tag_name": "v9.9.9", "assets": [
+				{"name": %q, "browser_download_url": "%s/bin"},
+				{"name": "checksums.txt", "browser_download_url": "%s/sums"},
+				{"name": "checksums.txt.sig", "browser_download_url": "%s/sig"}]}`,
+				releaseAssetName(), srv.URL, srv.URL, srv.URL)
+		case "/bin":
+			w.Write([]byte(binary))
+		case "/sums":
+			w.Write([]byte(checksums))
+		case "/sig":
+			w.Write([]byte(sig))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	old := latestReleaseURL
+	latestReleaseURL = srv.URL + "/latest"
+	t.Cleanup(func() { latestReleaseURL = old })
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Testing selfUpdate replacing the binary only when the checksum and
+// signature match
+func TestSelfUpdate(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(k, v string) { releaseKey, version = k, v }(releaseKey, version)
+	version = "v1.0.0"
+	releaseKey = base64.StdEncoding.EncodeToString(pub)
+
+	sums := sha256Hex("new binary") + "  " + releaseAssetName() + "\n" + sha256Hex("other") + "  other.zip\n"
+	sign := func(sums string) string { return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums))) }
+	badSums := strings.Replace(sums, sha256Hex("new binary"), sha256Hex("old binary"), 1)
+	tests := []struct {
+		name      string
+		checksums string
+		sig       string
+		updated   bool
+	}{
+		{"signed", sums, sign(sums), true},
+		{"unsigned", sums, "", false},
+		{"bad checksum", badSums, sign(badSums), false},
+		{"bad signature", sums + "\n", sign(sums), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaseServer(t, "new binary", tt.checksums, tt.sig)
+			exe := filepath.Join(t.TempDir(), "heictojpeg")
+			os.WriteFile(exe, []byte("old binary"), 0755)
+
+			err := selfUpdate(context.Background(), exe, false, false)
+			if tt.updated && err != nil {
+				t.Fatalf("selfUpdate failed: %v", err)
+			}
+			if !tt.updated && err == nil {
+				t.Fatal("selfUpdate succeeded, want an error")
+			}
+			want := "old binary"
+			if tt.updated {
+				want = "new binary"
+			}
+			if data, _ := os.ReadFile(exe); string(data) != want {
+				t.Errorf("Binary = %q, want %q", data, want)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+				t.Errorf("Left %d files next to the binary, want 1", len(entries))
+			}
+		})
+	}
+}
+
+// Testing that a build without a release key refuses to update
+func TestSelfUpdateWithoutReleaseKey(t *testing.T) {
+	defer func(k, v string) { releaseKey, version = k, v }(releaseKey, version)
+	releaseKey, version = "", "v1.0.0"
+	releaseServer(t, "new binary", sha256Hex("new binary")+"  "+releaseAssetName()+"\n", "")
+	exe := filepath.Join(t.TempDir(), "heictojpeg")
+	os.WriteFile(exe, []byte("old binary"), 0755)
+	if err := selfUpdate(context.Background(), exe, false, false); !errors.Is(err, errNoReleaseKey) {
+		t.Fatalf("selfUpdate = %v, want %v", err, errNoReleaseKey)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old binary" {
+		t.Errorf("Binary = %q, want it unchanged", data)
+	}
+}
+
+// Testing selfUpdate leaving a binary of the latest release alone
+func TestSelfUpdateCurrent(t *testing.T) {
+	defer func(v string) { version = v }(version)
+	version = "v9.9.9"
+	releaseServer(t, "new binary", sha256Hex("new binary")+"  "+releaseAssetName()+"\n", "")
+	exe := filepath.Join(t.TempDir(), "heictojpeg")
+	os.WriteFile(exe, []byte("old binary"), 0755)
+	if err := selfUpdate(context.Background(), exe, false, false); err != nil {
+		t.Fatalf("selfUpdate failed: %v", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old binary" {
+		t.Errorf("Binary = %q, want it unchanged", data)
+	}
+}
+
+// Testing newerVersion
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		tag, current string
+		want         bool
+	}{
+		{"v1.2.10", "v1.2.9", true},
+		{"v1.2.9", "v1.2.10", false},
+		{"v1.3", "v1.2.9", true},
+		{"v1.2.0", "v1.2", false},
+		{"v1.2.0", "dev", true},
+		{"nightly", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := newerVersion(tt.tag, tt.current); got != tt.want {
+			t.Errorf("newerVersion(%q, %q) = %v, want %v", tt.tag, tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	listenAddr     = flag.String("listen", "localhost:8080", "address heictojpeg serve listens on")
+	apiKeysFile    = flag.String("api-keys", "", "JSON file of the API keys heictojpeg serve accepts, with each key's rate and size limits; without it no key is needed")
+	tlsCert        = flag.String("tls-cert", "", "certificate file (PEM) for heictojpeg serve to use HTTPS, with -tls-key")
+	tlsKey         = flag.String("tls-key", "", "private key file (PEM) of -tls-cert")
+	maxBody        = flag.String("max-body", "100MB", "largest upload heictojpeg serve accepts from any key")
+	readTimeout    = flag.Duration("read-timeout", time.Minute, "how long heictojpeg serve waits for a request, including its upload")
+	writeTimeout   = flag.Duration("write-timeout", 5*time.Minute, "how long heictojpeg serve may take over a request, from the end of its headers to the end of the response")
+	idleTimeout    = flag.Duration("idle-timeout", 2*time.Minute, "how long heictojpeg serve keeps an idle connection open")
+	trustedProxies = flag.String("trusted-proxies", "", "comma-separated addresses or CIDR ranges of reverse proxies whose X-Forwarded-For heictojpeg serve believes")
+)
+
+// serveCommand is the verb in "heictojpeg serve [options]", which converts
+// HEIC files posted over HTTP.
+const serveCommand = "serve"
+
+// apiKey is an entry of the -api-keys file. Zero limits are unlimited.
+type apiKey struct {
+	Name              string `json:"name"`
+	Key               string `json:"key"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	MaxDimension      int    `json:"max_dimension"`
+	MaxUpload         string `json:"max_upload"`
+
+	maxUploadBytes int64
+	limiter        *rateLimiter
+}
+
+// loadAPIKeys reads the -api-keys file, {"keys": [{"name": ..., "key": ...}]}.
+func loadAPIKeys(path string) ([]*apiKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []*apiKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(file.Keys) == 0 {
+		return nil, fmt.Errorf("%s: no keys", path)
+	}
+	for _, k := range file.Keys {
+		if k.Key == "" || k.Name == "" {
+			return nil, fmt.Errorf("%s: every key needs a name and a key", path)
+		}
+		if k.MaxUpload != "" {
+			if k.maxUploadBytes, err = parseByteSize(k.MaxUpload); err != nil {
+				return nil, fmt.Errorf("%s: %s: max_upload: %v", path, k.Name, err)
+			}
+		}
+		if k.RequestsPerMinute > 0 {
+			k.limiter = newRateLimiter(k.RequestsPerMinute)
+		}
+	}
+	return file.Keys, nil
+}
+
+// rateLimiter is a token bucket refilled at a steady rate, holding at most
+// a minute's worth of requests.
+type rateLimiter struct {
+	mu       sync.Mutex
+	perMin   float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMin: float64(perMinute), tokens: float64(perMinute), lastFill: time.Now()}
+}
+
+// allow takes a token if there is one, or reports how long until there is.
+func (l *rateLimiter) allow(now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.perMin, l.tokens+now.Sub(l.lastFill).Minutes()*l.perMin)
+	l.lastFill = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - l.tokens) / l.perMin * float64(time.Minute))
+}
+
+// server answers POST /convert with the JPEG of the HEIC file in the
+// request body. The query sets the per-file options, e.g. ?quality=90.
+// /healthz and /readyz are for the probes of container platforms.
+type server struct {
+	keys     []*apiKey // nil when no key is needed
+	maxBody  int64     // 0 is unlimited
+	proxies  []*net.IPNet
+	inflight chan struct{} // nil is unlimited
+	ready    readiness
+	mux      *http.ServeMux
+}
+
+func newServer(keys []*apiKey) *server {
+	s := &server{keys: keys, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/convert", s.convert)
+	s.mux.HandleFunc("/healthz", s.healthz)
+	s.mux.HandleFunc("/readyz", s.readyz)
+	return s
+}
+
+// ServeHTTP logs each request, except the probes, with the address of the
+// client it came from.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	infof(tr("%s %s from %s: %d, %s in %s\n"), r.Method, r.URL.Path, s.clientIP(r), rec.status,
+		humanReadableFileSize(rec.written), time.Since(start).Round(time.Millisecond))
+}
+
+// statusRecorder keeps the status and size of a response for the log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.written += int64(n)
+	return n, err
+}
+
+// parseProxies parses -trusted-proxies; a plain address is a range of one.
+func parseProxies(list string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", entry)
+			}
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 128
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (s *server) trusted(ip net.IP) bool {
+	for _, network := range s.proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the address the request came from. Behind trusted proxies
+// it is the last address in X-Forwarded-For that isn't one of them: the
+// addresses before it were written by the client and can't be believed.
+func (s *server) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !s.trusted(ip) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		host = hop.String()
+		if !s.trusted(hop) {
+			break
+		}
+	}
+	return host
+}
+
+// authenticate finds the key of the request, given as a bearer token or in
+// X-API-Key. Every key is compared, in constant time, so the time taken
+// doesn't tell how close a guess was.
+func (s *server) authenticate(r *http.Request) *apiKey {
+	given := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		given = strings.TrimPrefix(auth, "Bearer ")
+	}
+	var found *apiKey
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(k.Key)) == 1 {
+			found = k
+		}
+	}
+	return found
+}
+
+func (s *server) convert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST a HEIC file", http.StatusMethodNotAllowed)
+		return
+	}
+	var key *apiKey
+	if s.keys != nil {
+		if key = s.authenticate(r); key == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or unknown API key", http.StatusUnauthorized)
+			return
+		}
+		if key.limiter != nil {
+			if ok, wait := key.limiter.allow(time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, fmt.Sprintf("over %d requests per minute", key.RequestsPerMinute), http.StatusTooManyRequests)
+				return
+			}
+		}
+	}
+	if !s.acquire() {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, errSaturated.Error(), http.StatusTooManyRequests)
+		return
+	}
+	defer s.release()
+
+	settings := globalSettings()
+	if err := settings.apply(queryOptions(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := withSettings(r.Context(), settings)
+
+	body, limit := r.Body, s.maxBody
+	if key != nil && key.maxUploadBytes > 0 && (limit == 0 || key.maxUploadBytes < limit) {
+		limit = key.maxUploadBytes
+	}
+	if limit > 0 {
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	input, err := saveUpload(body)
+	if input != "" {
+		defer os.Remove(input)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("the file is over %s", humanReadableFileSize(limit)), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if key != nil && key.MaxDimension > 0 {
+		width, height, _, err := imageLayout(input)
+		if err == nil && (width > key.MaxDimension || height > key.MaxDimension) {
+			http.Error(w, fmt.Sprintf("the image is %dx%d, over %d pixels", width, height, key.MaxDimension), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+
+	output := input + ".jpg"
+	defer os.Remove(output)
+	cached, err := convertCached(ctx, "upload from "+s.clientIP(r), input, output)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	f, err := os.Open(output)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.FormatInt(getFileSize(output), 10))
+	if cached {
+		w.Header().Set("X-Heictojpeg-Cache", "hit")
+	}
+	io.Copy(w, f)
+}
+
+// queryOptions turns the query of r into per-file options, with numbers
+// as numbers.
+func queryOptions(r *http.Request) map[string]interface{} {
+	options := make(map[string]interface{})
+	for name, values := range r.URL.Query() {
+		value := values[len(values)-1]
+		if n, err := strconv.Atoi(value); err == nil {
+			options[name] = n
+		} else {
+			options[name] = value
+		}
+	}
+	return options
+}
+
+// saveUpload writes body to a temporary file, so the decoder can seek in
+// it and the cache can hash it.
+func saveUpload(body io.Reader) (string, error) {
+	f, err := os.CreateTemp("", "heictojpeg-*.heic")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return f.Name(), err
+}
+
+// runServer serves until ctx is cancelled. The requests get their context
+// from ctx, with its cache and history, and are cancelled with it or when
+// the client goes away.
+func runServer(ctx context.Context) error {
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return errors.New("-tls-cert and -tls-key go together")
+	}
+	var keys []*apiKey
+	if *apiKeysFile != "" {
+		var err error
+		if keys, err = loadAPIKeys(*apiKeysFile); err != nil {
+			return err
+		}
+	}
+	handler := newServer(keys)
+	var err error
+	if handler.maxBody, err = parseByteSize(*maxBody); err != nil {
+		return fmt.Errorf("-max-body %q: %v", *maxBody, err)
+	}
+	if handler.proxies, err = parseProxies(*trustedProxies); err != nil {
+		return fmt.Errorf("-trusted-proxies: %v", err)
+	}
+	if *maxInflight > 0 {
+		handler.inflight = make(chan struct{}, *maxInflight)
+	}
+	srv := &http.Server{
+		Addr:              *listenAddr,
+		Handler:           handler,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+	go func() {
+		if err := selfTest(ctx); err != nil {
+			fmt.Printf(tr("Self-test failed, /readyz stays unready: %v\n"), err)
+			handler.ready.set(false, err)
+			return
+		}
+		handler.ready.set(true, nil)
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf(tr("Listening on %s\n"), *listenAddr)
+	if *tlsCert != "" {
+		err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKeys(t *testing.T, file string) []*apiKey {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+		t.Fatalf("Failed to write keys: %v", err)
+	}
+	keys, err := loadAPIKeys(path)
+	if err != nil {
+		t.Fatalf("loadAPIKeys failed: %v", err)
+	}
+	return keys
+}
+
+func post(s *server, query, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/convert"+query, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+// Testing the API key checks and limits of the server
+func TestServerLimits(t *testing.T) {
+	s := newServer(testKeys(t, `{"keys": [
+		{"name": "photos", "key": "k1", "requests_per_minute": 2},
+		{"name": "small", "key": "k2", "max_upload": "1KB"}
+	]}`))
+
+	tests := []struct {
+		name, query, key, body string
+		want                   int
+	}{
+		{"no key", "", "", "mock", http.StatusUnauthorized},
+		{"unknown key", "", "nope", "mock", http.StatusUnauthorized},
+		{"bad option", "?quality=500", "k2", "mock", http.StatusBadRequest},
+		{"too large", "", "k2", strings.Repeat("x", 2000), http.StatusRequestEntityTooLarge},
+		{"not a HEIC", "", "k1", "mock", http.StatusUnprocessableEntity},
+		{"second request", "", "k1", "mock", http.StatusUnprocessableEntity},
+		{"over the rate", "", "k1", "mock", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		w := post(s, tt.query, tt.key, tt.body)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+	if w := post(s, "", "k1", "mock"); w.Header().Get("Retry-After") == "" {
+		t.Error("A rate-limited request should get Retry-After")
+	}
+
+	open := newServer(nil)
+	if w := post(open, "", "", "mock"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Without -api-keys no key should be needed: %d", w.Code)
+	}
+}
+
+// Testing that the bucket refills at the key's rate
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(60)
+	now := l.lastFill
+	for i := 0; i < 60; i++ {
+		if ok, _ := l.allow(now); !ok {
+			t.Fatalf("Request %d should be allowed", i)
+		}
+	}
+	ok, wait := l.allow(now)
+	if ok || wait != time.Second {
+		t.Errorf("allow = %v, %v; want false, 1s", ok, wait)
+	}
+	if ok, _ := l.allow(now.Add(time.Second)); !ok {
+		t.Error("A token should be back after a second")
+	}
+}
+
+// Testing the checks of the key file
+func TestLoadAPIKeys(t *testing.T) {
+	for _, file := range []string{`{"keys": []}`, `{"keys": [{"key": "k"}]}`, `{"keys": [{"name": "a", "key": "k", "max_upload": "big"}]}`} {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		os.WriteFile(path, []byte(file), 0600)
+		if _, err := loadAPIKeys(path); err == nil {
+			t.Errorf("loadAPIKeys(%s) should fail", file)
+		}
+	}
+}
+
+// Testing which X-Forwarded-For address is believed
+func TestClientIP(t *testing.T) {
+	proxies, err := parseProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatalf("parseProxies failed: %v", err)
+	}
+	s := &server{proxies: proxies}
+	tests := []struct {
+		remote, forwarded, want string
+	}{
+		{"203.0.113.5:1234", "198.51.100.7", "203.0.113.5"},
+		{"192.0.2.1:1234", "198.51.100.7", "198.51.100.7"},
+		{"192.0.2.1:1234", "6.6.6.6, 198.51.100.7, 10.1.2.3", "198.51.100.7"},
+		{"10.0.0.2:1234", "", "10.0.0.2"},
+		{"10.0.0.2:1234", "junk", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/convert", nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := s.clientIP(r); got != tt.want {
+			t.Errorf("clientIP(%s, %q) = %s, want %s", tt.remote, tt.forwarded, got, tt.want)
+		}
+	}
+	if _, err := parseProxies("10.0.0.0/33"); err == nil {
+		t.Error("An invalid range should be rejected")
+	}
+}
+
+// Testing that -max-body applies to every key
+func TestServerMaxBody(t *testing.T) {
+	s := newServer(nil)
+	s.maxBody = 100
+	if w := post(s, "", "", strings.Repeat("x", 101)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+// Testing the probes and the -max-inflight guard
+func TestServerProbes(t *testing.T) {
+	s := newServer(nil)
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d", code)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz before the self-test = %d", code)
+	}
+	if err := selfTest(context.Background()); err != nil {
+		t.Fatalf("selfTest failed: %v", err)
+	}
+	s.ready.set(true, nil)
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz after the self-test = %d", code)
+	}
+
+	s.inflight = make(chan struct{}, 1)
+	s.inflight <- struct{}{}
+	if w := post(s, "", "", "mock"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("A saturated server answered %d", w.Code)
+	}
+	<-s.inflight
+	if w := post(s, "", "", "mock"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("A free slot should let the request through: %d", w.Code)
+	}
+}
+
+// Testing that a -self-test sample that doesn't decode fails the self-test
+func TestSelfTestSample(t *testing.T) {
+	defer func(path string) { *selfTestSample = path }(*selfTestSample)
+	*selfTestSample = filepath.Join(t.TempDir(), "sample.heic")
+	os.WriteFile(*selfTestSample, []byte("mock content"), 0644)
+	if err := selfTest(context.Background()); err == nil {
+		t.Error("selfTest should fail on an invalid sample")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// serviceLabel names the service, unit or task heictojpeg service
+// registers.
+const serviceLabel = "heictojpeg"
+
+func init() {
+	subcommands["service"] = serviceCommand
+}
+
+// serviceCommand registers heictojpeg to run in the background from login
+// or boot on: a systemd user unit on Linux, a launchd agent on macOS and a
+// scheduled task started at logon on Windows. The options after install
+// are the ones the service runs with; they need -schedule, here or in the
+// config file, or it would convert once and stop.
+func serviceCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: heictojpeg service install [options] | uninstall | status")
+	}
+	switch args[0] {
+	case "install":
+		command, err := serviceCommandLine(args[1:])
+		if err != nil {
+			return err
+		}
+		if err := installService(command); err != nil {
+			return err
+		}
+		fmt.Printf(tr("Installed the %s service, running: %s\n"), serviceLabel, strings.Join(command, " "))
+		return nil
+	case "uninstall":
+		if err := uninstallService(); err != nil {
+			return err
+		}
+		fmt.Printf(tr("Removed the %s service.\n"), serviceLabel)
+		return nil
+	case "status":
+		return printServiceStatus()
+	}
+	return fmt.Errorf("unknown service command %q: must be install, uninstall or status", args[0])
+}
+
+// serviceCommandLine checks the options the service will run with and
+// returns its command line. Without -source, the service converts the
+// current folder, like the command would.
+func serviceCommandLine(options []string) ([]string, error) {
+	flag.CommandLine.Parse(options)
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
+	if *schedule == "" {
+		return nil, errors.New(tr("the service needs -schedule, or it would convert once and stop"))
+	}
+	if _, err := parseSchedule(*schedule); err != nil {
+		return nil, fmt.Errorf(tr("Invalid -schedule %q: %v"), *schedule, err)
+	}
+	exe, err := executablePath()
+	if err != nil {
+		return nil, err
+	}
+	command := append([]string{exe}, options...)
+	if *sourceDir == "" && flag.NArg() == 0 {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		command = append(command, "-source", dir)
+	}
+	return command, nil
+}
+
+// serviceEnvironment passes the config file chosen when installing on to
+// the service.
+func serviceEnvironment() map[string]string {
+	path, err := configPath()
+	if err != nil {
+		return nil
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return map[string]string{configEnv: path}
+}
+
+// systemdUnit is the user unit running command.
+func systemdUnit(command []string, env map[string]string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	var b strings.Builder
+	b.WriteString("[Unit]\nDescription=heictojpeg HEIC to JPEG conversion\n\n[Service]\n")
+	for _, k := range sortedKeys(env) {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(k+"="+env[k]))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\nRestart=on-failure\nRestartSec=1min\n\n[Install]\nWantedBy=default.target\n", strings.Join(quoted, " "))
+	return b.String()
+}
+
+// systemdQuote quotes s for a unit file, where % starts a specifier.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(s)
+	return `"` + s + `"`
+}
+
+// launchdPlist is the launch agent running command, started at login and
+// again if it exits with an error.
+func launchdPlist(label string, command []string, env map[string]string, logPath string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + xmlEscape(label) + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range command {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+	for _, k := range sortedKeys(env) {
+		fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", xmlEscape(k), xmlEscape(env[k]))
+	}
+	b.WriteString(`	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>` + xmlEscape(logPath) + `</string>
+	<key>StandardErrorPath</key>
+	<string>` + xmlEscape(logPath) + `</string>
+</dict>
+</plist>
+`)
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
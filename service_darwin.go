@@ -0,0 +1,77 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// launchdLabel is the launch agent's label, in reverse DNS form.
+const launchdLabel = "com.github.cckalen." + serviceLabel
+
+func launchAgentPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+}
+
+// launchdDomain is the GUI session of the user, where launch agents run.
+func launchdDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+func installService(command []string) error {
+	path, err := launchAgentPath()
+	if err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	logPath := filepath.Join(home, "Library", "Logs", serviceLabel+".log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// A previous version of the agent has to be unloaded to be replaced.
+	launchctl("bootout", launchdDomain()+"/"+launchdLabel)
+	if err := os.WriteFile(path, []byte(launchdPlist(launchdLabel, command, serviceEnvironment(), logPath)), 0644); err != nil {
+		return err
+	}
+	return launchctl("bootstrap", launchdDomain(), path)
+}
+
+func uninstallService() error {
+	path, err := launchAgentPath()
+	if err != nil {
+		return err
+	}
+	launchctl("bootout", launchdDomain()+"/"+launchdLabel)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func printServiceStatus() error {
+	cmd := exec.Command("launchctl", "print", launchdDomain()+"/"+launchdLabel)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if cmd.Run() != nil {
+		fmt.Printf(tr("The %s service isn't installed.\n"), serviceLabel)
+	}
+	return nil
+}
+
+func launchctl(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s failed: %v: %s", args[0], err, out)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// serviceUnitPath is the systemd user unit, which needs no root. User
+// units stop at logout unless lingering is enabled for the user.
+func serviceUnitPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", serviceLabel+".service"), nil
+}
+
+func installService(command []string) error {
+	path, err := serviceUnitPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(systemdUnit(command, serviceEnvironment())), 0644); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", serviceLabel+".service")
+}
+
+func uninstallService() error {
+	path, err := serviceUnitPath()
+	if err != nil {
+		return err
+	}
+	// Disabling fails if it was never installed; removing the file is
+	// what counts.
+	systemctl("disable", "--now", serviceLabel+".service")
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+func printServiceStatus() error {
+	cmd := exec.Command("systemctl", "--user", "status", "--no-pager", serviceLabel+".service")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	// systemctl status exits with 3 for a stopped service, which isn't an
+	// error here.
+	cmd.Run()
+	return nil
+}
+
+func systemctl(args ...string) error {
+	args = append([]string{"--user"}, args...)
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s failed: %v: %s", args[1], err, out)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "errors"
+
+var errNoServiceManager = errors.New("heictojpeg service supports systemd, launchd and the Windows task scheduler; use -schedule from your system's own startup scripts")
+
+func installService(command []string) error {
+	return errNoServiceManager
+}
+
+func uninstallService() error {
+	return errNoServiceManager
+}
+
+func printServiceStatus() error {
+	return errNoServiceManager
+}
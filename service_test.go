@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit([]string{"/opt/heic to jpeg/heictojpeg", "-schedule", "0 2 * * *", "-rename", `100%_"$name"`}, map[string]string{configEnv: "/home/me/.config/heictojpeg/config.json"})
+	for _, want := range []string{
+		`ExecStart="/opt/heic to jpeg/heictojpeg" "-schedule" "0 2 * * *" "-rename" "100%%_\"$$name\""`,
+		`Environment="HEICTOJPEG_CONFIG=/home/me/.config/heictojpeg/config.json"`,
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit lacks %s:\n%s", want, unit)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := launchdPlist("com.example.heictojpeg", []string{"/usr/local/bin/heictojpeg", "-schedule", "@daily", "-source", "/Users/me/R&D <photos>"}, nil, "/Users/me/Library/Logs/heictojpeg.log")
+	dec := xml.NewDecoder(strings.NewReader(plist))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid XML: %v\n%s", err, plist)
+		}
+	}
+	if !strings.Contains(plist, "<string>/Users/me/R&amp;D &lt;photos&gt;</string>") {
+		t.Errorf("the source isn't escaped:\n%s", plist)
+	}
+}
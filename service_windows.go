@@ -0,0 +1,49 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// installService registers a scheduled task started at logon rather than
+// a Windows service: a service has to answer the service control manager,
+// and a task runs as the user, with their config and network drives,
+// without administrator rights.
+func installService(command []string) error {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = syscall.EscapeArg(arg)
+	}
+	if err := schtasks("/Create", "/TN", serviceLabel, "/TR", strings.Join(quoted, " "), "/SC", "ONLOGON", "/RL", "LIMITED", "/F"); err != nil {
+		return err
+	}
+	return schtasks("/Run", "/TN", serviceLabel)
+}
+
+func uninstallService() error {
+	// Ending fails when the task isn't running, which is fine.
+	schtasks("/End", "/TN", serviceLabel)
+	return schtasks("/Delete", "/TN", serviceLabel, "/F")
+}
+
+func printServiceStatus() error {
+	cmd := exec.Command("schtasks", "/Query", "/TN", serviceLabel, "/V", "/FO", "LIST")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if cmd.Run() != nil {
+		fmt.Printf(tr("The %s service isn't installed.\n"), serviceLabel)
+	}
+	return nil
+}
+
+func schtasks(args ...string) error {
+	out, err := exec.Command("schtasks", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("schtasks %s failed: %v: %s", args[0], err, out)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"heictojpeg/convert"
+)
+
+// settings are the options that can differ from file to file, e.g. by
+// folder. Everything else stays global.
+type settings struct {
+	Quality     int
+	MaxSize     int
+	Metadata    string
+	AutoQuality bool   // choose Quality per image, see -auto-quality
+	Crop        string // W:H aspect ratio, see -crop
+	CropFocus   string
+}
+
+// globalSettings are the settings from the command line, preset and
+// config file.
+func globalSettings() settings {
+	return settings{Quality: *quality, MaxSize: *maxSize, Metadata: *metadata, AutoQuality: *autoQuality, Crop: *cropAspect, CropFocus: *cropFocus}
+}
+
+// options are the conversion options of s and the global flags.
+func (s settings) options() convert.Options {
+	return convert.Options{
+		Quality:     s.Quality,
+		MaxSize:     s.MaxSize,
+		Metadata:    s.Metadata,
+		AutoQuality: s.AutoQuality,
+		TargetSSIM:  *targetSSIM,
+		Crop:        s.Crop,
+		CropFocus:   s.CropFocus,
+		ColorTarget: *colorTarget,
+		Alpha:       *alphaPolicy,
+		BestFrame:   *bestFrame,
+		VerifyGrid:  *verifyGrid,
+		LowMemory:   *lowMemory,
+		BitDepth:    *bitDepth,
+		Timeout:     *fileTimeout,
+	}
+}
+
+// key identifies the output s makes of a file, along with the global
+// options that change it too, so -history and -cache reuse only a JPEG
+// made the same way. A new option that changes the output belongs here.
+func (s settings) key() string {
+	data, _ := json.Marshal(struct {
+		settings
+		TargetSSIM    float64
+		BestFrame     bool
+		ColorTarget   string
+		ConvertToSRGB bool
+		BitDepth      string
+		DeepFormat    string
+		Alpha         string
+		Screenshots   string
+		Repair        bool
+		Sidecars      bool
+	}{s, *targetSSIM, *bestFrame, *colorTarget, *convertToSRGB, *bitDepth, *deepFormat, *alphaPolicy, *screenshots, *repair, *sidecars})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type settingsKey struct{}
+
+func withSettings(ctx context.Context, s settings) context.Context {
+	return context.WithValue(ctx, settingsKey{}, s)
+}
+
+// settingsFrom returns the settings for the file being converted under
+// ctx, falling back to the global ones.
+func settingsFrom(ctx context.Context) settings {
+	if s, ok := ctx.Value(settingsKey{}).(settings); ok {
+		return s
+	}
+	return globalSettings()
+}
+
+// customPresets are the presets defined in the config file, kept for
+// the overrides that name a preset.
+var customPresets map[string]map[string]interface{}
+
+// perFileOptions lists the options an override can set.
+var perFileOptions = []string{"crop", "crop-focus", "max-size", "metadata", "preset", "quality"}
+
+// apply sets the options of an override, e.g. {"quality": 95}. A preset
+// is applied first, so the other options in the same override win.
+func (s *settings) apply(options map[string]interface{}) error {
+	if name, ok := options["preset"]; ok {
+		values, ok := customPresets[fmt.Sprint(name)]
+		if !ok {
+			values, ok = presets[fmt.Sprint(name)]
+		}
+		if !ok {
+			return fmt.Errorf("unknown preset %q", name)
+		}
+		if _, nested := values["preset"]; nested {
+			return fmt.Errorf("preset %s can't name another preset", name)
+		}
+		if err := s.apply(values); err != nil {
+			return fmt.Errorf("preset %s: %v", name, err)
+		}
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := options[name]
+		switch name {
+		case "preset":
+		case "quality":
+			n, ok := wholeNumber(value)
+			if !ok || n < 1 || n > 100 {
+				return fmt.Errorf("quality %v: must be between 1 and 100", value)
+			}
+			s.Quality = n
+		case "max-size":
+			n, ok := wholeNumber(value)
+			if !ok || n < 0 {
+				return fmt.Errorf("max-size %v: must be 0 or more pixels", value)
+			}
+			s.MaxSize = n
+		case "metadata":
+			policy := fmt.Sprint(value)
+			if !metadataPolicies[policy] {
+				return fmt.Errorf("metadata %q: must be keep or strip", policy)
+			}
+			s.Metadata = policy
+		case "crop":
+			aspect := fmt.Sprint(value)
+			if aspect != "" {
+				if _, _, err := convert.ParseAspect(aspect); err != nil {
+					return fmt.Errorf("crop %v", err)
+				}
+			}
+			s.Crop = aspect
+		case "crop-focus":
+			focus := fmt.Sprint(value)
+			if !cropFocuses[focus] {
+				return fmt.Errorf("crop-focus %q: must be center or subject", focus)
+			}
+			s.CropFocus = focus
+		default:
+			return fmt.Errorf("unknown option %q (can set %s)", name, strings.Join(perFileOptions, ", "))
+		}
+	}
+	return nil
+}
+
+// wholeNumber accepts the numbers of Go literals and decoded JSON.
+func wholeNumber(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), n == float64(int(n))
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+)
+
+var settle = flag.Duration("settle", 0, "convert a file only once its size and modification time have stayed the same this long, and on Windows no other program has it open, e.g. 5s for folders iCloud or Dropbox sync into, which write photos bit by bit (0 converts files as they are)")
+
+// settleChecks is how many times per -settle a changing file is looked at.
+const settleChecks = 4
+
+// settlePeriods is how many -settle periods a file may keep changing
+// before it's skipped, to be converted by a later run.
+const settlePeriods = 5
+
+// waitSettled waits until the file at path has stopped changing for
+// period. A file last written longer ago than that is settled at once, so
+// only the files still arriving hold up a run.
+func waitSettled(ctx context.Context, path string, period time.Duration) error {
+	if period <= 0 {
+		return nil
+	}
+	info, err := os.Stat(longPath(path))
+	if err != nil {
+		return err
+	}
+	unchangedSince := info.ModTime()
+	if unchangedSince.After(time.Now()) {
+		// The clock of a network share can be ahead of ours.
+		unchangedSince = time.Now()
+	}
+	deadline := time.Now().Add(settlePeriods * period)
+	for {
+		if time.Since(unchangedSince) >= period && !openedElsewhere(path) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return &skipReason{"still being written"}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(period / settleChecks):
+		}
+		current, err := os.Stat(longPath(path))
+		if err != nil {
+			return err
+		}
+		if current.Size() != info.Size() || !current.ModTime().Equal(info.ModTime()) {
+			info, unchangedSince = current, time.Now()
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitSettled(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	old := filepath.Join(dir, "old.heic")
+	os.WriteFile(old, []byte("heic"), 0644)
+	os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	started := time.Now()
+	if err := waitSettled(ctx, old, time.Minute); err != nil || time.Since(started) > time.Second {
+		t.Errorf("a file unchanged for an hour: %v after %v", err, time.Since(started))
+	}
+
+	fresh := filepath.Join(dir, "fresh.heic")
+	os.WriteFile(fresh, []byte("heic"), 0644)
+	started = time.Now()
+	if err := waitSettled(ctx, fresh, 100*time.Millisecond); err != nil || time.Since(started) < 80*time.Millisecond {
+		t.Errorf("a file just written: %v after %v", err, time.Since(started))
+	}
+
+	// A file that keeps growing is skipped after a few periods.
+	growing := filepath.Join(dir, "growing.heic")
+	os.WriteFile(growing, []byte("h"), 0644)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			f, err := os.OpenFile(growing, os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return
+			}
+			f.WriteString("eic")
+			f.Close()
+		}
+	}()
+	err := waitSettled(ctx, growing, 50*time.Millisecond)
+	if reason, ok := skippedBy(err); !ok || reason != "still being written" {
+		t.Errorf("a growing file: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := waitSettled(cancelled, fresh, time.Minute); err == nil {
+		t.Error("waited on after the run was cancelled")
+	}
+	if err := waitSettled(ctx, filepath.Join(dir, "gone.heic"), time.Second); err == nil {
+		t.Error("a missing file settled")
+	}
+	if err := waitSettled(ctx, filepath.Join(dir, "gone.heic"), 0); err != nil {
+		t.Errorf("-settle 0 looked at the file: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// openedElsewhere can't tell on Unix, where opening a file never waits
+// for other programs to close it.
+func openedElsewhere(path string) bool {
+	return false
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+const errorSharingViolation syscall.Errno = 32
+
+// openedElsewhere reports whether another program, such as a sync client
+// still downloading it, has the file open: opening it without sharing
+// fails then.
+func openedElsewhere(path string) bool {
+	name, err := syscall.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return false
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ, 0, nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return err == errorSharingViolation
+	}
+	syscall.CloseHandle(h)
+	return false
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const shellMenuLabel = "Convert to JPEG"
+
+func init() {
+	subcommands["install-shell-integration"] = installShellIntegration
+	subcommands["uninstall-shell-integration"] = uninstallShellIntegration
+}
+
+func installShellIntegration(args []string) error {
+	exe, err := executablePath()
+	if err != nil {
+		return err
+	}
+	if err := installShellMenu(exe); err != nil {
+		return err
+	}
+	fmt.Printf(tr("Added %q to the context menu for .heic files and folders.\n"), shellMenuLabel)
+	return nil
+}
+
+func uninstallShellIntegration(args []string) error {
+	if err := uninstallShellMenu(); err != nil {
+		return err
+	}
+	fmt.Printf(tr("Removed %q from the context menu.\n"), shellMenuLabel)
+	return nil
+}
+
+// executablePath is the binary the menu entries will launch, so it should
+// stay where it is after installing.
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
@@ -0,0 +1,41 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Linux desktops have no common context-menu API, so install a Nautilus
+// script, which shows up under "Scripts" in the Files right-click menu.
+func nautilusScriptPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "nautilus", "scripts", shellMenuLabel), nil
+}
+
+func installShellMenu(exe string) error {
+	path, err := nautilusScriptPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	script := "#!/bin/sh\nexec " + shellQuote(exe) + " -wait \"$@\"\n"
+	return os.WriteFile(path, []byte(script), 0755)
+}
+
+func uninstallShellMenu() error {
+	path, err := nautilusScriptPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
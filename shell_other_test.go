@@ -0,0 +1,30 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// Testing the Nautilus script install and uninstall
+func TestShellMenuNautilus(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := installShellMenu("/opt/heic to jpeg/heictojpeg"); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	path, _ := nautilusScriptPath()
+	script, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(script), `'/opt/heic to jpeg/heictojpeg' -wait "$@"`) {
+		t.Fatalf("Unexpected script %q: %v", script, err)
+	}
+
+	if err := uninstallShellMenu(); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Script should be removed")
+	}
+}
@@ -0,0 +1,57 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Entries go under HKCU so installing doesn't need administrator rights.
+const shellKeyName = "heictojpeg"
+
+var shellMenuRoots = []string{
+	`HKCU\Software\Classes\SystemFileAssociations\.heic\shell\`,
+	`HKCU\Software\Classes\Directory\shell\`,
+	`HKCU\Software\Classes\Directory\Background\shell\`,
+}
+
+func installShellMenu(exe string) error {
+	// Explorer starts one process per selected file, so those wait for the
+	// folder's lock instead of failing. %V is the folder for background clicks.
+	commands := []string{
+		fmt.Sprintf(`"%s" -wait "%%1"`, exe),
+		fmt.Sprintf(`"%s" "%%1"`, exe),
+		fmt.Sprintf(`"%s" "%%V"`, exe),
+	}
+
+	for i, root := range shellMenuRoots {
+		key := root + shellKeyName
+		if err := reg("add", key, "/ve", "/d", shellMenuLabel, "/f"); err != nil {
+			return err
+		}
+		if err := reg("add", key, "/v", "Icon", "/d", exe, "/f"); err != nil {
+			return err
+		}
+		if err := reg("add", key+`\command`, "/ve", "/d", commands[i], "/f"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func uninstallShellMenu() error {
+	for _, root := range shellMenuRoots {
+		// A missing key just means it was never installed.
+		reg("delete", root+shellKeyName, "/f")
+	}
+	return nil
+}
+
+func reg(args ...string) error {
+	out, err := exec.Command("reg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("reg %s failed: %v: %s", args[0], err, out)
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func init() {
+	subcommands["completion"] = completionCommand
+}
+
+// flagChoices are the values offered after the options that take one of a
+// fixed set. -preset is added from the presets, including the config file's.
+var flagChoices = map[string][]string{
+	"crop-focus":    {"center", "subject"},
+	"dedupe":        {"skip", "link"},
+	"lang":          {"en", "es", "fr", "de"},
+	"metadata":      {"keep", "strip"},
+	"order":         {"name", "size-asc", "size-desc", "mtime"},
+	"output":        {"text", "ndjson"},
+	"quarantine":    {"copy", "move"},
+	"sanitize":      {"fat32", "exfat"},
+	"screenshots":   {"jpeg", "png", "skip"},
+	"sort":          {"path", "size", "duration", "status"},
+	"space-check":   {"refuse", "warn", "off"},
+	"symlink-names": {"link", "target"},
+	"verbosity":     {"quiet", "normal", "verbose", "debug"},
+	"watch-mode":    {"auto", "notify", "poll"},
+}
+
+// pathFlags are the options whose value is a file or folder, so paths are
+// completed after them. Other options with a value get no suggestions.
+var pathFlags = map[string]bool{
+	"api-keys":         true,
+	"archive-dir":      true,
+	"cache":            true,
+	"collect-failures": true,
+	"compare-dir":      true,
+	"dedupe-library":   true,
+	"download-dir":     true,
+	"ffmpeg":           true,
+	"files":            true,
+	"out":              true,
+	"self-test":        true,
+	"source":           true,
+	"tls-cert":         true,
+	"tls-key":          true,
+}
+
+// completionWord matches the words that can go into a script unquoted;
+// presets from the config file with other names are left out.
+var completionWord = regexp.MustCompile(`^[A-Za-z0-9_.:+-]+$`)
+
+func completionCommand(args []string) error {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg completion bash|zsh|fish|powershell")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	script, err := completionScript(fs.Arg(0), completionSpec(flag.CommandLine, configuredPresets()))
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.WriteString(script)
+	return err
+}
+
+// configuredPresets returns the presets of the config file, or none if it
+// can't be read; a broken config file shouldn't break tab completion.
+func configuredPresets() map[string]map[string]interface{} {
+	path, err := configPath()
+	if err != nil {
+		return nil
+	}
+	config, err := readConfig(path)
+	if err != nil {
+		return nil
+	}
+	custom, _ := configPresets(config)
+	return custom
+}
+
+type completionFlag struct {
+	Name    string
+	Usage   string
+	Bool    bool     // takes no value
+	Path    bool     // takes a file or folder
+	Choices []string // takes one of these
+}
+
+type completionTable struct {
+	Commands []string
+	Flags    []completionFlag
+}
+
+// completionSpec lists the subcommands and the options of fs for the
+// completion scripts.
+func completionSpec(fs *flag.FlagSet, custom map[string]map[string]interface{}) completionTable {
+	spec := completionTable{Commands: []string{convertCommand, workerCommand, serveCommand, botCommand, mailCommand, syncCommand}}
+	for name := range subcommands {
+		if !strings.HasPrefix(name, "-") {
+			spec.Commands = append(spec.Commands, name)
+		}
+	}
+	sort.Strings(spec.Commands)
+
+	fs.VisitAll(func(f *flag.Flag) {
+		cf := completionFlag{Name: f.Name, Usage: f.Usage, Path: pathFlags[f.Name], Choices: flagChoices[f.Name]}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.Bool = true
+		}
+		if f.Name == "preset" {
+			for _, name := range presetNames(custom) {
+				if completionWord.MatchString(name) {
+					cf.Choices = append(cf.Choices, name)
+				}
+			}
+		}
+		spec.Flags = append(spec.Flags, cf)
+	})
+	return spec
+}
+
+func completionScript(shell string, spec completionTable) (string, error) {
+	switch shell {
+	case "bash":
+		return bashCompletion(spec), nil
+	case "zsh":
+		return zshCompletion(spec), nil
+	case "fish":
+		return fishCompletion(spec), nil
+	case "powershell":
+		return powershellCompletion(spec), nil
+	}
+	return "", fmt.Errorf("unknown shell %q: must be bash, zsh, fish or powershell", shell)
+}
+
+func bashCompletion(spec completionTable) string {
+	var b strings.Builder
+	var flags, valued []string
+	b.WriteString("# bash completion for heictojpeg; load with: source <(heictojpeg completion bash)\n")
+	b.WriteString("_heictojpeg() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, f := range spec.Flags {
+		flags = append(flags, "-"+f.Name)
+		switch {
+		case len(f.Choices) > 0:
+			fmt.Fprintf(&b, "    -%s)\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return ;;\n", f.Name, strings.Join(f.Choices, " "))
+		case f.Path:
+			fmt.Fprintf(&b, "    -%s)\n        COMPREPLY=($(compgen -f -- \"$cur\"))\n        return ;;\n", f.Name)
+		case !f.Bool:
+			valued = append(valued, "-"+f.Name)
+		}
+	}
+	if len(valued) > 0 {
+		fmt.Fprintf(&b, "    %s)\n        return ;;\n", strings.Join(valued, "|"))
+	}
+	b.WriteString("    esac\n")
+	fmt.Fprintf(&b, "    if [[ $cur == -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return\n    fi\n", strings.Join(flags, " "))
+	fmt.Fprintf(&b, "    if [[ $COMP_CWORD -eq 1 ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n    fi\n", strings.Join(spec.Commands, " "))
+	b.WriteString("    COMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o filenames -F _heictojpeg heictojpeg\n")
+	return b.String()
+}
+
+// zshDescription escapes a usage text for an _arguments description.
+var zshDescription = strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`)
+
+func zshCompletion(spec completionTable) string {
+	var b strings.Builder
+	b.WriteString("#compdef heictojpeg\n")
+	b.WriteString("# zsh completion for heictojpeg; load with: source <(heictojpeg completion zsh)\n")
+	b.WriteString("_heictojpeg_targets() {\n")
+	fmt.Fprintf(&b, "    _alternative 'commands:command:(%s)' 'files:file:_files'\n", strings.Join(spec.Commands, " "))
+	b.WriteString("}\n")
+	b.WriteString("_heictojpeg() {\n")
+	b.WriteString("    _arguments -S \\\n")
+	for _, f := range spec.Flags {
+		fmt.Fprintf(&b, "        '-%s[%s]", f.Name, zshDescription.Replace(f.Usage))
+		switch {
+		case len(f.Choices) > 0:
+			fmt.Fprintf(&b, ":%s:(%s)", f.Name, strings.Join(f.Choices, " "))
+		case f.Path:
+			fmt.Fprintf(&b, ":%s:_files", f.Name)
+		case !f.Bool:
+			fmt.Fprintf(&b, ":%s: ", f.Name)
+		}
+		b.WriteString("' \\\n")
+	}
+	b.WriteString("        '1: :_heictojpeg_targets' \\\n")
+	b.WriteString("        '*: :_files'\n")
+	b.WriteString("}\n")
+	b.WriteString("compdef _heictojpeg heictojpeg\n")
+	return b.String()
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func fishCompletion(spec completionTable) string {
+	var b strings.Builder
+	b.WriteString("# fish completion for heictojpeg; load with: heictojpeg completion fish | source\n")
+	fmt.Fprintf(&b, "complete -c heictojpeg -n __fish_use_subcommand -a %s\n", fishQuote(strings.Join(spec.Commands, " ")))
+	for _, f := range spec.Flags {
+		fmt.Fprintf(&b, "complete -c heictojpeg -o %s -d %s", f.Name, fishQuote(f.Usage))
+		switch {
+		case len(f.Choices) > 0:
+			fmt.Fprintf(&b, " -x -a %s", fishQuote(strings.Join(f.Choices, " ")))
+		case f.Path:
+			b.WriteString(" -r -F")
+		case !f.Bool:
+			b.WriteString(" -x")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// powershellList writes words as a PowerShell array of single-quoted strings.
+func powershellList(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = "'" + strings.ReplaceAll(w, "'", "''") + "'"
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
+
+func powershellCompletion(spec completionTable) string {
+	var b strings.Builder
+	var flags []string
+	b.WriteString("# PowerShell completion for heictojpeg; load with: heictojpeg completion powershell | Out-String | Invoke-Expression\n")
+	b.WriteString("Register-ArgumentCompleter -Native -CommandName 'heictojpeg', 'heictojpeg.exe' -ScriptBlock {\n")
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	b.WriteString("    $choices = @{\n")
+	for _, f := range spec.Flags {
+		flags = append(flags, "-"+f.Name)
+		if len(f.Choices) > 0 {
+			fmt.Fprintf(&b, "        '%s' = %s\n", f.Name, powershellList(f.Choices))
+		}
+	}
+	b.WriteString("    }\n")
+	fmt.Fprintf(&b, "    $flags = %s\n", powershellList(flags))
+	fmt.Fprintf(&b, "    $commands = %s\n", powershellList(spec.Commands))
+	b.WriteString("    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })\n")
+	b.WriteString("    $prev = if ($words.Count -gt 1) { $words[-1] } else { '' }\n")
+	b.WriteString("    if ($prev.StartsWith('-') -and $choices.ContainsKey($prev.TrimStart('-'))) {\n")
+	b.WriteString("        $candidates = $choices[$prev.TrimStart('-')]\n")
+	b.WriteString("    } elseif ($wordToComplete.StartsWith('-')) {\n")
+	b.WriteString("        $candidates = $flags\n")
+	b.WriteString("    } elseif ($words.Count -eq 1) {\n")
+	b.WriteString("        $candidates = $commands\n")
+	b.WriteString("    } else {\n")
+	b.WriteString("        return\n")
+	b.WriteString("    }\n")
+	b.WriteString("    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	b.WriteString("        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
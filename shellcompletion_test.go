@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCompletionSpec(t *testing.T) {
+	custom := map[string]map[string]interface{}{"family": {"quality": 85}, "my preset": {"quality": 60}}
+	spec := completionSpec(flag.CommandLine, custom)
+
+	commands := strings.Join(spec.Commands, " ")
+	for _, want := range []string{"completion", "convert", "self-update", "serve"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("commands %q lack %s", commands, want)
+		}
+	}
+	if strings.Contains(commands, internalConvert) {
+		t.Errorf("commands %q offer %s", commands, internalConvert)
+	}
+
+	flags := map[string]completionFlag{}
+	for _, f := range spec.Flags {
+		flags[f.Name] = f
+	}
+	if !flags["recursive"].Bool || flags["quality"].Bool {
+		t.Errorf("recursive bool = %v, quality bool = %v", flags["recursive"].Bool, flags["quality"].Bool)
+	}
+	if !flags["tls-cert"].Path {
+		t.Error("-tls-cert doesn't complete paths")
+	}
+	if got, want := strings.Join(flags["preset"].Choices, " "), "archive email family print thumb web"; got != want {
+		t.Errorf("preset choices = %q, want %q", got, want)
+	}
+	for name := range flagChoices {
+		if _, ok := flags[name]; !ok {
+			t.Errorf("choices for unknown option -%s", name)
+		}
+	}
+	for name := range pathFlags {
+		if _, ok := flags[name]; !ok {
+			t.Errorf("paths for unknown option -%s", name)
+		}
+	}
+}
+
+func TestCompletionScript(t *testing.T) {
+	spec := completionSpec(flag.CommandLine, nil)
+	for shell, want := range map[string]string{
+		"bash":       `compgen -W "keep strip"`,
+		"zsh":        "'-metadata[EXIF in the JPEGs",
+		"fish":       "complete -c heictojpeg -o metadata",
+		"powershell": "'metadata' = @('keep', 'strip')",
+	} {
+		script, err := completionScript(shell, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(script, want) {
+			t.Errorf("%s script lacks %q", shell, want)
+		}
+	}
+	if _, err := completionScript("tcsh", spec); err == nil {
+		t.Error("no error for tcsh")
+	}
+}
+
+func TestBashCompletionSyntax(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	script, _ := completionScript("bash", completionSpec(flag.CommandLine, nil))
+	cmd := exec.Command(bash, "-n")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("bash -n: %v\n%s", err, out)
+	}
+}
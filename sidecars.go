@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var sidecars = flag.Bool("sidecars", true, "copy captions, keywords and favorites from .xmp or .plist sidecars next to each HEIC (e.g. from a Photos export) into the JPEG's XMP")
+
+// photoInfo is the curation a sidecar carries over to the JPEG.
+type photoInfo struct {
+	Title       string
+	Description string
+	Keywords    []string
+	Rating      int // 0 to 5 stars, 0 when unrated
+	Favorite    bool
+}
+
+func (p photoInfo) empty() bool {
+	return p.Title == "" && p.Description == "" && len(p.Keywords) == 0 && p.Rating == 0 && !p.Favorite
+}
+
+// merge fills what p lacks from other.
+func (p *photoInfo) merge(other photoInfo) {
+	if p.Title == "" {
+		p.Title = other.Title
+	}
+	if p.Description == "" {
+		p.Description = other.Description
+	}
+	if len(p.Keywords) == 0 {
+		p.Keywords = other.Keywords
+	}
+	if p.Rating == 0 {
+		p.Rating = other.Rating
+	}
+	p.Favorite = p.Favorite || other.Favorite
+}
+
+// sidecarPaths lists where a sidecar of input may be: IMG_1.xmp or
+// IMG_1.HEIC.xmp, in either case.
+func sidecarPaths(input, ext string) []string {
+	base := strings.TrimSuffix(input, filepath.Ext(input))
+	return []string{base + ext, base + strings.ToUpper(ext), input + ext, input + strings.ToUpper(ext)}
+}
+
+// readSidecars reads the .xmp and .plist sidecars of input, preferring
+// the XMP where both set something.
+func readSidecars(input string) (photoInfo, error) {
+	var info photoInfo
+	for _, s := range []struct {
+		ext   string
+		parse func(io.Reader) (photoInfo, error)
+	}{{".xmp", parseXMP}, {".plist", parsePlist}} {
+		for _, path := range sidecarPaths(input, s.ext) {
+			f, err := os.Open(longPath(path))
+			if err != nil {
+				continue
+			}
+			found, err := s.parse(f)
+			f.Close()
+			if err != nil {
+				return info, fmt.Errorf("%s: %v", filepath.Base(path), err)
+			}
+			info.merge(found)
+			break
+		}
+	}
+	return info, nil
+}
+
+const (
+	nsRDF = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsDC  = "http://purl.org/dc/elements/1.1/"
+	nsXMP = "http://ns.adobe.com/xap/1.0/"
+)
+
+// parseXMP reads the title, description, keywords and rating of an XMP
+// packet, whether they are written as elements or as attributes.
+func parseXMP(r io.Reader) (photoInfo, error) {
+	var info photoInfo
+	var stack []xml.Name
+	var text strings.Builder
+	inside := func(space, local string) bool {
+		for _, n := range stack {
+			if n.Space == space && n.Local == local {
+				return true
+			}
+		}
+		return false
+	}
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return info, nil
+		}
+		if err != nil {
+			return info, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+			text.Reset()
+			for _, a := range t.Attr {
+				if a.Name.Space == nsXMP && a.Name.Local == "Rating" {
+					info.Rating = xmpRating(a.Value)
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch {
+			case t.Name.Space == nsRDF && t.Name.Local == "li" && inside(nsDC, "subject") && value != "":
+				info.Keywords = append(info.Keywords, value)
+			case t.Name.Space == nsRDF && t.Name.Local == "li" && inside(nsDC, "title") && info.Title == "":
+				info.Title = value
+			case t.Name.Space == nsRDF && t.Name.Local == "li" && inside(nsDC, "description") && info.Description == "":
+				info.Description = value
+			case t.Name.Space == nsXMP && t.Name.Local == "Rating":
+				info.Rating = xmpRating(value)
+			}
+			stack = stack[:len(stack)-1]
+			text.Reset()
+		}
+	}
+}
+
+// xmpRating reads xmp:Rating, where -1 means rejected.
+func xmpRating(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	if n > 5 {
+		return 5
+	}
+	return n
+}
+
+// parsePlist reads an XML property list sidecar, as written by export
+// tools for Photos, taking its title, caption, keywords and favorite keys.
+func parsePlist(r io.Reader) (photoInfo, error) {
+	var info photoInfo
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return info, err
+	}
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		return info, errors.New("binary property lists are not supported; convert it with plutil -convert xml1")
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	depth, key := 0, ""
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return info, nil
+		}
+		if err != nil {
+			return info, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			if _, end := tok.(xml.EndElement); end {
+				depth--
+			}
+			continue
+		}
+		depth++
+		// Only the keys of the top-level dictionary, under <plist><dict>.
+		if depth != 3 {
+			continue
+		}
+		if start.Name.Local == "key" {
+			var k string
+			if err := d.DecodeElement(&k, &start); err != nil {
+				return info, err
+			}
+			depth--
+			key = strings.ToLower(strings.TrimSpace(k))
+			continue
+		}
+		switch key {
+		case "title", "name":
+			err = decodePlistString(d, start, &info.Title)
+		case "caption", "description", "comment":
+			err = decodePlistString(d, start, &info.Description)
+		case "keywords":
+			var array struct {
+				Strings []string `xml:"string"`
+			}
+			err = d.DecodeElement(&array, &start)
+			for _, k := range array.Strings {
+				if k = strings.TrimSpace(k); k != "" {
+					info.Keywords = append(info.Keywords, k)
+				}
+			}
+		case "favorite", "isfavorite", "favorited":
+			info.Favorite = start.Name.Local == "true"
+			err = d.Skip()
+		case "rating":
+			var n string
+			err = d.DecodeElement(&n, &start)
+			info.Rating = xmpRating(n)
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return info, err
+		}
+		depth--
+		key = ""
+	}
+}
+
+func decodePlistString(d *xml.Decoder, start xml.StartElement, s *string) error {
+	if start.Name.Local != "string" {
+		return d.Skip()
+	}
+	var v string
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*s = strings.TrimSpace(v)
+	return nil
+}
+
+// xmpPacket renders info as an XMP packet for the JPEG. A favorite
+// without a rating of its own gets 5 stars, which is how most photo
+// managers import Photos favorites.
+func xmpPacket(info photoInfo) []byte {
+	var b bytes.Buffer
+	escape := func(s string) string {
+		var e bytes.Buffer
+		xml.EscapeText(&e, []byte(s))
+		return e.String()
+	}
+	b.WriteString(`<?xpacket begin="` + "\ufeff" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>` + "\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(` <rdf:RDF xmlns:rdf="` + nsRDF + `">` + "\n")
+	b.WriteString(`  <rdf:Description rdf:about="" xmlns:dc="` + nsDC + `" xmlns:xmp="` + nsXMP + `">` + "\n")
+	if info.Title != "" {
+		b.WriteString(`   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">` + escape(info.Title) + `</rdf:li></rdf:Alt></dc:title>` + "\n")
+	}
+	if info.Description != "" {
+		b.WriteString(`   <dc:description><rdf:Alt><rdf:li xml:lang="x-default">` + escape(info.Description) + `</rdf:li></rdf:Alt></dc:description>` + "\n")
+	}
+	if len(info.Keywords) > 0 {
+		b.WriteString(`   <dc:subject><rdf:Bag>`)
+		for _, k := range info.Keywords {
+			b.WriteString(`<rdf:li>` + escape(k) + `</rdf:li>`)
+		}
+		b.WriteString(`</rdf:Bag></dc:subject>` + "\n")
+	}
+	rating := info.Rating
+	if rating == 0 && info.Favorite {
+		rating = 5
+	}
+	if rating > 0 {
+		b.WriteString(`   <xmp:Rating>` + strconv.Itoa(rating) + `</xmp:Rating>` + "\n")
+	}
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString(`<?xpacket end="w"?>`)
+	return b.Bytes()
+}
+
+// xmpSignature starts the APP1 segment that holds XMP in a JPEG.
+const xmpSignature = "http://ns.adobe.com/xap/1.0/\x00"
+
+// embedSidecar copies the curation in input's sidecars into the JPEG at
+// output, as an XMP segment after its EXIF.
+func embedSidecar(input, output string) error {
+	info, err := readSidecars(input)
+	if err != nil || info.empty() {
+		return err
+	}
+	payload := append([]byte(xmpSignature), xmpPacket(info)...)
+	if len(payload)+2 > 0xffff {
+		return errors.New("sidecar metadata is too large for a JPEG segment")
+	}
+	data, err := os.ReadFile(longPath(output))
+	if err != nil {
+		return err
+	}
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return errors.New("not a JPEG")
+	}
+	// Insert after SOI and the APPn segments that follow it.
+	at := 2
+	for at+4 <= len(data) && data[at] == 0xff && data[at+1] >= 0xe0 && data[at+1] <= 0xef {
+		at += 2 + int(binary.BigEndian.Uint16(data[at+2:]))
+	}
+	if at > len(data) {
+		return errors.New("malformed JPEG")
+	}
+	segment := []byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	var out bytes.Buffer
+	out.Grow(len(data) + len(segment) + len(payload))
+	out.Write(data[:at])
+	out.Write(segment)
+	out.Write(payload)
+	out.Write(data[at:])
+
+	tmp := output + ".tmp"
+	if err := os.WriteFile(longPath(tmp), out.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(longPath(tmp), longPath(output))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"heictojpeg/internal/exif"
+)
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:Rating="4">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">Sunset</rdf:li></rdf:Alt></dc:title>
+   <dc:description><rdf:Alt><rdf:li xml:lang="x-default">From the pier &amp; beach</rdf:li></rdf:Alt></dc:description>
+   <dc:subject><rdf:Bag><rdf:li>beach</rdf:li><rdf:li>family</rdf:li></rdf:Bag></dc:subject>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+const testPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Title</key><string>Ignored, the XMP has one</string>
+	<key>Keywords</key><array><string>ignored</string></array>
+	<key>Favorite</key><true/>
+	<key>Album</key><dict><key>Title</key><string>nested</string></dict>
+</dict>
+</plist>`
+
+func TestParseXMP(t *testing.T) {
+	info, err := parseXMP(strings.NewReader(testXMP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := photoInfo{Title: "Sunset", Description: "From the pier & beach", Keywords: []string{"beach", "family"}, Rating: 4}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("parseXMP = %+v, want %+v", info, want)
+	}
+
+	// The packet written to the JPEG reads back the same.
+	again, err := parseXMP(bytes.NewReader(xmpPacket(info)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip = %+v, want %+v", again, want)
+	}
+}
+
+func TestParsePlist(t *testing.T) {
+	info, err := parsePlist(strings.NewReader(testPlist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := photoInfo{Title: "Ignored, the XMP has one", Keywords: []string{"ignored"}, Favorite: true}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("parsePlist = %+v, want %+v", info, want)
+	}
+	if _, err := parsePlist(strings.NewReader("bplist00...")); err == nil {
+		t.Error("binary plist accepted")
+	}
+}
+
+func TestEmbedSidecar(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "IMG_1.HEIC")
+	os.WriteFile(filepath.Join(dir, "IMG_1.xmp"), []byte(testXMP), 0644)
+	os.WriteFile(filepath.Join(dir, "IMG_1.HEIC.plist"), []byte(testPlist), 0644)
+
+	var buf bytes.Buffer
+	data := exif.Build([]exif.Tag{exif.ASCII(exif.TagMake, "Apple")}, nil)
+	if err := encodeJPEGQuality(&buf, testPhoto(16, 16, false), data, 80); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "IMG_1.jpg")
+	os.WriteFile(output, buf.Bytes(), 0644)
+	if err := embedSidecar(input, output); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("JPEG no longer decodes: %v", err)
+	}
+	if x, err := readJPEGExif(output); err != nil || x == nil {
+		t.Errorf("EXIF lost: %v", err)
+	}
+	at := bytes.Index(data, []byte(xmpSignature))
+	if at < 0 {
+		t.Fatal("no XMP segment")
+	}
+	packet := data[at+len(xmpSignature):]
+	packet = packet[:bytes.Index(packet, []byte(`<?xpacket end="w"?>`))]
+	info, err := parseXMP(bytes.NewReader(packet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The XMP wins over the plist, and its rating over the favorite.
+	want := photoInfo{Title: "Sunset", Description: "From the pier & beach", Keywords: []string{"beach", "family"}, Rating: 4}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("embedded = %+v, want %+v", info, want)
+	}
+}
+
+func TestEmbedSidecarNone(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "IMG_2.jpg")
+	os.WriteFile(output, []byte{0xff, 0xd8, 0xff, 0xd9}, 0644)
+	if err := embedSidecar(filepath.Join(dir, "IMG_2.HEIC"), output); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(output); len(data) != 4 {
+		t.Error("JPEG changed without a sidecar")
+	}
+}
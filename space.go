@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var spaceCheck = flag.String("space-check", "refuse", "before converting a folder, compare the space its JPEGs are likely to need with the free space: refuse to start, warn, or off")
+
+var spaceCheckModes = map[string]bool{"refuse": true, "warn": true, "off": true}
+
+// iPhoneLongSide is the longer side of a 12 MP iPhone photo, the size the
+// expansion ratios below were measured at.
+const iPhoneLongSide = 4032
+
+// expansionRatio is how much larger a typical iPhone JPEG is than its HEIC
+// at quality q, on the generous side so the check errs towards warning.
+func expansionRatio(q, maxSize int) float64 {
+	var ratio float64
+	switch {
+	case q <= 70:
+		ratio = 1.5
+	case q <= 85:
+		ratio = 2
+	case q <= 92:
+		ratio = 2.5
+	default:
+		ratio = 3.5
+	}
+	if maxSize > 0 && maxSize < iPhoneLongSide {
+		scale := float64(maxSize) / iPhoneLongSide
+		ratio *= scale * scale
+	}
+	return ratio
+}
+
+// spaceNeeded estimates the bytes the HEIC files among files will add to
+// jpegDir, less the JPEGs of earlier runs they replace.
+func spaceNeeded(dir, jpegDir string, files []os.DirEntry, ratio float64) int64 {
+	var needed int64
+	for _, file := range files {
+		if !isHEIC(file.Name()) {
+			continue
+		}
+		size := int64(float64(getFileSize(filepath.Join(dir, file.Name()))) * ratio)
+		size -= getFileSize(getJPEGFilePath(jpegDir, namingSource(dir, file.Name())))
+		if size > 0 {
+			needed += size
+		}
+	}
+	return needed
+}
+
+// checkSpace runs the -space-check before files in dir are converted into
+// jpegDir. Without enough room it returns an error in refuse mode, and
+// only prints a warning in warn mode.
+func checkSpace(dir, jpegDir string, files []os.DirEntry) error {
+	if *spaceCheck == "off" || *pipeOutputs {
+		return nil
+	}
+	free, err := freeSpace(jpegDir)
+	if err != nil {
+		return nil // unknown, e.g. on some network shares
+	}
+	needed := spaceNeeded(dir, jpegDir, files, expansionRatio(*quality, *maxSize))
+	if needed <= free {
+		return nil
+	}
+	return spaceShortage(jpegDir, needed, free)
+}
+
+// spaceShortage is the outcome of a -space-check that found needed more
+// than free: an error in refuse mode, a warning in warn mode.
+func spaceShortage(jpegDir string, needed, free int64) error {
+	if *spaceCheck == "warn" {
+		fmt.Printf(tr("Warning: the JPEGs may need about %s but only %s is free in %s\n"), humanReadableFileSize(needed), humanReadableFileSize(free), jpegDir)
+		return nil
+	}
+	return fmt.Errorf(tr("not enough space in %s: the JPEGs may need about %s but only %s is free; free up space, or use -space-check warn to start anyway"), jpegDir, humanReadableFileSize(needed), humanReadableFileSize(free))
+}
+
+// watchSpace runs the -space-check on the files of a streamed scan as they
+// are found, passing them on to be converted. Once the running estimate is
+// more than is free, refuse mode stops passing files on and calls stop to
+// end the scan, and the returned func reports the shortage; warn mode
+// warns once. The func is called after the returned channel is drained.
+func watchSpace(dir, jpegDir string, files <-chan os.DirEntry, stop func()) (<-chan os.DirEntry, func() error) {
+	if *spaceCheck == "off" || *pipeOutputs {
+		return files, func() error { return nil }
+	}
+	free, err := freeSpace(jpegDir)
+	if err != nil {
+		return files, func() error { return nil } // unknown, e.g. on some network shares
+	}
+	ratio := expansionRatio(*quality, *maxSize)
+	out := make(chan os.DirEntry)
+	var shortage error
+	go func() {
+		defer close(out)
+		var needed int64
+		warned := false
+		for file := range files {
+			if shortage != nil {
+				continue // what the scan found before it stopped
+			}
+			needed += spaceNeeded(dir, jpegDir, []os.DirEntry{file}, ratio)
+			if needed > free && !warned {
+				warned = true
+				if shortage = spaceShortage(jpegDir, needed, free); shortage != nil {
+					stop()
+					continue
+				}
+			}
+			out <- file
+		}
+	}()
+	return out, func() error { return shortage }
+}
+
+// remainingSpace estimates the space the HEIC files of dir that have no
+// JPEG yet will need, for a run that ran out of space halfway.
+func remainingSpace(dir, jpegDir string) int64 {
+	files, err := getFilesInDirectory(dir)
+	if err != nil {
+		return 0
+	}
+	ratio := expansionRatio(*quality, *maxSize)
+	var needed int64
+	for _, file := range files {
+		if isHEIC(file.Name()) && !fileExists(getJPEGFilePath(jpegDir, namingSource(dir, file.Name()))) {
+			needed += int64(float64(getFileSize(filepath.Join(dir, file.Name()))) * ratio)
+		}
+	}
+	return needed
+}
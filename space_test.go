@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpansionRatio(t *testing.T) {
+	if low, high := expansionRatio(60, 0), expansionRatio(95, 0); low >= high {
+		t.Errorf("ratio at quality 60 = %v, at 95 = %v", low, high)
+	}
+	if full, small := expansionRatio(90, 0), expansionRatio(90, 2016); small != full/4 {
+		t.Errorf("ratio at half size = %v, want %v", small, full/4)
+	}
+}
+
+func TestSpaceNeeded(t *testing.T) {
+	dir := t.TempDir()
+	jpegDir := ensureJPEGDirectoryExists(dir)
+	os.WriteFile(filepath.Join(dir, "a.heic"), make([]byte, 1000), 0644)
+	os.WriteFile(filepath.Join(dir, "b.heic"), make([]byte, 1000), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), make([]byte, 5000), 0644)
+	os.WriteFile(filepath.Join(jpegDir, "b.jpg"), make([]byte, 1500), 0644)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := spaceNeeded(dir, jpegDir, files, 2), int64(2000+500); got != want {
+		t.Errorf("spaceNeeded = %d, want %d", got, want)
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	free, err := freeSpace(t.TempDir())
+	if err != nil || free <= 0 {
+		t.Errorf("freeSpace = %d, %v", free, err)
+	}
+}
+
+func TestCheckSpaceWhileStreaming(t *testing.T) {
+	defer func(r bool, o, mode string) { *recursive, *order, *spaceCheck = r, o, mode }(*recursive, *order, *spaceCheck)
+	*recursive, *order, *spaceCheck = true, "", "refuse"
+	dir := t.TempDir()
+	free, err := freeSpace(dir)
+	if err != nil {
+		t.Skip(err)
+	}
+	os.MkdirAll(filepath.Join(dir, "2023"), 0755)
+	// Sparse, so it takes no space itself.
+	f, err := os.Create(filepath.Join(dir, "2023", "big.heic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.Truncate(free)
+	f.Close()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	err = convertDirectory(context.Background(), dir, nil)
+	if err == nil || !strings.Contains(err.Error(), "not enough space") {
+		t.Errorf("convertDirectory = %v, want not enough space", err)
+	}
+
+	*spaceCheck = "warn"
+	if err := convertDirectory(context.Background(), dir, nil); err != nil {
+		t.Errorf("convertDirectory with -space-check warn = %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import "syscall"
+
+// freeSpace is the space available to this user on the volume holding path.
+func freeSpace(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize)), nil
+}
+
+// diskFullErrors are the errors of writes to a full volume.
+var diskFullErrors = []syscall.Errno{syscall.ENOSPC, syscall.EDQUOT}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// freeSpace is the space available to this user on the volume holding
+// path, taking quotas into account.
+func freeSpace(path string) (int64, error) {
+	p, err := syscall.UTF16PtrFromString(longPath(path))
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if r, _, err := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&available)), 0, 0); r == 0 {
+		return 0, err
+	}
+	return int64(available), nil
+}
+
+// diskFullErrors are ERROR_DISK_FULL and ERROR_HANDLE_DISK_FULL.
+var diskFullErrors = []syscall.Errno{112, 39}
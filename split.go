@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	splitOutputSize  = flag.String("split-output", "", "after converting, spread the JPEGs over jpegs_part1, jpegs_part2, ... of at most this size each, e.g. 4GB for FAT32 or 4.3GB for DVDs")
+	splitOutputFiles = flag.Int("split-files", 0, "like -split-output, but start a new part after this many files")
+)
+
+// splitOutputBytes is the parsed -split-output; 0 means no size limit.
+var splitOutputBytes int64
+
+func splitEnabled() bool {
+	return splitOutputBytes > 0 || *splitOutputFiles > 0
+}
+
+// splitOutput moves the JPEGs in jpegDir, in name order, into numbered part
+// directories next to it, starting a new part when the next file would go
+// over maxBytes or maxFiles. logs.txt and jpegs/failed stay where they are.
+// It returns the part directory name for each moved file, by slash-separated
+// path relative to jpegDir.
+func splitOutput(jpegDir string, maxBytes int64, maxFiles int) (map[string]string, error) {
+	type output struct {
+		rel  string
+		size int64
+	}
+	var outputs []output
+	err := filepath.WalkDir(jpegDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(jpegDir, path)
+		if d.IsDir() {
+			if rel == quarantineDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".jpg") && !isLosslessOutput(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, output{filepath.ToSlash(rel), info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].rel < outputs[j].rel })
+
+	parts := make(map[string]string, len(outputs))
+	part, used, count := 1, int64(0), 0
+	for _, o := range outputs {
+		if count > 0 && ((maxBytes > 0 && used+o.size > maxBytes) || (maxFiles > 0 && count >= maxFiles)) {
+			part, used, count = part+1, 0, 0
+		}
+		name := fmt.Sprintf("%s_part%d", filepath.Base(jpegDir), part)
+		dst := filepath.Join(filepath.Dir(jpegDir), name, filepath.FromSlash(o.rel))
+		if err := os.MkdirAll(longPath(filepath.Dir(dst)), 0755); err != nil {
+			return parts, err
+		}
+		if err := os.Rename(longPath(filepath.Join(jpegDir, filepath.FromSlash(o.rel))), longPath(dst)); err != nil {
+			return parts, err
+		}
+		parts[o.rel] = name
+		used += o.size
+		count++
+	}
+	removeEmptyDirs(jpegDir)
+	return parts, nil
+}
+
+// removeEmptyDirs removes the subdirectories of dir left empty by the move.
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(longPath(dir))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			sub := filepath.Join(dir, e.Name())
+			removeEmptyDirs(sub)
+			os.Remove(longPath(sub)) // fails, as intended, if not empty
+		}
+	}
+}
+
+// relocateLogs points the log lines of moved files at their part directory.
+func relocateLogs(logs map[string][]string, currentDir string, parts map[string]string) {
+	for k, lines := range logs {
+		rel := filepath.ToSlash(jpegFileName(namingSource(currentDir, k)))
+		part, ok := parts[rel]
+		// -screenshots png and -bit-depth write some of them as PNG or TIFF.
+		for _, name := range []string{pngFileName(rel), deepFileName(rel)} {
+			if !ok {
+				rel = name
+				part, ok = parts[rel]
+			}
+		}
+		if !ok {
+			continue
+		}
+		for i, line := range lines {
+			lines[i] = strings.Replace(line, "jpegs/"+rel, part+"/"+rel, 1)
+		}
+	}
+}
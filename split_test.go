@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitOutput(t *testing.T) {
+	dir := t.TempDir()
+	jpegDir := filepath.Join(dir, "jpegs")
+	for name, size := range map[string]int{"a.jpg": 40, "b.jpg": 40, "sub/c.jpg": 40, "d.jpg": 100, "logs.txt": 10, "failed/x.heic": 5} {
+		path := filepath.Join(jpegDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, make([]byte, size), 0644)
+	}
+
+	parts, err := splitOutput(jpegDir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Name order: a, b, d, sub/c. d doesn't fit after a and b, and fills a part by itself.
+	want := map[string]string{"a.jpg": "jpegs_part1", "b.jpg": "jpegs_part1", "d.jpg": "jpegs_part2", "sub/c.jpg": "jpegs_part3"}
+	for rel, part := range want {
+		if parts[rel] != part {
+			t.Errorf("%s went to %q, want %q", rel, parts[rel], part)
+		}
+		if _, err := os.Stat(filepath.Join(dir, part, filepath.FromSlash(rel))); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, kept := range []string{"logs.txt", "failed/x.heic"} {
+		if _, err := os.Stat(filepath.Join(jpegDir, filepath.FromSlash(kept))); err != nil {
+			t.Errorf("%s should stay in jpegs: %v", kept, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(jpegDir, "sub")); !os.IsNotExist(err) {
+		t.Error("empty jpegs/sub was left behind")
+	}
+
+	logs := map[string][]string{"sub/c.heic": {"sub/c.heic 1KB > Converted > jpegs/sub/c.jpg 40B"}}
+	relocateLogs(logs, dir, parts)
+	if got := logs["sub/c.heic"][0]; got != "sub/c.heic 1KB > Converted > jpegs_part3/sub/c.jpg 40B" {
+		t.Errorf("log line = %q", got)
+	}
+}
+
+func TestSplitOutputFileCount(t *testing.T) {
+	jpegDir := filepath.Join(t.TempDir(), "jpegs")
+	os.MkdirAll(jpegDir, 0755)
+	for _, name := range []string{"1.jpg", "2.jpg", "3.jpg"} {
+		os.WriteFile(filepath.Join(jpegDir, name), []byte("x"), 0644)
+	}
+	parts, err := splitOutput(jpegDir, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts["2.jpg"] != "jpegs_part1" || parts["3.jpg"] != "jpegs_part2" {
+		t.Errorf("parts = %v", parts)
+	}
+}
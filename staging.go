@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var stagingMB = flag.Int("staging-mb", 0, "encode into memory and write the JPEGs from one goroutine in sequential batches of up to this many MiB, for spinning disks and network shares (0 writes directly)")
+
+// stagingDelay is how long a partial batch waits for more files.
+const stagingDelay = 500 * time.Millisecond
+
+var stagingBuffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+type stagedFile struct {
+	path string
+	data *bytes.Buffer
+	done chan error
+}
+
+// stager collects encoded JPEGs and writes them in batches, one file after
+// another in path order, so the destination sees large sequential writes
+// instead of every worker writing at once. Workers block until their file
+// is written, so hooks and the report see it on disk as usual.
+type stager struct {
+	files    chan stagedFile
+	limit    int
+	maxFiles int
+	stopped  chan struct{}
+}
+
+type stagerKey struct{}
+
+func newStager(limit, maxFiles int) *stager {
+	s := &stager{files: make(chan stagedFile), limit: limit, maxFiles: maxFiles, stopped: make(chan struct{})}
+	go s.run()
+	return s
+}
+
+func withStager(ctx context.Context, s *stager) context.Context {
+	return context.WithValue(ctx, stagerKey{}, s)
+}
+
+func stagerFrom(ctx context.Context) *stager {
+	s, _ := ctx.Value(stagerKey{}).(*stager)
+	return s
+}
+
+func getStagingBuffer() *bytes.Buffer {
+	buf := stagingBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// write queues data for path and waits until it has been written. The
+// buffer is reused afterwards and must not be touched by the caller.
+func (s *stager) write(path string, data *bytes.Buffer) error {
+	f := stagedFile{path: path, data: data, done: make(chan error, 1)}
+	s.files <- f
+	return <-f.done
+}
+
+// close writes the pending batch and stops the stager.
+func (s *stager) close() {
+	close(s.files)
+	<-s.stopped
+}
+
+func (s *stager) run() {
+	defer close(s.stopped)
+	var batch []stagedFile
+	size := 0
+	timer := time.NewTimer(stagingDelay)
+	timer.Stop()
+	flush := func() {
+		timer.Stop()
+		writeBatch(batch)
+		batch, size = nil, 0
+	}
+	for {
+		select {
+		case f, ok := <-s.files:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, f)
+			size += f.data.Len()
+			if size >= s.limit || len(batch) >= s.maxFiles {
+				flush()
+			} else if len(batch) == 1 {
+				timer.Reset(stagingDelay)
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+func writeBatch(batch []stagedFile) {
+	sort.Slice(batch, func(i, j int) bool { return batch[i].path < batch[j].path })
+	for _, f := range batch {
+		err := os.WriteFile(longPath(f.path), f.data.Bytes(), 0644)
+		stagingBuffers.Put(f.data)
+		f.done <- err
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStagerWritesBatches(t *testing.T) {
+	dir := t.TempDir()
+	s := newStager(1<<20, 3)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := filepath.Join(dir, fmt.Sprintf("%d.jpg", i))
+			if err := s.write(path, bytes.NewBufferString(fmt.Sprint("jpeg ", i))); err != nil {
+				t.Error(err)
+			}
+			// write only returns once the file is on disk.
+			if data, err := os.ReadFile(path); err != nil || string(data) != fmt.Sprint("jpeg ", i) {
+				t.Errorf("%s = %q, %v", path, data, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	s.close()
+}
+
+func TestStagerWriteError(t *testing.T) {
+	s := newStager(1, 1)
+	defer s.close()
+	if err := s.write(filepath.Join(t.TempDir(), "missing", "a.jpg"), bytes.NewBufferString("x")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"heictojpeg/convert"
+
+	"github.com/adrium/goheif/heif"
+)
+
+var lowMemory = flag.Bool("low-memory", false, "decode tiled HEIC files one row of tiles at a time while encoding, instead of holding the whole frame (slower, for large panoramas)")
+
+// convertHeicToJpgBanded is the -low-memory conversion. Images that aren't
+// tiled use the regular decoder.
+func convertHeicToJpgBanded(ctx context.Context, input, output string) error {
+	fileInput, err := os.Open(longPath(input))
+	if err != nil {
+		return err
+	}
+	defer fileInput.Close()
+
+	d, done, err := convert.DecodeBanded(ctx, fileInput, settingsFrom(ctx).options())
+	if errors.Is(err, convert.ErrNotTiled) {
+		return convertHeicToJpgFull(ctx, input, output)
+	}
+	if err != nil {
+		return err
+	}
+	if d.Exif == nil {
+		done()
+		return heif.ErrNoEXIF
+	}
+	recordProfile(ctx, d.Profile)
+
+	fileOutput, err := openOutput(ctx, input, output, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		done()
+		return err
+	}
+	defer fileOutput.Close()
+	err = encodeJPEG(ctx, fileOutput, d.Image, d.Exif)
+	decodeErr := done()
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		// The encoder has already written part of the file.
+		fileOutput.Close()
+		if pipesFrom(ctx) == nil {
+			os.Remove(longPath(output))
+		}
+	}
+	if decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"heictojpeg/convert"
+)
+
+var filesFrom = flag.String("files", "", "read the files to convert from standard input (-files -), one per line or NUL-separated as from find -print0, instead of scanning a folder")
+
+// readFileList splits a list of paths read from r. The list is
+// NUL-separated if it contains a NUL byte, as from find -print0, and
+// otherwise has one path per line. Empty entries and repeats are dropped.
+func readFileList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sep := "\n"
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = "\x00"
+	}
+	var paths []string
+	seen := make(map[string]bool)
+	for _, path := range strings.Split(string(data), sep) {
+		if sep == "\n" {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// convertFileList converts exactly the files in paths, without scanning
+// any folder. Folders in the list (find prints the starting point) are
+// ignored, and files that can't be found are reported and skipped, since
+// the list may be older than the files.
+func convertFileList(ctx context.Context, paths []string, observers ...convert.Observer) error {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(longPath(path))
+		if err != nil {
+			fmt.Printf(tr("Skipping %s: %v\n"), path, err)
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 {
+		infoln(tr("No files to convert."))
+		return nil
+	}
+	return convertTargets(ctx, files, observers...)
+}
+
+// convertTargets converts the files and folders named on the command line.
+// Files are grouped by the folder they are in, so each folder still gets a
+// single jpegs/ subfolder and log file.
+func convertTargets(ctx context.Context, targets []string, observers ...convert.Observer) error {
+	var dirs []string
+	wholeDir := make(map[string]bool)
+	files := make(map[string][]os.DirEntry)
+
+	for _, target := range targets {
+		path, err := filepath.Abs(target)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(longPath(path))
+		if err != nil {
+			return err
+		}
+
+		dir := path
+		if !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		if _, seen := files[dir]; !seen && !wholeDir[dir] {
+			dirs = append(dirs, dir)
+		}
+		if info.IsDir() {
+			wholeDir[dir] = true
+		} else {
+			files[dir] = append(files[dir], fs.FileInfoToDirEntry(info))
+		}
+	}
+
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		selected := files[dir]
+		if wholeDir[dir] {
+			selected = nil
+		}
+		if err := convertDirectory(ctx, dir, selected, observers...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Testing convertTargets with files from two folders
+func TestConvertTargets(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"a/one.heic", "a/two.heic", "b/three.heic"} {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("mock content"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	targets := []string{filepath.Join(root, "a", "one.heic"), filepath.Join(root, "b")}
+	if err := convertTargets(context.Background(), targets); err != nil {
+		t.Fatalf("convertTargets failed: %v", err)
+	}
+
+	logA, err := os.ReadFile(filepath.Join(root, "a", "jpegs", logFileName))
+	if err != nil {
+		t.Fatalf("Missing log for folder a: %v", err)
+	}
+	if !strings.Contains(string(logA), "one.heic") || strings.Contains(string(logA), "two.heic") {
+		t.Errorf("Folder a should only log the selected file:\n%s", logA)
+	}
+	logB, err := os.ReadFile(filepath.Join(root, "b", "jpegs", logFileName))
+	if err != nil || !strings.Contains(string(logB), "three.heic") {
+		t.Errorf("Folder b should log three.heic: %v\n%s", err, logB)
+	}
+}
+
+// Testing readFileList with line and NUL-separated lists
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"a.heic\nb dir/c.heic\n\na.heic\n", []string{"a.heic", "b dir/c.heic"}},
+		{"a.heic\r\nb.heic", []string{"a.heic", "b.heic"}},
+		{"./line\nbreak.heic\x00b.heic\x00", []string{"./line\nbreak.heic", "b.heic"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := readFileList(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatalf("readFileList(%q) failed: %v", tt.input, err)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("readFileList(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// Testing that convertFileList converts only the listed files
+func TestConvertFileList(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"one.heic", "two.heic"} {
+		if err := os.WriteFile(filepath.Join(root, file), []byte("mock content"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	paths := []string{root, filepath.Join(root, "one.heic"), filepath.Join(root, "gone.heic")}
+	if err := convertFileList(context.Background(), paths); err != nil {
+		t.Fatalf("convertFileList failed: %v", err)
+	}
+
+	logs, err := os.ReadFile(filepath.Join(root, "jpegs", logFileName))
+	if err != nil {
+		t.Fatalf("Missing log: %v", err)
+	}
+	if !strings.Contains(string(logs), "one.heic") || strings.Contains(string(logs), "two.heic") {
+		t.Errorf("Only one.heic should be converted:\n%s", logs)
+	}
+}
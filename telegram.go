@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// telegramMaxDownload is the largest file the Bot API lets bots download.
+const telegramMaxDownload = 20 << 20
+
+// telegramPoll is how long getUpdates waits for a message, in seconds.
+const telegramPoll = 50
+
+// telegramBot answers a Telegram bot by long polling, so it needs no
+// public address.
+type telegramBot struct {
+	api    string
+	token  string
+	client *http.Client
+	conv   *botConverter
+}
+
+type telegramMessage struct {
+	MessageID int64 `json:"message_id"`
+	From      *struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"from"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text     string `json:"text"`
+	Document *struct {
+		FileID   string `json:"file_id"`
+		FileName string `json:"file_name"`
+		MimeType string `json:"mime_type"`
+		FileSize int64  `json:"file_size"`
+	} `json:"document"`
+	Photo []json.RawMessage `json:"photo"`
+}
+
+func newTelegramBot(api, token string, conv *botConverter) *telegramBot {
+	return &telegramBot{api: api, token: token, client: &http.Client{Timeout: 2 * time.Minute}, conv: conv}
+}
+
+// call calls a method of the Bot API with params as JSON, decoding its
+// result into result.
+func (b *telegramBot) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.api+"/bot"+b.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return b.do(req, method, result)
+}
+
+func (b *telegramBot) do(req *http.Request, method string, result interface{}) error {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %v", method, botError(err))
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("%s: %s", method, reply.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+// run answers messages until ctx is cancelled. A bad token fails it; other
+// errors are retried.
+func (b *telegramBot) run(ctx context.Context) error {
+	var me struct {
+		Username string `json:"username"`
+	}
+	if err := b.call(ctx, "getMe", struct{}{}, &me); err != nil {
+		return fmt.Errorf("Telegram: %v", err)
+	}
+	fmt.Printf(tr("Telegram bot @%s is running\n"), me.Username)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var offset int64
+	for ctx.Err() == nil {
+		var updates []struct {
+			UpdateID int64            `json:"update_id"`
+			Message  *telegramMessage `json:"message"`
+		}
+		params := map[string]interface{}{"offset": offset, "timeout": telegramPoll, "allowed_updates": []string{"message"}}
+		if err := b.call(ctx, "getUpdates", params, &updates); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Printf(tr("Telegram: %v\n"), err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message != nil {
+				wg.Add(1)
+				go func(m *telegramMessage) {
+					defer wg.Done()
+					b.handle(ctx, m)
+				}(update.Message)
+			}
+		}
+	}
+	return nil
+}
+
+// handle answers a message: a HEIC file with its JPEG, anything else with
+// what to send.
+func (b *telegramBot) handle(ctx context.Context, m *telegramMessage) {
+	if m.From == nil {
+		return
+	}
+	id := strconv.FormatInt(m.From.ID, 10)
+	who := "Telegram user " + id
+	if m.From.Username != "" {
+		who = "@" + m.From.Username
+	}
+	doc := m.Document
+	switch {
+	case !b.conv.allows(id, m.From.Username):
+		fmt.Printf(tr("Ignored a file from %s: %v\n"), who, errNotAllowed)
+		b.reply(ctx, m, tr("Sorry, I only convert photos for the people I was set up for."))
+	case doc == nil && len(m.Photo) > 0:
+		b.reply(ctx, m, tr("Telegram already made that photo a JPEG. To convert a HEIC photo, send it as a file."))
+	case doc == nil || !isHEICUpload(doc.FileName, doc.MimeType):
+		b.reply(ctx, m, tr("Send me a HEIC photo as a file and I'll send it back as a JPEG."))
+	case doc.FileSize > telegramMaxDownload:
+		b.reply(ctx, m, fmt.Sprintf(tr("%s is over the %s Telegram lets bots download."), doc.FileName, humanReadableFileSize(telegramMaxDownload)))
+	default:
+		output, err := b.convert(ctx, who, doc.FileID)
+		if err != nil {
+			fmt.Printf(tr("Failed to convert %s from %s: %v\n"), doc.FileName, who, err)
+			b.reply(ctx, m, fmt.Sprintf(tr("Couldn't convert %s: %v"), doc.FileName, err))
+			return
+		}
+		defer os.Remove(output)
+		if err := b.sendDocument(ctx, m, output, jpegName(doc.FileName)); err != nil {
+			fmt.Printf(tr("Failed to send the JPEG of %s to %s: %v\n"), doc.FileName, who, err)
+		}
+	}
+}
+
+// convert downloads the file fileID and converts it.
+func (b *telegramBot) convert(ctx context.Context, who, fileID string) (string, error) {
+	var file struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := b.call(ctx, "getFile", map[string]string{"file_id": fileID}, &file); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.api+"/file/bot"+b.token+"/"+file.FilePath, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", botError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download: %s", resp.Status)
+	}
+	return b.conv.convert(ctx, who, resp.Body)
+}
+
+func (b *telegramBot) reply(ctx context.Context, m *telegramMessage, text string) {
+	params := map[string]interface{}{"chat_id": m.Chat.ID, "text": text, "reply_to_message_id": m.MessageID}
+	if err := b.call(ctx, "sendMessage", params, nil); err != nil {
+		fmt.Printf(tr("Telegram: %v\n"), err)
+	}
+}
+
+// sendDocument sends the file at path as a reply to m, named name. As a
+// document rather than a photo, so Telegram doesn't compress it again.
+func (b *telegramBot) sendDocument(ctx context.Context, m *telegramMessage, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("chat_id", strconv.FormatInt(m.Chat.ID, 10))
+	w.WriteField("reply_to_message_id", strconv.FormatInt(m.MessageID, 10))
+	part, err := w.CreateFormFile("document", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.api+"/bot"+b.token+"/sendDocument", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return b.do(req, "sendDocument", nil)
+}
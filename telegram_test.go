@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTelegram serves the Bot API methods the bot calls, handing out
+// updates once and recording what is sent back.
+type fakeTelegram struct {
+	mu      sync.Mutex
+	updates []string
+	sent    []string // "text" or "document name"
+	file    []byte
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ok := func(result string) { fmt.Fprintf(w, `{"ok": true, "result": %s}`, result) }
+	switch {
+	case r.URL.Path == "/file/botTOKEN/photos/file_1.heic":
+		w.Write(f.file)
+	case !strings.HasPrefix(r.URL.Path, "/botTOKEN/"):
+		http.NotFound(w, r)
+	case strings.HasSuffix(r.URL.Path, "/getMe"):
+		ok(`{"username": "heic_bot"}`)
+	case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+		f.mu.Lock()
+		updates := "[" + strings.Join(f.updates, ",") + "]"
+		f.updates = nil
+		f.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		ok(updates)
+	case strings.HasSuffix(r.URL.Path, "/getFile"):
+		ok(`{"file_path": "photos/file_1.heic"}`)
+	case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+		var m struct{ Text string }
+		json.NewDecoder(r.Body).Decode(&m)
+		f.record(m.Text)
+		ok("{}")
+	case strings.HasSuffix(r.URL.Path, "/sendDocument"):
+		doc, header, err := r.FormFile("document")
+		if err != nil {
+			fmt.Fprintf(w, `{"ok": false, "description": %q}`, err.Error())
+			return
+		}
+		_, err = jpeg.DecodeConfig(doc)
+		f.record(fmt.Sprintf("document %s %v", header.Filename, err))
+		ok("{}")
+	default:
+		fmt.Fprint(w, `{"ok": false, "description": "Not Found"}`)
+	}
+}
+
+func (f *fakeTelegram) record(s string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, s)
+}
+
+func TestTelegramBot(t *testing.T) {
+	defer func(users string) { *botUsers = users }(*botUsers)
+	*botUsers = "grandma"
+	conv, err := newBotConverter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := func(id int, from, extra string) string {
+		return fmt.Sprintf(`{"update_id": %d, "message": {"message_id": %d, "from": {"id": %d, "username": %q}, "chat": {"id": 7}%s}}`, id, id, id, from, extra)
+	}
+	api := &fakeTelegram{file: exifSample(), updates: []string{
+		message(1, "grandma", `, "document": {"file_id": "f1", "file_name": "IMG_0001.HEIC", "mime_type": "image/heic", "file_size": 1000}`),
+		message(2, "grandma", `, "text": "hello"`),
+		message(3, "stranger", `, "document": {"file_id": "f1", "file_name": "IMG_0001.HEIC"}`),
+		message(4, "grandma", `, "photo": [{"file_id": "p"}]`),
+		message(5, "grandma", `, "document": {"file_id": "f1", "file_name": "IMG_0002.HEIC", "file_size": 30000000}`),
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- newTelegramBot(server.URL, "TOKEN", conv).run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		api.mu.Lock()
+		n := len(api.sent)
+		api.mu.Unlock()
+		if n == 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	sent := strings.Join(api.sent, "\n")
+	for _, want := range []string{
+		"document IMG_0001.jpg <nil>",
+		"Send me a HEIC photo as a file",
+		"Sorry, I only convert photos",
+		"send it as a file",
+		"IMG_0002.HEIC is over the 20.0MB",
+	} {
+		if !strings.Contains(sent, want) {
+			t.Errorf("sent:\n%s\nwithout %q", sent, want)
+		}
+	}
+}
+
+func TestTelegramBadToken(t *testing.T) {
+	server := httptest.NewServer(&fakeTelegram{})
+	defer server.Close()
+	conv, _ := newBotConverter()
+	err := newTelegramBot(server.URL, "WRONG", conv).run(context.Background())
+	if err == nil || strings.Contains(err.Error(), "WRONG") {
+		t.Errorf("run with a bad token = %v, want an error without the token", err)
+	}
+}
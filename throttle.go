@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var throttle = flag.String("throttle", "", "limit each worker to this share of its time, e.g. 50% rests as long as each file took to convert")
+
+// throttlePercent is the parsed -throttle; 100 means unthrottled.
+var throttlePercent = 100
+
+func parseThrottle(s string) (int, error) {
+	if s == "" {
+		return 100, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if err != nil || n < 1 || n > 100 {
+		return 0, fmt.Errorf("must be a percentage between 1%% and 100%%")
+	}
+	return n, nil
+}
+
+// throttlePause is the rest after a file that took elapsed to convert,
+// so that the worker is busy percent of the time.
+func throttlePause(elapsed time.Duration, percent int) time.Duration {
+	if percent >= 100 {
+		return 0
+	}
+	return elapsed * time.Duration(100-percent) / time.Duration(percent)
+}
+
+// pace sleeps for the throttle pause, or until ctx is done.
+func pace(ctx context.Context, elapsed time.Duration) {
+	pause := throttlePause(elapsed, throttlePercent)
+	if pause <= 0 {
+		return
+	}
+	t := time.NewTimer(pause)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseThrottle(t *testing.T) {
+	for in, want := range map[string]int{"": 100, "50%": 50, "25": 25, " 100% ": 100} {
+		if got, err := parseThrottle(in); err != nil || got != want {
+			t.Errorf("parseThrottle(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"0%", "150%", "fast"} {
+		if _, err := parseThrottle(in); err == nil {
+			t.Errorf("parseThrottle(%q) should fail", in)
+		}
+	}
+}
+
+func TestThrottlePause(t *testing.T) {
+	if got := throttlePause(time.Second, 50); got != time.Second {
+		t.Errorf("50%%: got %v", got)
+	}
+	if got := throttlePause(time.Second, 25); got != 3*time.Second {
+		t.Errorf("25%%: got %v", got)
+	}
+	if got := throttlePause(time.Second, 100); got != 0 {
+		t.Errorf("100%%: got %v", got)
+	}
+}
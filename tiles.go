@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"heictojpeg/convert"
+)
+
+var verifyGrid = flag.Bool("verify-grid", false, "check tiled photos after decoding: decode each tile again on its own and compare its edges with the reassembled frame, failing the file when a tile is misplaced")
+
+func init() {
+	subcommands["tiles"] = tilesCommand
+}
+
+// parseTilePicks parses a comma-separated list of tile numbers, or ranges
+// of them such as 4-7.
+func parseTilePicks(list string, count int) ([]int, error) {
+	var picks []int
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		from, to, isRange := strings.Cut(p, "-")
+		first, err := strconv.Atoi(from)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(to)
+		}
+		if err != nil || first < 0 || last < first || last >= count {
+			return nil, fmt.Errorf("invalid tile %q: tiles are numbered 0 to %d", p, count-1)
+		}
+		for i := first; i <= last; i++ {
+			picks = append(picks, i)
+		}
+	}
+	return picks, nil
+}
+
+// previewTiles decodes only the picked tiles of input's grid, cropped to
+// the image, and writes each as a JPEG into dir. It returns their paths.
+func previewTiles(input string, picks []int, dir string, quality int) ([]string, error) {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := convert.CheckGrid(f); err != nil {
+		return nil, err
+	}
+	g, err := convert.ReadGrid(f)
+	if err != nil {
+		return nil, err
+	}
+	if g.Tiles() == 0 {
+		return nil, convert.ErrNotTiled
+	}
+	if err := os.MkdirAll(longPath(dir), 0755); err != nil {
+		return nil, err
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	var paths []string
+	for _, i := range picks {
+		tile, err := convert.DecodeTile(f, i)
+		if err != nil {
+			return paths, fmt.Errorf("tile %d: %v", i, err)
+		}
+		// Tiles on the right and bottom edges reach past the image.
+		t := tile.Bounds()
+		x0, y0 := i%g.Columns*t.Dx(), i/g.Columns*t.Dy()
+		visible := image.Rect(0, 0, g.Width-x0, g.Height-y0).Intersect(t)
+		path := filepath.Join(dir, fmt.Sprintf("%s-tile%d.jpg", stem, i))
+		out, err := os.Create(longPath(path))
+		if err != nil {
+			return paths, err
+		}
+		err = jpeg.Encode(out, tile.SubImage(visible), &jpeg.Options{Quality: quality})
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// describeGrid tells how input is tiled.
+func describeGrid(input string) (string, int, error) {
+	f, err := os.Open(longPath(input))
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	g, err := convert.ReadGrid(f)
+	if err != nil {
+		return "", 0, err
+	}
+	if g.Tiles() == 0 {
+		return fmt.Sprintf(tr("%s: %d×%d, not tiled\n"), filepath.Base(input), g.Width, g.Height), 0, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("%s: %d×%d in %d columns and %d rows of %d×%d tiles, numbered from the top left:\n"), filepath.Base(input), g.Width, g.Height, g.Columns, g.Rows, g.TileWidth, g.TileHeight)
+	digits := len(strconv.Itoa(g.Tiles() - 1))
+	for row := 0; row < g.Rows; row++ {
+		for col := 0; col < g.Columns; col++ {
+			fmt.Fprintf(&b, " %*d", digits, row*g.Columns+col)
+		}
+		b.WriteString("\n")
+	}
+	if err := convert.CheckGrid(f); err != nil {
+		fmt.Fprintf(&b, tr("The tiles don't fit the image: %v\n"), err)
+	}
+	return b.String(), g.Tiles(), nil
+}
+
+// tilesCommand shows how a HEIC file is tiled and, with -pick, decodes
+// only the tiles asked for, as previews of parts of a large photo.
+func tilesCommand(args []string) error {
+	fs := flag.NewFlagSet("tiles", flag.ExitOnError)
+	pick := fs.String("pick", "", "tiles to decode, such as 0,5 or 8-11 (default none: only show the layout)")
+	out := fs.String("out", "", "folder for the tiles (default NAME-tiles next to the HEIC file)")
+	quality := fs.Int("quality", 90, "JPEG quality of the tiles")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: heictojpeg tiles [-pick 0,5] [-out DIR] [-quality 90] FILE.heic")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *quality < 1 || *quality > 100 {
+		return fmt.Errorf("invalid quality %d: must be 1 to 100", *quality)
+	}
+
+	input := fs.Arg(0)
+	layout, count, err := describeGrid(input)
+	if err != nil {
+		return err
+	}
+	fmt.Print(layout)
+	if *pick == "" {
+		return nil
+	}
+	if count == 0 {
+		return errors.New("the image isn't tiled")
+	}
+	picks, err := parseTilePicks(*pick, count)
+	if err != nil {
+		return err
+	}
+	dir := *out
+	if dir == "" {
+		dir = strings.TrimSuffix(input, filepath.Ext(input)) + "-tiles"
+	}
+	paths, err := previewTiles(input, picks, dir, *quality)
+	for _, p := range paths {
+		fmt.Printf(tr("Wrote %s\n"), p)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"heictojpeg/convert"
+	"heictojpeg/internal/heicsample"
+)
+
+func TestParseTilePicks(t *testing.T) {
+	got, err := parseTilePicks("0, 5,8-10", 12)
+	if err != nil || len(got) != 5 || got[0] != 0 || got[1] != 5 || got[4] != 10 {
+		t.Errorf("got %v, %v", got, err)
+	}
+	for _, bad := range []string{"12", "-1", "a", "5-3", "3-12", ""} {
+		if _, err := parseTilePicks(bad, 12); err == nil {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+}
+
+func TestPreviewTiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "IMG_1.heic")
+	if err := os.WriteFile(input, heicsample.Tiled(8, 2, 2, 100, 120, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+	layout, count, err := describeGrid(input)
+	if err != nil || count != 4 || !strings.Contains(layout, "2 columns and 2 rows of 64×64") {
+		t.Errorf("layout %q, %d, %v", layout, count, err)
+	}
+
+	paths, err := previewTiles(input, []int{0, 3}, filepath.Join(dir, "tiles"), 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []image.Point{{64, 64}, {36, 56}} {
+		f, err := os.Open(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("%s is %v, want %v", paths[i], got, want)
+		}
+	}
+	if filepath.Base(paths[1]) != "IMG_1-tile3.jpg" {
+		t.Errorf("named %s", paths[1])
+	}
+
+	single := filepath.Join(dir, "IMG_2.heic")
+	os.WriteFile(single, heicsample.Single(8), 0644)
+	if _, err := previewTiles(single, []int{0}, dir, 90); err != convert.ErrNotTiled {
+		t.Errorf("single image: %v", err)
+	}
+}
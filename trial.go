@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"time"
+)
+
+var trialFiles = flag.Int("sample", 0, "convert this many random files at the current settings into a temporary folder and open it, to check the quality before converting everything")
+
+// randomSample picks n of names at random, in name order.
+func randomSample(names []string, n int, r *rand.Rand) []string {
+	names = append([]string(nil), names...)
+	if n < len(names) {
+		r.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+		names = names[:n]
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runTrial converts a random sample of the HEIC files in dir into a new
+// temporary folder, leaving the usual output alone, and opens it.
+func runTrial(ctx context.Context, dir string) error {
+	files, err := getFilesInDirectory(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, file := range files {
+		if isHEIC(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no HEIC files in %s", dir)
+	}
+	trialDir, err := os.MkdirTemp("", "heictojpeg-sample-")
+	if err != nil {
+		return err
+	}
+	// The JPEGs go to the trial folder, not next to the originals.
+	*inPlace = false
+	ctx = withFolderConfigs(ctx, newFolderConfigs(dir))
+
+	sample := randomSample(names, *trialFiles, rand.New(rand.NewSource(time.Now().UnixNano())))
+	fmt.Printf(tr("Converting %d random files of %d at quality %d...\n"), len(sample), len(names), *quality)
+	converted := 0
+	for _, name := range sample {
+		output, err := convertFile(ctx, dir, name, trialDir)
+		if ctx.Err() != nil {
+			break
+		}
+		if reason, ok := skippedBy(err); ok {
+			fmt.Printf(tr("Skipped %s: %s\n"), name, reason)
+			continue
+		}
+		if err != nil && !isWarning(err) {
+			fmt.Printf(tr("Failed to convert %s: %v\n"), name, err)
+			continue
+		}
+		fmt.Printf("%s %s\n", output, humanReadableFileSize(getFileSize(output)))
+		converted++
+	}
+	if converted == 0 {
+		return fmt.Errorf("no sample could be converted")
+	}
+	if err := openWithDesktop(trialDir); err != nil {
+		fmt.Printf(tr("The samples are in %s\n"), trialDir)
+	} else {
+		fmt.Printf(tr("Opened %s\n"), trialDir)
+	}
+	return nil
+}
+
+// openWithDesktop shows target, a folder or a URL, in the file manager or
+// browser, where there is one.
+func openWithDesktop(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("explorer", target)
+	case "darwin":
+		cmd = exec.Command("open", target)
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return fmt.Errorf("no desktop")
+		}
+		cmd = exec.Command("xdg-open", target)
+	}
+	return cmd.Start()
+}
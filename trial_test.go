@@ -0,0 +1,29 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRandomSample(t *testing.T) {
+	names := []string{"e.heic", "a.heic", "c.heic", "b.heic", "d.heic", "f.heic"}
+	got := randomSample(names, 3, rand.New(rand.NewSource(1)))
+	if len(got) != 3 || !sort.StringsAreSorted(got) {
+		t.Fatalf("randomSample = %v, want 3 sorted names", got)
+	}
+	seen := map[string]bool{}
+	for _, name := range got {
+		if seen[name] {
+			t.Errorf("%s picked twice", name)
+		}
+		seen[name] = true
+	}
+	if names[0] != "e.heic" {
+		t.Error("randomSample reordered its argument")
+	}
+	if got := randomSample([]string{"b", "a"}, 5, rand.New(rand.NewSource(1))); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("randomSample with n > len = %v", got)
+	}
+}
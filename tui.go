@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var tuiMode = flag.Bool("tui", false, "show a live terminal dashboard; keys: p pause/resume, s skip the slowest file, 1-9 skip that worker's file, q abort")
+
+const (
+	tuiRecentFiles = 12
+	tuiMessages    = 4
+	tuiNameWidth   = 48
+	tuiPreviewSize = 24 // pixels; a line of the terminal shows two rows
+)
+
+type tuiWorker struct {
+	input   string
+	started time.Time
+}
+
+// tuiPreview is the last converted photo, drawn from its thumbnail.
+type tuiPreview struct {
+	input string
+	lines []string
+	seq   int // of the file whose preview was asked for last
+}
+
+// tui draws the dashboard on the terminal while the run's own console
+// output is captured into its message pane.
+type tui struct {
+	out     io.Writer
+	dir     string
+	control *runControl
+	abort   context.CancelFunc
+
+	mu       sync.Mutex
+	started  time.Time
+	total    int
+	done     int
+	failed   int
+	workers  map[int]tuiWorker
+	recent   []convert.ConversionResult
+	messages []string
+	preview  tuiPreview
+	aborting bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func newTUI(out io.Writer, dir string, control *runControl, abort context.CancelFunc) *tui {
+	return &tui{
+		out:     out,
+		dir:     dir,
+		control: control,
+		abort:   abort,
+		workers: make(map[int]tuiWorker),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (t *tui) OnStart(total int) {
+	t.mu.Lock()
+	t.total, t.started = total, time.Now()
+	t.mu.Unlock()
+}
+
+func (t *tui) OnFileStart(worker int, input string) {
+	t.mu.Lock()
+	t.workers[worker] = tuiWorker{input: input, started: time.Now()}
+	t.mu.Unlock()
+}
+
+func (t *tui) OnFileDone(result convert.ConversionResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, w := range t.workers {
+		if w.input == result.Input {
+			delete(t.workers, id)
+		}
+	}
+	t.done++
+	if result.Err != nil {
+		t.failed++
+	}
+	t.recent = append(t.recent, result)
+	if len(t.recent) > tuiRecentFiles {
+		t.recent = t.recent[len(t.recent)-tuiRecentFiles:]
+	}
+	if result.Err == nil {
+		t.preview.seq++
+		go t.showPreview(result.Input, t.preview.seq)
+	}
+}
+
+// showPreview decodes the preview of input, without holding up the run,
+// and shows it unless a later file's has been asked for since.
+func (t *tui) showPreview(input string, seq int) {
+	img, err := decodePreviewFile(input, tuiPreviewSize)
+	if err != nil {
+		return
+	}
+	lines := halfBlocks(img)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if seq == t.preview.seq {
+		t.preview.input, t.preview.lines = input, lines
+	}
+}
+
+// halfBlocks draws img on the terminal in 24-bit color, two pixels to a
+// character: the upper half block takes the top one's color, and its
+// background the bottom one's.
+func halfBlocks(img image.Image) []string {
+	b := img.Bounds()
+	var lines []string
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		var line strings.Builder
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			fmt.Fprintf(&line, "\x1b[38;2;%d;%d;%dm", r>>8, g>>8, bl>>8)
+			if y+1 < b.Max.Y {
+				r, g, bl, _ = img.At(x, y+1).RGBA()
+				fmt.Fprintf(&line, "\x1b[48;2;%d;%d;%dm", r>>8, g>>8, bl>>8)
+			} else {
+				line.WriteString("\x1b[49m")
+			}
+			line.WriteString("▀")
+		}
+		line.WriteString("\x1b[0m")
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func (t *tui) OnFinish(summary convert.Summary) {
+	t.mu.Lock()
+	if t.total == convert.UnknownTotal {
+		t.total = summary.Files
+	}
+	t.mu.Unlock()
+}
+
+func (t *tui) addMessage(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, line)
+	if len(t.messages) > tuiMessages {
+		t.messages = t.messages[len(t.messages)-tuiMessages:]
+	}
+}
+
+func (t *tui) handleKey(key byte) {
+	switch key {
+	case 'p', 'P', ' ':
+		if t.control.togglePause() {
+			t.addMessage(tr("Paused: running files finish, no new ones start. Press p to resume."))
+		} else {
+			t.addMessage(tr("Resumed."))
+		}
+	case 's', 'S':
+		if !t.control.skipLongest() {
+			t.addMessage(tr("Nothing to skip."))
+		}
+	case 'q', 'Q', 3: // 3 is Ctrl+C in raw mode
+		t.mu.Lock()
+		t.aborting = true
+		t.mu.Unlock()
+		t.addMessage(tr("Aborting, the log is still written..."))
+		t.abort()
+	default:
+		if key >= '1' && key <= '9' && !t.control.skip(int(key-'1')) {
+			t.addMessage(fmt.Sprintf(tr("Worker %c is idle."), key))
+		}
+	}
+}
+
+// run redraws the screen until close is called.
+func (t *tui) run() {
+	defer close(t.stopped)
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		t.draw()
+		select {
+		case <-t.stop:
+			fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *tui) close() {
+	close(t.stop)
+	<-t.stopped
+}
+
+func (t *tui) draw() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	state := tr("running")
+	if t.aborting {
+		state = tr("aborting")
+	} else if t.control.paused() {
+		state = tr("PAUSED")
+	}
+	elapsed := time.Duration(0)
+	if !t.started.IsZero() {
+		elapsed = time.Since(t.started).Round(time.Second)
+	}
+	fmt.Fprintf(&b, "heictojpeg  %s  [%s]\r\n", t.dir, state)
+	if t.total == convert.UnknownTotal {
+		fmt.Fprintf(&b, tr("%d files done (still scanning), %d failed, %v elapsed")+"\r\n\r\n", t.done, t.failed, elapsed)
+	} else {
+		fmt.Fprintf(&b, tr("%d/%d files done, %d failed, %v elapsed")+"\r\n\r\n", t.done, t.total, t.failed, elapsed)
+	}
+
+	b.WriteString(tr("Workers") + "\r\n")
+	ids := make([]int, 0, len(t.workers))
+	for id := range t.workers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	if len(ids) == 0 {
+		b.WriteString("  " + tr("(idle)") + "\r\n")
+	}
+	for _, id := range ids {
+		w := t.workers[id]
+		fmt.Fprintf(&b, "  %d  %-*s %v\r\n", id+1, tuiNameWidth, t.shortName(w.input), time.Since(w.started).Round(time.Second))
+	}
+
+	b.WriteString("\r\n" + tr("Recent files") + "\r\n")
+	for _, r := range t.recent {
+		status := humanReadableFileSize(r.InputSize) + " > " + humanReadableFileSize(r.OutputSize)
+		if r.Err != nil {
+			status = tr("FAILED: ") + r.Err.Error()
+		}
+		fmt.Fprintf(&b, "  %-*s %s\r\n", tuiNameWidth, t.shortName(r.Input), status)
+	}
+
+	if t.preview.lines != nil {
+		b.WriteString("\r\n" + t.shortName(t.preview.input) + "\r\n")
+		for _, line := range t.preview.lines {
+			b.WriteString("  " + line + "\r\n")
+		}
+	}
+
+	if len(t.messages) > 0 {
+		b.WriteString("\r\n")
+		for _, m := range t.messages {
+			fmt.Fprintf(&b, "  %s\r\n", m)
+		}
+	}
+	b.WriteString("\r\n" + tr("[p] pause/resume  [s] skip slowest  [1-9] skip worker's file  [q] abort") + "\r\n")
+	io.WriteString(t.out, b.String())
+}
+
+func (t *tui) shortName(path string) string {
+	name, err := filepath.Rel(t.dir, path)
+	if err != nil {
+		name = path
+	}
+	if r := []rune(name); len(r) > tuiNameWidth {
+		name = "..." + string(r[len(r)-tuiNameWidth+3:])
+	}
+	return name
+}
+
+// runWithTUI calls run with the dashboard on the terminal. The console
+// output of the run is captured and shown in the message pane.
+func runWithTUI(ctx context.Context, dir string, run func(ctx context.Context, observers ...convert.Observer) error, observers ...convert.Observer) error {
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	control := runControlFrom(ctx)
+	if control == nil {
+		control = newRunControl()
+		ctx = withRunControl(ctx, control)
+	}
+
+	restore, err := enableRawInput()
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %v", err)
+	}
+	defer restore()
+
+	terminal := os.Stdout
+	t := newTUI(terminal, dir, control, abort)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	os.Stdout = w
+	captured := make(chan struct{})
+	go func() {
+		defer close(captured)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			t.addMessage(scanner.Text())
+		}
+	}()
+
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				return
+			}
+			t.handleKey(buf[0])
+		}
+	}()
+
+	go t.run()
+	err = run(ctx, append(observers, t)...)
+
+	os.Stdout = terminal
+	w.Close()
+	<-captured
+	t.close()
+
+	t.mu.Lock()
+	fmt.Printf(tr("%d of %d files converted, %d failed, in %v.\n"), t.done-t.failed, t.total, t.failed, time.Since(t.started).Round(time.Millisecond))
+	t.mu.Unlock()
+	return err
+}
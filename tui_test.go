@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"heictojpeg/convert"
+)
+
+// Testing the dashboard shows workers, results and key actions
+func TestTUIDraw(t *testing.T) {
+	var out bytes.Buffer
+	control := newRunControl()
+	aborted := false
+	ui := newTUI(&out, "/photos", control, func() { aborted = true })
+
+	ui.OnStart(3)
+	ui.OnFileStart(0, "/photos/IMG_0001.HEIC")
+	ui.OnFileStart(1, "/photos/IMG_0002.HEIC")
+	ui.OnFileDone(convert.ConversionResult{Input: "/photos/IMG_0002.HEIC", Err: errors.New("decode timeout")})
+	ui.handleKey('p')
+	ui.draw()
+
+	screen := out.String()
+	for _, want := range []string{"[PAUSED]", "1/3 files done, 1 failed", "1  IMG_0001.HEIC", "IMG_0002.HEIC", "FAILED: decode timeout"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Screen is missing %q:\n%s", want, screen)
+		}
+	}
+
+	ui.handleKey('q')
+	if !aborted {
+		t.Errorf("q should abort the run")
+	}
+}
+
+func TestHalfBlocks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 3))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(0, 1, color.RGBA{0, 0, 255, 255})
+	lines := halfBlocks(img)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines for 3 rows, want 2", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀") {
+		t.Errorf("the first block doesn't show red over blue: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "\x1b[49m▀") {
+		t.Errorf("the odd last row should leave the background alone: %q", lines[1])
+	}
+}
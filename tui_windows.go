@@ -0,0 +1,50 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	enableEchoInput                 = 0x0004
+	enableLineInput                 = 0x0002
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+func setConsoleMode(h syscall.Handle, mode uint32) error {
+	r, _, err := procSetConsoleMode.Call(uintptr(h), uintptr(mode))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// enableRawInput turns off line buffering and echo on the console and
+// enables the ANSI escapes the dashboard draws with.
+func enableRawInput() (func(), error) {
+	in := syscall.Handle(os.Stdin.Fd())
+	out := syscall.Handle(os.Stdout.Fd())
+
+	var inMode, outMode uint32
+	if err := syscall.GetConsoleMode(in, &inMode); err != nil {
+		return nil, err
+	}
+	if err := syscall.GetConsoleMode(out, &outMode); err != nil {
+		return nil, err
+	}
+	if err := setConsoleMode(in, inMode&^(enableEchoInput|enableLineInput)); err != nil {
+		return nil, err
+	}
+	if err := setConsoleMode(out, outMode|enableVirtualTerminalProcessing); err != nil {
+		setConsoleMode(in, inMode)
+		return nil, err
+	}
+	return func() {
+		setConsoleMode(in, inMode)
+		setConsoleMode(out, outMode)
+	}, nil
+}
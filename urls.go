@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var downloadDir = flag.String("download-dir", ".", "folder that http(s) URLs given as targets are downloaded to before converting; their JPEGs go in its jpegs subfolder")
+
+// downloadAttempts is how many times a download is tried before it fails.
+const downloadAttempts = 3
+
+// downloadBackoff is the wait before the second attempt, doubling for each
+// one after that; tests shorten it.
+var downloadBackoff = time.Second
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// downloadTargets downloads the URLs among targets into dir, a few at a
+// time, and returns the targets with each URL replaced by the downloaded
+// file. A URL that can't be downloaded is reported and left out, like a
+// file that fails to convert.
+func downloadTargets(ctx context.Context, targets []string, dir string) ([]string, error) {
+	var urls []int
+	for i, target := range targets {
+		if isURL(target) {
+			urls = append(urls, i)
+		}
+	}
+	if len(urls) == 0 {
+		return targets, nil
+	}
+	if err := os.MkdirAll(longPath(dir), 0755); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(targets))
+	copy(paths, targets)
+	used := make(map[string]bool)
+	var created []string
+	for _, i := range urls {
+		name, err := createUnique(dir, downloadName(targets[i]), used)
+		if err != nil {
+			for _, path := range created {
+				os.Remove(longPath(path))
+			}
+			return nil, err
+		}
+		paths[i] = filepath.Join(dir, name)
+		created = append(created, paths[i])
+	}
+
+	infof(tr("Downloading %d files...\n"), len(urls))
+	failed := make([]bool, len(targets))
+	sem := make(chan struct{}, workerCount())
+	var wg sync.WaitGroup
+	for _, i := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := downloadFile(ctx, targets[i], paths[i]); err != nil {
+				fmt.Printf(tr("Failed to download %s: %v\n"), targets[i], err)
+				failed[i] = true
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var downloaded []string
+	for i, path := range paths {
+		if !failed[i] {
+			downloaded = append(downloaded, path)
+		}
+	}
+	return downloaded, nil
+}
+
+// downloadName is the file name a URL is saved as: the last part of its
+// path, with .heic added when it has no extension.
+func downloadName(rawURL string) string {
+	name := "download"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." {
+			name = base
+		}
+	}
+	if filepath.Ext(name) == "" {
+		name += ".heic"
+	}
+	return sanitizePath(name)
+}
+
+// createUnique creates an empty file named name in dir for a download to
+// go in, numbered (IMG-2.heic) when a file of that name is already there
+// or in used, so a photo already in the folder is never overwritten.
+func createUnique(dir, name string, used map[string]bool) (string, error) {
+	unique := name
+	for n := 2; ; n++ {
+		if !used[strings.ToLower(unique)] {
+			f, err := os.OpenFile(longPath(filepath.Join(dir, unique)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err == nil {
+				f.Close()
+				used[strings.ToLower(unique)] = true
+				return unique, nil
+			}
+			if !os.IsExist(err) {
+				return "", err
+			}
+		}
+		unique = strings.TrimSuffix(name, filepath.Ext(name)) + "-" + strconv.Itoa(n) + filepath.Ext(name)
+	}
+}
+
+// downloadFile fetches url into path, trying again after network errors
+// and server errors (5xx, 429). path must be the caller's own, such as a
+// file createUnique made: it is overwritten, and removed on failure.
+func downloadFile(ctx context.Context, url, path string) error {
+	wait := downloadBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = fetchOnce(ctx, url, path); err == nil || !retry || attempt == downloadAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	if err != nil {
+		os.Remove(longPath(path))
+	}
+	return err
+}
+
+// fetchOnce makes one attempt at downloading url into path, and reports
+// whether a failure is worth another attempt.
+func fetchOnce(ctx context.Context, url, path string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	f, err := os.Create(longPath(path))
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// A connection dropped mid-body is worth another try; a full disk isn't.
+		var pathErr *os.PathError
+		return ctx.Err() == nil && !errors.As(err, &pathErr), err
+	}
+	return false, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Testing downloadTargets with a flaky and a missing URL
+func TestDownloadTargets(t *testing.T) {
+	defer func(d time.Duration) { downloadBackoff = d }(downloadBackoff)
+	downloadBackoff = time.Millisecond
+
+	var flaky int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky/IMG_0001.HEIC":
+			if atomic.AddInt32(&flaky, 1) == 1 {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+				return
+			}
+		case "/other/IMG_0001.HEIC", "/photo":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("heic " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	// Photos already in the folder are kept.
+	for _, name := range []string{"IMG_0001.HEIC", "missing.heic"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("mine"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets := []string{
+		"local.heic",
+		srv.URL + "/flaky/IMG_0001.HEIC",
+		srv.URL + "/other/IMG_0001.HEIC",
+		srv.URL + "/missing.heic",
+		srv.URL + "/photo?size=full",
+	}
+	got, err := downloadTargets(context.Background(), targets, dir)
+	if err != nil {
+		t.Fatalf("downloadTargets failed: %v", err)
+	}
+
+	want := []string{
+		"local.heic",
+		filepath.Join(dir, "IMG_0001-2.HEIC"),
+		filepath.Join(dir, "IMG_0001-3.HEIC"),
+		filepath.Join(dir, "photo.heic"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("downloadTargets = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Target %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if data, err := os.ReadFile(want[1]); err != nil || string(data) != "heic /flaky/IMG_0001.HEIC" {
+		t.Errorf("The flaky download should be retried: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing-2.heic")); !os.IsNotExist(err) {
+		t.Errorf("A failed download should leave no file: %v", err)
+	}
+	for _, name := range []string{"IMG_0001.HEIC", "missing.heic"} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != "mine" {
+			t.Errorf("%s was overwritten: %q, %v", name, data, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/adrium/goheif/heif"
+)
+
+var verbosity = flag.String("verbosity", "normal", "console and logs.txt detail: quiet (only failures and warnings), normal, verbose (adds timings and memory statistics) or debug (adds decoder diagnostics per file)")
+
+// verbose predates -verbosity and is the same as -verbosity verbose.
+var verbose = flag.Bool("verbose", false, "same as -verbosity verbose")
+
+type level int
+
+const (
+	levelQuiet level = iota
+	levelNormal
+	levelVerbose
+	levelDebug
+)
+
+var verbosityLevels = map[string]level{"quiet": levelQuiet, "normal": levelNormal, "verbose": levelVerbose, "debug": levelDebug}
+
+// logLevel is set from -verbosity (and the older -verbose) in main.
+var logLevel = levelNormal
+
+func atLevel(l level) bool {
+	return logLevel >= l
+}
+
+// infof prints progress chatter that -verbosity quiet leaves out.
+func infof(format string, args ...interface{}) {
+	if atLevel(levelNormal) {
+		fmt.Printf(format, args...)
+	}
+}
+
+func infoln(args ...interface{}) {
+	if atLevel(levelNormal) {
+		fmt.Println(args...)
+	}
+}
+
+// maxDiagnosedBoxes keeps the box listing of a damaged file readable.
+const maxDiagnosedBoxes = 32
+
+// diagnose describes how a HEIC file is put together, for triaging files
+// the decoder doesn't support: the top-level boxes, the brands, and the
+// primary item with its properties, tiles and EXIF.
+func diagnose(path string) []string {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return []string{fmt.Sprintf("open: %v", err)}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return []string{fmt.Sprintf("stat: %v", err)}
+	}
+
+	lines := describeBoxes(f, info.Size())
+	hf := heif.Open(f)
+	item, err := hf.PrimaryItem()
+	if err != nil {
+		return append(lines, fmt.Sprintf("primary item: %v", err))
+	}
+
+	line := fmt.Sprintf("primary item %d", item.ID)
+	if item.Info != nil {
+		line += " type " + item.Info.ItemType
+	}
+	if w, h, ok := item.SpatialExtents(); ok {
+		line += fmt.Sprintf(", %dx%d", w, h)
+	}
+	if r := item.Rotations(); r != 0 {
+		line += fmt.Sprintf(", rotated %d°", r*90)
+	}
+	if m := item.Mirror(); m != 0 {
+		line += fmt.Sprintf(", mirrored (axis %d)", m)
+	}
+	lines = append(lines, line)
+
+	var props []string
+	for _, p := range item.Properties {
+		props = append(props, p.Type().String())
+	}
+	lines = append(lines, "properties: "+strings.Join(props, " "))
+	if ref := item.Reference("dimg"); ref != nil {
+		lines = append(lines, fmt.Sprintf("grid of %d tiles", len(ref.ToItemIDs)))
+	}
+	if exif, err := hf.EXIF(); err != nil {
+		lines = append(lines, fmt.Sprintf("exif: %v", err))
+	} else {
+		lines = append(lines, fmt.Sprintf("exif: %d bytes", len(exif)))
+	}
+	return lines
+}
+
+// describeBoxes lists the top-level boxes with their offsets and sizes,
+// and the brands from the ftyp box.
+func describeBoxes(r io.ReaderAt, size int64) []string {
+	var lines []string
+	var header [16]byte
+	for offset := int64(0); offset < size; {
+		if len(lines) == maxDiagnosedBoxes {
+			return append(lines, "...")
+		}
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return append(lines, fmt.Sprintf("box at %d: %v", offset, err))
+		}
+		boxType := string(header[4:8])
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return append(lines, fmt.Sprintf("box %q at %d: %v", boxType, offset, err))
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+		line := fmt.Sprintf("box %q at %d, %d bytes", boxType, offset, boxSize)
+		if boxType == "ftyp" && boxSize >= 16 && boxSize <= 1024 {
+			line += ", brand " + fileTypeBrands(r, offset, boxSize)
+		}
+		if boxSize < 8 {
+			return append(lines, line+" (invalid size)")
+		}
+		if offset+boxSize > size {
+			line += fmt.Sprintf(" (only %d present)", size-offset)
+		}
+		lines = append(lines, line)
+		offset += boxSize
+	}
+	return lines
+}
+
+func fileTypeBrands(r io.ReaderAt, offset, size int64) string {
+	buf := make([]byte, size-8)
+	if _, err := r.ReadAt(buf, offset+8); err != nil {
+		return "?"
+	}
+	var compatible []string
+	for i := 8; i+4 <= len(buf); i += 4 {
+		compatible = append(compatible, string(buf[i:i+4]))
+	}
+	return string(buf[:4]) + ", compatible " + strings.Join(compatible, " ")
+}
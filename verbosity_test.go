@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+func TestDescribeBoxes(t *testing.T) {
+	ftyp := box("ftyp", 12)
+	copy(ftyp[8:], "heic\x00\x00\x00\x00mif1")
+	data := append(ftyp, box("mdat", 92)[:13]...)
+
+	got := strings.Join(describeBoxes(bytes.NewReader(data), int64(len(data))), "\n")
+	want := `box "ftyp" at 0, 20 bytes, brand heic, compatible mif1
+box "mdat" at 20, 100 bytes (only 13 present)`
+	if got != want {
+		t.Errorf("describeBoxes =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Testing -verbosity quiet keeps only problems in the report
+func TestAggregateLogsQuiet(t *testing.T) {
+	defer func(old level) { logLevel = old }(logLevel)
+
+	for _, c := range []struct {
+		level level
+		lines int
+		took  bool
+	}{{levelQuiet, 2, false}, {levelNormal, 3, false}, {levelVerbose, 3, true}} {
+		logLevel = c.level
+		results := make(chan convert.ConversionResult, 3)
+		results <- convert.ConversionResult{Name: "ok.heic", Duration: time.Second}
+		results <- convert.ConversionResult{Name: "bad.heic", Err: errors.New("boom")}
+		results <- convert.ConversionResult{Name: "cut.heic", Warning: "file is truncated"}
+		close(results)
+
+		logs := make(map[string][]string)
+		aggregateLogs(results, logs, t.TempDir(), t.TempDir(), time.Now(), nil)
+		if got := len(logs) - 1; got != c.lines {
+			t.Errorf("level %d: %d files in the report, want %d", c.level, got, c.lines)
+		}
+		if !strings.Contains(strings.Join(logs["general"], "\n"), "3 Files") {
+			t.Errorf("level %d: general logs should still count every file: %v", c.level, logs["general"])
+		}
+		if took := strings.Contains(strings.Join(logs["bad.heic"], ""), "Took"); took != c.took {
+			t.Errorf("level %d: timing shown = %v", c.level, took)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+// extern const char* de265_get_version(void);
+import "C"
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// commit is the revision this binary was built from, set with
+// -ldflags "-X main.commit=abc1234" when the build has no VCS stamp, as
+// with packaging from a source tarball.
+var commit = ""
+
+const goheifModule = "github.com/adrium/goheif"
+
+func init() {
+	subcommands["version"] = versionCommand
+}
+
+// buildInfo identifies the binary and its decode stack, for packagers and
+// bug reports.
+type buildInfo struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Modified bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	Built    string `json:"built,omitempty"`    // time of the commit
+	Go       string `json:"go"`
+	Platform string `json:"platform"`
+	Goheif   string `json:"goheif"`
+	Libde265 string `json:"libde265"`
+}
+
+// currentBuild reads the module versions and VCS stamp Go embeds, and asks
+// the libde265 that goheif compiled into the binary for its version.
+func currentBuild() buildInfo {
+	b := buildInfo{
+		Version:  version,
+		Commit:   commit,
+		Go:       runtime.Version(),
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Libde265: C.GoString(C.de265_get_version()),
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == goheifModule {
+			b.Goheif = dep.Version
+			if dep.Replace != nil {
+				b.Goheif = dep.Replace.Path + " " + dep.Replace.Version
+			}
+		}
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if b.Commit == "" {
+				b.Commit = s.Value
+			}
+		case "vcs.time":
+			b.Built = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	return b
+}
+
+func (b buildInfo) String() string {
+	s := "heictojpeg " + b.Version
+	if b.Commit != "" {
+		s += " (" + shortCommit(b.Commit)
+		if b.Modified {
+			s += ", modified"
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s\n%s %s\ngoheif %s, libde265 %s\n", s, b.Go, b.Platform, b.Goheif, b.Libde265)
+}
+
+func shortCommit(c string) string {
+	if len(c) > 12 {
+		return c[:12]
+	}
+	return c
+}
+
+func versionCommand(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the version as JSON")
+	fs.Parse(args)
+
+	b := currentBuild()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(b)
+	}
+	fmt.Print(b)
+	return nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCurrentBuild(t *testing.T) {
+	b := currentBuild()
+	if b.Libde265 == "" || b.Platform == "" {
+		t.Errorf("build info %+v lacks the decoder or platform", b)
+	}
+	if s := b.String(); !strings.Contains(s, "heictojpeg "+version) || !strings.Contains(s, "libde265 "+b.Libde265) {
+		t.Errorf("String() = %q", s)
+	}
+}
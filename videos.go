@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	videos     = flag.Bool("videos", false, "also transcode HEVC .mov and .mp4 videos in the folder to H.264 MP4 under jpegs/, with ffmpeg")
+	ffmpegPath = flag.String("ffmpeg", "ffmpeg", "the ffmpeg program -videos runs")
+	videoCRF   = flag.Int("video-crf", 20, "H.264 quality for -videos, from 0 (lossless) to 51; lower is better and larger")
+)
+
+// videosLogKey holds the video lines of logs.txt, written after the
+// photos.
+const videosLogKey = "videos"
+
+// ffmpegCommand builds the ffmpeg process; tests replace it.
+var ffmpegCommand = func(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, *ffmpegPath, args...)
+}
+
+func isVideo(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mov", ".mp4":
+		return true
+	}
+	return false
+}
+
+// isHEVCVideo reports whether the video at path has an HEVC track, from
+// the sample descriptions in its moov box.
+func isHEVCVideo(path string) (bool, error) {
+	f, err := os.Open(longPath(path))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	var offset int64
+	for {
+		var header [16]byte
+		if _, err := f.ReadAt(header[:8], offset); err != nil {
+			if err == io.EOF {
+				return false, errors.New("no moov box")
+			}
+			return false, err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			return false, errors.New("no moov box")
+		case 1:
+			if _, err := f.ReadAt(header[8:16], offset+8); err != nil {
+				return false, err
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header[8:16])), 16
+		}
+		if size < headerSize {
+			return false, errors.New("malformed video")
+		}
+		if string(header[4:8]) == "moov" {
+			if size > 256<<20 {
+				return false, errors.New("moov box too large")
+			}
+			moov := make([]byte, size-headerSize)
+			if _, err := f.ReadAt(moov, offset+headerSize); err != nil {
+				return false, err
+			}
+			return bytes.Contains(moov, []byte("hvc1")) || bytes.Contains(moov, []byte("hev1")), nil
+		}
+		offset += size
+	}
+}
+
+// videoOutputPath is where the MP4 of a video goes, e.g. jpegs/IMG_1.mp4.
+func videoOutputPath(jpegDir, rel string) string {
+	return filepath.Join(jpegDir, sanitizePath(toNFC(strings.TrimSuffix(rel, filepath.Ext(rel)))+".mp4"))
+}
+
+// transcodeVideo converts one HEVC video to H.264, through a temporary
+// file so an interrupted run leaves no half-written MP4.
+func transcodeVideo(ctx context.Context, input, output string) error {
+	if err := os.MkdirAll(longPath(filepath.Dir(output)), 0755); err != nil {
+		return err
+	}
+	tmp := output + ".part"
+	cmd := ffmpegCommand(ctx, "-nostdin", "-y", "-loglevel", "error",
+		"-i", input,
+		"-map", "0:v:0", "-map", "0:a?",
+		"-c:v", "libx264", "-crf", strconv.Itoa(*videoCRF), "-preset", "medium", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "192k",
+		"-map_metadata", "0", "-movflags", "+faststart",
+		"-f", "mp4", tmp,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(longPath(tmp))
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %s", lastLine(msg))
+		}
+		return fmt.Errorf("ffmpeg: %v", err)
+	}
+	return os.Rename(longPath(tmp), longPath(output))
+}
+
+func lastLine(s string) string {
+	return s[strings.LastIndexByte(s, '\n')+1:]
+}
+
+// transcodeVideos converts the HEVC videos among files for -videos, one
+// at a time since ffmpeg uses every core, and adds their lines under
+// videosLogKey. Videos already transcoded since they last changed are
+// left alone.
+func transcodeVideos(ctx context.Context, currentDir, jpegDir string, files []os.DirEntry, logs map[string][]string) {
+	var lines []string
+	transcoded, failed := 0, 0
+	var inputSize, outputSize int64
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if file.IsDir() || !isVideo(file.Name()) {
+			continue
+		}
+		input := filepath.Join(currentDir, file.Name())
+		output := videoOutputPath(jpegDir, file.Name())
+		size := humanReadableFileSize(getFileSize(input))
+
+		hevc, err := isHEVCVideo(input)
+		switch {
+		case err != nil:
+			failed++
+			lines = append(lines, fmt.Sprintf(tr("%s %s > Failed > error details: %s"), file.Name(), size, err))
+			continue
+		case !hevc:
+			lines = append(lines, fmt.Sprintf(tr("%s %s > Skipped > %s"), file.Name(), size, tr("not HEVC")))
+			continue
+		case upToDate(input, output):
+			lines = append(lines, fmt.Sprintf(tr("%s %s > Skipped > %s"), file.Name(), size, tr("already transcoded")))
+			continue
+		}
+
+		infof(tr("Transcoding video: %s\n"), file.Name())
+		started := time.Now()
+		created := !fileExists(output)
+		err = transcodeVideo(ctx, input, output)
+		if j := journalFrom(ctx); j != nil && created && fileExists(output) {
+			j.created(output)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf(tr("Failed to transcode %s: %v\n"), file.Name(), err)
+			lines = append(lines, fmt.Sprintf(tr("%s %s > Failed > error details: %s"), file.Name(), size, err))
+			continue
+		}
+		transcoded++
+		inputSize += getFileSize(input)
+		outputSize += getFileSize(output)
+		rel, _ := filepath.Rel(jpegDir, output)
+		line := fmt.Sprintf(tr("%s %s > Transcoded > jpegs/%s %s"), file.Name(), size, filepath.ToSlash(rel), humanReadableFileSize(getFileSize(output)))
+		if atLevel(levelVerbose) {
+			line += fmt.Sprintf(tr(" > Took %v"), time.Since(started).Round(time.Millisecond))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	lines = append([]string{tr("\nVideos:")}, lines...)
+	lines = append(lines, fmt.Sprintf(tr("%d videos transcoded, %d failed (%s to %s)"), transcoded, failed, humanReadableFileSize(inputSize), humanReadableFileSize(outputSize)))
+	logs[videosLogKey] = lines
+}
+
+// upToDate reports whether output was written after input last changed.
+func upToDate(input, output string) bool {
+	in, err := os.Stat(longPath(input))
+	if err != nil {
+		return false
+	}
+	out, err := os.Stat(longPath(output))
+	return err == nil && !out.ModTime().Before(in.ModTime())
+}
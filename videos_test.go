@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testVideo is an MP4 whose moov box names the codec of its track.
+func testVideo(codec string) []byte {
+	ftyp := box("ftyp", 8)
+	copy(ftyp[8:], "qt  \x00\x00\x00\x00")
+	moov := box("moov", 16)
+	copy(moov[8:], "stsd"+codec+"\x00\x00\x00\x00\x00\x00\x00\x00")
+	return append(append(ftyp, box("mdat", 32)...), moov...)
+}
+
+func TestIsHEVCVideo(t *testing.T) {
+	dir := t.TempDir()
+	for name, want := range map[string]bool{"hvc1": true, "hev1": true, "avc1": false} {
+		path := filepath.Join(dir, name+".mov")
+		os.WriteFile(path, testVideo(name), 0644)
+		if got, err := isHEVCVideo(path); err != nil || got != want {
+			t.Errorf("%s: isHEVCVideo = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	path := filepath.Join(dir, "cut.mov")
+	os.WriteFile(path, box("ftyp", 8), 0644)
+	if _, err := isHEVCVideo(path); err == nil {
+		t.Error("video without moov accepted")
+	}
+}
+
+// TestFakeFFmpeg is the ffmpeg of the tests below: it writes its output.
+func TestFakeFFmpeg(t *testing.T) {
+	if os.Getenv("HEICTOJPEG_TEST_FFMPEG") == "" {
+		return
+	}
+	args := os.Args
+	for i, a := range args {
+		if a == "-i" && strings.Contains(args[i+1], "broken") {
+			os.Stderr.WriteString("frame=0\nInvalid data found when processing input\n")
+			os.Exit(1)
+		}
+	}
+	os.WriteFile(args[len(args)-1], []byte("mp4"), 0644)
+	os.Exit(0)
+}
+
+func useFakeFFmpeg(t *testing.T) {
+	old := ffmpegCommand
+	ffmpegCommand = func(ctx context.Context, args ...string) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, os.Args[0], append([]string{"-test.run=TestFakeFFmpeg", "--"}, args...)...)
+		cmd.Env = append(os.Environ(), "HEICTOJPEG_TEST_FFMPEG=1")
+		return cmd
+	}
+	t.Cleanup(func() { ffmpegCommand = old })
+}
+
+func TestTranscodeVideos(t *testing.T) {
+	useFakeFFmpeg(t)
+	dir := t.TempDir()
+	jpegDir := filepath.Join(dir, "jpegs")
+	os.WriteFile(filepath.Join(dir, "IMG_1.MOV"), testVideo("hvc1"), 0644)
+	os.WriteFile(filepath.Join(dir, "IMG_2.mp4"), testVideo("avc1"), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.mov"), testVideo("hvc1"), 0644)
+	os.WriteFile(filepath.Join(dir, "IMG_3.heic"), nil, 0644)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := map[string][]string{}
+	transcodeVideos(context.Background(), dir, jpegDir, files, logs)
+	if _, err := os.Stat(filepath.Join(jpegDir, "IMG_1.mp4")); err != nil {
+		t.Errorf("IMG_1.MOV not transcoded: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(jpegDir, "broken.mp4.part")); !os.IsNotExist(err) {
+		t.Error("failed transcode left its temporary file")
+	}
+	got := strings.Join(logs[videosLogKey], "\n")
+	for _, want := range []string{
+		"IMG_1.MOV 80B > Transcoded > jpegs/IMG_1.mp4 3B",
+		"IMG_2.mp4 80B > Skipped > not HEVC",
+		"broken.mov 80B > Failed > error details: ffmpeg: Invalid data found when processing input",
+		"1 videos transcoded, 1 failed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("video logs lack %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "IMG_3") {
+		t.Error("photo listed with the videos")
+	}
+
+	// A rerun leaves the transcoded video alone.
+	logs = map[string][]string{}
+	transcodeVideos(context.Background(), dir, jpegDir, files, logs)
+	if got := strings.Join(logs[videosLogKey], "\n"); !strings.Contains(got, "IMG_1.MOV 80B > Skipped > already transcoded") {
+		t.Errorf("rerun logs:\n%s", got)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	recursive      = flag.Bool("recursive", false, "also convert .heic files in subdirectories, mirroring them under jpegs/")
+	followSymlinks = flag.Bool("follow-symlinks", false, "follow symlinked directories when recursing")
+	oneFileSystem  = flag.Bool("one-file-system", false, "don't recurse into directories on other filesystems (mount points)")
+	symlinkNames   = flag.String("symlink-names", "link", "name outputs of symlinked files after the \"link\" or its \"target\"")
+)
+
+// scannedFile is a file found while recursing. Name reports the path
+// relative to the scan root so nested files keep their subdirectory
+// in the output tree.
+type scannedFile struct {
+	fs.DirEntry
+	rel string
+}
+
+func (f scannedFile) Name() string {
+	return f.rel
+}
+
+// scanConcurrency caps how many directories are read at the same time.
+const scanConcurrency = 8
+
+type walker struct {
+	root    string
+	rootDev uint64
+	emit    func(os.DirEntry) bool
+	slots   chan struct{}
+	wg      sync.WaitGroup
+	stopped atomic.Bool
+}
+
+func newWalker(root string, emit func(os.DirEntry) bool) (*walker, os.FileInfo, error) {
+	rootInfo, err := os.Stat(longPath(root))
+	if err != nil {
+		return nil, nil, err
+	}
+	w := &walker{root: root, emit: emit, slots: make(chan struct{}, scanConcurrency)}
+	w.rootDev, _ = deviceID(root, rootInfo)
+	return w, rootInfo, nil
+}
+
+// walkDirectory lists every file under root, in the same order a depth
+// first walk over sorted directory entries would find them.
+func walkDirectory(root string) ([]os.DirEntry, error) {
+	var mu sync.Mutex
+	var files []os.DirEntry
+	w, rootInfo, err := newWalker(root, func(file os.DirEntry) bool {
+		mu.Lock()
+		files = append(files, file)
+		mu.Unlock()
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = w.walk("", []os.FileInfo{rootInfo})
+	w.wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return pathLess(files[i].Name(), files[j].Name())
+	})
+	return files, nil
+}
+
+// streamDirectory walks root in the background and sends each file to
+// the returned channel as soon as it is found, so conversion can start
+// before a large tree has been listed. The channel is closed when the
+// walk ends or ctx is cancelled; wait then reports whether root itself
+// could be read.
+func streamDirectory(ctx context.Context, root string, buffer int) (files <-chan os.DirEntry, wait func() error, err error) {
+	out := make(chan os.DirEntry, buffer)
+	w, rootInfo, err := newWalker(root, func(file os.DirEntry) bool {
+		select {
+		case out <- file:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		err := w.walk("", []os.FileInfo{rootInfo})
+		w.wg.Wait()
+		close(out)
+		result <- err
+	}()
+	return out, func() error { return <-result }, nil
+}
+
+// pathLess orders relative paths component by component, which keeps a
+// directory's files next to each other regardless of separator sorting.
+func pathLess(a, b string) bool {
+	ap := strings.Split(a, string(filepath.Separator))
+	bp := strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if ap[i] != bp[i] {
+			return ap[i] < bp[i]
+		}
+	}
+	return len(ap) < len(bp)
+}
+
+func (w *walker) walk(rel string, ancestors []os.FileInfo) error {
+	entries, err := os.ReadDir(longPath(filepath.Join(w.root, rel)))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if w.stopped.Load() {
+			return nil
+		}
+		childRel := filepath.Join(rel, entry.Name())
+		childPath := filepath.Join(w.root, childRel)
+		if rel == "" && entry.Name() == "jpegs" {
+			continue
+		}
+
+		isDir := entry.IsDir()
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(longPath(childPath))
+			if err != nil {
+				fmt.Printf(tr("Skipping broken symlink: %s\n"), childRel)
+				continue
+			}
+			if info.IsDir() && !*followSymlinks {
+				fmt.Printf(tr("Skipping symlinked directory: %s\n"), childRel)
+				continue
+			}
+			isDir = info.IsDir()
+		}
+
+		if !isDir {
+			if !w.emit(scannedFile{DirEntry: entry, rel: childRel}) {
+				w.stopped.Store(true)
+			}
+			continue
+		}
+
+		info, err := os.Stat(longPath(childPath))
+		if err != nil {
+			fmt.Printf(tr("Failed to read directory %s: %v\n"), childRel, err)
+			continue
+		}
+		if isAncestor(info, ancestors) {
+			fmt.Printf(tr("Skipping symlink loop: %s\n"), childRel)
+			continue
+		}
+		if *oneFileSystem {
+			if dev, ok := deviceID(childPath, info); ok && dev != w.rootDev {
+				fmt.Printf(tr("Skipping mount point: %s\n"), childRel)
+				continue
+			}
+		}
+
+		w.walkSubdirectory(childRel, append(ancestors[:len(ancestors):len(ancestors)], info))
+	}
+	return nil
+}
+
+// walkSubdirectory reads rel on its own goroutine when a scan slot is
+// free and inline otherwise, so deep trees can't exhaust the slots.
+func (w *walker) walkSubdirectory(rel string, ancestors []os.FileInfo) {
+	walk := func() {
+		if err := w.walk(rel, ancestors); err != nil {
+			fmt.Printf(tr("Failed to read directory %s: %v\n"), rel, err)
+		}
+	}
+	select {
+	case w.slots <- struct{}{}:
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			defer func() { <-w.slots }()
+			walk()
+		}()
+	default:
+		walk()
+	}
+}
+
+func isAncestor(info os.FileInfo, ancestors []os.FileInfo) bool {
+	for _, a := range ancestors {
+		if os.SameFile(a, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// namingSource returns the name outputs for rel are derived from, which is
+// the link target's name for symlinked files with -symlink-names=target.
+func namingSource(currentDir, rel string) string {
+	if *symlinkNames != "target" {
+		return rel
+	}
+	path := filepath.Join(currentDir, rel)
+	info, err := os.Lstat(longPath(path))
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return rel
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return rel
+	}
+	return filepath.Join(filepath.Dir(rel), filepath.Base(target))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+func setupWalkDir(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs extra privileges on Windows")
+	}
+
+	root := t.TempDir()
+	for _, dir := range []string{"2023/trip", "jpegs", "elsewhere"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	for _, file := range []string{"top.heic", "2023/trip/a.heic", "jpegs/old.heic", "elsewhere/IMG_1.HEIC"} {
+		if err := os.WriteFile(filepath.Join(root, file), []byte("mock content"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	// A loop back to the root, a linked directory and a linked file.
+	links := map[string]string{
+		"2023/trip/loop": root,
+		"linked":         filepath.Join(root, "elsewhere"),
+		"best.heic":      filepath.Join(root, "elsewhere", "IMG_1.HEIC"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatalf("Failed to create symlink %s: %v", link, err)
+		}
+	}
+	return root
+}
+
+func walkedNames(t *testing.T, root string) []string {
+	t.Helper()
+	entries, err := walkDirectory(root)
+	if err != nil {
+		t.Fatalf("Failed to walk directory: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, filepath.ToSlash(entry.Name()))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Testing walkDirectory without following symlinked directories
+func TestWalkDirectory(t *testing.T) {
+	root := setupWalkDir(t)
+
+	got := walkedNames(t, root)
+	want := []string{"2023/trip/a.heic", "best.heic", "elsewhere/IMG_1.HEIC", "top.heic"}
+	if len(got) != len(want) {
+		t.Fatalf("walkDirectory = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("walkDirectory = %v, want %v", got, want)
+		}
+	}
+}
+
+// Testing walkDirectory following symlinks with a loop in the tree
+func TestWalkDirectoryFollowSymlinks(t *testing.T) {
+	root := setupWalkDir(t)
+	*followSymlinks = true
+	defer func() { *followSymlinks = false }()
+
+	got := walkedNames(t, root)
+	want := []string{"2023/trip/a.heic", "best.heic", "elsewhere/IMG_1.HEIC", "linked/IMG_1.HEIC", "top.heic"}
+	if len(got) != len(want) {
+		t.Fatalf("walkDirectory = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("walkDirectory = %v, want %v", got, want)
+		}
+	}
+}
+
+// Testing namingSource for symlinked files
+func TestNamingSource(t *testing.T) {
+	root := setupWalkDir(t)
+
+	if got := namingSource(root, "best.heic"); got != "best.heic" {
+		t.Errorf("Link naming should keep the link name, got %q", got)
+	}
+
+	*symlinkNames = "target"
+	defer func() { *symlinkNames = "link" }()
+	if got := namingSource(root, "best.heic"); got != "IMG_1.HEIC" {
+		t.Errorf("Target naming should use the target name, got %q", got)
+	}
+	if got := namingSource(root, "top.heic"); got != "top.heic" {
+		t.Errorf("Regular files should keep their name, got %q", got)
+	}
+}
+
+// Testing streamDirectory finds the same files as walkDirectory
+func TestStreamDirectory(t *testing.T) {
+	root := setupWalkDir(t)
+	want := walkedNames(t, root)
+
+	files, wait, err := streamDirectory(context.Background(), root, 1)
+	if err != nil {
+		t.Fatalf("Failed to stream directory: %v", err)
+	}
+	var got []string
+	for file := range files {
+		got = append(got, filepath.ToSlash(file.Name()))
+	}
+	if err := wait(); err != nil {
+		t.Fatalf("Stream reported an error: %v", err)
+	}
+	sort.Strings(got)
+	if len(got) != len(want) {
+		t.Fatalf("streamDirectory = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("streamDirectory = %v, want %v", got, want)
+		}
+	}
+
+	if _, _, err := streamDirectory(context.Background(), filepath.Join(root, "missing"), 1); err == nil {
+		t.Error("Expected an error for a missing root")
+	}
+}
+
+// Testing a cancelled stream closes its channel without being drained
+func TestStreamDirectoryCancel(t *testing.T) {
+	root := setupWalkDir(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	files, wait, err := streamDirectory(ctx, root, 0)
+	if err != nil {
+		t.Fatalf("Failed to stream directory: %v", err)
+	}
+	<-files
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range files {
+		}
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The stream did not stop after cancelling")
+	}
+}
+
+type doneSignal chan convert.ConversionResult
+
+func (d doneSignal) OnStart(int)                                {}
+func (d doneSignal) OnFileDone(result convert.ConversionResult) { d <- result }
+func (d doneSignal) OnFinish(convert.Summary)                   {}
+
+// Testing processStream converts files before the source is closed
+func TestProcessStreamStartsEarly(t *testing.T) {
+	currentDir := t.TempDir()
+	jpegDir := ensureJPEGDirectoryExists(currentDir)
+	if err := os.WriteFile(filepath.Join(currentDir, "first.heic"), []byte("mock content"), 0644); err != nil {
+		t.Fatalf("Failed to write first.heic: %v", err)
+	}
+	entries, err := os.ReadDir(currentDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+
+	source := make(chan os.DirEntry)
+	done := make(doneSignal, 1)
+	finished := make(chan map[string][]string)
+	go func() {
+		finished <- processStream(context.Background(), currentDir, jpegDir, source, convert.UnknownTotal, done)
+	}()
+
+	for _, entry := range entries {
+		if entry.Name() == "first.heic" {
+			source <- entry
+		}
+	}
+	select {
+	case result := <-done:
+		if filepath.Base(result.Input) != "first.heic" {
+			t.Errorf("Unexpected result for %s", result.Input)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("The first file wasn't processed while the source was open")
+	}
+	close(source)
+	<-finished
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package main
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// deviceID identifies the volume a directory lives on. Windows has no
+// st_dev, so resolve junctions and links and compare volume names instead.
+func deviceID(path string, info os.FileInfo) (uint64, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToUpper(filepath.VolumeName(resolved))))
+	return h.Sum64(), true
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+var (
+	watchFolder   = flag.Bool("watch", false, "keep running after converting and convert the HEIC files added to or changed in the folder, until stopped")
+	watchStrategy = flag.String("watch-mode", "auto", "how -watch notices changes: notify (the system's change notifications, Linux only), poll (scanning every -watch-interval, for network shares, where notifications miss changes made on other machines) or auto (poll on network mounts and where notify isn't supported)")
+	watchInterval = flag.Duration("watch-interval", 10*time.Second, "how often -watch scans the folder when polling")
+)
+
+var watchStrategies = map[string]bool{"auto": true, "notify": true, "poll": true}
+
+// errNotifyUnsupported is returned by newNotifier on systems whose change
+// notifications aren't used.
+var errNotifyUnsupported = errors.New("change notifications aren't supported on this system")
+
+// changeSource waits for HEIC files to be added or changed under the
+// watched folder and returns their paths relative to it, ready to convert.
+type changeSource interface {
+	next(ctx context.Context) ([]string, error)
+	close()
+}
+
+// chooseWatch picks the strategy for dir: mode, or for auto, polling on
+// network mounts, where notifications only see local changes, and where
+// they aren't supported.
+func chooseWatch(dir, mode string, recursive bool, interval time.Duration) (changeSource, string, error) {
+	if mode == "auto" {
+		mode = "notify"
+		if network, fsType := isNetworkMount(dir); network {
+			return newPoller(dir, recursive, interval), fmt.Sprintf(tr("polling every %v (%s is a %s mount)"), interval, dir, fsType), nil
+		}
+		n, err := newNotifier(dir, recursive)
+		if errors.Is(err, errNotifyUnsupported) {
+			return newPoller(dir, recursive, interval), fmt.Sprintf(tr("polling every %v"), interval), nil
+		}
+		return n, tr("change notifications"), err
+	}
+	if mode == "poll" {
+		return newPoller(dir, recursive, interval), fmt.Sprintf(tr("polling every %v"), interval), nil
+	}
+	n, err := newNotifier(dir, recursive)
+	return n, tr("change notifications"), err
+}
+
+// runWatch converts dir with run, then the HEIC files added to it or
+// changed, a batch at a time, until ctx is cancelled. Like scheduled
+// runs, each batch has its own journal and saves the history.
+func runWatch(ctx context.Context, dir string, run func(context.Context, ...convert.Observer) error, observers ...convert.Observer) error {
+	// Watching starts first, so files that arrive during the first
+	// conversion are picked up after it.
+	changes, how, err := chooseWatch(dir, *watchStrategy, *recursive, *watchInterval)
+	if err != nil {
+		return fmt.Errorf("-watch %s: %v", dir, err)
+	}
+	defer changes.close()
+	if err := run(ctx, observers...); err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		infof(tr("Watching %s for new photos, %s\n"), dir, how)
+		rels, err := changes.next(ctx)
+		if err != nil {
+			return err
+		}
+		if len(rels) == 0 {
+			continue
+		}
+		var files []os.DirEntry
+		for _, rel := range rels {
+			info, err := os.Stat(longPath(filepath.Join(dir, rel)))
+			if err != nil {
+				continue // gone again
+			}
+			files = append(files, scannedFile{DirEntry: fs.FileInfoToDirEntry(info), rel: rel})
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		runCtx := ctx
+		j, err := startJournal(dir)
+		if err != nil {
+			fmt.Printf(tr("Failed to start the journal, so this run can't be undone: %v\n"), err)
+		} else {
+			runCtx = withJournal(ctx, j)
+		}
+		if err := convertDirectory(runCtx, dir, files, observers...); err != nil {
+			fmt.Printf(tr("Failed to convert the new photos: %v\n"), err)
+		}
+		if j != nil {
+			j.close()
+		}
+		if h := historyFrom(ctx); h != nil {
+			if err := h.save(); err != nil {
+				fmt.Printf(tr("Failed to save the history: %v\n"), err)
+			}
+		}
+	}
+	return nil
+}
+
+// fileState is what polling compares between scans.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// poller finds changes by scanning the folder every interval. A new or
+// changed file is ready once a scan finds it as it was at the one before,
+// so files still being copied over the network aren't converted half
+// written.
+type poller struct {
+	dir       string
+	recursive bool
+	interval  time.Duration
+	last      map[string]fileState
+	pending   map[string]bool
+}
+
+func newPoller(dir string, recursive bool, interval time.Duration) *poller {
+	p := &poller{dir: dir, recursive: recursive, interval: interval, pending: map[string]bool{}}
+	p.last, _ = p.scan()
+	return p
+}
+
+func (p *poller) next(ctx context.Context) ([]string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(p.interval):
+		}
+		current, err := p.scan()
+		if err != nil {
+			// A share that went away may come back.
+			fmt.Printf(tr("Failed to scan %s: %v\n"), p.dir, err)
+			continue
+		}
+		var ready []string
+		for rel, state := range current {
+			switch previous, seen := p.last[rel]; {
+			case !seen || previous != state:
+				p.pending[rel] = true
+			case p.pending[rel]:
+				delete(p.pending, rel)
+				ready = append(ready, rel)
+			}
+		}
+		for rel := range p.pending {
+			if _, ok := current[rel]; !ok {
+				delete(p.pending, rel)
+			}
+		}
+		p.last = current
+		if len(ready) > 0 {
+			sort.Slice(ready, func(i, j int) bool { return pathLess(ready[i], ready[j]) })
+			return ready, nil
+		}
+	}
+}
+
+// scan returns the size and modification time of the HEIC files in the
+// folder.
+func (p *poller) scan() (map[string]fileState, error) {
+	var files []os.DirEntry
+	var err error
+	if p.recursive {
+		files, err = walkDirectory(p.dir)
+	} else {
+		files, err = os.ReadDir(longPath(p.dir))
+	}
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]fileState, len(files))
+	for _, file := range files {
+		if file.IsDir() || !isHEIC(file.Name()) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		states[file.Name()] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+	return states, nil
+}
+
+func (p *poller) close() {}
@@ -0,0 +1,203 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkFilesystems are the statfs types of mounts whose files can change
+// on other machines, which inotify doesn't hear about. FUSE covers sshfs
+// and rclone, and 9P the Windows drives of WSL 2.
+var networkFilesystems = map[int64]string{
+	0x6969:     "NFS",
+	0x517b:     "SMB",
+	0xff534d42: "CIFS",
+	0xfe534d42: "SMB2",
+	0x5346414f: "AFS",
+	0x00c36400: "Ceph",
+	0x65735546: "FUSE",
+	0x01021997: "9P",
+}
+
+func isNetworkMount(dir string) (bool, string) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return false, ""
+	}
+	name, ok := networkFilesystems[int64(st.Type)]
+	return ok, name
+}
+
+// notifyQuiet is how long the notifier waits after the last change
+// before handing over a batch, so a burst of photos is converted in one.
+const notifyQuiet = time.Second
+
+// inotifyMask reports files finished being written or moved in, and
+// folders created or moved in, which are watched too with -recursive.
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_CREATE
+
+// notifier hears about changes from inotify.
+type notifier struct {
+	root      string
+	recursive bool
+	fd        int
+	file      *os.File // fd, read through the runtime poller so close stops read
+	done      chan struct{}
+
+	mu      sync.Mutex
+	watches map[int32]string // watch descriptor to folder, relative to root
+	events  chan string
+	errs    chan error
+}
+
+func newNotifier(root string, recursive bool) (*notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	n := &notifier{
+		root:      root,
+		recursive: recursive,
+		fd:        fd,
+		file:      os.NewFile(uintptr(fd), "inotify"),
+		done:      make(chan struct{}),
+		watches:   map[int32]string{},
+		events:    make(chan string, 64),
+		errs:      make(chan error, 1),
+	}
+	if err := n.add(""); err != nil {
+		n.file.Close()
+		return nil, err
+	}
+	if recursive {
+		n.addTree("", false)
+	}
+	go n.read()
+	return n, nil
+}
+
+// add watches the folder rel.
+func (n *notifier) add(rel string) error {
+	wd, err := unix.InotifyAddWatch(n.fd, filepath.Join(n.root, rel), inotifyMask)
+	if err != nil {
+		if err == unix.ENOSPC {
+			return fmt.Errorf("%v: raise fs.inotify.max_user_watches or use -watch-mode poll", err)
+		}
+		return err
+	}
+	n.mu.Lock()
+	n.watches[int32(wd)] = rel
+	n.mu.Unlock()
+	return nil
+}
+
+// addTree watches the folders under rel. For a folder that just appeared,
+// found reports the HEIC files already in it, which may have been written
+// before its watch was added.
+func (n *notifier) addTree(rel string, found bool) {
+	entries, err := os.ReadDir(filepath.Join(n.root, rel))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		child := filepath.Join(rel, entry.Name())
+		switch {
+		case entry.IsDir() && !(rel == "" && entry.Name() == "jpegs"):
+			if err := n.add(child); err != nil {
+				fmt.Printf(tr("Failed to watch %s: %v\n"), child, err)
+				continue
+			}
+			n.addTree(child, found)
+		case found && isHEIC(entry.Name()):
+			n.emit(child)
+		}
+	}
+}
+
+func (n *notifier) read() {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			select {
+			case <-n.done:
+			default:
+				n.errs <- err
+			}
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= count; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := strings.TrimRight(string(buf[offset+unix.SizeofInotifyEvent:offset+unix.SizeofInotifyEvent+int(event.Len)]), "\x00")
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			n.mu.Lock()
+			dir, ok := n.watches[event.Wd]
+			if event.Mask&unix.IN_IGNORED != 0 {
+				delete(n.watches, event.Wd)
+			}
+			n.mu.Unlock()
+			switch {
+			case event.Mask&unix.IN_Q_OVERFLOW != 0:
+				fmt.Println(tr("Too many changes at once, some new photos may have been missed; they are converted on the next start"))
+			case !ok || name == "":
+			case event.Mask&unix.IN_ISDIR != 0:
+				rel := filepath.Join(dir, name)
+				if n.recursive && event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && !(dir == "" && name == "jpegs") {
+					// A folder moved in arrives with its photos.
+					if err := n.add(rel); err == nil {
+						n.addTree(rel, true)
+					}
+				}
+			case event.Mask&(unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO) != 0 && isHEIC(name):
+				n.emit(filepath.Join(dir, name))
+			}
+		}
+	}
+}
+
+func (n *notifier) emit(rel string) {
+	select {
+	case n.events <- rel:
+	case <-n.done:
+	}
+}
+
+func (n *notifier) next(ctx context.Context) ([]string, error) {
+	batch := map[string]bool{}
+	var quiet <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case err := <-n.errs:
+			return nil, err
+		case rel := <-n.events:
+			batch[rel] = true
+			quiet = time.After(notifyQuiet)
+		case <-quiet:
+			rels := make([]string, 0, len(batch))
+			for rel := range batch {
+				rels = append(rels, rel)
+			}
+			sort.Slice(rels, func(i, j int) bool { return pathLess(rels[i], rels[j]) })
+			return rels, nil
+		}
+	}
+}
+
+func (n *notifier) close() {
+	close(n.done)
+	n.file.Close()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifier(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "jpegs"), 0755)
+	n, err := newNotifier(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.close()
+
+	os.WriteFile(filepath.Join(dir, "IMG_0001.HEIC"), []byte("heic"), 0644)
+	os.WriteFile(filepath.Join(dir, "note.txt"), []byte("text"), 0644)
+	os.WriteFile(filepath.Join(dir, "jpegs", "IMG_0001.heic"), []byte("heic"), 0644)
+	// A folder moved in with a photo in it.
+	moved := filepath.Join(t.TempDir(), "Trip")
+	os.Mkdir(moved, 0755)
+	os.WriteFile(filepath.Join(moved, "IMG_0002.heic"), []byte("heic"), 0644)
+	if err := os.Rename(moved, filepath.Join(dir, "Trip")); err != nil {
+		t.Skip(err) // another filesystem
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rels, err := n.next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(rels, ","), "IMG_0001.HEIC,"+filepath.Join("Trip", "IMG_0002.heic"); got != want {
+		t.Errorf("next = %s, want %s", got, want)
+	}
+
+	// New folders are watched too.
+	os.WriteFile(filepath.Join(dir, "Trip", "IMG_0003.heic"), []byte("heic"), 0644)
+	if rels, err := n.next(ctx); err != nil || strings.Join(rels, ",") != filepath.Join("Trip", "IMG_0003.heic") {
+		t.Errorf("next = %q, %v", rels, err)
+	}
+}
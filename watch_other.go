@@ -0,0 +1,20 @@
+//go:build !linux
+
+package main
+
+import "context"
+
+// Only Linux's inotify is used, so -watch polls on other systems.
+type notifier struct{}
+
+func newNotifier(root string, recursive bool) (*notifier, error) {
+	return nil, errNotifyUnsupported
+}
+
+func (n *notifier) next(ctx context.Context) ([]string, error) { return nil, nil }
+
+func (n *notifier) close() {}
+
+func isNetworkMount(dir string) (bool, string) {
+	return false, ""
+}
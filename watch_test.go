@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"heictojpeg/convert"
+)
+
+func TestPoller(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "old.heic"), []byte("old"), 0644)
+	os.Mkdir(filepath.Join(dir, "Trip"), 0755)
+	p := newPoller(dir, true, 20*time.Millisecond)
+
+	// A file still being copied is held back until a scan finds it as it
+	// was at the previous one.
+	growing := filepath.Join(dir, "Trip", "IMG_0002.HEIC")
+	os.WriteFile(growing, []byte("par"), 0644)
+	stop := make(chan struct{})
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+			f, _ := os.OpenFile(growing, os.O_APPEND|os.O_WRONLY, 0644)
+			f.WriteString("t")
+			f.Close()
+			if i == 20 {
+				close(stop)
+				return
+			}
+		}
+	}()
+	os.WriteFile(filepath.Join(dir, "note.txt"), []byte("not a photo"), 0644)
+	started := time.Now()
+	rels, err := p.next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(rels, ",") != filepath.Join("Trip", "IMG_0002.HEIC") {
+		t.Errorf("next = %q", rels)
+	}
+	if time.Since(started) < 100*time.Millisecond {
+		t.Error("handed over a file that was still growing")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if rels, err := p.next(ctx); rels != nil || err != nil {
+		t.Errorf("next without changes = %q, %v", rels, err)
+	}
+}
+
+func TestChooseWatch(t *testing.T) {
+	dir := t.TempDir()
+	changes, how, err := chooseWatch(dir, "poll", false, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer changes.close()
+	if _, ok := changes.(*poller); !ok || !strings.Contains(how, "polling every 1m0s") {
+		t.Errorf("poll mode chose %T, %q", changes, how)
+	}
+	auto, _, err := chooseWatch(dir, "auto", false, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auto.close()
+	if network, _ := isNetworkMount(dir); network {
+		if _, ok := auto.(*poller); !ok {
+			t.Errorf("auto mode on a network mount chose %T", auto)
+		}
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	defer func(strategy string, interval time.Duration) {
+		*watchStrategy, *watchInterval = strategy, interval
+	}(*watchStrategy, *watchInterval)
+	*watchStrategy, *watchInterval = "poll", 20*time.Millisecond
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	first := make(chan struct{})
+	go func() {
+		done <- runWatch(ctx, dir, func(ctx context.Context, observers ...convert.Observer) error {
+			close(first)
+			return nil
+		})
+	}()
+	<-first
+	os.WriteFile(filepath.Join(dir, "IMG_0001.HEIC"), exifSample(), 0644)
+	output := filepath.Join(dir, "jpegs", "IMG_0001.jpg")
+	for deadline := time.Now().Add(5 * time.Second); !fileExists(output) && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(output) {
+		t.Error("the new photo wasn't converted")
+	}
+}
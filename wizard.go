@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	subcommands["setup"] = setupCommand
+}
+
+// firstRun reports whether heictojpeg was started without arguments from a
+// terminal, e.g. by double-clicking it, and has no config file yet. The
+// setup questions are asked then instead of converting right away.
+func firstRun() bool {
+	if len(os.Args) > 1 || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return false
+	}
+	path, err := configPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setupCommand asks for the usual settings and saves them to the config
+// file, keeping the options it doesn't ask about.
+func setupCommand(args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	config, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := askSettings(os.Stdin, os.Stdout, config); err != nil {
+		return err
+	}
+	if err := writeConfig(path, config); err != nil {
+		return err
+	}
+	fmt.Printf(tr("Saved the settings to %s; run heictojpeg setup to change them.\n"), path)
+	return nil
+}
+
+// askSettings asks for the folder to convert, the JPEG quality and whether
+// to delete the originals, and sets them in config. A folder is only set
+// when it isn't the current one. Pressing Enter keeps
+// the value shown in brackets.
+func askSettings(in io.Reader, out io.Writer, config map[string]interface{}) error {
+	r := bufio.NewReader(in)
+	ask := func(question, current string) (string, error) {
+		fmt.Fprintf(out, "%s [%s]: ", question, current)
+		answer, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || answer == "") {
+			return "", err
+		}
+		if answer = strings.TrimSpace(answer); answer == "" {
+			return current, nil
+		}
+		return answer, nil
+	}
+
+	fmt.Fprintln(out, tr("Welcome to heictojpeg! A few questions to get started; press Enter to keep the suggestion in brackets."))
+
+	cwd, _ := os.Getwd()
+	source, _ := config["source"].(string)
+	if source == "" {
+		source = cwd
+	}
+	for {
+		answer, err := ask(tr("Folder with the HEIC photos"), source)
+		if err != nil {
+			return err
+		}
+		answer, err = filepath.Abs(answer)
+		if err == nil {
+			var info os.FileInfo
+			if info, err = os.Stat(answer); err == nil && !info.IsDir() {
+				err = fmt.Errorf(tr("%s is not a folder"), answer)
+			}
+		}
+		if err == nil {
+			// The current folder isn't saved, so later runs convert the
+			// folder they're started in, as without a config file.
+			if resolvePath(answer) == resolvePath(cwd) {
+				delete(config, "source")
+			} else {
+				config["source"] = answer
+			}
+			break
+		}
+		fmt.Fprintln(out, err)
+	}
+
+	q := jpeg.DefaultQuality
+	if n, ok := wholeNumber(config["quality"]); ok {
+		q = n
+	}
+	for {
+		answer, err := ask(tr("JPEG quality from 1 to 100, higher is better and larger"), strconv.Itoa(q))
+		if err != nil {
+			return err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= 100 {
+			config["quality"] = n
+			break
+		}
+		fmt.Fprintf(out, tr("Invalid quality %q: must be between 1 and 100\n"), answer)
+	}
+
+	remove := "n"
+	if config["delete-originals"] == true {
+		remove = "y"
+	}
+	answer, err := ask(tr("Delete the HEIC files once they are converted? (y/n)"), remove)
+	if err != nil {
+		return err
+	}
+	config["delete-originals"] = consented(answer)
+	return nil
+}
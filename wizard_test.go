@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAskSettings(t *testing.T) {
+	dir := t.TempDir()
+	config := map[string]interface{}{"workers": 4}
+	in := strings.NewReader(filepath.Join(dir, "missing") + "\n" + dir + "\n150\n90\ny\n")
+	var out strings.Builder
+	if err := askSettings(in, &out, config); err != nil {
+		t.Fatal(err)
+	}
+	if config["source"] != dir || config["quality"] != 90 || config["delete-originals"] != true || config["workers"] != 4 {
+		t.Errorf("config = %v", config)
+	}
+	if !strings.Contains(out.String(), `Invalid quality "150"`) {
+		t.Errorf("no complaint about quality 150 in %q", out.String())
+	}
+}
+
+func TestAskSettingsKeepsCurrent(t *testing.T) {
+	dir := t.TempDir()
+	config := map[string]interface{}{"source": dir, "quality": 85.0, "delete-originals": true}
+	var out strings.Builder
+	if err := askSettings(strings.NewReader("\n\n\n"), &out, config); err != nil {
+		t.Fatal(err)
+	}
+	if config["source"] != dir || config["quality"] != 85 || config["delete-originals"] != true {
+		t.Errorf("config = %v", config)
+	}
+}
+
+func TestAskSettingsEOF(t *testing.T) {
+	if err := askSettings(strings.NewReader(""), &strings.Builder{}, map[string]interface{}{}); err == nil {
+		t.Error("no error at the end of the input")
+	}
+}
+
+func TestAskSettingsCurrentFolder(t *testing.T) {
+	config := map[string]interface{}{}
+	if err := askSettings(strings.NewReader("\n\n\n"), &strings.Builder{}, config); err != nil {
+		t.Fatal(err)
+	}
+	if source, ok := config["source"]; ok {
+		t.Errorf("saved the current folder as source %v", source)
+	}
+
+	config["source"] = t.TempDir()
+	cwd, _ := os.Getwd()
+	if err := askSettings(strings.NewReader(cwd+"\n\n\n"), &strings.Builder{}, config); err != nil {
+		t.Fatal(err)
+	}
+	if source, ok := config["source"]; ok {
+		t.Errorf("kept source %v after choosing the current folder", source)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	redisAddr = flag.String("redis", "localhost:6379", "Redis server that heictojpeg worker takes jobs from")
+	jobQueue  = flag.String("queue", "heictojpeg:jobs", "Redis list that heictojpeg worker takes jobs from; results go to <queue>:results")
+	workerID  = flag.String("worker-id", "", "name of this worker's list of jobs in progress, <queue>:processing:<id> (default the host name)")
+)
+
+// workerCommand is the verb in "heictojpeg worker [options]", which
+// converts jobs from a Redis queue instead of a folder.
+const workerCommand = "worker"
+
+const redisPasswordEnv = "HEICTOJPEG_REDIS_PASSWORD"
+
+// jobPoll is how long the worker waits for a job before checking that it
+// wasn't interrupted.
+const jobPoll = 5 * time.Second
+
+// job is a conversion request in the queue, as JSON. Options are the
+// per-file options of folder settings, e.g. {"quality": 90}.
+type job struct {
+	ID      string                 `json:"id,omitempty"`
+	Source  string                 `json:"source"`
+	Output  string                 `json:"output,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// jobResult is pushed to <queue>:results when a job is done.
+type jobResult struct {
+	ID          string  `json:"id,omitempty"`
+	Source      string  `json:"source"`
+	Output      string  `json:"output,omitempty"`
+	Status      string  `json:"status"`
+	Error       string  `json:"error,omitempty"`
+	Cached      bool    `json:"cached,omitempty"`
+	OutputBytes int64   `json:"output_bytes"`
+	DurationSec float64 `json:"duration_seconds"`
+}
+
+// runWorker converts jobs from the queue until ctx is cancelled. Each job
+// is moved to this worker's processing list while it is converted and
+// removed from it once its result is pushed, so the jobs of a worker that
+// dies are put back on the queue when it starts again with the same id.
+func runWorker(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	id := *workerID
+	if id == "" {
+		id, _ = os.Hostname()
+	}
+	processing := *jobQueue + ":processing:" + id
+	results := *jobQueue + ":results"
+	password := os.Getenv(redisPasswordEnv)
+
+	// The fetching connection spends its time blocked waiting for jobs,
+	// so results go back on a second one.
+	fetch, err := dialRedis(ctx, *redisAddr, password)
+	if err != nil {
+		return err
+	}
+	defer fetch.close()
+	ack, err := dialRedis(ctx, *redisAddr, password)
+	if err != nil {
+		return err
+	}
+	defer ack.close()
+
+	requeued := 0
+	for {
+		reply, err := fetch.do(0, "RPOPLPUSH", processing, *jobQueue)
+		if err != nil {
+			return err
+		}
+		if reply == nil {
+			break
+		}
+		requeued++
+	}
+	if requeued > 0 {
+		fmt.Printf(tr("Requeued %d unfinished jobs\n"), requeued)
+	}
+	infof(tr("Waiting for jobs on %s at %s\n"), *jobQueue, *redisAddr)
+
+	var mu sync.Mutex
+	var ackErr error
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for raw := range jobs {
+				result := runJob(ctx, raw)
+				if ctx.Err() != nil {
+					// Interrupted rather than failed: the job stays in the
+					// processing list, to be requeued on the next start.
+					continue
+				}
+				data, _ := json.Marshal(result)
+				mu.Lock()
+				_, err := ack.do(0, "LPUSH", results, string(data))
+				if err == nil {
+					_, err = ack.do(0, "LREM", processing, "1", raw)
+				}
+				if err != nil && ackErr == nil {
+					ackErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for ctx.Err() == nil {
+		reply, err := fetch.do(jobPoll, "BRPOPLPUSH", *jobQueue, processing, strconv.Itoa(int(jobPoll/time.Second)))
+		if err != nil {
+			if ctx.Err() == nil {
+				mu.Lock()
+				ackErr = err
+				mu.Unlock()
+			}
+			break
+		}
+		raw, ok := reply.(string)
+		if !ok {
+			continue
+		}
+		select {
+		case jobs <- raw:
+		case <-ctx.Done():
+			// The job stays in the processing list for the next start.
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return ackErr
+}
+
+// runJob converts one job from the queue.
+func runJob(ctx context.Context, raw string) jobResult {
+	start := time.Now()
+	var j job
+	if err := json.Unmarshal([]byte(raw), &j); err != nil {
+		fmt.Printf(tr("Invalid job %q: %v\n"), raw, err)
+		return jobResult{Status: "failed", Error: fmt.Sprintf("invalid job: %v", err)}
+	}
+
+	result := jobResult{ID: j.ID, Source: j.Source, Status: "failed"}
+	infof(tr("Processing file: %s\n"), j.Source)
+	output, cached, err := convertJob(ctx, j)
+	result.Output, result.Cached = output, cached
+	if err != nil {
+		fmt.Printf(tr("Failed to convert %s: %v\n"), j.Source, err)
+		result.Error = err.Error()
+	} else {
+		result.Status = "converted"
+		result.OutputBytes = getFileSize(output)
+	}
+	result.DurationSec = time.Since(start).Seconds()
+	return result
+}
+
+// convertJob converts the source of j and returns the path of the JPEG,
+// by default in the jpegs folder next to the source, and whether it came
+// from -cache.
+func convertJob(ctx context.Context, j job) (string, bool, error) {
+	if j.Source == "" {
+		return "", false, errors.New("the job has no source")
+	}
+	s := globalSettings()
+	if err := s.apply(j.Options); err != nil {
+		return "", false, err
+	}
+	ctx = withSettings(ctx, s)
+
+	input, output := j.Source, j.Output
+	if isURL(j.Source) {
+		if output == "" {
+			return "", false, errors.New("a job with a URL source needs an output")
+		}
+		f, err := os.CreateTemp("", "heictojpeg-*.heic")
+		if err != nil {
+			return "", false, err
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+		if err := downloadFile(ctx, j.Source, f.Name()); err != nil {
+			return "", false, err
+		}
+		input = f.Name()
+	}
+	if output == "" {
+		output = getJPEGFilePath(filepath.Join(filepath.Dir(input), "jpegs"), filepath.Base(input))
+	}
+	if err := os.MkdirAll(longPath(filepath.Dir(output)), 0755); err != nil {
+		return "", false, err
+	}
+	cached, err := convertCached(ctx, j.Source, input, output)
+	if err != nil {
+		return "", false, err
+	}
+	return output, cached, nil
+}
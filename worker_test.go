@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the list commands the worker uses from memory.
+type fakeRedis struct {
+	mu    sync.Mutex
+	lists map[string][]string
+	addr  string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	f := &fakeRedis{lists: make(map[string][]string), addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		cmd, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range cmd.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+		if args[0] == "BRPOPLPUSH" && f.length(args[1]) == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		fmt.Fprint(conn, f.run(args))
+	}
+}
+
+func (f *fakeRedis) run(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch args[0] {
+	case "LPUSH":
+		f.lists[args[1]] = append([]string{args[2]}, f.lists[args[1]]...)
+		return fmt.Sprintf(":%d\r\n", len(f.lists[args[1]]))
+	case "RPOPLPUSH", "BRPOPLPUSH":
+		src := f.lists[args[1]]
+		if len(src) == 0 {
+			return "$-1\r\n"
+		}
+		item := src[len(src)-1]
+		f.lists[args[1]] = src[:len(src)-1]
+		f.lists[args[2]] = append([]string{item}, f.lists[args[2]]...)
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(item), item)
+	case "LREM":
+		list := f.lists[args[1]]
+		for i, item := range list {
+			if item == args[3] {
+				f.lists[args[1]] = append(list[:i:i], list[i+1:]...)
+				return ":1\r\n"
+			}
+		}
+		return ":0\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func (f *fakeRedis) length(list string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.lists[list])
+}
+
+// Testing that the worker takes jobs, pushes their results and acks them
+func TestRunWorker(t *testing.T) {
+	redis := newFakeRedis(t)
+	defer func(addr, id string, n int) { *redisAddr, *workerID, *workers = addr, id, n }(*redisAddr, *workerID, *workers)
+	*redisAddr, *workerID, *workers = redis.addr, "test", 2
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "IMG_0001.heic")
+	if err := os.WriteFile(source, []byte("mock content"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	redis.lists[*jobQueue] = []string{
+		`not json`,
+		`{"id": "2", "source": "` + filepath.ToSlash(source) + `", "options": {"quality": 500}}`,
+	}
+	// Left over from an earlier run of this worker.
+	redis.lists[*jobQueue+":processing:test"] = []string{`{"id": "1", "source": "` + filepath.ToSlash(source) + `"}`}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runWorker(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for redis.length(*jobQueue+":results") < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("runWorker failed: %v", err)
+	}
+
+	if n := redis.length(*jobQueue + ":processing:test"); n != 0 {
+		t.Errorf("%d jobs left in the processing list", n)
+	}
+	errs := map[string]string{}
+	for _, raw := range redis.lists[*jobQueue+":results"] {
+		var r jobResult
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			t.Fatalf("Invalid result %q: %v", raw, err)
+		}
+		if r.Status != "failed" || r.Error == "" {
+			t.Errorf("Result %s should have failed: %+v", r.ID, r)
+		}
+		errs[r.ID] = r.Error
+	}
+	if len(errs) != 3 || !strings.Contains(errs[""], "invalid job") || !strings.Contains(errs["2"], "quality") {
+		t.Errorf("Unexpected results: %v", errs)
+	}
+}
+
+// Testing that a job interrupted mid-conversion is left to be requeued
+// rather than reported as failed
+func TestRunWorkerInterrupted(t *testing.T) {
+	redis := newFakeRedis(t)
+	defer func(addr, id string, n int) { *redisAddr, *workerID, *workers = addr, id, n }(*redisAddr, *workerID, *workers)
+	*redisAddr, *workerID, *workers = redis.addr, "test", 1
+
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	raw := `{"id": "1", "source": "` + srv.URL + `/IMG_0001.HEIC", "output": "` + filepath.ToSlash(filepath.Join(t.TempDir(), "IMG_0001.jpg")) + `"}`
+	redis.lists[*jobQueue] = []string{raw}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runWorker(ctx) }()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The job wasn't started")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("runWorker failed: %v", err)
+	}
+
+	if n := redis.length(*jobQueue + ":results"); n != 0 {
+		t.Errorf("%d results pushed for an interrupted job", n)
+	}
+	if processing := redis.lists[*jobQueue+":processing:test"]; len(processing) != 1 || processing[0] != raw {
+		t.Errorf("Processing list %q, want the interrupted job", processing)
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import "flag"
+
+var keepTags = flag.Bool("keep-tags", true, "copy Finder tags, color labels and comments (macOS) or xdg tags and ratings (Linux) from each HEIC to its JPEG")
+
+// copyTags copies the extended attributes that hold the user's own
+// organization, listed in keptAttributes, from input to output. Others,
+// like the download quarantine, are left behind.
+func copyTags(input, output string) error {
+	if !*keepTags || len(keptAttributes) == 0 {
+		return nil
+	}
+	return copyAttributes(input, output)
+}
@@ -0,0 +1,22 @@
+package main
+
+// keptAttributes are the Finder tags, color label, comment and rating,
+// and where the photo came from. A function picks what to keep of a value.
+var keptAttributes = map[string]func([]byte) []byte{
+	"com.apple.metadata:_kMDItemUserTags":     nil,
+	"com.apple.metadata:kMDItemFinderComment": nil,
+	"com.apple.metadata:kMDItemStarRating":    nil,
+	"com.apple.metadata:kMDItemWhereFroms":    nil,
+	"com.apple.FinderInfo":                    finderLabel,
+}
+
+// finderLabel keeps only the color label of a FinderInfo: the rest
+// describes the HEIC, like its type, creator and icon.
+func finderLabel(info []byte) []byte {
+	if len(info) != 32 || info[9]&0x0e == 0 {
+		return nil
+	}
+	label := make([]byte, 32)
+	label[9] = info[9] & 0x0e
+	return label
+}
@@ -0,0 +1,10 @@
+package main
+
+// keptAttributes are the freedesktop.org tags, comment and origin, and
+// the rating KDE's file manager sets.
+var keptAttributes = map[string]func([]byte) []byte{
+	"user.xdg.tags":       nil,
+	"user.xdg.comment":    nil,
+	"user.xdg.origin.url": nil,
+	"user.baloo.rating":   nil,
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopyTags(t *testing.T) {
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "a.heic"), filepath.Join(dir, "a.jpg")
+	for _, path := range []string{input, output} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := unix.Setxattr(input, "user.xdg.tags", []byte("holiday,family"), 0); errors.Is(err, unix.ENOTSUP) {
+		t.Skip("no extended attributes here")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if err := unix.Setxattr(input, "user.checksum", []byte("123"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyTags(input, output); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := getAttribute(output, "user.xdg.tags"); err != nil || string(got) != "holiday,family" {
+		t.Errorf("tags = %q, %v", got, err)
+	}
+	if _, err := getAttribute(output, "user.checksum"); err == nil {
+		t.Error("copied user.checksum")
+	}
+}
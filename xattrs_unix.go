@@ -0,0 +1,59 @@
+//go:build darwin || linux
+
+package main
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyAttributes copies the attributes of input in keptAttributes to
+// output. A file system without extended attributes has nothing to copy.
+func copyAttributes(input, output string) error {
+	size, err := unix.Listxattr(input, nil)
+	if err != nil || size == 0 {
+		return ignoreUnsupported(err)
+	}
+	list := make([]byte, size)
+	if size, err = unix.Listxattr(input, list); err != nil {
+		return ignoreUnsupported(err)
+	}
+	for _, name := range bytes.Split(list[:size], []byte{0}) {
+		keep, ok := keptAttributes[string(name)]
+		if !ok {
+			continue
+		}
+		value, err := getAttribute(input, string(name))
+		if err != nil {
+			return err
+		}
+		if keep != nil {
+			if value = keep(value); value == nil {
+				continue
+			}
+		}
+		if err := unix.Setxattr(output, string(name), value, 0); err != nil {
+			return ignoreUnsupported(err)
+		}
+	}
+	return nil
+}
+
+func getAttribute(path, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, size)
+	size, err = unix.Getxattr(path, name, value)
+	return value[:size], err
+}
+
+func ignoreUnsupported(err error) error {
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+	return err
+}